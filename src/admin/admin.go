@@ -0,0 +1,35 @@
+// Package admin provides operational endpoints for superusers, such as
+// changing the log level of the running process, switching maintenance mode
+// and profiling it.
+package admin
+
+import (
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// InitRoutes registers the admin endpoints. level is the atomic level the
+// application logger was built with. The pprof and diagnostics endpoints
+// are only mounted if enabled, see DebugEnabled.
+func InitRoutes(app core.App, logger *zap.Logger, level zap.AtomicLevel) {
+	levels := newLevelController(level, logger)
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		group := se.Router.Group("/api/admin")
+		group.Bind(apis.RequireSuperuserAuth())
+
+		group.GET("/loglevel", levels.getHandler)
+		group.PUT("/loglevel", levels.putHandler)
+
+		group.GET("/maintenance", getMaintenanceHandler)
+		group.PUT("/maintenance", putMaintenanceHandler(logger))
+
+		if DebugEnabled() {
+			bindDebugRoutes(group)
+			logger.Warn("Debug endpoints enabled under /api/admin/debug")
+		}
+
+		return se.Next()
+	})
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/sistemica/n8n-manager-backend/n8n"
+)
+
+// startedAt is used to report the process uptime
+var startedAt = time.Now()
+
+// DebugEnabled reports whether the pprof and diagnostics endpoints are mounted.
+// They are off unless ADMIN_DEBUG_ENDPOINTS=true.
+func DebugEnabled() bool {
+	return os.Getenv("ADMIN_DEBUG_ENDPOINTS") == "true"
+}
+
+// DebugVars is the response of GET /api/admin/debug/vars
+type DebugVars struct {
+	Uptime     string             `json:"uptime"`
+	GoVersion  string             `json:"go_version"`
+	Goroutines int                `json:"goroutines"`
+	Memory     MemoryStats        `json:"memory"`
+	Scheduler  n8n.SchedulerStats `json:"scheduler"`
+}
+
+// MemoryStats is a subset of runtime.MemStats relevant to heap growth
+type MemoryStats struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	HeapReleased uint64 `json:"heap_released"`
+	Sys          uint64 `json:"sys"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	NumGC        uint32 `json:"num_gc"`
+	LastGC       string `json:"last_gc,omitempty"`
+}
+
+// bindDebugRoutes mounts net/http/pprof and the runtime diagnostics endpoint
+func bindDebugRoutes(group *router.RouterGroup[*core.RequestEvent]) {
+	group.GET("/debug/vars", debugVarsHandler)
+
+	group.GET("/debug/pprof/cmdline", apis.WrapStdHandler(http.HandlerFunc(pprof.Cmdline)))
+	group.GET("/debug/pprof/profile", apis.WrapStdHandler(http.HandlerFunc(pprof.Profile)))
+	group.GET("/debug/pprof/symbol", apis.WrapStdHandler(http.HandlerFunc(pprof.Symbol)))
+	group.POST("/debug/pprof/symbol", apis.WrapStdHandler(http.HandlerFunc(pprof.Symbol)))
+	group.GET("/debug/pprof/trace", apis.WrapStdHandler(http.HandlerFunc(pprof.Trace)))
+	group.GET("/debug/pprof/{name...}", func(e *core.RequestEvent) error {
+		// pprof.Index derives the profile from a /debug/pprof/ prefix, which
+		// doesn't match the mount point, so named profiles are served directly
+		if name := e.Request.PathValue("name"); name != "" {
+			pprof.Handler(name).ServeHTTP(e.Response, e.Request)
+			return nil
+		}
+		pprof.Index(e.Response, e.Request)
+		return nil
+	})
+}
+
+func debugVarsHandler(e *core.RequestEvent) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	vars := DebugVars{
+		Uptime:     time.Since(startedAt).Round(time.Second).String(),
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: MemoryStats{
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			HeapObjects:  mem.HeapObjects,
+			HeapReleased: mem.HeapReleased,
+			Sys:          mem.Sys,
+			TotalAlloc:   mem.TotalAlloc,
+			NumGC:        mem.NumGC,
+		},
+		Scheduler: n8n.Scheduler(),
+	}
+	if mem.LastGC > 0 {
+		vars.Memory.LastGC = time.Unix(0, int64(mem.LastGC)).UTC().Format(time.RFC3339)
+	}
+
+	return e.JSON(http.StatusOK, vars)
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugEnabled(t *testing.T) {
+	t.Setenv("ADMIN_DEBUG_ENDPOINTS", "")
+	assert.False(t, DebugEnabled())
+	t.Setenv("ADMIN_DEBUG_ENDPOINTS", "1")
+	assert.False(t, DebugEnabled(), "only true enables them")
+	t.Setenv("ADMIN_DEBUG_ENDPOINTS", "true")
+	assert.True(t, DebugEnabled())
+}
+
+func TestDebugVarsHandler(t *testing.T) {
+	e := &core.RequestEvent{}
+	e.Request = httptest.NewRequest(http.MethodGet, "/api/admin/debug/vars", nil)
+	recorder := httptest.NewRecorder()
+	e.Response = recorder
+	require.NoError(t, debugVarsHandler(e))
+
+	var vars DebugVars
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &vars))
+	assert.NotEmpty(t, vars.GoVersion)
+	assert.Positive(t, vars.Goroutines)
+	assert.Positive(t, vars.Memory.HeapAlloc)
+	assert.NotEmpty(t, vars.Uptime)
+}
+
+func TestDebugRoutes(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	r, err := apis.NewRouter(app)
+	require.NoError(t, err)
+	bindDebugRoutes(r.Group("/api/admin"))
+	mux, err := r.BuildMux()
+	require.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	index := get("/api/admin/debug/pprof/")
+	assert.Equal(t, http.StatusOK, index.Code)
+	assert.Contains(t, index.Body.String(), "goroutine")
+
+	// Named profiles are served despite the mount point
+	goroutines := get("/api/admin/debug/pprof/goroutine?debug=1")
+	assert.Equal(t, http.StatusOK, goroutines.Code)
+	assert.Contains(t, goroutines.Body.String(), "goroutine profile")
+
+	assert.Equal(t, http.StatusOK, get("/api/admin/debug/pprof/cmdline").Code)
+	assert.Equal(t, http.StatusNotFound, get("/api/admin/debug/pprof/unknown").Code)
+}
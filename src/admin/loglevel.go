@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelRequest is the body of PUT /api/admin/loglevel
+type LevelRequest struct {
+	// Level is one of debug, info, warn, error
+	Level string `json:"level"`
+
+	// Duration optionally reverts to the previous level after the given
+	// time (e.g. "15m"), so debug logging isn't left on by accident
+	Duration string `json:"duration,omitempty"`
+}
+
+// LevelResponse describes the current log level
+type LevelResponse struct {
+	Level     string     `json:"level"`
+	RevertsTo string     `json:"reverts_to,omitempty"`
+	RevertsAt *time.Time `json:"reverts_at,omitempty"`
+}
+
+// levelController changes the atomic log level at runtime
+type levelController struct {
+	level  zap.AtomicLevel
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	revert    *time.Timer
+	revertsTo zapcore.Level
+	revertsAt time.Time
+}
+
+func newLevelController(level zap.AtomicLevel, logger *zap.Logger) *levelController {
+	return &levelController{level: level, logger: logger}
+}
+
+// set changes the level, optionally reverting to the current one after d.
+// A new call replaces a pending revert.
+func (c *levelController) set(level zapcore.Level, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.level.Level()
+	if c.revert != nil {
+		// Keep reverting to the level that was set before the temporary change
+		c.revert.Stop()
+		c.revert = nil
+		previous = c.revertsTo
+	}
+
+	c.level.SetLevel(level)
+
+	if d > 0 && level != previous {
+		c.revertsTo = previous
+		c.revertsAt = time.Now().Add(d)
+		c.revert = time.AfterFunc(d, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.level.SetLevel(c.revertsTo)
+			c.revert = nil
+			c.logger.Info("Log level reverted", zap.String("level", c.revertsTo.String()))
+		})
+	}
+}
+
+// status returns the current level and a pending revert, if any
+func (c *levelController) status() LevelResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := LevelResponse{Level: c.level.Level().String()}
+	if c.revert != nil {
+		revertsAt := c.revertsAt
+		status.RevertsTo = c.revertsTo.String()
+		status.RevertsAt = &revertsAt
+	}
+	return status
+}
+
+func (c *levelController) getHandler(e *core.RequestEvent) error {
+	return e.JSON(http.StatusOK, c.status())
+}
+
+func (c *levelController) putHandler(e *core.RequestEvent) error {
+	var body LevelRequest
+	if err := e.BindBody(&body); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+
+	level, err := zapcore.ParseLevel(body.Level)
+	if err != nil || level < zapcore.DebugLevel || level > zapcore.ErrorLevel {
+		return apis.NewBadRequestError("Level must be one of debug, info, warn, error", nil)
+	}
+
+	var d time.Duration
+	if body.Duration != "" {
+		d, err = time.ParseDuration(body.Duration)
+		if err != nil || d <= 0 {
+			return apis.NewBadRequestError("Invalid duration", nil)
+		}
+	}
+
+	c.set(level, d)
+
+	actor := audit.Actor(e.Auth)
+	c.logger.Info("Log level changed",
+		zap.String("level", level.String()),
+		zap.String("duration", body.Duration),
+		zap.String("actor", actor))
+
+	if err := audit.Log(e.App, audit.Entry{
+		Action:  "log_level.changed",
+		Actor:   actor,
+		Message: "Log level set to " + level.String(),
+		Success: true,
+		Details: map[string]any{"level": level.String(), "duration": body.Duration},
+	}); err != nil {
+		c.logger.Error("Failed to write audit log", zap.Error(err))
+	}
+
+	return e.JSON(http.StatusOK, c.status())
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelControllerSet(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	c := newLevelController(level, zap.NewNop())
+
+	c.set(zapcore.WarnLevel, 0)
+	assert.Equal(t, zapcore.WarnLevel, level.Level())
+	assert.Empty(t, c.status().RevertsTo)
+}
+
+func TestLevelControllerRevert(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	c := newLevelController(level, zap.NewNop())
+
+	c.set(zapcore.DebugLevel, 20*time.Millisecond)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+	assert.Equal(t, "info", c.status().RevertsTo)
+
+	assert.Eventually(t, func() bool {
+		return level.Level() == zapcore.InfoLevel
+	}, time.Second, 5*time.Millisecond)
+	assert.Nil(t, c.status().RevertsAt)
+}
+
+func TestLevelControllerReplacesPendingRevert(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	c := newLevelController(level, zap.NewNop())
+
+	c.set(zapcore.DebugLevel, time.Hour)
+	c.set(zapcore.WarnLevel, time.Hour)
+
+	// The revert still targets the level from before the first change
+	assert.Equal(t, zapcore.WarnLevel, level.Level())
+	assert.Equal(t, "info", c.status().RevertsTo)
+
+	c.set(zapcore.ErrorLevel, 0)
+	assert.Empty(t, c.status().RevertsTo)
+}
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"go.uber.org/zap"
+)
+
+// MaintenanceRequest is the body of PUT /api/admin/maintenance
+type MaintenanceRequest struct {
+	Enabled bool `json:"enabled"`
+
+	// Message is shown by the maintenance responder, a default is used if empty
+	Message string `json:"message,omitempty"`
+
+	// EndsAt is the planned end, sent to clients as Retry-After
+	EndsAt *time.Time `json:"ends_at,omitempty"`
+}
+
+func getMaintenanceHandler(e *core.RequestEvent) error {
+	state, err := maintenance.Current(e.App)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to read maintenance state", err)
+	}
+	return e.JSON(http.StatusOK, state)
+}
+
+func putMaintenanceHandler(logger *zap.Logger) func(e *core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		var body MaintenanceRequest
+		if err := e.BindBody(&body); err != nil {
+			return apis.NewBadRequestError("Invalid request body", err)
+		}
+
+		current, err := maintenance.Current(e.App)
+		if err != nil {
+			return apis.NewInternalServerError("Failed to read maintenance state", err)
+		}
+		if current.Forced && !body.Enabled {
+			return apis.NewApiError(http.StatusConflict, "Maintenance mode is forced by MAINTENANCE_MODE", nil)
+		}
+
+		actor := audit.Actor(e.Auth)
+		state := maintenance.State{
+			Enabled:   body.Enabled,
+			Message:   body.Message,
+			EndsAt:    body.EndsAt,
+			UpdatedBy: actor,
+		}
+		if err := maintenance.Set(e.App, state); err != nil {
+			return apis.NewInternalServerError("Failed to store maintenance state", err)
+		}
+
+		action, message := "maintenance.disabled", "Maintenance mode disabled"
+		if body.Enabled {
+			action, message = "maintenance.enabled", "Maintenance mode enabled"
+		}
+		logger.Warn(message, zap.String("actor", actor))
+
+		if err := audit.Log(e.App, audit.Entry{
+			Action:  action,
+			Actor:   actor,
+			Message: message,
+			Success: true,
+			Details: map[string]any{"message": body.Message, "ends_at": body.EndsAt},
+		}); err != nil {
+			logger.Error("Failed to write audit log", zap.Error(err))
+		}
+
+		return getMaintenanceHandler(e)
+	}
+}
@@ -0,0 +1,70 @@
+// Package audit records security relevant actions (credential rotations,
+// configuration changes) in the audit_logs collection.
+package audit
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Collection is the name of the audit log collection.
+const Collection = "audit_logs"
+
+// Entry describes a single audited action.
+type Entry struct {
+	// Action is a short machine readable identifier (e.g. "api_key.rotated")
+	Action string
+
+	// Instance is the optional id of the affected instance
+	Instance string
+
+	// Actor identifies who triggered the action (superuser email or "system")
+	Actor string
+
+	// Message is a human readable description
+	Message string
+
+	// Success reports whether the action completed
+	Success bool
+
+	// RunID is the optional sync run the action was part of
+	RunID string
+
+	// Details holds additional structured data, it must not contain secrets
+	Details map[string]any
+}
+
+// Log stores entry in the audit log.
+func Log(app core.App, entry Entry) error {
+	collection, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		return err
+	}
+
+	if entry.Actor == "" {
+		entry.Actor = "system"
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("action", entry.Action)
+	record.Set("instance", entry.Instance)
+	record.Set("actor", entry.Actor)
+	record.Set("message", entry.Message)
+	record.Set("success", entry.Success)
+	record.Set("run_id", entry.RunID)
+	if entry.Details != nil {
+		record.Set("details", entry.Details)
+	}
+
+	return app.Save(record)
+}
+
+// Actor returns the audit actor for an authenticated request, or "system".
+func Actor(auth *core.Record) string {
+	if auth == nil {
+		return "system"
+	}
+	if email := auth.Email(); email != "" {
+		return email
+	}
+	return auth.Collection().Name + ":" + auth.Id
+}
@@ -0,0 +1,113 @@
+// Package backup stores exported n8n data outside of the instances, so
+// it can be restored after a workflow was deleted.
+//
+// The target directory is configured with BACKUP_DIR and defaults to
+// "workflow_backups" inside the PocketBase data directory. Instances can
+// store their backups in another directory, on an SFTP server or a WebDAV
+// share instead, configured in the backup_targets collection. BACKUP_KEEP,
+// or the keep of a target, limits the backups kept per workflow. Backups can be
+// encrypted for OpenPGP recipients and their manifests signed, see Sealed.
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Target stores backup data
+type Target interface {
+	// Write stores data under key and returns the location to read it back
+	Write(ctx context.Context, key string, data []byte) (string, error)
+
+	// Read returns the data stored at location
+	Read(ctx context.Context, location string) ([]byte, error)
+}
+
+// Remover is implemented by targets able to delete backups, needed to
+// rotate them
+type Remover interface {
+	// Remove deletes the data stored at location
+	Remove(ctx context.Context, location string) error
+}
+
+// DefaultTarget returns the configured backup target, sealed if encryption
+// or signing is configured
+func DefaultTarget(ctx context.Context, app core.App) (Target, error) {
+	dir := os.Getenv("BACKUP_DIR")
+	if dir == "" {
+		dir = filepath.Join(app.DataDir(), "workflow_backups")
+	}
+	return sealedFromEnv(ctx, &Dir{Path: dir})
+}
+
+// Dir is a Target storing backups as files below a local directory.
+// Locations are paths relative to the directory.
+type Dir struct {
+	Path string
+}
+
+// Write stores data in the file key, creating parent directories as needed
+func (d *Dir) Write(ctx context.Context, key string, data []byte) (string, error) {
+	path, err := d.resolve(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("error creating backup directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("error writing backup: %w", err)
+	}
+
+	return cleanKey(key)
+}
+
+// Read returns the contents of the file at location
+func (d *Dir) Read(ctx context.Context, location string) ([]byte, error) {
+	path, err := d.resolve(location)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup: %w", err)
+	}
+	return data, nil
+}
+
+// Remove deletes the file at location
+func (d *Dir) Remove(ctx context.Context, location string) error {
+	path, err := d.resolve(location)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// resolve maps a key to a path, keys must stay inside the directory
+func (d *Dir) resolve(key string) (string, error) {
+	clean, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d.Path, filepath.FromSlash(clean)), nil
+}
+
+// cleanKey normalizes a slash separated key, which must be relative and
+// stay below the root of the target
+func cleanKey(key string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(key))
+	if clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errors.New("invalid backup location: " + key)
+	}
+	return clean, nil
+}
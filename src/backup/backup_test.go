@@ -0,0 +1,34 @@
+package backup
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir(t *testing.T) {
+	target := &Dir{Path: t.TempDir()}
+	ctx := context.Background()
+
+	location, err := target.Write(ctx, "instance/workflow.json", []byte(`{"name":"A"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "instance/workflow.json", location)
+
+	data, err := target.Read(ctx, location)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"A"}`, string(data))
+
+	require.NoError(t, target.Remove(ctx, location))
+	_, err = target.Read(ctx, location)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	for _, key := range []string{"../outside.json", "/etc/passwd", "a/../../b", ""} {
+		_, err := target.Write(ctx, key, nil)
+		assert.Error(t, err, key)
+		_, err = target.Read(ctx, key)
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,328 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+)
+
+// ManifestSuffix is appended to the location of a backup for its manifest
+const ManifestSuffix = ".manifest.json"
+
+// EncryptionGPG marks backups encrypted for OpenPGP recipients
+const EncryptionGPG = "gpg"
+
+// ErrUnsigned is returned when reading a backup without valid signature
+// while signatures are required
+var ErrUnsigned = errors.New("backup manifest is not signed")
+
+// ErrTampered is returned when a backup doesn't match its manifest
+var ErrTampered = errors.New("backup doesn't match its manifest")
+
+// Manifest describes a stored backup, written next to it
+type Manifest struct {
+	Location string    `json:"location"`
+	Created  time.Time `json:"created"`
+
+	// SHA256 and Size are of the stored, possibly encrypted, data
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+
+	// Encryption is empty for plaintext backups. Recipients are the key ids
+	// of the OpenPGP keys able to decrypt it.
+	Encryption string   `json:"encryption,omitempty"`
+	Recipients []string `json:"recipients,omitempty"`
+
+	// PublicKey and Signature are the Ed25519 public key and the signature
+	// of the manifest without signature, both base64 encoded
+	PublicKey string `json:"public_key,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// signedPayload returns what the signature of m covers
+func (m Manifest) signedPayload() ([]byte, error) {
+	m.Signature = ""
+	return json.Marshal(m)
+}
+
+// Sealed is a Target encrypting backups for OpenPGP recipients and writing
+// a manifest with their SHA-256, signed with an Ed25519 key, next to them.
+// Reading verifies the manifest and decrypts. Backups written without
+// manifest, before sealing was configured, are read as they are unless
+// RequireSignature is set.
+type Sealed struct {
+	Target Target
+
+	// Recipients are the keys backups are encrypted for, none stores them
+	// unencrypted
+	Recipients openpgp.EntityList
+
+	// Keyring holds the private keys to decrypt backups with
+	Keyring openpgp.EntityList
+
+	// SigningKey signs the manifests, nil writes them unsigned
+	SigningKey ed25519.PrivateKey
+
+	// RequireSignature rejects backups without manifest signed by SigningKey
+	RequireSignature bool
+}
+
+// Write encrypts data if there are recipients and stores it with its manifest
+func (s *Sealed) Write(ctx context.Context, key string, data []byte) (string, error) {
+	manifest := Manifest{Created: time.Now().UTC()}
+	if len(s.Recipients) > 0 {
+		var buf bytes.Buffer
+		writer, err := openpgp.Encrypt(&buf, s.Recipients, nil, &openpgp.FileHints{IsBinary: true}, nil)
+		if err != nil {
+			return "", fmt.Errorf("error encrypting backup: %w", err)
+		}
+		if _, err := writer.Write(data); err != nil {
+			return "", fmt.Errorf("error encrypting backup: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return "", fmt.Errorf("error encrypting backup: %w", err)
+		}
+		data = buf.Bytes()
+
+		manifest.Encryption = EncryptionGPG
+		for _, recipient := range s.Recipients {
+			manifest.Recipients = append(manifest.Recipients, recipient.PrimaryKey.KeyIdString())
+		}
+	}
+
+	location, err := s.Target.Write(ctx, key, data)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	manifest.Location = location
+	manifest.SHA256 = hex.EncodeToString(sum[:])
+	manifest.Size = len(data)
+	if s.SigningKey != nil {
+		manifest.PublicKey = base64.StdEncoding.EncodeToString(s.SigningKey.Public().(ed25519.PublicKey))
+		payload, err := manifest.signedPayload()
+		if err != nil {
+			return "", err
+		}
+		manifest.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.SigningKey, payload))
+	}
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.Target.Write(ctx, key+ManifestSuffix, encoded); err != nil {
+		return "", fmt.Errorf("error writing backup manifest: %w", err)
+	}
+	return location, nil
+}
+
+// Read returns the data stored at location after verifying it against its
+// manifest and decrypting it
+func (s *Sealed) Read(ctx context.Context, location string) ([]byte, error) {
+	data, err := s.Target.Read(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := s.Target.Read(ctx, location+ManifestSuffix)
+	if errors.Is(err, fs.ErrNotExist) {
+		if s.RequireSignature {
+			return nil, ErrUnsigned
+		}
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(encoded, &manifest); err != nil {
+		return nil, fmt.Errorf("error decoding backup manifest: %w", err)
+	}
+
+	if err := s.verify(manifest); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if manifest.SHA256 != hex.EncodeToString(sum[:]) || manifest.Size != len(data) {
+		return nil, ErrTampered
+	}
+
+	switch manifest.Encryption {
+	case "":
+		return data, nil
+	case EncryptionGPG:
+		if len(s.Keyring) == 0 {
+			return nil, errors.New("backup is encrypted, but no private key is configured")
+		}
+		message, err := openpgp.ReadMessage(bytes.NewReader(data), s.Keyring, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting backup: %w", err)
+		}
+		return io.ReadAll(message.UnverifiedBody)
+	default:
+		return nil, fmt.Errorf("unknown backup encryption %q", manifest.Encryption)
+	}
+}
+
+// Remove deletes the backup at location and its manifest, if the wrapped
+// target supports removing
+func (s *Sealed) Remove(ctx context.Context, location string) error {
+	remover, ok := s.Target.(Remover)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	if err := remover.Remove(ctx, location+ManifestSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return remover.Remove(ctx, location)
+}
+
+// verify checks the signature of manifest with the public key of the
+// signing key, the one in the manifest is informational
+func (s *Sealed) verify(manifest Manifest) error {
+	if manifest.Signature == "" || s.SigningKey == nil {
+		if s.RequireSignature {
+			return ErrUnsigned
+		}
+		return nil
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	if err != nil {
+		return ErrTampered
+	}
+	payload, err := manifest.signedPayload()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(s.SigningKey.Public().(ed25519.PublicKey), payload, signature) {
+		return ErrTampered
+	}
+	return nil
+}
+
+// sealedFromEnv wraps target in a Sealed target if any of its settings is
+// configured. Keys may be secret references, see secrets.Resolve:
+//
+//	BACKUP_GPG_RECIPIENTS     armored OpenPGP public keys backups are encrypted for
+//	BACKUP_GPG_PRIVATE_KEY    armored OpenPGP private key to decrypt backups with
+//	BACKUP_GPG_PASSPHRASE     passphrase of the private key, if it's protected
+//	BACKUP_SIGNING_KEY        base64 Ed25519 seed (32 bytes, e.g. "openssl rand -base64 32") or private key
+//	BACKUP_REQUIRE_SIGNATURE  "true" rejects backups without valid signature
+func sealedFromEnv(ctx context.Context, target Target) (Target, error) {
+	sealed := &Sealed{
+		Target:           target,
+		RequireSignature: os.Getenv("BACKUP_REQUIRE_SIGNATURE") == "true",
+	}
+
+	recipients, err := resolveSetting(ctx, "BACKUP_GPG_RECIPIENTS")
+	if err != nil {
+		return nil, err
+	}
+	if recipients != "" {
+		if sealed.Recipients, err = openpgp.ReadArmoredKeyRing(strings.NewReader(recipients)); err != nil {
+			return nil, fmt.Errorf("invalid BACKUP_GPG_RECIPIENTS: %w", err)
+		}
+	}
+
+	privateKey, err := resolveSetting(ctx, "BACKUP_GPG_PRIVATE_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if privateKey != "" {
+		passphrase, err := resolveSetting(ctx, "BACKUP_GPG_PASSPHRASE")
+		if err != nil {
+			return nil, err
+		}
+		if sealed.Keyring, err = readPrivateKeys(privateKey, passphrase); err != nil {
+			return nil, fmt.Errorf("invalid BACKUP_GPG_PRIVATE_KEY: %w", err)
+		}
+	}
+
+	signingKey, err := resolveSetting(ctx, "BACKUP_SIGNING_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if signingKey != "" {
+		if sealed.SigningKey, err = parseSigningKey(signingKey); err != nil {
+			return nil, fmt.Errorf("invalid BACKUP_SIGNING_KEY: %w", err)
+		}
+	}
+
+	if sealed.RequireSignature && sealed.SigningKey == nil {
+		return nil, errors.New("BACKUP_REQUIRE_SIGNATURE needs BACKUP_SIGNING_KEY")
+	}
+	if sealed.Recipients == nil && sealed.Keyring == nil && sealed.SigningKey == nil {
+		return target, nil
+	}
+	return sealed, nil
+}
+
+// resolveSetting returns the environment variable name, resolving secret
+// references
+func resolveSetting(ctx context.Context, name string) (string, error) {
+	value, err := secrets.Resolve(ctx, os.Getenv(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// readPrivateKeys reads armored private keys, decrypting them with
+// passphrase if they are protected
+func readPrivateKeys(armored, passphrase string) (openpgp.EntityList, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range keyring {
+		if entity.PrivateKey == nil {
+			return nil, errors.New("key " + entity.PrimaryKey.KeyIdString() + " has no private key")
+		}
+		if entity.PrivateKey.Encrypted {
+			if err := entity.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return nil, err
+			}
+		}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+				if err := subkey.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return keyring, nil
+}
+
+// parseSigningKey decodes a base64 Ed25519 seed or private key
+func parseSigningKey(encoded string) (ed25519.PrivateKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	default:
+		return nil, fmt.Errorf("key is %d bytes, expected %d or %d", len(key), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealed(t *testing.T) {
+	ctx := context.Background()
+	entity, err := openpgp.NewEntity("Backups", "", "backups@example.com", &packet.Config{DefaultHash: crypto.SHA256})
+	require.NoError(t, err)
+	_, signingKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	dir := &Dir{Path: t.TempDir()}
+	sealed := &Sealed{
+		Target:     dir,
+		Recipients: openpgp.EntityList{entity},
+		Keyring:    openpgp.EntityList{entity},
+		SigningKey: signingKey,
+	}
+
+	location, err := sealed.Write(ctx, "instance/workflow.json", []byte(`{"name":"A"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "instance/workflow.json", location)
+
+	stored, err := dir.Read(ctx, location)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stored), `"name"`)
+
+	data, err := sealed.Read(ctx, location)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"A"}`, string(data))
+
+	t.Run("tampered", func(t *testing.T) {
+		_, err := dir.Write(ctx, "instance/tampered.json", []byte(`{"name":"B"}`))
+		require.NoError(t, err)
+		manifest, err := dir.Read(ctx, location+ManifestSuffix)
+		require.NoError(t, err)
+		_, err = dir.Write(ctx, "instance/tampered.json"+ManifestSuffix, manifest)
+		require.NoError(t, err)
+
+		_, err = sealed.Read(ctx, "instance/tampered.json")
+		assert.ErrorIs(t, err, ErrTampered)
+	})
+
+	t.Run("other signing key", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		other := *sealed
+		other.SigningKey = otherKey
+		_, err = other.Read(ctx, location)
+		assert.ErrorIs(t, err, ErrTampered)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir.Path, "legacy.json"), []byte(`{}`), 0o600))
+		data, err := sealed.Read(ctx, "legacy.json")
+		require.NoError(t, err)
+		assert.Equal(t, `{}`, string(data))
+
+		strict := *sealed
+		strict.RequireSignature = true
+		_, err = strict.Read(ctx, "legacy.json")
+		assert.ErrorIs(t, err, ErrUnsigned)
+	})
+}
+
+func TestParseSigningKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	key, err := parseSigningKey(base64.StdEncoding.EncodeToString(seed))
+	require.NoError(t, err)
+	assert.Equal(t, ed25519.NewKeyFromSeed(seed), key)
+
+	same, err := parseSigningKey(base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	_, err = parseSigningKey("c2hvcnQ=")
+	assert.Error(t, err)
+}
+
+func TestSealedFromEnv(t *testing.T) {
+	ctx := context.Background()
+	dir := &Dir{Path: t.TempDir()}
+
+	target, err := sealedFromEnv(ctx, dir)
+	require.NoError(t, err)
+	assert.Same(t, dir, target)
+
+	t.Setenv("BACKUP_SIGNING_KEY", base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize)))
+	target, err = sealedFromEnv(ctx, dir)
+	require.NoError(t, err)
+	assert.IsType(t, &Sealed{}, target)
+
+	t.Setenv("BACKUP_SIGNING_KEY", "")
+	t.Setenv("BACKUP_REQUIRE_SIGNATURE", "true")
+	_, err = sealedFromEnv(ctx, dir)
+	assert.Error(t, err)
+}
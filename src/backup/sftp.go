@@ -0,0 +1,194 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTP is a Target storing backups below a directory of an SFTP server.
+// Every operation opens its own connection, backups are written rarely.
+type SFTP struct {
+	// Addr is the host:port of the server
+	Addr string
+
+	// Path is the directory backups are stored in, relative to the login
+	// directory unless absolute
+	Path string
+
+	// Config holds the user, authentication and host key check
+	Config *ssh.ClientConfig
+}
+
+// Write stores data in the file key, creating parent directories as needed.
+// The data is uploaded to a temporary file renamed to key once complete, so
+// an interrupted upload never leaves a truncated backup behind.
+func (s *SFTP) Write(ctx context.Context, key string, data []byte) (string, error) {
+	location, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	err = s.session(ctx, func(client *sftp.Client) error {
+		file := s.resolve(location)
+		if err := client.MkdirAll(path.Dir(file)); err != nil {
+			return fmt.Errorf("error creating backup directory: %w", err)
+		}
+		partial := path.Join(path.Dir(file), "."+path.Base(file)+".partial")
+		if err := writeFile(client, partial, data); err != nil {
+			client.Remove(partial)
+			return fmt.Errorf("error writing backup: %w", err)
+		}
+		if err := replace(client, partial, file); err != nil {
+			client.Remove(partial)
+			return fmt.Errorf("error renaming backup: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return location, nil
+}
+
+// Read returns the contents of the file at location
+func (s *SFTP) Read(ctx context.Context, location string) ([]byte, error) {
+	clean, err := cleanKey(location)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	err = s.session(ctx, func(client *sftp.Client) error {
+		file, err := client.Open(s.resolve(clean))
+		if err != nil {
+			return fmt.Errorf("error reading backup: %w", err)
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			return fmt.Errorf("error reading backup: %w", err)
+		}
+		return nil
+	})
+	return data, err
+}
+
+// Remove deletes the file at location
+func (s *SFTP) Remove(ctx context.Context, location string) error {
+	clean, err := cleanKey(location)
+	if err != nil {
+		return err
+	}
+	return s.session(ctx, func(client *sftp.Client) error {
+		return client.Remove(s.resolve(clean))
+	})
+}
+
+// resolve maps a cleaned key to a path on the server
+func (s *SFTP) resolve(key string) string {
+	if s.Path == "" {
+		return key
+	}
+	return path.Join(s.Path, key)
+}
+
+// session connects to the server, starts the sftp subsystem and runs f
+func (s *SFTP) session(ctx context.Context, f func(*sftp.Client) error) error {
+	dialer := net.Dialer{Timeout: 30 * time.Second}
+	netConn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("error connecting to SFTP server: %w", err)
+	}
+	// Closing the connection aborts whatever is in progress
+	stop := context.AfterFunc(ctx, func() { netConn.Close() })
+	defer stop()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, s.Addr, s.Config)
+	if err != nil {
+		netConn.Close()
+		return fmt.Errorf("error connecting to SFTP server: %w", err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	defer sshClient.Close()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return fmt.Errorf("error starting sftp subsystem: %w", err)
+	}
+	defer client.Close()
+
+	if err := f(client); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// writeFile creates or truncates the file name and writes data to it
+func writeFile(client *sftp.Client, name string, data []byte) error {
+	file, err := client.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// replace renames from to to, replacing to. Servers without the OpenSSH
+// posix-rename extension refuse to rename onto an existing file, which is
+// removed first there.
+func replace(client *sftp.Client, from, to string) error {
+	if _, ok := client.HasExtension("posix-rename@openssh.com"); ok {
+		return client.PosixRename(from, to)
+	}
+	if err := client.Remove(to); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return client.Rename(from, to)
+}
+
+// sftpConfig builds the client config of an SFTP target. The host key is
+// required, in authorized_keys format; the password, the private key or both
+// authenticate the user.
+func sftpConfig(user, password, privateKey, hostKey string) (*ssh.ClientConfig, error) {
+	if strings.TrimSpace(hostKey) == "" {
+		return nil, errors.New("the host key of the server is required")
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host key: %w", err)
+	}
+
+	config := &ssh.ClientConfig{
+		User:            user,
+		HostKeyCallback: ssh.FixedHostKey(key),
+		Timeout:         30 * time.Second,
+	}
+	if privateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		config.Auth = append(config.Auth, ssh.PublicKeys(signer))
+	}
+	if password != "" {
+		config.Auth = append(config.Auth, ssh.Password(password))
+	}
+	if len(config.Auth) == 0 {
+		return nil, errors.New("a password or private key is required")
+	}
+	return config, nil
+}
@@ -0,0 +1,172 @@
+package backup
+
+import (
+	"context"
+	"crypto/ed25519"
+	"io/fs"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSFTPServer runs an SSH server accepting the password "secret" whose
+// sftp subsystem serves files from memory
+func startSFTPServer(t *testing.T) (string, ssh.PublicKey) {
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == "backup" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, fs.ErrPermission
+		},
+	}
+	config.AddHostKey(signer)
+
+	handlers := sftp.InMemHandler()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(netConn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, requests, err := newChannel.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for req := range requests {
+							ok := req.Type == "subsystem" && strings.HasSuffix(string(req.Payload), "sftp")
+							req.Reply(ok, nil)
+							if ok {
+								go func() {
+									server := sftp.NewRequestServer(channel, handlers)
+									server.Serve()
+									server.Close()
+								}()
+							}
+						}
+					}()
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), signer.PublicKey()
+}
+
+// listSFTP returns the names of the files in dir
+func listSFTP(t *testing.T, target *SFTP, dir string) []string {
+	var names []string
+	err := target.session(context.Background(), func(client *sftp.Client) error {
+		infos, err := client.ReadDir(dir)
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return names
+}
+
+func TestSFTP(t *testing.T) {
+	ctx := context.Background()
+	addr, hostKey := startSFTPServer(t)
+
+	config, err := sftpConfig("backup", "secret", "", string(ssh.MarshalAuthorizedKey(hostKey)))
+	require.NoError(t, err)
+	target := &SFTP{Addr: addr, Path: "backups", Config: config}
+
+	// Larger than a packet, so reads and writes are split
+	data := []byte(`{"name":"A","nodes":[]}` + strings.Repeat(" ", 100*1024))
+	location, err := target.Write(ctx, "instance/workflow.json", data)
+	require.NoError(t, err)
+	assert.Equal(t, "instance/workflow.json", location)
+
+	read, err := target.Read(ctx, location)
+	require.NoError(t, err)
+	assert.Equal(t, data, read)
+
+	// Writing again replaces the file through a temporary one
+	_, err = target.Write(ctx, "instance/workflow.json", []byte(`{"name":"B"}`))
+	require.NoError(t, err)
+	read, err = target.Read(ctx, location)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"B"}`, string(read))
+	assert.Equal(t, []string{"workflow.json"}, listSFTP(t, target, "backups/instance"))
+
+	require.NoError(t, target.Remove(ctx, location))
+	_, err = target.Read(ctx, location)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = target.Write(ctx, "../outside.json", nil)
+	assert.Error(t, err)
+
+	t.Run("wrong password", func(t *testing.T) {
+		config, err := sftpConfig("backup", "wrong", "", string(ssh.MarshalAuthorizedKey(hostKey)))
+		require.NoError(t, err)
+		_, err = (&SFTP{Addr: addr, Config: config}).Read(ctx, "instance/workflow.json")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown host key", func(t *testing.T) {
+		other, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		otherKey, err := ssh.NewPublicKey(other)
+		require.NoError(t, err)
+		config, err := sftpConfig("backup", "secret", "", string(ssh.MarshalAuthorizedKey(otherKey)))
+		require.NoError(t, err)
+		_, err = (&SFTP{Addr: addr, Config: config}).Read(ctx, "instance/workflow.json")
+		assert.Error(t, err)
+	})
+}
+
+func TestSFTPConfig(t *testing.T) {
+	_, err := sftpConfig("backup", "secret", "", "")
+	assert.Error(t, err, "host key is required")
+	_, err = sftpConfig("backup", "secret", "", "not a key")
+	assert.Error(t, err)
+
+	key, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewPublicKey(key)
+	require.NoError(t, err)
+	_, err = sftpConfig("backup", "", "", string(ssh.MarshalAuthorizedKey(hostKey)))
+	assert.Error(t, err, "password or private key is required")
+}
+
+func TestParseSFTPURL(t *testing.T) {
+	addr, dir, err := parseSFTPURL("sftp://backup.example.com/n8n")
+	require.NoError(t, err)
+	assert.Equal(t, "backup.example.com:22", addr)
+	assert.Equal(t, "n8n", dir)
+
+	addr, dir, err = parseSFTPURL("sftp://backup.example.com:2222//srv/n8n")
+	require.NoError(t, err)
+	assert.Equal(t, "backup.example.com:2222", addr)
+	assert.Equal(t, "/srv/n8n", dir)
+
+	for _, raw := range []string{"https://backup.example.com/n8n", "sftp:///n8n", "backup.example.com"} {
+		_, _, err := parseSFTPURL(raw)
+		assert.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+	"golang.org/x/crypto/ssh"
+)
+
+// TargetsCollection configures the targets instances store their backups in
+// instead of BACKUP_DIR
+const TargetsCollection = "backup_targets"
+
+// Types of backup_targets records
+const (
+	TypeDir    = "dir"
+	TypeSFTP   = "sftp"
+	TypeWebDAV = "webdav"
+)
+
+// Destination is a target with the rotation of the backups written to it
+type Destination struct {
+	Target
+
+	// ID is the id of the backup_targets record, empty for BACKUP_DIR
+	ID string
+
+	// Keep is how many backups are kept per workflow, 0 keeps all
+	Keep int
+}
+
+// defaultDestination is BACKUP_DIR, rotated if BACKUP_KEEP is set
+func defaultDestination(ctx context.Context, app core.App) (*Destination, error) {
+	target, err := DefaultTarget(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	keep, _ := strconv.Atoi(os.Getenv("BACKUP_KEEP"))
+	return &Destination{Target: target, Keep: max(keep, 0)}, nil
+}
+
+// ForID returns the destination configured in the backup_targets record id,
+// BACKUP_DIR for an empty id
+func ForID(ctx context.Context, app core.App, id string) (*Destination, error) {
+	if id == "" {
+		return defaultDestination(ctx, app)
+	}
+	record, err := app.FindRecordById(TargetsCollection, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find backup target: %w", err)
+	}
+	target, err := targetFromRecord(ctx, record)
+	if err != nil {
+		return nil, fmt.Errorf("backup target %s is misconfigured: %w", record.GetString("name"), err)
+	}
+	if target, err = sealedFromEnv(ctx, target); err != nil {
+		return nil, err
+	}
+	return &Destination{Target: target, ID: record.Id, Keep: record.GetInt("keep")}, nil
+}
+
+// ForInstance returns the destination the backups of an instance are
+// written to
+func ForInstance(ctx context.Context, app core.App, instanceID string) (*Destination, error) {
+	instance, err := app.FindRecordById("instances", instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance: %w", err)
+	}
+	return ForID(ctx, app, instance.GetString("backup_target"))
+}
+
+// ForRecord returns the destination a backups record was written to
+func ForRecord(ctx context.Context, app core.App, record *core.Record) (*Destination, error) {
+	return ForID(ctx, app, record.GetString("target"))
+}
+
+// ForLocation returns the destination of a backup of an instance, found by
+// its backups record. Backups written before they were recorded are in
+// BACKUP_DIR.
+func ForLocation(ctx context.Context, app core.App, instanceID, location string) (*Destination, error) {
+	records, err := app.FindRecordsByFilter(Collection,
+		"instance = {:instance} && location = {:location}", "-created", 1, 0,
+		dbx.Params{"instance": instanceID, "location": location})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return defaultDestination(ctx, app)
+	}
+	return ForRecord(ctx, app, records[0])
+}
+
+// targetFromRecord builds the target of a backup_targets record, resolving
+// secret references in its password and private key
+func targetFromRecord(ctx context.Context, record *core.Record) (Target, error) {
+	password, err := secrets.Resolve(ctx, record.GetString("password"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve password: %w", err)
+	}
+
+	switch record.GetString("type") {
+	case TypeDir:
+		return &Dir{Path: record.GetString("path")}, nil
+	case TypeSFTP:
+		addr, dir, err := parseSFTPURL(record.GetString("url"))
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := secrets.Resolve(ctx, record.GetString("private_key"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve private key: %w", err)
+		}
+		config, err := sftpConfig(record.GetString("username"), password, privateKey, record.GetString("host_key"))
+		if err != nil {
+			return nil, err
+		}
+		return &SFTP{Addr: addr, Path: dir, Config: config}, nil
+	case TypeWebDAV:
+		return &WebDAV{
+			URL:      record.GetString("url"),
+			Username: record.GetString("username"),
+			Password: password,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown backup target type %q", record.GetString("type"))
+	}
+}
+
+// parseSFTPURL splits sftp://host[:port]/dir into the address and the
+// directory, relative to the login directory. sftp://host//srv/backups
+// points to an absolute directory.
+func parseSFTPURL(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "sftp" || u.Hostname() == "" {
+		return "", "", errors.New("the URL must look like sftp://host[:port]/directory")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(u.Hostname(), port), strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// validateTarget checks that a backup_targets record has the settings its
+// type needs. Secret references can't be checked before they are used.
+func validateTarget(record *core.Record) error {
+	errs := validation.Errors{}
+	switch record.GetString("type") {
+	case TypeDir:
+		if !filepath.IsAbs(record.GetString("path")) {
+			errs["path"] = validation.NewError("invalid_path", "An absolute directory is required")
+		}
+	case TypeSFTP:
+		if _, _, err := parseSFTPURL(record.GetString("url")); err != nil {
+			errs["url"] = validation.NewError("invalid_url", err.Error())
+		}
+		if record.GetString("username") == "" {
+			errs["username"] = validation.NewError("validation_required", "The username is required")
+		}
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(record.GetString("host_key"))); err != nil {
+			errs["host_key"] = validation.NewError("invalid_host_key",
+				"The public key of the server is required in authorized_keys format, e.g. from ssh-keyscan")
+		}
+		if record.GetString("password") == "" && record.GetString("private_key") == "" {
+			errs["password"] = validation.NewError("validation_required", "A password or private key is required")
+		}
+	case TypeWebDAV:
+		u, err := url.Parse(record.GetString("url"))
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs["url"] = validation.NewError("invalid_url", "The http(s) URL of a WebDAV collection is required")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Prune deletes the backups of a workflow written to dest beyond the newest
+// dest.Keep, with their records. It returns how many were deleted.
+func Prune(ctx context.Context, app core.App, dest *Destination, instanceID, workflowID string) (int, error) {
+	if dest.Keep <= 0 {
+		return 0, nil
+	}
+	remover, ok := dest.Target.(Remover)
+	if !ok {
+		return 0, errors.New("backup target doesn't support deleting backups")
+	}
+
+	records, err := app.FindRecordsByFilter(Collection,
+		"instance = {:instance} && workflow_id = {:workflow} && target = {:target}", "-created,-location", 0, 0,
+		dbx.Params{"instance": instanceID, "workflow": workflowID, "target": dest.ID})
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, record := range records[min(dest.Keep, len(records)):] {
+		if err := remover.Remove(ctx, record.GetString("location")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return pruned, err
+		}
+		if err := app.Delete(record); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// InitTargets validates backup_targets records when they are saved
+func InitTargets(app core.App) {
+	app.OnRecordValidate(TargetsCollection).BindFunc(func(e *core.RecordEvent) error {
+		if err := validateTarget(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+}
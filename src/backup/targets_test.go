@@ -0,0 +1,75 @@
+package backup
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTargetRecord(values map[string]any) *core.Record {
+	targets := core.NewBaseCollection(TargetsCollection)
+	for _, name := range []string{"name", "type", "path", "url", "username", "password", "private_key", "host_key"} {
+		targets.Fields.Add(&core.TextField{Name: name})
+	}
+	targets.Fields.Add(&core.NumberField{Name: "keep"})
+	record := core.NewRecord(targets)
+	for key, value := range values {
+		record.Set(key, value)
+	}
+	return record
+}
+
+func TestValidateTarget(t *testing.T) {
+	key, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	publicKey, err := ssh.NewPublicKey(key)
+	require.NoError(t, err)
+	hostKey := string(ssh.MarshalAuthorizedKey(publicKey))
+
+	valid := []map[string]any{
+		{"type": TypeDir, "path": "/var/backups/n8n"},
+		{"type": TypeSFTP, "url": "sftp://backup.example.com/n8n", "username": "n8n", "password": "vault:kv/backup#password", "host_key": hostKey},
+		{"type": TypeSFTP, "url": "sftp://backup.example.com:2222/n8n", "username": "n8n", "private_key": "env:BACKUP_SSH_KEY", "host_key": hostKey},
+		{"type": TypeWebDAV, "url": "https://cloud.example.com/remote.php/dav/files/n8n/backups"},
+	}
+	for _, values := range valid {
+		assert.NoError(t, validateTarget(newTargetRecord(values)), values)
+	}
+
+	invalid := []map[string]any{
+		{"type": TypeDir, "path": "backups"},
+		{"type": TypeSFTP, "url": "sftp://backup.example.com/n8n", "username": "n8n", "password": "secret"},
+		{"type": TypeSFTP, "url": "sftp://backup.example.com/n8n", "username": "n8n", "host_key": hostKey},
+		{"type": TypeSFTP, "url": "backup.example.com", "username": "n8n", "password": "secret", "host_key": hostKey},
+		{"type": TypeWebDAV, "url": "ftp://cloud.example.com/backups"},
+	}
+	for _, values := range invalid {
+		assert.Error(t, validateTarget(newTargetRecord(values)), values)
+	}
+}
+
+func TestTargetFromRecord(t *testing.T) {
+	ctx := context.Background()
+
+	target, err := targetFromRecord(ctx, newTargetRecord(map[string]any{"type": TypeDir, "path": "/var/backups/n8n"}))
+	require.NoError(t, err)
+	assert.Equal(t, &Dir{Path: "/var/backups/n8n"}, target)
+
+	t.Setenv("WEBDAV_TEST_PASSWORD", "app-password")
+	target, err = targetFromRecord(ctx, newTargetRecord(map[string]any{
+		"type":     TypeWebDAV,
+		"url":      "https://cloud.example.com/remote.php/dav/files/n8n/backups",
+		"username": "n8n",
+		"password": "env:WEBDAV_TEST_PASSWORD",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "app-password", target.(*WebDAV).Password)
+
+	_, err = targetFromRecord(ctx, newTargetRecord(map[string]any{"type": TypeSFTP, "url": "sftp://backup.example.com/n8n"}))
+	assert.Error(t, err, "host key is required")
+}
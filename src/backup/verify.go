@@ -0,0 +1,258 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"go.uber.org/zap"
+)
+
+// Collection stores a record per backup with its verification status
+const Collection = "backups"
+
+// VerifyJob is the id of the cron job verifying backups
+const VerifyJob = "verify-backups"
+
+// Verification states of backups records
+const (
+	StatusPending  = "pending"
+	StatusVerified = "verified"
+	StatusFailed   = "failed"
+)
+
+// defaultReverifyDays is how often verified backups are read back again
+// unless BACKUP_REVERIFY_DAYS is set
+const defaultReverifyDays = 7
+
+// Info describes a written backup
+type Info struct {
+	Instance     string
+	WorkflowID   string
+	WorkflowName string
+	Location     string
+	Size         int
+
+	// Target is the id of the backup_targets record, empty for BACKUP_DIR
+	Target string
+}
+
+// Record stores a backups record for a written backup, pending verification
+func Record(app core.App, info Info) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		return nil, err
+	}
+	record := core.NewRecord(collection)
+	record.Set("instance", info.Instance)
+	record.Set("workflow_id", info.WorkflowID)
+	record.Set("workflow_name", info.WorkflowName)
+	record.Set("location", info.Location)
+	record.Set("size", info.Size)
+	record.Set("target", info.Target)
+	record.Set("verification", StatusPending)
+	return record, app.Save(record)
+}
+
+// Verify reads the backup at location back from target, which checks its
+// manifest if target is Sealed, and parses the workflow JSON it contains,
+// a single workflow or a list of them. It returns the number of workflows.
+func Verify(ctx context.Context, target Target, location string) (int, error) {
+	data, err := target.Read(ctx, location)
+	if err != nil {
+		return 0, err
+	}
+	return parseWorkflows(data)
+}
+
+// parseWorkflows checks that data is a workflow or a list of workflows
+func parseWorkflows(data []byte) (int, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return 0, fmt.Errorf("backup is not valid JSON: %w", err)
+	}
+
+	workflows := []json.RawMessage{raw}
+	if len(raw) > 0 && raw[0] == '[' {
+		if err := json.Unmarshal(raw, &workflows); err != nil {
+			return 0, fmt.Errorf("backup is not a list of workflows: %w", err)
+		}
+	}
+	for i, item := range workflows {
+		var workflow struct {
+			Name  string            `json:"name"`
+			Nodes []json.RawMessage `json:"nodes"`
+		}
+		if err := json.Unmarshal(item, &workflow); err != nil {
+			return 0, fmt.Errorf("workflow %d is invalid: %w", i, err)
+		}
+		if workflow.Name == "" || workflow.Nodes == nil {
+			return 0, fmt.Errorf("workflow %d has no name or nodes", i)
+		}
+	}
+	return len(workflows), nil
+}
+
+// VerifyRecord verifies the backup of a backups record and stores the
+// result in it, alerting when a backup fails that didn't before. The error
+// is about storing the result, not the verification.
+func VerifyRecord(ctx context.Context, app core.App, target Target, record *core.Record, logger *zap.Logger) error {
+	previous := record.GetString("verification")
+	_, verifyErr := Verify(ctx, target, record.GetString("location"))
+
+	record.Set("verified_at", time.Now())
+	if verifyErr != nil {
+		record.Set("verification", StatusFailed)
+		record.Set("verification_error", verifyErr.Error())
+	} else {
+		record.Set("verification", StatusVerified)
+		record.Set("verification_error", "")
+	}
+	if err := app.Save(record); err != nil {
+		return err
+	}
+
+	if verifyErr != nil && previous != StatusFailed {
+		logger.Error("Backup verification failed",
+			zap.Error(verifyErr),
+			zap.String("backup", record.Id),
+			zap.String("location", record.GetString("location")))
+
+		err := notify.Send(ctx, notify.Notification{
+			Event:    "backup.verification_failed",
+			Severity: notify.SeverityCritical,
+			Title:    "Backup of " + record.GetString("workflow_name") + " is broken",
+			Message: fmt.Sprintf("The backup %s can't be restored: %v",
+				record.GetString("location"), verifyErr),
+			Fields: map[string]any{
+				"backup":   record.Id,
+				"instance": record.GetString("instance"),
+				"location": record.GetString("location"),
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send backup notification", zap.Error(err), zap.String("backup", record.Id))
+		}
+	}
+	return nil
+}
+
+// verifyConfig is read from the environment:
+//
+//	BACKUP_VERIFY_SCHEDULE  cron expression, default every 15 minutes, "off" disables the job
+//	BACKUP_REVERIFY_DAYS    days after which verified backups are read back again, default 7
+type verifyConfig struct {
+	schedule     string
+	reverifyDays int
+}
+
+func verifyConfigFromEnv() verifyConfig {
+	config := verifyConfig{
+		schedule:     os.Getenv("BACKUP_VERIFY_SCHEDULE"),
+		reverifyDays: defaultReverifyDays,
+	}
+	if config.schedule == "" {
+		config.schedule = "*/15 * * * *"
+	}
+	if days, err := strconv.Atoi(os.Getenv("BACKUP_REVERIFY_DAYS")); err == nil && days > 0 {
+		config.reverifyDays = days
+	}
+	return config
+}
+
+// dueForVerification selects the backups never verified and the ones
+// verified before
+func dueForVerification(before time.Time) dbx.Expression {
+	return dbx.Or(
+		dbx.HashExp{"verification": StatusPending},
+		dbx.NewExp("verified_at < {:before}", dbx.Params{"before": before.UTC().Format(types.DefaultDateLayout)}),
+	)
+}
+
+// InitVerification verifies pending backups and re-verifies old ones
+// periodically, and registers the endpoint verifying a backup on demand
+func InitVerification(app core.App, logger *zap.Logger) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/backups/{id}/verify", func(e *core.RequestEvent) error {
+			return verifyHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+		return se.Next()
+	})
+
+	config := verifyConfigFromEnv()
+	if config.schedule == "off" {
+		return
+	}
+	app.Cron().MustAdd(VerifyJob, config.schedule, func() {
+		defer errorreport.Recover(logger, zap.String("job", VerifyJob))
+
+		ctx := context.Background()
+		records, err := app.FindAllRecords(Collection,
+			dueForVerification(time.Now().AddDate(0, 0, -config.reverifyDays)))
+		if err != nil {
+			logger.Error("Failed to fetch backups", zap.Error(err))
+			return
+		}
+		// Backups are verified against the target they were written to
+		destinations := map[string]*Destination{}
+		for _, record := range records {
+			targetID := record.GetString("target")
+			dest, ok := destinations[targetID]
+			if !ok {
+				if dest, err = ForID(ctx, app, targetID); err != nil {
+					logger.Error("Backup target is misconfigured", zap.String("target", targetID), zap.Error(err))
+				}
+				destinations[targetID] = dest
+			}
+			if dest == nil {
+				continue
+			}
+			if err := VerifyRecord(ctx, app, dest, record, logger); err != nil {
+				logger.Error("Failed to save backup verification", zap.String("backup", record.Id), zap.Error(err))
+			}
+		}
+	})
+}
+
+// VerifyResult is the response of POST /api/backups/{id}/verify
+type VerifyResult struct {
+	ID           string    `json:"id"`
+	Location     string    `json:"location"`
+	Verification string    `json:"verification"`
+	Error        string    `json:"error,omitempty"`
+	VerifiedAt   time.Time `json:"verified_at"`
+}
+
+// verifyHandler verifies a backup on demand
+func verifyHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById(Collection, e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Backup not found", err)
+	}
+	dest, err := ForRecord(e.Request.Context(), e.App, record)
+	if err != nil {
+		return apis.NewInternalServerError("Backup target is misconfigured", err)
+	}
+
+	if err := VerifyRecord(e.Request.Context(), e.App, dest, record, logger); err != nil {
+		return apis.NewInternalServerError("Failed to save backup verification", err)
+	}
+
+	return e.JSON(http.StatusOK, VerifyResult{
+		ID:           record.Id,
+		Location:     record.GetString("location"),
+		Verification: record.GetString("verification"),
+		Error:        record.GetString("verification_error"),
+		VerifiedAt:   record.GetDateTime("verified_at").Time(),
+	})
+}
@@ -0,0 +1,38 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	target := &Dir{Path: t.TempDir()}
+
+	for key, data := range map[string]string{
+		"workflow.json":  `{"name": "Orders", "nodes": [{"name": "Webhook"}]}`,
+		"workflows.json": `[{"name": "Orders", "nodes": []}, {"name": "Invoices", "nodes": []}]`,
+		"truncated.json": `{"name": "Orders", "nodes": [`,
+		"empty.json":     `{}`,
+		"mixed.json":     `[{"name": "Orders", "nodes": []}, 42]`,
+	} {
+		_, err := target.Write(ctx, key, []byte(data))
+		require.NoError(t, err)
+	}
+
+	count, err := Verify(ctx, target, "workflow.json")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = Verify(ctx, target, "workflows.json")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	for _, location := range []string{"truncated.json", "empty.json", "mixed.json", "missing.json"} {
+		_, err := Verify(ctx, target, location)
+		assert.Error(t, err, location)
+	}
+}
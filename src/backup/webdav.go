@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebDAV is a Target storing backups below a WebDAV collection, e.g. a
+// Nextcloud folder (https://cloud.example.com/remote.php/dav/files/<user>/backups)
+type WebDAV struct {
+	// URL is the collection backups are stored in
+	URL string
+
+	// Username and Password are sent with basic authentication, Nextcloud
+	// needs an app password with two-factor authentication enabled
+	Username string
+	Password string
+
+	// Client defaults to a client with a 60 seconds timeout
+	Client *http.Client
+}
+
+var defaultWebDAVClient = &http.Client{Timeout: 60 * time.Second}
+
+// Write stores data in the file key, creating parent collections as needed
+func (w *WebDAV) Write(ctx context.Context, key string, data []byte) (string, error) {
+	location, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(location, "/")
+	for i := 1; i < len(segments); i++ {
+		if err := w.mkcol(ctx, strings.Join(segments[:i], "/")); err != nil {
+			return "", fmt.Errorf("error creating backup directory: %w", err)
+		}
+	}
+
+	resp, err := w.do(ctx, http.MethodPut, location, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("error writing backup: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error writing backup: WebDAV server returned %s", resp.Status)
+	}
+	return location, nil
+}
+
+// Read returns the contents of the file at location
+func (w *WebDAV) Read(ctx context.Context, location string) ([]byte, error) {
+	clean, err := cleanKey(location)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.do(ctx, http.MethodGet, clean, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("error reading backup %s: %w", clean, fs.ErrNotExist)
+	default:
+		return nil, fmt.Errorf("error reading backup: WebDAV server returned %s", resp.Status)
+	}
+}
+
+// Remove deletes the file at location
+func (w *WebDAV) Remove(ctx context.Context, location string) error {
+	clean, err := cleanKey(location)
+	if err != nil {
+		return err
+	}
+	resp, err := w.do(ctx, http.MethodDelete, clean, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting backup: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("error deleting backup %s: %w", clean, fs.ErrNotExist)
+	default:
+		return fmt.Errorf("error deleting backup: WebDAV server returned %s", resp.Status)
+	}
+}
+
+// mkcol creates the collection dir, existing ones are fine
+func (w *WebDAV) mkcol(ctx context.Context, dir string) error {
+	resp, err := w.do(ctx, "MKCOL", dir+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	// 405 Method Not Allowed is returned for existing collections
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("WebDAV server returned %s", resp.Status)
+	}
+	return nil
+}
+
+// do sends a request for the slash separated key below the collection
+func (w *WebDAV) do(ctx context.Context, method, key string, body io.Reader) (*http.Response, error) {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	req, err := http.NewRequestWithContext(ctx, method,
+		strings.TrimSuffix(w.URL, "/")+"/"+strings.Join(segments, "/"), body)
+	if err != nil {
+		return nil, err
+	}
+	if w.Username != "" || w.Password != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = defaultWebDAVClient
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,56 @@
+package backup
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func TestWebDAV(t *testing.T) {
+	ctx := context.Background()
+	handler := &webdav.Handler{
+		Prefix:     "/dav/backups",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "backup" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	target := &WebDAV{URL: server.URL + "/dav/backups/", Username: "backup", Password: "secret"}
+
+	location, err := target.Write(ctx, "instance/nested/workflow 1.json", []byte(`{"name":"A"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "instance/nested/workflow 1.json", location)
+
+	// Existing collections are reused
+	_, err = target.Write(ctx, "instance/nested/workflow 2.json", []byte(`{"name":"B"}`))
+	require.NoError(t, err)
+
+	data, err := target.Read(ctx, location)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"A"}`, string(data))
+
+	require.NoError(t, target.Remove(ctx, location))
+	_, err = target.Read(ctx, location)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorIs(t, target.Remove(ctx, location), fs.ErrNotExist)
+
+	_, err = target.Write(ctx, "../outside.json", nil)
+	assert.Error(t, err)
+
+	unauthorized := &WebDAV{URL: target.URL}
+	_, err = unauthorized.Write(ctx, "workflow.json", []byte(`{}`))
+	assert.Error(t, err)
+}
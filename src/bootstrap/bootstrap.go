@@ -0,0 +1,114 @@
+// Package bootstrap provisions the initial superuser and optional sample data
+// from environment variables once the database migrations have been applied.
+package bootstrap
+
+import (
+	"os"
+
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+const defaultSampleHost = "http://localhost:5678"
+
+// Init registers the bootstrap on server start. It is idempotent and only
+// creates records that don't exist yet.
+//
+// Environment variables:
+//
+//	ADMIN_EMAIL / ADMIN_PASSWORD: superuser to create if missing
+//	SEED_SAMPLE_DATA=true: create a sample instance when none exist
+//	SAMPLE_INSTANCE_HOST / SAMPLE_INSTANCE_API_KEY: sample instance settings
+func Init(app core.App, logger *zap.Logger) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		if err := ensureSuperuser(se.App, logger); err != nil {
+			return err
+		}
+
+		if os.Getenv("SEED_SAMPLE_DATA") == "true" {
+			if err := seedSampleData(se.App, logger); err != nil {
+				return err
+			}
+		}
+
+		return se.Next()
+	})
+}
+
+// ensureSuperuser creates the superuser configured via ADMIN_EMAIL and ADMIN_PASSWORD
+func ensureSuperuser(app core.App, logger *zap.Logger) error {
+	email := os.Getenv("ADMIN_EMAIL")
+	password := os.Getenv("ADMIN_PASSWORD")
+
+	if email == "" || password == "" {
+		total, err := app.CountRecords(core.CollectionNameSuperusers)
+		if err != nil {
+			return err
+		}
+		if total == 0 {
+			logger.Warn("No superuser configured, set ADMIN_EMAIL and ADMIN_PASSWORD or create one via the installer")
+		}
+		return nil
+	}
+
+	if _, err := app.FindAuthRecordByEmail(core.CollectionNameSuperusers, email); err == nil {
+		logger.Debug("Superuser already exists", zap.String("email", email))
+		return nil
+	}
+
+	superusers, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(superusers)
+	record.Set("email", email)
+	record.Set("password", password)
+
+	if err := app.Save(record); err != nil {
+		return err
+	}
+
+	logger.Info("Created superuser from environment", zap.String("email", email))
+	return nil
+}
+
+// seedSampleData creates a sample n8n instance if the instances collection is empty
+func seedSampleData(app core.App, logger *zap.Logger) error {
+	total, err := app.CountRecords("instances")
+	if err != nil {
+		return err
+	}
+	if total > 0 {
+		return nil
+	}
+
+	apiKey := os.Getenv("SAMPLE_INSTANCE_API_KEY")
+	if apiKey == "" {
+		logger.Warn("SEED_SAMPLE_DATA is enabled but SAMPLE_INSTANCE_API_KEY is not set, skipping sample instance")
+		return nil
+	}
+
+	host := os.Getenv("SAMPLE_INSTANCE_HOST")
+	if host == "" {
+		host = defaultSampleHost
+	}
+
+	instances, err := app.FindCollectionByNameOrId("instances")
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(instances)
+	record.Set("host", host)
+	record.Set("api_key", apiKey)
+	record.Set("check_interval_mins", 1) // Check every minute
+	record.Set("ignore_ssl_errors", false)
+
+	if err := app.Save(record); err != nil {
+		return err
+	}
+
+	logger.Info("Seeded sample instance", zap.String("host", host))
+	return nil
+}
@@ -0,0 +1,33 @@
+// Package cli provides the manager's subcommands for scripted provisioning,
+// operating on the database directly without the admin UI.
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cobra"
+)
+
+// migrate applies pending migrations, like "serve" does, so the commands
+// also work on a fresh data directory
+func migrate(app core.App) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := app.RunAllMigrations(); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		return nil
+	}
+}
+
+// run adapts fn to cobra's Run. PocketBase ignores command errors, so
+// failures are printed and exit with status 1 for scripts to detect them.
+func run(fn func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if err := fn(cmd, args); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	}
+}
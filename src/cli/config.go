@@ -0,0 +1,445 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configVersion is the version of the exported document format
+const configVersion = 1
+
+// relation describes a relation field, exported as the key of the related
+// record so documents can be imported into another environment
+type relation struct {
+	Section string
+	Field   string
+}
+
+// section describes how a collection is exported and imported
+type section struct {
+	// Name is the key of the section in the document
+	Name       string
+	Collection string
+
+	// Key identifies a record across environments, ids are not exported
+	Key []string
+
+	Fields []string
+
+	// Secrets are only exported if they hold a secret reference. Plain
+	// values are replaced by an env: reference to set on import.
+	Secrets []string
+
+	Relations map[string]relation
+}
+
+// sections are exported in order, relations must point to earlier sections
+var sections = []*section{
+	{
+		Name:       "environments",
+		Collection: "environments",
+		Key:        []string{"name"},
+		Fields:     []string{"name", "description", "alert_severity"},
+	},
+	{
+		Name:       "backup_targets",
+		Collection: "backup_targets",
+		Key:        []string{"name"},
+		Fields:     []string{"name", "type", "path", "url", "username", "host_key", "keep"},
+		Secrets:    []string{"password", "private_key"},
+	},
+	{
+		Name:       "instances",
+		Collection: "instances",
+		Key:        []string{"host"},
+		Fields: []string{"host", "check_interval_mins", "ignore_ssl_errors", "metrics_enabled",
+			"worker_health_urls", "api_key_rotation_days", "store_mode", "owner", "environment", "backup_target", "timezone"},
+		Secrets: []string{"api_key", "owner_email", "owner_password"},
+		Relations: map[string]relation{
+			"environment":   {Section: "environments", Field: "name"},
+			"backup_target": {Section: "backup_targets", Field: "name"},
+		},
+	},
+	{
+		Name:       "route_credentials",
+		Collection: "route_credentials",
+		Key:        []string{"name"},
+		Fields:     []string{"name", "username"},
+		Secrets:    []string{"password"},
+	},
+	{
+		Name:       "routes",
+		Collection: "routes",
+		Key:        []string{"host", "path"},
+		Fields: []string{"instance", "host", "hosts", "subdomain_header", "path", "webhook_path", "entrypoints", "path_params",
+			"query_params", "headers", "observability", "error_pages", "audience", "auth_type", "auth_username", "auth_credentials", "auth_oidc", "auth_hmac", "replay_protection", "capture_requests", "gateway", "dead_letter", "transform", "response_cache", "quota", "geoip", "auth_api_key_credential", "auth_api_key_rotation_days", "expires_at", "activation_windows", "active",
+			// The objectives the SLO burn-rate alerts fire on
+			"slo"},
+		Secrets: []string{"auth_password", "auth_api_key", "auth_hmac_secret"},
+		Relations: map[string]relation{
+			"instance":         {Section: "instances", Field: "host"},
+			"auth_credentials": {Section: "route_credentials", Field: "name"},
+		},
+	},
+}
+
+// findSection returns the section with the given name
+func findSection(name string) *section {
+	for _, s := range sections {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// keyOf returns the cross-environment key of an exported item
+func (s *section) keyOf(item map[string]any) string {
+	parts := make([]string, len(s.Key))
+	for i, field := range s.Key {
+		parts[i] = fmt.Sprint(item[field])
+	}
+	return strings.Join(parts, " ")
+}
+
+// NewConfigCommand returns the "config" command exporting and importing the
+// manager configuration as YAML
+func NewConfigCommand(app core.App) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "config",
+		Short: "Export and import the manager configuration",
+		Long: `Export and import the manager configuration as YAML: environments,
+backup targets, instances, route credentials and routes, including the SLO
+objectives of routes that alerts are raised on.
+
+Notification channels aren't stored in the database, alerts are sent to
+NOTIFY_WEBHOOK_URL, which has to be set in the target environment.`,
+		// Apply migrations before operating on the database
+		PersistentPreRun: run(migrate(app)),
+	}
+
+	command.AddCommand(configExportCommand(app))
+	command.AddCommand(configImportCommand(app))
+
+	return command
+}
+
+func configExportCommand(app core.App) *cobra.Command {
+	var output string
+	var includeSecrets bool
+
+	command := &cobra.Command{
+		Use:          "export",
+		Example:      "config export -o manager.yaml",
+		Short:        "Exports the manager configuration as YAML",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: run(func(cmd *cobra.Command, args []string) error {
+			document, envVars, err := exportConfig(app, includeSecrets)
+			if err != nil {
+				return err
+			}
+
+			var out io.Writer = cmd.OutOrStdout()
+			if output != "" && output != "-" {
+				file, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				out = file
+			}
+
+			encoder := yaml.NewEncoder(out)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(document); err != nil {
+				return err
+			}
+			if err := encoder.Close(); err != nil {
+				return err
+			}
+
+			if len(envVars) > 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Plain credentials were replaced by env: references, set these variables in the target environment:")
+				for _, name := range envVars {
+					fmt.Fprintln(cmd.ErrOrStderr(), "  "+name)
+				}
+			}
+			if notify.Enabled() {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Alerts are sent to NOTIFY_WEBHOOK_URL, which isn't exported, set it in the target environment")
+			}
+			return nil
+		}),
+	}
+
+	command.Flags().StringVarP(&output, "output", "o", "", "the file to write, defaults to stdout")
+	command.Flags().BoolVar(&includeSecrets, "include-secrets", false, "inline plain credentials instead of env: references")
+
+	return command
+}
+
+func configImportCommand(app core.App) *cobra.Command {
+	var dryRun bool
+
+	command := &cobra.Command{
+		Use:          "import <file>",
+		Example:      "config import manager.yaml",
+		Short:        "Creates or updates instances and routes from YAML",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Run: run(func(cmd *cobra.Command, args []string) error {
+			var in io.Reader = os.Stdin
+			if args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				in = file
+			}
+
+			var document map[string]any
+			if err := yaml.NewDecoder(in).Decode(&document); err != nil {
+				return fmt.Errorf("failed to parse config: %w", err)
+			}
+
+			created, updated, err := importConfig(app, document, dryRun)
+			if err != nil {
+				return err
+			}
+
+			prefix := ""
+			if dryRun {
+				prefix = "[dry run] "
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%sCreated %d and updated %d records\n", prefix, created, updated)
+			return nil
+		}),
+	}
+
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "validate the file without saving")
+
+	return command
+}
+
+// exportConfig builds the configuration document. It returns the names of
+// the environment variables referenced in place of plain credentials.
+func exportConfig(app core.App, includeSecrets bool) (map[string]any, []string, error) {
+	document := map[string]any{"version": configVersion}
+	var envVars []string
+
+	// Relation targets are exported by key, indexed by record id
+	keys := map[string]map[string]any{}
+
+	for _, s := range sections {
+		records, err := app.FindAllRecords(s.Collection)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch %s: %w", s.Collection, err)
+		}
+
+		items := make([]map[string]any, 0, len(records))
+		for _, record := range records {
+			item := map[string]any{}
+			for _, field := range s.Fields {
+				value, err := exportValue(record, field, s.Relations, keys)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s %s: %w", s.Name, record.Id, err)
+				}
+				if value != nil {
+					item[field] = value
+				}
+			}
+
+			for _, field := range s.Secrets {
+				value := record.GetString(field)
+				if value == "" {
+					continue
+				}
+				if !includeSecrets && !secrets.IsReference(value) {
+					name := envVarName(s.Name, s.keyOf(item), field)
+					value = "env:" + name
+					envVars = append(envVars, name)
+				}
+				item[field] = value
+			}
+
+			keys[record.Id] = item
+			items = append(items, item)
+		}
+		document[s.Name] = items
+	}
+
+	return document, envVars, nil
+}
+
+// exportValue converts a record field into a YAML friendly value
+func exportValue(record *core.Record, field string, relations map[string]relation, keys map[string]map[string]any) (any, error) {
+	if rel, ok := relations[field]; ok {
+		id := record.GetString(field)
+		if id == "" {
+			return nil, nil
+		}
+		target, ok := keys[id]
+		if !ok {
+			return nil, fmt.Errorf("related %s %s not found", rel.Section, id)
+		}
+		return target[rel.Field], nil
+	}
+
+	switch value := record.Get(field).(type) {
+	case nil:
+		return nil, nil
+	case string, bool, int, float64:
+		return value, nil
+	default:
+		// JSON fields and other types round-trip through JSON
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		var result any
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// envVarName derives the variable name referenced for a plain credential
+func envVarName(section, key, field string) string {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	name := strings.ToUpper(strings.Join([]string{"N8N_MANAGER", section, key, field}, "_"))
+	return strings.Trim(nonAlphanumeric.ReplaceAllString(name, "_"), "_")
+}
+
+// importConfig creates or updates the records of document, matched by the
+// section keys, in a single transaction
+func importConfig(app core.App, document map[string]any, dryRun bool) (created, updated int, err error) {
+	if version, _ := document["version"].(int); version != configVersion {
+		return 0, 0, fmt.Errorf("unsupported config version %v", document["version"])
+	}
+	for name := range document {
+		if name != "version" && findSection(name) == nil {
+			return 0, 0, fmt.Errorf("unknown section %q", name)
+		}
+	}
+
+	errDryRun := errors.New("dry run")
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		// Record ids by section and key, to resolve relations
+		ids := map[string]map[string]string{}
+
+		for _, s := range sections {
+			ids[s.Name] = map[string]string{}
+
+			raw, _ := document[s.Name].([]any)
+			for i, entry := range raw {
+				item, ok := entry.(map[string]any)
+				if !ok {
+					return fmt.Errorf("%s[%d]: expected a mapping", s.Name, i)
+				}
+
+				isNew, id, err := importItem(txApp, s, item, ids)
+				if err != nil {
+					return fmt.Errorf("%s[%d]: %w", s.Name, i, err)
+				}
+				ids[s.Name][s.keyOf(item)] = id
+				if isNew {
+					created++
+				} else {
+					updated++
+				}
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+
+	return created, updated, err
+}
+
+// importItem upserts a single item, returning whether it was created
+func importItem(app core.App, s *section, item map[string]any, ids map[string]map[string]string) (bool, string, error) {
+	match := dbx.HashExp{}
+	for _, field := range s.Key {
+		value, ok := item[field]
+		if !ok || value == "" {
+			return false, "", fmt.Errorf("missing key field %q", field)
+		}
+		match[field] = value
+	}
+
+	allowed := append(append([]string{}, s.Fields...), s.Secrets...)
+	values := make(map[string]any, len(item))
+	for field, value := range item {
+		if !slices.Contains(allowed, field) {
+			return false, "", fmt.Errorf("unknown field %q", field)
+		}
+		values[field] = value
+	}
+
+	// Resolve related keys to the record ids of this environment
+	for field, rel := range s.Relations {
+		key, ok := values[field]
+		if !ok {
+			continue
+		}
+		id, ok := ids[rel.Section][fmt.Sprint(key)]
+		if !ok {
+			existing, err := app.FindFirstRecordByData(findSection(rel.Section).Collection, rel.Field, key)
+			if err != nil {
+				return false, "", fmt.Errorf("%s %q not found", rel.Section, key)
+			}
+			id = existing.Id
+		}
+		values[field] = id
+	}
+
+	records, err := app.FindAllRecords(s.Collection, match)
+	if err != nil {
+		return false, "", err
+	}
+
+	var record *core.Record
+	isNew := len(records) == 0
+	if isNew {
+		collection, err := app.FindCollectionByNameOrId(s.Collection)
+		if err != nil {
+			return false, "", err
+		}
+		record = core.NewRecord(collection)
+	} else {
+		record = records[0]
+	}
+
+	for field, value := range values {
+		record.Set(field, value)
+	}
+
+	if err := app.Save(record); err != nil {
+		return false, "", err
+	}
+	return isNew, record.Id, nil
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEnvVarName(t *testing.T) {
+	assert.Equal(t, "N8N_MANAGER_INSTANCES_N8N_EXAMPLE_COM_API_KEY",
+		envVarName("instances", "https://n8n.example.com", "api_key"))
+	assert.Equal(t, "N8N_MANAGER_ROUTES_API_EXAMPLE_COM_ORDERS_AUTH_PASSWORD",
+		envVarName("routes", "api.example.com /orders", "auth_password"))
+}
+
+func TestSectionKey(t *testing.T) {
+	routes := findSection("routes")
+	assert.Equal(t, "api.example.com /orders", routes.keyOf(map[string]any{
+		"host": "api.example.com",
+		"path": "/orders",
+	}))
+
+	// Relations must point to sections exported before them
+	seen := map[string]bool{}
+	for _, s := range sections {
+		for _, rel := range s.Relations {
+			assert.True(t, seen[rel.Section], "%s relates to later section %s", s.Name, rel.Section)
+		}
+		seen[s.Name] = true
+	}
+}
+
+func TestExportImportConfig(t *testing.T) {
+	source := testutil.NewApp(t)
+	// Environments are seeded by the migrations
+	staging, err := source.FindFirstRecordByData("environments", "name", "staging")
+	require.NoError(t, err)
+	environments, err := source.CountRecords("environments")
+	require.NoError(t, err)
+	instance := testutil.Create(t, source, "instances", map[string]any{
+		"host":        "https://n8n.example.com",
+		"api_key":     "plain-key",
+		"environment": staging.Id,
+	})
+	testutil.Create(t, source, "routes", map[string]any{
+		"instance":      instance.Id,
+		"host":          "hooks.example.com",
+		"path":          "/orders",
+		"webhook_path":  "/webhook/orders",
+		"slo":           map[string]any{"latency_ms": 500, "objective": 99},
+		"auth_password": "vault:kv/hooks#password",
+	})
+
+	document, envVars, err := exportConfig(source, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"N8N_MANAGER_INSTANCES_N8N_EXAMPLE_COM_API_KEY"}, envVars)
+
+	// The document goes through YAML like the commands
+	data, err := yaml.Marshal(document)
+	require.NoError(t, err)
+	var parsed map[string]any
+	require.NoError(t, yaml.Unmarshal(data, &parsed))
+
+	instances := parsed["instances"].([]any)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "env:N8N_MANAGER_INSTANCES_N8N_EXAMPLE_COM_API_KEY", instances[0].(map[string]any)["api_key"], "plain secrets aren't inlined")
+	assert.Equal(t, "staging", instances[0].(map[string]any)["environment"], "relations are exported by key")
+	route := parsed["routes"].([]any)[0].(map[string]any)
+	assert.Equal(t, "vault:kv/hooks#password", route["auth_password"], "references are kept")
+	assert.Equal(t, map[string]any{"latency_ms": 500, "objective": 99}, route["slo"])
+
+	target := testutil.NewApp(t)
+	created, _, err := importConfig(target, parsed, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	count, err := target.CountRecords("routes")
+	require.NoError(t, err)
+	assert.Zero(t, count, "dry runs don't save")
+
+	created, updated, err := importConfig(target, parsed, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.EqualValues(t, environments, updated)
+
+	imported, err := target.FindFirstRecordByData("routes", "host", "hooks.example.com")
+	require.NoError(t, err)
+	importedInstance, err := target.FindRecordById("instances", imported.GetString("instance"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://n8n.example.com", importedInstance.GetString("host"))
+	assert.Contains(t, imported.GetString("slo"), `"objective":99`)
+
+	// Importing again updates the records matched by their keys
+	created, updated, err = importConfig(target, parsed, false)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+	assert.EqualValues(t, environments+2, updated)
+
+	parsed["alert_rules"] = []any{}
+	_, _, err = importConfig(target, parsed, false)
+	assert.ErrorContains(t, err, `unknown section "alert_rules"`)
+}
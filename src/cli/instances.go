@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"github.com/spf13/cobra"
+)
+
+// NewInstancesCommand returns the "instances" command for managing n8n instances
+func NewInstancesCommand(app core.App) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "instances",
+		Short: "Manage n8n instances",
+		// Apply migrations before operating on the database
+		PersistentPreRun: run(migrate(app)),
+	}
+
+	command.AddCommand(instancesAddCommand(app))
+	command.AddCommand(instancesListCommand(app))
+
+	return command
+}
+
+// instanceOptions are the settings of an instance added with "instances add"
+type instanceOptions struct {
+	Host           string
+	APIKey         string
+	Interval       int
+	IgnoreSSL      bool
+	MetricsEnabled bool
+}
+
+func instancesAddCommand(app core.App) *cobra.Command {
+	var options instanceOptions
+
+	command := &cobra.Command{
+		Use:          "add",
+		Example:      "instances add --host https://n8n.example.com --api-key vault:kv/n8n#api_key --interval 5",
+		Short:        "Adds an n8n instance",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: run(func(cmd *cobra.Command, args []string) error {
+			record, err := addInstance(app, options)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), record.Id)
+			return nil
+		}),
+	}
+
+	command.Flags().StringVar(&options.Host, "host", "", "the base URL of the instance")
+	command.Flags().StringVar(&options.APIKey, "api-key", "", "the n8n API key or a secret reference")
+	command.Flags().IntVar(&options.Interval, "interval", 5, "the check interval in minutes")
+	command.Flags().BoolVar(&options.IgnoreSSL, "ignore-ssl-errors", false, "skip TLS certificate verification")
+	command.Flags().BoolVar(&options.MetricsEnabled, "metrics", false, "scrape the instance's /metrics endpoint")
+
+	return command
+}
+
+// addInstance validates the options and creates the instance
+func addInstance(app core.App, options instanceOptions) (*core.Record, error) {
+	host := strings.TrimRight(options.Host, "/")
+	if u, err := url.Parse(host); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("--host must be an absolute URL, e.g. https://n8n.example.com")
+	}
+	if options.APIKey == "" {
+		return nil, errors.New("--api-key is required")
+	}
+	if options.Interval < 0 {
+		return nil, errors.New("--interval must not be negative")
+	}
+
+	existing, _ := app.FindFirstRecordByData("instances", "host", host)
+	if existing != nil {
+		return nil, fmt.Errorf("instance %s already exists with id %s", host, existing.Id)
+	}
+
+	collection, err := app.FindCollectionByNameOrId("instances")
+	if err != nil {
+		return nil, err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("host", host)
+	record.Set("api_key", options.APIKey)
+	record.Set("check_interval_mins", options.Interval)
+	record.Set("ignore_ssl_errors", options.IgnoreSSL)
+	record.Set("metrics_enabled", options.MetricsEnabled)
+	if err := app.Save(record); err != nil {
+		return nil, fmt.Errorf("failed to create instance: %w", err)
+	}
+	return record, nil
+}
+
+// instanceSummary is the list output of an instance, without its API key
+type instanceSummary struct {
+	ID            string `json:"id"`
+	Host          string `json:"host"`
+	CheckInterval int    `json:"check_interval_mins"`
+	Health        string `json:"health"`
+	LastCheck     string `json:"last_check"`
+	Workflows     int    `json:"workflows"`
+	Webhooks      int    `json:"webhooks"`
+}
+
+func instancesListCommand(app core.App) *cobra.Command {
+	var asJSON bool
+
+	command := &cobra.Command{
+		Use:          "list",
+		Short:        "Lists the n8n instances",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: run(func(cmd *cobra.Command, args []string) error {
+			summaries, err := listInstances(app)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if asJSON {
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(summaries)
+			}
+
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tHOST\tINTERVAL\tHEALTH\tWORKFLOWS\tWEBHOOKS\tLAST CHECK")
+			for _, s := range summaries {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%d\t%s\n",
+					s.ID, s.Host, s.CheckInterval, s.Health, s.Workflows, s.Webhooks, s.LastCheck)
+			}
+			return w.Flush()
+		}),
+	}
+
+	command.Flags().BoolVar(&asJSON, "json", false, "print the instances as JSON")
+
+	return command
+}
+
+// listInstances returns the summaries of the instances not in the trash
+func listInstances(app core.App) ([]instanceSummary, error) {
+	records, err := app.FindAllRecords("instances", trash.NotDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]instanceSummary, 0, len(records))
+	for _, record := range records {
+		summary := instanceSummary{
+			ID:            record.Id,
+			Host:          record.GetString("host"),
+			CheckInterval: record.GetInt("check_interval_mins"),
+			Health:        record.GetString("health"),
+			Workflows:     record.GetInt("workflows_active") + record.GetInt("workflows_inactive"),
+			Webhooks:      record.GetInt("webhooks_active") + record.GetInt("webhooks_inactive"),
+		}
+		if lastCheck := record.GetDateTime("last_check"); !lastCheck.IsZero() {
+			summary.LastCheck = lastCheck.String()
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddInstance(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	record, err := addInstance(app, instanceOptions{
+		Host:     "https://n8n.example.com/",
+		APIKey:   "vault:kv/n8n#api_key",
+		Interval: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://n8n.example.com", record.GetString("host"), "the trailing slash is dropped")
+	assert.Equal(t, "vault:kv/n8n#api_key", record.GetString("api_key"), "references are stored, not resolved")
+	assert.Equal(t, 10, record.GetInt("check_interval_mins"))
+
+	_, err = addInstance(app, instanceOptions{Host: "https://n8n.example.com", APIKey: "key"})
+	assert.ErrorContains(t, err, "already exists with id "+record.Id)
+
+	for _, options := range []instanceOptions{
+		{Host: "n8n.example.com", APIKey: "key"},
+		{Host: "https://other.example.com"},
+		{Host: "https://other.example.com", APIKey: "key", Interval: -1},
+	} {
+		_, err := addInstance(app, options)
+		assert.Error(t, err, options)
+	}
+}
+
+func TestInstancesList(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	testutil.Create(t, app, "instances", map[string]any{
+		"host":               "https://n8n.example.com",
+		"api_key":            "secret",
+		"workflows_active":   2,
+		"workflows_inactive": 1,
+		"webhooks_active":    4,
+	})
+	testutil.Create(t, app, "instances", map[string]any{"host": "https://old.example.com", trash.Field: "2026-01-01 00:00:00.000Z"})
+
+	command := NewInstancesCommand(app)
+	var out bytes.Buffer
+	command.SetOut(&out)
+	command.SetArgs([]string{"list", "--json"})
+	require.NoError(t, command.Execute())
+
+	var summaries []instanceSummary
+	require.NoError(t, json.Unmarshal(out.Bytes(), &summaries))
+	require.Len(t, summaries, 1, "instances in the trash aren't listed")
+	assert.Equal(t, "https://n8n.example.com", summaries[0].Host)
+	assert.Equal(t, 3, summaries[0].Workflows)
+	assert.Equal(t, 4, summaries[0].Webhooks)
+	assert.NotContains(t, out.String(), "secret", "API keys aren't printed")
+
+	// Flags keep their values between executions
+	out.Reset()
+	command = NewInstancesCommand(app)
+	command.SetOut(&out)
+	command.SetArgs([]string{"list"})
+	require.NoError(t, command.Execute())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "ID"))
+	assert.Contains(t, lines[1], "https://n8n.example.com")
+}
@@ -0,0 +1,151 @@
+// Package configfile loads the settings of the manager from the YAML or
+// TOML file in CONFIG_FILE, so deployments don't need a dozen environment
+// variables. Every setting is read from the environment, the file only
+// fills in the variables that aren't set: environment variables override it.
+//
+// Keys are the names of the environment variables in lower case. Tables
+// prefix the keys they contain, so these set PORT, LOG_LEVEL,
+// WORKFLOW_FETCH_CONCURRENCY, TRAEFIK_BCRYPT_COST, NOTIFY_WEBHOOK_URL and
+// VAULT_ADDR:
+//
+//	port: 8090
+//	log_level: debug
+//	workflow_fetch_concurrency: 8
+//	traefik:
+//	  bcrypt_cost: 12
+//	notify:
+//	  webhook_url: https://hooks.example.com/n8n-manager
+//	vault:
+//	  addr: https://vault.example.com:8200
+//
+// Lists are joined with commas, e.g. for K8S_DISCOVERY_NAMESPACES.
+package configfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the file in CONFIG_FILE, if set, and sets the environment
+// variables it configures that aren't set already. It returns the path and
+// the names of the variables it set.
+func Load() (string, []string, error) {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return "", nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return path, nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	settings, err := Parse(path, data)
+	if err != nil {
+		return path, nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return path, apply(settings), nil
+}
+
+// Parse decodes a config file, by its extension, into the environment
+// variables it sets
+func Parse(path string, data []byte) (map[string]string, error) {
+	var document map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&document); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("unsupported config file format, use .yaml, .yml or .toml")
+	}
+
+	settings := map[string]string{}
+	for key, value := range document {
+		if err := flatten(key, value, settings); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// flatten adds the variables set by value under key to settings
+func flatten(key string, value any, settings map[string]string) error {
+	name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		for child, item := range v {
+			if err := flatten(key+"_"+child, item, settings); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := scalar(item)
+			if !ok {
+				return fmt.Errorf("%s: lists may only contain strings, numbers and booleans", key)
+			}
+			items = append(items, s)
+		}
+		value = strings.Join(items, ",")
+	}
+
+	s, ok := scalar(value)
+	if !ok {
+		return fmt.Errorf("%s: unsupported value %T", key, value)
+	}
+	if _, ok := settings[name]; ok {
+		return fmt.Errorf("%s is set twice", name)
+	}
+	settings[name] = s
+	return nil
+}
+
+// scalar formats strings, numbers and booleans as environment values
+func scalar(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// apply sets the settings not set in the environment and returns their
+// names, sorted
+func apply(settings map[string]string) []string {
+	applied := []string{}
+	for name, value := range settings {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		os.Setenv(name, value)
+		applied = append(applied, name)
+	}
+	slices.Sort(applied)
+	return applied
+}
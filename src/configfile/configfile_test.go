@@ -0,0 +1,135 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseYAML(t *testing.T) {
+	settings, err := Parse("config.yaml", []byte(`
+port: 8090
+log_level: debug
+workflow_fetch_concurrency: 8
+traefik:
+  bcrypt_cost: 12
+  route_header: X-N8N-Route
+notify:
+  webhook_url: https://hooks.example.com/n8n-manager
+k8s-discovery:
+  namespaces: [n8n, automation]
+secrets:
+  cache_ttl: 1.5m
+sentry_dsn: null
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PORT":                       "8090",
+		"LOG_LEVEL":                  "debug",
+		"WORKFLOW_FETCH_CONCURRENCY": "8",
+		"TRAEFIK_BCRYPT_COST":        "12",
+		"TRAEFIK_ROUTE_HEADER":       "X-N8N-Route",
+		"NOTIFY_WEBHOOK_URL":         "https://hooks.example.com/n8n-manager",
+		"K8S_DISCOVERY_NAMESPACES":   "n8n,automation",
+		"SECRETS_CACHE_TTL":          "1.5m",
+	}, settings)
+
+	settings, err = Parse("config.yml", nil)
+	require.NoError(t, err)
+	assert.Empty(t, settings)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse("config.json", []byte(`{}`))
+	assert.Error(t, err)
+
+	_, err = Parse("config.yaml", []byte("vault:\n  addr: [{url: x}]\n"))
+	assert.Error(t, err, "lists of tables")
+
+	_, err = Parse("config.yaml", []byte("vault_addr: a\nvault:\n  addr: b\n"))
+	assert.ErrorContains(t, err, "VAULT_ADDR is set twice")
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+log_level = "debug"
+
+[configfile_test]
+from_file = "file"
+from_env = "file"
+`), 0o600))
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("CONFIGFILE_TEST_FROM_ENV", "env")
+	// Registers cleanups restoring the variables Load sets
+	t.Setenv("LOG_LEVEL", "")
+	os.Unsetenv("LOG_LEVEL")
+	t.Setenv("CONFIGFILE_TEST_FROM_FILE", "")
+	os.Unsetenv("CONFIGFILE_TEST_FROM_FILE")
+
+	loaded, applied, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, path, loaded)
+	assert.Equal(t, []string{"CONFIGFILE_TEST_FROM_FILE", "LOG_LEVEL"}, applied)
+	assert.Equal(t, "file", os.Getenv("CONFIGFILE_TEST_FROM_FILE"))
+	assert.Equal(t, "env", os.Getenv("CONFIGFILE_TEST_FROM_ENV"), "environment variables override the file")
+	assert.Equal(t, "debug", os.Getenv("LOG_LEVEL"))
+
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	_, _, err = Load()
+	assert.Error(t, err)
+}
+
+func TestParseTOML(t *testing.T) {
+	settings, err := Parse("config.toml", []byte(`
+# Manager settings
+port = 8_090
+log_level = "debug" # inline comment
+health.fail_mode = 'open'
+notify = { webhook_url = "https://hooks.example.com/n8n-manager" }
+
+[traefik]
+bcrypt_cost = 12
+route_header = "X-N8N-\"Route\"!"
+
+[k8s.discovery]
+enabled = true
+namespaces = [
+  "n8n",
+  "automation",
+]
+interval = 2.5
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PORT":                     "8090",
+		"LOG_LEVEL":                "debug",
+		"HEALTH_FAIL_MODE":         "open",
+		"NOTIFY_WEBHOOK_URL":       "https://hooks.example.com/n8n-manager",
+		"TRAEFIK_BCRYPT_COST":      "12",
+		"TRAEFIK_ROUTE_HEADER":     `X-N8N-"Route"!`,
+		"K8S_DISCOVERY_ENABLED":    "true",
+		"K8S_DISCOVERY_NAMESPACES": "n8n,automation",
+		"K8S_DISCOVERY_INTERVAL":   "2.5",
+	}, settings)
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	for name, input := range map[string]string{
+		"unquoted string":  "log_level = debug",
+		"missing value":    "log_level",
+		"duplicate key":    "port = 1\nport = 2",
+		"table over value": "vault = 1\n[vault]\naddr = 'x'",
+		"array of tables":  "[[instances]]\nhost = 'x'",
+		"date":             "started = 2025-06-02",
+		"unterminated":     `log_level = "debug`,
+		"trailing garbage": "port = 1 2",
+	} {
+		_, err := Parse("config.toml", []byte(input))
+		assert.Error(t, err, name)
+	}
+}
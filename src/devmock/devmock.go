@@ -0,0 +1,174 @@
+// Package devmock runs fake n8n instances inside the manager, so the full
+// stack can be used without access to a real n8n.
+//
+// The mock instance, enabled with --dev-mock-n8n or DEV_MOCK_N8N=true,
+// serves a few hand written workflows for frontend development. A
+// simulation, enabled with --simulate-instances or SIMULATE_INSTANCES,
+// serves a fleet of generated instances whose workflows keep changing, to
+// load-test the sync, the database growth and the Traefik configuration.
+package devmock
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/discovery"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// Source is the managed_by value of the mock instance
+const Source = "devmock"
+
+// APIKey is the API key the mock and simulated instances accept
+const APIKey = "dev-mock-api-key"
+
+const (
+	defaultAddr           = "127.0.0.1:5680"
+	defaultSimulationAddr = "127.0.0.1:5681"
+)
+
+// Init registers the flags and starts the fake instances with the server
+// if they are enabled
+func Init(app *pocketbase.PocketBase, logger *zap.Logger) {
+	flags := app.RootCmd.PersistentFlags()
+	flags.Bool("dev-mock-n8n", false,
+		"start a fake n8n instance for development (or DEV_MOCK_N8N=true)")
+	flags.Int("simulate-instances", 0,
+		"simulate a fleet of n8n instances for load tests (or SIMULATE_INSTANCES)")
+	flags.Int("simulate-workflows", 50,
+		"workflows per simulated instance (or SIMULATE_WORKFLOWS)")
+	flags.Float64("simulate-churn", 0.05,
+		"share of simulated workflows changing between two syncs (or SIMULATE_CHURN)")
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		enabled, _ := flags.GetBool("dev-mock-n8n")
+		if enabled || os.Getenv("DEV_MOCK_N8N") == "true" {
+			if err := startMock(app, logger); err != nil {
+				return err
+			}
+		}
+
+		if config := simulationConfig(app.RootCmd); config.Instances > 0 {
+			if err := startSimulation(app, config, logger); err != nil {
+				return err
+			}
+		}
+		return se.Next()
+	})
+}
+
+// simulationConfig reads the simulation flags, falling back to the
+// environment for flags that weren't set
+func simulationConfig(cmd *cobra.Command) FleetConfig {
+	flags := cmd.PersistentFlags()
+	config := FleetConfig{WebhookShare: 0.3, Seed: 1}
+
+	config.Instances, _ = flags.GetInt("simulate-instances")
+	if !flags.Changed("simulate-instances") {
+		config.Instances, _ = strconv.Atoi(os.Getenv("SIMULATE_INSTANCES"))
+	}
+	config.Workflows, _ = flags.GetInt("simulate-workflows")
+	if value, err := strconv.Atoi(os.Getenv("SIMULATE_WORKFLOWS")); err == nil && !flags.Changed("simulate-workflows") {
+		config.Workflows = value
+	}
+	config.Churn, _ = flags.GetFloat64("simulate-churn")
+	if value, err := strconv.ParseFloat(os.Getenv("SIMULATE_CHURN"), 64); err == nil && !flags.Changed("simulate-churn") {
+		config.Churn = value
+	}
+	return config
+}
+
+// startMock serves the mock instance and registers it
+func startMock(app core.App, logger *zap.Logger) error {
+	mock, err := NewServer(APIKey)
+	if err != nil {
+		return err
+	}
+
+	baseURL, err := serve(app, envOr("DEV_MOCK_N8N_ADDR", defaultAddr), mock.Handler(), logger)
+	if err != nil {
+		return err
+	}
+	if err := register(app, Source, []string{baseURL}, logger); err != nil {
+		return err
+	}
+
+	logger.Warn("Mock n8n instance running, do not use in production", zap.String("host", baseURL))
+	return nil
+}
+
+// startSimulation serves the simulated fleet and registers its instances
+func startSimulation(app core.App, config FleetConfig, logger *zap.Logger) error {
+	fleet := NewFleet(config)
+
+	baseURL, err := serve(app, envOr("SIMULATE_ADDR", defaultSimulationAddr), fleet.Handler(), logger)
+	if err != nil {
+		return err
+	}
+	if err := register(app, SimulationSource, fleet.Hosts(baseURL), logger); err != nil {
+		return err
+	}
+
+	logger.Warn("Simulated n8n fleet running, do not use in production",
+		zap.String("host", baseURL),
+		zap.Int("instances", config.Instances),
+		zap.Int("workflows", config.Workflows),
+		zap.Float64("churn", config.Churn))
+	return nil
+}
+
+// serve runs handler on addr until the app terminates and returns its URL
+func serve(app core.App, addr string, handler http.Handler, logger *zap.Logger) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Fake n8n stopped", zap.Error(err), zap.String("addr", addr))
+		}
+	}()
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+		return e.Next()
+	})
+
+	return "http://" + listener.Addr().String(), nil
+}
+
+// register reconciles the instances of source with hosts, removing the
+// ones of a previous run with more instances
+func register(app core.App, source string, hosts []string, logger *zap.Logger) error {
+	instances := make([]discovery.Instance, len(hosts))
+	for i, host := range hosts {
+		instances[i] = discovery.Instance{
+			Host:          host,
+			APIKey:        APIKey,
+			CheckInterval: 1,
+		}
+	}
+	_, err := discovery.Reconcile(app, source, instances, true, logger)
+	return err
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,150 @@
+package devmock
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimulationSource is the managed_by value of simulated instances
+const SimulationSource = "simulation"
+
+// FleetConfig describes a simulated fleet of instances
+type FleetConfig struct {
+	Instances int
+	// Workflows per instance
+	Workflows int
+	// Churn is the share of workflows changing between two syncs, 0 to 1
+	Churn float64
+	// WebhookShare is the share of workflows started by a routed webhook
+	WebhookShare float64
+	// Seed makes the generated workflows and changes reproducible
+	Seed int64
+}
+
+// Fleet serves the instances of a simulation below /i/{n}/ of one listener
+type Fleet struct {
+	config  FleetConfig
+	servers []*Server
+}
+
+// NewFleet generates the instances and workflows of config
+func NewFleet(config FleetConfig) *Fleet {
+	fleet := &Fleet{config: config}
+
+	random := rand.New(rand.NewSource(config.Seed))
+	var mu sync.Mutex
+	for i := range config.Instances {
+		workflows := make([]map[string]any, config.Workflows)
+		for j := range workflows {
+			workflows[j] = generateWorkflow(i, j, config)
+		}
+
+		server := newServer(APIKey, workflows)
+		if config.Churn > 0 {
+			server.churn = func(workflows map[string]map[string]any) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, workflow := range workflows {
+					if random.Float64() < config.Churn {
+						changeWorkflow(workflow)
+					}
+				}
+			}
+		}
+		fleet.servers = append(fleet.servers, server)
+	}
+	return fleet
+}
+
+// Hosts returns the base URLs of the instances served at baseURL
+func (f *Fleet) Hosts(baseURL string) []string {
+	hosts := make([]string, len(f.servers))
+	for i := range f.servers {
+		hosts[i] = fmt.Sprintf("%s/i/%d", strings.TrimRight(baseURL, "/"), i)
+	}
+	return hosts
+}
+
+// Handler returns the HTTP handler serving all instances
+func (f *Fleet) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for i, server := range f.servers {
+		prefix := fmt.Sprintf("/i/%d", i)
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, server.Handler()))
+	}
+	return mux
+}
+
+// generateWorkflow returns workflow j of instance i. The first workflows
+// are started by a webhook with a route, the others by a schedule.
+func generateWorkflow(i, j int, config FleetConfig) map[string]any {
+	id := fmt.Sprintf("sim%dw%d", i, j)
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(j) * time.Minute).Format("2006-01-02T15:04:05.000Z")
+
+	var trigger map[string]any
+	if float64(j) < config.WebhookShare*float64(config.Workflows) {
+		path := fmt.Sprintf("sim-%d-%d", i, j)
+		trigger = map[string]any{
+			"id":        id + "-trigger",
+			"name":      "Webhook",
+			"type":      "n8n-nodes-base.webhook",
+			"webhookId": fmt.Sprintf("%s-webhook", id),
+			"notes":     fmt.Sprintf("route: https://sim-%d.example.localhost/%s", i, path),
+			"parameters": map[string]any{
+				"httpMethod": "POST",
+				"path":       path,
+				"options":    map[string]any{},
+			},
+		}
+	} else {
+		trigger = map[string]any{
+			"id":   id + "-trigger",
+			"name": "Schedule",
+			"type": "n8n-nodes-base.scheduleTrigger",
+			"parameters": map[string]any{
+				"rule": map[string]any{"interval": []any{map[string]any{
+					"field":      "cronExpression",
+					"expression": fmt.Sprintf("%d * * * *", j%60),
+				}}},
+			},
+		}
+	}
+
+	return map[string]any{
+		"id":        id,
+		"name":      fmt.Sprintf("Simulated workflow %d", j),
+		"active":    j%4 != 3,
+		"createdAt": created,
+		"updatedAt": created,
+		"nodes": []any{trigger, map[string]any{
+			"id":         id + "-set",
+			"name":       "Set revision",
+			"type":       "n8n-nodes-base.set",
+			"parameters": map[string]any{"revision": 0},
+		}},
+		"connections": map[string]any{
+			trigger["name"].(string): map[string]any{"main": []any{[]any{
+				map[string]any{"node": "Set revision", "type": "main", "index": 0},
+			}}},
+		},
+		"settings": map[string]any{"executionOrder": "v1"},
+		"tags":     []any{},
+	}
+}
+
+// changeWorkflow simulates an edit by bumping the revision of the Set node
+func changeWorkflow(workflow map[string]any) {
+	nodes, _ := workflow["nodes"].([]any)
+	for _, node := range nodes {
+		node, _ := node.(map[string]any)
+		if params, ok := node["parameters"].(map[string]any); ok && node["type"] == "n8n-nodes-base.set" {
+			revision, _ := params["revision"].(int)
+			params["revision"] = revision + 1
+		}
+	}
+	workflow["updatedAt"] = timestamp()
+}
@@ -0,0 +1,42 @@
+package devmock
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sistemica/n8n-manager-backend/n8n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFleet(t *testing.T) {
+	fleet := NewFleet(FleetConfig{Instances: 3, Workflows: 20, Churn: 0.5, WebhookShare: 0.25, Seed: 1})
+	server := httptest.NewServer(fleet.Handler())
+	defer server.Close()
+
+	ctx := context.Background()
+	hosts := fleet.Hosts(server.URL)
+	require.Len(t, hosts, 3)
+
+	instance := n8n.NewInstance("sim", hosts[2], APIKey)
+	first, err := instance.GetWorkflows(ctx)
+	require.NoError(t, err)
+	require.Len(t, first, 20)
+
+	stats, err := instance.GetInstanceStats(first)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.TotalWebhooks)
+	assert.Equal(t, 15, stats.ActiveWorkflows)
+
+	second, err := instance.GetWorkflows(ctx)
+	require.NoError(t, err)
+	changed := 0
+	for i := range second {
+		if !second[i].UpdatedAt.Equal(first[i].UpdatedAt) {
+			changed++
+		}
+	}
+	assert.Greater(t, changed, 0)
+	assert.Less(t, changed, 20)
+}
@@ -0,0 +1,394 @@
+package devmock
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed fixtures/workflows.json
+var fixtureWorkflows []byte
+
+// Server is a fake n8n serving the subset of the public API, the REST
+// settings and the webhooks the manager uses. Workflows live in memory and
+// start from the embedded fixtures, or generated ones for a Fleet; changes
+// are kept until restart.
+type Server struct {
+	apiKey string
+
+	// churn is called with the workflows before they are listed, see Fleet
+	churn func(workflows map[string]map[string]any)
+
+	mu          sync.Mutex
+	workflows   map[string]map[string]any
+	tags        map[string]string
+	credentials map[string]map[string]any
+}
+
+// NewServer returns a fake n8n with the fixture workflows accepting apiKey
+func NewServer(apiKey string) (*Server, error) {
+	var workflows []map[string]any
+	if err := json.Unmarshal(fixtureWorkflows, &workflows); err != nil {
+		return nil, err
+	}
+	return newServer(apiKey, workflows), nil
+}
+
+func newServer(apiKey string, workflows []map[string]any) *Server {
+	server := &Server{
+		apiKey:      apiKey,
+		workflows:   map[string]map[string]any{},
+		tags:        map[string]string{},
+		credentials: map[string]map[string]any{},
+	}
+	for _, workflow := range workflows {
+		server.workflows[workflow["id"].(string)] = workflow
+		for _, tag := range tagList(workflow) {
+			server.tags[tag["id"]] = tag["name"]
+		}
+	}
+	return server
+}
+
+// Handler returns the HTTP handler of the fake n8n
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /rest/settings", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"executionMode":            "regular",
+			"timezone":                 "UTC",
+			"saveDataErrorExecution":   "all",
+			"saveDataSuccessExecution": "all",
+			"executionTimeout":         -1,
+			"maxExecutionTimeout":      3600,
+		}})
+	})
+	mux.HandleFunc("/webhook/", s.webhook)
+	mux.HandleFunc("/webhook-test/", s.webhook)
+
+	api := http.NewServeMux()
+	api.HandleFunc("GET /api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	api.HandleFunc("GET /api/v1/workflows", s.listWorkflows)
+	api.HandleFunc("POST /api/v1/workflows", s.createWorkflow)
+	api.HandleFunc("GET /api/v1/workflows/{id}", s.getWorkflow)
+	api.HandleFunc("PUT /api/v1/workflows/{id}", s.updateWorkflow)
+	api.HandleFunc("DELETE /api/v1/workflows/{id}", s.deleteWorkflow)
+	api.HandleFunc("POST /api/v1/workflows/{id}/activate", s.setActive(true))
+	api.HandleFunc("POST /api/v1/workflows/{id}/deactivate", s.setActive(false))
+	api.HandleFunc("PUT /api/v1/workflows/{id}/tags", s.setWorkflowTags)
+	api.HandleFunc("GET /api/v1/executions", func(w http.ResponseWriter, r *http.Request) {
+		// Nothing runs on the fake n8n
+		writeJSON(w, http.StatusOK, map[string]any{"data": []any{}, "nextCursor": nil})
+	})
+	api.HandleFunc("GET /api/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"data": []map[string]any{{
+			"id":        "1",
+			"email":     "owner@example.com",
+			"firstName": "Dev",
+			"lastName":  "Owner",
+			"role":      "global:owner",
+			"isPending": false,
+			"createdAt": "2025-01-01T00:00:00.000Z",
+		}}, "nextCursor": nil})
+	})
+	api.HandleFunc("GET /api/v1/tags", s.listTags)
+	api.HandleFunc("POST /api/v1/tags", s.createTag)
+	api.HandleFunc("PATCH /api/v1/credentials/{id}", s.updateCredential)
+	mux.Handle("/api/v1/", s.authenticate(api))
+
+	return mux
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-N8N-API-KEY") != s.apiKey {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "'X-N8N-API-KEY' header required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listWorkflows(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if value := r.URL.Query().Get("updatedAfter"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid updatedAfter"})
+			return
+		}
+		since = parsed
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.churn != nil {
+		s.churn(s.workflows)
+	}
+
+	ids := make([]string, 0, len(s.workflows))
+	for id := range s.workflows {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	data := []map[string]any{}
+	for _, id := range ids {
+		workflow := s.workflows[id]
+		if updatedAt, _ := time.Parse(time.RFC3339, workflow["updatedAt"].(string)); updatedAt.Before(since) {
+			continue
+		}
+		data = append(data, workflow)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": data, "nextCursor": nil})
+}
+
+func (s *Server) getWorkflow(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workflow, ok := s.workflows[r.PathValue("id")]
+	if !ok {
+		notFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, workflow)
+}
+
+func (s *Server) createWorkflow(w http.ResponseWriter, r *http.Request) {
+	var workflow map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "request body must be a workflow"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := timestamp()
+	workflow["id"] = newID()
+	workflow["active"] = false
+	workflow["createdAt"] = now
+	workflow["updatedAt"] = now
+	workflow["tags"] = []map[string]string{}
+	s.workflows[workflow["id"].(string)] = workflow
+	writeJSON(w, http.StatusOK, workflow)
+}
+
+func (s *Server) updateWorkflow(w http.ResponseWriter, r *http.Request) {
+	var update map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "request body must be a workflow"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workflow, ok := s.workflows[r.PathValue("id")]
+	if !ok {
+		notFound(w)
+		return
+	}
+	// Like n8n, only the importable fields can be changed
+	for _, field := range []string{"name", "nodes", "connections", "settings", "staticData"} {
+		if value, ok := update[field]; ok {
+			workflow[field] = value
+		}
+	}
+	workflow["updatedAt"] = timestamp()
+	writeJSON(w, http.StatusOK, workflow)
+}
+
+func (s *Server) deleteWorkflow(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workflow, ok := s.workflows[r.PathValue("id")]
+	if !ok {
+		notFound(w)
+		return
+	}
+	delete(s.workflows, r.PathValue("id"))
+	writeJSON(w, http.StatusOK, workflow)
+}
+
+func (s *Server) setActive(active bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		workflow, ok := s.workflows[r.PathValue("id")]
+		if !ok {
+			notFound(w)
+			return
+		}
+		workflow["active"] = active
+		workflow["updatedAt"] = timestamp()
+		writeJSON(w, http.StatusOK, workflow)
+	}
+}
+
+func (s *Server) setWorkflowTags(w http.ResponseWriter, r *http.Request) {
+	var ids []map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "request body must be a list of tag ids"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workflow, ok := s.workflows[r.PathValue("id")]
+	if !ok {
+		notFound(w)
+		return
+	}
+	tags := []map[string]string{}
+	for _, id := range ids {
+		name, ok := s.tags[id["id"]]
+		if !ok {
+			notFound(w)
+			return
+		}
+		tags = append(tags, map[string]string{"id": id["id"], "name": name})
+	}
+	workflow["tags"] = tags
+	workflow["updatedAt"] = timestamp()
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := []map[string]string{}
+	for id, name := range s.tags {
+		data = append(data, map[string]string{"id": id, "name": name})
+	}
+	slices.SortFunc(data, func(a, b map[string]string) int { return strings.Compare(a["name"], b["name"]) })
+	writeJSON(w, http.StatusOK, map[string]any{"data": data, "nextCursor": nil})
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request) {
+	var tag map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&tag); err != nil || tag["name"] == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "request body must have a name"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range s.tags {
+		if strings.EqualFold(name, tag["name"]) {
+			writeJSON(w, http.StatusConflict, map[string]string{"message": "Tag already exists"})
+			return
+		}
+	}
+	id := newID()
+	s.tags[id] = tag["name"]
+	writeJSON(w, http.StatusCreated, map[string]string{"id": id, "name": tag["name"]})
+}
+
+// updateCredential stores the credential. Unlike n8n, unknown credentials
+// are created, the fixtures have none.
+func (s *Server) updateCredential(w http.ResponseWriter, r *http.Request) {
+	var credential map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&credential); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "request body must be a credential"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	s.credentials[id] = credential
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "type": credential["type"], "updatedAt": timestamp()})
+}
+
+// webhook answers calls of the webhooks of active workflows like n8n
+// does for a webhook responding immediately
+func (s *Server) webhook(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/webhook-test/"), "/webhook/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, workflow := range s.workflows {
+		if active, _ := workflow["active"].(bool); !active {
+			continue
+		}
+		nodes, _ := workflow["nodes"].([]any)
+		for _, node := range nodes {
+			node, _ := node.(map[string]any)
+			if node["type"] != "n8n-nodes-base.webhook" {
+				continue
+			}
+			params, _ := node["parameters"].(map[string]any)
+			if params["path"] != path {
+				continue
+			}
+			method, _ := params["httpMethod"].(string)
+			if method == "" {
+				method = http.MethodGet
+			}
+			if r.Method != method && r.Method != http.MethodOptions && r.Method != http.MethodHead {
+				continue
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"message": "Workflow was started"})
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusNotFound, map[string]any{
+		"code":    404,
+		"message": "The requested webhook \"" + r.Method + " " + path + "\" is not registered.",
+	})
+}
+
+func tagList(workflow map[string]any) []map[string]string {
+	var tags []map[string]string
+	list, _ := workflow["tags"].([]any)
+	for _, item := range list {
+		tag, _ := item.(map[string]any)
+		id, _ := tag["id"].(string)
+		name, _ := tag["name"].(string)
+		tags = append(tags, map[string]string{"id": id, "name": name})
+	}
+	return tags
+}
+
+func notFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func timestamp() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// newID returns a random id in the format of n8n workflow ids
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
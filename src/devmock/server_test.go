@@ -0,0 +1,60 @@
+package devmock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sistemica/n8n-manager-backend/n8n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	mock, err := NewServer(APIKey)
+	require.NoError(t, err)
+	server := httptest.NewServer(mock.Handler())
+	defer server.Close()
+
+	ctx := context.Background()
+	instance := n8n.NewInstance("mock", server.URL, APIKey)
+
+	workflows, err := instance.GetWorkflows(ctx)
+	require.NoError(t, err)
+	require.Len(t, workflows, 4)
+	assert.True(t, instance.IsHealthy(ctx))
+
+	created, err := instance.CreateWorkflow(ctx, map[string]any{"name": "New", "nodes": []any{}, "connections": map[string]any{}})
+	require.NoError(t, err)
+	require.NoError(t, instance.ActivateWorkflow(ctx, created.WorkflowID))
+
+	changed, supported, err := instance.GetWorkflowsUpdatedAfter(ctx, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.True(t, supported)
+	require.Len(t, changed, 1)
+	assert.True(t, changed[0].Active)
+
+	tags, err := instance.SetWorkflowTags(ctx, created.WorkflowID, []string{"production", "dev"})
+	require.NoError(t, err)
+	assert.Len(t, tags, 2)
+
+	require.NoError(t, instance.UpdateCredential(ctx, "orderToken", "httpHeaderAuth", map[string]any{"name": "X-API-Key", "value": "new"}))
+	assert.Equal(t, "new", mock.credentials["orderToken"]["data"].(map[string]any)["value"])
+
+	resp, err := http.Post(server.URL+"/webhook/orders", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, instance.DeactivateWorkflow(ctx, "mockOrderWebhook"))
+	resp, err = http.Post(server.URL+"/webhook/orders", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	unauthorized := n8n.NewInstance("mock", server.URL, "wrong")
+	_, err = unauthorized.GetWorkflows(ctx)
+	assert.ErrorContains(t, err, "401")
+}
@@ -0,0 +1,212 @@
+// Package discovery keeps the instances collection in sync with external
+// sources of truth: a declarative YAML file, the containers of a Docker host
+// or the Services/Pods of a Kubernetes cluster. Instances created by a
+// source are marked with its name in managed_by, so a source only ever
+// updates or prunes its own instances.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"go.uber.org/zap"
+)
+
+// Instance is the desired state of an instance as declared by a source
+type Instance struct {
+	Host               string   `yaml:"host"`
+	APIKey             string   `yaml:"api_key"`
+	CheckInterval      int      `yaml:"check_interval_mins"`
+	IgnoreSSLErrors    bool     `yaml:"ignore_ssl_errors"`
+	MetricsEnabled     bool     `yaml:"metrics_enabled"`
+	WorkerHealthURLs   []string `yaml:"worker_health_urls"`
+	APIKeyRotationDays int      `yaml:"api_key_rotation_days"`
+	OwnerEmail         string   `yaml:"owner_email"`
+	OwnerPassword      string   `yaml:"owner_password"`
+	StoreMode          string   `yaml:"store_mode"`
+}
+
+// fields returns the record fields of the instance
+func (instance Instance) fields() map[string]any {
+	fields := map[string]any{
+		"host":                  instance.Host,
+		"api_key":               instance.APIKey,
+		"check_interval_mins":   instance.CheckInterval,
+		"ignore_ssl_errors":     instance.IgnoreSSLErrors,
+		"metrics_enabled":       instance.MetricsEnabled,
+		"api_key_rotation_days": instance.APIKeyRotationDays,
+		"owner_email":           instance.OwnerEmail,
+		"owner_password":        instance.OwnerPassword,
+		"worker_health_urls":    instance.WorkerHealthURLs,
+		"store_mode":            instance.StoreMode,
+	}
+	return fields
+}
+
+// Result summarizes a reconciliation
+type Result struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Pruned    int
+}
+
+// normalizeHost makes hosts comparable, n8n base URLs have no trailing slash
+func normalizeHost(host string) string {
+	return strings.TrimRight(strings.TrimSpace(host), "/")
+}
+
+// Reconcile creates and updates the desired instances and, if prune is
+// set, deletes the instances previously created by source that are no
+// longer desired. Instances are matched by host; an existing instance
+// created elsewhere is taken over by source.
+func Reconcile(app core.App, source string, desired []Instance, prune bool, logger *zap.Logger) (Result, error) {
+	var result Result
+
+	collection, err := app.FindCollectionByNameOrId("instances")
+	if err != nil {
+		return result, err
+	}
+
+	hosts := map[string]bool{}
+	for _, instance := range desired {
+		instance.Host = normalizeHost(instance.Host)
+		if instance.Host == "" {
+			return result, fmt.Errorf("%s: instance without host", source)
+		}
+		if hosts[instance.Host] {
+			return result, fmt.Errorf("%s: duplicate instance %s", source, instance.Host)
+		}
+		hosts[instance.Host] = true
+	}
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		for _, instance := range desired {
+			instance.Host = normalizeHost(instance.Host)
+
+			record, _ := txApp.FindFirstRecordByData(collection, "host", instance.Host)
+			isNew := record == nil
+			if isNew {
+				record = core.NewRecord(collection)
+			}
+
+			changed := isNew || record.GetString("managed_by") != source
+			for field, value := range instance.fields() {
+				if !isNew && equal(record.Get(field), value) {
+					continue
+				}
+				record.Set(field, value)
+				changed = true
+			}
+
+			if !changed {
+				result.Unchanged++
+				continue
+			}
+
+			record.Set("managed_by", source)
+			if err := txApp.Save(record); err != nil {
+				return fmt.Errorf("failed to save instance %s: %w", instance.Host, err)
+			}
+
+			if isNew {
+				result.Created++
+				logger.Info("Created instance", zap.String("source", source), zap.String("host", instance.Host))
+			} else {
+				result.Updated++
+				logger.Info("Updated instance", zap.String("source", source), zap.String("host", instance.Host))
+			}
+		}
+
+		if !prune {
+			return nil
+		}
+
+		managed, err := txApp.FindAllRecords(collection, dbx.HashExp{"managed_by": source})
+		if err != nil {
+			return err
+		}
+		for _, record := range managed {
+			if hosts[normalizeHost(record.GetString("host"))] {
+				continue
+			}
+			if err := txApp.Delete(record); err != nil {
+				return fmt.Errorf("failed to prune instance %s: %w", record.GetString("host"), err)
+			}
+			result.Pruned++
+			logger.Info("Pruned instance", zap.String("source", source), zap.String("host", record.GetString("host")))
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// equal compares a stored field value with a desired one. Values are
+// compared in their JSON form, which covers numbers and JSON fields.
+func equal(stored, desired any) bool {
+	if reflect.DeepEqual(stored, desired) {
+		return true
+	}
+
+	a, errA := json.Marshal(stored)
+	b, errB := json.Marshal(desired)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	// Unset JSON fields are stored as null
+	if string(b) == "null" && (string(a) == "null" || string(a) == `""`) {
+		return true
+	}
+	return string(a) == string(b)
+}
+
+// poll runs sync on server start and then every interval until the app
+// terminates. Errors are passed to onError and don't stop polling. Sources
+// behind the feature flag are only polled if it is enabled on server start.
+func poll(app core.App, flag string, interval time.Duration, sync func(ctx context.Context) error, onError func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		if flag != "" && !features.Enabled(se.App, flag) {
+			return se.Next()
+		}
+
+		// A failing source must not keep the manager from starting
+		if err := sync(ctx); err != nil {
+			onError(err)
+		}
+
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := sync(ctx); err != nil {
+						onError(err)
+					}
+				}
+			}
+		}()
+
+		return se.Next()
+	})
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		cancel()
+		return e.Next()
+	})
+}
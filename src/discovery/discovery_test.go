@@ -0,0 +1,40 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	assert.True(t, equal("https://n8n.example.com", "https://n8n.example.com"))
+	assert.True(t, equal(float64(5), 5))
+	assert.True(t, equal(types.JSONRaw(`["http://w1/healthz"]`), []string{"http://w1/healthz"}))
+	assert.True(t, equal(types.JSONRaw(nil), []string(nil)))
+	assert.False(t, equal(float64(5), 10))
+	assert.False(t, equal(types.JSONRaw(`["a"]`), []string{"b"}))
+}
+
+func TestLoadInstancesFile(t *testing.T) {
+	instances, err := loadInstancesFile([]byte(`
+instances:
+  - host: https://n8n.example.com
+    api_key: env:N8N_API_KEY
+    check_interval_mins: 5
+    worker_health_urls: [http://worker:5678/healthz]
+`))
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "env:N8N_API_KEY", instances[0].APIKey)
+	assert.Equal(t, 5, instances[0].CheckInterval)
+	assert.Equal(t, []string{"http://worker:5678/healthz"}, instances[0].WorkerHealthURLs)
+
+	instances, err = loadInstancesFile(nil)
+	require.NoError(t, err)
+	assert.Empty(t, instances)
+
+	_, err = loadInstancesFile([]byte("instances:\n  - host: x\n    apikey: typo\n"))
+	assert.Error(t, err)
+}
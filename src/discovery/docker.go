@@ -0,0 +1,280 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"go.uber.org/zap"
+)
+
+// DockerSource is the managed_by value of instances discovered via Docker
+const DockerSource = "docker"
+
+// Container labels, following Traefik's "<prefix>.enable" convention
+const (
+	labelPrefix       = "n8n-manager."
+	labelEnable       = labelPrefix + "enable"
+	labelHost         = labelPrefix + "host"
+	labelScheme       = labelPrefix + "scheme"
+	labelPort         = labelPrefix + "port"
+	labelNetwork      = labelPrefix + "network"
+	labelAPIKeySecret = labelPrefix + "api-key-secret"
+	labelInterval     = labelPrefix + "interval"
+	labelMetrics      = labelPrefix + "metrics"
+	labelIgnoreSSL    = labelPrefix + "ignore-ssl-errors"
+)
+
+const (
+	defaultDockerHost              = "unix:///var/run/docker.sock"
+	defaultDockerDiscoveryInterval = 30 * time.Second
+	defaultN8NPort                 = "5678"
+)
+
+// dockerContainer is the subset of the Docker container list response we use
+type dockerContainer struct {
+	ID              string            `json:"Id"`
+	Names           []string          `json:"Names"`
+	Labels          map[string]string `json:"Labels"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// dockerClient talks to the Docker Engine API
+type dockerClient struct {
+	http    *http.Client
+	baseURL string
+}
+
+// newDockerClient creates a client for DOCKER_HOST, either a unix socket
+// (the default) or tcp://host:port
+func newDockerClient(dockerHost string) (*dockerClient, error) {
+	u, err := url.Parse(dockerHost)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCKER_HOST: %w", err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &dockerClient{
+			http:    &http.Client{Timeout: 10 * time.Second, Transport: transport},
+			baseURL: "http://docker",
+		}, nil
+	case "tcp", "http":
+		return &dockerClient{
+			http:    &http.Client{Timeout: 10 * time.Second},
+			baseURL: "http://" + u.Host,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported DOCKER_HOST scheme %q", u.Scheme)
+	}
+}
+
+// listContainers returns the running containers with n8n-manager.enable=true
+func (c *dockerClient) listContainers(ctx context.Context) ([]dockerContainer, error) {
+	filters, err := json.Marshal(map[string][]string{"label": {labelEnable + "=true"}})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/containers/json?filters="+url.QueryEscape(string(filters)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var containers []dockerContainer
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	return containers, nil
+}
+
+// name returns the container name without the leading slash
+func (c dockerContainer) name() string {
+	if len(c.Names) > 0 {
+		return strings.TrimPrefix(c.Names[0], "/")
+	}
+	return c.ID[:min(12, len(c.ID))]
+}
+
+// instanceFromContainer builds the desired instance from container labels.
+// Without an explicit host the base URL is built from the container IP in
+// the selected network (label or defaultNetwork) and the port label.
+func instanceFromContainer(c dockerContainer, defaultNetwork string) (Instance, error) {
+	labels := c.Labels
+
+	apiKey := labels[labelAPIKeySecret]
+	if apiKey == "" {
+		return Instance{}, fmt.Errorf("missing label %s", labelAPIKeySecret)
+	}
+
+	host := labels[labelHost]
+	if host == "" {
+		address, err := containerAddress(c, firstNonEmpty(labels[labelNetwork], defaultNetwork))
+		if err != nil {
+			return Instance{}, err
+		}
+		scheme := firstNonEmpty(labels[labelScheme], "http")
+		port := firstNonEmpty(labels[labelPort], defaultN8NPort)
+		host = scheme + "://" + net.JoinHostPort(address, port)
+	}
+
+	instance := Instance{
+		Host:            host,
+		APIKey:          apiKey,
+		MetricsEnabled:  labels[labelMetrics] == "true",
+		IgnoreSSLErrors: labels[labelIgnoreSSL] == "true",
+	}
+	if interval := labels[labelInterval]; interval != "" {
+		minutes, err := strconv.Atoi(interval)
+		if err != nil {
+			return Instance{}, fmt.Errorf("invalid label %s: %w", labelInterval, err)
+		}
+		instance.CheckInterval = minutes
+	}
+
+	return instance, nil
+}
+
+// containerAddress returns the IP of the container in network, or in its
+// only network if none is selected
+func containerAddress(c dockerContainer, network string) (string, error) {
+	networks := c.NetworkSettings.Networks
+	if network != "" {
+		settings, ok := networks[network]
+		if !ok || settings.IPAddress == "" {
+			return "", fmt.Errorf("container is not attached to network %s", network)
+		}
+		return settings.IPAddress, nil
+	}
+
+	if len(networks) != 1 {
+		return "", fmt.Errorf("container has %d networks, set the %s label", len(networks), labelNetwork)
+	}
+	for _, settings := range networks {
+		if settings.IPAddress != "" {
+			return settings.IPAddress, nil
+		}
+	}
+	return "", fmt.Errorf("container has no IP address")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// dockerDiscovery registers labeled containers as instances
+type dockerDiscovery struct {
+	app     core.App
+	logger  *zap.Logger
+	client  *dockerClient
+	network string
+}
+
+// sync reconciles the instances with the labeled containers. Instances of
+// stopped or removed containers are deregistered.
+func (d *dockerDiscovery) sync(ctx context.Context) error {
+	containers, err := d.client.listContainers(ctx)
+	if err != nil {
+		// Don't prune anything if Docker is unreachable
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	desired := make([]Instance, 0, len(containers))
+	for _, container := range containers {
+		instance, err := instanceFromContainer(container, d.network)
+		if err != nil {
+			d.logger.Warn("Skipping container",
+				zap.String("container", container.name()),
+				zap.Error(err))
+			continue
+		}
+		desired = append(desired, instance)
+	}
+
+	result, err := Reconcile(d.app, DockerSource, desired, true, d.logger)
+	if err != nil {
+		return err
+	}
+
+	if result.Created+result.Updated+result.Pruned > 0 {
+		d.logger.Info("Reconciled Docker instances",
+			zap.Int("created", result.Created),
+			zap.Int("updated", result.Updated),
+			zap.Int("pruned", result.Pruned))
+	}
+	return nil
+}
+
+// InitDocker discovers n8n containers via the Docker API if enabled.
+//
+// Environment variables:
+//
+//	FEATURE_DOCKER_DISCOVERY=true (or DOCKER_DISCOVERY=true): enable the discovery, see features
+//	DOCKER_HOST: the Docker API, defaults to unix:///var/run/docker.sock
+//	DOCKER_DISCOVERY_NETWORK: default network to reach containers in
+//	DOCKER_DISCOVERY_INTERVAL: how often to list containers (default 30s)
+//
+// Containers are matched by the label n8n-manager.enable=true and configured
+// by further labels: n8n-manager.api-key-secret (required, a secret
+// reference), n8n-manager.host or n8n-manager.scheme/port/network,
+// n8n-manager.interval, n8n-manager.metrics and n8n-manager.ignore-ssl-errors.
+func InitDocker(app core.App, logger *zap.Logger) {
+	client, err := newDockerClient(firstNonEmpty(os.Getenv("DOCKER_HOST"), defaultDockerHost))
+	if err != nil {
+		logger.Error("Docker discovery disabled", zap.Error(err))
+		return
+	}
+
+	interval := defaultDockerDiscoveryInterval
+	if d, err := time.ParseDuration(os.Getenv("DOCKER_DISCOVERY_INTERVAL")); err == nil && d > 0 {
+		interval = d
+	}
+
+	discovery := &dockerDiscovery{
+		app:     app,
+		logger:  logger,
+		client:  client,
+		network: os.Getenv("DOCKER_DISCOVERY_NETWORK"),
+	}
+
+	poll(app, features.DockerDiscovery, interval, discovery.sync, func(err error) {
+		logger.Error("Docker discovery failed", zap.Error(err))
+	})
+}
@@ -0,0 +1,78 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func container(labels map[string]string, networks map[string]string) dockerContainer {
+	c := dockerContainer{ID: "0123456789abcdef", Names: []string{"/n8n"}, Labels: labels}
+	c.NetworkSettings.Networks = map[string]struct {
+		IPAddress string `json:"IPAddress"`
+	}{}
+	for name, ip := range networks {
+		c.NetworkSettings.Networks[name] = struct {
+			IPAddress string `json:"IPAddress"`
+		}{IPAddress: ip}
+	}
+	return c
+}
+
+func TestInstanceFromContainer(t *testing.T) {
+	labels := map[string]string{
+		labelEnable:       "true",
+		labelAPIKeySecret: "env:N8N_API_KEY",
+		labelInterval:     "2",
+		labelMetrics:      "true",
+	}
+
+	instance, err := instanceFromContainer(container(labels, map[string]string{"n8n": "172.18.0.5"}), "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://172.18.0.5:5678", instance.Host)
+	assert.Equal(t, "env:N8N_API_KEY", instance.APIKey)
+	assert.Equal(t, 2, instance.CheckInterval)
+	assert.True(t, instance.MetricsEnabled)
+
+	// Multiple networks need a selection
+	multi := container(labels, map[string]string{"n8n": "172.18.0.5", "proxy": "172.19.0.7"})
+	_, err = instanceFromContainer(multi, "")
+	assert.Error(t, err)
+
+	instance, err = instanceFromContainer(multi, "proxy")
+	require.NoError(t, err)
+	assert.Equal(t, "http://172.19.0.7:5678", instance.Host)
+
+	// An explicit host wins
+	labels[labelHost] = "https://n8n.example.com"
+	instance, err = instanceFromContainer(container(labels, nil), "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://n8n.example.com", instance.Host)
+
+	delete(labels, labelAPIKeySecret)
+	_, err = instanceFromContainer(container(labels, nil), "")
+	assert.ErrorContains(t, err, labelAPIKeySecret)
+}
+
+func TestDockerListContainers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/containers/json", r.URL.Path)
+		assert.Contains(t, r.URL.Query().Get("filters"), labelEnable+"=true")
+		json.NewEncoder(w).Encode([]dockerContainer{container(map[string]string{labelEnable: "true"}, nil)})
+	}))
+	defer server.Close()
+
+	client, err := newDockerClient("tcp://" + strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+
+	containers, err := client.listContainers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, "n8n", containers[0].name())
+}
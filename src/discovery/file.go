@@ -0,0 +1,145 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// FileSource is the managed_by value of instances declared in the instances file
+const FileSource = "file"
+
+// defaultInstancesFile is looked up next to the binary if INSTANCES_FILE is unset
+const defaultInstancesFile = "instances.yaml"
+
+const defaultFilePollInterval = 15 * time.Second
+
+// InstancesFile is the format of the declarative instances file. It matches
+// the instances section of "config export", e.g.:
+//
+//	instances:
+//	  - host: https://n8n.example.com
+//	    api_key: env:N8N_PROD_API_KEY
+//	    check_interval_mins: 5
+type InstancesFile struct {
+	Instances []Instance `yaml:"instances"`
+}
+
+// instancesFilePath returns INSTANCES_FILE or instances.yaml next to the
+// binary if that exists, "" otherwise
+func instancesFilePath() string {
+	if path := os.Getenv("INSTANCES_FILE"); path != "" {
+		return path
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(filepath.Dir(executable), defaultInstancesFile)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// loadInstancesFile parses the instances file, rejecting unknown fields
+func loadInstancesFile(data []byte) ([]Instance, error) {
+	var file InstancesFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return file.Instances, nil
+}
+
+// fileWatcher reconciles the instances file whenever its content changes
+type fileWatcher struct {
+	app    core.App
+	logger *zap.Logger
+	path   string
+	prune  bool
+
+	lastHash [sha256.Size]byte
+}
+
+// sync reconciles the file if it changed since the last successful run.
+// Comparing the content hash also catches the symlink swaps used by
+// Kubernetes ConfigMap mounts.
+func (w *fileWatcher) sync(context.Context) error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(data)
+	if hash == w.lastHash {
+		return nil
+	}
+
+	instances, err := loadInstancesFile(data)
+	if err != nil {
+		return fmt.Errorf("invalid instances file %s: %w", w.path, err)
+	}
+
+	result, err := Reconcile(w.app, FileSource, instances, w.prune, w.logger)
+	if err != nil {
+		return err
+	}
+
+	w.lastHash = hash
+	w.logger.Info("Reconciled instances file",
+		zap.String("path", w.path),
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("unchanged", result.Unchanged),
+		zap.Int("pruned", result.Pruned))
+	return nil
+}
+
+// InitFile reconciles the declarative instances file on server start and
+// whenever it changes.
+//
+// Environment variables:
+//
+//	INSTANCES_FILE: path of the file, defaults to instances.yaml next to the binary
+//	INSTANCES_FILE_PRUNE=true: delete instances removed from the file
+//	INSTANCES_FILE_POLL_INTERVAL: how often to check for changes (default 15s)
+func InitFile(app core.App, logger *zap.Logger) {
+	path := instancesFilePath()
+	if path == "" {
+		return
+	}
+
+	interval := defaultFilePollInterval
+	if d, err := time.ParseDuration(os.Getenv("INSTANCES_FILE_POLL_INTERVAL")); err == nil && d > 0 {
+		interval = d
+	}
+
+	watcher := &fileWatcher{
+		app:    app,
+		logger: logger,
+		path:   path,
+		prune:  os.Getenv("INSTANCES_FILE_PRUNE") == "true",
+	}
+
+	poll(app, "", interval, watcher.sync, func(err error) {
+		if errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("Instances file not found", zap.String("path", path))
+			return
+		}
+		logger.Error("Failed to reconcile instances file", zap.Error(err))
+	})
+}
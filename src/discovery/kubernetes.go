@@ -0,0 +1,260 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"github.com/sistemica/n8n-manager-backend/kube"
+	"go.uber.org/zap"
+)
+
+// KubernetesSource is the managed_by value of instances discovered in Kubernetes
+const KubernetesSource = "kubernetes"
+
+// Object annotations configuring a discovered instance
+const (
+	annotationPrefix       = "n8n-manager/"
+	annotationAPIKeySecret = annotationPrefix + "api-key-secret"
+	annotationHost         = annotationPrefix + "host"
+	annotationScheme       = annotationPrefix + "scheme"
+	annotationPort         = annotationPrefix + "port"
+	annotationInterval     = annotationPrefix + "interval"
+	annotationMetrics      = annotationPrefix + "metrics"
+)
+
+const (
+	defaultKubernetesSelector = "n8n-manager/enable=true"
+	defaultKubernetesInterval = 30 * time.Second
+	defaultSecretKey          = "api-key"
+)
+
+// kubernetesDiscovery registers Services or Pods matching a label selector
+type kubernetesDiscovery struct {
+	app        core.App
+	logger     *zap.Logger
+	client     *kube.Client
+	namespaces []string
+	selector   string
+	pods       bool
+}
+
+// secretReference turns the api-key-secret annotation ("<name>[#key]") into
+// a k8s secret reference in the namespace of the object
+func secretReference(meta kube.ObjectMeta) (string, error) {
+	value := meta.Annotations[annotationAPIKeySecret]
+	if value == "" {
+		return "", fmt.Errorf("missing annotation %s", annotationAPIKeySecret)
+	}
+
+	name, key, _ := strings.Cut(value, "#")
+	if key == "" {
+		key = defaultSecretKey
+	}
+	return "k8s:" + meta.Namespace + "/" + name + "#" + key, nil
+}
+
+// instanceFromMeta applies the annotations shared by Services and Pods
+func instanceFromMeta(meta kube.ObjectMeta, address string, port int) (Instance, error) {
+	apiKey, err := secretReference(meta)
+	if err != nil {
+		return Instance{}, err
+	}
+
+	annotations := meta.Annotations
+	if p := annotations[annotationPort]; p != "" {
+		if port, err = strconv.Atoi(p); err != nil {
+			return Instance{}, fmt.Errorf("invalid annotation %s: %w", annotationPort, err)
+		}
+	}
+	if port == 0 {
+		port, _ = strconv.Atoi(defaultN8NPort)
+	}
+
+	host := annotations[annotationHost]
+	if host == "" {
+		scheme := firstNonEmpty(annotations[annotationScheme], "http")
+		host = scheme + "://" + net.JoinHostPort(address, strconv.Itoa(port))
+	}
+
+	instance := Instance{
+		Host:           host,
+		APIKey:         apiKey,
+		MetricsEnabled: annotations[annotationMetrics] == "true",
+	}
+	if interval := annotations[annotationInterval]; interval != "" {
+		minutes, err := strconv.Atoi(interval)
+		if err != nil {
+			return Instance{}, fmt.Errorf("invalid annotation %s: %w", annotationInterval, err)
+		}
+		instance.CheckInterval = minutes
+	}
+
+	return instance, nil
+}
+
+// instanceFromService addresses the Service by its cluster DNS name, using
+// the port named "http" or the first port
+func instanceFromService(service kube.Service) (Instance, error) {
+	port := 0
+	for _, p := range service.Spec.Ports {
+		if p.Name == "http" {
+			port = p.Port
+			break
+		}
+		if port == 0 {
+			port = p.Port
+		}
+	}
+
+	address := service.Metadata.Name + "." + service.Metadata.Namespace + ".svc"
+	return instanceFromMeta(service.Metadata, address, port)
+}
+
+// instanceFromPod addresses the Pod by its IP
+func instanceFromPod(pod kube.Pod) (Instance, error) {
+	port := 0
+	for _, container := range pod.Spec.Containers {
+		for _, p := range container.Ports {
+			if p.Name == "http" {
+				return instanceFromMeta(pod.Metadata, pod.Status.PodIP, p.ContainerPort)
+			}
+			if port == 0 {
+				port = p.ContainerPort
+			}
+		}
+	}
+
+	return instanceFromMeta(pod.Metadata, pod.Status.PodIP, port)
+}
+
+// list returns the desired instances of a namespace
+func (d *kubernetesDiscovery) list(ctx context.Context, namespace string) ([]Instance, error) {
+	query := "?labelSelector=" + url.QueryEscape(d.selector)
+	var instances []Instance
+
+	if d.pods {
+		var pods struct {
+			Items []kube.Pod `json:"items"`
+		}
+		if err := d.client.Get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/pods"+query, &pods); err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			if !pod.Ready() {
+				continue
+			}
+			instance, err := instanceFromPod(pod)
+			if err != nil {
+				d.logger.Warn("Skipping pod", zap.String("pod", namespace+"/"+pod.Metadata.Name), zap.Error(err))
+				continue
+			}
+			instances = append(instances, instance)
+		}
+		return instances, nil
+	}
+
+	var services struct {
+		Items []kube.Service `json:"items"`
+	}
+	if err := d.client.Get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/services"+query, &services); err != nil {
+		return nil, err
+	}
+	for _, service := range services.Items {
+		instance, err := instanceFromService(service)
+		if err != nil {
+			d.logger.Warn("Skipping service", zap.String("service", namespace+"/"+service.Metadata.Name), zap.Error(err))
+			continue
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// sync reconciles the instances with the matching objects of all namespaces.
+// The in-cluster client is created on the first sync, when the discovery
+// turned out to be enabled.
+func (d *kubernetesDiscovery) sync(ctx context.Context) error {
+	if d.client == nil {
+		client, err := kube.InCluster()
+		if err != nil {
+			return err
+		}
+		d.client = client
+		if len(d.namespaces) == 0 {
+			d.namespaces = []string{client.Namespace}
+		}
+	}
+
+	var desired []Instance
+	for _, namespace := range d.namespaces {
+		instances, err := d.list(ctx, namespace)
+		if err != nil {
+			// Don't prune anything if a namespace can't be listed
+			return fmt.Errorf("failed to list namespace %s: %w", namespace, err)
+		}
+		desired = append(desired, instances...)
+	}
+
+	result, err := Reconcile(d.app, KubernetesSource, desired, true, d.logger)
+	if err != nil {
+		return err
+	}
+
+	if result.Created+result.Updated+result.Pruned > 0 {
+		d.logger.Info("Reconciled Kubernetes instances",
+			zap.Int("created", result.Created),
+			zap.Int("updated", result.Updated),
+			zap.Int("pruned", result.Pruned))
+	}
+	return nil
+}
+
+// InitKubernetes discovers n8n Services or Pods in the cluster if enabled.
+// The service account needs list permissions on the discovered kind and get
+// on the referenced Secrets.
+//
+// Environment variables:
+//
+//	FEATURE_KUBERNETES_DISCOVERY=true (or K8S_DISCOVERY=true): enable the discovery, see features
+//	K8S_DISCOVERY_NAMESPACES: comma separated namespaces, defaults to the own namespace
+//	K8S_DISCOVERY_SELECTOR: label selector (default n8n-manager/enable=true)
+//	K8S_DISCOVERY_KIND: "services" (default) or "pods"
+//	K8S_DISCOVERY_INTERVAL: how often to list objects (default 30s)
+//
+// Objects are configured by annotations: n8n-manager/api-key-secret
+// (required, "<secret name>[#key]" in the object's namespace, key defaults
+// to api-key), n8n-manager/host or n8n-manager/scheme and port,
+// n8n-manager/interval and n8n-manager/metrics.
+func InitKubernetes(app core.App, logger *zap.Logger) {
+	var namespaces []string
+	for _, namespace := range strings.Split(os.Getenv("K8S_DISCOVERY_NAMESPACES"), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	interval := defaultKubernetesInterval
+	if d, err := time.ParseDuration(os.Getenv("K8S_DISCOVERY_INTERVAL")); err == nil && d > 0 {
+		interval = d
+	}
+
+	discovery := &kubernetesDiscovery{
+		app:        app,
+		logger:     logger,
+		namespaces: namespaces,
+		selector:   firstNonEmpty(os.Getenv("K8S_DISCOVERY_SELECTOR"), defaultKubernetesSelector),
+		pods:       os.Getenv("K8S_DISCOVERY_KIND") == "pods",
+	}
+
+	poll(app, features.KubernetesDiscovery, interval, discovery.sync, func(err error) {
+		logger.Error("Kubernetes discovery failed", zap.Error(err))
+	})
+}
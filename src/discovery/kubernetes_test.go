@@ -0,0 +1,51 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/sistemica/n8n-manager-backend/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceFromService(t *testing.T) {
+	var service kube.Service
+	service.Metadata = kube.ObjectMeta{
+		Name:        "n8n",
+		Namespace:   "automation",
+		Annotations: map[string]string{annotationAPIKeySecret: "n8n-credentials", annotationInterval: "3"},
+	}
+	service.Spec.Ports = []kube.ServicePort{{Name: "metrics", Port: 9090}, {Name: "http", Port: 80}}
+
+	instance, err := instanceFromService(service)
+	require.NoError(t, err)
+	assert.Equal(t, "http://n8n.automation.svc:80", instance.Host)
+	assert.Equal(t, "k8s:automation/n8n-credentials#api-key", instance.APIKey)
+	assert.Equal(t, 3, instance.CheckInterval)
+
+	service.Metadata.Annotations[annotationAPIKeySecret] = "n8n-credentials#N8N_API_KEY"
+	service.Metadata.Annotations[annotationHost] = "https://n8n.example.com"
+	instance, err = instanceFromService(service)
+	require.NoError(t, err)
+	assert.Equal(t, "https://n8n.example.com", instance.Host)
+	assert.Equal(t, "k8s:automation/n8n-credentials#N8N_API_KEY", instance.APIKey)
+
+	delete(service.Metadata.Annotations, annotationAPIKeySecret)
+	_, err = instanceFromService(service)
+	assert.ErrorContains(t, err, annotationAPIKeySecret)
+}
+
+func TestInstanceFromPod(t *testing.T) {
+	var pod kube.Pod
+	pod.Metadata = kube.ObjectMeta{
+		Name:        "n8n-0",
+		Namespace:   "automation",
+		Annotations: map[string]string{annotationAPIKeySecret: "n8n-credentials"},
+	}
+	pod.Status.PodIP = "10.1.2.3"
+
+	// Without declared ports the n8n default port is used
+	instance, err := instanceFromPod(pod)
+	require.NoError(t, err)
+	assert.Equal(t, "http://10.1.2.3:5678", instance.Host)
+}
@@ -0,0 +1,148 @@
+// Package environments groups instances into environments such as
+// development, staging and production. The environment of an instance sets
+// the severity of its outage alerts and where its workflows are promoted
+// to, list and stats endpoints can be narrowed to one with ?environment=.
+package environments
+
+import (
+	"net/http"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"github.com/sistemica/n8n-manager-backend/trash"
+)
+
+// Collection stores the environments
+const Collection = "environments"
+
+// QueryParam narrows list and stats endpoints to an environment, by name or id
+const QueryParam = "environment"
+
+// SeverityLog is the alert severity of environments whose outages are only
+// logged, e.g. development
+const SeverityLog = "log"
+
+// Find returns the environment with the given id or name
+func Find(app core.App, nameOrId string) (*core.Record, error) {
+	record, err := app.FindRecordById(Collection, nameOrId)
+	if err == nil {
+		return record, nil
+	}
+	return app.FindFirstRecordByData(Collection, "name", nameOrId)
+}
+
+// Of returns the environment of an instances record, nil if it has none
+func Of(app core.App, instance *core.Record) *core.Record {
+	id := instance.GetString("environment")
+	if id == "" {
+		return nil
+	}
+	record, _ := app.FindRecordById(Collection, id)
+	return record
+}
+
+// NameOf returns the name of the environment of an instances record, empty
+// if it has none
+func NameOf(app core.App, instance *core.Record) string {
+	if environment := Of(app, instance); environment != nil {
+		return environment.GetString("name")
+	}
+	return ""
+}
+
+// AlertSeverity returns the severity outages of an instance are alerted
+// with, SeverityLog if they should only be logged. Instances without
+// environment alert with a warning.
+func AlertSeverity(app core.App, instance *core.Record) string {
+	if environment := Of(app, instance); environment != nil {
+		if severity := environment.GetString("alert_severity"); severity != "" {
+			return severity
+		}
+	}
+	return notify.SeverityWarning
+}
+
+// InstanceIDs returns the ids of the instances in an environment
+func InstanceIDs(app core.App, environment *core.Record) ([]string, error) {
+	instances, err := app.FindAllRecords("instances", dbx.HashExp{"environment": environment.Id}, trash.NotDeleted)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.Id)
+	}
+	return ids, nil
+}
+
+// Filter returns an expression limiting column, holding instance ids, to the
+// environment requested with ?environment=. It returns nil without the query
+// parameter and a 400 error for unknown environments.
+func Filter(e *core.RequestEvent, column string) (dbx.Expression, error) {
+	name := e.Request.URL.Query().Get(QueryParam)
+	if name == "" {
+		return nil, nil
+	}
+
+	environment, err := Find(e.App, name)
+	if err != nil {
+		return nil, apis.NewBadRequestError("Unknown environment "+name, nil)
+	}
+	ids, err := InstanceIDs(e.App, environment)
+	if err != nil {
+		return nil, apis.NewInternalServerError("Failed to fetch instances", err)
+	}
+
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	return dbx.In(column, values...), nil
+}
+
+// Target is an instance workflows can be promoted to
+type Target struct {
+	ID   string `json:"id"`
+	Host string `json:"host"`
+}
+
+// PromotionTargets returns the instances of the environment the environment
+// of instance promotes to, none if either isn't set
+func PromotionTargets(app core.App, instance *core.Record) ([]*core.Record, error) {
+	environment := Of(app, instance)
+	if environment == nil || environment.GetString("promotes_to") == "" {
+		return []*core.Record{}, nil
+	}
+	return app.FindAllRecords("instances", dbx.HashExp{"environment": environment.GetString("promotes_to")}, trash.NotDeleted)
+}
+
+// InitRoutes registers the promotion targets endpoint
+func InitRoutes(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/instances/{id}/promotion-targets", promotionTargetsHandler).
+			Bind(apis.RequireAuth())
+
+		return se.Next()
+	})
+}
+
+// promotionTargetsHandler lists the instances workflows of an instance may
+// be promoted to
+func promotionTargetsHandler(e *core.RequestEvent) error {
+	instance, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	records, err := PromotionTargets(e.App, instance)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to fetch instances", err)
+	}
+	targets := make([]Target, 0, len(records))
+	for _, record := range records {
+		targets = append(targets, Target{ID: record.Id, Host: record.GetString("host")})
+	}
+	return e.JSON(http.StatusOK, targets)
+}
@@ -0,0 +1,79 @@
+package environments
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	app := testutil.NewApp(t)
+	production, err := Find(app, "production")
+	require.NoError(t, err)
+	empty := testutil.Create(t, app, Collection, map[string]any{"name": "qa"})
+	prod := testutil.Create(t, app, "instances", map[string]any{"host": "prod.example.com", "environment": production.Id})
+	testutil.Create(t, app, "instances", map[string]any{"host": "trashed.example.com", "environment": production.Id, "deleted_at": "2025-06-02 12:00:00.000Z"})
+	testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+
+	// filter returns the instances ?environment=name narrows to, all
+	// without the query parameter
+	filter := func(name string) ([]string, error) {
+		e := &core.RequestEvent{}
+		e.App = app
+		e.Request = httptest.NewRequest(http.MethodGet, "/api/health/instances?"+QueryParam+"="+name, nil)
+		expression, err := Filter(e, "id")
+		if err != nil {
+			return nil, err
+		}
+		instances, err := app.FindAllRecords("instances", expression)
+		require.NoError(t, err)
+		ids := []string{}
+		for _, instance := range instances {
+			ids = append(ids, instance.Id)
+		}
+		return ids, nil
+	}
+
+	ids, err := filter("production")
+	require.NoError(t, err)
+	assert.Equal(t, []string{prod.Id}, ids, "trashed instances aren't in the environment")
+
+	ids, err = filter(production.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{prod.Id}, ids, "environments are found by id too")
+
+	ids, err = filter(empty.Id)
+	require.NoError(t, err)
+	assert.Empty(t, ids, "an environment without instances matches nothing")
+
+	ids, err = filter("")
+	require.NoError(t, err)
+	assert.Len(t, ids, 3)
+
+	_, err = filter("unknown")
+	var apiErr *router.ApiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+}
+
+func TestAlertSeverity(t *testing.T) {
+	app := testutil.NewApp(t)
+	production, err := Find(app, "production")
+	require.NoError(t, err)
+	unset := testutil.Create(t, app, Collection, map[string]any{"name": "qa"})
+	instance := func(environment string) *core.Record {
+		return testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com", "environment": environment})
+	}
+
+	assert.Equal(t, notify.SeverityCritical, AlertSeverity(app, instance(production.Id)))
+	assert.Equal(t, notify.SeverityWarning, AlertSeverity(app, instance(unset.Id)), "environments without a severity warn")
+	assert.Equal(t, notify.SeverityWarning, AlertSeverity(app, instance("")), "instances without environment warn")
+	assert.Equal(t, notify.SeverityWarning, AlertSeverity(app, instance("missing00000000")))
+}
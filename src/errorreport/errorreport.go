@@ -0,0 +1,63 @@
+// Package errorreport forwards errors to Sentry (or any Sentry compatible
+// service such as GlitchTip). It is enabled by setting SENTRY_DSN; the
+// optional SENTRY_ENVIRONMENT and SENTRY_RELEASE tag the reported events.
+//
+// Errors are reported from two places: every zap entry at error level or
+// above (see WrapCore) and panics recovered in cron jobs (see Recover).
+package errorreport
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+)
+
+// flushTimeout bounds how long shutdown waits for pending events
+const flushTimeout = 5 * time.Second
+
+// Enabled reports whether error reporting is configured.
+func Enabled() bool {
+	return os.Getenv("SENTRY_DSN") != ""
+}
+
+// Init sets up the Sentry client. The returned function flushes pending
+// events and should be called on shutdown.
+func Init(logger *zap.Logger) (func(), error) {
+	if !Enabled() {
+		logger.Debug("Error reporting disabled, no SENTRY_DSN configured")
+		return func() {}, nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         os.Getenv("SENTRY_DSN"),
+		Environment: os.Getenv("SENTRY_ENVIRONMENT"),
+		Release:     os.Getenv("SENTRY_RELEASE"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Error reporting enabled")
+	return func() { sentry.Flush(flushTimeout) }, nil
+}
+
+// Recover recovers a panic, logs it with a stack trace and lets the error
+// path report it. It must be deferred directly:
+//
+//	defer errorreport.Recover(logger, zap.String("job", "check-instances"))
+//
+// fields add context such as the affected instance.
+func Recover(logger *zap.Logger, fields ...zap.Field) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	fields = append(fields,
+		zap.String("panic", fmt.Sprint(r)),
+		zap.Stack("stacktrace"))
+	logger.Error("Recovered from panic", fields...)
+}
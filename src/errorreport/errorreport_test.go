@@ -0,0 +1,88 @@
+package errorreport
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// captureEvents installs a Sentry client that records events instead of sending them
+func captureEvents(t *testing.T) func() []*sentry.Event {
+	var mu sync.Mutex
+	var events []*sentry.Event
+
+	err := sentry.Init(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { sentry.CurrentHub().BindClient(nil) })
+
+	return func() []*sentry.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return events
+	}
+}
+
+func TestCoreReportsErrors(t *testing.T) {
+	events := captureEvents(t)
+
+	observed, _ := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore()).With(zap.String("sync_run_id", "run1"))
+
+	logger.Info("not reported")
+	logger.Error("Failed to sync instance",
+		zap.Error(errors.New("connection refused")),
+		zap.String("instance", "https://n8n.example.com"),
+		zap.Int("attempt", 2))
+
+	require.Len(t, events(), 1)
+	event := events()[0]
+	assert.Equal(t, sentry.LevelError, event.Level)
+	assert.Equal(t, "Failed to sync instance", event.Message)
+	assert.Equal(t, "run1", event.Tags["sync_run_id"])
+	assert.Equal(t, "https://n8n.example.com", event.Tags["instance"])
+	assert.EqualValues(t, 2, event.Extra["attempt"])
+	require.NotEmpty(t, event.Exception)
+	assert.Equal(t, "connection refused", event.Exception[0].Value)
+}
+
+func TestCoreWithoutClient(t *testing.T) {
+	sentry.CurrentHub().BindClient(nil)
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore())
+	logger.Error("still logged")
+
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestRecover(t *testing.T) {
+	events := captureEvents(t)
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore())
+
+	assert.NotPanics(t, func() {
+		defer Recover(logger, zap.String("job", "check-instances"))
+		panic("boom")
+	})
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "boom", logs.All()[0].ContextMap()["panic"])
+
+	require.Len(t, events(), 1)
+	assert.Equal(t, "check-instances", events()[0].Tags["job"])
+}
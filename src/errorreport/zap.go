@@ -0,0 +1,124 @@
+package errorreport
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// tagKeys are log fields promoted to Sentry tags so events can be searched
+// and grouped by instance or sync run.
+var tagKeys = map[string]bool{
+	"instance":    true,
+	"instance_id": true,
+	"sync_run_id": true,
+	"job":         true,
+}
+
+// reportingCore sends error level entries to Sentry.
+type reportingCore struct {
+	fields []zapcore.Field
+}
+
+// NewCore returns a zapcore.Core that reports entries at error level or
+// above. It does nothing until Init has configured a client.
+func NewCore() zapcore.Core {
+	return &reportingCore{}
+}
+
+// WrapCore is a zap.Option teeing log entries into the reporting core. Apply
+// it before redact.WrapCore so reported events are masked as well.
+func WrapCore() zap.Option {
+	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, NewCore())
+	})
+}
+
+func (c *reportingCore) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.ErrorLevel
+}
+
+func (c *reportingCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+	combined = append(combined, fields...)
+	return &reportingCore{fields: combined}
+}
+
+func (c *reportingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) && sentry.CurrentHub().Client() != nil {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *reportingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// Tee'd cores are written without a level check
+	hub := sentry.CurrentHub()
+	if !c.Enabled(entry.Level) || hub.Client() == nil {
+		return nil
+	}
+
+	event := newEvent(entry, append(append([]zapcore.Field{}, c.fields...), fields...))
+	hub.CaptureEvent(event)
+	return nil
+}
+
+func (c *reportingCore) Sync() error {
+	return nil
+}
+
+// newEvent converts a zap entry into a Sentry event. Known context fields
+// become tags, everything else is attached as extra data.
+func newEvent(entry zapcore.Entry, fields []zapcore.Field) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = sentryLevel(entry.Level)
+	event.Message = entry.Message
+	event.Logger = entry.LoggerName
+	event.Timestamp = entry.Time
+
+	enc := zapcore.NewMapObjectEncoder()
+	var err error
+	for _, field := range fields {
+		if field.Type == zapcore.ErrorType && err == nil {
+			err, _ = field.Interface.(error)
+		}
+		field.AddTo(enc)
+	}
+
+	for key, value := range enc.Fields {
+		if tagKeys[key] {
+			event.Tags[key] = fmt.Sprint(value)
+			continue
+		}
+		event.Extra[key] = value
+	}
+
+	if err == nil {
+		// Redacted errors arrive as strings, still group them as exceptions
+		if msg, ok := enc.Fields["error"].(string); ok {
+			err = errors.New(msg)
+		}
+	}
+	if err != nil {
+		event.SetException(err, 10)
+		// Group by log message rather than the (often dynamic) error text
+		event.Fingerprint = []string{"{{ default }}", entry.Message}
+	}
+
+	return event
+}
+
+func sentryLevel(level zapcore.Level) sentry.Level {
+	switch level {
+	case zapcore.ErrorLevel:
+		return sentry.LevelError
+	case zapcore.DPanicLevel, zapcore.PanicLevel, zapcore.FatalLevel:
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelWarning
+	}
+}
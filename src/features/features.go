@@ -0,0 +1,148 @@
+// Package features gates experimental subsystems behind feature flags, so
+// operators can enable them per deployment without separate builds.
+//
+// A flag is set by the environment variable FEATURE_<NAME>, e.g.
+// FEATURE_GATEWAY=false, and overridden by a record of the feature_flags
+// collection with its name, which superusers can change at runtime.
+// Subsystems started with the server, like the discovery providers, read
+// their flag when the server starts.
+package features
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Collection holds the flags overriding the environment
+const Collection = "feature_flags"
+
+// Flags of experimental subsystems
+const (
+	// Gateway proxies webhooks through the manager, see the gateway package
+	Gateway = "gateway"
+
+	// DockerDiscovery and KubernetesDiscovery register n8n containers and
+	// cluster objects as instances
+	DockerDiscovery     = "docker_discovery"
+	KubernetesDiscovery = "kubernetes_discovery"
+
+	// ExecutionSync fetches the executions of instances with
+	// executions_enabled for the workflow performance and stuck executions
+	ExecutionSync = "execution_sync"
+)
+
+// Flag describes a feature flag
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+
+	// LegacyEnv is read if FEATURE_<NAME> isn't set, for the variables that
+	// enabled the feature before it had a flag
+	LegacyEnv string
+}
+
+// Flags are the known feature flags
+var Flags = []Flag{
+	{Name: Gateway, Description: "Proxy webhooks through the manager at /gateway", Default: true},
+	{Name: DockerDiscovery, Description: "Register n8n containers found via the Docker API", LegacyEnv: "DOCKER_DISCOVERY"},
+	{Name: KubernetesDiscovery, Description: "Register n8n Services or Pods found in the cluster", LegacyEnv: "K8S_DISCOVERY"},
+	{Name: ExecutionSync, Description: "Sync executions for workflow performance and stuck executions", Default: true},
+}
+
+// Sources of the state of a flag
+const (
+	SourceDefault    = "default"
+	SourceEnv        = "env"
+	SourceCollection = "collection"
+)
+
+// State is the effective state of a flag
+type State struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"source"`
+}
+
+// find returns the known flag name
+func find(name string) (Flag, bool) {
+	for _, flag := range Flags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// EnvName returns the environment variable setting the flag name
+func EnvName(name string) string {
+	return "FEATURE_" + strings.ToUpper(name)
+}
+
+// fromEnv returns the state of flag set by the environment
+func fromEnv(flag Flag) State {
+	state := State{Name: flag.Name, Description: flag.Description, Enabled: flag.Default, Source: SourceDefault}
+	for _, name := range []string{EnvName(flag.Name), flag.LegacyEnv} {
+		if name == "" {
+			continue
+		}
+		if enabled, err := strconv.ParseBool(os.Getenv(name)); err == nil {
+			state.Enabled = enabled
+			state.Source = SourceEnv
+			break
+		}
+	}
+	return state
+}
+
+// Lookup returns the effective state of the flag name. Unknown flags are
+// disabled.
+func Lookup(app core.App, name string) State {
+	flag, ok := find(name)
+	if !ok {
+		return State{Name: name, Source: SourceDefault}
+	}
+	state := fromEnv(flag)
+
+	record, err := app.FindFirstRecordByData(Collection, "name", name)
+	if err == nil {
+		state.Enabled = record.GetBool("enabled")
+		state.Source = SourceCollection
+	}
+	return state
+}
+
+// Enabled reports whether the flag name is enabled
+func Enabled(app core.App, name string) bool {
+	return Lookup(app, name).Enabled
+}
+
+// Init rejects feature_flags records of unknown flags and registers
+// GET /api/features listing the state of all flags. Flags are changed
+// through the records of the feature_flags collection.
+func Init(app core.App) {
+	app.OnRecordValidate(Collection).BindFunc(func(e *core.RecordEvent) error {
+		if _, ok := find(e.Record.GetString("name")); !ok {
+			return validation.Errors{"name": validation.NewError("unknown_feature_flag", "Unknown feature flag")}
+		}
+		return e.Next()
+	})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/features", func(e *core.RequestEvent) error {
+			states := make([]State, 0, len(Flags))
+			for _, flag := range Flags {
+				states = append(states, Lookup(e.App, flag.Name))
+			}
+			return e.JSON(http.StatusOK, states)
+		}).Bind(apis.RequireAuth())
+		return se.Next()
+	})
+}
@@ -0,0 +1,36 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromEnv(t *testing.T) {
+	flag := Flag{Name: "docker_discovery", LegacyEnv: "DOCKER_DISCOVERY"}
+	t.Setenv("FEATURE_DOCKER_DISCOVERY", "")
+	t.Setenv("DOCKER_DISCOVERY", "")
+
+	state := fromEnv(flag)
+	assert.False(t, state.Enabled)
+	assert.Equal(t, SourceDefault, state.Source)
+
+	t.Setenv("DOCKER_DISCOVERY", "true")
+	state = fromEnv(flag)
+	assert.True(t, state.Enabled, "the legacy variable still enables the feature")
+	assert.Equal(t, SourceEnv, state.Source)
+
+	t.Setenv("FEATURE_DOCKER_DISCOVERY", "false")
+	assert.False(t, fromEnv(flag).Enabled, "the flag variable wins over the legacy one")
+
+	gateway, ok := find(Gateway)
+	assert.True(t, ok)
+	t.Setenv("FEATURE_GATEWAY", "")
+	assert.True(t, fromEnv(gateway).Enabled, "the gateway is enabled by default")
+	t.Setenv("FEATURE_GATEWAY", "0")
+	assert.False(t, fromEnv(gateway).Enabled)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "FEATURE_EXECUTION_SYNC", EnvName(ExecutionSync))
+}
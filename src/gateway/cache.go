@@ -0,0 +1,149 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// CacheHeader reports whether a response was served from the cache, HIT or
+// MISS
+const CacheHeader = "X-Cache"
+
+const (
+	defaultCacheTTL = time.Minute
+	maxCacheTTL     = 24 * time.Hour
+
+	// maxCachedBody is the largest response body that is cached
+	maxCachedBody = 1 << 20
+
+	// maxCachedResponses bounds the cached responses of all routes, further
+	// responses aren't cached until entries expire
+	maxCachedResponses = 1000
+)
+
+// cacheConfig is the "response_cache" field of routes records, e.g.
+// {"ttl": "5m"}. Only GET requests of routes with a config are cached.
+type cacheConfig struct {
+	// TTL is how long responses are served from the cache, one minute by
+	// default and at most a day
+	TTL string `json:"ttl,omitempty"`
+}
+
+// cacheOf returns the response cache config of route, ok is false if the
+// route doesn't cache responses
+func cacheOf(route *core.Record) (config cacheConfig, ok bool) {
+	if raw := route.GetString("response_cache"); raw == "" || raw == "null" {
+		return cacheConfig{}, false
+	}
+	if err := route.UnmarshalJSONField("response_cache", &config); err != nil {
+		return cacheConfig{}, false
+	}
+	return config, true
+}
+
+// ttl returns how long the responses of c are cached
+func (c cacheConfig) ttl() time.Duration {
+	return min(duration(c.TTL, defaultCacheTTL), maxCacheTTL)
+}
+
+// cachedResponse is a response of n8n kept until it expires
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// responseCache keeps responses in memory, keyed by route and URI. It is
+// emptied when the manager restarts.
+type responseCache struct {
+	mu       sync.Mutex
+	entries  map[string]cachedResponse
+	prunedAt time.Time
+}
+
+// responses are the cached responses of all routes
+var responses = &responseCache{entries: map[string]cachedResponse{}}
+
+// cacheKey returns the key of the response to uri, the path and query of
+// the request, on the route with id routeId
+func cacheKey(routeId, uri string) string {
+	return routeId + "\x00" + uri
+}
+
+// get returns the response cached under key if it hasn't expired
+func (c *responseCache) get(key string, now time.Time) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	response, ok := c.entries[key]
+	if !ok || !now.Before(response.expires) {
+		return cachedResponse{}, false
+	}
+	return response, true
+}
+
+// set caches response under key, unless the cache is full
+func (c *responseCache) set(key string, response cachedResponse, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCachedResponses && now.Sub(c.prunedAt) >= time.Second {
+		for k, cached := range c.entries {
+			if !now.Before(cached.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.prunedAt = now
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCachedResponses {
+		return
+	}
+	c.entries[key] = response
+}
+
+// dropRoute removes the cached responses of the route with id routeId
+func (c *responseCache) dropRoute(routeId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := cacheKey(routeId, "")
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cacheable reports whether resp may be served to other senders. Only
+// successful responses without cookies are cached, and n8n can opt out with
+// Cache-Control.
+func cacheable(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK || len(resp.Header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	for _, value := range resp.Header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-store", "no-cache", "private":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// bindCacheInvalidation drops the cached responses of routes when they are
+// changed or deleted
+func bindCacheInvalidation(app core.App) {
+	drop := func(e *core.RecordEvent) error {
+		responses.dropRoute(e.Record.Id)
+		return e.Next()
+	}
+	app.OnRecordAfterUpdateSuccess("routes").BindFunc(drop)
+	app.OnRecordAfterDeleteSuccess("routes").BindFunc(drop)
+}
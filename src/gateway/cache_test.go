@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+	cache := &responseCache{entries: map[string]cachedResponse{}}
+	now := time.Now()
+
+	cache.set(cacheKey("r1", "/rates?currency=eur"), cachedResponse{status: 200, body: []byte("eur"), expires: now.Add(time.Minute)}, now)
+	cache.set(cacheKey("r2", "/rates?currency=eur"), cachedResponse{status: 200, body: []byte("other"), expires: now.Add(time.Minute)}, now)
+
+	response, ok := cache.get(cacheKey("r1", "/rates?currency=eur"), now)
+	assert.True(t, ok)
+	assert.Equal(t, "eur", string(response.body))
+
+	_, ok = cache.get(cacheKey("r1", "/rates?currency=usd"), now)
+	assert.False(t, ok, "the query is part of the key")
+
+	_, ok = cache.get(cacheKey("r1", "/rates?currency=eur"), now.Add(time.Minute))
+	assert.False(t, ok, "expired responses aren't served")
+
+	cache.dropRoute("r1")
+	_, ok = cache.get(cacheKey("r1", "/rates?currency=eur"), now)
+	assert.False(t, ok)
+	_, ok = cache.get(cacheKey("r2", "/rates?currency=eur"), now)
+	assert.True(t, ok, "other routes are kept")
+}
+
+func TestCacheable(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		want   bool
+	}{
+		{"ok", http.StatusOK, http.Header{}, true},
+		{"public", http.StatusOK, http.Header{"Cache-Control": {"public, max-age=60"}}, true},
+		{"not found", http.StatusNotFound, http.Header{}, false},
+		{"cookie", http.StatusOK, http.Header{"Set-Cookie": {"session=1"}}, false},
+		{"no-store", http.StatusOK, http.Header{"Cache-Control": {"No-Store"}}, false},
+		{"private", http.StatusOK, http.Header{"Cache-Control": {"max-age=60, private"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cacheable(&http.Response{StatusCode: tt.status, Header: tt.header}))
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	assert.Equal(t, time.Minute, cacheConfig{}.ttl())
+	assert.Equal(t, 5*time.Minute, cacheConfig{TTL: "5m"}.ttl())
+	assert.Equal(t, 24*time.Hour, cacheConfig{TTL: "72h"}.ttl())
+}
@@ -0,0 +1,270 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"go.uber.org/zap"
+)
+
+// DeadLettersCollection stores the failed deliveries of gateway routes
+const DeadLettersCollection = "dead_letters"
+
+// RetryDeadLettersJob is the id of the cron job retrying dead letters
+const RetryDeadLettersJob = "retry-dead-letters"
+
+// States of a dead letter
+const (
+	// StatePending letters are retried once next_attempt is due
+	StatePending = "pending"
+	// StateDelivered letters were accepted by n8n on a retry
+	StateDelivered = "delivered"
+	// StateFailed letters used up their attempts
+	StateFailed = "failed"
+	// StateExpired letters weren't delivered before they expired
+	StateExpired = "expired"
+)
+
+// retryBatch limits the letters retried per run
+const retryBatch = 50
+
+// Policy is the "dead_letter" field of routes records, e.g.
+// {"max_attempts": 5, "backoff": "1m", "max_backoff": "1h", "expire_after": "72h"}.
+// Failed deliveries of routes without a policy are answered with the error.
+type Policy struct {
+	// MaxAttempts counts all deliveries including the first, 5 by default
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Backoff is the delay before the first retry, doubled for each
+	// further retry up to MaxBackoff. By default 1m and 1h.
+	Backoff    string `json:"backoff,omitempty"`
+	MaxBackoff string `json:"max_backoff,omitempty"`
+
+	// ExpireAfter is how long a letter is retried at most, 72h by default
+	ExpireAfter string `json:"expire_after,omitempty"`
+}
+
+// policyOf returns the dead-letter policy of route, ok is false if the
+// route has none
+func policyOf(route *core.Record) (policy Policy, ok bool) {
+	if raw := route.GetString("dead_letter"); raw == "" || raw == "null" {
+		return Policy{}, false
+	}
+	if err := route.UnmarshalJSONField("dead_letter", &policy); err != nil {
+		return Policy{}, false
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 5
+	}
+	return policy, true
+}
+
+// duration parses value, falling back to fallback if it isn't positive
+func duration(value string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// delay returns the delay after the given number of failed attempts
+func (p Policy) delay(attempts int) time.Duration {
+	backoff := duration(p.Backoff, time.Minute)
+	maxBackoff := duration(p.MaxBackoff, time.Hour)
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// expireAfter returns how long letters are retried
+func (p Policy) expireAfter() time.Duration {
+	return duration(p.ExpireAfter, 72*time.Hour)
+}
+
+// storeDeadLetter queues a failed delivery of route for a retry
+func storeDeadLetter(app core.App, route *core.Record, delivery Delivery, status int, message string, policy Policy) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(DeadLettersCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	letter := core.NewRecord(collection)
+	letter.Set("route", route.Id)
+	letter.Set("method", delivery.Method)
+	letter.Set("uri", delivery.URI)
+	letter.Set("headers", delivery.Header)
+	if utf8.Valid(delivery.Body) {
+		letter.Set("body", string(delivery.Body))
+	} else {
+		letter.Set("body", base64.StdEncoding.EncodeToString(delivery.Body))
+		letter.Set("body_base64", true)
+	}
+	letter.Set("attempts", 1)
+	letter.Set("status", status)
+	letter.Set("error", message)
+	letter.Set("expires", now.Add(policy.expireAfter()))
+	if policy.MaxAttempts > 1 {
+		letter.Set("state", StatePending)
+		letter.Set("next_attempt", now.Add(policy.delay(1)))
+	} else {
+		letter.Set("state", StateFailed)
+	}
+
+	if err := app.Save(letter); err != nil {
+		return nil, err
+	}
+	return letter, nil
+}
+
+// retry delivers letter again and updates its state. Responses below 500
+// count as delivered, retrying wouldn't change them.
+func retry(ctx context.Context, app core.App, letter *core.Record) error {
+	route, err := app.FindRecordById("routes", letter.GetString("route"))
+	if err != nil {
+		return fmt.Errorf("route of dead letter not found: %w", err)
+	}
+
+	delivery := Delivery{
+		Method: letter.GetString("method"),
+		URI:    letter.GetString("uri"),
+		Header: http.Header{},
+		Body:   []byte(letter.GetString("body")),
+	}
+	letter.UnmarshalJSONField("headers", &delivery.Header)
+	if letter.GetBool("body_base64") {
+		if delivery.Body, err = base64.StdEncoding.DecodeString(letter.GetString("body")); err != nil {
+			return fmt.Errorf("invalid body of dead letter: %w", err)
+		}
+	}
+
+	attempts := letter.GetInt("attempts") + 1
+	letter.Set("attempts", attempts)
+	letter.Set("last_attempt", time.Now())
+
+	resp, err := deliver(ctx, app, route, delivery)
+	switch {
+	case err != nil:
+		letter.Set("status", 0)
+		letter.Set("error", err.Error())
+	case resp.StatusCode >= http.StatusInternalServerError:
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		letter.Set("status", resp.StatusCode)
+		letter.Set("error", resp.Status)
+	default:
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		letter.Set("status", resp.StatusCode)
+		letter.Set("error", "")
+		letter.Set("state", StateDelivered)
+		letter.Set("next_attempt", nil)
+		return app.Save(letter)
+	}
+
+	policy, ok := policyOf(route)
+	if !ok || attempts >= policy.MaxAttempts {
+		letter.Set("state", StateFailed)
+		letter.Set("next_attempt", nil)
+	} else {
+		letter.Set("state", StatePending)
+		letter.Set("next_attempt", time.Now().Add(policy.delay(attempts)))
+	}
+	return app.Save(letter)
+}
+
+// initRetryCron retries the due dead letters every minute and expires the
+// ones past their expiry
+func initRetryCron(app core.App, logger *zap.Logger) {
+	app.Cron().MustAdd(RetryDeadLettersJob, "* * * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", RetryDeadLettersJob))
+
+		// The instances are expected to be down
+		if maintenance.Enabled(app, logger) || !features.Enabled(app, features.Gateway) {
+			return
+		}
+
+		now, err := types.ParseDateTime(time.Now())
+		if err != nil {
+			return
+		}
+
+		expired, err := app.FindAllRecords(DeadLettersCollection,
+			dbx.HashExp{"state": StatePending},
+			dbx.NewExp("expires != '' AND expires <= {:now}", dbx.Params{"now": now.String()}))
+		if err != nil {
+			logger.Error("Failed to fetch expired dead letters", zap.Error(err))
+			return
+		}
+		for _, letter := range expired {
+			letter.Set("state", StateExpired)
+			letter.Set("next_attempt", nil)
+			if err := app.Save(letter); err != nil {
+				logger.Error("Failed to expire dead letter", zap.String("dead_letter", letter.Id), zap.Error(err))
+			}
+		}
+
+		due, err := app.FindRecordsByFilter(DeadLettersCollection,
+			"state = {:state} && next_attempt <= {:now}", "next_attempt", retryBatch, 0,
+			dbx.Params{"state": StatePending, "now": now.String()})
+		if err != nil {
+			logger.Error("Failed to fetch due dead letters", zap.Error(err))
+			return
+		}
+		for _, letter := range due {
+			if err := retry(context.Background(), app, letter); err != nil {
+				logger.Error("Failed to retry dead letter", zap.String("dead_letter", letter.Id), zap.Error(err))
+				continue
+			}
+			logger.Info("Retried dead letter",
+				zap.String("dead_letter", letter.Id),
+				zap.String("state", letter.GetString("state")),
+				zap.Int("attempts", letter.GetInt("attempts")))
+		}
+	})
+}
+
+// RetryResult is the state of a dead letter after a manual retry
+type RetryResult struct {
+	ID          string         `json:"id"`
+	State       string         `json:"state"`
+	Attempts    int            `json:"attempts"`
+	Status      int            `json:"status"`
+	Error       string         `json:"error"`
+	NextAttempt types.DateTime `json:"next_attempt"`
+}
+
+// retryHandler retries a dead letter right away, whatever its state, and
+// returns its new state
+func retryHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	letter, err := e.App.FindRecordById(DeadLettersCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Dead letter not found", err)
+	}
+
+	if err := retry(e.Request.Context(), e.App, letter); err != nil {
+		logger.Error("Failed to retry dead letter", zap.String("dead_letter", letter.Id), zap.Error(err))
+		return apis.NewBadRequestError(err.Error(), nil)
+	}
+	return e.JSON(http.StatusOK, RetryResult{
+		ID:          letter.Id,
+		State:       letter.GetString("state"),
+		Attempts:    letter.GetInt("attempts"),
+		Status:      letter.GetInt("status"),
+		Error:       letter.GetString("error"),
+		NextAttempt: letter.GetDateTime("next_attempt"),
+	})
+}
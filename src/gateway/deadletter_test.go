@@ -0,0 +1,289 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{Backoff: "30s", MaxBackoff: "5m"}
+	assert.Equal(t, 30*time.Second, policy.delay(1))
+	assert.Equal(t, time.Minute, policy.delay(2))
+	assert.Equal(t, 2*time.Minute, policy.delay(3))
+	assert.Equal(t, 5*time.Minute, policy.delay(5), "capped at max_backoff")
+	assert.Equal(t, 5*time.Minute, policy.delay(100))
+
+	assert.Equal(t, time.Minute, Policy{}.delay(1))
+	assert.Equal(t, 72*time.Hour, Policy{}.expireAfter())
+	assert.Equal(t, time.Hour, Policy{ExpireAfter: "1h"}.expireAfter())
+}
+
+// n8nStub is an n8n instance answering with status and recording the
+// bodies it received
+type n8nStub struct {
+	*httptest.Server
+	status   atomic.Int32
+	received chan []byte
+}
+
+func newN8NStub(t *testing.T) *n8nStub {
+	stub := &n8nStub{received: make(chan []byte, 10)}
+	stub.status.Store(http.StatusOK)
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		stub.received <- body
+		w.WriteHeader(int(stub.status.Load()))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// gatewayRoute creates an instance at host and a gateway route of it with
+// the dead-letter policy
+func gatewayRoute(t *testing.T, app core.App, host string, policy Policy) *core.Record {
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": host})
+	return testutil.Create(t, app, "routes", map[string]any{
+		"instance":     instance.Id,
+		"host":         "hooks.example.com",
+		"path":         "/orders",
+		"webhook_path": "/webhook/orders",
+		"active":       true,
+		"gateway":      true,
+		"dead_letter":  policy,
+	})
+}
+
+// reload returns the stored state of letter
+func reload(t *testing.T, app core.App, letter *core.Record) *core.Record {
+	fresh, err := app.FindRecordById(DeadLettersCollection, letter.Id)
+	require.NoError(t, err)
+	return fresh
+}
+
+func TestStoreDeadLetter(t *testing.T) {
+	app := testutil.NewApp(t)
+	route := gatewayRoute(t, app, "http://n8n.example.com", Policy{})
+	delivery := Delivery{
+		Method: http.MethodPost,
+		URI:    "/orders?id=1",
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   []byte(`{"id":1}`),
+	}
+
+	before := time.Now()
+	letter, err := storeDeadLetter(app, route, delivery, http.StatusBadGateway, "502 Bad Gateway", Policy{MaxAttempts: 3, Backoff: "30s", ExpireAfter: "1h"})
+	require.NoError(t, err)
+	letter = reload(t, app, letter)
+	assert.Equal(t, route.Id, letter.GetString("route"))
+	assert.Equal(t, StatePending, letter.GetString("state"))
+	assert.Equal(t, "/orders?id=1", letter.GetString("uri"))
+	assert.Equal(t, `{"id":1}`, letter.GetString("body"))
+	assert.False(t, letter.GetBool("body_base64"))
+	assert.Equal(t, 1, letter.GetInt("attempts"))
+	assert.Equal(t, http.StatusBadGateway, letter.GetInt("status"))
+	assert.Equal(t, "502 Bad Gateway", letter.GetString("error"))
+	assert.WithinDuration(t, before.Add(30*time.Second), letter.GetDateTime("next_attempt").Time(), 5*time.Second)
+	assert.WithinDuration(t, before.Add(time.Hour), letter.GetDateTime("expires").Time(), 5*time.Second)
+	var header http.Header
+	require.NoError(t, letter.UnmarshalJSONField("headers", &header))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+
+	// Binary bodies are stored base64 encoded
+	binary := []byte{0xff, 0x00, 0xfe}
+	delivery.Body = binary
+	letter, err = storeDeadLetter(app, route, delivery, 0, "connection refused", Policy{MaxAttempts: 1})
+	require.NoError(t, err)
+	letter = reload(t, app, letter)
+	assert.True(t, letter.GetBool("body_base64"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(binary), letter.GetString("body"))
+	assert.Equal(t, StateFailed, letter.GetString("state"), "no retries are left after a single attempt")
+	assert.True(t, letter.GetDateTime("next_attempt").IsZero())
+}
+
+func TestRetry(t *testing.T) {
+	app := testutil.NewApp(t)
+	n8n := newN8NStub(t)
+	policy := Policy{MaxAttempts: 3, Backoff: "1m"}
+	route := gatewayRoute(t, app, n8n.URL, policy)
+	binary := []byte{0xff, 0x00, 0xfe}
+	store := func() *core.Record {
+		letter, err := storeDeadLetter(app, route, Delivery{Method: http.MethodPost, URI: "/orders", Header: http.Header{}, Body: binary}, 0, "connection refused", policy)
+		require.NoError(t, err)
+		return letter
+	}
+
+	// Responses below 500 are delivered, retrying wouldn't change them
+	n8n.status.Store(http.StatusNotFound)
+	letter := store()
+	require.NoError(t, retry(context.Background(), app, letter))
+	assert.Equal(t, binary, <-n8n.received, "base64 bodies are decoded")
+	letter = reload(t, app, letter)
+	assert.Equal(t, StateDelivered, letter.GetString("state"))
+	assert.Equal(t, http.StatusNotFound, letter.GetInt("status"))
+	assert.Empty(t, letter.GetString("error"))
+	assert.Equal(t, 2, letter.GetInt("attempts"))
+	assert.True(t, letter.GetDateTime("next_attempt").IsZero())
+
+	// Errors of n8n are retried with backoff until the attempts run out
+	n8n.status.Store(http.StatusServiceUnavailable)
+	letter = store()
+	before := time.Now()
+	require.NoError(t, retry(context.Background(), app, letter))
+	<-n8n.received
+	letter = reload(t, app, letter)
+	assert.Equal(t, StatePending, letter.GetString("state"))
+	assert.Equal(t, http.StatusServiceUnavailable, letter.GetInt("status"))
+	assert.Equal(t, "503 Service Unavailable", letter.GetString("error"))
+	assert.Equal(t, 2, letter.GetInt("attempts"))
+	assert.WithinDuration(t, before.Add(policy.delay(2)), letter.GetDateTime("next_attempt").Time(), 5*time.Second)
+
+	require.NoError(t, retry(context.Background(), app, letter))
+	<-n8n.received
+	letter = reload(t, app, letter)
+	assert.Equal(t, StateFailed, letter.GetString("state"))
+	assert.Equal(t, 3, letter.GetInt("attempts"))
+	assert.True(t, letter.GetDateTime("next_attempt").IsZero())
+}
+
+// runJob runs the cron job with id of app
+func runJob(t *testing.T, app core.App, id string) {
+	for _, job := range app.Cron().Jobs() {
+		if job.Id() == id {
+			job.Run()
+			return
+		}
+	}
+	t.Fatalf("cron job %s not registered", id)
+}
+
+func TestRetryCron(t *testing.T) {
+	app := testutil.NewApp(t)
+	t.Setenv(features.EnvName(features.Gateway), "true")
+	initRetryCron(app, zap.NewNop())
+	n8n := newN8NStub(t)
+	route := gatewayRoute(t, app, n8n.URL, Policy{})
+	letter := func(nextAttempt, expires time.Time) *core.Record {
+		return testutil.Create(t, app, DeadLettersCollection, map[string]any{
+			"route":        route.Id,
+			"state":        StatePending,
+			"method":       http.MethodPost,
+			"uri":          "/orders",
+			"body":         "{}",
+			"attempts":     1,
+			"next_attempt": nextAttempt,
+			"expires":      expires,
+		})
+	}
+	now := time.Now()
+	expired := letter(now.Add(-time.Minute), now.Add(-time.Second))
+	due := letter(now.Add(-time.Minute), now.Add(time.Hour))
+	later := letter(now.Add(time.Hour), now.Add(2*time.Hour))
+
+	runJob(t, app, RetryDeadLettersJob)
+
+	assert.Equal(t, StateExpired, reload(t, app, expired).GetString("state"))
+	assert.True(t, reload(t, app, expired).GetDateTime("next_attempt").IsZero())
+	assert.Equal(t, StateDelivered, reload(t, app, due).GetString("state"))
+	assert.Equal(t, StatePending, reload(t, app, later).GetString("state"))
+	assert.Equal(t, 1, reload(t, app, later).GetInt("attempts"), "letters aren't retried before they are due")
+	assert.Len(t, n8n.received, 1, "expired letters aren't retried")
+}
+
+func TestRetryHandler(t *testing.T) {
+	app := testutil.NewApp(t)
+	n8n := newN8NStub(t)
+	n8n.status.Store(http.StatusBadGateway)
+	route := gatewayRoute(t, app, n8n.URL, Policy{MaxAttempts: 5, Backoff: "1m"})
+	// Failed letters are retried too
+	letter := testutil.Create(t, app, DeadLettersCollection, map[string]any{
+		"route":    route.Id,
+		"state":    StateFailed,
+		"method":   http.MethodPost,
+		"uri":      "/orders",
+		"attempts": 1,
+	})
+
+	retryLetter := func(id string) (*httptest.ResponseRecorder, error) {
+		e := &core.RequestEvent{}
+		e.App = app
+		e.Request = httptest.NewRequest(http.MethodPost, "/api/dead-letters/"+id+"/retry", nil)
+		e.Request.SetPathValue("id", id)
+		recorder := httptest.NewRecorder()
+		e.Response = recorder
+		return recorder, retryHandler(e, zap.NewNop())
+	}
+
+	recorder, err := retryLetter(letter.Id)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var result RetryResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Equal(t, letter.Id, result.ID)
+	assert.Equal(t, StatePending, result.State)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, http.StatusBadGateway, result.Status)
+	assert.Equal(t, "502 Bad Gateway", result.Error)
+	assert.False(t, result.NextAttempt.IsZero())
+
+	_, err = retryLetter("missing00000000")
+	var apiErr *router.ApiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+}
+
+func TestHandlerQueuesDeadLetter(t *testing.T) {
+	app := testutil.NewApp(t)
+	t.Setenv("GATEWAY_TOKEN", "gateway-token")
+	n8n := newN8NStub(t)
+	n8n.status.Store(http.StatusServiceUnavailable)
+	route := gatewayRoute(t, app, n8n.URL, Policy{MaxAttempts: 3})
+
+	e := &core.RequestEvent{}
+	e.App = app
+	e.Request = httptest.NewRequest(http.MethodPost, Path+"/"+route.Id, strings.NewReader(`{"id":1}`))
+	e.Request.SetPathValue("route", route.Id)
+	e.Request.Header.Set(TokenHeader, "gateway-token")
+	e.Request.Header.Set("X-Replaced-Path", "/orders")
+	recorder := httptest.NewRecorder()
+	e.Response = recorder
+
+	require.NoError(t, handler(e, zap.NewNop()))
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+	letter, err := app.FindRecordById(DeadLettersCollection, response["dead_letter"])
+	require.NoError(t, err)
+	assert.Equal(t, StatePending, letter.GetString("state"))
+	assert.Equal(t, http.StatusServiceUnavailable, letter.GetInt("status"))
+	assert.Equal(t, `{"id":1}`, letter.GetString("body"))
+	assert.Equal(t, "/orders", letter.GetString("uri"))
+
+	// Without a policy the error is returned
+	route.Set("dead_letter", types.JSONRaw("null"))
+	require.NoError(t, app.SaveNoValidate(route))
+	recorder = httptest.NewRecorder()
+	e.Response = recorder
+	e.Request = httptest.NewRequest(http.MethodPost, Path+"/"+route.Id, strings.NewReader(`{"id":2}`))
+	e.Request.SetPathValue("route", route.Id)
+	e.Request.Header.Set(TokenHeader, "gateway-token")
+	require.NoError(t, handler(e, zap.NewNop()))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code, "the response of n8n is passed on")
+}
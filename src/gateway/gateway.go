@@ -0,0 +1,304 @@
+// Package gateway proxies the requests of routes in gateway mode to their
+// n8n instance. Traefik sends these requests to the manager instead of n8n,
+// which lets the manager act on the delivery, e.g. queue it for a retry
+// when n8n fails.
+//
+// Traefik adds the token in GATEWAY_TOKEN to every request it sends to the
+// gateway, requests without it are rejected so the edge auth can't be
+// bypassed by calling the manager directly.
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"github.com/sistemica/n8n-manager-backend/slo"
+	"github.com/sistemica/n8n-manager-backend/usage"
+	"go.uber.org/zap"
+)
+
+// Path is the gateway endpoint, followed by the id of the route
+const Path = "/api/gateway"
+
+// TokenHeader carries the gateway token from Traefik to the gateway
+const TokenHeader = "X-Gateway-Token"
+
+const (
+	// maxBodySize limits the request bodies the gateway accepts
+	maxBodySize = 10 << 20
+
+	// deliveryTimeout bounds waiting for n8n, which may only answer once
+	// the workflow finished
+	deliveryTimeout = 2 * time.Minute
+)
+
+// hopHeaders only apply to a single connection and aren't forwarded
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// client delivers requests to n8n, redirects are returned to the sender
+var client = &http.Client{
+	Timeout: deliveryTimeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Delivery is a request received by the gateway, as forwarded to n8n
+type Delivery struct {
+	Method string
+	// URI is the public path and query of the request
+	URI    string
+	Header http.Header
+	Body   []byte
+}
+
+// Token returns the token Traefik authenticates with, set in GATEWAY_TOKEN
+func Token() string {
+	return os.Getenv("GATEWAY_TOKEN")
+}
+
+// Target returns the URL the request to uri is delivered to on the instance
+// at host, with the path replaced by servicePath if set
+func Target(host, servicePath, uri string) (string, error) {
+	base, err := url.Parse(host)
+	if err != nil || base.Host == "" {
+		return "", errors.New("invalid instance host")
+	}
+	captured, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.New("invalid uri")
+	}
+
+	path := captured.Path
+	if servicePath != "" {
+		path = servicePath
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + path
+	base.RawQuery = captured.RawQuery
+	return base.String(), nil
+}
+
+// Init registers the gateway and dead-letter endpoints and the retry of
+// dead letters
+func Init(app core.App, logger *zap.Logger) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.Any(Path+"/{route}", func(e *core.RequestEvent) error {
+			return handler(e, logger)
+		})
+		se.Router.POST("/api/dead-letters/{id}/retry", func(e *core.RequestEvent) error {
+			return retryHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+
+	bindValidation(app)
+	bindCacheInvalidation(app)
+	initRetryCron(app, logger)
+}
+
+// bindValidation rejects routes with transformation rules that can't be
+// applied
+func bindValidation(app core.App) {
+	app.OnRecordValidate("routes").BindFunc(func(e *core.RecordEvent) error {
+		var transform Transform
+		if err := e.Record.UnmarshalJSONField("transform", &transform); err != nil {
+			return validation.Errors{"transform": validation.NewError("invalid_transform", "transform must be an object of transformation rules")}
+		}
+		if err := transform.Validate(); err != nil {
+			return validation.Errors{"transform": validation.NewError("invalid_transform", err.Error())}
+		}
+		return e.Next()
+	})
+}
+
+// handler delivers a request of a route in gateway mode to its instance.
+// Failed deliveries of routes with a dead-letter policy are queued for a
+// retry and answered with 202, GET requests of routes with a response cache
+// are answered from it while the cached response is fresh.
+func handler(e *core.RequestEvent, logger *zap.Logger) error {
+	started := time.Now()
+	if !features.Enabled(e.App, features.Gateway) {
+		return apis.NewApiError(http.StatusServiceUnavailable, "Gateway mode is disabled", nil)
+	}
+	token := Token()
+	if token == "" {
+		return apis.NewApiError(http.StatusServiceUnavailable, "Gateway mode is not configured", nil)
+	}
+	if subtle.ConstantTimeCompare([]byte(e.Request.Header.Get(TokenHeader)), []byte(token)) != 1 {
+		return apis.NewUnauthorizedError("Invalid gateway token", nil)
+	}
+
+	route, err := e.App.FindRecordById("routes", e.Request.PathValue("route"))
+	if err != nil || !route.GetBool("gateway") {
+		return apis.NewNotFoundError("Route not found", nil)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(e.Request.Body, maxBodySize+1))
+	if err != nil {
+		return apis.NewBadRequestError("Failed to read body", nil)
+	}
+	if len(body) > maxBodySize {
+		return apis.NewApiError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Body exceeds %d bytes", maxBodySize), nil)
+	}
+
+	// Usage is tracked by the client Traefik verified, the latency for the
+	// SLO of the route
+	subject := usage.Subject(e.Request, route.GetString("auth_type"))
+	track := func(status int, bytesOut int64) {
+		usage.Track(route.Id, subject, status, int64(len(body)), bytesOut)
+		slo.Track(route, time.Since(started), status >= http.StatusInternalServerError)
+	}
+
+	delivery := receivedDelivery(e.Request, body)
+	config, cached := cacheOf(route)
+	cached = cached && delivery.Method == http.MethodGet
+	key := cacheKey(route.Id, delivery.URI)
+	if cached {
+		if response, ok := responses.get(key, time.Now()); ok {
+			copyHeader(e.Response.Header(), response.header)
+			e.Response.Header().Set(CacheHeader, "HIT")
+			e.Response.WriteHeader(response.status)
+			n, err := e.Response.Write(response.body)
+			track(response.status, int64(n))
+			return err
+		}
+	}
+
+	var transform Transform
+	if err := route.UnmarshalJSONField("transform", &transform); err == nil {
+		if delivery, err = transform.Apply(delivery); err != nil {
+			track(http.StatusBadRequest, 0)
+			return apis.NewBadRequestError("Failed to transform request", nil)
+		}
+	}
+	resp, err := deliver(e.Request.Context(), e.App, route, delivery)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		status, message := 0, ""
+		if err != nil {
+			message = err.Error()
+		} else {
+			status = resp.StatusCode
+			message = resp.Status
+		}
+
+		if policy, ok := policyOf(route); ok {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			letter, storeErr := storeDeadLetter(e.App, route, delivery, status, message, policy)
+			if storeErr != nil {
+				logger.Error("Failed to store dead letter", zap.String("route", route.Id), zap.Error(storeErr))
+				track(http.StatusBadGateway, 0)
+				return apis.NewApiError(http.StatusBadGateway, "Delivery failed", nil)
+			}
+			logger.Warn("Delivery failed, queued for retry",
+				zap.String("route", route.Id),
+				zap.String("dead_letter", letter.Id),
+				zap.String("error", message))
+			track(http.StatusAccepted, 0)
+			return e.JSON(http.StatusAccepted, map[string]string{"dead_letter": letter.Id})
+		}
+		if err != nil {
+			track(http.StatusBadGateway, 0)
+			return apis.NewApiError(http.StatusBadGateway, "Delivery failed", nil)
+		}
+	}
+	defer resp.Body.Close()
+
+	cacheStatus := ""
+	if cached && cacheable(resp) {
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBody+1))
+		if err != nil {
+			track(http.StatusBadGateway, 0)
+			return apis.NewApiError(http.StatusBadGateway, "Failed to read response", nil)
+		}
+		if len(data) <= maxCachedBody {
+			header := http.Header{}
+			copyHeader(header, resp.Header)
+			now := time.Now()
+			responses.set(key, cachedResponse{
+				status:  resp.StatusCode,
+				header:  header,
+				body:    data,
+				expires: now.Add(config.ttl()),
+			}, now)
+			cacheStatus = "MISS"
+		}
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), resp.Body))
+	}
+
+	copyHeader(e.Response.Header(), resp.Header)
+	if cacheStatus != "" {
+		e.Response.Header().Set(CacheHeader, cacheStatus)
+	}
+	e.Response.WriteHeader(resp.StatusCode)
+	n, err := io.Copy(e.Response, resp.Body)
+	track(resp.StatusCode, n)
+	return err
+}
+
+// receivedDelivery returns the request received from Traefik, whose
+// replacePath middleware keeps the public path in X-Replaced-Path
+func receivedDelivery(r *http.Request, body []byte) Delivery {
+	path := r.Header.Get("X-Replaced-Path")
+	if path == "" {
+		path = "/"
+	}
+	uri := path
+	if r.URL.RawQuery != "" {
+		uri += "?" + r.URL.RawQuery
+	}
+
+	header := r.Header.Clone()
+	header.Del(TokenHeader)
+	header.Del("X-Replaced-Path")
+	header.Del("Content-Length")
+
+	return Delivery{Method: r.Method, URI: uri, Header: header, Body: body}
+}
+
+// deliver sends delivery to the instance of route
+func deliver(ctx context.Context, app core.App, route *core.Record, delivery Delivery) (*http.Response, error) {
+	instance, err := app.FindRecordById("instances", route.GetString("instance"))
+	if err != nil {
+		return nil, fmt.Errorf("instance of route not found")
+	}
+	target, err := Target(instance.GetString("host"), route.GetString("webhook_path"), delivery.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, delivery.Method, target, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	copyHeader(req.Header, delivery.Header)
+	return client.Do(req)
+}
+
+// copyHeader copies the end-to-end headers of src to dst
+func copyHeader(dst, src http.Header) {
+	for name, values := range src {
+		dst[name] = append([]string(nil), values...)
+	}
+	for _, name := range hopHeaders {
+		dst.Del(name)
+	}
+}
@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		host        string
+		servicePath string
+		uri         string
+		expected    string
+	}{
+		{"public path", "http://n8n:5678", "", "/orders?id=1", "http://n8n:5678/orders?id=1"},
+		{"service path", "http://n8n:5678/", "/webhook/3f2a", "/orders?id=1", "http://n8n:5678/webhook/3f2a?id=1"},
+		{"host with base path", "https://n8n.example.com/base", "/webhook/3f2a", "/orders", "https://n8n.example.com/base/webhook/3f2a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := Target(tt.host, tt.servicePath, tt.uri)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+
+	_, err := Target("", "", "/orders")
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Transform is the "transform" field of routes records, adapting the
+// requests of legacy senders before they are delivered, e.g.
+//
+//	{
+//	  "normalize_content_type": true,
+//	  "headers_to_body": {"X-Event-Type": "event"},
+//	  "fields": [{"from": "data.cust_id", "to": "customer.id"}, {"from": "debug"}]
+//	}
+//
+// The content type is normalized first, then headers are copied and the
+// field rules applied in their order.
+type Transform struct {
+	// NormalizeContentType converts form bodies to JSON and labels JSON
+	// bodies sent as text or with a vendor type as application/json
+	NormalizeContentType bool `json:"normalize_content_type,omitempty"`
+
+	// HeadersToBody copies header values into fields of a JSON body, keyed
+	// by header name
+	HeadersToBody map[string]string `json:"headers_to_body,omitempty"`
+
+	// Fields move the fields of a JSON body
+	Fields []FieldRule `json:"fields,omitempty"`
+}
+
+// FieldRule moves the field at the dotted path From to To, or removes it
+// if To is empty
+type FieldRule struct {
+	From string `json:"from"`
+	To   string `json:"to,omitempty"`
+}
+
+// Validate reports whether the rules of t can be applied
+func (t Transform) Validate() error {
+	for header, path := range t.HeadersToBody {
+		if header == "" || path == "" {
+			return errors.New("headers_to_body needs header names and field paths")
+		}
+	}
+	for _, rule := range t.Fields {
+		if rule.From == "" {
+			return errors.New("field rules need a from path")
+		}
+	}
+	return nil
+}
+
+// empty reports whether t changes nothing
+func (t Transform) empty() bool {
+	return !t.NormalizeContentType && len(t.HeadersToBody) == 0 && len(t.Fields) == 0
+}
+
+// Apply returns delivery transformed by t. Header and field rules only
+// apply to JSON object bodies, other bodies are passed on unchanged.
+func (t Transform) Apply(delivery Delivery) (Delivery, error) {
+	if t.empty() {
+		return delivery, nil
+	}
+	delivery.Header = delivery.Header.Clone()
+
+	mediaType, _, _ := mime.ParseMediaType(delivery.Header.Get("Content-Type"))
+	if t.NormalizeContentType {
+		switch {
+		case mediaType == "application/x-www-form-urlencoded":
+			form, err := url.ParseQuery(string(delivery.Body))
+			if err != nil {
+				return delivery, fmt.Errorf("invalid form body: %w", err)
+			}
+			object := make(map[string]any, len(form))
+			for key, values := range form {
+				if len(values) == 1 {
+					object[key] = values[0]
+				} else {
+					object[key] = values
+				}
+			}
+			if delivery.Body, err = json.Marshal(object); err != nil {
+				return delivery, err
+			}
+			mediaType = "application/json"
+		case mediaType != "application/json" && json.Valid(delivery.Body):
+			// e.g. text/plain, text/json or application/vnd.legacy+json
+			mediaType = "application/json"
+		}
+		if mediaType == "application/json" {
+			delivery.Header.Set("Content-Type", "application/json")
+		}
+	}
+
+	if len(t.HeadersToBody) == 0 && len(t.Fields) == 0 {
+		return delivery, nil
+	}
+	var body map[string]any
+	if len(delivery.Body) == 0 {
+		body = map[string]any{}
+	} else if err := json.Unmarshal(delivery.Body, &body); err != nil || body == nil {
+		return delivery, nil
+	}
+
+	for header, path := range t.HeadersToBody {
+		if value := delivery.Header.Get(header); value != "" {
+			setPath(body, path, value)
+		}
+	}
+	for _, rule := range t.Fields {
+		value, found := deletePath(body, rule.From)
+		if found && rule.To != "" {
+			setPath(body, rule.To, value)
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return delivery, err
+	}
+	delivery.Body = data
+	if delivery.Header.Get("Content-Type") == "" {
+		delivery.Header.Set("Content-Type", "application/json")
+	}
+	return delivery, nil
+}
+
+// setPath sets the field at the dotted path in object, creating missing
+// objects on the way and replacing non-object values
+func setPath(object map[string]any, path string, value any) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := object[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			object[key] = next
+		}
+		object = next
+	}
+	object[keys[len(keys)-1]] = value
+}
+
+// deletePath removes the field at the dotted path from object and returns
+// its value. Numeric keys index into arrays.
+func deletePath(object map[string]any, path string) (any, bool) {
+	keys := strings.Split(path, ".")
+	var parent any = object
+	for _, key := range keys[:len(keys)-1] {
+		switch node := parent.(type) {
+		case map[string]any:
+			parent = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			parent = node[i]
+		default:
+			return nil, false
+		}
+	}
+
+	last := keys[len(keys)-1]
+	node, ok := parent.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	value, found := node[last]
+	delete(node, last)
+	return value, found
+}
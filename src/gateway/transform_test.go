@@ -0,0 +1,98 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformApply(t *testing.T) {
+	delivery := func(contentType, body string) Delivery {
+		header := http.Header{}
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		header.Set("X-Event-Type", "order.created")
+		return Delivery{Method: http.MethodPost, URI: "/orders", Header: header, Body: []byte(body)}
+	}
+
+	tests := []struct {
+		name        string
+		transform   Transform
+		delivery    Delivery
+		body        string
+		contentType string
+	}{
+		{
+			name:        "form to json",
+			transform:   Transform{NormalizeContentType: true},
+			delivery:    delivery("application/x-www-form-urlencoded", "id=7&tag=a&tag=b"),
+			body:        `{"id":"7","tag":["a","b"]}`,
+			contentType: "application/json",
+		},
+		{
+			name:        "json sent as text",
+			transform:   Transform{NormalizeContentType: true},
+			delivery:    delivery("text/plain; charset=utf-8", `{"id":7}`),
+			body:        `{"id":7}`,
+			contentType: "application/json",
+		},
+		{
+			name:        "plain text stays",
+			transform:   Transform{NormalizeContentType: true, Fields: []FieldRule{{From: "id"}}},
+			delivery:    delivery("text/plain", "hello"),
+			body:        "hello",
+			contentType: "text/plain",
+		},
+		{
+			name:        "header to body",
+			transform:   Transform{HeadersToBody: map[string]string{"X-Event-Type": "meta.event"}},
+			delivery:    delivery("application/json", `{"id":7}`),
+			body:        `{"id":7,"meta":{"event":"order.created"}}`,
+			contentType: "application/json",
+		},
+		{
+			name: "field mapping",
+			transform: Transform{Fields: []FieldRule{
+				{From: "data.cust_id", To: "customer.id"},
+				{From: "items.0.sku", To: "first_sku"},
+				{From: "debug"},
+				{From: "missing", To: "ignored"},
+			}},
+			delivery:    delivery("application/json", `{"data":{"cust_id":42},"items":[{"sku":"A1"}],"debug":true}`),
+			body:        `{"customer":{"id":42},"data":{},"first_sku":"A1","items":[{}]}`,
+			contentType: "application/json",
+		},
+		{
+			name:        "empty body",
+			transform:   Transform{HeadersToBody: map[string]string{"X-Event-Type": "event"}},
+			delivery:    delivery("", ""),
+			body:        `{"event":"order.created"}`,
+			contentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.delivery.Header.Get("Content-Type")
+			transformed, err := tt.transform.Apply(tt.delivery)
+			require.NoError(t, err)
+			if tt.contentType == "application/json" && json.Valid([]byte(tt.body)) {
+				assert.JSONEq(t, tt.body, string(transformed.Body))
+			} else {
+				assert.Equal(t, tt.body, string(transformed.Body))
+			}
+			assert.Equal(t, tt.contentType, transformed.Header.Get("Content-Type"))
+			assert.Equal(t, original, tt.delivery.Header.Get("Content-Type"), "the received headers are kept")
+		})
+	}
+}
+
+func TestTransformValidate(t *testing.T) {
+	assert.NoError(t, Transform{}.Validate())
+	assert.Error(t, Transform{Fields: []FieldRule{{To: "id"}}}.Validate())
+	assert.Error(t, Transform{HeadersToBody: map[string]string{"X-Event": ""}}.Validate())
+}
@@ -0,0 +1,121 @@
+// Package geoip resolves the country of client IPs from a MaxMind DB file
+// such as GeoLite2-Country or GeoIP2-City.
+//
+// The database is set with GEOIP_DATABASE (e.g. "/data/GeoLite2-Country.mmdb").
+// It is reloaded when the file changes, so geoipupdate can replace it while
+// the manager runs.
+package geoip
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// checkInterval is how often the file is checked for changes
+const checkInterval = time.Minute
+
+// Database is a MaxMind DB file, loaded on first use
+type Database struct {
+	path string
+
+	mu        sync.Mutex
+	reader    *maxminddb.Reader
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// Open returns the database at path. The file is read on the first lookup.
+func Open(path string) *Database {
+	return &Database{path: path}
+}
+
+// FromEnv returns the database set in GEOIP_DATABASE
+func FromEnv() (*Database, error) {
+	path := os.Getenv("GEOIP_DATABASE")
+	if path == "" {
+		return nil, errors.New("GEOIP_DATABASE must be set")
+	}
+	return Open(path), nil
+}
+
+// load returns the reader of the current file, reloading it if it changed
+// since it was read
+func (db *Database) load() (*maxminddb.Reader, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	now := time.Now()
+	if db.reader != nil && now.Sub(db.checkedAt) < checkInterval {
+		return db.reader, nil
+	}
+	db.checkedAt = now
+
+	r, modTime, err := db.read()
+	if err != nil {
+		// Keep serving the loaded file, e.g. while it is being replaced
+		if db.reader != nil {
+			return db.reader, nil
+		}
+		return nil, err
+	}
+	if r != nil {
+		db.reader, db.modTime = r, modTime
+	}
+	return db.reader, nil
+}
+
+// read reads the file if it changed since it was loaded, r is nil if it
+// didn't
+func (db *Database) read() (r *maxminddb.Reader, modTime time.Time, err error) {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if db.reader != nil && info.ModTime().Equal(db.modTime) {
+		return nil, time.Time{}, nil
+	}
+
+	data, err := os.ReadFile(db.path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if r, err = maxminddb.FromBytes(data); err != nil {
+		return nil, time.Time{}, err
+	}
+	return r, info.ModTime(), nil
+}
+
+// Country returns the ISO 3166-1 code of the country of addr, e.g. "DE", or
+// an empty string if the database doesn't know the address. The registered
+// country is used for addresses without a located one.
+func (db *Database) Country(addr netip.Addr) (string, error) {
+	r, err := db.load()
+	if err != nil {
+		return "", err
+	}
+	var record countryRecord
+	if err := r.Lookup(net.IP(addr.Unmap().AsSlice()), &record); err != nil {
+		return "", err
+	}
+	if record.Country.ISOCode != "" {
+		return record.Country.ISOCode, nil
+	}
+	return record.RegisteredCountry.ISOCode, nil
+}
+
+// countryRecord holds the countries of a network in Country and City
+// databases
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
@@ -0,0 +1,242 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metadataMarker starts the metadata at the end of MaxMind DB files
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// Data types of the data section, see
+// https://maxmind.github.io/MaxMind-DB/
+const (
+	typePointer = 1
+	typeString  = 2
+	typeUint16  = 5
+	typeUint32  = 6
+	typeMap     = 7
+)
+
+// testDB builds MaxMind DB files with an IPv6 search tree
+type testDB struct {
+	// nodes hold the records of the tree: the index of the next node, 0 for
+	// none or -(offset+1) for data
+	nodes [][2]int
+	data  []byte
+}
+
+func newTestDB() *testDB {
+	return &testDB{nodes: [][2]int{{0, 0}}}
+}
+
+// insert points the network of prefix to data at offset
+func (db *testDB) insert(prefix netip.Prefix, offset int) {
+	var ip [16]byte
+	bits := prefix.Bits()
+	if prefix.Addr().Is4() {
+		// IPv4 addresses live in ::/96 of IPv6 trees
+		v4 := prefix.Addr().As4()
+		copy(ip[12:], v4[:])
+		bits += 96
+	} else {
+		ip = prefix.Addr().As16()
+	}
+
+	node := 0
+	for i := 0; i < bits; i++ {
+		bit := ip[i/8] >> (7 - i%8) & 1
+		if i == bits-1 {
+			db.nodes[node][bit] = -(offset + 1)
+			return
+		}
+		if db.nodes[node][bit] <= 0 {
+			db.nodes = append(db.nodes, [2]int{0, 0})
+			db.nodes[node][bit] = len(db.nodes) - 1
+		}
+		node = db.nodes[node][bit]
+	}
+}
+
+// add appends value to the data section and returns its offset
+func (db *testDB) add(value []byte) int {
+	db.data = append(db.data, value...)
+	return len(db.data) - len(value)
+}
+
+// build returns the database file with records of recordSize bits
+func (db *testDB) build(recordSize int) []byte {
+	count := len(db.nodes)
+	value := func(record int) uint32 {
+		switch {
+		case record > 0:
+			return uint32(record)
+		case record == 0:
+			return uint32(count)
+		default:
+			return uint32(count + 16 - record - 1)
+		}
+	}
+
+	var file []byte
+	for _, node := range db.nodes {
+		left, right := value(node[0]), value(node[1])
+		switch recordSize {
+		case 24:
+			file = append(file, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+		case 28:
+			file = append(file, byte(left>>16), byte(left>>8), byte(left),
+				byte(left>>24)<<4|byte(right>>24), byte(right>>16), byte(right>>8), byte(right))
+		default:
+			file = binary.BigEndian.AppendUint32(file, left)
+			file = binary.BigEndian.AppendUint32(file, right)
+		}
+	}
+	file = append(file, make([]byte, 16)...)
+	file = append(file, db.data...)
+	file = append(file, metadataMarker...)
+	return append(file, encodeMap(
+		"database_type", encodeString("Test-Country"),
+		"ip_version", encodeUint(typeUint16, 6),
+		"node_count", encodeUint(typeUint32, uint64(count)),
+		"record_size", encodeUint(typeUint16, uint64(recordSize)),
+	)...)
+}
+
+func encodeString(s string) []byte {
+	return append([]byte{typeString<<5 | byte(len(s))}, s...)
+}
+
+func encodeUint(kind byte, v uint64) []byte {
+	var b []byte
+	for ; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	return append([]byte{kind<<5 | byte(len(b))}, b...)
+}
+
+// encodePointer returns a pointer to offset, which must be below 2048
+func encodePointer(offset int) []byte {
+	return []byte{typePointer<<5 | byte(offset>>8), byte(offset)}
+}
+
+// encodeMap encodes the pairs of keys and encoded values
+func encodeMap(pairs ...any) []byte {
+	keys := make([]string, 0, len(pairs)/2)
+	values := map[string][]byte{}
+	for i := 0; i < len(pairs); i += 2 {
+		keys = append(keys, pairs[i].(string))
+		values[pairs[i].(string)] = pairs[i+1].([]byte)
+	}
+	sort.Strings(keys)
+
+	b := []byte{typeMap<<5 | byte(len(keys))}
+	for _, key := range keys {
+		b = append(b, encodeString(key)...)
+		b = append(b, values[key]...)
+	}
+	return b
+}
+
+// countryDB returns a database of documentation networks
+func countryDB(recordSize int, germany string) []byte {
+	db := newTestDB()
+	de := db.add(encodeMap("iso_code", encodeString(germany)))
+	db.insert(netip.MustParsePrefix("192.0.2.0/24"), db.add(encodeMap("country", encodePointer(de))))
+	db.insert(netip.MustParsePrefix("198.51.100.0/24"), db.add(encodeMap(
+		"registered_country", encodeMap("iso_code", encodeString("US")),
+	)))
+	db.insert(netip.MustParsePrefix("203.0.113.0/24"), db.add(encodeMap(
+		"continent", encodeMap("code", encodeString("EU")),
+	)))
+	db.insert(netip.MustParsePrefix("2001:db8::/32"), db.add(encodeMap(
+		"country", encodeMap("iso_code", encodeString("FR")),
+		"registered_country", encodeMap("iso_code", encodeString("BE")),
+	)))
+	return db.build(recordSize)
+}
+
+func writeDB(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestCountry(t *testing.T) {
+	for _, recordSize := range []int{24, 28, 32} {
+		path := filepath.Join(t.TempDir(), "country.mmdb")
+		writeDB(t, path, countryDB(recordSize, "DE"), time.Now())
+		db := Open(path)
+
+		tests := map[string]string{
+			"192.0.2.10":          "DE",
+			"::ffff:192.0.2.10":   "DE",
+			"198.51.100.1":        "US",
+			"203.0.113.5":         "",
+			"2001:db8:1::1":       "FR",
+			"10.0.0.1":            "",
+			"2001:db9::1":         "",
+			"::1":                 "",
+			"255.255.255.255":     "",
+			"192.0.3.1":           "",
+			"2001:db8:ffff::ffff": "FR",
+		}
+		for ip, want := range tests {
+			country, err := db.Country(netip.MustParseAddr(ip))
+			require.NoError(t, err, "record size %d, %s", recordSize, ip)
+			assert.Equal(t, want, country, "record size %d, %s", recordSize, ip)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "country.mmdb")
+	modTime := time.Now().Add(-time.Hour)
+	writeDB(t, path, countryDB(24, "DE"), modTime)
+	db := Open(path)
+	ip := netip.MustParseAddr("192.0.2.10")
+
+	country, err := db.Country(ip)
+	require.NoError(t, err)
+	assert.Equal(t, "DE", country)
+
+	writeDB(t, path, countryDB(24, "AT"), modTime.Add(time.Minute))
+	country, _ = db.Country(ip)
+	assert.Equal(t, "DE", country, "the file is checked once a minute")
+
+	db.checkedAt = time.Time{}
+	country, _ = db.Country(ip)
+	assert.Equal(t, "AT", country, "changed files are reloaded")
+
+	writeDB(t, path, []byte("broken"), modTime.Add(2*time.Minute))
+	db.checkedAt = time.Time{}
+	country, err = db.Country(ip)
+	require.NoError(t, err)
+	assert.Equal(t, "AT", country, "invalid files don't replace the loaded one")
+}
+
+func TestOpenErrors(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "missing.mmdb")).Country(netip.MustParseAddr("192.0.2.1"))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "broken.mmdb")
+	writeDB(t, path, []byte("not a database"), time.Now())
+	_, err = Open(path).Country(netip.MustParseAddr("192.0.2.1"))
+	assert.Error(t, err)
+
+	path = filepath.Join(t.TempDir(), "truncated.mmdb")
+	writeDB(t, path, append(metadataMarker, encodeMap(
+		"node_count", encodeUint(typeUint32, 1000),
+		"record_size", encodeUint(typeUint16, 24),
+	)...), time.Now())
+	_, err = Open(path).Country(netip.MustParseAddr("192.0.2.1"))
+	assert.Error(t, err, "the tree must fit into the file")
+}
@@ -0,0 +1,65 @@
+// Package graphql exposes a read-only GraphQL API over the manager's data
+// model (instances → workflows → webhooks, nodes and triggers), so
+// dashboards can fetch nested data in a single request.
+//
+// The endpoint is /api/graphql and accepts GET (?query=...) and POST
+// ({"query", "variables", "operationName"}) for any authenticated user,
+// matching the list rules of the underlying collections.
+package graphql
+
+import (
+	"net/http"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// Request is a GraphQL request
+type Request struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// InitRoutes registers the GraphQL endpoint
+func InitRoutes(app core.App, logger *zap.Logger) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		schema, err := NewSchema(app)
+		if err != nil {
+			return err
+		}
+
+		handler := func(e *core.RequestEvent) error {
+			var body Request
+			if e.Request.Method == http.MethodGet {
+				body.Query = e.Request.URL.Query().Get("query")
+				body.OperationName = e.Request.URL.Query().Get("operationName")
+			} else if err := e.BindBody(&body); err != nil {
+				return apis.NewBadRequestError("Invalid request body", err)
+			}
+			if body.Query == "" {
+				return apis.NewBadRequestError("Missing query", nil)
+			}
+
+			result := gql.Do(gql.Params{
+				Schema:         schema,
+				RequestString:  body.Query,
+				VariableValues: body.Variables,
+				OperationName:  body.OperationName,
+				Context:        e.Request.Context(),
+			})
+			if result.HasErrors() {
+				logger.Debug("GraphQL query failed", zap.Any("errors", result.Errors))
+			}
+
+			return e.JSON(http.StatusOK, result)
+		}
+
+		se.Router.GET("/api/graphql", handler).Bind(apis.RequireAuth())
+		se.Router.POST("/api/graphql", handler).Bind(apis.RequireAuth())
+
+		return se.Next()
+	})
+}
@@ -0,0 +1,307 @@
+package graphql
+
+import (
+	"encoding/json"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/trash"
+)
+
+// field resolves a record field as a scalar
+func field(typ gql.Output, name string) *gql.Field {
+	return &gql.Field{
+		Type: typ,
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			record, ok := p.Source.(*core.Record)
+			if !ok {
+				return nil, nil
+			}
+			switch typ {
+			case gql.String:
+				return record.GetString(name), nil
+			case gql.Int:
+				return record.GetInt(name), nil
+			case gql.Float:
+				return record.GetFloat(name), nil
+			case gql.Boolean:
+				return record.GetBool(name), nil
+			}
+			return record.Get(name), nil
+		},
+	}
+}
+
+// dateField resolves a date record field as RFC 3339 string, empty dates are null
+func dateField(name string) *gql.Field {
+	return &gql.Field{
+		Type: gql.String,
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			record := p.Source.(*core.Record)
+			date := record.GetDateTime(name)
+			if date.IsZero() {
+				return nil, nil
+			}
+			return date.Time().Format("2006-01-02T15:04:05Z07:00"), nil
+		},
+	}
+}
+
+// stringListField resolves a JSON record field holding a list of strings
+func stringListField(name string) *gql.Field {
+	return &gql.Field{
+		Type: gql.NewList(gql.String),
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			var values []string
+			p.Source.(*core.Record).UnmarshalJSONField(name, &values)
+			return values, nil
+		},
+	}
+}
+
+// jsonField resolves a JSON record field as its encoded string
+func jsonField(name string) *gql.Field {
+	return &gql.Field{
+		Type: gql.String,
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			value := p.Source.(*core.Record).Get(name)
+			if value == nil {
+				return nil, nil
+			}
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}
+}
+
+// children resolves the records of collection pointing to the source record
+func children(app core.App, typ gql.Output, collection, relation string) *gql.Field {
+	return &gql.Field{
+		Type: gql.NewList(typ),
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			record := p.Source.(*core.Record)
+			return app.FindAllRecords(collection, dbx.HashExp{relation: record.Id})
+		},
+	}
+}
+
+// latestVersions keeps the newest record of every workflow, records must
+// be sorted newest first
+func latestVersions(records []*core.Record) []*core.Record {
+	seen := map[string]bool{}
+	latest := make([]*core.Record, 0, len(records))
+	for _, record := range records {
+		key := record.GetString("instance") + "/" + record.GetString("workflow_id")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		latest = append(latest, record)
+	}
+	return latest
+}
+
+// workflowArgs filter workflow lists
+var workflowArgs = gql.FieldConfigArgument{
+	"latest": &gql.ArgumentConfig{
+		Type:         gql.Boolean,
+		DefaultValue: true,
+		Description:  "Only the newest version of each workflow",
+	},
+	"archived": &gql.ArgumentConfig{
+		Type:         gql.Boolean,
+		DefaultValue: false,
+	},
+	"active": &gql.ArgumentConfig{
+		Type: gql.Boolean,
+	},
+}
+
+// findWorkflows loads workflow records matching the workflowArgs, leaving
+// out the ones in the trash
+func findWorkflows(app core.App, filter dbx.HashExp, args map[string]any) ([]*core.Record, error) {
+	filter[trash.Field] = ""
+	if archived, ok := args["archived"].(bool); ok {
+		filter["archived"] = archived
+	}
+	if active, ok := args["active"].(bool); ok {
+		filter["active"] = active
+	}
+
+	records, err := findSorted(app, "workflows", filter, "updated_at")
+	if err != nil {
+		return nil, err
+	}
+
+	if latest, _ := args["latest"].(bool); latest {
+		records = latestVersions(records)
+	}
+	return records, nil
+}
+
+// findSorted loads the records matching filter, newest first by the given column
+func findSorted(app core.App, collection string, filter dbx.HashExp, column string) ([]*core.Record, error) {
+	var records []*core.Record
+	err := app.RecordQuery(collection).
+		AndWhere(filter).
+		OrderBy(column + " DESC").
+		All(&records)
+	return records, err
+}
+
+// NewSchema builds the read-only schema over instances, workflows and
+// their webhooks, nodes and triggers
+func NewSchema(app core.App) (gql.Schema, error) {
+	nodeType := gql.NewObject(gql.ObjectConfig{
+		Name: "Node",
+		Fields: gql.Fields{
+			"id":          field(gql.String, "id"),
+			"node_id":     field(gql.String, "node_id"),
+			"name":        field(gql.String, "name"),
+			"type":        field(gql.String, "type"),
+			"disabled":    field(gql.Boolean, "disabled"),
+			"credentials": jsonField("credentials"),
+		},
+	})
+
+	triggerType := gql.NewObject(gql.ObjectConfig{
+		Name: "Trigger",
+		Fields: gql.Fields{
+			"id":        field(gql.String, "id"),
+			"node_name": field(gql.String, "node_name"),
+			"type":      field(gql.String, "type"),
+			"kind":      field(gql.String, "kind"),
+			"disabled":  field(gql.Boolean, "disabled"),
+			"cron":      stringListField("cron"),
+			"config":    jsonField("config"),
+		},
+	})
+
+	webhookType := gql.NewObject(gql.ObjectConfig{
+		Name: "Webhook",
+		Fields: gql.Fields{
+			"id":           field(gql.String, "id"),
+			"workflow_id":  field(gql.String, "workflow_id"),
+			"node_id":      field(gql.String, "node_id"),
+			"webhook_url":  field(gql.String, "webhook_url"),
+			"methods":      stringListField("methods"),
+			"route":        field(gql.String, "route"),
+			"auth_type":    field(gql.String, "auth_type"),
+			"reachable":    field(gql.Boolean, "reachable"),
+			"http_status":  field(gql.Int, "http_status"),
+			"route_broken": field(gql.Boolean, "route_broken"),
+			"checked_at":   dateField("checked_at"),
+			"response":     jsonField("response"),
+		},
+	})
+
+	workflowType := gql.NewObject(gql.ObjectConfig{
+		Name: "Workflow",
+		Fields: gql.Fields{
+			"id":              field(gql.String, "id"),
+			"workflow_id":     field(gql.String, "workflow_id"),
+			"workflow_name":   field(gql.String, "workflow_name"),
+			"active":          field(gql.Boolean, "active"),
+			"archived":        field(gql.Boolean, "archived"),
+			"created_at":      field(gql.String, "created_at"),
+			"updated_at":      field(gql.String, "updated_at"),
+			"number_of_nodes": field(gql.Int, "number_of_nodes"),
+			"data_size":       field(gql.Int, "data_size"),
+			"dependencies":    jsonField("dependencies"),
+			"nodes":           children(app, nodeType, "workflow_nodes", "workflow"),
+			"triggers":        children(app, triggerType, "triggers", "workflow"),
+			"webhooks": &gql.Field{
+				Type: gql.NewList(webhookType),
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					record := p.Source.(*core.Record)
+					return app.FindAllRecords("webhooks", dbx.HashExp{
+						"instance":    record.GetString("instance"),
+						"workflow_id": record.GetString("workflow_id"),
+					})
+				},
+			},
+		},
+	})
+
+	instanceType := gql.NewObject(gql.ObjectConfig{
+		Name: "Instance",
+		Fields: gql.Fields{
+			"id":                  field(gql.String, "id"),
+			"host":                field(gql.String, "host"),
+			"check_interval_mins": field(gql.Int, "check_interval_mins"),
+			"availability_status": field(gql.Boolean, "availability_status"),
+			"availability_note":   field(gql.String, "availability_note"),
+			"health":              field(gql.String, "health"),
+			"execution_mode":      field(gql.String, "execution_mode"),
+			"workflows_active":    field(gql.Int, "workflows_active"),
+			"workflows_inactive":  field(gql.Int, "workflows_inactive"),
+			"webhooks_active":     field(gql.Int, "webhooks_active"),
+			"webhooks_inactive":   field(gql.Int, "webhooks_inactive"),
+			"storage_bytes":       field(gql.Float, "storage_bytes"),
+			"last_check":          dateField("last_check"),
+			"workflows": &gql.Field{
+				Type: gql.NewList(workflowType),
+				Args: workflowArgs,
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					record := p.Source.(*core.Record)
+					return findWorkflows(app, dbx.HashExp{"instance": record.Id}, p.Args)
+				},
+			},
+		},
+	})
+
+	workflowType.AddFieldConfig("instance", &gql.Field{
+		Type: instanceType,
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return app.FindRecordById("instances", p.Source.(*core.Record).GetString("instance"))
+		},
+	})
+
+	query := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"instances": &gql.Field{
+				Type: gql.NewList(instanceType),
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					return app.FindAllRecords("instances", trash.NotDeleted)
+				},
+			},
+			"instance": &gql.Field{
+				Type: instanceType,
+				Args: gql.FieldConfigArgument{
+					"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					record, err := app.FindRecordById("instances", p.Args["id"].(string))
+					if err != nil || trash.Deleted(record) {
+						return nil, nil
+					}
+					return record, nil
+				},
+			},
+			"workflows": &gql.Field{
+				Type: gql.NewList(workflowType),
+				Args: workflowArgs,
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					return findWorkflows(app, dbx.HashExp{}, p.Args)
+				},
+			},
+			"workflow": &gql.Field{
+				Type: workflowType,
+				Args: gql.FieldConfigArgument{
+					"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					record, err := app.FindRecordById("workflows", p.Args["id"].(string))
+					if err != nil || trash.Deleted(record) {
+						return nil, nil
+					}
+					return record, nil
+				},
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: query})
+}
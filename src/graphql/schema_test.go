@@ -0,0 +1,84 @@
+package graphql
+
+import (
+	"testing"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	schema, err := NewSchema(nil)
+	require.NoError(t, err)
+
+	result := gql.Do(gql.Params{
+		Schema:        schema,
+		RequestString: `{ __type(name: "Workflow") { fields { name } } }`,
+	})
+	require.False(t, result.HasErrors(), "%v", result.Errors)
+
+	var names []string
+	for _, f := range result.Data.(map[string]any)["__type"].(map[string]any)["fields"].([]any) {
+		names = append(names, f.(map[string]any)["name"].(string))
+	}
+	assert.Subset(t, names, []string{"workflow_name", "nodes", "webhooks", "triggers", "instance"})
+	assert.NotContains(t, names, "workflow_data")
+}
+
+func TestLatestVersions(t *testing.T) {
+	collection := core.NewBaseCollection("workflows")
+	collection.Fields.Add(&core.TextField{Name: "instance"}, &core.TextField{Name: "workflow_id"})
+
+	newVersion := func(id, instance, workflowID string) *core.Record {
+		record := core.NewRecord(collection)
+		record.Id = id
+		record.Set("instance", instance)
+		record.Set("workflow_id", workflowID)
+		return record
+	}
+
+	latest := latestVersions([]*core.Record{
+		newVersion("v3", "a", "1"),
+		newVersion("v2", "a", "1"),
+		newVersion("v1", "b", "1"),
+		newVersion("v0", "a", "2"),
+	})
+
+	var ids []string
+	for _, record := range latest {
+		ids = append(ids, record.Id)
+	}
+	assert.Equal(t, []string{"v3", "v1", "v0"}, ids)
+}
+
+func TestSchemaHidesTrash(t *testing.T) {
+	app := testutil.NewApp(t)
+	schema, err := NewSchema(app)
+	require.NoError(t, err)
+
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	live := testutil.Create(t, app, "workflows", map[string]any{"instance": instance.Id, "workflow_id": "1"})
+	trashed := testutil.Create(t, app, "workflows", map[string]any{"instance": instance.Id, "workflow_id": "2", "deleted_at": "2025-06-02 12:00:00.000Z"})
+
+	query := func(request string) map[string]any {
+		result := gql.Do(gql.Params{Schema: schema, RequestString: request})
+		require.False(t, result.HasErrors(), "%v", result.Errors)
+		return result.Data.(map[string]any)
+	}
+	ids := func(list any) []string {
+		var ids []string
+		for _, item := range list.([]any) {
+			ids = append(ids, item.(map[string]any)["id"].(string))
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{live.Id}, ids(query(`{ workflows { id } }`)["workflows"]))
+	nested := query(`{ instance(id: "` + instance.Id + `") { workflows { id } } }`)["instance"].(map[string]any)
+	assert.Equal(t, []string{live.Id}, ids(nested["workflows"]))
+	assert.NotNil(t, query(`{ workflow(id: "` + live.Id + `") { id } }`)["workflow"])
+	assert.Nil(t, query(`{ workflow(id: "` + trashed.Id + `") { id } }`)["workflow"])
+}
@@ -0,0 +1,56 @@
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NewHealthcheckCommand returns the "healthcheck" command. It requests the
+// local readiness endpoint and exits non-zero unless it reports ready, so
+// images without curl can declare:
+//
+//	HEALTHCHECK CMD ["/app/n8n-manager", "healthcheck"]
+func NewHealthcheckCommand(port string) *cobra.Command {
+	var url string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:          "healthcheck",
+		Short:        "Checks the readiness of a running server",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// PocketBase ignores command errors, exit explicitly so the
+			// container runtime sees the failure
+			if err := checkReady(url, timeout); err != nil {
+				fmt.Fprintln(os.Stderr, "healthcheck failed:", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", "http://127.0.0.1:"+port+ReadinessPath, "the readiness endpoint to check")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "the request timeout")
+
+	return cmd
+}
+
+// checkReady requests url and fails unless it answers with 200 OK
+func checkReady(url string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server not ready, status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReady(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, ReadinessPath, r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, checkReady(server.URL+ReadinessPath, time.Second))
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, checkReady(server.URL+ReadinessPath, time.Second), "status 503")
+
+	server.Close()
+	assert.Error(t, checkReady(server.URL+ReadinessPath, time.Second))
+}
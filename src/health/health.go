@@ -0,0 +1,18 @@
+// Package health provides liveness and readiness probes for the manager
+// itself and health endpoints for external monitoring of the n8n fleet.
+package health
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// InitRoutes registers the health endpoints
+func InitRoutes(app core.App, logger *zap.Logger) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		bindProbeRoutes(se, logger)
+		bindInstanceRoutes(se, logger)
+
+		return se.Next()
+	})
+}
@@ -0,0 +1,202 @@
+package health
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/environments"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// Fail modes decide when the aggregated endpoint reports failure (HTTP 503)
+const (
+	// FailAny fails if any instance is down
+	FailAny = "any"
+	// FailAll fails only if all instances are down
+	FailAll = "all"
+	// FailManager fails only if the manager itself is broken
+	FailManager = "manager"
+)
+
+// Overall states of the fleet
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFailing  = "failing"
+)
+
+// staleFactor marks an instance as stale if it wasn't checked for this many intervals
+const staleFactor = 3
+
+// InstanceState is the health of a single instance
+type InstanceState struct {
+	ID        string    `json:"id"`
+	Host      string    `json:"host"`
+	Health    string    `json:"health"`
+	Note      string    `json:"note,omitempty"`
+	LastCheck time.Time `json:"last_check"`
+}
+
+// FleetStatus is the response of GET /api/health/instances
+type FleetStatus struct {
+	Status    string          `json:"status"`
+	Mode      string          `json:"mode"`
+	Total     int             `json:"total"`
+	Healthy   int             `json:"healthy"`
+	Degraded  int             `json:"degraded"`
+	Down      int             `json:"down"`
+	Stale     int             `json:"stale"`
+	Instances []InstanceState `json:"instances,omitempty"`
+}
+
+// failMode returns the mode requested via ?mode=, HEALTH_FAIL_MODE or FailAny
+func failMode(e *core.RequestEvent) string {
+	for _, mode := range []string{e.Request.URL.Query().Get("mode"), os.Getenv("HEALTH_FAIL_MODE")} {
+		switch mode {
+		case FailAny, FailAll, FailManager:
+			return mode
+		}
+	}
+	return FailAny
+}
+
+// canViewDetails reports whether the per-instance states may be returned.
+// Anonymous requests only see the overall status; details require a
+// superuser or HEALTH_TOKEN, as bearer header or ?token= for uptime
+// checkers that can't set headers.
+func canViewDetails(e *core.RequestEvent) bool {
+	if e.HasSuperuserAuth() {
+		return true
+	}
+
+	token := os.Getenv("HEALTH_TOKEN")
+	if token == "" {
+		return false
+	}
+
+	provided, found := strings.CutPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		provided = e.Request.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// instanceState derives the health of an instance record. Instances that
+// weren't checked for several intervals are reported as "stale".
+func instanceState(record *core.Record, now time.Time) InstanceState {
+	state := InstanceState{
+		ID:        record.Id,
+		Host:      record.GetString("host"),
+		Health:    record.GetString("health"),
+		Note:      record.GetString("availability_note"),
+		LastCheck: record.GetDateTime("last_check").Time(),
+	}
+
+	if state.Health == "" {
+		// Instances checked before tri-state health existed
+		state.Health = "down"
+		if record.GetBool("availability_status") {
+			state.Health = "healthy"
+		}
+	}
+
+	interval := record.GetInt("check_interval_mins")
+	if interval == 0 {
+		interval = 5
+	}
+	if state.LastCheck.IsZero() || now.Sub(state.LastCheck) > staleFactor*time.Duration(interval)*time.Minute {
+		state.Health = "stale"
+	}
+
+	return state
+}
+
+// StateOf returns the health of an instance record, see instanceState
+func StateOf(record *core.Record, now time.Time) InstanceState {
+	return instanceState(record, now)
+}
+
+// aggregate computes the fleet status for the given mode
+func aggregate(states []InstanceState, mode string) FleetStatus {
+	status := FleetStatus{Mode: mode, Total: len(states), Instances: states}
+	for _, state := range states {
+		switch state.Health {
+		case "healthy":
+			status.Healthy++
+		case "degraded":
+			status.Degraded++
+		case "stale":
+			status.Stale++
+		default:
+			status.Down++
+		}
+	}
+
+	// Stale instances are treated as down, nobody knows their state
+	unavailable := status.Down + status.Stale
+
+	status.Status = StatusOK
+	if status.Degraded > 0 || unavailable > 0 {
+		status.Status = StatusDegraded
+	}
+
+	switch mode {
+	case FailAny:
+		if unavailable > 0 {
+			status.Status = StatusFailing
+		}
+	case FailAll:
+		if status.Total > 0 && unavailable == status.Total {
+			status.Status = StatusFailing
+		}
+	}
+
+	return status
+}
+
+// instancesHandler serves the aggregated health of all instances, or of the
+// ones in ?environment=
+func instancesHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	mode := failMode(e)
+
+	environment, err := environments.Filter(e, "id")
+	if err != nil {
+		return err
+	}
+
+	records, err := e.App.FindAllRecords("instances", environment, trash.NotDeleted)
+	if err != nil {
+		// The manager itself is broken, this fails in every mode
+		logger.Error("Failed to fetch n8n instances", zap.Error(err))
+		return e.JSON(http.StatusServiceUnavailable, FleetStatus{Status: StatusFailing, Mode: mode})
+	}
+
+	now := time.Now()
+	states := make([]InstanceState, 0, len(records))
+	for _, record := range records {
+		states = append(states, instanceState(record, now))
+	}
+
+	status := aggregate(states, mode)
+	if !canViewDetails(e) {
+		status.Instances = nil
+	}
+
+	code := http.StatusOK
+	if status.Status == StatusFailing {
+		code = http.StatusServiceUnavailable
+	}
+	return e.JSON(code, status)
+}
+
+// bindInstanceRoutes registers the fleet health endpoint
+func bindInstanceRoutes(se *core.ServeEvent, logger *zap.Logger) {
+	se.Router.GET("/api/health/instances", func(e *core.RequestEvent) error {
+		return instancesHandler(e, logger)
+	})
+}
@@ -0,0 +1,50 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func states(health ...string) []InstanceState {
+	result := make([]InstanceState, len(health))
+	for i, h := range health {
+		result[i] = InstanceState{ID: string(rune('a' + i)), Health: h}
+	}
+	return result
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []InstanceState
+		mode   string
+		want   string
+	}{
+		{"all healthy", states("healthy", "healthy"), FailAny, StatusOK},
+		{"no instances", states(), FailAll, StatusOK},
+		{"degraded only", states("healthy", "degraded"), FailAny, StatusDegraded},
+		{"one down, any", states("healthy", "down"), FailAny, StatusFailing},
+		{"one down, all", states("healthy", "down"), FailAll, StatusDegraded},
+		{"all down, all", states("down", "stale"), FailAll, StatusFailing},
+		{"all down, manager", states("down", "down"), FailManager, StatusDegraded},
+		{"stale counts as down", states("healthy", "stale"), FailAny, StatusFailing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := aggregate(tt.states, tt.mode)
+			assert.Equal(t, tt.want, status.Status)
+			assert.Equal(t, len(tt.states), status.Total)
+		})
+	}
+}
+
+func TestAggregateCounts(t *testing.T) {
+	status := aggregate(states("healthy", "degraded", "down", "stale", "healthy"), FailAll)
+
+	assert.Equal(t, 2, status.Healthy)
+	assert.Equal(t, 1, status.Degraded)
+	assert.Equal(t, 1, status.Down)
+	assert.Equal(t, 1, status.Stale)
+}
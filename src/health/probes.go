@@ -0,0 +1,108 @@
+package health
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/n8n"
+	"go.uber.org/zap"
+)
+
+// Probe paths for Kubernetes probes and Docker HEALTHCHECK
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// requiredJobs must be registered for the manager to be ready
+var requiredJobs = []string{n8n.CheckInstancesJob}
+
+// ProbeResult is the response of the probe endpoints
+type ProbeResult struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// livenessHandler reports that the process is up and serving requests
+func livenessHandler(e *core.RequestEvent) error {
+	return e.JSON(http.StatusOK, ProbeResult{Status: "ok"})
+}
+
+// readinessCheck returns "ok" or why the manager isn't ready. The reason is
+// served without authentication, details are in err, which is only logged.
+type readinessCheck func(app core.App) (reason string, err error)
+
+// readinessChecks are the checks of the readiness endpoint by name
+var readinessChecks = map[string]readinessCheck{
+	"database":   checkDatabase,
+	"cron":       checkCron,
+	"migrations": checkMigrations,
+}
+
+// readinessHandler reports whether the manager can do its work: the
+// database is reachable, the cron jobs are registered and all migrations
+// are applied
+func readinessHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	result := ProbeResult{Status: "ok", Checks: map[string]string{}}
+	for name, check := range readinessChecks {
+		reason, err := check(e.App)
+		result.Checks[name] = reason
+		if reason != "ok" {
+			result.Status = "failing"
+			logger.Warn("Readiness check failed", zap.String("check", name), zap.String("reason", reason), zap.Error(err))
+		}
+	}
+
+	if result.Status != "ok" {
+		return e.JSON(http.StatusServiceUnavailable, result)
+	}
+	return e.JSON(http.StatusOK, result)
+}
+
+func checkDatabase(app core.App) (string, error) {
+	var one int
+	if err := app.DB().NewQuery("SELECT 1").Row(&one); err != nil {
+		return "unreachable", err
+	}
+	return "ok", nil
+}
+
+func checkCron(app core.App) (string, error) {
+	registered := make([]string, 0, app.Cron().Total())
+	for _, job := range app.Cron().Jobs() {
+		registered = append(registered, job.Id())
+	}
+	for _, id := range requiredJobs {
+		if !slices.Contains(registered, id) {
+			return "cron job " + id + " not registered", nil
+		}
+	}
+	return "ok", nil
+}
+
+func checkMigrations(app core.App) (string, error) {
+	var applied []string
+	err := app.DB().Select("file").From(core.DefaultMigrationsTable).Column(&applied)
+	if err != nil {
+		return "unreadable", err
+	}
+
+	for _, list := range []core.MigrationsList{core.SystemMigrations, core.AppMigrations} {
+		for _, migration := range list.Items() {
+			if !slices.Contains(applied, migration.File) {
+				return "migration " + migration.File + " not applied", nil
+			}
+		}
+	}
+	return "ok", nil
+}
+
+// bindProbeRoutes registers the liveness and readiness endpoints. They are
+// unauthenticated and don't depend on PocketBase's admin routes.
+func bindProbeRoutes(se *core.ServeEvent, logger *zap.Logger) {
+	se.Router.GET(LivenessPath, livenessHandler)
+	se.Router.GET(ReadinessPath, func(e *core.RequestEvent) error {
+		return readinessHandler(e, logger)
+	})
+}
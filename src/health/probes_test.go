@@ -0,0 +1,69 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/n8n"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// probe calls the readiness endpoint of app
+func probe(t *testing.T, app core.App, logger *zap.Logger) (int, ProbeResult) {
+	e := &core.RequestEvent{}
+	e.App = app
+	e.Request = httptest.NewRequest(http.MethodGet, ReadinessPath, nil)
+	recorder := httptest.NewRecorder()
+	e.Response = recorder
+	require.NoError(t, readinessHandler(e, logger))
+
+	var result ProbeResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	return recorder.Code, result
+}
+
+func TestReadiness(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	status, result := probe(t, app, zap.NewNop())
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "failing", result.Status)
+	assert.Equal(t, "cron job "+n8n.CheckInstancesJob+" not registered", result.Checks["cron"])
+	assert.Equal(t, "ok", result.Checks["database"])
+
+	app.Cron().MustAdd(n8n.CheckInstancesJob, "* * * * *", func() {})
+	status, result = probe(t, app, zap.NewNop())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ProbeResult{Status: "ok", Checks: map[string]string{"database": "ok", "cron": "ok", "migrations": "ok"}}, result)
+
+	latest := core.AppMigrations.Items()[len(core.AppMigrations.Items())-1].File
+	_, err := app.DB().Delete(core.DefaultMigrationsTable, dbx.HashExp{"file": latest}).Execute()
+	require.NoError(t, err)
+	status, result = probe(t, app, zap.NewNop())
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "migration "+latest+" not applied", result.Checks["migrations"])
+}
+
+func TestReadinessHidesErrors(t *testing.T) {
+	app := testutil.NewApp(t)
+	app.Cron().MustAdd(n8n.CheckInstancesJob, "* * * * *", func() {})
+	require.NoError(t, app.DB().(*dbx.DB).Close())
+
+	// The reasons are served without authentication, the errors only logged
+	observed, logs := observer.New(zap.WarnLevel)
+	status, result := probe(t, app, zap.New(observed))
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "unreachable", result.Checks["database"])
+	assert.Equal(t, "unreadable", result.Checks["migrations"])
+	database := logs.FilterField(zap.String("check", "database")).All()
+	require.Len(t, database, 1)
+	assert.Contains(t, database[0].ContextMap()["error"], "closed")
+}
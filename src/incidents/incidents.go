@@ -0,0 +1,276 @@
+// Package incidents records outages of instances and of the webhooks behind
+// routes, as reported by the instance and webhook checks, into the
+// incidents collection. The history is the source of the uptime and the
+// incident list of the status page.
+//
+// An incident opens when a check fails after a successful one and resolves
+// with the next successful check, so its duration is accurate to the check
+// interval. Instances going down and coming back are alerted with the
+// severity of their environment.
+package incidents
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/environments"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// Collection stores the incidents
+const Collection = "incidents"
+
+// PurgeJob is the id of the cron job deleting old incidents
+const PurgeJob = "purge-incidents"
+
+// HistoryDays is how many days of incidents are kept and reported
+const HistoryDays = 90
+
+// Kinds of components incidents are recorded for
+const (
+	KindInstance = "instance"
+	KindWebhook  = "webhook"
+)
+
+// Incident is an outage of a component
+type Incident struct {
+	Kind string `json:"kind"`
+
+	// Component identifies the instance or webhook, see WebhookComponent
+	Component string `json:"-"`
+
+	// Name is the host of the instance or the public address of the webhook
+	Name     string     `json:"name"`
+	Note     string     `json:"note,omitempty"`
+	Started  time.Time  `json:"started"`
+	Resolved *time.Time `json:"resolved,omitempty"`
+}
+
+// WebhookComponent returns the component of the webhook of instance with
+// path (e.g. "/webhook/orders"). Webhook records are recreated by every
+// sync, the path stays.
+func WebhookComponent(instance, path string) string {
+	return instance + " " + path
+}
+
+// Init records incidents from the instance and webhook checks and purges
+// incidents older than HistoryDays once a day
+func Init(app core.App, logger *zap.Logger) {
+	app.OnRecordAfterUpdateSuccess("instances").BindFunc(func(e *core.RecordEvent) error {
+		wasDown, down := instanceDown(e.Record.Original()), instanceDown(e.Record)
+		if wasDown != down {
+			recordTransition(e.App, logger, KindInstance, e.Record.Id, instanceName(e.Record),
+				e.Record.GetString("availability_note"), down)
+			notifyInstance(e.App, logger, e.Record, down)
+		}
+		return e.Next()
+	})
+
+	app.OnRecordAfterUpdateSuccess("webhooks").BindFunc(func(e *core.RecordEvent) error {
+		original := e.Record.Original()
+		if e.Record.GetDateTime("checked_at").Equal(original.GetDateTime("checked_at")) {
+			return e.Next()
+		}
+		path, name, routed := webhookRoute(e.App, e.Record)
+		if !routed {
+			return e.Next()
+		}
+		down := !e.Record.GetBool("reachable") || e.Record.GetBool("route_broken")
+		recordTransition(e.App, logger, KindWebhook, WebhookComponent(e.Record.GetString("instance"), path), name,
+			e.Record.GetString("check_note"), down)
+		return e.Next()
+	})
+
+	app.Cron().MustAdd(PurgeJob, "45 4 * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", PurgeJob))
+
+		if err := purge(app, time.Now().AddDate(0, 0, -HistoryDays)); err != nil {
+			logger.Error("Failed to purge incidents", zap.Error(err))
+		}
+	})
+}
+
+// recordTransition opens or resolves the incident of component
+func recordTransition(app core.App, logger *zap.Logger, kind, component, name, note string, down bool) {
+	var err error
+	if down {
+		err = Open(app, kind, component, name, note, time.Now())
+	} else {
+		err = Resolve(app, component, time.Now())
+	}
+	if err != nil {
+		logger.Error("Failed to record incident",
+			zap.Error(err),
+			zap.String("kind", kind),
+			zap.String("component", component))
+	}
+}
+
+// notifyInstance alerts about an instance going down or coming back with the
+// alert severity of its environment, environments with SeverityLog only log
+func notifyInstance(app core.App, logger *zap.Logger, record *core.Record, down bool) {
+	severity := environments.AlertSeverity(app, record)
+	environment := environments.NameOf(app, record)
+	if severity == environments.SeverityLog {
+		if down {
+			logger.Warn("Instance is down",
+				zap.String("instance", record.Id),
+				zap.String("environment", environment),
+				zap.String("note", record.GetString("availability_note")))
+		} else {
+			logger.Info("Instance is up again",
+				zap.String("instance", record.Id),
+				zap.String("environment", environment))
+		}
+		return
+	}
+
+	n := notify.Notification{
+		Event:    "instance.down",
+		Severity: severity,
+		Title:    instanceName(record) + " is down",
+		Message:  record.GetString("availability_note"),
+		Resolved: !down,
+		Fields: map[string]any{
+			"instance":    record.Id,
+			"environment": environment,
+		},
+	}
+	if !down {
+		n.Title = instanceName(record) + " is up again"
+	}
+	if err := notify.Send(context.Background(), n); err != nil {
+		logger.Error("Failed to send instance notification", zap.Error(err), zap.String("instance", record.Id))
+	}
+}
+
+// instanceDown reports whether an instance record is down. Degraded
+// instances still serve requests.
+func instanceDown(record *core.Record) bool {
+	switch record.GetString("health") {
+	case "healthy", "degraded":
+		return false
+	case "":
+		// Instances checked before tri-state health existed
+		return !record.GetBool("availability_status")
+	}
+	return true
+}
+
+// instanceName returns the host name of an instance record
+func instanceName(record *core.Record) string {
+	if u, err := url.Parse(record.GetString("host")); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return record.GetString("host")
+}
+
+// webhookRoute returns the path of a webhooks record and the public address
+// of its route, routed is false if no route exposes it
+func webhookRoute(app core.App, webhook *core.Record) (path, name string, routed bool) {
+	u, err := url.Parse(webhook.GetString("webhook_url"))
+	if err != nil {
+		return "", "", false
+	}
+	if annotation := webhook.GetString("route"); annotation != "" {
+		return u.Path, annotation, true
+	}
+
+	routes, err := app.FindAllRecords("routes", dbx.HashExp{
+		"instance":     webhook.GetString("instance"),
+		"webhook_path": u.Path,
+		"active":       true,
+		trash.Field:    "",
+	})
+	if err != nil || len(routes) == 0 {
+		return "", "", false
+	}
+	return u.Path, routes[0].GetString("host") + routes[0].GetString("path"), true
+}
+
+// Open records an incident of component starting at, unless one is open
+func Open(app core.App, kind, component, name, note string, at time.Time) error {
+	open, err := app.FindAllRecords(Collection, dbx.HashExp{"component": component, "resolved": ""})
+	if err != nil {
+		return err
+	}
+	if len(open) > 0 {
+		return nil
+	}
+
+	collection, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(collection)
+	record.Set("kind", kind)
+	record.Set("component", component)
+	record.Set("name", name)
+	record.Set("note", note)
+	record.Set("started", at)
+	return app.Save(record)
+}
+
+// Resolve ends the open incidents of component at
+func Resolve(app core.App, component string, at time.Time) error {
+	open, err := app.FindAllRecords(Collection, dbx.HashExp{"component": component, "resolved": ""})
+	if err != nil {
+		return err
+	}
+	for _, record := range open {
+		record.Set("resolved", at)
+		if err := app.Save(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the incidents open after since, latest first
+func List(app core.App, since time.Time) ([]Incident, error) {
+	records, err := app.FindRecordsByFilter(Collection,
+		"resolved = '' || resolved >= {:since}", "-started", 0, 0,
+		dbx.Params{"since": since.UTC().Format(types.DefaultDateLayout)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch incidents: %w", err)
+	}
+
+	incidents := make([]Incident, len(records))
+	for i, record := range records {
+		incidents[i] = Incident{
+			Kind:      record.GetString("kind"),
+			Component: record.GetString("component"),
+			Name:      record.GetString("name"),
+			Note:      record.GetString("note"),
+			Started:   record.GetDateTime("started").Time(),
+		}
+		if resolved := record.GetDateTime("resolved"); !resolved.IsZero() {
+			at := resolved.Time()
+			incidents[i].Resolved = &at
+		}
+	}
+	return incidents, nil
+}
+
+// purge deletes the incidents resolved before
+func purge(app core.App, before time.Time) error {
+	records, err := app.FindAllRecords(Collection,
+		dbx.NewExp("resolved != '' AND resolved < {:before}", dbx.Params{"before": before.UTC().Format(types.DefaultDateLayout)}))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := app.Delete(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
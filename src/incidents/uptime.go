@@ -0,0 +1,97 @@
+package incidents
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Interval is a period a component was down
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Day is the uptime of a day
+type Day struct {
+	Date   string  `json:"date"`
+	Uptime float64 `json:"uptime"`
+}
+
+// Downtime returns the periods between from and to in which any of
+// components had an incident, merged and sorted. Open incidents last
+// until to.
+func Downtime(incidents []Incident, from, to time.Time, components ...string) []Interval {
+	var intervals []Interval
+	for _, incident := range incidents {
+		if !slices.Contains(components, incident.Component) {
+			continue
+		}
+		interval := Interval{Start: incident.Started, End: to}
+		if incident.Resolved != nil && incident.Resolved.Before(to) {
+			interval.End = *incident.Resolved
+		}
+		if interval.Start.Before(from) {
+			interval.Start = from
+		}
+		if interval.End.After(interval.Start) {
+			intervals = append(intervals, interval)
+		}
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start.Before(intervals[j].Start) })
+	merged := intervals[:0]
+	for _, interval := range intervals {
+		if last := len(merged) - 1; last >= 0 && !interval.Start.After(merged[last].End) {
+			if interval.End.After(merged[last].End) {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// Uptime returns the percentage of the time between from and to not
+// covered by the merged downtime, rounded to three decimals
+func Uptime(downtime []Interval, from, to time.Time) float64 {
+	total := to.Sub(from)
+	if total <= 0 {
+		return 100
+	}
+
+	var down time.Duration
+	for _, interval := range downtime {
+		start, end := interval.Start, interval.End
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			down += end.Sub(start)
+		}
+	}
+	return math.Round((1-down.Seconds()/total.Seconds())*100*1000) / 1000
+}
+
+// Daily returns the uptime of each of the last days days in loc, the last
+// one being today until now
+func Daily(downtime []Interval, days int, now time.Time, loc *time.Location) []Day {
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	result := make([]Day, days)
+	for i := range result {
+		start := today.AddDate(0, 0, i-days+1)
+		end := start.AddDate(0, 0, 1)
+		if end.After(now) {
+			end = now
+		}
+		result[i] = Day{Date: start.Format(time.DateOnly), Uptime: Uptime(downtime, start, end)}
+	}
+	return result
+}
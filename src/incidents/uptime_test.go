@@ -0,0 +1,73 @@
+package incidents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(hour int) time.Time {
+	return time.Date(2025, 4, 14, hour, 0, 0, 0, time.UTC)
+}
+
+func resolved(t time.Time) *time.Time {
+	return &t
+}
+
+func TestDowntime(t *testing.T) {
+	history := []Incident{
+		{Component: "instance", Started: at(1), Resolved: resolved(at(3))},
+		{Component: "hook", Started: at(2), Resolved: resolved(at(4))},
+		{Component: "hook", Started: at(6), Resolved: resolved(at(7))},
+		{Component: "other", Started: at(8), Resolved: resolved(at(9))},
+		{Component: "instance", Started: at(10)},
+	}
+
+	downtime := Downtime(history, at(0), at(12), "instance", "hook")
+	assert.Equal(t, []Interval{
+		{Start: at(1), End: at(4)},
+		{Start: at(6), End: at(7)},
+		{Start: at(10), End: at(12)},
+	}, downtime, "overlapping incidents are merged, open ones last until the end")
+
+	downtime = Downtime(history, at(2), at(11), "instance")
+	assert.Equal(t, []Interval{{Start: at(2), End: at(3)}, {Start: at(10), End: at(11)}}, downtime)
+
+	assert.Empty(t, Downtime(history, at(0), at(12), "unknown"))
+}
+
+func TestUptime(t *testing.T) {
+	downtime := []Interval{{Start: at(1), End: at(4)}, {Start: at(6), End: at(7)}}
+	assert.Equal(t, 66.667, Uptime(downtime, at(0), at(12)))
+	assert.Equal(t, 100.0, Uptime(downtime, at(8), at(12)))
+	assert.Equal(t, 0.0, Uptime(downtime, at(2), at(3)))
+	assert.Equal(t, 100.0, Uptime(nil, at(5), at(5)))
+}
+
+func TestDaily(t *testing.T) {
+	now := time.Date(2025, 4, 14, 12, 0, 0, 0, time.UTC)
+	downtime := []Interval{{
+		Start: time.Date(2025, 4, 12, 18, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 4, 13, 6, 0, 0, 0, time.UTC),
+	}, {
+		Start: time.Date(2025, 4, 14, 9, 0, 0, 0, time.UTC),
+		End:   now,
+	}}
+
+	days := Daily(downtime, 3, now, time.UTC)
+	require.Len(t, days, 3)
+	assert.Equal(t, []Day{
+		{Date: "2025-04-12", Uptime: 75},
+		{Date: "2025-04-13", Uptime: 75},
+		{Date: "2025-04-14", Uptime: 75},
+	}, days, "today only counts until now")
+
+	// Days start at midnight in the timezone, 06:00 UTC in UTC-6
+	days = Daily(downtime, 2, now, time.FixedZone("UTC-6", -6*3600))
+	assert.Equal(t, []Day{
+		{Date: "2025-04-13", Uptime: 100},
+		{Date: "2025-04-14", Uptime: 50},
+	}, days)
+}
@@ -0,0 +1,167 @@
+// Package kube is a minimal Kubernetes API client using the in-cluster
+// service account, enough to read Services, Pods and Secrets.
+package kube
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir holds the token, CA certificate and namespace of the pod
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ErrNotInCluster is returned when not running inside a Kubernetes pod
+var ErrNotInCluster = errors.New("not running in a Kubernetes cluster")
+
+// Client calls the Kubernetes API with the pod's service account
+type Client struct {
+	http      *http.Client
+	baseURL   string
+	tokenFile string
+
+	// Namespace is the namespace the pod runs in
+	Namespace string
+}
+
+// InCluster creates a client from the in-cluster configuration
+func InCluster() (*Client, error) {
+	return newClient(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"), serviceAccountDir)
+}
+
+func newClient(host, port, dir string) (*Client, error) {
+	if host == "" || port == "" {
+		return nil, ErrNotInCluster
+	}
+
+	pool := x509.NewCertPool()
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid service account CA")
+	}
+
+	namespace, _ := os.ReadFile(filepath.Join(dir, "namespace"))
+
+	return &Client{
+		http: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		},
+		baseURL:   "https://" + net.JoinHostPort(host, port),
+		tokenFile: filepath.Join(dir, "token"),
+		Namespace: strings.TrimSpace(string(namespace)),
+	}, nil
+}
+
+// Get requests path (e.g. /api/v1/namespaces/default/services) and decodes
+// the JSON response into target
+func (c *Client) Get(ctx context.Context, path string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	// The token is rotated by the kubelet, read it for every request
+	token, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read service account token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// ObjectMeta is the metadata shared by all objects
+type ObjectMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// ServicePort is a port exposed by a Service
+type ServicePort struct {
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+// Service is the subset of a Service object used for discovery
+type Service struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Ports []ServicePort `json:"ports"`
+	} `json:"spec"`
+}
+
+// ContainerPort is a port declared by a pod container
+type ContainerPort struct {
+	Name          string `json:"name"`
+	ContainerPort int    `json:"containerPort"`
+}
+
+// Pod is the subset of a Pod object used for discovery
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Containers []struct {
+			Ports []ContainerPort `json:"ports"`
+		} `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		Phase      string `json:"phase"`
+		PodIP      string `json:"podIP"`
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// Ready reports whether the pod is running and ready
+func (p Pod) Ready() bool {
+	if p.Status.Phase != "Running" || p.Status.PodIP == "" {
+		return false
+	}
+	for _, condition := range p.Status.Conditions {
+		if condition.Type == "Ready" {
+			return condition.Status == "True"
+		}
+	}
+	return false
+}
+
+// Secret is a Secret object, data values are base64 encoded
+type Secret struct {
+	Metadata ObjectMeta        `json:"metadata"`
+	Data     map[string][]byte `json:"data"`
+}
@@ -0,0 +1,46 @@
+package kube
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientGet(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "/api/v1/namespaces/automation/secrets/n8n", r.URL.Path)
+		w.Write([]byte(`{"metadata":{"name":"n8n"},"data":{"api-key":"c2VjcmV0"}}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("test-token\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "namespace"), []byte("automation"), 0600))
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	client, err := newClient(host, port, dir)
+	require.NoError(t, err)
+	assert.Equal(t, "automation", client.Namespace)
+
+	var secret Secret
+	require.NoError(t, client.Get(context.Background(), "/api/v1/namespaces/automation/secrets/n8n", &secret))
+	assert.Equal(t, "secret", string(secret.Data["api-key"]))
+}
+
+func TestNotInCluster(t *testing.T) {
+	_, err := newClient("", "", t.TempDir())
+	assert.ErrorIs(t, err, ErrNotInCluster)
+}
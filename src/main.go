@@ -1,20 +1,47 @@
 package main
 
 import (
+	"context"
 	"os"
 	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/plugins/migratecmd"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/sistemica/n8n-manager-backend/admin"
+	"github.com/sistemica/n8n-manager-backend/backup"
+	"github.com/sistemica/n8n-manager-backend/bootstrap"
+	"github.com/sistemica/n8n-manager-backend/cli"
+	"github.com/sistemica/n8n-manager-backend/configfile"
+	"github.com/sistemica/n8n-manager-backend/devmock"
+	"github.com/sistemica/n8n-manager-backend/discovery"
+	"github.com/sistemica/n8n-manager-backend/environments"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"github.com/sistemica/n8n-manager-backend/gateway"
+	"github.com/sistemica/n8n-manager-backend/graphql"
+	"github.com/sistemica/n8n-manager-backend/health"
+	"github.com/sistemica/n8n-manager-backend/incidents"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/metrics"
 	_ "github.com/sistemica/n8n-manager-backend/migrations"
 	"github.com/sistemica/n8n-manager-backend/n8n"
+	"github.com/sistemica/n8n-manager-backend/provider"
+	"github.com/sistemica/n8n-manager-backend/redact"
+	"github.com/sistemica/n8n-manager-backend/slo"
+	"github.com/sistemica/n8n-manager-backend/synthetic"
+	"github.com/sistemica/n8n-manager-backend/templates"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/tracing"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"github.com/sistemica/n8n-manager-backend/usage"
 )
 
-func initLogger() *zap.Logger {
+func initLogger() (*zap.Logger, zap.AtomicLevel) {
 	// Get log level from environment variable (default to "info")
 	logLevel := os.Getenv("LOG_LEVEL")
 	var level zapcore.Level
@@ -33,11 +60,14 @@ func initLogger() *zap.Logger {
 	}
 
 	config := zap.NewProductionConfig()
+	// The atomic level can be changed at runtime via PUT /api/admin/loglevel
 	config.Level = zap.NewAtomicLevelAt(level)
 	config.EncoderConfig.TimeKey = "timestamp"
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	logger, err := config.Build()
+	// Report errors to Sentry if configured, and mask API keys and
+	// Authorization values in every log line and reported event
+	logger, err := config.Build(errorreport.WrapCore(), redact.WrapCore())
 	if err != nil {
 		panic(err)
 	}
@@ -45,32 +75,92 @@ func initLogger() *zap.Logger {
 	// Log the current level for confirmation
 	logger.Info("Logger initialized", zap.String("level", level.String()))
 
-	return logger
+	return logger, config.Level
 }
 
 func main() {
-	logger := initLogger()
+	// The .env file and the config file only fill in variables that aren't
+	// set, both are loaded before the logger reads LOG_LEVEL
+	dotenvErr := godotenv.Load()
+	configFile, configSettings, configErr := configfile.Load()
+
+	logger, logLevel := initLogger()
 	defer logger.Sync()
 
-	if err := godotenv.Load(); err != nil {
+	if dotenvErr != nil {
 		logger.Info("No .env file found, using environment variables")
 	}
+	if configErr != nil {
+		logger.Fatal("Failed to load config file", zap.Error(configErr))
+	}
+	if configFile != "" {
+		logger.Info("Loaded config file", zap.String("path", configFile), zap.Strings("settings", configSettings))
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8090"
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
+	flushErrors, err := errorreport.Init(logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize error reporting", zap.Error(err))
+	}
+
 	app := pocketbase.New()
 
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+		flushErrors()
+		return e.Next()
+	})
+
 	isGoRun := strings.HasPrefix(os.Args[0], os.TempDir())
 	migratecmd.MustRegister(app, app.RootCmd, migratecmd.Config{
 		Automigrate: isGoRun,
 	})
 
+	if err := timezone.Init(app); err != nil {
+		logger.Fatal("Failed to initialize timezone", zap.Error(err))
+	}
+
+	bootstrap.Init(app, logger)
+	discovery.InitFile(app, logger)
+	discovery.InitDocker(app, logger)
+	discovery.InitKubernetes(app, logger)
+	devmock.Init(app, logger)
+	redact.BindRecordHooks(app)
 	n8n.InitCronJobs(app, logger)
+	n8n.InitAPI(app, logger)
+	templates.InitAPI(app, logger)
+	provider.InitRoutes(app, logger)
+	gateway.Init(app, logger)
+	usage.Init(app, logger)
+	incidents.Init(app, logger)
+	trash.Init(app, logger)
+	features.Init(app)
+	backup.InitTargets(app)
+	backup.InitVerification(app, logger)
+	environments.InitRoutes(app)
+	synthetic.Init(app, logger)
+	slo.Init(app, logger)
+	maintenance.InitRoutes(app, logger)
+	admin.InitRoutes(app, logger, logLevel)
+	metrics.InitRoutes(app, logger)
+	graphql.InitRoutes(app, logger)
+	health.InitRoutes(app, logger)
 
 	app.RootCmd.PersistentFlags().String("http", "0.0.0.0:"+port, "the HTTP server address")
+	app.RootCmd.AddCommand(health.NewHealthcheckCommand(port))
+	app.RootCmd.AddCommand(cli.NewInstancesCommand(app))
+	app.RootCmd.AddCommand(cli.NewConfigCommand(app))
 
 	logger.Info("Starting PocketBase server",
 		zap.String("port", port),
@@ -0,0 +1,136 @@
+// Package maintenance holds the global maintenance switch for planned
+// outages. While it is enabled the instance sync is paused and the Traefik
+// configuration answers every route with a 503 maintenance response.
+package maintenance
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// Collection is the name of the collection holding the maintenance state
+const Collection = "maintenance"
+
+// Path is the endpoint answering requests during maintenance
+const Path = "/api/maintenance"
+
+const defaultMessage = "The service is down for maintenance, please try again later."
+
+// State is the current maintenance state
+type State struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+	// Forced is set if MAINTENANCE_MODE=true, the state can't be changed then
+	Forced    bool       `json:"forced,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+// forced reports whether MAINTENANCE_MODE=true enables maintenance
+func forced() bool {
+	return os.Getenv("MAINTENANCE_MODE") == "true"
+}
+
+// ServiceURL returns MAINTENANCE_SERVICE_URL, the URL Traefik reaches the
+// manager at (e.g. "http://n8n-manager:8090"). Without it all routers are
+// dropped during maintenance instead.
+func ServiceURL() string {
+	return os.Getenv("MAINTENANCE_SERVICE_URL")
+}
+
+// Current returns the maintenance state
+func Current(app core.App) (State, error) {
+	state := State{Forced: forced()}
+
+	records, err := app.FindRecordsByFilter(Collection, "", "-updated", 1, 0)
+	if err != nil {
+		return state, err
+	}
+	if len(records) > 0 {
+		record := records[0]
+		state.Enabled = record.GetBool("enabled")
+		state.Message = record.GetString("message")
+		state.UpdatedBy = record.GetString("updated_by")
+		if endsAt := record.GetDateTime("ends_at"); !endsAt.IsZero() {
+			t := endsAt.Time()
+			state.EndsAt = &t
+		}
+	}
+
+	state.Enabled = state.Enabled || state.Forced
+	if state.Message == "" {
+		state.Message = defaultMessage
+	}
+	return state, nil
+}
+
+// Enabled reports whether maintenance is enabled. A failed lookup counts as
+// disabled, so a broken database doesn't take all routes down.
+func Enabled(app core.App, logger *zap.Logger) bool {
+	state, err := Current(app)
+	if err != nil {
+		logger.Error("Failed to read maintenance state", zap.Error(err))
+	}
+	return state.Enabled
+}
+
+// Set stores the maintenance state
+func Set(app core.App, state State) error {
+	collection, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		return err
+	}
+
+	records, err := app.FindRecordsByFilter(collection, "", "-updated", 1, 0)
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(collection)
+	if len(records) > 0 {
+		record = records[0]
+	}
+
+	record.Set("enabled", state.Enabled)
+	record.Set("message", state.Message)
+	record.Set("updated_by", state.UpdatedBy)
+	if state.EndsAt != nil {
+		record.Set("ends_at", *state.EndsAt)
+	} else {
+		record.Set("ends_at", "")
+	}
+	return app.Save(record)
+}
+
+// InitRoutes registers the maintenance responder, which Traefik forwards
+// all requests to during maintenance
+func InitRoutes(app core.App, logger *zap.Logger) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.Any(Path, func(e *core.RequestEvent) error {
+			state, err := Current(e.App)
+			if err != nil {
+				logger.Error("Failed to read maintenance state", zap.Error(err))
+			}
+
+			if state.EndsAt != nil {
+				if wait := time.Until(*state.EndsAt); wait > 0 {
+					e.Response.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())))
+				}
+			}
+			return e.JSON(http.StatusServiceUnavailable, map[string]any{
+				"status":  http.StatusServiceUnavailable,
+				"message": state.Message,
+				"ends_at": state.EndsAt,
+			})
+		})
+
+		if forced() {
+			logger.Warn("Maintenance mode enabled by MAINTENANCE_MODE", zap.String("responder", Path))
+		}
+		return se.Next()
+	})
+}
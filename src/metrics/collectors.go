@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sistemica/n8n-manager-backend/n8n"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+var instanceLabels = []string{"instance", "host"}
+
+// instanceGauge maps an instance_metrics field to an exported gauge
+type instanceGauge struct {
+	field string
+	desc  *prometheus.Desc
+}
+
+func newInstanceGauge(field, name, help string) instanceGauge {
+	return instanceGauge{
+		field: field,
+		desc:  prometheus.NewDesc("n8n_manager_instance_"+name, help, instanceLabels, nil),
+	}
+}
+
+// instanceCollector re-exports the latest scraped metrics of every instance
+type instanceCollector struct {
+	app    core.App
+	logger *zap.Logger
+
+	up        *prometheus.Desc
+	health    *prometheus.Desc
+	workersUp *prometheus.Desc
+	storage   *prometheus.Desc
+	gauges    []instanceGauge
+	// disk gauges are fields of the instance, set by its disk probe
+	disk []instanceGauge
+}
+
+func newInstanceCollector(app core.App, logger *zap.Logger) *instanceCollector {
+	return &instanceCollector{
+		app:    app,
+		logger: logger,
+		up: prometheus.NewDesc("n8n_manager_instance_up",
+			"Whether the last check of the instance succeeded", instanceLabels, nil),
+		health: prometheus.NewDesc("n8n_manager_instance_health",
+			"Health of the instance, 1 for the current state", append(instanceLabels, "state"), nil),
+		workersUp: prometheus.NewDesc("n8n_manager_instance_workers_up",
+			"Reachable queue mode workers of the instance", instanceLabels, nil),
+		storage: prometheus.NewDesc("n8n_manager_instance_storage_bytes",
+			"Stored size of the workflow history of the instance", instanceLabels, nil),
+		gauges: []instanceGauge{
+			newInstanceGauge("event_loop_lag", "event_loop_lag_seconds", "Node.js event loop lag of the instance"),
+			newInstanceGauge("queue_waiting", "queue_jobs_waiting", "Jobs waiting in the queue (queue mode)"),
+			newInstanceGauge("queue_active", "queue_jobs_active", "Jobs being executed (queue mode)"),
+			newInstanceGauge("queue_failed", "queue_jobs_failed", "Failed queue jobs (queue mode)"),
+			newInstanceGauge("active_workflows", "active_workflows", "Active workflows reported by the instance"),
+			newInstanceGauge("heap_used", "heap_used_bytes", "Node.js heap used by the instance"),
+		},
+		disk: []instanceGauge{
+			newInstanceGauge("binary_data_bytes", "binary_data_bytes", "Binary data stored by the instance"),
+			newInstanceGauge("database_bytes", "database_bytes", "Database size of the instance"),
+			newInstanceGauge("disk_used_percent", "disk_used_percent", "Used disk space of the instance in percent"),
+		},
+	}
+}
+
+func (c *instanceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.health
+	ch <- c.workersUp
+	ch <- c.storage
+	for _, gauge := range c.gauges {
+		ch <- gauge.desc
+	}
+	for _, gauge := range c.disk {
+		ch <- gauge.desc
+	}
+}
+
+func (c *instanceCollector) Collect(ch chan<- prometheus.Metric) {
+	instances, err := c.app.FindAllRecords("instances", trash.NotDeleted)
+	if err != nil {
+		c.logger.Error("Failed to fetch n8n instances", zap.Error(err))
+		return
+	}
+
+	for _, instance := range instances {
+		labels := []string{instance.Id, instance.GetString("host")}
+
+		up := 0.0
+		if instance.GetBool("availability_status") {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up, labels...)
+
+		if health := instance.GetString("health"); health != "" {
+			for _, state := range []string{"healthy", "degraded", "down"} {
+				value := 0.0
+				if state == health {
+					value = 1
+				}
+				ch <- prometheus.MustNewConstMetric(c.health, prometheus.GaugeValue, value, append(labels, state)...)
+			}
+		}
+
+		if instance.GetString("execution_mode") == "queue" {
+			ch <- prometheus.MustNewConstMetric(c.workersUp, prometheus.GaugeValue,
+				float64(instance.GetInt("workers_up")), labels...)
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.storage, prometheus.GaugeValue,
+			instance.GetFloat("storage_bytes"), labels...)
+
+		if instance.GetString("disk_probe_url") != "" {
+			for _, gauge := range c.disk {
+				ch <- prometheus.MustNewConstMetric(gauge.desc, prometheus.GaugeValue,
+					instance.GetFloat(gauge.field), labels...)
+			}
+		}
+
+		if !instance.GetBool("metrics_enabled") {
+			continue
+		}
+
+		latest, err := c.app.FindRecordsByFilter("instance_metrics",
+			"instance = {:instance}", "-created", 1, 0,
+			dbx.Params{"instance": instance.Id})
+		if err != nil || len(latest) == 0 {
+			continue
+		}
+
+		for _, gauge := range c.gauges {
+			ch <- prometheus.MustNewConstMetric(gauge.desc, prometheus.GaugeValue,
+				latest[0].GetFloat(gauge.field), labels...)
+		}
+	}
+}
+
+// schedulerCollector exports the state of the instance check scheduler
+type schedulerCollector struct {
+	queueDepth   *prometheus.Desc
+	tickLag      *prometheus.Desc
+	tickDuration *prometheus.Desc
+}
+
+func newSchedulerCollector() *schedulerCollector {
+	return &schedulerCollector{
+		queueDepth: prometheus.NewDesc("n8n_manager_sync_queue_depth",
+			"Due instances not yet synced in the current tick", nil, nil),
+		tickLag: prometheus.NewDesc("n8n_manager_sync_tick_lag_seconds",
+			"Delay of the last check tick compared to its schedule", nil, nil),
+		tickDuration: prometheus.NewDesc("n8n_manager_sync_tick_duration_seconds",
+			"Duration of the last completed check tick", nil, nil),
+	}
+}
+
+func (c *schedulerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.queueDepth
+	ch <- c.tickLag
+	ch <- c.tickDuration
+}
+
+func (c *schedulerCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := n8n.Scheduler()
+	ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(stats.QueueDepth))
+	ch <- prometheus.MustNewConstMetric(c.tickLag, prometheus.GaugeValue, stats.LastTickLag.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.tickDuration, prometheus.GaugeValue, stats.LastTickDuration.Seconds())
+}
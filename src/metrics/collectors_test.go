@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// gather collects the metrics of c by name
+func gather(t *testing.T, c prometheus.Collector) map[string]*dto.MetricFamily {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(c))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	byName := map[string]*dto.MetricFamily{}
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName
+}
+
+func TestInstanceCollectorGauges(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	scraped := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com", "metrics_enabled": true})
+	testutil.Create(t, app, "instance_metrics", map[string]any{"instance": scraped.Id, "queue_waiting": 5, "event_loop_lag": 0.5})
+	testutil.Create(t, app, "instances", map[string]any{"host": "other.example.com"})
+
+	families := gather(t, newInstanceCollector(app, zap.NewNop()))
+
+	require.Contains(t, families, "n8n_manager_instance_up")
+	assert.Len(t, families["n8n_manager_instance_up"].GetMetric(), 2)
+
+	// Only instances with metrics enabled export the scraped gauges
+	waiting := families["n8n_manager_instance_queue_jobs_waiting"].GetMetric()
+	require.Len(t, waiting, 1)
+	assert.Equal(t, 5.0, waiting[0].GetGauge().GetValue())
+	assert.Equal(t, scraped.Id, labelValue(waiting[0], "instance"))
+	assert.Equal(t, "n8n.example.com", labelValue(waiting[0], "host"))
+	assert.Equal(t, 0.5, families["n8n_manager_instance_event_loop_lag_seconds"].GetMetric()[0].GetGauge().GetValue())
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestInstanceCollectorHealth(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	queue := testutil.Create(t, app, "instances", map[string]any{
+		"host":                "queue.example.com",
+		"availability_status": true,
+		"health":              "degraded",
+		"execution_mode":      "queue",
+		"workers_up":          0,
+	})
+	testutil.Create(t, app, "instances", map[string]any{"host": "regular.example.com", "availability_status": true, "health": "healthy"})
+
+	families := gather(t, newInstanceCollector(app, zap.NewNop()))
+
+	// One series per state, 1 for the current one
+	states := map[string]float64{}
+	for _, metric := range families["n8n_manager_instance_health"].GetMetric() {
+		if labelValue(metric, "instance") == queue.Id {
+			states[labelValue(metric, "state")] = metric.GetGauge().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{"healthy": 0, "degraded": 1, "down": 0}, states)
+
+	// Workers are only exported for queue mode instances
+	workers := families["n8n_manager_instance_workers_up"].GetMetric()
+	require.Len(t, workers, 1)
+	assert.Equal(t, queue.Id, labelValue(workers[0], "instance"))
+	assert.Equal(t, 0.0, workers[0].GetGauge().GetValue())
+}
@@ -0,0 +1,62 @@
+// Package metrics serves the manager's Prometheus metrics, including the
+// gauges scraped from monitored n8n instances, labeled per instance.
+package metrics
+
+import (
+	"crypto/subtle"
+	"os"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// Path is the endpoint Prometheus scrapes
+const Path = "/metrics"
+
+// InitRoutes registers the /metrics endpoint
+func InitRoutes(app core.App, logger *zap.Logger) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		newSchedulerCollector(),
+		newInstanceCollector(app, logger),
+	)
+
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET(Path, apis.WrapStdHandler(handler)).Bind(RequireMetricsAuth())
+
+		return se.Next()
+	})
+}
+
+// RequireMetricsAuth accepts superusers and, if METRICS_TOKEN is set,
+// requests with "Authorization: Bearer <METRICS_TOKEN>" as sent by
+// Prometheus' authorization scrape option.
+func RequireMetricsAuth() *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "metricsAuth",
+		Func: func(e *core.RequestEvent) error {
+			if e.HasSuperuserAuth() {
+				return e.Next()
+			}
+
+			if token := os.Getenv("METRICS_TOKEN"); token != "" {
+				bearer, found := strings.CutPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+				if found && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+					return e.Next()
+				}
+			}
+
+			return apis.NewUnauthorizedError("Missing or invalid metrics credentials", nil)
+		},
+	}
+}
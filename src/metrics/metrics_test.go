@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireMetricsAuth(t *testing.T) {
+	check := func(authorization string) error {
+		e := &core.RequestEvent{}
+		e.Request = httptest.NewRequest(http.MethodGet, Path, nil)
+		if authorization != "" {
+			e.Request.Header.Set("Authorization", authorization)
+		}
+		return RequireMetricsAuth().Func(e)
+	}
+
+	t.Setenv("METRICS_TOKEN", "")
+	var apiErr *router.ApiError
+	require.ErrorAs(t, check("Bearer "), &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status)
+
+	t.Setenv("METRICS_TOKEN", "scrape-token")
+	assert.NoError(t, check("Bearer scrape-token"))
+	assert.Error(t, check("Bearer other-token"))
+	assert.Error(t, check("scrape-token"))
+}
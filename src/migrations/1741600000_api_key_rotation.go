@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Create the audit log collection, only visible to superusers
+		auditCollection := core.NewBaseCollection("audit_logs")
+		auditCollection.Fields.Add(
+			&core.TextField{
+				Name:     "action",
+				Required: true,
+			},
+			&core.TextField{
+				Name: "instance",
+			},
+			&core.TextField{
+				Name: "actor",
+			},
+			&core.TextField{
+				Name: "message",
+			},
+			&core.BoolField{
+				Name: "success",
+			},
+			&core.JSONField{
+				Name: "details",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		auditCollection.AddIndex("idx_audit_logs_instance", false, "instance", "")
+
+		if err := app.Save(auditCollection); err != nil {
+			return err
+		}
+
+		// Add rotation settings to instances
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.Add(
+			// n8n owner login used to manage API keys, may hold secret references
+			&core.TextField{
+				Name: "owner_email",
+			},
+			&core.TextField{
+				Name: "owner_password",
+			},
+			&core.TextField{
+				Name: "api_key_id",
+			},
+			&core.NumberField{
+				Name: "api_key_rotation_days",
+			},
+			&core.DateField{
+				Name: "api_key_rotated_at",
+			},
+		)
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range []string{"owner_email", "owner_password", "api_key_id", "api_key_rotation_days", "api_key_rotated_at"} {
+			instances.Fields.RemoveByName(name)
+		}
+		if err := app.Save(instances); err != nil {
+			return err
+		}
+
+		auditCollection, err := app.FindCollectionByNameOrId("audit_logs")
+		if err != nil {
+			return err
+		}
+		return app.Delete(auditCollection)
+	})
+}
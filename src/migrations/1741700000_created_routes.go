@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Create the routes collection - public Traefik routes to n8n webhooks
+		routesCollection := core.NewBaseCollection("routes")
+		routesCollection.ListRule = types.Pointer(`@request.auth.id != ""`)
+		routesCollection.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		routesCollection.CreateRule = types.Pointer(`@request.auth.id != ""`)
+		routesCollection.UpdateRule = types.Pointer(`@request.auth.id != ""`)
+		routesCollection.DeleteRule = types.Pointer(`@request.auth.id != ""`)
+		routesCollection.Fields.Add(
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			// Public host and path matched by Traefik
+			&core.TextField{
+				Name:     "host",
+				Required: true,
+			},
+			&core.TextField{
+				Name:     "path",
+				Required: true,
+			},
+			// Path on the n8n instance the request is forwarded to (e.g. /webhook/orders)
+			&core.TextField{
+				Name:     "webhook_path",
+				Required: true,
+			},
+			&core.JSONField{
+				Name: "entrypoints",
+			},
+			&core.JSONField{
+				Name: "path_params",
+			},
+			&core.JSONField{
+				Name: "query_params",
+			},
+			&core.SelectField{
+				Name:      "auth_type",
+				Values:    []string{"none", "basic", "apikey"},
+				MaxSelect: 1,
+			},
+			&core.TextField{
+				Name: "auth_username",
+			},
+			&core.TextField{
+				Name: "auth_password",
+			},
+			&core.TextField{
+				Name: "auth_api_key",
+			},
+			&core.BoolField{
+				Name: "active",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+
+		return app.Save(routesCollection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Create the sync_runs collection - one record per instance check
+		syncRuns := core.NewBaseCollection("sync_runs")
+		syncRuns.ListRule = types.Pointer(`@request.auth.id != ""`)
+		syncRuns.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		syncRuns.Fields.Add(
+			// Correlation id, attached to every log line of the run as sync_run_id
+			&core.TextField{
+				Name:     "run_id",
+				Required: true,
+			},
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			&core.DateField{
+				Name: "started",
+			},
+			&core.DateField{
+				Name: "finished",
+			},
+			&core.NumberField{
+				Name: "duration_ms",
+			},
+			&core.BoolField{
+				Name: "success",
+			},
+			&core.TextField{
+				Name: "error",
+			},
+			&core.NumberField{
+				Name: "workflows",
+			},
+			&core.NumberField{
+				Name: "webhooks",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		syncRuns.AddIndex("idx_sync_runs_run_id", true, "run_id", "")
+		syncRuns.AddIndex("idx_sync_runs_instance", false, "instance", "")
+
+		if err := app.Save(syncRuns); err != nil {
+			return err
+		}
+
+		// Correlate audit entries with the sync run that produced them
+		auditCollection, err := app.FindCollectionByNameOrId("audit_logs")
+		if err != nil {
+			return err
+		}
+		auditCollection.Fields.Add(&core.TextField{
+			Name: "run_id",
+		})
+
+		return app.Save(auditCollection)
+	}, func(app core.App) error {
+		auditCollection, err := app.FindCollectionByNameOrId("audit_logs")
+		if err != nil {
+			return err
+		}
+		auditCollection.Fields.RemoveByName("run_id")
+		if err := app.Save(auditCollection); err != nil {
+			return err
+		}
+
+		syncRuns, err := app.FindCollectionByNameOrId("sync_runs")
+		if err != nil {
+			return err
+		}
+		return app.Delete(syncRuns)
+	})
+}
@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Opt-in scraping of the instance's own /metrics (requires N8N_METRICS=true)
+		instances.Fields.Add(&core.BoolField{
+			Name: "metrics_enabled",
+		})
+		if err := app.Save(instances); err != nil {
+			return err
+		}
+
+		// Create the instance_metrics collection - gauges scraped per sync run
+		metrics := core.NewBaseCollection("instance_metrics")
+		metrics.ListRule = types.Pointer(`@request.auth.id != ""`)
+		metrics.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		metrics.Fields.Add(
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name: "run_id",
+			},
+			&core.NumberField{
+				Name: "event_loop_lag",
+			},
+			&core.NumberField{
+				Name: "queue_waiting",
+			},
+			&core.NumberField{
+				Name: "queue_active",
+			},
+			&core.NumberField{
+				Name: "queue_failed",
+			},
+			&core.NumberField{
+				Name: "active_workflows",
+			},
+			&core.NumberField{
+				Name: "heap_used",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		metrics.AddIndex("idx_instance_metrics_instance_created", false, "instance, created", "")
+
+		return app.Save(metrics)
+	}, func(app core.App) error {
+		metrics, err := app.FindCollectionByNameOrId("instance_metrics")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(metrics); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		instances.Fields.RemoveByName("metrics_enabled")
+		return app.Save(instances)
+	})
+}
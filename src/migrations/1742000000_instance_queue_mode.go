@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+var queueModeFields = []string{"execution_mode", "worker_health_urls", "workers_total", "workers_up", "health"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.Add(
+			// "regular" or "queue", as reported by the instance settings
+			&core.TextField{
+				Name: "execution_mode",
+			},
+			// Health check URLs of the queue workers (QUEUE_HEALTH_CHECK_ACTIVE=true)
+			&core.JSONField{
+				Name: "worker_health_urls",
+			},
+			&core.NumberField{
+				Name: "workers_total",
+			},
+			&core.NumberField{
+				Name: "workers_up",
+			},
+			&core.SelectField{
+				Name:      "health",
+				Values:    []string{"healthy", "degraded", "down"},
+				MaxSelect: 1,
+			},
+		)
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range queueModeFields {
+			instances.Fields.RemoveByName(name)
+		}
+		return app.Save(instances)
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Source that created the instance (e.g. "file"), empty for manual instances
+		instances.Fields.Add(&core.TextField{
+			Name: "managed_by",
+		})
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.RemoveByName("managed_by")
+		return app.Save(instances)
+	})
+}
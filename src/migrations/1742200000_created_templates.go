@@ -0,0 +1,111 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Create the templates collection - reusable workflow JSON with [[NAME]] placeholders
+		templates := core.NewBaseCollection("templates")
+		templates.ListRule = types.Pointer(`@request.auth.id != ""`)
+		templates.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		templates.CreateRule = types.Pointer(`@request.auth.id != ""`)
+		templates.UpdateRule = types.Pointer(`@request.auth.id != ""`)
+		templates.DeleteRule = types.Pointer(`@request.auth.id != ""`)
+		templates.Fields.Add(
+			&core.TextField{
+				Name:     "name",
+				Required: true,
+			},
+			&core.TextField{
+				Name: "description",
+			},
+			// Incremented whenever the workflow or variables change
+			&core.NumberField{
+				Name:    "version",
+				OnlyInt: true,
+			},
+			&core.JSONField{
+				Name:     "workflow",
+				Required: true,
+			},
+			// List of {name, description, default, required}
+			&core.JSONField{
+				Name: "variables",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+
+		if err := app.Save(templates); err != nil {
+			return err
+		}
+
+		// Create the template_deployments collection - one record per deploy
+		deployments := core.NewBaseCollection("template_deployments")
+		deployments.ListRule = types.Pointer(`@request.auth.id != ""`)
+		deployments.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		deployments.Fields.Add(
+			&core.RelationField{
+				Name:          "template",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  templates.Id,
+				MaxSelect:     1,
+			},
+			&core.NumberField{
+				Name:    "template_version",
+				OnlyInt: true,
+			},
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name: "workflow_id",
+			},
+			&core.JSONField{
+				Name: "variables",
+			},
+			&core.TextField{
+				Name: "deployed_by",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		deployments.AddIndex("idx_template_deployments_template", false, "template", "")
+
+		return app.Save(deployments)
+	}, func(app core.App) error {
+		for _, name := range []string{"template_deployments", "templates"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		// Set when the workflow was deleted from n8n through the manager
+		workflows.Fields.Add(
+			&core.BoolField{
+				Name: "archived",
+			},
+			&core.DateField{
+				Name: "archived_at",
+			},
+			// Location of the exported workflow JSON in the backup target
+			&core.TextField{
+				Name: "backup_location",
+			},
+			// Whether the workflow was active when archived, restored workflows are reactivated
+			&core.BoolField{
+				Name: "was_active",
+			},
+		)
+
+		return app.Save(workflows)
+	}, func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range []string{"archived", "archived_at", "backup_location", "was_active"} {
+			workflows.Fields.RemoveByName(name)
+		}
+		return app.Save(workflows)
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		// Environment variables, n8n variables and credentials the workflow references
+		workflows.Fields.Add(&core.JSONField{
+			Name: "dependencies",
+		})
+
+		return app.Save(workflows)
+	}, func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		workflows.Fields.RemoveByName("dependencies")
+		return app.Save(workflows)
+	})
+}
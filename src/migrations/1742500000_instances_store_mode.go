@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// How much of the workflow JSON is stored in workflows.workflow_data, empty means full
+		instances.Fields.Add(&core.SelectField{
+			Name:      "store_mode",
+			Values:    []string{"full", "metadata", "masked"},
+			MaxSelect: 1,
+		})
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.RemoveByName("store_mode")
+		return app.Save(instances)
+	})
+}
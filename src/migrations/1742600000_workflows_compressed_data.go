@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+var compressedDataFields = []string{"workflow_data_gz", "data_size", "data_size_stored"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		workflows.Fields.Add(
+			// Base64 encoded gzip of the workflow JSON, read it through /api/workflows/{id}/data
+			&core.TextField{
+				Name:   "workflow_data_gz",
+				Hidden: true,
+				Max:    16 << 20,
+			},
+			// Size of the uncompressed workflow JSON in bytes
+			&core.NumberField{
+				Name: "data_size",
+			},
+			// Size of workflow_data_gz in bytes
+			&core.NumberField{
+				Name: "data_size_stored",
+			},
+		)
+
+		if err := app.Save(workflows); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Stored size of all workflow versions of the instance
+		instances.Fields.Add(&core.NumberField{
+			Name: "storage_bytes",
+		})
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range compressedDataFields {
+			workflows.Fields.RemoveByName(name)
+		}
+		if err := app.Save(workflows); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.RemoveByName("storage_bytes")
+		return app.Save(instances)
+	})
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		// Create the workflow_nodes collection - one record per node of a workflow version
+		nodes := core.NewBaseCollection("workflow_nodes")
+		nodes.ListRule = types.Pointer(`@request.auth.id != ""`)
+		nodes.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		nodes.Fields.Add(
+			&core.RelationField{
+				Name:          "workflow",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  workflows.Id,
+				MaxSelect:     1,
+			},
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			// n8n id of the workflow, shared by all its versions
+			&core.TextField{
+				Name: "workflow_id",
+			},
+			&core.TextField{
+				Name: "node_id",
+			},
+			&core.TextField{
+				Name: "name",
+			},
+			&core.TextField{
+				Name: "type",
+			},
+			&core.BoolField{
+				Name: "disabled",
+			},
+			// List of {type, id, name}
+			&core.JSONField{
+				Name: "credentials",
+			},
+		)
+		nodes.AddIndex("idx_workflow_nodes_workflow", false, "workflow", "")
+		nodes.AddIndex("idx_workflow_nodes_type", false, "type", "")
+
+		return app.Save(nodes)
+	}, func(app core.App) error {
+		nodes, err := app.FindCollectionByNameOrId("workflow_nodes")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(nodes)
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+var webhookCheckFields = []string{"reachable", "http_status", "route_broken", "check_note", "checked_at"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+
+		// Result of the last probe of webhook_url
+		webhooks.Fields.Add(
+			&core.BoolField{
+				Name: "reachable",
+			},
+			&core.NumberField{
+				Name: "http_status",
+			},
+			// A route is configured but n8n doesn't serve the webhook
+			&core.BoolField{
+				Name: "route_broken",
+			},
+			&core.TextField{
+				Name: "check_note",
+			},
+			&core.DateField{
+				Name: "checked_at",
+			},
+		)
+
+		return app.Save(webhooks)
+	}, func(app core.App) error {
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range webhookCheckFields {
+			webhooks.Fields.RemoveByName(name)
+		}
+		return app.Save(webhooks)
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+
+		// Expected response: {mode, code, data, nodes: [{name, respond_with, code, headers}]}
+		webhooks.Fields.Add(&core.JSONField{
+			Name: "response",
+		})
+
+		return app.Save(webhooks)
+	}, func(app core.App) error {
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+
+		webhooks.Fields.RemoveByName("response")
+		return app.Save(webhooks)
+	})
+}
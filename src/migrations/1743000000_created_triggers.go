@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		// Create the triggers collection - the nodes starting the current version of each workflow
+		triggers := core.NewBaseCollection("triggers")
+		triggers.ListRule = types.Pointer(`@request.auth.id != ""`)
+		triggers.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		triggers.Fields.Add(
+			&core.RelationField{
+				Name:          "workflow",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  workflows.Id,
+				MaxSelect:     1,
+			},
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name: "workflow_id",
+			},
+			&core.TextField{
+				Name: "workflow_name",
+			},
+			&core.BoolField{
+				Name: "workflow_active",
+			},
+			&core.TextField{
+				Name: "node_id",
+			},
+			&core.TextField{
+				Name: "node_name",
+			},
+			&core.TextField{
+				Name: "type",
+			},
+			&core.SelectField{
+				Name:      "kind",
+				Values:    []string{"webhook", "schedule", "redis", "email", "chat", "manual", "other"},
+				MaxSelect: 1,
+			},
+			&core.BoolField{
+				Name: "disabled",
+			},
+			// Kind specific settings, e.g. path, channels, mailbox, timezone
+			&core.JSONField{
+				Name: "config",
+			},
+			// Cron expressions of schedule triggers
+			&core.JSONField{
+				Name: "cron",
+			},
+		)
+		triggers.AddIndex("idx_triggers_instance_workflow", false, "instance, workflow_id", "")
+		triggers.AddIndex("idx_triggers_kind", false, "kind", "")
+
+		return app.Save(triggers)
+	}, func(app core.App) error {
+		triggers, err := app.FindCollectionByNameOrId("triggers")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(triggers)
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Start of the last successful sync, changed workflows are fetched from here
+		instances.Fields.Add(
+			&core.DateField{
+				Name: "synced_at",
+			},
+			// Start of the last sync which fetched all workflows
+			&core.DateField{
+				Name: "last_full_sync",
+			},
+		)
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.RemoveByName("synced_at")
+		instances.Fields.RemoveByName("last_full_sync")
+		return app.Save(instances)
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Per-router overrides, e.g. {"accessLogs": false} for health checks
+		routes.Fields.Add(&core.JSONField{
+			Name: "observability",
+		})
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		routes.Fields.RemoveByName("observability")
+		return app.Save(routes)
+	})
+}
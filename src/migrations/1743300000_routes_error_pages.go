@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Branded error pages, e.g. {"status": ["502-504"], "url": "http://errors:8080", "query": "/{status}.json"}
+		routes.Fields.Add(&core.JSONField{
+			Name: "error_pages",
+		})
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		routes.Fields.RemoveByName("error_pages")
+		return app.Save(routes)
+	})
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Create the maintenance collection - a single record holding the global switch
+		maintenance := core.NewBaseCollection("maintenance")
+		maintenance.ListRule = types.Pointer(`@request.auth.id != ""`)
+		maintenance.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		maintenance.Fields.Add(
+			&core.BoolField{
+				Name: "enabled",
+			},
+			// Shown by the maintenance responder
+			&core.TextField{
+				Name: "message",
+			},
+			// Planned end, sent as Retry-After
+			&core.DateField{
+				Name: "ends_at",
+			},
+			&core.TextField{
+				Name: "updated_by",
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+
+		return app.Save(maintenance)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("maintenance")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
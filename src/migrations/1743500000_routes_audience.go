@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// routeAudiences are the Traefik config documents a route can be limited to
+var routeAudiences = []string{"internal", "external"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+
+		// Empty serves the route to every Traefik instance
+		routes.Fields.Add(&core.SelectField{
+			Name:      "audience",
+			Values:    routeAudiences,
+			MaxSelect: 1,
+		})
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		// From a "route-audience:" line in the webhook notes
+		webhooks.Fields.Add(&core.TextField{
+			Name: "route_audience",
+		})
+		return app.Save(webhooks)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.RemoveByName("audience")
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+		webhooks.Fields.RemoveByName("route_audience")
+		return app.Save(webhooks)
+	})
+}
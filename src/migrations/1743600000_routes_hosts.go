@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+
+		// Further domains of the route, e.g. ["legacy.example.com"]
+		routes.Fields.Add(&core.JSONField{
+			Name: "hosts",
+		})
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		// Comma separated, from a "route-hosts:" line in the webhook notes
+		webhooks.Fields.Add(&core.TextField{
+			Name: "route_hosts",
+		})
+		return app.Save(webhooks)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.RemoveByName("hosts")
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+		webhooks.Fields.RemoveByName("route_hosts")
+		return app.Save(webhooks)
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+
+		// Header forwarding the subdomain matched by a wildcard host
+		routes.Fields.Add(&core.TextField{
+			Name: "subdomain_header",
+		})
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		// From a "route-subdomain-header:" line in the webhook notes
+		webhooks.Fields.Add(&core.TextField{
+			Name: "route_subdomain_header",
+		})
+		return app.Save(webhooks)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.RemoveByName("subdomain_header")
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		webhooks, err := app.FindCollectionByNameOrId("webhooks")
+		if err != nil {
+			return err
+		}
+		webhooks.Fields.RemoveByName("route_subdomain_header")
+		return app.Save(webhooks)
+	})
+}
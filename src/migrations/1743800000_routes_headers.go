@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Header rules, e.g. {"set_request": {"X-Token": "env:HOOK_TOKEN"},
+		// "remove_request": ["Cookie"], "set_response": {"X-Served-By": "n8n"}}
+		routes.Fields.Add(&core.JSONField{
+			Name: "headers",
+		})
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		routes.Fields.RemoveByName("headers")
+		return app.Save(routes)
+	})
+}
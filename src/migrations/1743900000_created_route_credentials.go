@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Basic auth credentials shared by routes, rotated without editing them
+		credentials := core.NewBaseCollection("route_credentials")
+		credentials.ListRule = types.Pointer(`@request.auth.id != ""`)
+		credentials.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		credentials.Fields.Add(
+			&core.TextField{
+				Name:     "name",
+				Required: true,
+			},
+			&core.TextField{
+				Name:     "username",
+				Required: true,
+			},
+			// Plain or a secret reference, hashed when the config is built
+			&core.TextField{
+				Name:     "password",
+				Required: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		credentials.AddIndex("idx_route_credentials_name", true, "name", "")
+		if err := app.Save(credentials); err != nil {
+			return err
+		}
+
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		// Used by basic auth routes instead of auth_username and auth_password
+		routes.Fields.Add(&core.RelationField{
+			Name:         "auth_credentials",
+			CollectionId: credentials.Id,
+			MaxSelect:    1,
+		})
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.RemoveByName("auth_credentials")
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		credentials, err := app.FindCollectionByNameOrId("route_credentials")
+		if err != nil {
+			return err
+		}
+		return app.Delete(credentials)
+	})
+}
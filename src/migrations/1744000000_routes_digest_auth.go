@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Digest auth uses the username, password and credentials of basic auth
+		if field, ok := routes.Fields.GetByName("auth_type").(*core.SelectField); ok {
+			field.Values = []string{"none", "basic", "digest", "apikey"}
+		}
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := routes.Fields.GetByName("auth_type").(*core.SelectField); ok {
+			field.Values = []string{"none", "basic", "apikey"}
+		}
+		return app.Save(routes)
+	})
+}
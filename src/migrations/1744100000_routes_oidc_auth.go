@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := routes.Fields.GetByName("auth_type").(*core.SelectField); ok {
+			field.Values = []string{"none", "basic", "digest", "apikey", "oidc"}
+		}
+
+		// Token requirements of oidc auth, e.g. {"audience": "n8n-hooks",
+		// "scopes": ["hooks:write"]}
+		routes.Fields.Add(&core.JSONField{
+			Name: "auth_oidc",
+		})
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := routes.Fields.GetByName("auth_type").(*core.SelectField); ok {
+			field.Values = []string{"none", "basic", "digest", "apikey"}
+		}
+		routes.Fields.RemoveByName("auth_oidc")
+		return app.Save(routes)
+	})
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := routes.Fields.GetByName("auth_type").(*core.SelectField); ok {
+			field.Values = []string{"none", "basic", "digest", "apikey", "oidc", "hmac"}
+		}
+
+		// Signature scheme of hmac auth, e.g. {"header": "X-Signature",
+		// "algorithm": "sha1", "prefix": "sha1="} or {"scheme": "stripe"}
+		routes.Fields.Add(&core.JSONField{
+			Name: "auth_hmac",
+		})
+
+		// Shared secret of the signatures, may be a secret reference
+		routes.Fields.Add(&core.TextField{
+			Name: "auth_hmac_secret",
+		})
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := routes.Fields.GetByName("auth_type").(*core.SelectField); ok {
+			field.Values = []string{"none", "basic", "digest", "apikey", "oidc"}
+		}
+		routes.Fields.RemoveByName("auth_hmac")
+		routes.Fields.RemoveByName("auth_hmac_secret")
+		return app.Save(routes)
+	})
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Header of the idempotency key or delivery id whose duplicates are
+		// rejected, e.g. {"header": "X-GitHub-Delivery", "ttl": "1h"}
+		routes.Fields.Add(&core.JSONField{
+			Name: "replay_protection",
+		})
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		routes.Fields.RemoveByName("replay_protection")
+		return app.Save(routes)
+	})
+}
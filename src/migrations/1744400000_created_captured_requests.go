@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Number of recent requests captured for debugging, 0 disables capturing
+		routes.Fields.Add(&core.NumberField{
+			Name:    "capture_requests",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+			Max:     types.Pointer(100.0),
+		})
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		// Captured requests may hold payload data, only superusers see them
+		captures := core.NewBaseCollection("captured_requests")
+		captures.Fields.Add(
+			&core.RelationField{
+				Name:          "route",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  routes.Id,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name: "method",
+			},
+			// Public path and query of the request
+			&core.TextField{
+				Name: "uri",
+				Max:  8192,
+			},
+			// Headers as received by n8n, sensitive values masked
+			&core.JSONField{
+				Name: "headers",
+			},
+			// Body up to 256 KiB, base64 encoded if it isn't text
+			&core.TextField{
+				Name: "body",
+				Max:  350000,
+			},
+			&core.BoolField{
+				Name: "body_base64",
+			},
+			&core.BoolField{
+				Name: "truncated",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		captures.AddIndex("idx_captured_requests_route", false, "route, created", "")
+
+		return app.Save(captures)
+	}, func(app core.App) error {
+		captures, err := app.FindCollectionByNameOrId("captured_requests")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(captures); err != nil {
+			return err
+		}
+
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.RemoveByName("capture_requests")
+		return app.Save(routes)
+	})
+}
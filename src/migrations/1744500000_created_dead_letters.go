@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Gateway mode proxies the requests of the route through the manager
+		routes.Fields.Add(&core.BoolField{
+			Name: "gateway",
+		})
+		// Retry policy of failed deliveries in gateway mode, e.g.
+		// {"max_attempts": 5, "backoff": "1m", "expire_after": "72h"}
+		routes.Fields.Add(&core.JSONField{
+			Name: "dead_letter",
+		})
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		// Dead letters hold full requests including credentials, only
+		// superusers see them
+		letters := core.NewBaseCollection("dead_letters")
+		letters.Fields.Add(
+			&core.RelationField{
+				Name:          "route",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  routes.Id,
+				MaxSelect:     1,
+			},
+			&core.SelectField{
+				Name:      "state",
+				Values:    []string{"pending", "delivered", "failed", "expired"},
+				MaxSelect: 1,
+			},
+			&core.TextField{
+				Name: "method",
+			},
+			// Public path and query of the request
+			&core.TextField{
+				Name: "uri",
+				Max:  8192,
+			},
+			&core.JSONField{
+				Name:    "headers",
+				MaxSize: 1 << 20,
+			},
+			// Body up to 10 MiB, base64 encoded if it isn't text
+			&core.TextField{
+				Name: "body",
+				Max:  14 << 20,
+			},
+			&core.BoolField{
+				Name: "body_base64",
+			},
+			&core.NumberField{
+				Name:    "attempts",
+				OnlyInt: true,
+			},
+			// Status of the last attempt, 0 if n8n was unreachable
+			&core.NumberField{
+				Name:    "status",
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name: "error",
+			},
+			&core.DateField{
+				Name: "last_attempt",
+			},
+			&core.DateField{
+				Name: "next_attempt",
+			},
+			// Pending letters expire at this time, editable to extend or
+			// end retrying
+			&core.DateField{
+				Name: "expires",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		letters.AddIndex("idx_dead_letters_state", false, "state, next_attempt", "")
+		letters.AddIndex("idx_dead_letters_route", false, "route", "")
+
+		return app.Save(letters)
+	}, func(app core.App) error {
+		letters, err := app.FindCollectionByNameOrId("dead_letters")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(letters); err != nil {
+			return err
+		}
+
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.RemoveByName("gateway")
+		routes.Fields.RemoveByName("dead_letter")
+		return app.Save(routes)
+	})
+}
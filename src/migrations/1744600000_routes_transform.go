@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Transformation rules applied in gateway mode, e.g.
+		// {"normalize_content_type": true, "fields": [{"from": "cust_id", "to": "customer.id"}]}
+		routes.Fields.Add(&core.JSONField{
+			Name: "transform",
+		})
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		routes.Fields.RemoveByName("transform")
+		return app.Save(routes)
+	})
+}
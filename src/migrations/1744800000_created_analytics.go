@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Daily and monthly request quotas per API key or client IP, e.g.
+		// {"per": "api_key", "daily": 1000, "monthly": 20000}
+		routes.Fields.Add(&core.JSONField{
+			Name: "quota",
+		})
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		// Request counters of the routes with a quota, one per subject and
+		// day or month. Subjects hold client IPs, only superusers see them.
+		analytics := core.NewBaseCollection("analytics")
+		analytics.Fields.Add(
+			&core.RelationField{
+				Name:          "route",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  routes.Id,
+				MaxSelect:     1,
+			},
+			// "key:" and a hash of the API key, or "ip:" and the client IP
+			&core.TextField{
+				Name:     "subject",
+				Required: true,
+			},
+			&core.SelectField{
+				Name:      "period",
+				Required:  true,
+				Values:    []string{"day", "month"},
+				MaxSelect: 1,
+			},
+			// UTC day (2006-01-02) or month (2006-01) counted
+			&core.TextField{
+				Name:     "bucket",
+				Required: true,
+			},
+			&core.NumberField{
+				Name:    "count",
+				OnlyInt: true,
+			},
+			// Limit of the period when the counter was last updated
+			&core.NumberField{
+				Name:    "quota",
+				OnlyInt: true,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		analytics.AddIndex("idx_analytics_counter", true, "route, subject, period, bucket", "")
+
+		return app.Save(analytics)
+	}, func(app core.App) error {
+		analytics, err := app.FindCollectionByNameOrId("analytics")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(analytics); err != nil {
+			return err
+		}
+
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.RemoveByName("quota")
+		return app.Save(routes)
+	})
+}
@@ -0,0 +1,85 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Daily usage of gateway routes per client, for billing exports.
+		// Subjects hold client IPs, only superusers see them.
+		reports := core.NewBaseCollection("usage_reports")
+		reports.Fields.Add(
+			&core.RelationField{
+				Name:          "route",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  routes.Id,
+				MaxSelect:     1,
+			},
+			// "sub:" and the oidc subject, "user:" and the basic or digest user,
+			// or "ip:" and the client IP
+			&core.TextField{
+				Name:     "subject",
+				Required: true,
+			},
+			// UTC day, 2006-01-02
+			&core.TextField{
+				Name:     "day",
+				Required: true,
+			},
+			&core.NumberField{
+				Name:    "requests",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "bytes_in",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "bytes_out",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "status_2xx",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "status_3xx",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "status_4xx",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "status_5xx",
+				OnlyInt: true,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		reports.AddIndex("idx_usage_reports_day", true, "day, route, subject", "")
+
+		return app.Save(reports)
+	}, func(app core.App) error {
+		reports, err := app.FindCollectionByNameOrId("usage_reports")
+		if err != nil {
+			return err
+		}
+		return app.Delete(reports)
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Id of the n8n Header Auth credential checking the token of routes
+		// with apikey auth, updated when the token is rotated
+		routes.Fields.Add(&core.TextField{
+			Name: "auth_api_key_credential",
+		})
+		// Rotate the token every N days, 0 disables scheduled rotation
+		routes.Fields.Add(&core.NumberField{
+			Name:    "auth_api_key_rotation_days",
+			OnlyInt: true,
+		})
+		routes.Fields.Add(&core.DateField{
+			Name: "auth_api_key_rotated_at",
+		})
+
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		routes.Fields.RemoveByName("auth_api_key_credential")
+		routes.Fields.RemoveByName("auth_api_key_rotation_days")
+		routes.Fields.RemoveByName("auth_api_key_rotated_at")
+		return app.Save(routes)
+	})
+}
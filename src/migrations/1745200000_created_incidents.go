@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Outages of instances and routed webhooks, for the status page
+		incidents := core.NewBaseCollection("incidents")
+		incidents.ListRule = types.Pointer(`@request.auth.id != ""`)
+		incidents.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		incidents.Fields.Add(
+			&core.SelectField{
+				Name:      "kind",
+				Required:  true,
+				Values:    []string{"instance", "webhook"},
+				MaxSelect: 1,
+			},
+			// Instance id, or instance id and webhook path
+			&core.TextField{
+				Name:     "component",
+				Required: true,
+			},
+			&core.TextField{
+				Name: "name",
+			},
+			&core.TextField{
+				Name: "note",
+			},
+			&core.DateField{
+				Name:     "started",
+				Required: true,
+			},
+			// Empty while the incident is open
+			&core.DateField{
+				Name: "resolved",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		incidents.AddIndex("idx_incidents_component", false, "component, resolved", "")
+		incidents.AddIndex("idx_incidents_started", false, "started", "")
+
+		return app.Save(incidents)
+	}, func(app core.App) error {
+		incidents, err := app.FindCollectionByNameOrId("incidents")
+		if err != nil {
+			return err
+		}
+		return app.Delete(incidents)
+	})
+}
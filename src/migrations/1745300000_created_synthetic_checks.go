@@ -0,0 +1,160 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Requests sent periodically to the public URL of a route. Headers
+		// and body may hold credentials, only superusers see them.
+		checks := core.NewBaseCollection("synthetic_checks")
+		checks.Fields.Add(
+			&core.RelationField{
+				Name:          "route",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  routes.Id,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name: "name",
+			},
+			&core.BoolField{
+				Name: "active",
+			},
+			// GET if empty
+			&core.SelectField{
+				Name:      "method",
+				Values:    []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+				MaxSelect: 1,
+			},
+			// Path and query requested instead of the route path, e.g. for
+			// routes with path parameters
+			&core.TextField{
+				Name: "path",
+			},
+			// e.g. {"X-API-Key": "..."}
+			&core.JSONField{
+				Name: "headers",
+			},
+			&core.TextField{
+				Name: "body",
+				Max:  65536,
+			},
+			&core.NumberField{
+				Name:    "interval_mins",
+				OnlyInt: true,
+				Min:     types.Pointer(0.0),
+			},
+			&core.NumberField{
+				Name:    "timeout_secs",
+				OnlyInt: true,
+				Min:     types.Pointer(0.0),
+				Max:     types.Pointer(300.0),
+			},
+			// Any 2xx status if 0
+			&core.NumberField{
+				Name:    "expected_status",
+				OnlyInt: true,
+				Min:     types.Pointer(0.0),
+				Max:     types.Pointer(599.0),
+			},
+			// Substring the response body must contain
+			&core.TextField{
+				Name: "expected_body",
+			},
+			// Outcome of the last run
+			&core.DateField{
+				Name: "last_run",
+			},
+			&core.BoolField{
+				Name: "last_ok",
+			},
+			&core.NumberField{
+				Name:    "last_status",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "last_latency_ms",
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name: "last_error",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		if err := app.Save(checks); err != nil {
+			return err
+		}
+
+		// One record per run, for latency and correctness over time
+		results := core.NewBaseCollection("synthetic_results")
+		results.ListRule = types.Pointer(`@request.auth.id != ""`)
+		results.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		results.Fields.Add(
+			&core.RelationField{
+				Name:          "check",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  checks.Id,
+				MaxSelect:     1,
+			},
+			&core.RelationField{
+				Name:          "route",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  routes.Id,
+				MaxSelect:     1,
+			},
+			&core.BoolField{
+				Name: "ok",
+			},
+			&core.NumberField{
+				Name:    "status",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "latency_ms",
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name: "error",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		results.AddIndex("idx_synthetic_results_check", false, "`check`, created", "")
+		results.AddIndex("idx_synthetic_results_route", false, "route, created", "")
+
+		return app.Save(results)
+	}, func(app core.App) error {
+		for _, name := range []string{"synthetic_results", "synthetic_checks"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		// Latency objective of the route, e.g.
+		// {"latency_ms": 500, "objective": 99, "window_days": 30}
+		routes.Fields.Add(&core.JSONField{
+			Name: "slo",
+		})
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		// Requests and synthetic check runs of routes with an SLO, counted
+		// per five minutes
+		events := core.NewBaseCollection("slo_events")
+		events.Fields.Add(
+			&core.RelationField{
+				Name:          "route",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  routes.Id,
+				MaxSelect:     1,
+			},
+			&core.DateField{
+				Name:     "start",
+				Required: true,
+			},
+			&core.NumberField{
+				Name:    "total",
+				OnlyInt: true,
+			},
+			// Events answered successfully within the latency objective
+			&core.NumberField{
+				Name:    "good",
+				OnlyInt: true,
+			},
+		)
+		events.AddIndex("idx_slo_events_bucket", true, "route, start", "")
+		if err := app.Save(events); err != nil {
+			return err
+		}
+
+		// Burn-rate alerts, open while resolved is empty
+		alerts := core.NewBaseCollection("slo_alerts")
+		alerts.ListRule = types.Pointer(`@request.auth.id != ""`)
+		alerts.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		alerts.Fields.Add(
+			&core.RelationField{
+				Name:          "route",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  routes.Id,
+				MaxSelect:     1,
+			},
+			&core.SelectField{
+				Name:      "severity",
+				Required:  true,
+				Values:    []string{"warning", "critical"},
+				MaxSelect: 1,
+			},
+			// Burn rate of the long window when the alert opened
+			&core.NumberField{
+				Name: "burn_rate",
+			},
+			&core.DateField{
+				Name:     "started",
+				Required: true,
+			},
+			&core.DateField{
+				Name: "resolved",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		alerts.AddIndex("idx_slo_alerts_route", false, "route, resolved", "")
+
+		return app.Save(alerts)
+	}, func(app core.App) error {
+		for _, name := range []string{"slo_alerts", "slo_events"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.RemoveByName("slo")
+		return app.Save(routes)
+	})
+}
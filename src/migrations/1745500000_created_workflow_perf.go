@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Sync the executions of the instance, off by default since busy
+		// instances list many executions per sync
+		instances.Fields.Add(
+			&core.BoolField{
+				Name: "executions_enabled",
+			},
+			// Start of the last sync of executions
+			&core.DateField{
+				Name: "executions_synced_at",
+			},
+		)
+		if err := app.Save(instances); err != nil {
+			return err
+		}
+
+		// Execution durations per workflow and UTC day
+		perf := core.NewBaseCollection("workflow_perf")
+		perf.ListRule = types.Pointer(`@request.auth.id != ""`)
+		perf.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		perf.Fields.Add(
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name:     "workflow_id",
+				Required: true,
+			},
+			// 2006-01-02
+			&core.TextField{
+				Name:     "day",
+				Required: true,
+			},
+			&core.NumberField{
+				Name:    "executions",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "errors",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "p50_ms",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "p95_ms",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "p99_ms",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "max_ms",
+				OnlyInt: true,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		perf.AddIndex("idx_workflow_perf_day", true, "instance, workflow_id, day", "")
+
+		return app.Save(perf)
+	}, func(app core.App) error {
+		perf, err := app.FindCollectionByNameOrId("workflow_perf")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(perf); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		instances.Fields.RemoveByName("executions_enabled")
+		instances.Fields.RemoveByName("executions_synced_at")
+		return app.Save(instances)
+	})
+}
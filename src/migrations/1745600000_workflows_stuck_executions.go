@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		// Executions running or waiting beyond the threshold at the last
+		// execution sync
+		workflows.Fields.Add(
+			&core.NumberField{
+				Name:    "stuck_executions",
+				OnlyInt: true,
+			},
+			// Ids of these executions in n8n, e.g. ["1042", "1057"]
+			&core.JSONField{
+				Name: "stuck_execution_ids",
+			},
+		)
+
+		return app.Save(workflows)
+	}, func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		workflows.Fields.RemoveByName("stuck_executions")
+		workflows.Fields.RemoveByName("stuck_execution_ids")
+		return app.Save(workflows)
+	})
+}
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		// The errorWorkflow setting, id of the workflow n8n runs when an
+		// execution fails
+		workflows.Fields.Add(
+			&core.TextField{
+				Name: "error_workflow",
+			},
+			// The workflow starts with an Error Trigger
+			&core.BoolField{
+				Name: "error_handler",
+			},
+		)
+		if err := app.Save(workflows); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Error workflow assigned to workflows without one
+		instances.Fields.Add(
+			&core.TextField{
+				Name: "default_error_workflow",
+			},
+			// Assign it on every sync instead of on demand only
+			&core.BoolField{
+				Name: "error_workflow_auto",
+			},
+		)
+		return app.Save(instances)
+	}, func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+		workflows.Fields.RemoveByName("error_workflow")
+		workflows.Fields.RemoveByName("error_handler")
+		if err := app.Save(workflows); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		instances.Fields.RemoveByName("default_error_workflow")
+		instances.Fields.RemoveByName("error_workflow_auto")
+		return app.Save(instances)
+	})
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		// Problems found in the version, e.g.
+		// [{"rule": "pinned-data", "severity": "warning", "message": "...", "nodes": ["Webhook"]}]
+		workflows.Fields.Add(&core.JSONField{
+			Name: "lint_findings",
+		})
+
+		return app.Save(workflows)
+	}, func(app core.App) error {
+		workflows, err := app.FindCollectionByNameOrId("workflows")
+		if err != nil {
+			return err
+		}
+
+		workflows.Fields.RemoveByName("lint_findings")
+		return app.Save(workflows)
+	})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Instance-level settings of n8n, a new snapshot whenever they change
+		config := core.NewBaseCollection("instance_config")
+		config.ListRule = types.Pointer(`@request.auth.id != ""`)
+		config.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		config.Fields.Add(
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			// e.g. {"timezone": "Europe/Berlin", "save_data_success_execution": "none", ...}
+			&core.JSONField{
+				Name: "config",
+			},
+			// Settings changed since the previous snapshot, e.g.
+			// [{"setting": "timezone", "old": "UTC", "new": "Europe/Berlin"}]
+			&core.JSONField{
+				Name: "changes",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		config.AddIndex("idx_instance_config_created", false, "instance, created", "")
+
+		return app.Save(config)
+	}, func(app core.App) error {
+		config, err := app.FindCollectionByNameOrId("instance_config")
+		if err != nil {
+			return err
+		}
+		return app.Delete(config)
+	})
+}
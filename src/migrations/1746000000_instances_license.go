@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Detected from the instance settings on sync, empty until then
+		instances.Fields.Add(
+			&core.SelectField{
+				Name:      "edition",
+				Values:    []string{"community", "enterprise"},
+				MaxSelect: 1,
+			},
+			&core.TextField{
+				Name: "license_plan",
+			},
+			// Licensed enterprise features, e.g. ["sourceControl", "variables"].
+			// Manager features needing them are disabled without.
+			&core.JSONField{
+				Name: "features",
+			},
+		)
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.RemoveByName("edition")
+		instances.Fields.RemoveByName("license_plan")
+		instances.Fields.RemoveByName("features")
+		return app.Save(instances)
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Last pull of the n8n source control repository through the manager
+		instances.Fields.Add(
+			&core.DateField{
+				Name: "source_control_pulled_at",
+			},
+			&core.SelectField{
+				Name:      "source_control_status",
+				Values:    []string{"ok", "conflict", "failed"},
+				MaxSelect: 1,
+			},
+			// What the pull imported, or {"error": "..."}
+			&core.JSONField{
+				Name: "source_control_result",
+			},
+		)
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.RemoveByName("source_control_pulled_at")
+		instances.Fields.RemoveByName("source_control_status")
+		instances.Fields.RemoveByName("source_control_result")
+		return app.Save(instances)
+	})
+}
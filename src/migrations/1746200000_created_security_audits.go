@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Generate the n8n security audit on the SECURITY_AUDIT_SCHEDULE,
+		// needs an API key of the instance owner
+		instances.Fields.Add(&core.BoolField{
+			Name: "security_audit_enabled",
+		})
+		if err := app.Save(instances); err != nil {
+			return err
+		}
+
+		// Security audit reports of n8n, superusers only
+		audits := core.NewBaseCollection("security_audits")
+		audits.Fields.Add(
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			// The report as returned by n8n, by report title
+			&core.JSONField{
+				Name: "report",
+			},
+			&core.NumberField{
+				Name:    "findings",
+				OnlyInt: true,
+			},
+			// Findings missing in the previous report, e.g.
+			// [{"risk": "credentials", "section": "Credentials not used in recently run workflows", "location": {...}}]
+			&core.JSONField{
+				Name: "new_findings",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		audits.AddIndex("idx_security_audits_created", false, "instance, created", "")
+
+		return app.Save(audits)
+	}, func(app core.App) error {
+		audits, err := app.FindCollectionByNameOrId("security_audits")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(audits); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		instances.Fields.RemoveByName("security_audit_enabled")
+		return app.Save(instances)
+	})
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Users of the n8n instances for access reviews, superusers only
+		users := core.NewBaseCollection("n8n_users")
+		users.Fields.Add(
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name:     "user_id",
+				Required: true,
+			},
+			&core.TextField{
+				Name: "email",
+			},
+			&core.TextField{
+				Name: "first_name",
+			},
+			&core.TextField{
+				Name: "last_name",
+			},
+			// e.g. global:owner, global:admin, global:member
+			&core.TextField{
+				Name: "role",
+			},
+			// Invited but not signed up yet
+			&core.BoolField{
+				Name: "pending",
+			},
+			&core.DateField{
+				Name: "created_at",
+			},
+			// Last activity, only reported by recent n8n versions
+			&core.DateField{
+				Name: "last_seen",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		users.AddIndex("idx_n8n_users_user", true, "instance, user_id", "")
+		users.AddIndex("idx_n8n_users_email", false, "email", "")
+
+		return app.Save(users)
+	}, func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("n8n_users")
+		if err != nil {
+			return err
+		}
+		return app.Delete(users)
+	})
+}
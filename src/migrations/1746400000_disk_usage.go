@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Optional helper workflow webhook answering with the disk usage as
+		// {"binary_data_bytes", "database_bytes", "disk_free_bytes", "disk_total_bytes"}
+		instances.Fields.Add(
+			&core.URLField{
+				Name: "disk_probe_url",
+			},
+			// Latest disk usage, the history is kept in sync_runs
+			&core.NumberField{
+				Name:    "binary_data_bytes",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "database_bytes",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name: "disk_used_percent",
+			},
+		)
+		if err := app.Save(instances); err != nil {
+			return err
+		}
+
+		syncRuns, err := app.FindCollectionByNameOrId("sync_runs")
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"binary_data_bytes", "database_bytes", "disk_free_bytes", "disk_total_bytes"} {
+			syncRuns.Fields.Add(&core.NumberField{
+				Name:    name,
+				OnlyInt: true,
+			})
+		}
+		return app.Save(syncRuns)
+	}, func(app core.App) error {
+		syncRuns, err := app.FindCollectionByNameOrId("sync_runs")
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"binary_data_bytes", "database_bytes", "disk_free_bytes", "disk_total_bytes"} {
+			syncRuns.Fields.RemoveByName(name)
+		}
+		if err := app.Save(syncRuns); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"disk_probe_url", "binary_data_bytes", "database_bytes", "disk_used_percent"} {
+			instances.Fields.RemoveByName(name)
+		}
+		return app.Save(instances)
+	})
+}
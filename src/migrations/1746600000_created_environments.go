@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Groups of instances, e.g. development, staging and production
+		environments := core.NewBaseCollection("environments")
+		environments.ListRule = types.Pointer(`@request.auth.id != ""`)
+		environments.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		environments.CreateRule = types.Pointer(`@request.auth.id != ""`)
+		environments.UpdateRule = types.Pointer(`@request.auth.id != ""`)
+		environments.DeleteRule = types.Pointer(`@request.auth.id != ""`)
+		environments.Fields.Add(
+			&core.TextField{
+				Name:     "name",
+				Required: true,
+				Pattern:  `^[a-z0-9][a-z0-9_-]*$`,
+			},
+			&core.TextField{
+				Name: "description",
+			},
+			// Severity of outage notifications of its instances, "log" only
+			// logs them
+			&core.SelectField{
+				Name:      "alert_severity",
+				Values:    []string{"critical", "warning", "info", "log"},
+				MaxSelect: 1,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		environments.AddIndex("idx_environments_name", true, "name", "")
+
+		if err := app.Save(environments); err != nil {
+			return err
+		}
+
+		// The environment workflows are promoted to, e.g. staging to
+		// production. Added after saving, the relation points to itself.
+		environments.Fields.Add(&core.RelationField{
+			Name:         "promotes_to",
+			CollectionId: environments.Id,
+			MaxSelect:    1,
+		})
+		if err := app.Save(environments); err != nil {
+			return err
+		}
+
+		var promotesTo string
+		for _, seed := range []struct{ name, severity string }{
+			{"production", "critical"},
+			{"staging", "warning"},
+			{"development", "log"},
+		} {
+			record := core.NewRecord(environments)
+			record.Set("name", seed.name)
+			record.Set("alert_severity", seed.severity)
+			record.Set("promotes_to", promotesTo)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+			promotesTo = record.Id
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		instances.Fields.Add(&core.RelationField{
+			Name:         "environment",
+			CollectionId: environments.Id,
+			MaxSelect:    1,
+		})
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		instances.Fields.RemoveByName("environment")
+		if err := app.Save(instances); err != nil {
+			return err
+		}
+
+		environments, err := app.FindCollectionByNameOrId("environments")
+		if err != nil {
+			return err
+		}
+		return app.Delete(environments)
+	})
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		environments, err := app.FindCollectionByNameOrId("environments")
+		if err != nil {
+			return err
+		}
+
+		// Route policies of an environment, only superusers may change them
+		policies := core.NewBaseCollection("policies")
+		policies.ListRule = types.Pointer(`@request.auth.id != ""`)
+		policies.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		policies.Fields.Add(
+			&core.RelationField{
+				Name:          "environment",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  environments.Id,
+				MaxSelect:     1,
+			},
+			// Auth types routes must use, empty allows routes without auth
+			&core.SelectField{
+				Name:      "auth_types",
+				Values:    []string{"basic", "digest", "apikey", "oidc", "hmac"},
+				MaxSelect: 5,
+			},
+			// Requests per minute, 0 keeps the defaults of the builder
+			&core.NumberField{
+				Name:    "rate_limit_average",
+				OnlyInt: true,
+				Min:     types.Pointer(0.0),
+			},
+			&core.NumberField{
+				Name:    "rate_limit_burst",
+				OnlyInt: true,
+				Min:     types.Pointer(0.0),
+			},
+			// Whether routes may expose webhooks of inactive workflows
+			&core.BoolField{
+				Name: "allow_inactive",
+			},
+			// Entrypoints routes may use, also the default of routes without
+			// their own. Empty allows any.
+			&core.JSONField{
+				Name: "entrypoints",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		policies.AddIndex("idx_policies_environment", true, "environment", "")
+
+		return app.Save(policies)
+	}, func(app core.App) error {
+		policies, err := app.FindCollectionByNameOrId("policies")
+		if err != nil {
+			return err
+		}
+		return app.Delete(policies)
+	})
+}
@@ -0,0 +1,128 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		policies, err := app.FindCollectionByNameOrId("policies")
+		if err != nil {
+			return err
+		}
+		// New routes of the environment aren't exposed before an operator
+		// approved them
+		policies.Fields.Add(&core.BoolField{
+			Name: "require_approval",
+		})
+		if err := app.Save(policies); err != nil {
+			return err
+		}
+
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		// Empty for routes created without approval
+		routes.Fields.Add(
+			&core.SelectField{
+				Name:      "approval",
+				Values:    []string{"pending", "approved"},
+				MaxSelect: 1,
+			},
+			&core.TextField{
+				Name: "approved_by",
+			},
+			&core.DateField{
+				Name: "approved_at",
+			},
+		)
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// Approvals of "route:" annotations. Webhooks records are recreated
+		// by every sync, approvals stay as long as the annotation does.
+		approvals := core.NewBaseCollection("route_approvals")
+		approvals.ListRule = types.Pointer(`@request.auth.id != ""`)
+		approvals.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		approvals.Fields.Add(
+			&core.RelationField{
+				Name:          "instance",
+				Required:      true,
+				CascadeDelete: true,
+				CollectionId:  instances.Id,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name:     "webhook_path",
+				Required: true,
+			},
+			// The annotation, e.g. "hooks.example.com/orders"
+			&core.TextField{
+				Name:     "route",
+				Required: true,
+			},
+			&core.TextField{
+				Name: "workflow_name",
+			},
+			&core.SelectField{
+				Name:      "approval",
+				Required:  true,
+				Values:    []string{"pending", "approved"},
+				MaxSelect: 1,
+			},
+			&core.TextField{
+				Name: "approved_by",
+			},
+			&core.DateField{
+				Name: "approved_at",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		approvals.AddIndex("idx_route_approvals_annotation", true, "instance, webhook_path, route", "")
+
+		return app.Save(approvals)
+	}, func(app core.App) error {
+		approvals, err := app.FindCollectionByNameOrId("route_approvals")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(approvals); err != nil {
+			return err
+		}
+
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"approval", "approved_by", "approved_at"} {
+			routes.Fields.RemoveByName(name)
+		}
+		if err := app.Save(routes); err != nil {
+			return err
+		}
+
+		policies, err := app.FindCollectionByNameOrId("policies")
+		if err != nil {
+			return err
+		}
+		policies.Fields.RemoveByName("require_approval")
+		return app.Save(policies)
+	})
+}
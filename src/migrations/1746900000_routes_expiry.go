@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.Fields.Add(
+			// Expired routes stay stored but aren't exposed anymore
+			&core.DateField{
+				Name: "expires_at",
+			},
+			// Periods the route is exposed in, e.g.
+			// [{"start": "2025-06-02T09:00:00Z", "end": "2025-06-02T17:00:00Z"}].
+			// Empty exposes it until it expires.
+			&core.JSONField{
+				Name: "activation_windows",
+			},
+			// When the reminder of the upcoming expiry was sent, cleared when
+			// expires_at changes
+			&core.DateField{
+				Name: "expiry_reminded_at",
+			},
+		)
+		routes.AddIndex("idx_routes_expires_at", false, "expires_at", "")
+		return app.Save(routes)
+	}, func(app core.App) error {
+		routes, err := app.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+		routes.RemoveIndex("idx_routes_expires_at")
+		for _, name := range []string{"expires_at", "activation_windows", "expiry_reminded_at"} {
+			routes.Fields.RemoveByName(name)
+		}
+		return app.Save(routes)
+	})
+}
@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// softDeleted are the collections whose records are moved to the trash
+// instead of being deleted
+var softDeleted = []string{"instances", "routes", "workflows"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		for _, name := range softDeleted {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			// Set while the record is in the trash, it's purged after the
+			// retention period
+			collection.Fields.Add(&core.DateField{
+				Name: "deleted_at",
+			})
+			collection.AddIndex("idx_"+name+"_deleted_at", false, "deleted_at", "")
+
+			// Records in the trash are only visible to superusers, deleting
+			// them again purges them
+			live := `@request.auth.id != "" && deleted_at = ""`
+			collection.ListRule = types.Pointer(live)
+			collection.ViewRule = types.Pointer(live)
+			collection.UpdateRule = types.Pointer(live)
+			collection.DeleteRule = types.Pointer(live)
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error {
+		for _, name := range softDeleted {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.RemoveIndex("idx_" + name + "_deleted_at")
+			collection.Fields.RemoveByName("deleted_at")
+
+			authenticated := `@request.auth.id != ""`
+			collection.ListRule = types.Pointer(authenticated)
+			collection.ViewRule = types.Pointer(authenticated)
+			collection.UpdateRule = types.Pointer(authenticated)
+			collection.DeleteRule = types.Pointer(authenticated)
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
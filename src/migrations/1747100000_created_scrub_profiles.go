@@ -0,0 +1,96 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// defaultSecretPatterns match static values that look like credentials:
+// JWTs, Authorization headers, private keys and the API keys of AWS,
+// GitHub, Slack and OpenAI or Stripe
+var defaultSecretPatterns = []string{
+	`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	`(?i)^(Bearer|Basic|Token)\s+\S+`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----`,
+	`AKIA[0-9A-Z]{16}`,
+	`gh[pousr]_[A-Za-z0-9]{36,}`,
+	`xox[abposr]-[A-Za-z0-9-]{10,}`,
+	`sk_(live|test)_[A-Za-z0-9]{16,}`,
+	`sk-[A-Za-z0-9_-]{20,}`,
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Rules applied to workflow JSON exported with ?profile=, only
+		// superusers may change them
+		profiles := core.NewBaseCollection("scrub_profiles")
+		profiles.ListRule = types.Pointer(`@request.auth.id != ""`)
+		profiles.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		profiles.Fields.Add(
+			&core.TextField{
+				Name:     "name",
+				Required: true,
+				Pattern:  `^[a-z0-9][a-z0-9_-]*$`,
+			},
+			&core.TextField{
+				Name: "description",
+			},
+			// Drops the credential references of the nodes
+			&core.BoolField{
+				Name: "remove_credentials",
+			},
+			// Drops the webhook ids of the nodes, n8n assigns new ones on
+			// import
+			&core.BoolField{
+				Name: "strip_webhook_ids",
+			},
+			// Regular expressions, static node parameters matching one are
+			// blanked
+			&core.JSONField{
+				Name: "secret_patterns",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		profiles.AddIndex("idx_scrub_profiles_name", true, "name", "")
+
+		if err := app.Save(profiles); err != nil {
+			return err
+		}
+
+		for _, seed := range []struct {
+			name, description string
+			public            bool
+		}{
+			{"share", "Without credential references, for sharing with other instances", false},
+			{"public", "Without credential references, webhook ids and secret-looking values, for public repositories", true},
+		} {
+			record := core.NewRecord(profiles)
+			record.Set("name", seed.name)
+			record.Set("description", seed.description)
+			record.Set("remove_credentials", true)
+			record.Set("strip_webhook_ids", seed.public)
+			if seed.public {
+				record.Set("secret_patterns", defaultSecretPatterns)
+			}
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error {
+		profiles, err := app.FindCollectionByNameOrId("scrub_profiles")
+		if err != nil {
+			return err
+		}
+		return app.Delete(profiles)
+	})
+}
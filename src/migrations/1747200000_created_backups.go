@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// One record per backup written to the backup target, with the result
+		// of reading it back
+		backups := core.NewBaseCollection("backups")
+		backups.ListRule = types.Pointer(`@request.auth.id != ""`)
+		backups.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		backups.Fields.Add(
+			// Backups outlive their instance
+			&core.RelationField{
+				Name:         "instance",
+				CollectionId: instances.Id,
+				MaxSelect:    1,
+			},
+			&core.TextField{
+				Name: "workflow_id",
+			},
+			&core.TextField{
+				Name: "workflow_name",
+			},
+			&core.TextField{
+				Name:     "location",
+				Required: true,
+			},
+			&core.NumberField{
+				Name:    "size",
+				OnlyInt: true,
+			},
+			&core.SelectField{
+				Name:      "verification",
+				Required:  true,
+				Values:    []string{"pending", "verified", "failed"},
+				MaxSelect: 1,
+			},
+			&core.TextField{
+				Name: "verification_error",
+			},
+			&core.DateField{
+				Name: "verified_at",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		backups.AddIndex("idx_backups_location", true, "location", "")
+		backups.AddIndex("idx_backups_verification", false, "verification, verified_at", "")
+
+		return app.Save(backups)
+	}, func(app core.App) error {
+		backups, err := app.FindCollectionByNameOrId("backups")
+		if err != nil {
+			return err
+		}
+		return app.Delete(backups)
+	})
+}
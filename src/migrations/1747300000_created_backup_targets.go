@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Where the backups of instances are stored instead of BACKUP_DIR.
+		// Holds credentials, so only superusers may see or change them.
+		targets := core.NewBaseCollection("backup_targets")
+		targets.Fields.Add(
+			&core.TextField{
+				Name:     "name",
+				Required: true,
+			},
+			&core.SelectField{
+				Name:      "type",
+				Required:  true,
+				Values:    []string{"dir", "sftp", "webdav"},
+				MaxSelect: 1,
+			},
+			// Absolute directory of dir targets
+			&core.TextField{
+				Name: "path",
+			},
+			// sftp://host[:port]/directory or the https:// URL of a WebDAV
+			// collection, e.g. a Nextcloud folder
+			&core.TextField{
+				Name: "url",
+			},
+			&core.TextField{
+				Name: "username",
+			},
+			// Password and private key may be secret references
+			&core.TextField{
+				Name:   "password",
+				Hidden: true,
+			},
+			&core.TextField{
+				Name:   "private_key",
+				Hidden: true,
+			},
+			// Public key of the SFTP server in authorized_keys format
+			&core.TextField{
+				Name: "host_key",
+			},
+			// Backups kept per workflow, older ones are deleted, 0 keeps all
+			&core.NumberField{
+				Name:    "keep",
+				OnlyInt: true,
+				Min:     types.Pointer(0.0),
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		targets.AddIndex("idx_backup_targets_name", true, "name", "")
+
+		if err := app.Save(targets); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		// Empty stores the backups of the instance in BACKUP_DIR
+		instances.Fields.Add(&core.RelationField{
+			Name:         "backup_target",
+			CollectionId: targets.Id,
+			MaxSelect:    1,
+		})
+		if err := app.Save(instances); err != nil {
+			return err
+		}
+
+		backups, err := app.FindCollectionByNameOrId("backups")
+		if err != nil {
+			return err
+		}
+		// Target the backup was written to, empty for BACKUP_DIR
+		backups.Fields.Add(&core.RelationField{
+			Name:         "target",
+			CollectionId: targets.Id,
+			MaxSelect:    1,
+		})
+		backups.RemoveIndex("idx_backups_location")
+		backups.AddIndex("idx_backups_location", true, "target, location", "")
+		return app.Save(backups)
+	}, func(app core.App) error {
+		backups, err := app.FindCollectionByNameOrId("backups")
+		if err != nil {
+			return err
+		}
+		backups.RemoveIndex("idx_backups_location")
+		backups.AddIndex("idx_backups_location", true, "location", "")
+		backups.Fields.RemoveByName("target")
+		if err := app.Save(backups); err != nil {
+			return err
+		}
+
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+		instances.Fields.RemoveByName("backup_target")
+		if err := app.Save(instances); err != nil {
+			return err
+		}
+
+		targets, err := app.FindCollectionByNameOrId("backup_targets")
+		if err != nil {
+			return err
+		}
+		return app.Delete(targets)
+	})
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Overrides of the FEATURE_<NAME> environment variables, only
+		// superusers may change them
+		flags := core.NewBaseCollection("feature_flags")
+		flags.ListRule = types.Pointer(`@request.auth.id != ""`)
+		flags.ViewRule = types.Pointer(`@request.auth.id != ""`)
+		flags.Fields.Add(
+			&core.TextField{
+				Name:     "name",
+				Required: true,
+				Pattern:  `^[a-z0-9_]+$`,
+			},
+			&core.BoolField{
+				Name: "enabled",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		flags.AddIndex("idx_feature_flags_name", true, "name", "")
+
+		return app.Save(flags)
+	}, func(app core.App) error {
+		flags, err := app.FindCollectionByNameOrId("feature_flags")
+		if err != nil {
+			return err
+		}
+		return app.Delete(flags)
+	})
+}
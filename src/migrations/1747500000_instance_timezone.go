@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		// IANA timezone of the instance, e.g. Europe/Berlin, overriding
+		// TIMEZONE for its schedules, activation windows and notifications
+		instances.Fields.Add(&core.TextField{
+			Name: "timezone",
+		})
+
+		return app.Save(instances)
+	}, func(app core.App) error {
+		instances, err := app.FindCollectionByNameOrId("instances")
+		if err != nil {
+			return err
+		}
+
+		instances.Fields.RemoveByName("timezone")
+		return app.Save(instances)
+	})
+}
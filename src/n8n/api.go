@@ -0,0 +1,219 @@
+package n8n
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/backup"
+	"go.uber.org/zap"
+)
+
+// InitAPI registers the custom n8n management endpoints
+func InitAPI(app core.App, logger *zap.Logger) {
+	bindScrubProfileValidation(app)
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/instances/import", func(e *core.RequestEvent) error {
+			return importInstancesHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.POST("/api/instances/{id}/rotate-key", func(e *core.RequestEvent) error {
+			return rotateKeyHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.POST("/api/routes/{id}/rotate-token", func(e *core.RequestEvent) error {
+			return rotateRouteTokenHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/instances/{id}/dependencies", instanceDependenciesHandler).
+			Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/instances/{id}/credentials/orphaned", orphanedCredentialsHandler).
+			Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/instances/{id}/error-coverage", errorCoverageHandler).
+			Bind(apis.RequireAuth())
+
+		se.Router.POST("/api/instances/{id}/error-workflow", func(e *core.RequestEvent) error {
+			return assignErrorWorkflowHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/instances/{id}/lint", instanceLintHandler).
+			Bind(apis.RequireAuth())
+
+		se.Router.POST("/api/instances/{id}/executions/stop", func(e *core.RequestEvent) error {
+			return stopExecutionsHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.POST("/api/instances/{id}/security-audit", func(e *core.RequestEvent) error {
+			return securityAuditHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/instances/{id}/source-control", sourceControlStatusHandler).
+			Bind(apis.RequireAuth())
+
+		se.Router.POST("/api/instances/{id}/source-control/pull", func(e *core.RequestEvent) error {
+			return sourceControlPullHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/instances/{id}/sync/status", syncStatusHandler).
+			Bind(apis.RequireAuth())
+
+		se.Router.GET("/api/schedule", scheduleHandler).
+			Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/workflows/{id}/data", workflowDataHandler).
+			Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/workflows/{id}/perf", perfHandler).
+			Bind(apis.RequireAuth())
+
+		se.Router.POST("/api/webhooks/{id}/test", func(e *core.RequestEvent) error {
+			return webhookTestHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.DELETE("/api/workflows/{id}", func(e *core.RequestEvent) error {
+			return archiveWorkflowHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.PATCH("/api/workflows/{id}", func(e *core.RequestEvent) error {
+			return updateWorkflowHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.POST("/api/workflows/{id}/strip-pin-data", func(e *core.RequestEvent) error {
+			return stripPinDataHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.POST("/api/workflows/{id}/restore", func(e *core.RequestEvent) error {
+			return restoreWorkflowHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/workflows/{id}/export", func(e *core.RequestEvent) error {
+			return exportWorkflowHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/workflows/{id}/versions", workflowVersionsHandler).
+			Bind(apis.RequireAuth())
+
+		se.Router.POST("/api/workflows/{id}/versions/{version}/restore", func(e *core.RequestEvent) error {
+			return restoreVersionHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		se.Router.GET("/api/instances/{id}/workflows/export", func(e *core.RequestEvent) error {
+			return exportInstanceWorkflowsHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
+
+// rotateKeyHandler rotates the API key of a single instance on demand
+func rotateKeyHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	result, err := RotateAPIKey(e.Request.Context(), e.App, record, audit.Actor(e.Auth), logger)
+	if err != nil {
+		if errors.Is(err, ErrRotationUnsupported) {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusBadGateway, "API key rotation failed: "+err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// rotateRouteTokenHandler rotates the token of a single route on demand
+func rotateRouteTokenHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	route, err := e.App.FindRecordById("routes", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Route not found", err)
+	}
+
+	result, err := RotateRouteToken(e.Request.Context(), e.App, route, audit.Actor(e.Auth), logger)
+	if err != nil {
+		if errors.Is(err, ErrTokenRotationUnsupported) {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusBadGateway, "Rotating the token failed: "+err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// archiveWorkflowHandler backs up a workflow and deletes it from its instance.
+// The id is the id of any workflows record of the workflow.
+func archiveWorkflowHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	dest, err := backup.ForInstance(e.Request.Context(), e.App, record.GetString("instance"))
+	if err != nil {
+		return apis.NewInternalServerError("Backup target is misconfigured", err)
+	}
+
+	result, err := ArchiveWorkflow(e.Request.Context(), e.App, dest, record, audit.Actor(e.Auth), logger)
+	if err != nil {
+		if errors.Is(err, ErrArchived) {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusBadGateway, "Archiving workflow failed: "+err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// restoreWorkflowHandler re-imports an archived workflow from its backup
+func restoreWorkflowHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	dest, err := backup.ForLocation(e.Request.Context(), e.App, record.GetString("instance"), record.GetString("backup_location"))
+	if err != nil {
+		return apis.NewInternalServerError("Backup target is misconfigured", err)
+	}
+
+	result, err := RestoreWorkflow(e.Request.Context(), e.App, dest, record, audit.Actor(e.Auth), logger)
+	if err != nil {
+		if errors.Is(err, ErrNotArchived) {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusBadGateway, "Restoring workflow failed: "+err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// updateWorkflowHandler proxies name and tag changes to the n8n instance
+func updateWorkflowHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	var update WorkflowUpdate
+	if err := e.BindBody(&update); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+	if update.Name == nil && update.Tags == nil {
+		return apis.NewBadRequestError("Nothing to update, set name and/or tags", nil)
+	}
+
+	if err := UpdateWorkflowMetadata(e.Request.Context(), e.App, record, update, audit.Actor(e.Auth), logger); err != nil {
+		if errors.Is(err, ErrArchived) || errors.Is(err, ErrEmptyName) {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusBadGateway, "Updating workflow failed: "+err.Error(), nil)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
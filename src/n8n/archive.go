@@ -0,0 +1,297 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/backup"
+	"go.uber.org/zap"
+)
+
+// ErrNotArchived is returned when restoring a workflow that is not archived
+var ErrNotArchived = errors.New("workflow is not archived")
+
+// ErrArchived is returned when archiving a workflow twice
+var ErrArchived = errors.New("workflow is already archived")
+
+// ArchiveResult describes an archived or restored workflow
+type ArchiveResult struct {
+	Instance   string `json:"instance"`
+	WorkflowID string `json:"workflow_id"`
+	Backup     string `json:"backup"`
+	WasActive  bool   `json:"was_active"`
+	// Verification is the result of reading the backup back, see backup.VerifyRecord
+	Verification string `json:"verification,omitempty"`
+	// RestoredID is the id of the re-imported workflow, n8n assigns a new one
+	RestoredID string `json:"restored_id,omitempty"`
+}
+
+// ArchiveWorkflow exports a workflow to the backup destination of its
+// instance, scrubbed with the profile set in BACKUP_SCRUB_PROFILE,
+// deactivates and deletes it in n8n and marks its local records archived.
+// record is any record of the workflows collection for the workflow.
+func ArchiveWorkflow(ctx context.Context, app core.App, dest *backup.Destination, record *core.Record, actor string, logger *zap.Logger) (*ArchiveResult, error) {
+	result, err := archiveWorkflow(ctx, app, dest, record, logger)
+	logArchiveAudit(app, "workflow.archived", "Workflow archived", record, actor, result, err, logger)
+	return result, err
+}
+
+// RestoreWorkflow re-imports an archived workflow from its backup. The local
+// history is moved to the id n8n assigns to the new workflow.
+func RestoreWorkflow(ctx context.Context, app core.App, target backup.Target, record *core.Record, actor string, logger *zap.Logger) (*ArchiveResult, error) {
+	result, err := restoreWorkflow(ctx, app, target, record, logger)
+	logArchiveAudit(app, "workflow.restored", "Workflow restored from backup", record, actor, result, err, logger)
+	return result, err
+}
+
+func logArchiveAudit(app core.App, action, message string, record *core.Record, actor string, result *ArchiveResult, err error, logger *zap.Logger) {
+	entry := audit.Entry{
+		Action:   action,
+		Instance: record.GetString("instance"),
+		Actor:    actor,
+		Success:  err == nil,
+		Details: map[string]any{
+			"workflow_id":   record.GetString("workflow_id"),
+			"workflow_name": record.GetString("workflow_name"),
+		},
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Message = message
+		entry.Details["backup"] = result.Backup
+		if result.RestoredID != "" {
+			entry.Details["restored_id"] = result.RestoredID
+		}
+	}
+	if auditErr := audit.Log(app, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+}
+
+func archiveWorkflow(ctx context.Context, app core.App, dest *backup.Destination, record *core.Record, logger *zap.Logger) (*ArchiveResult, error) {
+	if record.GetBool("archived") {
+		return nil, ErrArchived
+	}
+
+	instance, err := instanceForWorkflow(ctx, app, record)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewN8NClient(instance)
+
+	workflowID := record.GetString("workflow_id")
+	data, err := client.GetWorkflowJSON(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export workflow: %w", err)
+	}
+
+	var workflow Workflow
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("error decoding workflow: %w", err)
+	}
+
+	profile, err := backupScrubProfile(app)
+	if err != nil {
+		return nil, err
+	}
+	scrubbed, err := profile.Scrub(data)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%s/%s_%s.json", instance.Id, workflowID, now.Format("20060102T150405Z"))
+	location, err := dest.Write(ctx, key, scrubbed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ArchiveResult{
+		Instance:   instance.Id,
+		WorkflowID: workflowID,
+		Backup:     location,
+		WasActive:  workflow.Active,
+	}
+
+	// Read the backup back before the workflow is deleted, an unreadable
+	// backup stops the archiving
+	backupRecord, err := backup.Record(app, backup.Info{
+		Instance:     instance.Id,
+		WorkflowID:   workflowID,
+		WorkflowName: workflow.Name,
+		Location:     location,
+		Size:         len(scrubbed),
+		Target:       dest.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record backup: %w", err)
+	}
+	if err := backup.VerifyRecord(ctx, app, dest, backupRecord, logger); err != nil {
+		return nil, fmt.Errorf("failed to record backup verification: %w", err)
+	}
+	result.Verification = backupRecord.GetString("verification")
+	if result.Verification != backup.StatusVerified {
+		return nil, fmt.Errorf("backup failed verification: %s", backupRecord.GetString("verification_error"))
+	}
+
+	// Rotation failing leaves old backups behind, it doesn't stop the archiving
+	if pruned, err := backup.Prune(ctx, app, dest, instance.Id, workflowID); err != nil {
+		logger.Warn("Failed to delete old backups", zap.Error(err), zap.String("workflow", workflowID))
+	} else if pruned > 0 {
+		logger.Info("Deleted old backups", zap.Int("backups", pruned), zap.String("workflow", workflowID))
+	}
+
+	// Deactivate first, so webhooks are unregistered even if the delete fails
+	if workflow.Active {
+		if err := client.DeactivateWorkflow(ctx, workflowID); err != nil {
+			return nil, fmt.Errorf("failed to deactivate workflow: %w", err)
+		}
+	}
+	if err := client.DeleteWorkflow(ctx, workflowID); err != nil {
+		return nil, fmt.Errorf("failed to delete workflow: %w", err)
+	}
+
+	logger.Info("Deleted workflow from n8n",
+		zap.String("instance", instance.Id),
+		zap.String("workflow", workflowID),
+		zap.String("backup", location))
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		records, err := workflowHistory(txApp, instance.Id, workflowID)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			r.Set("archived", true)
+			r.Set("archived_at", now)
+			r.Set("backup_location", location)
+			r.Set("was_active", workflow.Active)
+			if err := txApp.Save(r); err != nil {
+				return err
+			}
+		}
+
+		// The webhooks are gone with the workflow, drop them so no routes point there
+		webhooks, err := txApp.FindAllRecords("webhooks", dbx.HashExp{"instance": instance.Id, "workflow_id": workflowID})
+		if err != nil {
+			return err
+		}
+		for _, webhook := range webhooks {
+			if err := txApp.Delete(webhook); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("workflow was deleted but could not be marked archived: %w", err)
+	}
+
+	return result, nil
+}
+
+func restoreWorkflow(ctx context.Context, app core.App, target backup.Target, record *core.Record, logger *zap.Logger) (*ArchiveResult, error) {
+	if !record.GetBool("archived") {
+		return nil, ErrNotArchived
+	}
+
+	instance, err := instanceForWorkflow(ctx, app, record)
+	if err != nil {
+		return nil, err
+	}
+
+	location := record.GetString("backup_location")
+	data, err := target.Read(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	var workflow map[string]any
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("error decoding backup: %w", err)
+	}
+
+	client := NewN8NClient(instance)
+	created, err := client.CreateWorkflow(ctx, workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import workflow: %w", err)
+	}
+
+	workflowID := record.GetString("workflow_id")
+	result := &ArchiveResult{
+		Instance:   instance.Id,
+		WorkflowID: workflowID,
+		Backup:     location,
+		WasActive:  record.GetBool("was_active"),
+		RestoredID: created.WorkflowID,
+	}
+
+	if result.WasActive {
+		if err := client.ActivateWorkflow(ctx, created.WorkflowID); err != nil {
+			logger.Warn("Restored workflow could not be activated",
+				zap.Error(err),
+				zap.String("instance", instance.Id),
+				zap.String("workflow", created.WorkflowID))
+		}
+	}
+
+	logger.Info("Restored workflow from backup",
+		zap.String("instance", instance.Id),
+		zap.String("workflow", workflowID),
+		zap.String("restored_id", created.WorkflowID))
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		records, err := workflowHistory(txApp, instance.Id, workflowID)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			r.Set("workflow_id", created.WorkflowID)
+			r.Set("archived", false)
+			r.Set("archived_at", nil)
+			if err := txApp.Save(r); err != nil {
+				return err
+			}
+		}
+		// Backups follow the new id so they stay listed as versions
+		backups, err := txApp.FindAllRecords(backup.Collection,
+			dbx.HashExp{"instance": instance.Id, "workflow_id": workflowID})
+		if err != nil {
+			return err
+		}
+		for _, b := range backups {
+			b.Set("workflow_id", created.WorkflowID)
+			if err := txApp.Save(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("workflow was restored but its history could not be updated: %w", err)
+	}
+
+	return result, nil
+}
+
+// instanceForWorkflow returns the instance a workflow record belongs to
+func instanceForWorkflow(ctx context.Context, app core.App, record *core.Record) (*Instance, error) {
+	instanceRecord, err := app.FindRecordById("instances", record.GetString("instance"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance: %w", err)
+	}
+	return InstanceFromRecord(ctx, instanceRecord)
+}
+
+// workflowHistory returns all stored versions of a workflow
+func workflowHistory(app core.App, instanceID, workflowID string) ([]*core.Record, error) {
+	return app.FindAllRecords("workflows", dbx.HashExp{"instance": instanceID, "workflow_id": workflowID})
+}
@@ -1,6 +1,8 @@
 package n8n
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,10 +10,19 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/sistemica/n8n-manager-backend/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const API_PATH = "/api/v1/"
 
+var tracer = tracing.Tracer("github.com/sistemica/n8n-manager-backend/n8n")
+
 // Client represents an HTTP client with configuration
 type Client struct {
 	http    *http.Client
@@ -22,7 +33,8 @@ type Client struct {
 func NewClient() *Client {
 	return &Client{
 		http: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newTransport(),
 		},
 		timeout: 30 * time.Second,
 	}
@@ -54,9 +66,9 @@ func (instance *Instance) GetDeactivationPath(workflowId string) string {
 }
 
 // newRequest creates a new HTTP request with common headers
-func (instance *Instance) newRequest(method, path string) (*http.Request, error) {
+func (instance *Instance) newRequest(ctx context.Context, method, path string) (*http.Request, error) {
 	url := instance.GetApiPath() + path
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -65,15 +77,60 @@ func (instance *Instance) newRequest(method, path string) (*http.Request, error)
 	return req, nil
 }
 
+// newJSONRequest creates a new HTTP request with body encoded as JSON
+func (instance *Instance) newJSONRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request: %w", err)
+	}
+
+	req, err := instance.newRequest(ctx, method, path)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	req.ContentLength = int64(len(payload))
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// do sends a request to the n8n API, wrapped in a client span
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx, span := tracer.Start(req.Context(), "n8n "+req.Method+" "+strings.TrimPrefix(req.URL.Path, API_PATH),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		))
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	span.SetAttributes(attribute.Bool("http.response.compressed", resp.Uncompressed))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+
+	return resp, nil
+}
+
 // IsHealthy checks if the n8n instance is healthy
-func (instance *Instance) IsHealthy() bool {
-	req, err := instance.newRequest("GET", "health")
+func (instance *Instance) IsHealthy(ctx context.Context) bool {
+	req, err := instance.newRequest(ctx, "GET", "health")
 	if err != nil {
 		return false
 	}
 
 	client := NewClient()
-	resp, err := client.http.Do(req)
+	resp, err := client.do(req)
 	if err != nil {
 		return false
 	}
@@ -82,15 +139,30 @@ func (instance *Instance) IsHealthy() bool {
 	return resp.StatusCode == http.StatusOK
 }
 
+// StatusError is returned when the n8n API answers with an unexpected status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
 // GetWorkflows retrieves all workflows from the n8n instance
-func (instance *Instance) GetWorkflows() ([]Workflow, error) {
-	req, err := instance.newRequest("GET", "workflows")
+func (instance *Instance) GetWorkflows(ctx context.Context) ([]Workflow, error) {
+	return instance.getWorkflows(ctx, "workflows")
+}
+
+// getWorkflows retrieves the workflow list at path, which may carry query parameters
+func (instance *Instance) getWorkflows(ctx context.Context, path string) ([]Workflow, error) {
+	req, err := instance.newRequest(ctx, "GET", path)
 	if err != nil {
 		return nil, err
 	}
 
 	client := NewClient()
-	resp, err := client.http.Do(req)
+	resp, err := client.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error making request: %w", err)
 	}
@@ -98,7 +170,7 @@ func (instance *Instance) GetWorkflows() ([]Workflow, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	responseBytes, err := io.ReadAll(resp.Body)
@@ -120,23 +192,34 @@ func (instance *Instance) GetWorkflows() ([]Workflow, error) {
 		return nil, fmt.Errorf("error decoding response: %w", err)
 	}
 
+	// Keep the raw JSON, the Workflow model only holds a subset of it
+	var raw struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(responseBytes, &raw); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
 	// Add instance ID to each workflow
 	for idx := range response.Data {
 		response.Data[idx].InstanceID = instance.Id
+		if idx < len(raw.Data) {
+			response.Data[idx].Raw = raw.Data[idx]
+		}
 	}
 
 	return response.Data, nil
 }
 
 // GetWorkflow retrieves a specific workflow by ID
-func (instance *Instance) GetWorkflow(id string) (*Workflow, error) {
-	req, err := instance.newRequest("GET", fmt.Sprintf("workflows/%s", id))
+func (instance *Instance) GetWorkflow(ctx context.Context, id string) (*Workflow, error) {
+	req, err := instance.newRequest(ctx, "GET", fmt.Sprintf("workflows/%s", id))
 	if err != nil {
 		return nil, err
 	}
 
 	client := NewClient()
-	resp, err := client.http.Do(req)
+	resp, err := client.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error making request: %w", err)
 	}
@@ -158,29 +241,166 @@ func (instance *Instance) GetWorkflow(id string) (*Workflow, error) {
 	return &workflow, nil
 }
 
+// GetWorkflowJSON retrieves the complete JSON of a workflow, including
+// the properties that are not part of the Workflow model
+func (instance *Instance) GetWorkflowJSON(ctx context.Context, id string) ([]byte, error) {
+	req, err := instance.newRequest(ctx, "GET", fmt.Sprintf("workflows/%s", id))
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient()
+	resp, err := client.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
+
+// DeleteWorkflow deletes a workflow from the n8n instance
+func (instance *Instance) DeleteWorkflow(ctx context.Context, id string) error {
+	req, err := instance.newRequest(ctx, "DELETE", fmt.Sprintf("workflows/%s", id))
+	if err != nil {
+		return err
+	}
+
+	client := NewClient()
+	resp, err := client.do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// workflowImportFields are the properties accepted when creating or updating
+// a workflow, the public API rejects any other (e.g. id, active, tags)
+var workflowImportFields = []string{"name", "nodes", "connections", "settings", "staticData"}
+
+// importableWorkflow strips a workflow JSON object down to the fields the
+// public API accepts
+func importableWorkflow(workflow map[string]any) map[string]any {
+	body := make(map[string]any, len(workflowImportFields))
+	for _, field := range workflowImportFields {
+		if value, ok := workflow[field]; ok && value != nil {
+			body[field] = value
+		}
+	}
+	// settings is required by the API
+	if _, ok := body["settings"]; !ok {
+		body["settings"] = map[string]any{}
+	}
+	// Pinned data is only sent empty to clear it, n8n keeps the pinned data
+	// of an update without it
+	if pinData, ok := workflow["pinData"].(map[string]any); ok && len(pinData) == 0 {
+		body["pinData"] = pinData
+	}
+	return body
+}
+
+// CreateWorkflow imports a workflow JSON object into the n8n instance
+func (instance *Instance) CreateWorkflow(ctx context.Context, workflow map[string]any) (*Workflow, error) {
+	req, err := instance.newJSONRequest(ctx, "POST", "workflows", importableWorkflow(workflow))
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient()
+	resp, err := client.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var created Workflow
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	created.InstanceID = instance.Id
+
+	return &created, nil
+}
+
+// ActivateWorkflow activates a workflow on the n8n instance
+func (instance *Instance) ActivateWorkflow(ctx context.Context, id string) error {
+	return instance.postWorkflowAction(ctx, fmt.Sprintf("workflows/%s/activate", id))
+}
+
+// DeactivateWorkflow deactivates a workflow on the n8n instance
+func (instance *Instance) DeactivateWorkflow(ctx context.Context, id string) error {
+	return instance.postWorkflowAction(ctx, fmt.Sprintf("workflows/%s/deactivate", id))
+}
+
+// postWorkflowAction sends a POST without body and checks the status
+func (instance *Instance) postWorkflowAction(ctx context.Context, path string) error {
+	req, err := instance.newRequest(ctx, "POST", path)
+	if err != nil {
+		return err
+	}
+
+	client := NewClient()
+	resp, err := client.do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
 // DownloadWorkflows downloads all workflows and returns them as a map of filename to JSON content
-func (instance *Instance) DownloadWorkflows() (map[string][]byte, error) {
-	workflows, err := instance.GetWorkflows()
+func (instance *Instance) DownloadWorkflows(ctx context.Context) (map[string][]byte, error) {
+	workflows, err := instance.GetWorkflows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(workflows))
+	for i, workflow := range workflows {
+		ids[i] = workflow.WorkflowID
+	}
+
+	details, err := instance.FetchWorkflowsJSON(ctx, ids, FetchConcurrency())
 	if err != nil {
 		return nil, err
 	}
 
 	result := make(map[string][]byte)
-	for _, workflow := range workflows {
+	for i, workflow := range workflows {
 		cleanName := cleanString(workflow.Name)
 		filename := fmt.Sprintf("workflow_%s.json", cleanName)
 
-		fullWorkflow, err := instance.GetWorkflow(workflow.ID)
-		if err != nil {
-			return nil, fmt.Errorf("error downloading workflow %s: %w", workflow.ID, err)
-		}
-
-		jsonData, err := json.MarshalIndent(fullWorkflow, "", "  ")
-		if err != nil {
-			return nil, fmt.Errorf("error marshaling workflow %s: %w", workflow.ID, err)
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, details[i], "", "  "); err != nil {
+			return nil, fmt.Errorf("error formatting workflow %s: %w", workflow.WorkflowID, err)
 		}
 
-		result[filename] = jsonData
+		result[filename] = buf.Bytes()
 	}
 
 	return result, nil
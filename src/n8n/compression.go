@@ -0,0 +1,22 @@
+package n8n
+
+import (
+	"net/http"
+	"os"
+)
+
+// compressionEnabled reports whether gzip compressed responses are requested
+// from n8n. N8N_API_COMPRESSION=false turns it off, e.g. for proxies which
+// mangle compressed bodies.
+func compressionEnabled() bool {
+	return os.Getenv("N8N_API_COMPRESSION") != "false"
+}
+
+// newTransport returns the transport of the n8n API client. Workflow exports
+// are large, compressed they transfer much faster: the transport asks for
+// gzip and decodes the responses unless compression is disabled.
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = !compressionEnabled()
+	return transport
+}
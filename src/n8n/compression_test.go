@@ -0,0 +1,47 @@
+package n8n
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedResponses(t *testing.T) {
+	var encoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Accept-Encoding")
+		if r.URL.Path == API_PATH+"health" {
+			// Compressed header but no body, like some proxies answer
+			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		gz.Write([]byte(`{"data":[{"id":"a","name":"Compressed"}]}`))
+	}))
+	defer server.Close()
+
+	instance := NewInstance("test", server.URL, "key")
+
+	workflows, err := instance.GetWorkflows(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", encoding)
+	require.Len(t, workflows, 1)
+	assert.Equal(t, "Compressed", workflows[0].Name)
+
+	assert.True(t, instance.IsHealthy(context.Background()))
+
+	// The transport is created per client, so the setting applies at once
+	t.Setenv("N8N_API_COMPRESSION", "false")
+	encoding = "unset"
+	assert.True(t, instance.IsHealthy(context.Background()))
+	assert.Empty(t, encoding)
+}
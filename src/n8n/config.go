@@ -0,0 +1,149 @@
+package n8n
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// ConfigCollection stores the instance-level configuration, a new snapshot
+// per change
+const ConfigCollection = "instance_config"
+
+// InstanceConfig is the instance-level configuration exposed by the n8n
+// settings, set through environment variables on the n8n side
+type InstanceConfig struct {
+	Version                  string `json:"version"`
+	Timezone                 string `json:"timezone"`
+	ExecutionMode            string `json:"execution_mode"`
+	SaveDataErrorExecution   string `json:"save_data_error_execution"`
+	SaveDataSuccessExecution string `json:"save_data_success_execution"`
+	SaveManualExecutions     bool   `json:"save_manual_executions"`
+	SaveExecutionProgress    bool   `json:"save_execution_progress"`
+	ExecutionTimeout         int    `json:"execution_timeout"`
+	MaxExecutionTimeout      int    `json:"max_execution_timeout"`
+	PruneData                *bool  `json:"prune_data,omitempty"`
+	PruneDataMaxAgeHours     *int   `json:"prune_data_max_age_hours,omitempty"`
+	PruneDataMaxCount        *int   `json:"prune_data_max_count,omitempty"`
+}
+
+// ConfigChange is a setting that differs from the previous snapshot
+type ConfigChange struct {
+	Setting string `json:"setting"`
+	Old     any    `json:"old"`
+	New     any    `json:"new"`
+}
+
+// configOf returns the configuration of the instance settings
+func configOf(settings *Settings) InstanceConfig {
+	config := InstanceConfig{
+		Version:                  settings.VersionCli,
+		Timezone:                 settings.Timezone,
+		ExecutionMode:            settings.ExecutionMode,
+		SaveDataErrorExecution:   settings.SaveDataErrorExecution,
+		SaveDataSuccessExecution: settings.SaveDataSuccessExecution,
+		SaveManualExecutions:     settings.SaveManualExecutions,
+		SaveExecutionProgress:    settings.SaveExecutionProgress,
+		ExecutionTimeout:         settings.ExecutionTimeout,
+		MaxExecutionTimeout:      settings.MaxExecutionTimeout,
+	}
+	if pruning := settings.Pruning; pruning != nil {
+		config.PruneData = &pruning.IsEnabled
+		config.PruneDataMaxAgeHours = &pruning.MaxAge
+		config.PruneDataMaxCount = &pruning.MaxCount
+	}
+	return config
+}
+
+// configChanges returns the settings that differ between two snapshots,
+// sorted by setting
+func configChanges(previous, current InstanceConfig) []ConfigChange {
+	before, after := configMap(previous), configMap(current)
+
+	changes := []ConfigChange{}
+	for setting, value := range after {
+		if old, ok := before[setting]; !ok || !reflect.DeepEqual(old, value) {
+			changes = append(changes, ConfigChange{Setting: setting, Old: before[setting], New: value})
+		}
+	}
+	for setting, old := range before {
+		if _, ok := after[setting]; !ok {
+			changes = append(changes, ConfigChange{Setting: setting, Old: old})
+		}
+	}
+	slices.SortFunc(changes, func(a, b ConfigChange) int { return cmp.Compare(a.Setting, b.Setting) })
+	return changes
+}
+
+// configMap returns a snapshot as its JSON object
+func configMap(config InstanceConfig) map[string]any {
+	data, _ := json.Marshal(config)
+	var m map[string]any
+	json.Unmarshal(data, &m)
+	return m
+}
+
+// snapshotConfig stores the configuration of an instance if it changed since
+// the last snapshot. Changes of a previous snapshot are logged and audited
+// as configuration drift.
+func snapshotConfig(app core.App, instanceId string, config InstanceConfig, logger *zap.Logger) error {
+	collection, err := app.FindCollectionByNameOrId(ConfigCollection)
+	if err != nil {
+		return err
+	}
+
+	changes := []ConfigChange{}
+	latest, err := app.FindRecordsByFilter(ConfigCollection, "instance = {:instance}", "-created", 1, 0,
+		dbx.Params{"instance": instanceId})
+	if err != nil {
+		return err
+	}
+	if len(latest) > 0 {
+		var previous InstanceConfig
+		if err := latest[0].UnmarshalJSONField("config", &previous); err != nil {
+			return fmt.Errorf("invalid stored configuration: %w", err)
+		}
+		if changes = configChanges(previous, config); len(changes) == 0 {
+			return nil
+		}
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("instance", instanceId)
+	record.Set("config", config)
+	record.Set("changes", changes)
+	if err := app.Save(record); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	settings := make([]string, len(changes))
+	for i, change := range changes {
+		settings[i] = change.Setting
+	}
+	logger.Warn("Instance configuration changed",
+		zap.String("instance", instanceId),
+		zap.Strings("settings", settings))
+
+	entry := audit.Entry{
+		Action:   "instance.config_changed",
+		Instance: instanceId,
+		Actor:    audit.Actor(nil),
+		Success:  true,
+		Message:  fmt.Sprintf("%d instance settings changed", len(changes)),
+		Details:  map[string]any{"changes": changes},
+	}
+	if err := audit.Log(app, entry); err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package n8n
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigOf(t *testing.T) {
+	var settings Settings
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"executionMode": "queue",
+		"timezone": "Europe/Berlin",
+		"versionCli": "1.90.2",
+		"saveDataSuccessExecution": "none",
+		"executionTimeout": -1,
+		"pruning": {"isEnabled": true, "maxAge": 336, "maxCount": 10000}
+	}`), &settings))
+
+	config := configOf(&settings)
+	assert.Equal(t, "Europe/Berlin", config.Timezone)
+	assert.Equal(t, "none", config.SaveDataSuccessExecution)
+	assert.Equal(t, -1, config.ExecutionTimeout)
+	require.NotNil(t, config.PruneDataMaxAgeHours)
+	assert.Equal(t, 336, *config.PruneDataMaxAgeHours)
+
+	assert.Nil(t, configOf(&Settings{}).PruneData, "older versions don't report pruning")
+}
+
+func TestConfigChanges(t *testing.T) {
+	enabled := true
+	previous := InstanceConfig{Timezone: "UTC", ExecutionMode: "regular", SaveManualExecutions: true, PruneData: &enabled}
+	current := previous
+	assert.Empty(t, configChanges(previous, current))
+
+	current.Timezone = "Europe/Berlin"
+	current.SaveManualExecutions = false
+	current.PruneData = nil
+	assert.Equal(t, []ConfigChange{
+		{Setting: "prune_data", Old: true},
+		{Setting: "save_manual_executions", Old: true, New: false},
+		{Setting: "timezone", Old: "UTC", New: "Europe/Berlin"},
+	}, configChanges(previous, current))
+}
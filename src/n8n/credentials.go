@@ -0,0 +1,19 @@
+package n8n
+
+import (
+	"context"
+)
+
+// UpdateCredential replaces the data of the credential with the given id.
+// The credential type must match the existing one.
+func (instance *Instance) UpdateCredential(ctx context.Context, id, credentialType string, data map[string]any) error {
+	body := map[string]any{
+		"type": credentialType,
+		"data": data,
+	}
+	req, err := instance.newJSONRequest(ctx, "PATCH", "credentials/"+id, body)
+	if err != nil {
+		return err
+	}
+	return instance.doJSON(req, nil)
+}
@@ -0,0 +1,172 @@
+package n8n
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+var (
+	// envPattern matches $env.NAME and $env["NAME"], in expressions and Code nodes
+	envPattern = regexp.MustCompile(`\$env(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[\s*['"]([^'"]+)['"]\s*\])`)
+	// varsPattern matches n8n variables, $vars.NAME and $vars["NAME"]
+	varsPattern = regexp.MustCompile(`\$vars(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[\s*['"]([^'"]+)['"]\s*\])`)
+)
+
+// Dependencies lists what a workflow needs from the instance it runs on
+type Dependencies struct {
+	// Env are environment variables referenced with $env
+	Env []string `json:"env"`
+	// Variables are n8n variables referenced with $vars
+	Variables []string `json:"variables"`
+	// Credentials are the credentials used by nodes, as "type:name"
+	Credentials []string `json:"credentials"`
+}
+
+// ScanDependencies extracts the environment variables, n8n variables and
+// credentials a workflow JSON depends on
+func ScanDependencies(raw []byte) Dependencies {
+	deps := Dependencies{Env: []string{}, Variables: []string{}, Credentials: []string{}}
+
+	var workflow struct {
+		Nodes []map[string]any `json:"nodes"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &workflow) != nil {
+		return deps
+	}
+
+	env := map[string]bool{}
+	vars := map[string]bool{}
+	credentials := map[string]bool{}
+
+	for _, node := range workflow.Nodes {
+		walkStrings(node["parameters"], func(s string) {
+			collectMatches(envPattern, s, env)
+			collectMatches(varsPattern, s, vars)
+		})
+
+		if creds, ok := node["credentials"].(map[string]any); ok {
+			for credentialType, value := range creds {
+				name := ""
+				if credential, ok := value.(map[string]any); ok {
+					name, _ = credential["name"].(string)
+				}
+				credentials[credentialType+":"+name] = true
+			}
+		}
+	}
+
+	deps.Env = sortedKeys(env)
+	deps.Variables = sortedKeys(vars)
+	deps.Credentials = sortedKeys(credentials)
+	return deps
+}
+
+// walkStrings calls fn for every string in a decoded JSON value
+func walkStrings(value any, fn func(string)) {
+	switch v := value.(type) {
+	case string:
+		fn(v)
+	case map[string]any:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	case []any:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	}
+}
+
+func collectMatches(pattern *regexp.Regexp, s string, into map[string]bool) {
+	for _, match := range pattern.FindAllStringSubmatch(s, -1) {
+		if match[1] != "" {
+			into[match[1]] = true
+		} else {
+			into[match[2]] = true
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DependencyUsage is a dependency and the workflows using it
+type DependencyUsage struct {
+	Name      string         `json:"name"`
+	Workflows []WorkflowInfo `json:"workflows"`
+}
+
+// WorkflowInfo identifies a workflow
+type WorkflowInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// InstanceDependencies is the response of GET /api/instances/{id}/dependencies
+type InstanceDependencies struct {
+	Env         []DependencyUsage `json:"env"`
+	Variables   []DependencyUsage `json:"variables"`
+	Credentials []DependencyUsage `json:"credentials"`
+}
+
+// instanceDependenciesHandler aggregates the dependencies of the current
+// version of every workflow of an instance
+func instanceDependenciesHandler(e *core.RequestEvent) error {
+	instance, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	records, err := latestWorkflows(e.App, instance.Id)
+	if err != nil {
+		return apis.NewBadRequestError("Failed to load workflows", err)
+	}
+
+	env := map[string][]WorkflowInfo{}
+	vars := map[string][]WorkflowInfo{}
+	credentials := map[string][]WorkflowInfo{}
+
+	for _, record := range records {
+		var deps Dependencies
+		if err := record.UnmarshalJSONField("dependencies", &deps); err != nil {
+			continue
+		}
+
+		info := WorkflowInfo{ID: record.GetString("workflow_id"), Name: record.GetString("workflow_name")}
+		for _, name := range deps.Env {
+			env[name] = append(env[name], info)
+		}
+		for _, name := range deps.Variables {
+			vars[name] = append(vars[name], info)
+		}
+		for _, name := range deps.Credentials {
+			credentials[name] = append(credentials[name], info)
+		}
+	}
+
+	return e.JSON(http.StatusOK, InstanceDependencies{
+		Env:         usages(env),
+		Variables:   usages(vars),
+		Credentials: usages(credentials),
+	})
+}
+
+func usages(m map[string][]WorkflowInfo) []DependencyUsage {
+	result := make([]DependencyUsage, 0, len(m))
+	for name, workflows := range m {
+		result = append(result, DependencyUsage{Name: name, Workflows: workflows})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,37 @@
+package n8n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanDependencies(t *testing.T) {
+	raw := []byte(`{
+		"name": "Orders",
+		"nodes": [
+			{
+				"name": "HTTP Request",
+				"parameters": {
+					"url": "={{ $env.API_BASE_URL }}/orders?key={{ $env[\"API_KEY\"] }}",
+					"options": {"headers": ["={{ $vars.tenant }}"]}
+				},
+				"credentials": {"httpHeaderAuth": {"id": "1", "name": "Orders API"}}
+			},
+			{
+				"name": "Code",
+				"parameters": {"jsCode": "const region = $env.REGION;\nreturn [{ json: { t: $vars['tenant'] } }];"},
+				"notes": "$env.NOT_A_PARAMETER"
+			}
+		]
+	}`)
+
+	deps := ScanDependencies(raw)
+	assert.Equal(t, []string{"API_BASE_URL", "API_KEY", "REGION"}, deps.Env)
+	assert.Equal(t, []string{"tenant"}, deps.Variables)
+	assert.Equal(t, []string{"httpHeaderAuth:Orders API"}, deps.Credentials)
+
+	empty := ScanDependencies(nil)
+	assert.Empty(t, empty.Env)
+	assert.NotNil(t, empty.Env)
+}
@@ -0,0 +1,119 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"go.uber.org/zap"
+)
+
+// defaultDiskWarnPercent is used unless DISK_USAGE_WARN_PERCENT is set
+const defaultDiskWarnPercent = 90
+
+// DiskUsage is the storage usage reported by the disk probe of an instance.
+// n8n doesn't expose it, the probe is a helper workflow on the instance (or
+// any service next to it) answering a GET with this JSON, unknown values
+// left out.
+type DiskUsage struct {
+	BinaryDataBytes int64 `json:"binary_data_bytes"`
+	DatabaseBytes   int64 `json:"database_bytes"`
+	DiskFreeBytes   int64 `json:"disk_free_bytes"`
+	DiskTotalBytes  int64 `json:"disk_total_bytes"`
+}
+
+// UsedPercent returns how full the disk is, 0 if its size is unknown
+func (u DiskUsage) UsedPercent() float64 {
+	if u.DiskTotalBytes <= 0 {
+		return 0
+	}
+	return 100 * float64(u.DiskTotalBytes-u.DiskFreeBytes) / float64(u.DiskTotalBytes)
+}
+
+// diskWarnPercent returns the disk usage alerted about, set with
+// DISK_USAGE_WARN_PERCENT
+func diskWarnPercent() float64 {
+	if percent, err := strconv.ParseFloat(os.Getenv("DISK_USAGE_WARN_PERCENT"), 64); err == nil && percent > 0 {
+		return percent
+	}
+	return defaultDiskWarnPercent
+}
+
+// probeDisk fetches the disk usage from the disk probe at url
+func probeDisk(ctx context.Context, url string) (*DiskUsage, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := NewClient().do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("disk probe failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var usage DiskUsage
+	if err := json.NewDecoder(resp.Body).Decode(&usage); err != nil {
+		return nil, fmt.Errorf("error decoding disk usage: %w", err)
+	}
+	return &usage, nil
+}
+
+// checkDiskUsage probes the disk usage of an instance with a disk_probe_url
+// and sets it on the record, alerting when the disk fills up beyond
+// DISK_USAGE_WARN_PERCENT. It returns nil without a probe or if it failed.
+func checkDiskUsage(ctx context.Context, record *core.Record, logger *zap.Logger) *DiskUsage {
+	url := record.GetString("disk_probe_url")
+	if url == "" {
+		return nil
+	}
+
+	usage, err := probeDisk(ctx, url)
+	if err != nil {
+		logger.Warn("Failed to probe disk usage",
+			zap.Error(err),
+			zap.String("instance", record.Id))
+		return nil
+	}
+
+	previous := record.GetFloat("disk_used_percent")
+	percent := usage.UsedPercent()
+	record.Set("binary_data_bytes", usage.BinaryDataBytes)
+	record.Set("database_bytes", usage.DatabaseBytes)
+	record.Set("disk_used_percent", percent)
+
+	// Alert once when crossing the threshold, not on every sync
+	if warn := diskWarnPercent(); percent >= warn && previous < warn {
+		logger.Warn("Instance is running out of disk",
+			zap.String("instance", record.Id),
+			zap.Float64("disk_used_percent", percent))
+
+		err := notify.Send(ctx, notify.Notification{
+			Event:    "instance.disk_usage",
+			Severity: notify.SeverityWarning,
+			Title:    fmt.Sprintf("%s is running out of disk", record.GetString("host")),
+			Message: fmt.Sprintf("%.1f%% of the disk used, %d MB free. Binary data takes %d MB, the database %d MB.",
+				percent, usage.DiskFreeBytes>>20, usage.BinaryDataBytes>>20, usage.DatabaseBytes>>20),
+			Fields: map[string]any{
+				"instance":   record.Id,
+				"disk_usage": usage,
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send disk usage notification", zap.Error(err), zap.String("instance", record.Id))
+		}
+	}
+
+	return usage
+}
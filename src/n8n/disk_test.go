@@ -0,0 +1,34 @@
+package n8n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeDisk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"binary_data_bytes": 5368709120, "database_bytes": 1073741824,
+			"disk_free_bytes": 8589934592, "disk_total_bytes": 85899345920}`)
+	}))
+	defer server.Close()
+
+	usage, err := probeDisk(context.Background(), server.URL+"/webhook/disk-usage")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5368709120), usage.BinaryDataBytes)
+	assert.InDelta(t, 90, usage.UsedPercent(), 0.001)
+
+	_, err = probeDisk(context.Background(), server.URL+"/broken")
+	assert.Error(t, err)
+
+	assert.Zero(t, DiskUsage{DatabaseBytes: 1024}.UsedPercent(), "disk size unknown")
+}
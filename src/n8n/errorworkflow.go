@@ -0,0 +1,348 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// errorTriggerType starts error workflows, n8n runs them when an execution
+// of a workflow pointing to them fails
+const errorTriggerType = "n8n-nodes-base.errorTrigger"
+
+// Reasons a workflow isn't covered by an error workflow
+const (
+	UncoveredMissing = "missing" // no error workflow configured
+	UncoveredUnknown = "unknown" // the error workflow doesn't exist on the instance
+)
+
+// ErrUnknownErrorWorkflow is returned when assigning an error workflow that
+// isn't an error workflow of the instance
+var ErrUnknownErrorWorkflow = errors.New("not an error workflow of the instance")
+
+// ErrorHandling is how a workflow handles failed executions
+type ErrorHandling struct {
+	// ErrorWorkflow is the id of the workflow run when an execution fails
+	ErrorWorkflow string
+	// Handler is set for error workflows, they start with an Error Trigger
+	Handler bool
+}
+
+// workflowErrorHandling reads the errorWorkflow setting and the Error
+// Trigger of a workflow
+func workflowErrorHandling(workflow Workflow) ErrorHandling {
+	var handling ErrorHandling
+	for _, node := range workflow.Nodes {
+		if node.Type == errorTriggerType && !node.Disabled {
+			handling.Handler = true
+		}
+	}
+
+	var raw struct {
+		Settings struct {
+			ErrorWorkflow json.RawMessage `json:"errorWorkflow"`
+		} `json:"settings"`
+	}
+	if len(workflow.Raw) == 0 || json.Unmarshal(workflow.Raw, &raw) != nil {
+		return handling
+	}
+	if id := rawID(raw.Settings.ErrorWorkflow); id != "null" {
+		handling.ErrorWorkflow = id
+	}
+	return handling
+}
+
+// setErrorHandling stores the error handling on a workflows record and
+// reports whether it changed
+func setErrorHandling(record *core.Record, handling ErrorHandling) bool {
+	if record.GetString("error_workflow") == handling.ErrorWorkflow &&
+		record.GetBool("error_handler") == handling.Handler {
+		return false
+	}
+	record.Set("error_workflow", handling.ErrorWorkflow)
+	record.Set("error_handler", handling.Handler)
+	return true
+}
+
+// coverageWorkflow is the latest version of a workflow as needed for the
+// error workflow coverage
+type coverageWorkflow struct {
+	Record *core.Record
+	WorkflowInfo
+	Active   bool
+	Handling ErrorHandling
+}
+
+// UncoveredWorkflow is an active workflow whose failures go unnoticed
+type UncoveredWorkflow struct {
+	// Record is the id of the latest workflows record
+	Record        string `json:"record"`
+	WorkflowID    string `json:"workflow_id"`
+	Name          string `json:"name"`
+	ErrorWorkflow string `json:"error_workflow,omitempty"`
+	Reason        string `json:"reason"`
+}
+
+// ErrorCoverage is the response of GET /api/instances/{id}/error-coverage
+type ErrorCoverage struct {
+	Instance string `json:"instance"`
+	// DefaultErrorWorkflow is assigned by POST /api/instances/{id}/error-workflow
+	DefaultErrorWorkflow string              `json:"default_error_workflow"`
+	Active               int                 `json:"active"`
+	Covered              int                 `json:"covered"`
+	Handlers             []WorkflowInfo      `json:"handlers"`
+	Uncovered            []UncoveredWorkflow `json:"uncovered"`
+}
+
+// coverageWorkflows returns the latest version of every workflow of an
+// instance that isn't archived
+func coverageWorkflows(app core.App, instanceID string) ([]coverageWorkflow, error) {
+	records, err := latestWorkflows(app, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var workflows []coverageWorkflow
+	for _, record := range records {
+		workflows = append(workflows, coverageWorkflow{
+			Record:       record,
+			WorkflowInfo: WorkflowInfo{ID: record.GetString("workflow_id"), Name: record.GetString("workflow_name")},
+			Active:       record.GetBool("active"),
+			Handling: ErrorHandling{
+				ErrorWorkflow: record.GetString("error_workflow"),
+				Handler:       record.GetBool("error_handler"),
+			},
+		})
+	}
+	return workflows, nil
+}
+
+// errorCoverage reports the active workflows without a working error
+// workflow. Error workflows themselves are not expected to have one.
+func errorCoverage(workflows []coverageWorkflow) ErrorCoverage {
+	coverage := ErrorCoverage{Handlers: []WorkflowInfo{}, Uncovered: []UncoveredWorkflow{}}
+	exists := map[string]bool{}
+	for _, workflow := range workflows {
+		exists[workflow.ID] = true
+		if workflow.Handling.Handler {
+			coverage.Handlers = append(coverage.Handlers, workflow.WorkflowInfo)
+		}
+	}
+
+	for _, workflow := range workflows {
+		if !workflow.Active || workflow.Handling.Handler {
+			continue
+		}
+		coverage.Active++
+
+		reason := ""
+		switch {
+		case workflow.Handling.ErrorWorkflow == "":
+			reason = UncoveredMissing
+		case !exists[workflow.Handling.ErrorWorkflow]:
+			reason = UncoveredUnknown
+		}
+		if reason == "" {
+			coverage.Covered++
+			continue
+		}
+
+		uncovered := UncoveredWorkflow{
+			WorkflowID:    workflow.ID,
+			Name:          workflow.Name,
+			ErrorWorkflow: workflow.Handling.ErrorWorkflow,
+			Reason:        reason,
+		}
+		if workflow.Record != nil {
+			uncovered.Record = workflow.Record.Id
+		}
+		coverage.Uncovered = append(coverage.Uncovered, uncovered)
+	}
+	return coverage
+}
+
+// errorCoverageHandler reports the active workflows of an instance without
+// an error workflow
+func errorCoverageHandler(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	workflows, err := coverageWorkflows(e.App, record.Id)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to load workflows", err)
+	}
+
+	coverage := errorCoverage(workflows)
+	coverage.Instance = record.Id
+	coverage.DefaultErrorWorkflow = record.GetString("default_error_workflow")
+	return e.JSON(http.StatusOK, coverage)
+}
+
+// AssignResult is the response of POST /api/instances/{id}/error-workflow
+type AssignResult struct {
+	ErrorWorkflow string            `json:"error_workflow"`
+	Assigned      []string          `json:"assigned"`
+	Failed        map[string]string `json:"failed,omitempty"`
+}
+
+// AssignErrorWorkflow sets the error workflow of the uncovered active
+// workflows of an instance, or of the given workflows only, through the n8n
+// API and writes an audit entry
+func AssignErrorWorkflow(ctx context.Context, app core.App, record *core.Record, errorWorkflow string, workflowIDs []string, actor string, logger *zap.Logger) (*AssignResult, error) {
+	workflows, err := coverageWorkflows(app, record.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflows: %w", err)
+	}
+	if !slices.ContainsFunc(workflows, func(w coverageWorkflow) bool {
+		return w.ID == errorWorkflow && w.Handling.Handler
+	}) {
+		return nil, ErrUnknownErrorWorkflow
+	}
+
+	if len(workflowIDs) == 0 {
+		for _, uncovered := range errorCoverage(workflows).Uncovered {
+			workflowIDs = append(workflowIDs, uncovered.WorkflowID)
+		}
+	}
+
+	result := &AssignResult{ErrorWorkflow: errorWorkflow, Assigned: []string{}, Failed: map[string]string{}}
+	if len(workflowIDs) == 0 {
+		return result, nil
+	}
+
+	instance, err := InstanceFromRecord(ctx, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load instance: %w", err)
+	}
+	client := NewN8NClient(instance)
+
+	latest := map[string]*core.Record{}
+	for _, workflow := range workflows {
+		latest[workflow.ID] = workflow.Record
+	}
+	for _, workflowID := range workflowIDs {
+		if err := setErrorWorkflow(ctx, client, workflowID, errorWorkflow); err != nil {
+			result.Failed[workflowID] = err.Error()
+			continue
+		}
+		result.Assigned = append(result.Assigned, workflowID)
+
+		// Keep the report accurate until the next sync stores the new version
+		if workflow := latest[workflowID]; workflow != nil {
+			workflow.Set("error_workflow", errorWorkflow)
+			if err := app.Save(workflow); err != nil {
+				logger.Warn("Failed to update workflow record",
+					zap.Error(err),
+					zap.String("workflow", workflowID))
+			}
+		}
+	}
+
+	entry := audit.Entry{
+		Action:   "workflows.error_workflow_assigned",
+		Instance: record.Id,
+		Actor:    actor,
+		Message:  fmt.Sprintf("Assigned error workflow %s to %d of %d workflows", errorWorkflow, len(result.Assigned), len(workflowIDs)),
+		Success:  len(result.Failed) == 0,
+		Details:  map[string]any{"error_workflow": errorWorkflow, "assigned": result.Assigned, "failed": result.Failed},
+	}
+	if err := audit.Log(app, entry); err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err))
+	}
+
+	return result, nil
+}
+
+// setErrorWorkflow updates the errorWorkflow setting of a workflow
+func setErrorWorkflow(ctx context.Context, client N8NClient, workflowID, errorWorkflow string) error {
+	data, err := client.GetWorkflowJSON(ctx, workflowID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch workflow: %w", err)
+	}
+	var workflow map[string]any
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return fmt.Errorf("error decoding workflow: %w", err)
+	}
+
+	settings, _ := workflow["settings"].(map[string]any)
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	settings["errorWorkflow"] = errorWorkflow
+	workflow["settings"] = settings
+
+	if err := client.UpdateWorkflow(ctx, workflowID, workflow); err != nil {
+		return fmt.Errorf("failed to update workflow: %w", err)
+	}
+	return nil
+}
+
+// assignErrorWorkflowHandler assigns {"error_workflow": "..."}, by default
+// the instance's default_error_workflow, to the workflows in "workflow_ids"
+// or to all uncovered active workflows
+func assignErrorWorkflowHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	var body struct {
+		ErrorWorkflow string   `json:"error_workflow"`
+		WorkflowIDs   []string `json:"workflow_ids"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+	errorWorkflow := body.ErrorWorkflow
+	if errorWorkflow == "" {
+		errorWorkflow = record.GetString("default_error_workflow")
+	}
+	if errorWorkflow == "" {
+		return apis.NewBadRequestError("Set error_workflow or the default error workflow of the instance", nil)
+	}
+
+	result, err := AssignErrorWorkflow(e.Request.Context(), e.App, record, errorWorkflow, body.WorkflowIDs, audit.Actor(e.Auth), logger)
+	if err != nil {
+		if errors.Is(err, ErrUnknownErrorWorkflow) {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		return apis.NewInternalServerError("Assigning the error workflow failed", err)
+	}
+
+	status := http.StatusOK
+	if len(result.Assigned) == 0 && len(result.Failed) > 0 {
+		status = http.StatusBadGateway
+	}
+	return e.JSON(status, result)
+}
+
+// autoAssignErrorWorkflow assigns the default error workflow of an instance
+// to its uncovered active workflows when error_workflow_auto is set
+func autoAssignErrorWorkflow(ctx context.Context, app core.App, record *core.Record, logger *zap.Logger) error {
+	errorWorkflow := record.GetString("default_error_workflow")
+	if !record.GetBool("error_workflow_auto") || errorWorkflow == "" {
+		return nil
+	}
+
+	result, err := AssignErrorWorkflow(ctx, app, record, errorWorkflow, nil, audit.Actor(nil), logger)
+	if err != nil {
+		return err
+	}
+	if len(result.Assigned) > 0 || len(result.Failed) > 0 {
+		logger.Info("Assigned default error workflow",
+			zap.String("instance", record.Id),
+			zap.Int("assigned", len(result.Assigned)),
+			zap.Int("failed", len(result.Failed)))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowErrorHandling(t *testing.T) {
+	workflow := Workflow{Raw: json.RawMessage(`{"settings": {"errorWorkflow": "42", "timezone": "Europe/Berlin"}}`)}
+	assert.Equal(t, ErrorHandling{ErrorWorkflow: "42"}, workflowErrorHandling(workflow))
+
+	workflow.Raw = json.RawMessage(`{"settings": {"errorWorkflow": 42}}`)
+	assert.Equal(t, "42", workflowErrorHandling(workflow).ErrorWorkflow, "numeric ids are read as strings")
+
+	workflow.Raw = json.RawMessage(`{"settings": {}}`)
+	assert.Empty(t, workflowErrorHandling(workflow).ErrorWorkflow)
+
+	handler := Workflow{Nodes: []Node{{Name: "Error Trigger", Type: errorTriggerType}}}
+	assert.Equal(t, ErrorHandling{Handler: true}, workflowErrorHandling(handler))
+
+	handler.Nodes[0].Disabled = true
+	assert.False(t, workflowErrorHandling(handler).Handler, "disabled Error Triggers don't run")
+}
+
+func TestErrorCoverage(t *testing.T) {
+	workflow := func(id string, active bool, handling ErrorHandling) coverageWorkflow {
+		return coverageWorkflow{WorkflowInfo: WorkflowInfo{ID: id, Name: id}, Active: active, Handling: handling}
+	}
+
+	coverage := errorCoverage([]coverageWorkflow{
+		workflow("alerts", true, ErrorHandling{Handler: true}),
+		workflow("orders", true, ErrorHandling{ErrorWorkflow: "alerts"}),
+		workflow("invoices", true, ErrorHandling{}),
+		workflow("reports", true, ErrorHandling{ErrorWorkflow: "deleted"}),
+		workflow("drafts", false, ErrorHandling{}),
+	})
+
+	assert.Equal(t, 3, coverage.Active, "error workflows don't need one")
+	assert.Equal(t, 1, coverage.Covered)
+	assert.Equal(t, []WorkflowInfo{{ID: "alerts", Name: "alerts"}}, coverage.Handlers)
+	assert.Equal(t, []UncoveredWorkflow{
+		{WorkflowID: "invoices", Name: "invoices", Reason: UncoveredMissing},
+		{WorkflowID: "reports", Name: "reports", ErrorWorkflow: "deleted", Reason: UncoveredUnknown},
+	}, coverage.Uncovered)
+}
+
+func TestSetErrorWorkflow(t *testing.T) {
+	client := &MockClient{
+		GetWorkflowJSONFunc: func(context.Context, string) ([]byte, error) {
+			return []byte(`{"id": "7", "name": "Orders", "nodes": [], "settings": {"timezone": "UTC"}}`), nil
+		},
+	}
+
+	require.NoError(t, setErrorWorkflow(context.Background(), client, "7", "42"))
+
+	calls := client.Calls("UpdateWorkflow")
+	require.Len(t, calls, 1)
+	assert.Equal(t, "7", calls[0].Args[0])
+	updated := calls[0].Args[1].(map[string]any)
+	assert.Equal(t, map[string]any{"timezone": "UTC", "errorWorkflow": "42"}, updated["settings"],
+		"other settings are kept")
+}
@@ -0,0 +1,130 @@
+package n8n
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Execution statuses as reported by n8n
+const (
+	ExecutionSuccess  = "success"
+	ExecutionError    = "error"
+	ExecutionCrashed  = "crashed"
+	ExecutionCanceled = "canceled"
+	ExecutionRunning  = "running"
+	ExecutionWaiting  = "waiting"
+	ExecutionNew      = "new"
+)
+
+// executionsPageSize is the largest page the executions list returns
+const executionsPageSize = 250
+
+// Execution is an execution as listed by the n8n API, without its data
+type Execution struct {
+	ID         string     `json:"id"`
+	WorkflowID string     `json:"workflowId"`
+	Status     string     `json:"status"`
+	Mode       string     `json:"mode"`
+	Finished   bool       `json:"finished"`
+	StartedAt  time.Time  `json:"startedAt"`
+	StoppedAt  *time.Time `json:"stoppedAt"`
+}
+
+// UnmarshalJSON accepts numeric ids, older n8n versions don't quote them
+func (e *Execution) UnmarshalJSON(data []byte) error {
+	type execution Execution
+	var raw struct {
+		execution
+		ID         json.RawMessage `json:"id"`
+		WorkflowID json.RawMessage `json:"workflowId"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = Execution(raw.execution)
+	e.ID = rawID(raw.ID)
+	e.WorkflowID = rawID(raw.WorkflowID)
+	return nil
+}
+
+// rawID returns a JSON string or number as string
+func rawID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	return string(bytes.TrimSpace(raw))
+}
+
+// Duration returns how long a stopped execution ran, ok is false while it
+// runs
+func (e Execution) Duration() (duration time.Duration, ok bool) {
+	if e.StoppedAt == nil || e.StartedAt.IsZero() {
+		return 0, false
+	}
+	switch e.Status {
+	case ExecutionRunning, ExecutionWaiting, ExecutionNew:
+		return 0, false
+	}
+	return e.StoppedAt.Sub(e.StartedAt), true
+}
+
+// ExecutionFilter selects the executions listed by GetExecutions
+type ExecutionFilter struct {
+	// Status only lists executions with this status if set
+	Status string
+
+	// StartedAfter stops listing at the first execution started before,
+	// executions are listed newest first
+	StartedAfter time.Time
+
+	// Limit caps the number of executions, 0 lists all
+	Limit int
+}
+
+// executionsResponse is a page of the executions list
+type executionsResponse struct {
+	Data       []Execution `json:"data"`
+	NextCursor string      `json:"nextCursor"`
+}
+
+// GetExecutions retrieves the executions matching filter, newest first.
+// truncated is set if filter.Limit stopped the listing early.
+func (instance *Instance) GetExecutions(ctx context.Context, filter ExecutionFilter) (executions []Execution, truncated bool, err error) {
+	query := url.Values{}
+	query.Set("includeData", "false")
+	query.Set("limit", strconv.Itoa(executionsPageSize))
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+
+	for {
+		req, err := instance.newRequest(ctx, "GET", "executions?"+query.Encode())
+		if err != nil {
+			return nil, false, err
+		}
+
+		var page executionsResponse
+		if err := instance.doJSON(req, &page); err != nil {
+			return nil, false, err
+		}
+		for _, execution := range page.Data {
+			if !filter.StartedAfter.IsZero() && execution.StartedAt.Before(filter.StartedAfter) {
+				return executions, false, nil
+			}
+			if filter.Limit > 0 && len(executions) == filter.Limit {
+				return executions, true, nil
+			}
+			executions = append(executions, execution)
+		}
+
+		if page.NextCursor == "" {
+			return executions, false, nil
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+}
@@ -0,0 +1,59 @@
+package n8n
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultFetchConcurrency is the number of parallel workflow detail
+// requests, configurable with WORKFLOW_FETCH_CONCURRENCY
+const defaultFetchConcurrency = 4
+
+// fetchRequestTimeout bounds each single detail request
+const fetchRequestTimeout = 30 * time.Second
+
+// FetchConcurrency returns the configured number of parallel detail requests
+func FetchConcurrency() int {
+	if value, err := strconv.Atoi(os.Getenv("WORKFLOW_FETCH_CONCURRENCY")); err == nil && value > 0 {
+		return value
+	}
+	return defaultFetchConcurrency
+}
+
+// FetchWorkflowsJSON fetches the complete JSON of the given workflows with
+// at most concurrency requests in flight. Results are in the order of ids.
+// Every request has its own timeout; the first error cancels the
+// remaining requests.
+func (instance *Instance) FetchWorkflowsJSON(ctx context.Context, ids []string, concurrency int) ([][]byte, error) {
+	if concurrency <= 0 {
+		concurrency = FetchConcurrency()
+	}
+
+	results := make([][]byte, len(ids))
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+
+	for i, id := range ids {
+		group.Go(func() error {
+			reqCtx, cancel := context.WithTimeout(ctx, fetchRequestTimeout)
+			defer cancel()
+
+			data, err := instance.GetWorkflowJSON(reqCtx, id)
+			if err != nil {
+				return fmt.Errorf("error downloading workflow %s: %w", id, err)
+			}
+			results[i] = data
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,53 @@
+package n8n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchWorkflowsJSON(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			previous := maxInFlight.Load()
+			if current <= previous || maxInFlight.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		id := strings.TrimPrefix(r.URL.Path, API_PATH+"workflows/")
+		if id == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"id":%q}`, id)
+	}))
+	defer server.Close()
+
+	instance := NewInstance("test", server.URL, "key")
+
+	ids := []string{"1", "2", "3", "4", "5", "6", "7", "8"}
+	results, err := instance.FetchWorkflowsJSON(context.Background(), ids, 3)
+	require.NoError(t, err)
+	require.Len(t, results, len(ids))
+	for i, id := range ids {
+		assert.JSONEq(t, fmt.Sprintf(`{"id":%q}`, id), string(results[i]))
+	}
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+	assert.Greater(t, maxInFlight.Load(), int32(1))
+
+	_, err = instance.FetchWorkflowsJSON(context.Background(), []string{"1", "broken", "3"}, 2)
+	assert.ErrorContains(t, err, "error downloading workflow broken")
+}
@@ -0,0 +1,210 @@
+package n8n
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// defaultImportInterval is the check interval of imported instances without one
+const defaultImportInterval = 5
+
+// ImportRow is an instance to import. In CSV the columns are named like the
+// JSON fields, with a header row.
+type ImportRow struct {
+	Host string `json:"host"`
+	// APIKey is the n8n API key or a secret reference
+	APIKey          string `json:"api_key"`
+	CheckInterval   *int   `json:"check_interval_mins"`
+	Owner           string `json:"owner"`
+	IgnoreSSLErrors bool   `json:"ignore_ssl_errors"`
+}
+
+// ImportResult is the outcome of a row, ID is set if the instance was created
+type ImportResult struct {
+	// Row is the 1-based position of the row, not counting the CSV header
+	Row   int    `json:"row"`
+	Host  string `json:"host"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportSummary is the response of POST /api/instances/import
+type ImportSummary struct {
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Results []ImportResult `json:"results"`
+}
+
+// parseImportCSV reads import rows from CSV with a header row
+func parseImportCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty CSV")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "host", "api_key", "check_interval_mins", "owner", "ignore_ssl_errors":
+		default:
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		header[i] = column
+	}
+
+	rows := []ImportRow{}
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var row ImportRow
+		for i, value := range values {
+			value = strings.TrimSpace(value)
+			switch header[i] {
+			case "host":
+				row.Host = value
+			case "api_key":
+				row.APIKey = value
+			case "owner":
+				row.Owner = value
+			case "check_interval_mins":
+				if value == "" {
+					continue
+				}
+				interval, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid check_interval_mins %q", len(rows)+2, value)
+				}
+				row.CheckInterval = &interval
+			case "ignore_ssl_errors":
+				row.IgnoreSSLErrors, _ = strconv.ParseBool(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// validateImportRow checks a row and normalizes its host
+func validateImportRow(row *ImportRow) error {
+	row.Host = strings.TrimRight(strings.TrimSpace(row.Host), "/")
+	if u, err := url.Parse(row.Host); err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("host must be an absolute URL, e.g. https://n8n.example.com")
+	}
+	if row.APIKey == "" {
+		return errors.New("api_key is required")
+	}
+	if row.CheckInterval != nil && *row.CheckInterval < 0 {
+		return errors.New("check_interval_mins must not be negative")
+	}
+	return nil
+}
+
+// ImportInstances creates the instances of rows, skipping invalid rows and
+// hosts that already exist. Every row gets a result.
+func ImportInstances(app core.App, rows []ImportRow) (*ImportSummary, error) {
+	collection, err := app.FindCollectionByNameOrId("instances")
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ImportSummary{Results: make([]ImportResult, 0, len(rows))}
+	for i, row := range rows {
+		err := validateImportRow(&row)
+		result := ImportResult{Row: i + 1, Host: row.Host}
+
+		if err == nil {
+			if existing, _ := app.FindFirstRecordByData(collection, "host", row.Host); existing != nil {
+				err = fmt.Errorf("instance already exists with id %s", existing.Id)
+			}
+		}
+		if err == nil {
+			interval := defaultImportInterval
+			if row.CheckInterval != nil {
+				interval = *row.CheckInterval
+			}
+
+			record := core.NewRecord(collection)
+			record.Set("host", row.Host)
+			record.Set("api_key", row.APIKey)
+			record.Set("check_interval_mins", interval)
+			record.Set("owner", row.Owner)
+			record.Set("ignore_ssl_errors", row.IgnoreSSLErrors)
+			if err = app.Save(record); err == nil {
+				result.ID = record.Id
+			}
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+			summary.Failed++
+		} else {
+			summary.Created++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	return summary, nil
+}
+
+// importInstancesHandler creates instances from a JSON array of rows or,
+// with Content-Type text/csv, from CSV
+func importInstancesHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	var rows []ImportRow
+	mediaType, _, _ := mime.ParseMediaType(e.Request.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		var err error
+		if rows, err = parseImportCSV(e.Request.Body); err != nil {
+			return apis.NewBadRequestError("Invalid CSV: "+err.Error(), nil)
+		}
+	} else if err := json.NewDecoder(e.Request.Body).Decode(&rows); err != nil {
+		return apis.NewBadRequestError("Invalid request body, expected a JSON array of instances", err)
+	}
+	if len(rows) == 0 {
+		return apis.NewBadRequestError("No instances to import", nil)
+	}
+
+	summary, err := ImportInstances(e.App, rows)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to import instances", err)
+	}
+
+	if summary.Created > 0 {
+		logger.Info("Imported instances",
+			zap.Int("created", summary.Created),
+			zap.Int("failed", summary.Failed))
+	}
+	err = audit.Log(e.App, audit.Entry{
+		Action:  "instance.imported",
+		Actor:   audit.Actor(e.Auth),
+		Success: summary.Failed == 0,
+		Message: fmt.Sprintf("Imported %d of %d instances", summary.Created, len(rows)),
+		Details: map[string]any{"results": summary.Results},
+	})
+	if err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err))
+	}
+
+	return e.JSON(http.StatusOK, summary)
+}
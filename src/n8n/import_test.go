@@ -0,0 +1,42 @@
+package n8n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	rows, err := parseImportCSV(strings.NewReader(
+		"Host,api_key,check_interval_mins,owner\n" +
+			"https://n8n-a.example.com/,key-a,10,team-a\n" +
+			"https://n8n-b.example.com,vault://secret/n8n-b#api_key,,team-b\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "https://n8n-a.example.com/", rows[0].Host)
+	assert.Equal(t, 10, *rows[0].CheckInterval)
+	assert.Equal(t, "team-a", rows[0].Owner)
+	assert.Equal(t, "vault://secret/n8n-b#api_key", rows[1].APIKey)
+	assert.Nil(t, rows[1].CheckInterval)
+
+	_, err = parseImportCSV(strings.NewReader("host,apikey\nhttps://n8n.example.com,key\n"))
+	assert.ErrorContains(t, err, `unknown column "apikey"`)
+
+	_, err = parseImportCSV(strings.NewReader("host,check_interval_mins\nhttps://n8n.example.com,often\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestValidateImportRow(t *testing.T) {
+	row := ImportRow{Host: " https://n8n.example.com/ ", APIKey: "key"}
+	require.NoError(t, validateImportRow(&row))
+	assert.Equal(t, "https://n8n.example.com", row.Host)
+
+	assert.Error(t, validateImportRow(&ImportRow{Host: "n8n.example.com", APIKey: "key"}))
+	assert.Error(t, validateImportRow(&ImportRow{Host: "https://n8n.example.com"}))
+
+	interval := -1
+	assert.Error(t, validateImportRow(&ImportRow{Host: "https://n8n.example.com", APIKey: "key", CheckInterval: &interval}))
+}
@@ -0,0 +1,149 @@
+package n8n
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// updatedAfterMargin is subtracted from the last sync time to tolerate clock
+// differences between the manager and the n8n instance. Unchanged workflows
+// returned because of it are skipped by syncWorkflows.
+const updatedAfterMargin = 5 * time.Minute
+
+// filterUnsupported remembers instances which rejected or ignored the
+// updatedAfter filter, they are always fetched in full until restart.
+var filterUnsupported sync.Map
+
+// FullSyncInterval returns how often an instance is fetched completely even
+// if it supports incremental fetching. Only a full fetch detects deleted
+// workflows. Configured with SYNC_FULL_INTERVAL_MINS, 0 disables incremental
+// fetching.
+func FullSyncInterval() time.Duration {
+	if value := os.Getenv("SYNC_FULL_INTERVAL_MINS"); value != "" {
+		if mins, err := strconv.Atoi(value); err == nil && mins >= 0 {
+			return time.Duration(mins) * time.Minute
+		}
+	}
+	return 60 * time.Minute
+}
+
+// GetWorkflowsUpdatedAfter retrieves the workflows changed after since.
+// supported is false if the instance doesn't know the filter: a rejected
+// filter returns no workflows, an ignored one returns the complete list.
+func (instance *Instance) GetWorkflowsUpdatedAfter(ctx context.Context, since time.Time) (workflows []Workflow, supported bool, err error) {
+	path := "workflows?updatedAfter=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+	workflows, err = instance.getWorkflows(ctx, path)
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Older versions ignore unknown query parameters and return everything
+	for _, workflow := range workflows {
+		if workflow.UpdatedAt.Before(since) {
+			return workflows, false, nil
+		}
+	}
+	return workflows, true, nil
+}
+
+// fetchWorkflows retrieves the workflows to sync. full is false if only the
+// workflows changed since the last successful sync were returned.
+func fetchWorkflows(ctx context.Context, client N8NClient, record *core.Record, logger *zap.Logger) (workflows []Workflow, full bool, err error) {
+	syncedAt := record.GetDateTime("synced_at").Time()
+	lastFull := record.GetDateTime("last_full_sync").Time()
+	interval := FullSyncInterval()
+
+	_, unsupported := filterUnsupported.Load(record.Id)
+	if unsupported || interval == 0 || syncedAt.IsZero() || time.Since(lastFull) >= interval {
+		workflows, err = client.GetWorkflows(ctx)
+		return workflows, true, err
+	}
+
+	workflows, supported, err := client.GetWorkflowsUpdatedAfter(ctx, syncedAt.Add(-updatedAfterMargin))
+	if err != nil {
+		return nil, false, err
+	}
+	if supported {
+		return workflows, false, nil
+	}
+
+	logger.Info("Instance doesn't support the updatedAfter filter, using full fetches",
+		zap.String("instance", record.Id))
+	filterUnsupported.Store(record.Id, true)
+
+	if workflows == nil {
+		workflows, err = client.GetWorkflows(ctx)
+	}
+	return workflows, true, err
+}
+
+// storedWorkflows returns the latest stored version of every workflow of an
+// instance with its node types, enough to calculate the instance statistics.
+func storedWorkflows(app core.App, instanceID string) ([]Workflow, error) {
+	records, err := latestWorkflows(app, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := app.FindAllRecords("workflow_nodes", dbx.HashExp{"instance": instanceID})
+	if err != nil {
+		return nil, err
+	}
+	nodesByVersion := map[string][]Node{}
+	for _, node := range nodes {
+		version := node.GetString("workflow")
+		nodesByVersion[version] = append(nodesByVersion[version], Node{
+			ID:       node.GetString("node_id"),
+			Name:     node.GetString("name"),
+			Type:     node.GetString("type"),
+			Disabled: node.GetBool("disabled"),
+		})
+	}
+
+	var workflows []Workflow
+	for _, record := range records {
+		workflows = append(workflows, Workflow{
+			ID:         record.Id,
+			Name:       record.GetString("workflow_name"),
+			WorkflowID: record.GetString("workflow_id"),
+			Active:     record.GetBool("active"),
+			Nodes:      nodesByVersion[record.Id],
+			InstanceID: instanceID,
+		})
+	}
+	return workflows, nil
+}
+
+// mergeWorkflows replaces stored workflows by their changed version
+func mergeWorkflows(stored, changed []Workflow) []Workflow {
+	index := map[string]int{}
+	merged := make([]Workflow, 0, len(stored)+len(changed))
+	for _, workflow := range stored {
+		index[workflow.WorkflowID] = len(merged)
+		merged = append(merged, workflow)
+	}
+	for _, workflow := range changed {
+		if i, ok := index[workflow.WorkflowID]; ok {
+			merged[i] = workflow
+			continue
+		}
+		index[workflow.WorkflowID] = len(merged)
+		merged = append(merged, workflow)
+	}
+	return merged
+}
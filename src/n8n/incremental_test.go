@@ -0,0 +1,127 @@
+package n8n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetWorkflowsUpdatedAfter(t *testing.T) {
+	since := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		supported bool
+		count     int
+	}{
+		{"filtered", http.StatusOK, `{"data":[{"id":"a","updatedAt":"2025-03-01T12:30:00Z"}]}`, true, 1},
+		{"nothing changed", http.StatusOK, `{"data":[]}`, true, 0},
+		{"ignored", http.StatusOK, `{"data":[{"id":"a","updatedAt":"2025-03-01T12:30:00Z"},{"id":"b","updatedAt":"2025-01-01T00:00:00Z"}]}`, false, 2},
+		{"rejected", http.StatusBadRequest, `{"message":"unknown query parameter"}`, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "2025-03-01T12:00:00Z", r.URL.Query().Get("updatedAfter"))
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			instance := NewInstance("test", server.URL, "key")
+			workflows, supported, err := instance.GetWorkflowsUpdatedAfter(context.Background(), since)
+			require.NoError(t, err)
+			assert.Equal(t, tt.supported, supported)
+			assert.Len(t, workflows, tt.count)
+		})
+	}
+}
+
+func TestMergeWorkflows(t *testing.T) {
+	stored := []Workflow{
+		{WorkflowID: "a", Name: "A"},
+		{WorkflowID: "b", Name: "B"},
+	}
+	changed := []Workflow{
+		{WorkflowID: "b", Name: "B2", Active: true},
+		{WorkflowID: "c", Name: "C"},
+	}
+
+	merged := mergeWorkflows(stored, changed)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "A", merged[0].Name)
+	assert.Equal(t, "B2", merged[1].Name)
+	assert.True(t, merged[1].Active)
+	assert.Equal(t, "C", merged[2].Name)
+}
+
+func TestFetchWorkflows(t *testing.T) {
+	collection := core.NewBaseCollection("instances")
+	collection.Fields.Add(&core.DateField{Name: "synced_at"}, &core.DateField{Name: "last_full_sync"})
+	newRecord := func(id string, syncedAt, lastFull time.Time) *core.Record {
+		record := core.NewRecord(collection)
+		record.Id = id
+		if !syncedAt.IsZero() {
+			record.Set("synced_at", syncedAt)
+		}
+		if !lastFull.IsZero() {
+			record.Set("last_full_sync", lastFull)
+		}
+		return record
+	}
+
+	now := time.Now()
+	changed := []Workflow{{WorkflowID: "a"}}
+
+	t.Run("first sync is full", func(t *testing.T) {
+		client := &MockClient{GetWorkflowsFunc: func(context.Context) ([]Workflow, error) { return changed, nil }}
+		_, full, err := fetchWorkflows(context.Background(), client, newRecord("first", time.Time{}, time.Time{}), zap.NewNop())
+		require.NoError(t, err)
+		assert.True(t, full)
+		assert.Empty(t, client.Calls("GetWorkflowsUpdatedAfter"))
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		client := &MockClient{GetWorkflowsUpdatedAfterFunc: func(context.Context, time.Time) ([]Workflow, bool, error) {
+			return changed, true, nil
+		}}
+		syncedAt := now.Add(-time.Minute)
+		workflows, full, err := fetchWorkflows(context.Background(), client, newRecord("incremental", syncedAt, now), zap.NewNop())
+		require.NoError(t, err)
+		assert.False(t, full)
+		assert.Equal(t, changed, workflows)
+		require.Len(t, client.Calls("GetWorkflowsUpdatedAfter"), 1)
+		since := client.Calls("GetWorkflowsUpdatedAfter")[0].Args[0].(time.Time)
+		assert.WithinDuration(t, syncedAt.Add(-updatedAfterMargin), since, time.Second)
+	})
+
+	t.Run("full sync is due", func(t *testing.T) {
+		client := &MockClient{}
+		_, full, err := fetchWorkflows(context.Background(), client, newRecord("due", now, now.Add(-2*FullSyncInterval())), zap.NewNop())
+		require.NoError(t, err)
+		assert.True(t, full)
+		assert.Len(t, client.Calls("GetWorkflows"), 1)
+	})
+
+	t.Run("rejected filter is remembered", func(t *testing.T) {
+		client := &MockClient{}
+		record := newRecord("rejected", now, now)
+		for range 2 {
+			_, full, err := fetchWorkflows(context.Background(), client, record, zap.NewNop())
+			require.NoError(t, err)
+			assert.True(t, full)
+		}
+		assert.Len(t, client.Calls("GetWorkflowsUpdatedAfter"), 1)
+		assert.Len(t, client.Calls("GetWorkflows"), 2)
+	})
+}
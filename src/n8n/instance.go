@@ -1,11 +1,20 @@
 package n8n
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+	"github.com/sistemica/n8n-manager-backend/tracing"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -43,6 +52,28 @@ func (instance *Instance) GetInstanceStats(workflows []Workflow) (*InstanceStats
 	return stats, nil
 }
 
+// InstanceFromRecord creates an Instance from an instances record.
+// The api_key field may hold a secret reference which is resolved here.
+func InstanceFromRecord(ctx context.Context, record *core.Record) (*Instance, error) {
+	apiKey, err := secrets.Resolve(ctx, record.GetString("api_key"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve API key: %w", err)
+	}
+
+	instance := NewInstance(
+		record.Id,
+		record.GetString("host"),
+		apiKey,
+	)
+
+	// Set instance's check interval from DB
+	instance.CheckInterval = record.GetInt("check_interval_mins")
+	instance.IgnoreSSLErrors = record.GetBool("ignore_ssl_errors")
+	instance.StoreMode = record.GetString("store_mode")
+
+	return instance, nil
+}
+
 // shouldCheckInstance determines if it's time to check an instance based on its check interval
 func shouldCheckInstance(lastCheck types.DateTime, checkInterval int) bool {
 	if checkInterval == 0 {
@@ -54,83 +85,226 @@ func shouldCheckInstance(lastCheck types.DateTime, checkInterval int) bool {
 	return time.Now().After(nextCheckTime)
 }
 
+// CheckInstancesJob is the id of the cron job syncing instances
+const CheckInstancesJob = "check-instances"
+
 // InitCronJobs sets up the recurring check of n8n instances
 func InitCronJobs(app core.App, logger *zap.Logger) {
-	app.Cron().MustAdd("check-instances", "* * * * *", func() {
-		instances, err := app.FindAllRecords("instances")
+	app.Cron().MustAdd(CheckInstancesJob, "* * * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", CheckInstancesJob))
+		started := time.Now()
+
+		if maintenance.Enabled(app, logger) {
+			logger.Debug("Maintenance mode enabled, skipping instance checks")
+			return
+		}
+
+		instances, err := app.FindAllRecords("instances", trash.NotDeleted)
 		if err != nil {
 			logger.Error("Failed to fetch n8n instances", zap.Error(err))
 			return
 		}
 
+		// Only check instances whose check interval has elapsed
+		var due []*core.Record
 		for _, record := range instances {
-			// Skip if it's not time to check this instance yet
-			if !shouldCheckInstance(record.GetDateTime("last_check"), record.GetInt("check_interval_mins")) {
-				continue
+			if shouldCheckInstance(record.GetDateTime("last_check"), record.GetInt("check_interval_mins")) {
+				due = append(due, record)
 			}
+		}
 
-			// Create instance object
-			instance := NewInstance(
-				record.Id,
-				record.GetString("host"),
-				record.GetString("api_key"),
-			)
-
-			// Set instance's check interval from DB
-			instance.CheckInterval = record.GetInt("check_interval_mins")
-			instance.IgnoreSSLErrors = record.GetBool("ignore_ssl_errors")
-
-			// Start the sync process
-			err := syncInstance(app, instance, record, logger)
-			if err != nil {
-				logger.Error("Failed to sync instance",
-					zap.Error(err),
-					zap.String("instance", instance.Host))
-
-				// Update record with error information
-				record.Set("last_check", time.Now())
-				record.Set("availability_status", false)
-				record.Set("availability_note", err.Error())
-				if saveErr := app.Save(record); saveErr != nil {
-					logger.Error("Failed to update instance status", zap.Error(saveErr))
-				}
-			}
+		tickStarted(started, len(due))
+		defer func() { tickFinished(time.Now()) }()
+
+		for _, record := range due {
+			checkInstance(context.Background(), app, record, logger)
+			instanceDone()
 		}
 	})
+
+	initCredentialEncryption(app)
+	initRotationCron(app, logger)
+	initTokenRotationCron(app, logger)
+	initRealtimeEvents(app)
+	initWebhookCheckCron(app, logger)
+	initSecurityAuditCron(app, logger)
+}
+
+// checkInstance runs a single sync of an instance as a tracked sync run.
+// All log lines of the run carry its sync_run_id.
+func checkInstance(ctx context.Context, app core.App, record *core.Record, logger *zap.Logger) {
+	run := startSyncRun(record, logger)
+	ctx = WithRunID(ctx, run.ID)
+	logger = run.logger
+
+	// A panic while syncing one instance must not stop the others
+	defer errorreport.Recover(logger,
+		zap.String("job", CheckInstancesJob),
+		zap.String("instance", record.GetString("host")),
+		zap.String("instance_id", record.Id))
+
+	publishProgress(ctx, app, record.Id, PhaseStarted, 0, 0, nil)
+
+	// Create instance object, resolving a secret reference in api_key if needed
+	instance, err := InstanceFromRecord(ctx, record)
+	var stats *InstanceStats
+	if err == nil {
+		// Start the sync process
+		stats, err = syncInstance(ctx, app, instance, record, logger)
+	}
+	if err != nil {
+		logger.Error("Failed to sync instance",
+			zap.Error(err),
+			zap.String("instance", record.GetString("host")))
+
+		// Update record with error information
+		record.Set("last_check", time.Now())
+		record.Set("availability_status", false)
+		record.Set("availability_note", err.Error())
+		record.Set("health", string(HealthDown))
+		if saveErr := app.Save(record); saveErr != nil {
+			logger.Error("Failed to update instance status", zap.Error(saveErr))
+		}
+	}
+
+	run.finish(app, stats, err)
+
+	if err != nil {
+		publishProgress(ctx, app, record.Id, PhaseFailed, 0, 0, err)
+	} else {
+		publishProgress(ctx, app, record.Id, PhaseFinished, stats.TotalWorkflows, stats.TotalWorkflows, nil)
+	}
 }
 
 // syncInstance handles the complete sync process for a single instance
-func syncInstance(app core.App, instance *Instance, record *core.Record, logger *zap.Logger) error {
-	// Fetch all workflows from the instance
-	workflows, err := instance.GetWorkflows()
+func syncInstance(ctx context.Context, app core.App, instance *Instance, record *core.Record, logger *zap.Logger) (stats *InstanceStats, err error) {
+	ctx, span := tracer.Start(ctx, "syncInstance", trace.WithAttributes(
+		attribute.String("n8n.instance.id", instance.Id),
+		attribute.String("n8n.instance.host", instance.Host),
+		attribute.String("n8n.sync_run.id", RunIDFromContext(ctx)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	publishProgress(ctx, app, instance.Id, PhaseFetching, 0, 0, nil)
+
+	client := NewN8NClient(instance)
+
+	// Fetch the workflows, only the changed ones if the instance supports it
+	startedAt := time.Now()
+	workflows, full, err := fetchWorkflows(ctx, client, record, logger)
 	if err != nil {
-		return fmt.Errorf("failed to get workflows: %w", err)
+		return nil, fmt.Errorf("failed to get workflows: %w", err)
+	}
+
+	// Statistics cover all workflows, unchanged ones come from the database
+	current := workflows
+	if !full {
+		stored, err := storedWorkflows(app, instance.Id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load stored workflows: %w", err)
+		}
+		current = mergeWorkflows(stored, workflows)
+		logger.Debug("Fetched changed workflows",
+			zap.String("instance", instance.Id),
+			zap.Int("changed", len(workflows)))
 	}
 
 	// Get statistics based on the workflows
-	stats, err := instance.GetInstanceStats(workflows)
+	stats, err = instance.GetInstanceStats(current)
 	if err != nil {
-		return fmt.Errorf("failed to calculate instance statistics: %w", err)
+		return nil, fmt.Errorf("failed to calculate instance statistics: %w", err)
 	}
 
 	// Sync workflows to the database
-	if err := syncWorkflows(app, instance, workflows, logger); err != nil {
+	if err := syncWorkflows(ctx, app, instance, workflows, logger); err != nil {
 		logger.Error("Failed to sync workflows",
 			zap.Error(err),
 			zap.String("instance", instance.Id))
 	}
 
+	// Cover new workflows with the default error workflow, if enabled
+	if err := autoAssignErrorWorkflow(ctx, app, record, logger); err != nil {
+		logger.Warn("Failed to assign default error workflow",
+			zap.Error(err),
+			zap.String("instance", instance.Id))
+	}
+
+	if stats.StorageBytes, err = instanceStorageBytes(app, instance.Id); err != nil {
+		logger.Warn("Failed to calculate storage usage",
+			zap.Error(err),
+			zap.String("instance", instance.Id))
+	}
+
+	// Binary data and database size, if the instance has a disk probe
+	stats.Disk = checkDiskUsage(ctx, record, logger)
+
+	// Scrape the instance's own Prometheus metrics if enabled
+	var metrics *InstanceMetrics
+	if record.GetBool("metrics_enabled") {
+		if metrics, err = scrapeMetrics(ctx, app, client, instance.Id, logger); err != nil {
+			logger.Warn("Failed to scrape instance metrics",
+				zap.Error(err),
+				zap.String("instance", instance.Id))
+		}
+	}
+
+	// Execution durations per workflow, if enabled
+	if record.GetBool("executions_enabled") && features.Enabled(app, features.ExecutionSync) {
+		if err := syncExecutions(ctx, app, client, record, logger); err != nil {
+			logger.Warn("Failed to sync executions",
+				zap.Error(err),
+				zap.String("instance", instance.Id))
+		} else {
+			record.Set("executions_synced_at", startedAt)
+		}
+	}
+
+	// Users of the instance for access reviews, if the API key may list them
+	if err := syncUsers(ctx, app, client, record.Id, logger); err != nil {
+		logger.Warn("Failed to sync users",
+			zap.Error(err),
+			zap.String("instance", instance.Id))
+	}
+
+	// Instance-level settings, for the queue mode, license and config drift
+	settings, err := client.GetSettings(ctx)
+	if err != nil {
+		logger.Debug("Failed to fetch instance settings",
+			zap.Error(err),
+			zap.String("instance", instance.Id))
+	}
+	if settings != nil {
+		setLicense(record, licenseOf(settings))
+		if err := snapshotConfig(app, record.Id, configOf(settings), logger); err != nil {
+			logger.Warn("Failed to store instance configuration",
+				zap.Error(err),
+				zap.String("instance", instance.Id))
+		}
+	}
+
+	// A queue mode instance without workers is reachable but degraded
+	queue := checkQueueMode(ctx, record, settings, metrics, logger)
+
 	// Update instance record with new statistics
 	record.Set("workflows_active", stats.ActiveWorkflows)
 	record.Set("workflows_inactive", stats.InactiveWorkflows)
 	record.Set("webhooks_active", stats.ActiveWebhooks)
 	record.Set("webhooks_inactive", stats.InactiveWebhooks)
+	record.Set("storage_bytes", stats.StorageBytes)
+	record.Set("execution_mode", queue.ExecutionMode)
+	record.Set("workers_total", queue.WorkersTotal)
+	record.Set("workers_up", queue.WorkersUp)
+	record.Set("health", string(queue.Health))
 	record.Set("last_check", time.Now())
+	record.Set("synced_at", startedAt)
+	if full {
+		record.Set("last_full_sync", startedAt)
+	}
 	record.Set("availability_status", true)
-	record.Set("availability_note", "")
+	record.Set("availability_note", queue.Note)
 
 	if err := app.Save(record); err != nil {
-		return fmt.Errorf("failed to update instance record: %w", err)
+		return nil, fmt.Errorf("failed to update instance record: %w", err)
 	}
 
 	logger.Info("Updated n8n instance",
@@ -139,5 +313,5 @@ func syncInstance(app core.App, instance *Instance, record *core.Record, logger
 		zap.Int("active_workflows", stats.ActiveWorkflows),
 		zap.Int("webhooks", stats.TotalWebhooks))
 
-	return nil
+	return stats, nil
 }
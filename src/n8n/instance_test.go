@@ -0,0 +1,61 @@
+package n8n
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSyncInstanceUsesClient(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	record := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com", "api_key": "key", "metrics_enabled": true})
+	updatedAt := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	client := &MockClient{
+		GetWorkflowsFunc: func(context.Context) ([]Workflow, error) {
+			return []Workflow{{
+				Name:       "Orders",
+				WorkflowID: "wf1",
+				Active:     true,
+				CreatedAt:  updatedAt,
+				UpdatedAt:  updatedAt,
+				Nodes:      []Node{{ID: "n1", Name: "Webhook", Type: "n8n-nodes-base.webhook"}},
+			}}, nil
+		},
+		GetMetricsFunc: func(context.Context) (*InstanceMetrics, error) {
+			return &InstanceMetrics{QueueWaiting: 3, QueueMode: true}, nil
+		},
+		GetSettingsFunc: func(context.Context) (*Settings, error) {
+			return &Settings{ExecutionMode: ExecutionModeQueue}, nil
+		},
+	}
+	newClient := NewN8NClient
+	NewN8NClient = func(*Instance) N8NClient { return client }
+	t.Cleanup(func() { NewN8NClient = newClient })
+
+	instance := NewInstance(record.Id, "n8n.example.com", "key")
+	stats, err := syncInstance(context.Background(), app, instance, record, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.ActiveWorkflows)
+	assert.Len(t, client.Calls("GetWorkflows"), 1)
+	assert.Len(t, client.Calls("GetMetrics"), 1)
+
+	versions, err := app.CountRecords("workflows", dbx.HashExp{"instance": record.Id, "workflow_id": "wf1"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, versions)
+	samples, err := app.CountRecords("instance_metrics", dbx.HashExp{"instance": record.Id})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, samples)
+
+	// Waiting jobs without active ones degrade a queue mode instance
+	stored, err := app.FindRecordById("instances", record.Id)
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionModeQueue, stored.GetString("execution_mode"))
+	assert.Equal(t, string(HealthDegraded), stored.GetString("health"))
+}
@@ -0,0 +1,93 @@
+package n8n
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Editions of n8n
+const (
+	EditionCommunity  = "community"
+	EditionEnterprise = "enterprise"
+)
+
+// Enterprise features used by the manager, as named in the n8n settings
+const (
+	FeatureProjects      = "projects"
+	FeatureVariables     = "variables"
+	FeatureSourceControl = "sourceControl"
+)
+
+// ErrFeatureUnavailable is returned for actions that need an enterprise
+// feature the instance isn't licensed for
+var ErrFeatureUnavailable = errors.New("enterprise feature not available on this instance")
+
+// nonFeatures are entries of the enterprise settings that aren't features
+var nonFeatures = []string{"showNonProdBanner"}
+
+// License is the edition of an instance and its enterprise features
+type License struct {
+	Edition  string   `json:"edition"`
+	Plan     string   `json:"plan"`
+	Features []string `json:"features"`
+}
+
+// licenseOf returns the license of the instance settings. Features are
+// enabled with true, projects with a team project limit other than 0.
+func licenseOf(settings *Settings) License {
+	license := License{Edition: EditionCommunity, Features: []string{}}
+	for name, value := range settings.Enterprise {
+		if slices.Contains(nonFeatures, name) {
+			continue
+		}
+		switch value := value.(type) {
+		case bool:
+			if value {
+				license.Features = append(license.Features, name)
+			}
+		case map[string]any:
+			if name == FeatureProjects {
+				team, _ := value["team"].(map[string]any)
+				if limit, ok := team["limit"].(float64); ok && limit != 0 {
+					license.Features = append(license.Features, name)
+				}
+			}
+		}
+	}
+	slices.Sort(license.Features)
+
+	if len(license.Features) > 0 {
+		license.Edition = EditionEnterprise
+	}
+	if settings.License != nil {
+		license.Plan = settings.License.PlanName
+	}
+	return license
+}
+
+// setLicense stores the license on an instances record
+func setLicense(record *core.Record, license License) {
+	record.Set("edition", license.Edition)
+	record.Set("license_plan", license.Plan)
+	record.Set("features", license.Features)
+}
+
+// HasFeature reports whether the instance of an instances record is licensed
+// for an enterprise feature, as detected by the last sync
+func HasFeature(record *core.Record, feature string) bool {
+	var features []string
+	record.UnmarshalJSONField("features", &features)
+	return slices.Contains(features, feature)
+}
+
+// RequireFeature returns ErrFeatureUnavailable if the instance of an
+// instances record isn't licensed for an enterprise feature
+func RequireFeature(record *core.Record, feature string) error {
+	if HasFeature(record, feature) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrFeatureUnavailable, feature)
+}
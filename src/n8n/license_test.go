@@ -0,0 +1,38 @@
+package n8n
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseOf(t *testing.T) {
+	var settings Settings
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"enterprise": {
+			"sharing": false,
+			"variables": false,
+			"showNonProdBanner": true,
+			"projects": {"team": {"limit": 0}}
+		}
+	}`), &settings))
+	assert.Equal(t, License{Edition: EditionCommunity, Features: []string{}}, licenseOf(&settings))
+
+	settings = Settings{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"enterprise": {
+			"sharing": true,
+			"variables": true,
+			"sourceControl": true,
+			"projects": {"team": {"limit": -1}}
+		},
+		"license": {"planName": "Enterprise", "environment": "production"}
+	}`), &settings))
+	assert.Equal(t, License{
+		Edition:  EditionEnterprise,
+		Plan:     "Enterprise",
+		Features: []string{FeatureProjects, "sharing", FeatureSourceControl, FeatureVariables},
+	}, licenseOf(&settings))
+}
@@ -0,0 +1,249 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// Lint rules
+const (
+	// RulePinnedData flags active workflows with pinned data, test data that
+	// n8n uses instead of real input when running from the editor
+	RulePinnedData = "pinned-data"
+)
+
+// Severities of lint findings
+const (
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is a problem found in a workflow version, stored in lint_findings
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity string   `json:"severity"`
+	Message  string   `json:"message"`
+	Nodes    []string `json:"nodes,omitempty"`
+}
+
+// pinnedNodes returns the sorted names of the nodes with pinned data
+func pinnedNodes(raw []byte) []string {
+	var workflow struct {
+		PinData map[string]json.RawMessage `json:"pinData"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &workflow) != nil {
+		return nil
+	}
+
+	var nodes []string
+	for name, items := range workflow.PinData {
+		// n8n keeps an empty list after unpinning in some versions
+		var list []json.RawMessage
+		if json.Unmarshal(items, &list) == nil && len(list) == 0 {
+			continue
+		}
+		nodes = append(nodes, name)
+	}
+	slices.Sort(nodes)
+	return nodes
+}
+
+// lintWorkflow returns the findings of a workflow
+func lintWorkflow(workflow Workflow) []Finding {
+	findings := []Finding{}
+
+	if nodes := pinnedNodes(workflow.Raw); len(nodes) > 0 {
+		finding := Finding{
+			Rule:     RulePinnedData,
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("Pinned data in %d nodes", len(nodes)),
+			Nodes:    nodes,
+		}
+		if workflow.Active {
+			// Pinned data is fine while building, in production it hides
+			// stale test data
+			finding.Severity = SeverityWarning
+			finding.Message = fmt.Sprintf("Active workflow with pinned data in %d nodes", len(nodes))
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+// findingsOf returns the lint findings stored on a workflows record
+func findingsOf(record *core.Record) []Finding {
+	var findings []Finding
+	record.UnmarshalJSONField("lint_findings", &findings)
+	if findings == nil {
+		return []Finding{}
+	}
+	return findings
+}
+
+// setLintFindings stores the findings on a workflows record and reports
+// whether they changed
+func setLintFindings(record *core.Record, findings []Finding) bool {
+	current, _ := json.Marshal(findingsOf(record))
+	updated, _ := json.Marshal(findings)
+	if string(current) == string(updated) {
+		return false
+	}
+	record.Set("lint_findings", findings)
+	return true
+}
+
+// LintedWorkflow is a workflow with findings
+type LintedWorkflow struct {
+	// Record is the id of the latest workflows record
+	Record     string    `json:"record"`
+	WorkflowID string    `json:"workflow_id"`
+	Name       string    `json:"name"`
+	Active     bool      `json:"active"`
+	Findings   []Finding `json:"findings"`
+}
+
+// instanceLintHandler returns the latest version of the workflows of an
+// instance with lint findings, filtered by ?rule= and ?severity=
+func instanceLintHandler(e *core.RequestEvent) error {
+	instance, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+	rule := e.Request.URL.Query().Get("rule")
+	severity := e.Request.URL.Query().Get("severity")
+
+	records, err := latestWorkflows(e.App, instance.Id)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to load workflows", err)
+	}
+
+	workflows := []LintedWorkflow{}
+	for _, record := range records {
+		findings := slices.DeleteFunc(findingsOf(record), func(f Finding) bool {
+			return (rule != "" && f.Rule != rule) || (severity != "" && f.Severity != severity)
+		})
+		if len(findings) == 0 {
+			continue
+		}
+		workflows = append(workflows, LintedWorkflow{
+			Record:     record.Id,
+			WorkflowID: record.GetString("workflow_id"),
+			Name:       record.GetString("workflow_name"),
+			Active:     record.GetBool("active"),
+			Findings:   findings,
+		})
+	}
+	return e.JSON(http.StatusOK, workflows)
+}
+
+// StripResult is the response of POST /api/workflows/{id}/strip-pin-data
+type StripResult struct {
+	WorkflowID string   `json:"workflow_id"`
+	Nodes      []string `json:"nodes"`
+}
+
+// StripPinData removes the pinned data of a workflow through the n8n API and
+// writes an audit entry. The id is the id of any workflows record of the
+// workflow.
+func StripPinData(ctx context.Context, app core.App, record *core.Record, actor string, logger *zap.Logger) (*StripResult, error) {
+	result, err := stripPinData(ctx, app, record)
+
+	entry := audit.Entry{
+		Action:   "workflow.pin_data_stripped",
+		Instance: record.GetString("instance"),
+		Actor:    actor,
+		Success:  err == nil,
+		Message:  "Pinned data removed",
+		Details:  map[string]any{"workflow_id": record.GetString("workflow_id")},
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Details["nodes"] = result.Nodes
+	}
+	if auditErr := audit.Log(app, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+
+	return result, err
+}
+
+func stripPinData(ctx context.Context, app core.App, record *core.Record) (*StripResult, error) {
+	if record.GetBool("archived") {
+		return nil, ErrArchived
+	}
+
+	instance, err := instanceForWorkflow(ctx, app, record)
+	if err != nil {
+		return nil, err
+	}
+	client := NewN8NClient(instance)
+	workflowID := record.GetString("workflow_id")
+
+	data, err := client.GetWorkflowJSON(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workflow: %w", err)
+	}
+	var workflow map[string]any
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("error decoding workflow: %w", err)
+	}
+
+	result := &StripResult{WorkflowID: workflowID, Nodes: pinnedNodes(data)}
+	if result.Nodes == nil {
+		result.Nodes = []string{}
+		return result, nil
+	}
+
+	workflow["pinData"] = map[string]any{}
+	if err := client.UpdateWorkflow(ctx, workflowID, workflow); err != nil {
+		return nil, fmt.Errorf("failed to update workflow: %w", err)
+	}
+
+	// The next sync stores the new version, until then the latest one
+	// shouldn't report the pinned data anymore
+	latest, err := app.FindRecordsByFilter("workflows",
+		"instance = {:instance} && workflow_id = {:workflow}", "-updated_at", 1, 0,
+		dbx.Params{"instance": record.GetString("instance"), "workflow": workflowID})
+	if err == nil && len(latest) > 0 {
+		findings := slices.DeleteFunc(findingsOf(latest[0]), func(f Finding) bool {
+			return f.Rule == RulePinnedData
+		})
+		if setLintFindings(latest[0], findings) {
+			if err := app.Save(latest[0]); err != nil {
+				return nil, fmt.Errorf("failed to update workflow record: %w", err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// stripPinDataHandler removes the pinned data of a workflow
+func stripPinDataHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	result, err := StripPinData(e.Request.Context(), e.App, record, audit.Actor(e.Auth), logger)
+	if err != nil {
+		if errors.Is(err, ErrArchived) {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusBadGateway, "Removing pinned data failed: "+err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
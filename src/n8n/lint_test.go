@@ -0,0 +1,39 @@
+package n8n
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintWorkflow(t *testing.T) {
+	workflow := Workflow{Raw: json.RawMessage(`{
+		"pinData": {
+			"Webhook": [{"json": {"email": "someone@example.com"}}],
+			"HTTP Request": [{"json": {"ok": true}}],
+			"Unpinned": []
+		}
+	}`)}
+
+	findings := lintWorkflow(workflow)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RulePinnedData, findings[0].Rule)
+	assert.Equal(t, SeverityInfo, findings[0].Severity, "pinned data is fine while building")
+	assert.Equal(t, []string{"HTTP Request", "Webhook"}, findings[0].Nodes)
+
+	workflow.Active = true
+	assert.Equal(t, SeverityWarning, lintWorkflow(workflow)[0].Severity)
+
+	assert.Empty(t, lintWorkflow(Workflow{Active: true, Raw: json.RawMessage(`{"pinData": {}}`)}))
+	assert.NotNil(t, lintWorkflow(Workflow{}))
+}
+
+func TestImportableWorkflowPinData(t *testing.T) {
+	pinned := map[string]any{"name": "Orders", "pinData": map[string]any{"Webhook": []any{}}}
+	assert.NotContains(t, importableWorkflow(pinned), "pinData", "pinned data isn't imported")
+
+	cleared := map[string]any{"name": "Orders", "pinData": map[string]any{}}
+	assert.Equal(t, map[string]any{}, importableWorkflow(cleared)["pinData"])
+}
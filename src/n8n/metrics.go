@@ -0,0 +1,160 @@
+package n8n
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+)
+
+// METRICS_PATH is where n8n serves Prometheus metrics when N8N_METRICS=true
+const METRICS_PATH = "/metrics"
+
+// defaultMetricsRetentionDays is used unless INSTANCE_METRICS_RETENTION_DAYS is set
+const defaultMetricsRetentionDays = 7
+
+// InstanceMetrics holds the key gauges scraped from an instance's /metrics
+type InstanceMetrics struct {
+	EventLoopLag    float64 `json:"event_loop_lag_seconds"`
+	QueueWaiting    float64 `json:"queue_waiting"`
+	QueueActive     float64 `json:"queue_active"`
+	QueueFailed     float64 `json:"queue_failed"`
+	ActiveWorkflows float64 `json:"active_workflows"`
+	HeapUsed        float64 `json:"heap_used_bytes"`
+
+	// QueueMode is set if the instance exposes queue (scaling mode) metrics
+	QueueMode bool `json:"queue_mode"`
+}
+
+// metricSuffixes maps n8n metric names, without the configurable
+// N8N_METRICS_PREFIX (default "n8n_"), to the field they are stored in
+var metricSuffixes = map[string]func(*InstanceMetrics) *float64{
+	"nodejs_eventloop_lag_seconds":    func(m *InstanceMetrics) *float64 { return &m.EventLoopLag },
+	"scaling_mode_queue_jobs_waiting": func(m *InstanceMetrics) *float64 { return &m.QueueWaiting },
+	"scaling_mode_queue_jobs_active":  func(m *InstanceMetrics) *float64 { return &m.QueueActive },
+	"scaling_mode_queue_jobs_failed":  func(m *InstanceMetrics) *float64 { return &m.QueueFailed },
+	"active_workflow_count":           func(m *InstanceMetrics) *float64 { return &m.ActiveWorkflows },
+	"nodejs_heap_size_used_bytes":     func(m *InstanceMetrics) *float64 { return &m.HeapUsed },
+}
+
+// GetMetrics scrapes the Prometheus endpoint of the n8n instance
+func (instance *Instance) GetMetrics(ctx context.Context) (*InstanceMetrics, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", instance.Host+METRICS_PATH, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	client := NewClient()
+	resp, err := client.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("metrics request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return parseMetrics(resp.Body)
+}
+
+// parseMetrics extracts the known gauges from the text exposition format
+func parseMetrics(r io.Reader) (*InstanceMetrics, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing metrics: %w", err)
+	}
+
+	metrics := &InstanceMetrics{}
+	for name, family := range families {
+		if strings.Contains(name, "scaling_mode_queue_") {
+			metrics.QueueMode = true
+		}
+		for suffix, field := range metricSuffixes {
+			if !strings.HasSuffix(name, "_"+suffix) && name != suffix {
+				continue
+			}
+			// Sum over all label combinations, e.g. per queue
+			for _, metric := range family.GetMetric() {
+				*field(metrics) += metricValue(metric)
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+func metricValue(metric *dto.Metric) float64 {
+	switch {
+	case metric.GetGauge() != nil:
+		return metric.GetGauge().GetValue()
+	case metric.GetCounter() != nil:
+		return metric.GetCounter().GetValue()
+	case metric.GetUntyped() != nil:
+		return metric.GetUntyped().GetValue()
+	}
+	return 0
+}
+
+// scrapeMetrics stores the current metrics of an instance in instance_metrics
+func scrapeMetrics(ctx context.Context, app core.App, client N8NClient, instanceID string, logger *zap.Logger) (*InstanceMetrics, error) {
+	metrics, err := client.GetMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := app.FindCollectionByNameOrId("instance_metrics")
+	if err != nil {
+		return metrics, err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("instance", instanceID)
+	record.Set("run_id", RunIDFromContext(ctx))
+	record.Set("event_loop_lag", metrics.EventLoopLag)
+	record.Set("queue_waiting", metrics.QueueWaiting)
+	record.Set("queue_active", metrics.QueueActive)
+	record.Set("queue_failed", metrics.QueueFailed)
+	record.Set("active_workflows", metrics.ActiveWorkflows)
+	record.Set("heap_used", metrics.HeapUsed)
+	if err := app.Save(record); err != nil {
+		return metrics, fmt.Errorf("failed to store instance metrics: %w", err)
+	}
+
+	// Prune samples older than the retention period
+	cutoff := time.Now().AddDate(0, 0, -metricsRetentionDays()).UTC().Format(types.DefaultDateLayout)
+	if _, err := app.DB().Delete("instance_metrics", dbx.And(
+		dbx.HashExp{"instance": instanceID},
+		dbx.NewExp("created < {:cutoff}", dbx.Params{"cutoff": cutoff}),
+	)).Execute(); err != nil {
+		logger.Warn("Failed to prune instance metrics", zap.Error(err))
+	}
+
+	logger.Debug("Scraped instance metrics",
+		zap.String("instance", instanceID),
+		zap.Float64("event_loop_lag", metrics.EventLoopLag),
+		zap.Float64("queue_waiting", metrics.QueueWaiting),
+		zap.Float64("queue_active", metrics.QueueActive))
+
+	return metrics, nil
+}
+
+func metricsRetentionDays() int {
+	if days, err := strconv.Atoi(os.Getenv("INSTANCE_METRICS_RETENTION_DAYS")); err == nil && days > 0 {
+		return days
+	}
+	return defaultMetricsRetentionDays
+}
@@ -0,0 +1,95 @@
+package n8n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const metricsExposition = `# TYPE n8n_nodejs_eventloop_lag_seconds gauge
+n8n_nodejs_eventloop_lag_seconds 0.25
+# TYPE n8n_active_workflow_count gauge
+n8n_active_workflow_count 12
+# TYPE n8n_scaling_mode_queue_jobs_waiting gauge
+n8n_scaling_mode_queue_jobs_waiting{queue="default"} 3
+n8n_scaling_mode_queue_jobs_waiting{queue="priority"} 2
+# TYPE n8n_scaling_mode_queue_jobs_failed counter
+n8n_scaling_mode_queue_jobs_failed 7
+# TYPE n8n_unrelated gauge
+n8n_unrelated 99
+`
+
+func TestParseMetrics(t *testing.T) {
+	metrics, err := parseMetrics(strings.NewReader(metricsExposition))
+	require.NoError(t, err)
+	assert.Equal(t, &InstanceMetrics{
+		EventLoopLag:    0.25,
+		ActiveWorkflows: 12,
+		QueueWaiting:    5,
+		QueueFailed:     7,
+		QueueMode:       true,
+	}, metrics)
+
+	// A custom N8N_METRICS_PREFIX, without queue metrics
+	metrics, err = parseMetrics(strings.NewReader("# TYPE prod_nodejs_heap_size_used_bytes gauge\nprod_nodejs_heap_size_used_bytes 1024\n"))
+	require.NoError(t, err)
+	assert.Equal(t, &InstanceMetrics{HeapUsed: 1024}, metrics)
+
+	_, err = parseMetrics(strings.NewReader("not metrics {"))
+	assert.Error(t, err)
+}
+
+func TestGetMetrics(t *testing.T) {
+	enabled := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != METRICS_PATH || !enabled {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(metricsExposition))
+	}))
+	defer server.Close()
+
+	instance := NewInstance("test", server.URL, "key")
+	metrics, err := instance.GetMetrics(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 12.0, metrics.ActiveWorkflows)
+
+	// N8N_METRICS isn't enabled on the instance
+	enabled = false
+	_, err = instance.GetMetrics(context.Background())
+	assert.ErrorContains(t, err, "status 404")
+}
+
+func TestScrapeMetrics(t *testing.T) {
+	app := testutil.NewApp(t)
+	t.Setenv("INSTANCE_METRICS_RETENTION_DAYS", "")
+
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	old := testutil.Create(t, app, "instance_metrics", map[string]any{"instance": instance.Id, "queue_waiting": 1})
+	_, err := app.DB().Update("instance_metrics", dbx.Params{"created": "2020-01-01 00:00:00.000Z"},
+		dbx.HashExp{"id": old.Id}).Execute()
+	require.NoError(t, err)
+
+	client := &MockClient{GetMetricsFunc: func(context.Context) (*InstanceMetrics, error) {
+		return &InstanceMetrics{QueueWaiting: 4, HeapUsed: 2048}, nil
+	}}
+	metrics, err := scrapeMetrics(context.Background(), app, client, instance.Id, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, metrics.QueueWaiting)
+
+	// The new sample is stored, the one past the retention period pruned
+	samples, err := app.FindAllRecords("instance_metrics", dbx.HashExp{"instance": instance.Id})
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, 4.0, samples[0].GetFloat("queue_waiting"))
+	assert.Equal(t, 2048.0, samples[0].GetFloat("heap_used"))
+}
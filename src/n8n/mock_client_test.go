@@ -0,0 +1,224 @@
+package n8n
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MockClient is an N8NClient for tests. Every method calls the function of
+// the same name with a Func suffix if set and returns zero values otherwise.
+// All calls are recorded and can be inspected with Calls.
+type MockClient struct {
+	IsHealthyFunc                func(ctx context.Context) bool
+	GetSettingsFunc              func(ctx context.Context) (*Settings, error)
+	GetMetricsFunc               func(ctx context.Context) (*InstanceMetrics, error)
+	GetWorkflowsFunc             func(ctx context.Context) ([]Workflow, error)
+	GetWorkflowsUpdatedAfterFunc func(ctx context.Context, since time.Time) ([]Workflow, bool, error)
+	GetWorkflowFunc              func(ctx context.Context, id string) (*Workflow, error)
+	GetWorkflowJSONFunc          func(ctx context.Context, id string) ([]byte, error)
+	CreateWorkflowFunc           func(ctx context.Context, workflow map[string]any) (*Workflow, error)
+	UpdateWorkflowFunc           func(ctx context.Context, id string, workflow map[string]any) error
+	DeleteWorkflowFunc           func(ctx context.Context, id string) error
+	ActivateWorkflowFunc         func(ctx context.Context, id string) error
+	DeactivateWorkflowFunc       func(ctx context.Context, id string) error
+	GetExecutionsFunc            func(ctx context.Context, filter ExecutionFilter) ([]Execution, bool, error)
+	StopExecutionFunc            func(ctx context.Context, id string) error
+	SourceControlPullFunc        func(ctx context.Context, force bool) (*PullResult, error)
+	GenerateAuditFunc            func(ctx context.Context, abandonedDays int) (AuditReport, error)
+	GetUsersFunc                 func(ctx context.Context) ([]User, error)
+	GetTagsFunc                  func(ctx context.Context) ([]Tag, error)
+	CreateTagFunc                func(ctx context.Context, name string) (*Tag, error)
+	SetWorkflowTagsFunc          func(ctx context.Context, id string, names []string) ([]Tag, error)
+
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+// MockCall is a recorded call of a MockClient method
+type MockCall struct {
+	Method string
+	Args   []any
+}
+
+var _ N8NClient = (*MockClient)(nil)
+
+func (m *MockClient) record(method string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, MockCall{Method: method, Args: args})
+}
+
+// Calls returns the recorded calls of method, or of all methods if empty
+func (m *MockClient) Calls(method string) []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var calls []MockCall
+	for _, call := range m.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (m *MockClient) IsHealthy(ctx context.Context) bool {
+	m.record("IsHealthy")
+	if m.IsHealthyFunc != nil {
+		return m.IsHealthyFunc(ctx)
+	}
+	return false
+}
+
+func (m *MockClient) GetSettings(ctx context.Context) (*Settings, error) {
+	m.record("GetSettings")
+	if m.GetSettingsFunc != nil {
+		return m.GetSettingsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetMetrics(ctx context.Context) (*InstanceMetrics, error) {
+	m.record("GetMetrics")
+	if m.GetMetricsFunc != nil {
+		return m.GetMetricsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetWorkflows(ctx context.Context) ([]Workflow, error) {
+	m.record("GetWorkflows")
+	if m.GetWorkflowsFunc != nil {
+		return m.GetWorkflowsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetWorkflowsUpdatedAfter(ctx context.Context, since time.Time) ([]Workflow, bool, error) {
+	m.record("GetWorkflowsUpdatedAfter", since)
+	if m.GetWorkflowsUpdatedAfterFunc != nil {
+		return m.GetWorkflowsUpdatedAfterFunc(ctx, since)
+	}
+	return nil, false, nil
+}
+
+func (m *MockClient) GetWorkflow(ctx context.Context, id string) (*Workflow, error) {
+	m.record("GetWorkflow", id)
+	if m.GetWorkflowFunc != nil {
+		return m.GetWorkflowFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetWorkflowJSON(ctx context.Context, id string) ([]byte, error) {
+	m.record("GetWorkflowJSON", id)
+	if m.GetWorkflowJSONFunc != nil {
+		return m.GetWorkflowJSONFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) CreateWorkflow(ctx context.Context, workflow map[string]any) (*Workflow, error) {
+	m.record("CreateWorkflow", workflow)
+	if m.CreateWorkflowFunc != nil {
+		return m.CreateWorkflowFunc(ctx, workflow)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) UpdateWorkflow(ctx context.Context, id string, workflow map[string]any) error {
+	m.record("UpdateWorkflow", id, workflow)
+	if m.UpdateWorkflowFunc != nil {
+		return m.UpdateWorkflowFunc(ctx, id, workflow)
+	}
+	return nil
+}
+
+func (m *MockClient) DeleteWorkflow(ctx context.Context, id string) error {
+	m.record("DeleteWorkflow", id)
+	if m.DeleteWorkflowFunc != nil {
+		return m.DeleteWorkflowFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockClient) ActivateWorkflow(ctx context.Context, id string) error {
+	m.record("ActivateWorkflow", id)
+	if m.ActivateWorkflowFunc != nil {
+		return m.ActivateWorkflowFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockClient) DeactivateWorkflow(ctx context.Context, id string) error {
+	m.record("DeactivateWorkflow", id)
+	if m.DeactivateWorkflowFunc != nil {
+		return m.DeactivateWorkflowFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockClient) GetExecutions(ctx context.Context, filter ExecutionFilter) ([]Execution, bool, error) {
+	m.record("GetExecutions", filter)
+	if m.GetExecutionsFunc != nil {
+		return m.GetExecutionsFunc(ctx, filter)
+	}
+	return nil, false, nil
+}
+
+func (m *MockClient) StopExecution(ctx context.Context, id string) error {
+	m.record("StopExecution", id)
+	if m.StopExecutionFunc != nil {
+		return m.StopExecutionFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockClient) SourceControlPull(ctx context.Context, force bool) (*PullResult, error) {
+	m.record("SourceControlPull", force)
+	if m.SourceControlPullFunc != nil {
+		return m.SourceControlPullFunc(ctx, force)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GenerateAudit(ctx context.Context, abandonedDays int) (AuditReport, error) {
+	m.record("GenerateAudit", abandonedDays)
+	if m.GenerateAuditFunc != nil {
+		return m.GenerateAuditFunc(ctx, abandonedDays)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetUsers(ctx context.Context) ([]User, error) {
+	m.record("GetUsers")
+	if m.GetUsersFunc != nil {
+		return m.GetUsersFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetTags(ctx context.Context) ([]Tag, error) {
+	m.record("GetTags")
+	if m.GetTagsFunc != nil {
+		return m.GetTagsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) CreateTag(ctx context.Context, name string) (*Tag, error) {
+	m.record("CreateTag", name)
+	if m.CreateTagFunc != nil {
+		return m.CreateTagFunc(ctx, name)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) SetWorkflowTags(ctx context.Context, id string, names []string) ([]Tag, error) {
+	m.record("SetWorkflowTags", id, names)
+	if m.SetWorkflowTagsFunc != nil {
+		return m.SetWorkflowTagsFunc(ctx, id, names)
+	}
+	return nil, nil
+}
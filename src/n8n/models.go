@@ -1,6 +1,7 @@
 package n8n
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -11,6 +12,8 @@ type Instance struct {
 	APIKey          string `json:"api_key"`
 	IgnoreSSLErrors bool   `json:"ignore_ssl_errors"`
 	CheckInterval   int    `json:"check_interval_mins"`
+	// StoreMode controls what is stored of the workflow JSON, see StoreFull
+	StoreMode string `json:"store_mode"`
 }
 
 // NewInstance creates a new n8n instance
@@ -32,8 +35,12 @@ type Workflow struct {
 	CreatedAt  time.Time `json:"createdAt"`
 	UpdatedAt  time.Time `json:"updatedAt"`
 	Nodes      []Node    `json:"nodes"`
+	// Connections maps a source node name to its outputs by connection type
+	Connections map[string]map[string][][]Connection `json:"connections"`
 	// Reference to parent instance - not serialized to JSON
 	InstanceID string `json:"-"`
+	// Raw is the complete workflow JSON as returned by the API
+	Raw json.RawMessage `json:"-"`
 }
 
 // Node represents a node in an n8n workflow
@@ -45,6 +52,7 @@ type Node struct {
 	Credentials map[string]NodeCredential `json:"credentials"`
 	WebhookID   string                    `json:"webhookId"`
 	Notes       string                    `json:"notes"`
+	Disabled    bool                      `json:"disabled"`
 }
 
 // NodeParameters contains the configuration for a node
@@ -53,6 +61,19 @@ type NodeParameters struct {
 	Path           string                 `json:"path"`
 	Authentication string                 `json:"authentication"`
 	Options        map[string]interface{} `json:"options"`
+	// Webhook response settings
+	ResponseMode string `json:"responseMode"`
+	ResponseData string `json:"responseData"`
+	ResponseCode any    `json:"responseCode"`
+	// Respond to Webhook node settings
+	RespondWith string `json:"respondWith"`
+}
+
+// Connection is the target of a node output
+type Connection struct {
+	Node  string `json:"node"`
+	Type  string `json:"type"`
+	Index int    `json:"index"`
 }
 
 // NodeCredential represents authentication credentials for a node
@@ -82,6 +103,9 @@ type Webhook struct {
 	AuthType    string              `json:"authentication"`
 	Credentials *WebhookCredentials `json:"credentials,omitempty"`
 
+	// Response is the expected response behavior
+	Response WebhookResponse `json:"response"`
+
 	// Essential references - these are the minimum needed
 	WorkflowID string `json:"workflow_id"`
 	InstanceID string `json:"instance_id"`
@@ -104,6 +128,10 @@ type InstanceStats struct {
 	InactiveWebhooks  int `json:"inactive_webhooks"`
 	RedisTriggers     int `json:"redis"`
 	ScheduledTriggers int `json:"scheduled"`
+	// StorageBytes is the stored size of all workflow versions
+	StorageBytes int64 `json:"storage_bytes"`
+	// Disk is the storage usage of the instance, nil without a disk probe
+	Disk *DiskUsage `json:"disk,omitempty"`
 }
 
 // API response types
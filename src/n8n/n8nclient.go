@@ -0,0 +1,43 @@
+package n8n
+
+import (
+	"context"
+	"time"
+)
+
+// N8NClient is the n8n API as used by the sync and the workflow actions.
+// *Instance implements it against a live instance, tests use MockClient.
+type N8NClient interface {
+	IsHealthy(ctx context.Context) bool
+	GetSettings(ctx context.Context) (*Settings, error)
+	GetMetrics(ctx context.Context) (*InstanceMetrics, error)
+
+	GetWorkflows(ctx context.Context) ([]Workflow, error)
+	GetWorkflowsUpdatedAfter(ctx context.Context, since time.Time) ([]Workflow, bool, error)
+	GetWorkflow(ctx context.Context, id string) (*Workflow, error)
+	GetWorkflowJSON(ctx context.Context, id string) ([]byte, error)
+	CreateWorkflow(ctx context.Context, workflow map[string]any) (*Workflow, error)
+	UpdateWorkflow(ctx context.Context, id string, workflow map[string]any) error
+	DeleteWorkflow(ctx context.Context, id string) error
+	ActivateWorkflow(ctx context.Context, id string) error
+	DeactivateWorkflow(ctx context.Context, id string) error
+
+	GetExecutions(ctx context.Context, filter ExecutionFilter) ([]Execution, bool, error)
+	StopExecution(ctx context.Context, id string) error
+
+	SourceControlPull(ctx context.Context, force bool) (*PullResult, error)
+	GenerateAudit(ctx context.Context, abandonedDays int) (AuditReport, error)
+	GetUsers(ctx context.Context) ([]User, error)
+
+	GetTags(ctx context.Context) ([]Tag, error)
+	CreateTag(ctx context.Context, name string) (*Tag, error)
+	SetWorkflowTags(ctx context.Context, id string, names []string) ([]Tag, error)
+}
+
+var _ N8NClient = (*Instance)(nil)
+
+// NewN8NClient returns the client used to talk to an instance. Tests replace
+// it to run the sync and the API handlers without a live n8n.
+var NewN8NClient = func(instance *Instance) N8NClient {
+	return instance
+}
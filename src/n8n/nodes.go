@@ -0,0 +1,62 @@
+package n8n
+
+import (
+	"sort"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// CredentialRef is a credential used by a node
+type CredentialRef struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// syncNodes stores the nodes of a workflow version in the workflow_nodes
+// collection, so they can be queried without parsing the workflow JSON
+func syncNodes(app core.App, instance *Instance, record *core.Record, workflow Workflow) error {
+	collection, err := app.FindCollectionByNameOrId("workflow_nodes")
+	if err != nil {
+		return err
+	}
+
+	return app.RunInTransaction(func(txApp core.App) error {
+		for _, node := range workflow.Nodes {
+			nodeRecord := core.NewRecord(collection)
+			nodeRecord.Set("workflow", record.Id)
+			nodeRecord.Set("instance", instance.Id)
+			nodeRecord.Set("workflow_id", workflow.WorkflowID)
+			nodeRecord.Set("node_id", node.ID)
+			nodeRecord.Set("name", node.Name)
+			nodeRecord.Set("type", node.Type)
+			nodeRecord.Set("disabled", node.Disabled)
+			nodeRecord.Set("credentials", credentialRefs(node))
+			if err := txApp.Save(nodeRecord); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillNodes stores the nodes of a workflow version synced before nodes
+// were stored, versions with nodes are left as they are
+func backfillNodes(app core.App, instance *Instance, record *core.Record, workflow Workflow) error {
+	count, err := app.CountRecords("workflow_nodes", dbx.HashExp{"workflow": record.Id})
+	if err != nil || count > 0 {
+		return err
+	}
+	return syncNodes(app, instance, record, workflow)
+}
+
+// credentialRefs returns the credentials of a node sorted by type
+func credentialRefs(node Node) []CredentialRef {
+	refs := make([]CredentialRef, 0, len(node.Credentials))
+	for credentialType, credential := range node.Credentials {
+		refs = append(refs, CredentialRef{Type: credentialType, ID: credential.ID, Name: credential.Name})
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Type < refs[j].Type })
+	return refs
+}
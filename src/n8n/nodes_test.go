@@ -0,0 +1,69 @@
+package n8n
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCredentialRefs(t *testing.T) {
+	node := Node{Credentials: map[string]NodeCredential{
+		"slackApi":      {ID: "2", Name: "Slack"},
+		"httpBasicAuth": {ID: "1", Name: "Basic"},
+	}}
+	assert.Equal(t, []CredentialRef{
+		{Type: "httpBasicAuth", ID: "1", Name: "Basic"},
+		{Type: "slackApi", ID: "2", Name: "Slack"},
+	}, credentialRefs(node))
+}
+
+func TestSyncWorkflowsBackfillsNodes(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	record := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	instance := NewInstance(record.Id, "n8n.example.com", "")
+	updatedAt := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	workflow := Workflow{
+		Name:       "Orders",
+		WorkflowID: "wf1",
+		CreatedAt:  updatedAt,
+		UpdatedAt:  updatedAt,
+		Nodes: []Node{
+			{ID: "n1", Name: "Webhook", Type: "n8n-nodes-base.webhook"},
+			{ID: "n2", Name: "Slack", Type: "n8n-nodes-base.slack", Disabled: true},
+		},
+	}
+
+	// A version synced before nodes were stored
+	version := testutil.Create(t, app, "workflows", map[string]any{
+		"instance":    record.Id,
+		"workflow_id": "wf1",
+		"created_at":  updatedAt.Format(time.RFC3339),
+		"updated_at":  updatedAt.Format(time.RFC3339),
+	})
+
+	for range 2 {
+		require.NoError(t, syncWorkflows(context.Background(), app, instance, []Workflow{workflow}, zap.NewNop()))
+
+		nodes, err := app.FindAllRecords("workflow_nodes", dbx.HashExp{"workflow": version.Id})
+		require.NoError(t, err)
+		require.Len(t, nodes, 2, "the unchanged version gets its nodes once")
+	}
+
+	disabled, err := app.FindFirstRecordByData("workflow_nodes", "node_id", "n2")
+	require.NoError(t, err)
+	assert.Equal(t, "Slack", disabled.GetString("name"))
+	assert.Equal(t, "n8n-nodes-base.slack", disabled.GetString("type"))
+	assert.True(t, disabled.GetBool("disabled"))
+	assert.Equal(t, "wf1", disabled.GetString("workflow_id"))
+
+	versions, err := app.CountRecords("workflows", dbx.HashExp{"workflow_id": "wf1"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, versions)
+}
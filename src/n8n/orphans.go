@@ -0,0 +1,136 @@
+package n8n
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Credential is a credential defined on an n8n instance
+type Credential struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// listCredentials returns all credentials of the instance, without their data
+func (s *restSession) listCredentials(ctx context.Context) ([]Credential, error) {
+	var credentials []Credential
+	if err := s.do(ctx, http.MethodGet, "credentials", nil, &credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// usedCredentials returns the credentials referenced by the nodes of the
+// latest version of every workflow of an instance that isn't archived
+func usedCredentials(app core.App, instanceID string) ([]CredentialRef, error) {
+	records, err := latestWorkflows(app, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	latest := map[string]bool{}
+	for _, record := range records {
+		latest[record.Id] = true
+	}
+
+	nodes, err := app.FindAllRecords("workflow_nodes", dbx.HashExp{"instance": instanceID})
+	if err != nil {
+		return nil, err
+	}
+	var refs []CredentialRef
+	for _, node := range nodes {
+		if !latest[node.GetString("workflow")] {
+			continue
+		}
+		var nodeRefs []CredentialRef
+		node.UnmarshalJSONField("credentials", &nodeRefs)
+		refs = append(refs, nodeRefs...)
+	}
+	return refs, nil
+}
+
+// orphanedCredentials returns the credentials no reference points to, sorted
+// by type and name. References of older versions without id match by type
+// and name.
+func orphanedCredentials(credentials []Credential, refs []CredentialRef) []Credential {
+	ids := map[string]bool{}
+	names := map[string]bool{}
+	for _, ref := range refs {
+		if ref.ID != "" {
+			ids[ref.ID] = true
+		} else {
+			names[ref.Type+":"+ref.Name] = true
+		}
+	}
+
+	orphaned := []Credential{}
+	for _, credential := range credentials {
+		if !ids[credential.ID] && !names[credential.Type+":"+credential.Name] {
+			orphaned = append(orphaned, credential)
+		}
+	}
+	slices.SortFunc(orphaned, func(a, b Credential) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.Name, b.Name))
+	})
+	return orphaned
+}
+
+// orphanedCredentialsHandler lists the credentials of an instance that no
+// workflow uses, as JSON or with format=csv as CSV
+func orphanedCredentialsHandler(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	session, err := ownerSession(e.Request.Context(), record)
+	if errors.Is(err, ErrNoOwnerCredentials) {
+		return apis.NewBadRequestError(err.Error(), nil)
+	}
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, err.Error(), nil)
+	}
+	credentials, err := session.listCredentials(e.Request.Context())
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Failed to list credentials: "+err.Error(), nil)
+	}
+
+	refs, err := usedCredentials(e.App, record.Id)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to load workflow nodes", err)
+	}
+	orphaned := orphanedCredentials(credentials, refs)
+
+	if e.Request.URL.Query().Get("format") != "csv" {
+		return e.JSON(http.StatusOK, orphaned)
+	}
+
+	e.Response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	e.Response.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="orphaned-credentials-%s.csv"`, record.Id))
+	w := csv.NewWriter(e.Response)
+	w.Write([]string{"id", "name", "type", "created_at", "updated_at"})
+	for _, credential := range orphaned {
+		w.Write([]string{
+			credential.ID,
+			credential.Name,
+			credential.Type,
+			credential.CreatedAt.Format(time.RFC3339),
+			credential.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,28 @@
+package n8n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrphanedCredentials(t *testing.T) {
+	credentials := []Credential{
+		{ID: "1", Name: "Orders API", Type: "httpHeaderAuth"},
+		{ID: "2", Name: "Old SMTP", Type: "smtp"},
+		{ID: "3", Name: "Slack", Type: "slackApi"},
+		{ID: "4", Name: "Legacy", Type: "httpBasicAuth"},
+		{ID: "5", Name: "Orders API", Type: "httpBasicAuth"},
+	}
+	refs := []CredentialRef{
+		{ID: "1", Name: "Orders API (renamed)", Type: "httpHeaderAuth"},
+		{ID: "3", Name: "Slack", Type: "slackApi"},
+		// Versions stored before n8n returned credential ids
+		{Name: "Legacy", Type: "httpBasicAuth"},
+	}
+
+	assert.Equal(t, []Credential{
+		{ID: "5", Name: "Orders API", Type: "httpBasicAuth"},
+		{ID: "2", Name: "Old SMTP", Type: "smtp"},
+	}, orphanedCredentials(credentials, refs))
+}
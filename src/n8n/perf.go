@@ -0,0 +1,235 @@
+package n8n
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// PerfCollection stores the execution durations per workflow and day
+const PerfCollection = "workflow_perf"
+
+const (
+	// perfHistoryDays is how many days of performance are kept
+	perfHistoryDays = 90
+
+	// maxPerfExecutions caps the executions listed per sync. Days are
+	// recomputed from all their executions, a busy instance would
+	// otherwise list too many.
+	maxPerfExecutions = 20000
+)
+
+// WorkflowPerf is the execution performance of a workflow on a UTC day
+type WorkflowPerf struct {
+	WorkflowID string `json:"-"`
+	Day        string `json:"day"`
+	Executions int    `json:"executions"`
+	Errors     int    `json:"errors"`
+	P50Ms      int64  `json:"p50_ms"`
+	P95Ms      int64  `json:"p95_ms"`
+	P99Ms      int64  `json:"p99_ms"`
+	MaxMs      int64  `json:"max_ms"`
+}
+
+// percentile returns the nearest-rank percentile p (0-100) of the sorted
+// durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// executionPerf computes the performance of the stopped executions per
+// workflow and day they started on
+func executionPerf(executions []Execution) []WorkflowPerf {
+	type key struct{ workflow, day string }
+	durations := map[key][]time.Duration{}
+	errors := map[key]int{}
+	for _, execution := range executions {
+		duration, ok := execution.Duration()
+		if !ok {
+			continue
+		}
+		k := key{execution.WorkflowID, execution.StartedAt.UTC().Format(time.DateOnly)}
+		durations[k] = append(durations[k], duration)
+		if execution.Status == ExecutionError || execution.Status == ExecutionCrashed {
+			errors[k]++
+		}
+	}
+
+	perf := make([]WorkflowPerf, 0, len(durations))
+	for k, values := range durations {
+		slices.Sort(values)
+		perf = append(perf, WorkflowPerf{
+			WorkflowID: k.workflow,
+			Day:        k.day,
+			Executions: len(values),
+			Errors:     errors[k],
+			P50Ms:      percentile(values, 50).Milliseconds(),
+			P95Ms:      percentile(values, 95).Milliseconds(),
+			P99Ms:      percentile(values, 99).Milliseconds(),
+			MaxMs:      values[len(values)-1].Milliseconds(),
+		})
+	}
+	slices.SortFunc(perf, func(a, b WorkflowPerf) int {
+		return cmp.Or(cmp.Compare(a.WorkflowID, b.WorkflowID), cmp.Compare(a.Day, b.Day))
+	})
+	return perf
+}
+
+// executionsSince returns the start of the first day whose executions are
+// fetched: the day of the last execution sync, or yesterday on the first
+func executionsSince(syncedAt, now time.Time) time.Time {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if syncedAt.IsZero() {
+		return today.AddDate(0, 0, -1)
+	}
+	syncedAt = syncedAt.UTC()
+	since := time.Date(syncedAt.Year(), syncedAt.Month(), syncedAt.Day(), 0, 0, 0, 0, time.UTC)
+	if oldest := today.AddDate(0, 0, -perfHistoryDays); since.Before(oldest) {
+		return oldest
+	}
+	return since
+}
+
+// syncExecutions fetches the executions started since the day of the last
+// execution sync and recomputes the performance of these days, then flags
+// the workflows with stuck executions
+func syncExecutions(ctx context.Context, app core.App, client N8NClient, record *core.Record, logger *zap.Logger) error {
+	now := time.Now()
+	since := executionsSince(record.GetDateTime("executions_synced_at").Time(), now)
+	executions, truncated, err := client.GetExecutions(ctx, ExecutionFilter{StartedAfter: since, Limit: maxPerfExecutions})
+	if err != nil {
+		return fmt.Errorf("failed to get executions: %w", err)
+	}
+
+	perf := executionPerf(executions)
+	if truncated && len(executions) > 0 {
+		// The oldest day was cut off, its performance would be wrong
+		partial := executions[len(executions)-1].StartedAt.UTC().Format(time.DateOnly)
+		perf = slices.DeleteFunc(perf, func(p WorkflowPerf) bool { return p.Day <= partial })
+		logger.Warn("Too many executions to compute the performance of all days",
+			zap.String("instance", record.Id),
+			zap.String("skipped_day", partial))
+	}
+
+	if err := savePerf(app, record.Id, perf); err != nil {
+		return err
+	}
+	if err := purgePerf(app, record.Id, now.AddDate(0, 0, -perfHistoryDays)); err != nil {
+		return err
+	}
+	return detectStuckExecutions(ctx, app, client, record, logger)
+}
+
+// savePerf creates or updates the workflow_perf records of an instance
+func savePerf(app core.App, instanceId string, perf []WorkflowPerf) error {
+	collection, err := app.FindCollectionByNameOrId(PerfCollection)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range perf {
+		record, err := app.FindFirstRecordByFilter(PerfCollection,
+			"instance = {:instance} && workflow_id = {:workflow} && day = {:day}",
+			dbx.Params{"instance": instanceId, "workflow": p.WorkflowID, "day": p.Day})
+		if err != nil {
+			record = core.NewRecord(collection)
+			record.Set("instance", instanceId)
+			record.Set("workflow_id", p.WorkflowID)
+			record.Set("day", p.Day)
+		}
+		record.Set("executions", p.Executions)
+		record.Set("errors", p.Errors)
+		record.Set("p50_ms", p.P50Ms)
+		record.Set("p95_ms", p.P95Ms)
+		record.Set("p99_ms", p.P99Ms)
+		record.Set("max_ms", p.MaxMs)
+		if err := app.Save(record); err != nil {
+			return fmt.Errorf("failed to save workflow performance: %w", err)
+		}
+	}
+	return nil
+}
+
+// purgePerf deletes the performance of an instance from days before
+func purgePerf(app core.App, instanceId string, before time.Time) error {
+	records, err := app.FindAllRecords(PerfCollection, dbx.NewExp("instance = {:instance} AND day < {:day}",
+		dbx.Params{"instance": instanceId, "day": before.UTC().Format(time.DateOnly)}))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := app.Delete(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PerfTrend is the response of GET /api/workflows/{id}/perf
+type PerfTrend struct {
+	Instance     string         `json:"instance"`
+	WorkflowID   string         `json:"workflow_id"`
+	WorkflowName string         `json:"workflow_name"`
+	Days         []WorkflowPerf `json:"days"`
+}
+
+// perfHandler returns the daily execution performance of the workflow of a
+// workflows record over the last ?days= (30 by default), oldest first
+func perfHandler(e *core.RequestEvent) error {
+	workflow, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	days := 30
+	if value := e.Request.URL.Query().Get("days"); value != "" {
+		if days, err = strconv.Atoi(value); err != nil || days < 1 || days > perfHistoryDays {
+			return apis.NewBadRequestError(fmt.Sprintf("days must be between 1 and %d", perfHistoryDays), nil)
+		}
+	}
+
+	trend := PerfTrend{
+		Instance:     workflow.GetString("instance"),
+		WorkflowID:   workflow.GetString("workflow_id"),
+		WorkflowName: workflow.GetString("workflow_name"),
+		Days:         []WorkflowPerf{},
+	}
+	records, err := e.App.FindRecordsByFilter(PerfCollection,
+		"instance = {:instance} && workflow_id = {:workflow} && day >= {:since}", "day", 0, 0,
+		dbx.Params{
+			"instance": trend.Instance,
+			"workflow": trend.WorkflowID,
+			"since":    time.Now().UTC().AddDate(0, 0, 1-days).Format(time.DateOnly),
+		})
+	if err != nil {
+		return apis.NewInternalServerError("Failed to fetch workflow performance", err)
+	}
+	for _, record := range records {
+		trend.Days = append(trend.Days, WorkflowPerf{
+			WorkflowID: trend.WorkflowID,
+			Day:        record.GetString("day"),
+			Executions: record.GetInt("executions"),
+			Errors:     record.GetInt("errors"),
+			P50Ms:      int64(record.GetInt("p50_ms")),
+			P95Ms:      int64(record.GetInt("p95_ms")),
+			P99Ms:      int64(record.GetInt("p99_ms")),
+			MaxMs:      int64(record.GetInt("max_ms")),
+		})
+	}
+	return e.JSON(http.StatusOK, trend)
+}
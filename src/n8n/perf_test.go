@@ -0,0 +1,103 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execution(workflow, status string, started time.Time, duration time.Duration) Execution {
+	stopped := started.Add(duration)
+	return Execution{WorkflowID: workflow, Status: status, StartedAt: started, StoppedAt: &stopped}
+}
+
+func TestExecutionPerf(t *testing.T) {
+	day := time.Date(2025, 5, 2, 10, 0, 0, 0, time.UTC)
+	var executions []Execution
+	for i := 1; i <= 100; i++ {
+		status := ExecutionSuccess
+		if i%10 == 0 {
+			status = ExecutionError
+		}
+		executions = append(executions, execution("orders", status, day, time.Duration(i)*time.Second))
+	}
+	executions = append(executions,
+		execution("orders", ExecutionSuccess, day.AddDate(0, 0, 1), time.Second),
+		Execution{WorkflowID: "orders", Status: ExecutionRunning, StartedAt: day},
+		execution("invoices", ExecutionWaiting, day, time.Hour),
+	)
+
+	perf := executionPerf(executions)
+	require.Len(t, perf, 2, "running and waiting executions are skipped")
+	assert.Equal(t, WorkflowPerf{
+		WorkflowID: "orders",
+		Day:        "2025-05-02",
+		Executions: 100,
+		Errors:     10,
+		P50Ms:      50000,
+		P95Ms:      95000,
+		P99Ms:      99000,
+		MaxMs:      100000,
+	}, perf[0])
+	assert.Equal(t, "2025-05-03", perf[1].Day)
+	assert.Equal(t, int64(1000), perf[1].P99Ms)
+}
+
+func TestExecutionsSince(t *testing.T) {
+	now := time.Date(2025, 5, 2, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), executionsSince(time.Time{}, now))
+	assert.Equal(t, time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC), executionsSince(now.AddDate(0, 0, -2), now))
+	assert.Equal(t, now.Truncate(24*time.Hour).AddDate(0, 0, -perfHistoryDays), executionsSince(now.AddDate(-1, 0, 0), now))
+}
+
+func TestGetExecutions(t *testing.T) {
+	started := time.Date(2025, 5, 2, 10, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "false", r.URL.Query().Get("includeData"))
+		// Executions 6 to 1, newest first, two per page
+		page := 0
+		fmt.Sscan(r.URL.Query().Get("cursor"), &page)
+		var data []map[string]any
+		for id := 6 - 2*page; id > 4-2*page && id > 0; id-- {
+			data = append(data, map[string]any{
+				"id":         id,
+				"workflowId": 1000,
+				"status":     ExecutionSuccess,
+				"startedAt":  started.Add(time.Duration(id) * time.Minute),
+				"stoppedAt":  started.Add(time.Duration(id)*time.Minute + time.Second),
+			})
+		}
+		response := map[string]any{"data": data}
+		if page < 2 {
+			response["nextCursor"] = fmt.Sprint(page + 1)
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	instance := NewInstance("test", server.URL, "key")
+
+	executions, truncated, err := instance.GetExecutions(context.Background(), ExecutionFilter{})
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	require.Len(t, executions, 6)
+	assert.Equal(t, "6", executions[0].ID, "numeric ids are read as strings")
+	assert.Equal(t, "1000", executions[0].WorkflowID)
+
+	executions, truncated, err = instance.GetExecutions(context.Background(), ExecutionFilter{StartedAfter: started.Add(3 * time.Minute)})
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, executions, 4)
+
+	executions, truncated, err = instance.GetExecutions(context.Background(), ExecutionFilter{Limit: 3})
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, executions, 3)
+}
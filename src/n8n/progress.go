@@ -0,0 +1,138 @@
+package n8n
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/realtime"
+)
+
+// Sync phases reported on realtime.TopicSync
+const (
+	PhaseIdle     = "idle"
+	PhaseStarted  = "started"
+	PhaseFetching = "fetching"
+	PhaseSyncing  = "syncing"
+	PhaseFinished = "finished"
+	PhaseFailed   = "failed"
+)
+
+// SyncProgress is published on realtime.TopicSync while an instance syncs
+type SyncProgress struct {
+	Instance string `json:"instance"`
+	RunID    string `json:"run_id"`
+	Phase    string `json:"phase"`
+	Done     int    `json:"done"`
+	Total    int    `json:"total"`
+	Percent  int    `json:"percent"`
+	Error    string `json:"error,omitempty"`
+	// Running is false once the sync finished or failed
+	Running   bool      `json:"running"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// progressStore keeps the latest progress of every instance in memory
+var progressStore = struct {
+	sync.RWMutex
+	byInstance map[string]SyncProgress
+}{byInstance: map[string]SyncProgress{}}
+
+// CurrentProgress returns the progress of the running or last sync of an instance
+func CurrentProgress(instanceID string) (SyncProgress, bool) {
+	progressStore.RLock()
+	defer progressStore.RUnlock()
+
+	progress, ok := progressStore.byInstance[instanceID]
+	return progress, ok
+}
+
+// InstanceTransition is published on realtime.TopicInstances when the
+// availability or health of an instance changes
+type InstanceTransition struct {
+	Instance         string `json:"instance"`
+	Host             string `json:"host"`
+	Available        bool   `json:"available"`
+	WasAvailable     bool   `json:"was_available"`
+	Health           string `json:"health"`
+	PreviousHealth   string `json:"previous_health"`
+	AvailabilityNote string `json:"availability_note,omitempty"`
+}
+
+// publishProgress records the sync progress of an instance and publishes it
+func publishProgress(ctx context.Context, app core.App, instanceID, phase string, done, total int, syncErr error) {
+	now := time.Now()
+	progress := SyncProgress{
+		Instance:  instanceID,
+		RunID:     RunIDFromContext(ctx),
+		Phase:     phase,
+		Done:      done,
+		Total:     total,
+		Running:   phase != PhaseFinished && phase != PhaseFailed,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	switch {
+	case phase == PhaseFinished || phase == PhaseFailed:
+		progress.Percent = 100
+	case total > 0:
+		progress.Percent = done * 100 / total
+	}
+	if syncErr != nil {
+		progress.Error = syncErr.Error()
+	}
+
+	realtime.Publish(app, realtime.TopicSync, storeProgress(progress))
+}
+
+// storeProgress keeps progress as the current progress of its instance,
+// updates of the same run keep the start time of the run
+func storeProgress(progress SyncProgress) SyncProgress {
+	progressStore.Lock()
+	defer progressStore.Unlock()
+
+	if previous, ok := progressStore.byInstance[progress.Instance]; ok && previous.RunID == progress.RunID {
+		progress.StartedAt = previous.StartedAt
+	}
+	progressStore.byInstance[progress.Instance] = progress
+	return progress
+}
+
+// initRealtimeEvents publishes instance state transitions
+func initRealtimeEvents(app core.App) {
+	app.OnRecordAfterUpdateSuccess("instances").BindFunc(func(e *core.RecordEvent) error {
+		original := e.Record.Original()
+		if original.GetBool("availability_status") != e.Record.GetBool("availability_status") ||
+			original.GetString("health") != e.Record.GetString("health") {
+			realtime.Publish(e.App, realtime.TopicInstances, InstanceTransition{
+				Instance:         e.Record.Id,
+				Host:             e.Record.GetString("host"),
+				Available:        e.Record.GetBool("availability_status"),
+				WasAvailable:     original.GetBool("availability_status"),
+				Health:           e.Record.GetString("health"),
+				PreviousHealth:   original.GetString("health"),
+				AvailabilityNote: e.Record.GetString("availability_note"),
+			})
+		}
+		return e.Next()
+	})
+}
+
+// syncStatusHandler returns the progress of the running or last sync of an instance
+func syncStatusHandler(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	progress, ok := CurrentProgress(record.Id)
+	if !ok {
+		progress = SyncProgress{Instance: record.Id, Phase: PhaseIdle}
+	}
+
+	return e.JSON(http.StatusOK, progress)
+}
@@ -0,0 +1,132 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"github.com/sistemica/n8n-manager-backend/realtime"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreProgress(t *testing.T) {
+	_, ok := CurrentProgress("progress-test")
+	assert.False(t, ok)
+
+	started := time.Now().Add(-time.Minute)
+	storeProgress(SyncProgress{Instance: "progress-test", RunID: "run1", Phase: PhaseStarted, Running: true, StartedAt: started})
+	storeProgress(SyncProgress{Instance: "progress-test", RunID: "run1", Phase: PhaseSyncing, Done: 5, Total: 10, Running: true, StartedAt: time.Now()})
+
+	progress, ok := CurrentProgress("progress-test")
+	require.True(t, ok)
+	assert.Equal(t, PhaseSyncing, progress.Phase)
+	assert.Equal(t, 5, progress.Done)
+	assert.True(t, progress.StartedAt.Equal(started), "start time of the run is kept")
+
+	// A new run starts over
+	now := time.Now()
+	storeProgress(SyncProgress{Instance: "progress-test", RunID: "run2", Phase: PhaseStarted, StartedAt: now})
+	progress, _ = CurrentProgress("progress-test")
+	assert.True(t, progress.StartedAt.Equal(now))
+}
+
+func TestPublishProgress(t *testing.T) {
+	app := testutil.NewApp(t)
+	ctx := WithRunID(context.Background(), "run1")
+
+	publishProgress(ctx, app, "publish-test", PhaseSyncing, 3, 12, nil)
+	progress, ok := CurrentProgress("publish-test")
+	require.True(t, ok)
+	assert.Equal(t, "run1", progress.RunID)
+	assert.Equal(t, 25, progress.Percent)
+	assert.True(t, progress.Running)
+
+	publishProgress(ctx, app, "publish-test", PhaseFailed, 3, 12, errors.New("timeout"))
+	progress, _ = CurrentProgress("publish-test")
+	assert.Equal(t, 100, progress.Percent)
+	assert.False(t, progress.Running)
+	assert.Equal(t, "timeout", progress.Error)
+}
+
+func TestInstanceTransitions(t *testing.T) {
+	app := testutil.NewApp(t)
+	initRealtimeEvents(app)
+
+	superusers, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	require.NoError(t, err)
+	client := subscriptions.NewDefaultClient()
+	client.Subscribe(realtime.TopicInstances)
+	client.Set(apis.RealtimeClientAuthKey, core.NewRecord(superusers))
+	app.SubscriptionsBroker().Register(client)
+
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com", "availability_status": true, "health": "healthy"})
+	update := func(values map[string]any) {
+		t.Helper()
+		record, err := app.FindRecordById("instances", instance.Id)
+		require.NoError(t, err)
+		for key, value := range values {
+			record.Set(key, value)
+		}
+		require.NoError(t, app.SaveNoValidate(record))
+	}
+	receive := func() (InstanceTransition, bool) {
+		select {
+		case message := <-client.Channel():
+			var transition InstanceTransition
+			require.NoError(t, json.Unmarshal(message.Data, &transition))
+			return transition, true
+		case <-time.After(100 * time.Millisecond):
+			return InstanceTransition{}, false
+		}
+	}
+
+	// Syncs without a state change publish nothing
+	update(map[string]any{"workflows_active": 3})
+	_, ok := receive()
+	assert.False(t, ok)
+
+	update(map[string]any{"availability_status": false, "health": "down", "availability_note": "connection refused"})
+	transition, ok := receive()
+	require.True(t, ok)
+	assert.Equal(t, InstanceTransition{
+		Instance:         instance.Id,
+		Host:             "n8n.example.com",
+		WasAvailable:     true,
+		Health:           "down",
+		PreviousHealth:   "healthy",
+		AvailabilityNote: "connection refused",
+	}, transition)
+}
+
+func TestSyncStatusHandler(t *testing.T) {
+	app := testutil.NewApp(t)
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+
+	status := func() SyncProgress {
+		e := &core.RequestEvent{}
+		e.App = app
+		e.Request = httptest.NewRequest(http.MethodGet, "/api/instances/"+instance.Id+"/sync/status", nil)
+		e.Request.SetPathValue("id", instance.Id)
+		recorder := httptest.NewRecorder()
+		e.Response = recorder
+		require.NoError(t, syncStatusHandler(e))
+
+		var progress SyncProgress
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &progress))
+		return progress
+	}
+
+	assert.Equal(t, PhaseIdle, status().Phase)
+
+	storeProgress(SyncProgress{Instance: instance.Id, RunID: "run1", Phase: PhaseFetching, Running: true})
+	assert.Equal(t, PhaseFetching, status().Phase)
+}
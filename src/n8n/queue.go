@@ -0,0 +1,156 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// Health is the tri-state health of an instance
+type Health string
+
+const (
+	HealthHealthy Health = "healthy"
+	// HealthDegraded means the main process is reachable but executions
+	// can't run, e.g. a queue mode instance without workers
+	HealthDegraded Health = "degraded"
+	HealthDown     Health = "down"
+)
+
+// ExecutionModeQueue is the execution mode of n8n instances running workers
+const ExecutionModeQueue = "queue"
+
+// Settings is the subset of the public n8n frontend settings we use
+type Settings struct {
+	ExecutionMode string `json:"executionMode"`
+	Timezone      string `json:"timezone"`
+	VersionCli    string `json:"versionCli"`
+	// "all" or "none"
+	SaveDataErrorExecution   string `json:"saveDataErrorExecution"`
+	SaveDataSuccessExecution string `json:"saveDataSuccessExecution"`
+	SaveManualExecutions     bool   `json:"saveManualExecutions"`
+	SaveExecutionProgress    bool   `json:"saveExecutionProgress"`
+	// Seconds, -1 when disabled
+	ExecutionTimeout    int `json:"executionTimeout"`
+	MaxExecutionTimeout int `json:"maxExecutionTimeout"`
+	// Execution data pruning, missing in older versions
+	Pruning *struct {
+		IsEnabled bool `json:"isEnabled"`
+		// Hours
+		MaxAge   int `json:"maxAge"`
+		MaxCount int `json:"maxCount"`
+	} `json:"pruning"`
+	// Enterprise features by name, true or an object with limits when licensed
+	Enterprise map[string]any `json:"enterprise"`
+	// License of the instance, missing in older versions
+	License *struct {
+		PlanName    string `json:"planName"`
+		Environment string `json:"environment"`
+	} `json:"license"`
+}
+
+// QueueStatus describes the queue mode state of an instance
+type QueueStatus struct {
+	ExecutionMode string
+	WorkersTotal  int
+	WorkersUp     int
+	Health        Health
+	Note          string
+}
+
+// GetSettings fetches the public frontend settings of the instance
+func (instance *Instance) GetSettings(ctx context.Context) (*Settings, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", instance.Host+REST_PATH+"settings", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	client := NewClient()
+	resp, err := client.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var envelope struct {
+		Data Settings `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	return &envelope.Data, nil
+}
+
+// countWorkers checks the health endpoints of the workers (enabled on n8n
+// workers with QUEUE_HEALTH_CHECK_ACTIVE=true) and returns how many are up
+func countWorkers(ctx context.Context, urls []string) int {
+	client := NewClient()
+	up := 0
+	for _, url := range urls {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			continue
+		}
+		resp, err := client.do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			up++
+		}
+	}
+	return up
+}
+
+// checkQueueMode detects whether an instance runs in queue mode and whether
+// its workers are able to pick up executions. settings and metrics may be
+// nil.
+func checkQueueMode(ctx context.Context, record *core.Record, settings *Settings, metrics *InstanceMetrics, logger *zap.Logger) QueueStatus {
+	status := QueueStatus{Health: HealthHealthy}
+
+	if settings != nil {
+		status.ExecutionMode = settings.ExecutionMode
+	}
+	if status.ExecutionMode == "" && metrics != nil && metrics.QueueMode {
+		status.ExecutionMode = ExecutionModeQueue
+	}
+
+	if status.ExecutionMode != ExecutionModeQueue {
+		return status
+	}
+
+	var workerURLs []string
+	if err := record.UnmarshalJSONField("worker_health_urls", &workerURLs); err != nil {
+		logger.Warn("Invalid worker_health_urls",
+			zap.Error(err),
+			zap.String("instance", record.Id))
+	}
+
+	switch {
+	case len(workerURLs) > 0:
+		status.WorkersTotal = len(workerURLs)
+		status.WorkersUp = countWorkers(ctx, workerURLs)
+		if status.WorkersUp == 0 {
+			status.Health = HealthDegraded
+			status.Note = "Queue mode without reachable workers"
+		}
+	case metrics != nil && metrics.QueueWaiting > 0 && metrics.QueueActive == 0:
+		// Without worker health checks, jobs piling up with none active is
+		// the best indication that no worker is consuming the queue
+		status.Health = HealthDegraded
+		status.Note = "Queue mode with waiting jobs but none active"
+	}
+
+	return status
+}
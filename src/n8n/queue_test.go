@@ -0,0 +1,74 @@
+package n8n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != REST_PATH+"settings" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data": {"executionMode": "queue", "timezone": "Europe/Berlin", "versionCli": "1.80.0"}}`))
+	}))
+	defer server.Close()
+
+	settings, err := NewInstance("test", server.URL, "key").GetSettings(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionModeQueue, settings.ExecutionMode)
+	assert.Equal(t, "1.80.0", settings.VersionCli)
+}
+
+func TestCheckQueueMode(t *testing.T) {
+	worker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer worker.Close()
+
+	collection := core.NewBaseCollection("instances")
+	collection.Fields.Add(&core.JSONField{Name: "worker_health_urls"})
+	instance := func(workerURLs ...string) *core.Record {
+		record := core.NewRecord(collection)
+		record.Set("worker_health_urls", workerURLs)
+		return record
+	}
+	check := func(record *core.Record, settings *Settings, metrics *InstanceMetrics) QueueStatus {
+		return checkQueueMode(context.Background(), record, settings, metrics, zap.NewNop())
+	}
+
+	// Regular mode has no workers to check
+	status := check(instance(worker.URL+"/down"), &Settings{ExecutionMode: "regular"}, nil)
+	assert.Equal(t, QueueStatus{ExecutionMode: "regular", Health: HealthHealthy}, status)
+
+	// Queue mode detected from the settings, one of two workers up
+	status = check(instance(worker.URL+"/healthz", worker.URL+"/down"), &Settings{ExecutionMode: ExecutionModeQueue}, nil)
+	assert.Equal(t, QueueStatus{ExecutionMode: ExecutionModeQueue, WorkersTotal: 2, WorkersUp: 1, Health: HealthHealthy}, status)
+
+	// No worker reachable
+	status = check(instance(worker.URL+"/down"), &Settings{ExecutionMode: ExecutionModeQueue}, nil)
+	assert.Equal(t, HealthDegraded, status.Health)
+	assert.Equal(t, "Queue mode without reachable workers", status.Note)
+
+	// Without settings the queue metrics reveal the mode, waiting jobs with
+	// none active and no worker checks degrade the instance
+	status = check(instance(), nil, &InstanceMetrics{QueueMode: true, QueueWaiting: 4})
+	assert.Equal(t, ExecutionModeQueue, status.ExecutionMode)
+	assert.Equal(t, HealthDegraded, status.Health)
+
+	status = check(instance(), nil, &InstanceMetrics{QueueMode: true, QueueWaiting: 4, QueueActive: 2})
+	assert.Equal(t, HealthHealthy, status.Health)
+
+	status = check(instance(), nil, nil)
+	assert.Equal(t, QueueStatus{Health: HealthHealthy}, status)
+}
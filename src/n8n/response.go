@@ -0,0 +1,156 @@
+package n8n
+
+import (
+	"strconv"
+)
+
+// respondToWebhookType is the node type answering webhooks in "responseNode" mode
+const respondToWebhookType = "n8n-nodes-base.respondToWebhook"
+
+// WebhookResponse describes how a webhook answers its callers
+type WebhookResponse struct {
+	// Mode is "onReceived" (immediately), "lastNode" (after the last node)
+	// or "responseNode" (by a Respond to Webhook node)
+	Mode string `json:"mode"`
+	// Code is the status code for "onReceived" and "lastNode"
+	Code int `json:"code,omitempty"`
+	// Data is the returned data for "lastNode", e.g. "firstEntryJson"
+	Data string `json:"data,omitempty"`
+	// Nodes are the Respond to Webhook nodes reachable from the webhook
+	Nodes []RespondNode `json:"nodes,omitempty"`
+}
+
+// RespondNode is a Respond to Webhook node
+type RespondNode struct {
+	Name        string            `json:"name"`
+	RespondWith string            `json:"respond_with"`
+	Code        int               `json:"code"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// extractWebhookResponse determines the response behavior of a webhook node
+func extractWebhookResponse(workflow Workflow, node Node) WebhookResponse {
+	response := WebhookResponse{
+		Mode: node.Parameters.ResponseMode,
+		Data: node.Parameters.ResponseData,
+		Code: responseCode(node.Parameters.ResponseCode, node.Parameters.Options),
+	}
+	if response.Mode == "" {
+		response.Mode = "onReceived"
+	}
+	if response.Code == 0 {
+		response.Code = 200
+	}
+
+	if response.Mode != "responseNode" {
+		return response
+	}
+	response.Code = 0
+
+	nodes := make(map[string]Node, len(workflow.Nodes))
+	for _, n := range workflow.Nodes {
+		nodes[n.Name] = n
+	}
+	for _, name := range downstreamNodes(workflow, node.Name) {
+		n, ok := nodes[name]
+		if !ok || n.Type != respondToWebhookType {
+			continue
+		}
+
+		respond := RespondNode{
+			Name:        n.Name,
+			RespondWith: n.Parameters.RespondWith,
+			Code:        responseCode(nil, n.Parameters.Options),
+			Headers:     responseHeaders(n.Parameters.Options),
+		}
+		if respond.RespondWith == "" {
+			respond.RespondWith = "firstIncomingItem"
+		}
+		if respond.Code == 0 {
+			respond.Code = 200
+		}
+		response.Nodes = append(response.Nodes, respond)
+	}
+
+	return response
+}
+
+// downstreamNodes returns the names of all nodes reachable from start, in
+// breadth-first order
+func downstreamNodes(workflow Workflow, start string) []string {
+	visited := map[string]bool{start: true}
+	queue := []string{start}
+	var result []string
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, outputs := range workflow.Connections[current] {
+			for _, output := range outputs {
+				for _, connection := range output {
+					if visited[connection.Node] {
+						continue
+					}
+					visited[connection.Node] = true
+					result = append(result, connection.Node)
+					queue = append(queue, connection.Node)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// responseCode reads a status code from a parameter or the "responseCode"
+// option, which newer node versions store as {"values": {"responseCode": n}}
+func responseCode(parameter any, options map[string]any) int {
+	if code := toInt(parameter); code != 0 {
+		return code
+	}
+
+	option := options["responseCode"]
+	if values, ok := option.(map[string]any); ok {
+		if nested, ok := values["values"].(map[string]any); ok {
+			return toInt(nested["responseCode"])
+		}
+	}
+	return toInt(option)
+}
+
+// responseHeaders reads the "responseHeaders" option of a node
+func responseHeaders(options map[string]any) map[string]string {
+	headers, _ := options["responseHeaders"].(map[string]any)
+	entries, _ := headers["entries"].([]any)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(entries))
+	for _, item := range entries {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		value, _ := entry["value"].(string)
+		if name != "" {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+func toInt(value any) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package n8n
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractWebhookResponse(t *testing.T) {
+	var workflow Workflow
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "w1",
+		"nodes": [
+			{"name": "Webhook", "type": "n8n-nodes-base.webhook", "parameters": {"path": "orders", "responseMode": "responseNode"}},
+			{"name": "IF", "type": "n8n-nodes-base.if", "parameters": {}},
+			{"name": "Created", "type": "n8n-nodes-base.respondToWebhook", "parameters": {
+				"respondWith": "json",
+				"options": {
+					"responseCode": 201,
+					"responseHeaders": {"entries": [{"name": "Location", "value": "={{ $json.url }}"}]}
+				}
+			}},
+			{"name": "Rejected", "type": "n8n-nodes-base.respondToWebhook", "parameters": {"options": {"responseCode": "422"}}},
+			{"name": "Unconnected", "type": "n8n-nodes-base.respondToWebhook", "parameters": {}},
+			{"name": "Simple", "type": "n8n-nodes-base.webhook", "parameters": {"path": "ping", "options": {"responseCode": {"values": {"responseCode": 204}}}}},
+			{"name": "Last", "type": "n8n-nodes-base.webhook", "parameters": {"path": "last", "responseMode": "lastNode", "responseData": "firstEntryJson", "responseCode": 202}}
+		],
+		"connections": {
+			"Webhook": {"main": [[{"node": "IF", "type": "main", "index": 0}]]},
+			"IF": {"main": [[{"node": "Created", "type": "main", "index": 0}], [{"node": "Rejected", "type": "main", "index": 0}]]}
+		}
+	}`), &workflow))
+
+	webhooks := extractWebhooksFromWorkflow(workflow)
+	require.Len(t, webhooks, 3)
+
+	assert.Equal(t, WebhookResponse{
+		Mode: "responseNode",
+		Nodes: []RespondNode{
+			{Name: "Created", RespondWith: "json", Code: 201, Headers: map[string]string{"Location": "={{ $json.url }}"}},
+			{Name: "Rejected", RespondWith: "firstIncomingItem", Code: 422},
+		},
+	}, webhooks[0].Response)
+
+	assert.Equal(t, WebhookResponse{Mode: "onReceived", Code: 204}, webhooks[1].Response)
+	assert.Equal(t, WebhookResponse{Mode: "lastNode", Code: 202, Data: "firstEntryJson"}, webhooks[2].Response)
+}
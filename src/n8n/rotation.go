@@ -0,0 +1,388 @@
+package n8n
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// REST_PATH is the internal n8n API used by the editor UI. API keys can only
+// be managed there, authenticated with an owner session.
+const REST_PATH = "/rest/"
+
+// browserID is sent with every session request, n8n binds the auth cookie to it
+const browserID = "n8n-manager-backend"
+
+// ErrRotationUnsupported is returned when an instance can't rotate API keys
+var ErrRotationUnsupported = errors.New("API key rotation is not supported for this instance")
+
+// ErrNoOwnerCredentials is returned for actions that need an owner session
+// on instances without owner_email and owner_password
+var ErrNoOwnerCredentials = errors.New("owner credentials are not configured for this instance")
+
+// APIKey represents an API key as returned by the n8n REST API
+type APIKey struct {
+	ID     string `json:"id"`
+	Label  string `json:"label"`
+	APIKey string `json:"apiKey"`
+	// RawAPIKey is only set in the response of the create call
+	RawAPIKey string `json:"rawApiKey"`
+}
+
+// restSession is a logged in session against the internal n8n REST API
+type restSession struct {
+	instance *Instance
+	http     *http.Client
+}
+
+// newRESTSession logs into the n8n editor API with the owner credentials
+func (instance *Instance) newRESTSession(ctx context.Context, email, password string) (*restSession, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &restSession{
+		instance: instance,
+		http: &http.Client{
+			Timeout: 30 * time.Second,
+			Jar:     jar,
+		},
+	}
+
+	body := map[string]string{
+		"emailOrLdapLoginId": email,
+		"password":           password,
+	}
+	if err := session.do(ctx, http.MethodPost, "login", body, nil); err != nil {
+		return nil, fmt.Errorf("failed to log into n8n: %w", err)
+	}
+
+	return session, nil
+}
+
+// ownerSession logs into the n8n editor API of an instances record with its
+// owner credentials
+func ownerSession(ctx context.Context, record *core.Record) (*restSession, error) {
+	email, err := secrets.Resolve(ctx, record.GetString("owner_email"))
+	if err != nil {
+		return nil, err
+	}
+	password, err := secrets.Resolve(ctx, record.GetString("owner_password"))
+	if err != nil {
+		return nil, err
+	}
+	if email == "" || password == "" {
+		return nil, ErrNoOwnerCredentials
+	}
+
+	instance, err := InstanceFromRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	return instance.newRESTSession(ctx, email, password)
+}
+
+// do performs a JSON request against the REST API and decodes the "data" envelope into target
+func (s *restSession) do(ctx context.Context, method, path string, body any, target any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.instance.Host+REST_PATH+path, reader)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("browser-id", browserID)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrRotationUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if target == nil {
+		return nil
+	}
+
+	envelope := struct {
+		Data any `json:"data"`
+	}{Data: target}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// createAPIKey creates a new API key labeled with label
+func (s *restSession) createAPIKey(ctx context.Context, label string) (*APIKey, error) {
+	var key APIKey
+	body := map[string]any{
+		"label":     label,
+		"expiresAt": nil,
+	}
+	if err := s.do(ctx, http.MethodPost, "api-keys", body, &key); err != nil {
+		return nil, err
+	}
+
+	// Older versions only return the raw key in apiKey
+	if key.RawAPIKey == "" {
+		key.RawAPIKey = key.APIKey
+	}
+	if key.ID == "" || key.RawAPIKey == "" {
+		return nil, errors.New("n8n did not return the created API key")
+	}
+	return &key, nil
+}
+
+// listAPIKeys lists the API keys of the logged in user, with redacted values
+func (s *restSession) listAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	if err := s.do(ctx, http.MethodGet, "api-keys", nil, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// deleteAPIKey revokes the API key with the given id
+func (s *restSession) deleteAPIKey(ctx context.Context, id string) error {
+	return s.do(ctx, http.MethodDelete, "api-keys/"+id, nil, nil)
+}
+
+// findAPIKeyID finds the id of apiKey among the redacted keys listed by n8n,
+// matching on the visible suffix. It returns "" if there is no unique match.
+func findAPIKeyID(keys []APIKey, apiKey string) string {
+	id := ""
+	for _, key := range keys {
+		visible := strings.TrimLeft(key.APIKey, "*")
+		if len(visible) < 4 || !strings.HasSuffix(apiKey, visible) {
+			continue
+		}
+		if id != "" {
+			return ""
+		}
+		id = key.ID
+	}
+	return id
+}
+
+// RotationResult describes a completed API key rotation
+type RotationResult struct {
+	Instance   string    `json:"instance"`
+	NewKeyID   string    `json:"new_key_id"`
+	OldKeyID   string    `json:"old_key_id,omitempty"`
+	OldRevoked bool      `json:"old_revoked"`
+	RotatedAt  time.Time `json:"rotated_at"`
+}
+
+// RotateAPIKey replaces the API key of an instance: it creates a new key via
+// the n8n REST API, verifies it against the public API, stores it encrypted
+// on the record and finally revokes the old key. Every attempt is written to
+// the audit log.
+func RotateAPIKey(ctx context.Context, app core.App, record *core.Record, actor string, logger *zap.Logger) (*RotationResult, error) {
+	result, err := rotateAPIKey(ctx, app, record, logger)
+
+	entry := audit.Entry{
+		Action:   "api_key.rotated",
+		Instance: record.Id,
+		Actor:    actor,
+		Success:  err == nil,
+		RunID:    RunIDFromContext(ctx),
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Message = "API key rotated"
+		entry.Details = map[string]any{
+			"new_key_id":  result.NewKeyID,
+			"old_key_id":  result.OldKeyID,
+			"old_revoked": result.OldRevoked,
+		}
+	}
+	if auditErr := audit.Log(app, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+
+	return result, err
+}
+
+func rotateAPIKey(ctx context.Context, app core.App, record *core.Record, logger *zap.Logger) (*RotationResult, error) {
+	if apiKey := record.GetString("api_key"); secrets.IsReference(apiKey) && !secrets.IsEncrypted(apiKey) {
+		return nil, errors.New("API key is managed by a secret backend, rotate it there")
+	}
+	// Rotated keys are only stored encrypted
+	if !secrets.EncryptionEnabled() {
+		return nil, fmt.Errorf("%w: %w", ErrRotationUnsupported, secrets.ErrNoEncryptionKey)
+	}
+
+	session, err := ownerSession(ctx, record)
+	if errors.Is(err, ErrNoOwnerCredentials) {
+		return nil, fmt.Errorf("%w: owner credentials are not configured", ErrRotationUnsupported)
+	}
+	if err != nil {
+		return nil, err
+	}
+	instance := session.instance
+
+	// Resolve the id of the current key so it can be revoked afterwards
+	oldKeyID := record.GetString("api_key_id")
+	if oldKeyID == "" {
+		if keys, err := session.listAPIKeys(ctx); err == nil {
+			oldKeyID = findAPIKeyID(keys, instance.APIKey)
+		}
+	}
+
+	now := time.Now()
+	key, err := session.createAPIKey(ctx, "n8n-manager "+now.Format("2006-01-02 15:04"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	// Verify the new key before it replaces the old one
+	verify := NewInstance(instance.Id, instance.Host, key.RawAPIKey)
+	if _, err := verify.GetWorkflows(ctx); err != nil {
+		if delErr := session.deleteAPIKey(ctx, key.ID); delErr != nil {
+			logger.Error("Failed to remove unverified API key", zap.Error(delErr))
+		}
+		return nil, fmt.Errorf("new API key failed verification: %w", err)
+	}
+
+	// The old key keeps working until the new one is stored, a key that
+	// couldn't be stored is removed again
+	encrypted, err := secrets.Encrypt(key.RawAPIKey)
+	if err == nil {
+		record.Set("api_key", encrypted)
+		record.Set("api_key_id", key.ID)
+		record.Set("api_key_rotated_at", now)
+		err = app.Save(record)
+	}
+	if err != nil {
+		if delErr := session.deleteAPIKey(ctx, key.ID); delErr != nil {
+			logger.Error("Failed to remove unstored API key", zap.Error(delErr))
+		}
+		return nil, fmt.Errorf("failed to store new API key: %w", err)
+	}
+
+	result := &RotationResult{
+		Instance:  record.Id,
+		NewKeyID:  key.ID,
+		OldKeyID:  oldKeyID,
+		RotatedAt: now,
+	}
+
+	if oldKeyID == "" {
+		logger.Warn("Could not identify the previous API key, it was not revoked",
+			zap.String("instance", record.Id))
+		return result, nil
+	}
+
+	if err := session.deleteAPIKey(ctx, oldKeyID); err != nil {
+		logger.Error("Failed to revoke previous API key",
+			zap.Error(err),
+			zap.String("instance", record.Id))
+		return result, nil
+	}
+	result.OldRevoked = true
+
+	logger.Info("Rotated API key",
+		zap.String("instance", record.Id),
+		zap.String("new_key_id", key.ID))
+
+	return result, nil
+}
+
+// shouldRotate determines if an instance's API key is due for rotation
+func shouldRotate(record *core.Record) bool {
+	days := record.GetInt("api_key_rotation_days")
+	if days <= 0 {
+		return false
+	}
+
+	rotatedAt := record.GetDateTime("api_key_rotated_at")
+	if rotatedAt.IsZero() {
+		return true
+	}
+	return time.Now().After(rotatedAt.Time().AddDate(0, 0, days))
+}
+
+// encryptOwnerPassword encrypts a plain owner_password of an instances
+// record if SECRETS_ENCRYPTION_KEY is set
+func encryptOwnerPassword(record *core.Record) error {
+	password := record.GetString("owner_password")
+	if password == "" || secrets.IsReference(password) || !secrets.EncryptionEnabled() {
+		return nil
+	}
+	encrypted, err := secrets.Encrypt(password)
+	if err != nil {
+		return err
+	}
+	record.Set("owner_password", encrypted)
+	return nil
+}
+
+// initCredentialEncryption stores the owner passwords of instances
+// encrypted
+func initCredentialEncryption(app core.App) {
+	encrypt := func(e *core.RecordEvent) error {
+		if err := encryptOwnerPassword(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	}
+	app.OnRecordCreate("instances").BindFunc(encrypt)
+	app.OnRecordUpdate("instances").BindFunc(encrypt)
+}
+
+// initRotationCron rotates API keys of instances with a rotation policy once a day
+func initRotationCron(app core.App, logger *zap.Logger) {
+	app.Cron().MustAdd("rotate-api-keys", "0 3 * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", "rotate-api-keys"))
+
+		records, err := app.FindAllRecords("instances", dbx.NewExp("api_key_rotation_days > 0"), trash.NotDeleted)
+		if err != nil {
+			logger.Error("Failed to fetch n8n instances", zap.Error(err))
+			return
+		}
+
+		for _, record := range records {
+			if !shouldRotate(record) {
+				continue
+			}
+
+			if _, err := RotateAPIKey(context.Background(), app, record, "system", logger); err != nil {
+				logger.Error("Scheduled API key rotation failed",
+					zap.Error(err),
+					zap.String("instance", record.Id))
+			}
+		}
+	})
+}
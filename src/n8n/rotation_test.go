@@ -0,0 +1,124 @@
+package n8n
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeKeyAPI is an n8n instance managing API keys, it accepts the keys it
+// has created on its public API
+type fakeKeyAPI struct {
+	mu      sync.Mutex
+	created []string
+	deleted []string
+}
+
+func (f *fakeKeyAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/login":
+		w.Write([]byte(`{"data":{}}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/api-keys":
+		f.created = append(f.created, "new")
+		w.Write([]byte(`{"data":{"id":"new","rawApiKey":"new-api-key"}}`))
+	case r.Method == http.MethodDelete:
+		f.deleted = append(f.deleted, r.URL.Path[len("/rest/api-keys/"):])
+		w.Write([]byte(`{"data":true}`))
+	case r.URL.Path == "/api/v1/workflows" && r.Header.Get("X-N8N-API-KEY") == "new-api-key":
+		w.Write([]byte(`{"data":[]}`))
+	default:
+		http.Error(w, "unexpected request", http.StatusUnauthorized)
+	}
+}
+
+// rotationInstance returns an instances record of the fake n8n at url with
+// owner credentials and the API key with id "old"
+func rotationInstance(t *testing.T, app core.App, url string) *core.Record {
+	return testutil.Create(t, app, "instances", map[string]any{
+		"host":           url,
+		"api_key":        "old-api-key",
+		"api_key_id":     "old",
+		"owner_email":    "owner@example.com",
+		"owner_password": "owner-password",
+	})
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "0123456789abcdef0123456789abcdef")
+	app := testutil.NewApp(t)
+	initCredentialEncryption(app)
+
+	n8n := &fakeKeyAPI{}
+	server := httptest.NewServer(n8n)
+	defer server.Close()
+	record := rotationInstance(t, app, server.URL)
+
+	result, err := RotateAPIKey(context.Background(), app, record, "admin@example.com", zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, "new", result.NewKeyID)
+	assert.True(t, result.OldRevoked)
+	assert.Equal(t, []string{"old"}, n8n.deleted)
+
+	stored, err := app.FindRecordById("instances", record.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "new", stored.GetString("api_key_id"))
+	for field, plain := range map[string]string{"api_key": "new-api-key", "owner_password": "owner-password"} {
+		value := stored.GetString(field)
+		assert.True(t, secrets.IsEncrypted(value), field)
+		resolved, err := secrets.Resolve(context.Background(), value)
+		require.NoError(t, err)
+		assert.Equal(t, plain, resolved, field)
+	}
+}
+
+func TestRotateAPIKeyStoreFailure(t *testing.T) {
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "0123456789abcdef0123456789abcdef")
+	app := testutil.NewApp(t)
+
+	n8n := &fakeKeyAPI{}
+	server := httptest.NewServer(n8n)
+	defer server.Close()
+	record := rotationInstance(t, app, server.URL)
+
+	app.OnRecordUpdate("instances").BindFunc(func(e *core.RecordEvent) error {
+		return errors.New("database is locked")
+	})
+
+	_, err := RotateAPIKey(context.Background(), app, record, "system", zap.NewNop())
+	require.ErrorContains(t, err, "failed to store new API key")
+
+	// The new key is removed again, the old one keeps working
+	assert.Equal(t, []string{"new"}, n8n.deleted)
+	stored, err := app.FindRecordById("instances", record.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "old-api-key", stored.GetString("api_key"))
+	assert.Equal(t, "old", stored.GetString("api_key_id"))
+}
+
+func TestRotateAPIKeyWithoutEncryptionKey(t *testing.T) {
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "")
+	app := testutil.NewApp(t)
+
+	n8n := &fakeKeyAPI{}
+	server := httptest.NewServer(n8n)
+	defer server.Close()
+	record := rotationInstance(t, app, server.URL)
+
+	_, err := RotateAPIKey(context.Background(), app, record, "system", zap.NewNop())
+	assert.ErrorIs(t, err, ErrRotationUnsupported)
+	assert.ErrorIs(t, err, secrets.ErrNoEncryptionKey)
+	assert.Empty(t, n8n.created)
+}
@@ -0,0 +1,183 @@
+package n8n
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// RotateRouteTokensJob is the id of the cron job rotating route tokens
+const RotateRouteTokensJob = "rotate-route-tokens"
+
+// ErrTokenRotationUnsupported is returned when a route's token can't be rotated
+var ErrTokenRotationUnsupported = errors.New("rotating the token is not supported for this route")
+
+// headerAuthCredential is the n8n credential type webhooks check header
+// tokens with
+const headerAuthCredential = "httpHeaderAuth"
+
+// TokenRotationResult describes a completed route token rotation
+type TokenRotationResult struct {
+	Route      string    `json:"route"`
+	Credential string    `json:"credential"`
+	RotatedAt  time.Time `json:"rotated_at"`
+}
+
+// newRouteToken returns a random token for a route
+func newRouteToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// RotateRouteToken replaces the static token a route with apikey auth
+// injects: it generates a new token, sets it on the Header Auth credential
+// of the workflow in auth_api_key_credential and then stores it on the
+// route, which updates the Traefik middleware. Every attempt is written to
+// the audit log.
+func RotateRouteToken(ctx context.Context, app core.App, route *core.Record, actor string, logger *zap.Logger) (*TokenRotationResult, error) {
+	result, err := rotateRouteToken(ctx, app, route, logger)
+
+	entry := audit.Entry{
+		Action:   "route_token.rotated",
+		Instance: route.GetString("instance"),
+		Actor:    actor,
+		Success:  err == nil,
+		Details: map[string]any{
+			"route":      route.Id,
+			"credential": route.GetString("auth_api_key_credential"),
+		},
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Message = "Rotated route token"
+	}
+	if auditErr := audit.Log(app, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+
+	return result, err
+}
+
+func rotateRouteToken(ctx context.Context, app core.App, route *core.Record, logger *zap.Logger) (*TokenRotationResult, error) {
+	if route.GetString("auth_type") != "apikey" {
+		return nil, fmt.Errorf("%w: route doesn't inject an API key", ErrTokenRotationUnsupported)
+	}
+	credential := route.GetString("auth_api_key_credential")
+	if credential == "" {
+		return nil, fmt.Errorf("%w: the n8n credential of the route is not configured", ErrTokenRotationUnsupported)
+	}
+	oldToken := route.GetString("auth_api_key")
+	if secrets.IsReference(oldToken) {
+		return nil, fmt.Errorf("%w: a secret backend manages the token, rotate it there", ErrTokenRotationUnsupported)
+	}
+
+	record, err := app.FindRecordById("instances", route.GetString("instance"))
+	if err != nil {
+		return nil, fmt.Errorf("instance of route not found: %w", err)
+	}
+	instance, err := InstanceFromRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newRouteToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// n8n first, Traefik keeps sending the old token until it polls the new
+	// configuration, which rejects requests briefly either way
+	if err := instance.UpdateCredential(ctx, credential, headerAuthCredential, map[string]any{
+		"name":  traefik.APIKeyHeader,
+		"value": token,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update n8n credential: %w", err)
+	}
+
+	now := time.Now()
+	route.Set("auth_api_key", token)
+	route.Set("auth_api_key_rotated_at", now)
+	if err := app.Save(route); err != nil {
+		// Restore the token Traefik still sends
+		if oldToken != "" {
+			if restoreErr := instance.UpdateCredential(ctx, credential, headerAuthCredential, map[string]any{
+				"name":  traefik.APIKeyHeader,
+				"value": oldToken,
+			}); restoreErr != nil {
+				logger.Error("Failed to restore n8n credential, the route is out of sync",
+					zap.Error(restoreErr),
+					zap.String("route", route.Id))
+			}
+		}
+		return nil, fmt.Errorf("failed to store the new token: %w", err)
+	}
+
+	logger.Info("Rotated route token",
+		zap.String("route", route.Id),
+		zap.String("credential", credential))
+
+	return &TokenRotationResult{
+		Route:      route.Id,
+		Credential: credential,
+		RotatedAt:  now,
+	}, nil
+}
+
+// shouldRotateToken determines if a route's token is due for rotation
+func shouldRotateToken(route *core.Record) bool {
+	days := route.GetInt("auth_api_key_rotation_days")
+	if days <= 0 {
+		return false
+	}
+
+	rotatedAt := route.GetDateTime("auth_api_key_rotated_at")
+	if rotatedAt.IsZero() {
+		return true
+	}
+	return time.Now().After(rotatedAt.Time().AddDate(0, 0, days))
+}
+
+// initTokenRotationCron rotates the tokens of routes with a rotation
+// policy once a day
+func initTokenRotationCron(app core.App, logger *zap.Logger) {
+	app.Cron().MustAdd(RotateRouteTokensJob, "30 3 * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", RotateRouteTokensJob))
+
+		routes, err := app.FindAllRecords("routes",
+			dbx.HashExp{"auth_type": "apikey"},
+			dbx.NewExp("auth_api_key_rotation_days > 0"),
+			trash.NotDeleted)
+		if err != nil {
+			logger.Error("Failed to fetch routes", zap.Error(err))
+			return
+		}
+
+		for _, route := range routes {
+			if !shouldRotateToken(route) {
+				continue
+			}
+
+			if _, err := RotateRouteToken(context.Background(), app, route, "system", logger); err != nil {
+				logger.Error("Scheduled rotation of route token failed",
+					zap.Error(err),
+					zap.String("route", route.Id))
+			}
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package n8n
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/cron"
+	"github.com/sistemica/n8n-manager-backend/environments"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/trash"
+)
+
+// defaultScheduleLimit caps the number of runs returned by GET /api/schedule
+const defaultScheduleLimit = 5000
+
+// ScheduledRun is an upcoming execution of a schedule trigger
+type ScheduledRun struct {
+	Time         time.Time `json:"time"`
+	Instance     string    `json:"instance"`
+	WorkflowID   string    `json:"workflow_id"`
+	WorkflowName string    `json:"workflow_name"`
+	NodeName     string    `json:"node_name"`
+	Cron         string    `json:"cron"`
+	Timezone     string    `json:"timezone"`
+}
+
+// ScheduleOverlap is a minute in which several workflows start
+type ScheduleOverlap struct {
+	Time      time.Time `json:"time"`
+	Count     int       `json:"count"`
+	Workflows []string  `json:"workflows"`
+}
+
+// InvalidSchedule is a cron expression of a schedule trigger that can't be
+// parsed, its runs are missing from the schedule
+type InvalidSchedule struct {
+	Instance     string `json:"instance"`
+	WorkflowID   string `json:"workflow_id"`
+	WorkflowName string `json:"workflow_name"`
+	NodeName     string `json:"node_name"`
+	Cron         string `json:"cron"`
+	Error        string `json:"error"`
+}
+
+// ScheduleResponse is the response of GET /api/schedule
+type ScheduleResponse struct {
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	Runs      []ScheduledRun    `json:"runs"`
+	Overlaps  []ScheduleOverlap `json:"overlaps"`
+	Invalid   []InvalidSchedule `json:"invalid"`
+	Truncated bool              `json:"truncated"`
+}
+
+// upcomingRuns expands a 5-field cron expression into the run times in
+// [from, to), evaluated in loc. Like the PocketBase scheduler, day of month
+// and day of week must both match.
+func upcomingRuns(expression string, loc *time.Location, from, to time.Time) ([]time.Time, error) {
+	schedule, err := cron.NewSchedule(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []time.Time
+	for t := from.Truncate(time.Minute); t.Before(to); t = t.Add(time.Minute) {
+		if t.Before(from) {
+			continue
+		}
+		if schedule.IsDue(cron.NewMoment(t.In(loc))) {
+			runs = append(runs, t)
+		}
+	}
+	return runs, nil
+}
+
+// scheduleLocation returns the timezone a schedule trigger runs in: the
+// timezone setting of its workflow or, without one, the timezone of its
+// instance, SCHEDULE_DEFAULT_TIMEZONE (n8n's GENERIC_TIMEZONE) or TIMEZONE
+func scheduleLocation(workflowTimezone string, locations timezone.Locations, instance string) *time.Location {
+	if workflowTimezone != "" {
+		if loc, err := time.LoadLocation(workflowTimezone); err == nil {
+			return loc
+		}
+	}
+	if loc := locations[instance]; loc != nil {
+		return loc
+	}
+	if name := os.Getenv("SCHEDULE_DEFAULT_TIMEZONE"); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return timezone.Global()
+}
+
+// scheduleHandler lists upcoming runs of schedule triggers across all
+// instances. Query parameters: range (24h or 7d, default 24h), instance,
+// environment, all (include inactive workflows and disabled triggers) and
+// limit.
+func scheduleHandler(e *core.RequestEvent) error {
+	query := e.Request.URL.Query()
+
+	window := 24 * time.Hour
+	switch query.Get("range") {
+	case "", "24h":
+	case "7d":
+		window = 7 * 24 * time.Hour
+	default:
+		return apis.NewBadRequestError("range must be 24h or 7d", nil)
+	}
+
+	limit := defaultScheduleLimit
+	if value, err := strconv.Atoi(query.Get("limit")); err == nil && value > 0 {
+		limit = value
+	}
+
+	filter := dbx.HashExp{"kind": TriggerSchedule}
+	if instance := query.Get("instance"); instance != "" {
+		filter["instance"] = instance
+	}
+	if query.Get("all") != "true" {
+		filter["workflow_active"] = true
+		filter["disabled"] = false
+	}
+
+	environment, err := environments.Filter(e, "instance")
+	if err != nil {
+		return err
+	}
+
+	triggers, err := e.App.FindAllRecords("triggers", filter, environment,
+		trash.InstanceNotDeleted("instance"), trash.WorkflowNotDeleted("workflow"))
+	if err != nil {
+		return apis.NewBadRequestError("Failed to load triggers", err)
+	}
+	locations, err := timezone.ByInstance(e.App)
+	if err != nil {
+		return apis.NewBadRequestError("Failed to load instances", err)
+	}
+
+	from := time.Now().UTC().Truncate(time.Minute).Add(time.Minute)
+	response := ScheduleResponse{From: from, To: from.Add(window), Runs: []ScheduledRun{}, Overlaps: []ScheduleOverlap{}, Invalid: []InvalidSchedule{}}
+
+	for _, trigger := range triggers {
+		var expressions []string
+		if err := trigger.UnmarshalJSONField("cron", &expressions); err != nil {
+			continue
+		}
+		var config map[string]any
+		trigger.UnmarshalJSONField("config", &config)
+
+		workflowTimezone, _ := config["timezone"].(string)
+		loc := scheduleLocation(workflowTimezone, locations, trigger.GetString("instance"))
+
+		for _, expression := range expressions {
+			runs, err := upcomingRuns(expression, loc, response.From, response.To)
+			if err != nil {
+				response.Invalid = append(response.Invalid, InvalidSchedule{
+					Instance:     trigger.GetString("instance"),
+					WorkflowID:   trigger.GetString("workflow_id"),
+					WorkflowName: trigger.GetString("workflow_name"),
+					NodeName:     trigger.GetString("node_name"),
+					Cron:         expression,
+					Error:        err.Error(),
+				})
+				continue
+			}
+			for _, t := range runs {
+				response.Runs = append(response.Runs, ScheduledRun{
+					Time:         t.In(loc),
+					Instance:     trigger.GetString("instance"),
+					WorkflowID:   trigger.GetString("workflow_id"),
+					WorkflowName: trigger.GetString("workflow_name"),
+					NodeName:     trigger.GetString("node_name"),
+					Cron:         expression,
+					Timezone:     loc.String(),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(response.Runs, func(i, j int) bool { return response.Runs[i].Time.Before(response.Runs[j].Time) })
+	response.Overlaps = scheduleOverlaps(response.Runs)
+
+	if len(response.Runs) > limit {
+		response.Runs = response.Runs[:limit]
+		response.Truncated = true
+	}
+
+	return e.JSON(http.StatusOK, response)
+}
+
+// scheduleOverlaps returns the minutes in which more than one workflow
+// starts. runs must be sorted by time.
+func scheduleOverlaps(runs []ScheduledRun) []ScheduleOverlap {
+	overlaps := []ScheduleOverlap{}
+
+	for i := 0; i < len(runs); {
+		j := i
+		seen := map[string]bool{}
+		var workflows []string
+		for ; j < len(runs) && runs[j].Time.Equal(runs[i].Time); j++ {
+			key := runs[j].Instance + "/" + runs[j].WorkflowID
+			if !seen[key] {
+				seen[key] = true
+				workflows = append(workflows, runs[j].WorkflowName)
+			}
+		}
+		if len(workflows) > 1 {
+			overlaps = append(overlaps, ScheduleOverlap{Time: runs[i].Time, Count: len(workflows), Workflows: workflows})
+		}
+		i = j
+	}
+
+	return overlaps
+}
@@ -0,0 +1,98 @@
+package n8n
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpcomingRuns(t *testing.T) {
+	from := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC) // a Monday
+	to := from.Add(24 * time.Hour)
+
+	runs, err := upcomingRuns("15 */6 * * *", time.UTC, from, to)
+	require.NoError(t, err)
+	require.Len(t, runs, 4)
+	assert.Equal(t, from.Add(15*time.Minute), runs[0])
+	assert.Equal(t, from.Add(18*time.Hour+15*time.Minute), runs[3])
+
+	// 06:30 in Berlin is 05:30 UTC in winter time
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	runs, err = upcomingRuns("30 6 * * 1-5", berlin, from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{from.Add(5*time.Hour + 30*time.Minute)}, runs)
+
+	_, err = upcomingRuns("not a cron", time.UTC, from, to)
+	assert.Error(t, err)
+}
+
+func TestScheduleLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	locations := timezone.Locations{"instance-tokyo": tokyo}
+	t.Setenv("SCHEDULE_DEFAULT_TIMEZONE", "")
+
+	assert.Equal(t, "Europe/Berlin", scheduleLocation("Europe/Berlin", locations, "instance-tokyo").String())
+	assert.Equal(t, tokyo, scheduleLocation("", locations, "instance-tokyo"))
+	assert.Equal(t, tokyo, scheduleLocation("Not/AZone", locations, "instance-tokyo"))
+	assert.Equal(t, timezone.Global(), scheduleLocation("", locations, "other"))
+
+	t.Setenv("SCHEDULE_DEFAULT_TIMEZONE", "America/New_York")
+	assert.Equal(t, "America/New_York", scheduleLocation("", locations, "other").String())
+	assert.Equal(t, tokyo, scheduleLocation("", locations, "instance-tokyo"), "the instance timezone wins")
+}
+
+func TestScheduleOverlaps(t *testing.T) {
+	at := time.Date(2025, 3, 10, 2, 0, 0, 0, time.UTC)
+	runs := []ScheduledRun{
+		{Time: at, Instance: "a", WorkflowID: "1", WorkflowName: "Backup"},
+		{Time: at, Instance: "a", WorkflowID: "1", WorkflowName: "Backup"},
+		{Time: at, Instance: "b", WorkflowID: "1", WorkflowName: "Report"},
+		{Time: at.Add(time.Minute), Instance: "a", WorkflowID: "2", WorkflowName: "Sync"},
+	}
+
+	overlaps := scheduleOverlaps(runs)
+	require.Len(t, overlaps, 1)
+	assert.Equal(t, ScheduleOverlap{Time: at, Count: 2, Workflows: []string{"Backup", "Report"}}, overlaps[0])
+}
+
+func TestScheduleHandlerReportsInvalidExpressions(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	workflow := testutil.Create(t, app, "workflows", map[string]any{"instance": instance.Id, "workflow_id": "wf1"})
+	testutil.Create(t, app, "triggers", map[string]any{
+		"instance":        instance.Id,
+		"workflow":        workflow.Id,
+		"workflow_id":     "wf1",
+		"workflow_name":   "Report",
+		"workflow_active": true,
+		"node_name":       "Schedule",
+		"kind":            TriggerSchedule,
+		"cron":            []string{"0 * * * *", "*/90 * * * *"},
+	})
+
+	e := &core.RequestEvent{}
+	e.App = app
+	e.Request = httptest.NewRequest(http.MethodGet, "/api/schedule", nil)
+	recorder := httptest.NewRecorder()
+	e.Response = recorder
+	require.NoError(t, scheduleHandler(e))
+
+	var response ScheduleResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Runs, 24)
+	require.Len(t, response.Invalid, 1)
+	assert.Equal(t, "*/90 * * * *", response.Invalid[0].Cron)
+	assert.Equal(t, "Report", response.Invalid[0].WorkflowName)
+	assert.NotEmpty(t, response.Invalid[0].Error)
+}
@@ -0,0 +1,257 @@
+package n8n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// ScrubProfilesCollection stores the scrubbing profiles of workflow exports
+const ScrubProfilesCollection = "scrub_profiles"
+
+// ErrUnknownScrubProfile is returned for a profile name without record
+var ErrUnknownScrubProfile = errors.New("unknown scrub profile")
+
+// ScrubProfile removes what shouldn't leave the instance from workflow JSON,
+// so exports can be shared or committed to public repositories
+type ScrubProfile struct {
+	Name string
+
+	// RemoveCredentials drops the credential references of the nodes
+	RemoveCredentials bool
+
+	// StripWebhookIDs drops the webhook ids of the nodes
+	StripWebhookIDs bool
+
+	// SecretPatterns blank the static node parameters matching one of them,
+	// expressions (values starting with "=") are kept
+	SecretPatterns []*regexp.Regexp
+}
+
+// compileSecretPatterns compiles the secret_patterns of a scrub_profiles record
+func compileSecretPatterns(record *core.Record) ([]*regexp.Regexp, error) {
+	var sources []string
+	if err := record.UnmarshalJSONField("secret_patterns", &sources); err != nil {
+		return nil, errors.New("secret patterns must be a list of regular expressions")
+	}
+	patterns := make([]*regexp.Regexp, 0, len(sources))
+	for _, source := range sources {
+		pattern, err := regexp.Compile(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %q: %w", source, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// FindScrubProfile returns the profile with the given name
+func FindScrubProfile(app core.App, name string) (*ScrubProfile, error) {
+	record, err := app.FindFirstRecordByData(ScrubProfilesCollection, "name", name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownScrubProfile, name)
+	}
+	patterns, err := compileSecretPatterns(record)
+	if err != nil {
+		return nil, err
+	}
+	return &ScrubProfile{
+		Name:              name,
+		RemoveCredentials: record.GetBool("remove_credentials"),
+		StripWebhookIDs:   record.GetBool("strip_webhook_ids"),
+		SecretPatterns:    patterns,
+	}, nil
+}
+
+// backupScrubProfile returns the profile set in BACKUP_SCRUB_PROFILE, nil
+// if backups are stored unscrubbed. Workflows restored from scrubbed backups
+// need their credentials assigned again.
+func backupScrubProfile(app core.App) (*ScrubProfile, error) {
+	name := os.Getenv("BACKUP_SCRUB_PROFILE")
+	if name == "" {
+		return nil, nil
+	}
+	return FindScrubProfile(app, name)
+}
+
+// Scrub applies the profile to workflow JSON, fields it doesn't touch are
+// kept as they are. A nil profile returns data unchanged.
+func (p *ScrubProfile) Scrub(data []byte) ([]byte, error) {
+	if p == nil {
+		return data, nil
+	}
+
+	var workflow map[string]any
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("error decoding workflow: %w", err)
+	}
+	nodes, _ := workflow["nodes"].([]any)
+	for _, item := range nodes {
+		node, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if p.RemoveCredentials {
+			delete(node, "credentials")
+		}
+		if p.StripWebhookIDs {
+			delete(node, "webhookId")
+		}
+		if parameters, ok := node["parameters"]; ok && len(p.SecretPatterns) > 0 {
+			node["parameters"] = p.blank(parameters)
+		}
+	}
+	return json.Marshal(workflow)
+}
+
+// blank replaces the static strings in v matching a secret pattern with ""
+func (p *ScrubProfile) blank(v any) any {
+	switch val := v.(type) {
+	case string:
+		if strings.HasPrefix(val, "=") {
+			return val
+		}
+		for _, pattern := range p.SecretPatterns {
+			if pattern.MatchString(val) {
+				return ""
+			}
+		}
+		return val
+	case map[string]any:
+		for k, item := range val {
+			val[k] = p.blank(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = p.blank(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// bindScrubProfileValidation rejects scrub profiles with invalid patterns
+func bindScrubProfileValidation(app core.App) {
+	app.OnRecordValidate(ScrubProfilesCollection).BindFunc(func(e *core.RecordEvent) error {
+		if _, err := compileSecretPatterns(e.Record); err != nil {
+			return validation.Errors{"secret_patterns": validation.NewError("invalid_secret_patterns", err.Error())}
+		}
+		return e.Next()
+	})
+}
+
+// exportProfile returns the profile of ?profile=, nil if none is requested
+func exportProfile(e *core.RequestEvent) (*ScrubProfile, error) {
+	name := e.Request.URL.Query().Get("profile")
+	if name == "" {
+		return nil, nil
+	}
+	profile, err := FindScrubProfile(e.App, name)
+	if err != nil {
+		return nil, apis.NewBadRequestError(err.Error(), nil)
+	}
+	return profile, nil
+}
+
+// logExport audits an export, the profile tells whether it was scrubbed
+func logExport(e *core.RequestEvent, instanceID, message string, profile *ScrubProfile, details map[string]any, logger *zap.Logger) {
+	details["profile"] = ""
+	if profile != nil {
+		details["profile"] = profile.Name
+	}
+	err := audit.Log(e.App, audit.Entry{
+		Action:   "workflow.exported",
+		Instance: instanceID,
+		Actor:    audit.Actor(e.Auth),
+		Success:  true,
+		Message:  message,
+		Details:  details,
+	})
+	if err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err))
+	}
+}
+
+// exportWorkflowHandler downloads the workflow JSON of a workflows record,
+// scrubbed with the profile of ?profile=
+func exportWorkflowHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	profile, err := exportProfile(e)
+	if err != nil {
+		return err
+	}
+	record, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	data, err := WorkflowData(record)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to read workflow data", err)
+	}
+	if data == nil {
+		return apis.NewNotFoundError("No workflow data stored for this version", nil)
+	}
+	if data, err = profile.Scrub(data); err != nil {
+		return apis.NewInternalServerError("Failed to scrub workflow", err)
+	}
+
+	logExport(e, record.GetString("instance"), "Exported workflow "+record.GetString("workflow_name"), profile,
+		map[string]any{"workflow_id": record.GetString("workflow_id")}, logger)
+
+	e.Response.Header().Set("Content-Disposition",
+		`attachment; filename="workflow-`+record.GetString("workflow_id")+`.json"`)
+	return e.Blob(http.StatusOK, "application/json", data)
+}
+
+// exportInstanceWorkflowsHandler downloads the latest version of every
+// workflow of an instance as a JSON array, scrubbed with the profile of
+// ?profile=
+func exportInstanceWorkflowsHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	profile, err := exportProfile(e)
+	if err != nil {
+		return err
+	}
+	instance, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	records, err := latestWorkflows(e.App, instance.Id)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to load workflows", err)
+	}
+
+	workflows := []json.RawMessage{}
+	for _, record := range records {
+		data, err := WorkflowData(record)
+		if err != nil {
+			return apis.NewInternalServerError("Failed to read workflow data", err)
+		}
+		if data == nil {
+			continue
+		}
+		if data, err = profile.Scrub(data); err != nil {
+			return apis.NewInternalServerError("Failed to scrub workflow "+record.GetString("workflow_id"), err)
+		}
+		workflows = append(workflows, data)
+	}
+
+	logExport(e, instance.Id, fmt.Sprintf("Exported %d workflows", len(workflows)), profile,
+		map[string]any{"workflows": len(workflows)}, logger)
+
+	e.Response.Header().Set("Content-Disposition",
+		`attachment; filename="workflows-`+instance.Id+`.json"`)
+	return e.JSON(http.StatusOK, workflows)
+}
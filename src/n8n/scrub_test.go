@@ -0,0 +1,80 @@
+package n8n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrubProfile(t *testing.T) {
+	workflow := []byte(`{
+		"name": "Orders",
+		"nodes": [
+			{
+				"name": "Webhook",
+				"type": "n8n-nodes-base.webhook",
+				"webhookId": "2f6b1c9e",
+				"parameters": {"path": "orders"}
+			},
+			{
+				"name": "Notify",
+				"type": "n8n-nodes-base.httpRequest",
+				"credentials": {"httpHeaderAuth": {"id": "7", "name": "Slack"}},
+				"parameters": {
+					"url": "https://hooks.example.com/notify",
+					"headerParameters": {"parameters": [
+						{"name": "Authorization", "value": "Bearer abc123"},
+						{"name": "X-Token", "value": "={{ $env.TOKEN }}"}
+					]}
+				}
+			}
+		],
+		"connections": {}
+	}`)
+
+	t.Run("nil profile", func(t *testing.T) {
+		var profile *ScrubProfile
+		scrubbed, err := profile.Scrub(workflow)
+		require.NoError(t, err)
+		assert.Equal(t, workflow, scrubbed)
+	})
+
+	t.Run("public", func(t *testing.T) {
+		profile := &ScrubProfile{
+			RemoveCredentials: true,
+			StripWebhookIDs:   true,
+			SecretPatterns:    []*regexp.Regexp{regexp.MustCompile(`(?i)^Bearer\s+\S+`)},
+		}
+		scrubbed, err := profile.Scrub(workflow)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"name": "Orders",
+			"nodes": [
+				{
+					"name": "Webhook",
+					"type": "n8n-nodes-base.webhook",
+					"parameters": {"path": "orders"}
+				},
+				{
+					"name": "Notify",
+					"type": "n8n-nodes-base.httpRequest",
+					"parameters": {
+						"url": "https://hooks.example.com/notify",
+						"headerParameters": {"parameters": [
+							{"name": "Authorization", "value": ""},
+							{"name": "X-Token", "value": "={{ $env.TOKEN }}"}
+						]}
+					}
+				}
+			],
+			"connections": {}
+		}`, string(scrubbed))
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := (&ScrubProfile{}).Scrub([]byte("not json"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,287 @@
+package n8n
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+const (
+	// SecurityAuditJob is the id of the cron job generating security audits
+	SecurityAuditJob = "security-audit"
+
+	// SecurityAuditCollection stores the security audit reports
+	SecurityAuditCollection = "security_audits"
+
+	// securityAuditHistoryDays is how many days of reports are kept
+	securityAuditHistoryDays = 90
+)
+
+// AuditReport is the security audit of an instance by report title, e.g.
+// "Credentials Risk Report"
+type AuditReport map[string]RiskReport
+
+// RiskReport is a risk category of the security audit
+type RiskReport struct {
+	Risk     string         `json:"risk"`
+	Sections []AuditSection `json:"sections"`
+}
+
+// AuditSection is a finding of the security audit and where it applies
+type AuditSection struct {
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	Recommendation string `json:"recommendation"`
+	// Location items differ per risk, e.g. {"kind": "credential", "id": "3",
+	// "name": "Orders API"} or {"kind": "node", "workflowId": "7", ...}
+	Location []map[string]any `json:"location"`
+}
+
+// AuditFinding is a single location of a section, the unit compared between
+// reports
+type AuditFinding struct {
+	Risk     string         `json:"risk"`
+	Section  string         `json:"section"`
+	Location map[string]any `json:"location"`
+}
+
+// key identifies a finding across reports, json.Marshal sorts map keys
+func (f AuditFinding) key() string {
+	location, _ := json.Marshal(f.Location)
+	return f.Risk + "\x00" + f.Section + "\x00" + string(location)
+}
+
+// securityAuditSchedule returns the cron expression of the security audit
+// job, set with SECURITY_AUDIT_SCHEDULE (default daily at 03:00, "off"
+// disables it)
+func securityAuditSchedule() string {
+	if schedule := os.Getenv("SECURITY_AUDIT_SCHEDULE"); schedule != "" {
+		return schedule
+	}
+	return "0 3 * * *"
+}
+
+// abandonedWorkflowDays returns after how many days without executions the
+// audit reports a workflow as abandoned, set with
+// SECURITY_AUDIT_ABANDONED_DAYS (0 keeps the n8n default of 90)
+func abandonedWorkflowDays() int {
+	days, _ := strconv.Atoi(os.Getenv("SECURITY_AUDIT_ABANDONED_DAYS"))
+	return max(days, 0)
+}
+
+// GenerateAudit runs the security audit of the instance
+func (instance *Instance) GenerateAudit(ctx context.Context, abandonedDays int) (AuditReport, error) {
+	body := map[string]any{}
+	if abandonedDays > 0 {
+		body["additionalOptions"] = map[string]any{"daysAbandonedWorkflow": abandonedDays}
+	}
+	req, err := instance.newJSONRequest(ctx, "POST", "audit", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if err := instance.doJSON(req, &raw); err != nil {
+		return nil, err
+	}
+	// Without any risk n8n answers an empty array
+	report := AuditReport{}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		return report, nil
+	}
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return nil, fmt.Errorf("error decoding audit: %w", err)
+	}
+	return report, nil
+}
+
+// auditFindings returns the findings of a report, sorted by risk and section
+func auditFindings(report AuditReport) []AuditFinding {
+	findings := []AuditFinding{}
+	for _, risk := range report {
+		for _, section := range risk.Sections {
+			for _, location := range section.Location {
+				findings = append(findings, AuditFinding{Risk: risk.Risk, Section: section.Title, Location: location})
+			}
+			if len(section.Location) == 0 {
+				// Instance-wide findings, e.g. an outdated version
+				findings = append(findings, AuditFinding{Risk: risk.Risk, Section: section.Title})
+			}
+		}
+	}
+	slices.SortStableFunc(findings, func(a, b AuditFinding) int {
+		return cmp.Or(cmp.Compare(a.Risk, b.Risk), cmp.Compare(a.Section, b.Section), cmp.Compare(a.key(), b.key()))
+	})
+	return findings
+}
+
+// newAuditFindings returns the findings of current missing in previous
+func newAuditFindings(previous, current []AuditFinding) []AuditFinding {
+	known := make(map[string]bool, len(previous))
+	for _, finding := range previous {
+		known[finding.key()] = true
+	}
+	added := []AuditFinding{}
+	for _, finding := range current {
+		if !known[finding.key()] {
+			added = append(added, finding)
+		}
+	}
+	return added
+}
+
+// initSecurityAuditCron periodically audits the instances with
+// security_audit_enabled and purges old reports
+func initSecurityAuditCron(app core.App, logger *zap.Logger) {
+	schedule := securityAuditSchedule()
+	if schedule == "off" {
+		return
+	}
+
+	app.Cron().MustAdd(SecurityAuditJob, schedule, func() {
+		defer errorreport.Recover(logger, zap.String("job", SecurityAuditJob))
+
+		if maintenance.Enabled(app, logger) {
+			return
+		}
+
+		instances, err := app.FindAllRecords("instances", dbx.HashExp{"security_audit_enabled": true}, trash.NotDeleted)
+		if err != nil {
+			logger.Error("Failed to fetch n8n instances", zap.Error(err))
+			return
+		}
+
+		for _, record := range instances {
+			if _, err := runSecurityAudit(context.Background(), app, record, logger); err != nil {
+				logger.Warn("Failed to run security audit",
+					zap.Error(err),
+					zap.String("instance", record.Id))
+			}
+		}
+
+		if err := purgeSecurityAudits(app, time.Now().AddDate(0, 0, -securityAuditHistoryDays)); err != nil {
+			logger.Error("Failed to purge security audits", zap.Error(err))
+		}
+	})
+}
+
+// runSecurityAudit generates and stores the security audit of an instance
+// and alerts about findings new since the previous report
+func runSecurityAudit(ctx context.Context, app core.App, record *core.Record, logger *zap.Logger) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(SecurityAuditCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := InstanceFromRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	report, err := NewN8NClient(instance).GenerateAudit(ctx, abandonedWorkflowDays())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate audit: %w", err)
+	}
+	findings := auditFindings(report)
+
+	// The first report is the baseline, nothing in it is new
+	added := []AuditFinding{}
+	latest, err := app.FindRecordsByFilter(SecurityAuditCollection, "instance = {:instance}", "-created", 1, 0,
+		dbx.Params{"instance": record.Id})
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) > 0 {
+		var previous AuditReport
+		if err := latest[0].UnmarshalJSONField("report", &previous); err != nil {
+			return nil, fmt.Errorf("invalid stored audit: %w", err)
+		}
+		added = newAuditFindings(auditFindings(previous), findings)
+	}
+
+	audit := core.NewRecord(collection)
+	audit.Set("instance", record.Id)
+	audit.Set("report", report)
+	audit.Set("findings", len(findings))
+	audit.Set("new_findings", added)
+	if err := app.Save(audit); err != nil {
+		return nil, fmt.Errorf("failed to save audit: %w", err)
+	}
+
+	if len(added) > 0 {
+		logger.Warn("New security audit findings",
+			zap.String("instance", record.Id),
+			zap.Int("new_findings", len(added)))
+		notifyAuditFindings(logger, record, added)
+	}
+	return audit, nil
+}
+
+// notifyAuditFindings alerts about new findings of an instance
+func notifyAuditFindings(logger *zap.Logger, record *core.Record, added []AuditFinding) {
+	sections := []string{}
+	for _, finding := range added {
+		if !slices.Contains(sections, finding.Section) {
+			sections = append(sections, finding.Section)
+		}
+	}
+	err := notify.Send(context.Background(), notify.Notification{
+		Event:    "n8n.security_audit",
+		Severity: notify.SeverityWarning,
+		Title:    fmt.Sprintf("New security audit findings on %s", record.GetString("host")),
+		Message:  fmt.Sprintf("%d new findings: %s", len(added), strings.Join(sections, ", ")),
+		Fields: map[string]any{
+			"instance": record.Id,
+			"findings": added,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to send security audit notification", zap.Error(err), zap.String("instance", record.Id))
+	}
+}
+
+// purgeSecurityAudits deletes the reports created before
+func purgeSecurityAudits(app core.App, before time.Time) error {
+	records, err := app.FindAllRecords(SecurityAuditCollection, dbx.NewExp("created < {:before}",
+		dbx.Params{"before": before.UTC().Format(types.DefaultDateLayout)}))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := app.Delete(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// securityAuditHandler runs the security audit of an instance on demand
+func securityAuditHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	audit, err := runSecurityAudit(e.Request.Context(), e.App, record, logger)
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Security audit failed: "+err.Error(), nil)
+	}
+	return e.JSON(http.StatusOK, audit)
+}
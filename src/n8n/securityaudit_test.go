@@ -0,0 +1,83 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const auditResponse = `{
+	"Credentials Risk Report": {
+		"risk": "credentials",
+		"sections": [{
+			"title": "Credentials not used in recently run workflows",
+			"description": "These credentials are not used in any workflow executed in the past 90 days.",
+			"recommendation": "Consider deleting these credentials if you no longer need them.",
+			"location": [
+				{"kind": "credential", "id": "3", "name": "Orders API"},
+				{"kind": "credential", "id": "5", "name": "Old SMTP"}
+			]
+		}]
+	},
+	"Instance Risk Report": {
+		"risk": "instance",
+		"sections": [{"title": "Outdated instance", "description": "", "recommendation": "Update n8n", "location": []}]
+	}
+}`
+
+func TestGenerateAudit(t *testing.T) {
+	response := auditResponse
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/v1/audit", r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if len(body) > 0 {
+			assert.Equal(t, map[string]any{"additionalOptions": map[string]any{"daysAbandonedWorkflow": 30.0}}, body)
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	instance := NewInstance("test", server.URL, "key")
+
+	report, err := instance.GenerateAudit(context.Background(), 30)
+	require.NoError(t, err)
+	assert.Len(t, report["Credentials Risk Report"].Sections[0].Location, 2)
+
+	response = `[]`
+	report, err = instance.GenerateAudit(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Empty(t, report, "n8n answers an empty array without risks")
+}
+
+func TestNewAuditFindings(t *testing.T) {
+	var report AuditReport
+	require.NoError(t, json.Unmarshal([]byte(auditResponse), &report))
+
+	findings := auditFindings(report)
+	require.Len(t, findings, 3)
+	assert.Equal(t, "credentials", findings[0].Risk)
+	assert.Equal(t, "3", findings[0].Location["id"])
+	assert.Equal(t, AuditFinding{Risk: "instance", Section: "Outdated instance"}, findings[2])
+
+	assert.Empty(t, newAuditFindings(findings, auditFindings(report)))
+
+	// One credential was deleted, another one became unused
+	credentials := report["Credentials Risk Report"]
+	credentials.Sections[0].Location = []map[string]any{
+		{"name": "Orders API", "id": "3", "kind": "credential"},
+		{"kind": "credential", "id": "8", "name": "Billing"},
+	}
+	report["Credentials Risk Report"] = credentials
+
+	added := newAuditFindings(findings, auditFindings(report))
+	require.Len(t, added, 1)
+	assert.Equal(t, "Billing", added[0].Location["name"])
+}
@@ -0,0 +1,184 @@
+package n8n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// Outcomes of the last source control pull of an instance
+const (
+	PullOK       = "ok"
+	PullConflict = "conflict"
+	PullFailed   = "failed"
+)
+
+// ErrPullConflict is returned when a pull would overwrite local changes of
+// the instance, pulling with force overwrites them
+var ErrPullConflict = errors.New("local changes conflict with the repository, pull with force to overwrite them")
+
+// PullResult is what a source control pull imported into the instance
+type PullResult struct {
+	Workflows   []WorkflowInfo `json:"workflows"`
+	Credentials []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"credentials"`
+	Variables struct {
+		Added   []string `json:"added"`
+		Changed []string `json:"changed"`
+	} `json:"variables"`
+	Tags struct {
+		Tags []Tag `json:"tags"`
+	} `json:"tags"`
+}
+
+// SourceControlPull pulls the connected Git repository into the instance.
+// The public API has no push, pushes are made from the n8n editor.
+func (instance *Instance) SourceControlPull(ctx context.Context, force bool) (*PullResult, error) {
+	req, err := instance.newJSONRequest(ctx, "POST", "source-control/pull", map[string]any{"force": force})
+	if err != nil {
+		return nil, err
+	}
+
+	var result PullResult
+	if err := instance.doJSON(req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PullSourceControl pulls the Git repository connected to the instance of an
+// instances record, stores the outcome on the record and writes an audit
+// entry. The instance needs the source control enterprise feature.
+func PullSourceControl(ctx context.Context, app core.App, record *core.Record, force bool, actor string, logger *zap.Logger) (*PullResult, error) {
+	if err := RequireFeature(record, FeatureSourceControl); err != nil {
+		return nil, err
+	}
+
+	result, err := pullSourceControl(ctx, record, force)
+
+	status := PullOK
+	var stored any = result
+	switch {
+	case errors.Is(err, ErrPullConflict):
+		status, stored = PullConflict, map[string]string{"error": err.Error()}
+	case err != nil:
+		status, stored = PullFailed, map[string]string{"error": err.Error()}
+	default:
+		// Sync the pulled workflows on the next check instead of waiting
+		// for the check interval
+		record.Set("last_check", nil)
+	}
+	record.Set("source_control_pulled_at", time.Now())
+	record.Set("source_control_status", status)
+	record.Set("source_control_result", stored)
+	if saveErr := app.Save(record); saveErr != nil {
+		logger.Error("Failed to store source control pull",
+			zap.Error(saveErr),
+			zap.String("instance", record.Id))
+	}
+
+	entry := audit.Entry{
+		Action:   "source_control.pulled",
+		Instance: record.Id,
+		Actor:    actor,
+		Success:  err == nil,
+		Message:  "Pulled from source control",
+		Details:  map[string]any{"force": force},
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Details["workflows"] = len(result.Workflows)
+		entry.Details["credentials"] = len(result.Credentials)
+	}
+	if auditErr := audit.Log(app, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+
+	return result, err
+}
+
+func pullSourceControl(ctx context.Context, record *core.Record, force bool) (*PullResult, error) {
+	instance, err := InstanceFromRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := NewN8NClient(instance).SourceControlPull(ctx, force)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusConflict {
+		return nil, ErrPullConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull: %w", err)
+	}
+	return result, nil
+}
+
+// SourceControlStatus is the response of GET /api/instances/{id}/source-control
+type SourceControlStatus struct {
+	// Available is set when the instance is licensed for source control
+	Available bool `json:"available"`
+	// LastPull is empty before the first pull through the manager
+	LastPull       string `json:"last_pull,omitempty"`
+	LastPullStatus string `json:"last_pull_status,omitempty"`
+	LastPullResult any    `json:"last_pull_result,omitempty"`
+}
+
+// sourceControlStatusHandler returns whether source control is available on
+// an instance and the outcome of the last pull
+func sourceControlStatusHandler(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	status := SourceControlStatus{
+		Available:      HasFeature(record, FeatureSourceControl),
+		LastPullStatus: record.GetString("source_control_status"),
+	}
+	if pulled := record.GetDateTime("source_control_pulled_at"); !pulled.IsZero() {
+		status.LastPull = pulled.Time().Format(time.RFC3339)
+		status.LastPullResult = record.Get("source_control_result")
+	}
+	return e.JSON(http.StatusOK, status)
+}
+
+// sourceControlPullHandler pulls the Git repository into an instance, with
+// {"force": true} local changes are overwritten
+func sourceControlPullHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	var body struct {
+		Force bool `json:"force"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+
+	result, err := PullSourceControl(e.Request.Context(), e.App, record, body.Force, audit.Actor(e.Auth), logger)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrFeatureUnavailable):
+			return apis.NewBadRequestError(err.Error(), nil)
+		case errors.Is(err, ErrPullConflict):
+			return apis.NewApiError(http.StatusConflict, err.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusBadGateway, "Source control pull failed: "+err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,49 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceControlPull(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/v1/source-control/pull", r.URL.Path)
+
+		var body struct {
+			Force bool `json:"force"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if !body.Force {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.Write([]byte(`{
+			"workflows": [{"id": "7", "name": "Orders"}],
+			"credentials": [{"id": "3", "name": "Orders API", "type": "httpHeaderAuth"}],
+			"variables": {"added": ["tenant"], "changed": []},
+			"tags": {"tags": [{"id": "1", "name": "prod"}], "mappings": []}
+		}`))
+	}))
+	defer server.Close()
+
+	instance := NewInstance("test", server.URL, "key")
+
+	_, err := instance.SourceControlPull(context.Background(), false)
+	var statusErr *StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusConflict, statusErr.StatusCode)
+
+	result, err := instance.SourceControlPull(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, []WorkflowInfo{{ID: "7", Name: "Orders"}}, result.Workflows)
+	assert.Equal(t, "httpHeaderAuth", result.Credentials[0].Type)
+	assert.Equal(t, []string{"tenant"}, result.Variables.Added)
+}
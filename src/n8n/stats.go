@@ -0,0 +1,65 @@
+package n8n
+
+import (
+	"sync"
+	"time"
+)
+
+// SchedulerStats describes the state of the instance check scheduler
+type SchedulerStats struct {
+	// QueueDepth is the number of due instances not yet synced in the current tick
+	QueueDepth int `json:"queue_depth"`
+
+	// Running reports whether a tick is in progress
+	Running bool `json:"running"`
+
+	// LastTick is when the last tick started
+	LastTick time.Time `json:"last_tick"`
+
+	// LastTickLag is how late the last tick started compared to its schedule
+	LastTickLag time.Duration `json:"last_tick_lag_ns"`
+
+	// LastTickDuration is how long the last completed tick took
+	LastTickDuration time.Duration `json:"last_tick_duration_ns"`
+}
+
+var (
+	schedulerMu    sync.Mutex
+	schedulerStats SchedulerStats
+)
+
+// Scheduler returns a snapshot of the scheduler statistics
+func Scheduler() SchedulerStats {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+	return schedulerStats
+}
+
+// tickStarted records the start of a cron tick with queued due instances.
+// Cron ticks are scheduled on full minutes, anything after that is lag.
+func tickStarted(now time.Time, queued int) {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+	schedulerStats.Running = true
+	schedulerStats.QueueDepth = queued
+	schedulerStats.LastTick = now
+	schedulerStats.LastTickLag = now.Sub(now.Truncate(time.Minute))
+}
+
+// instanceDone removes a synced instance from the queue
+func instanceDone() {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+	if schedulerStats.QueueDepth > 0 {
+		schedulerStats.QueueDepth--
+	}
+}
+
+// tickFinished records the end of the current cron tick
+func tickFinished(now time.Time) {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+	schedulerStats.Running = false
+	schedulerStats.QueueDepth = 0
+	schedulerStats.LastTickDuration = now.Sub(schedulerStats.LastTick)
+}
@@ -0,0 +1,30 @@
+package n8n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerStats(t *testing.T) {
+	started := time.Date(2025, 3, 10, 12, 0, 3, 0, time.UTC)
+	tickStarted(started, 2)
+
+	stats := Scheduler()
+	assert.True(t, stats.Running)
+	assert.Equal(t, 2, stats.QueueDepth)
+	assert.Equal(t, 3*time.Second, stats.LastTickLag, "ticks are due on the full minute")
+
+	instanceDone()
+	assert.Equal(t, 1, Scheduler().QueueDepth)
+	instanceDone()
+	instanceDone()
+	assert.Equal(t, 0, Scheduler().QueueDepth, "the depth doesn't go negative")
+
+	tickFinished(started.Add(10 * time.Second))
+	stats = Scheduler()
+	assert.False(t, stats.Running)
+	assert.Equal(t, 10*time.Second, stats.LastTickDuration)
+	assert.Equal(t, started, stats.LastTick)
+}
@@ -0,0 +1,64 @@
+package n8n
+
+import (
+	"encoding/json"
+
+	"github.com/sistemica/n8n-manager-backend/redact"
+)
+
+// Store modes of an instance, they control what is kept in workflow_data
+const (
+	// StoreFull keeps the complete workflow JSON
+	StoreFull = "full"
+	// StoreMetadata keeps no workflow JSON, only the record metadata
+	StoreMetadata = "metadata"
+	// StoreMasked drops pinned data and masks secret-looking parameter values
+	StoreMasked = "masked"
+)
+
+// workflowData returns the workflow JSON to store for the given store mode,
+// or nil if nothing should be stored
+func workflowData(workflow Workflow, mode string) []byte {
+	if mode == StoreMetadata {
+		return nil
+	}
+
+	data := []byte(workflow.Raw)
+	if len(data) == 0 {
+		data, _ = json.Marshal(workflow)
+	}
+
+	if mode == StoreMasked {
+		return maskWorkflow(data)
+	}
+	return data
+}
+
+// maskWorkflow removes pinData and masks node parameters using the redact
+// rules: values of secret keys, JWTs and Authorization credentials
+func maskWorkflow(data []byte) []byte {
+	var workflow map[string]any
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil
+	}
+
+	delete(workflow, "pinData")
+
+	if nodes, ok := workflow["nodes"].([]any); ok {
+		for _, item := range nodes {
+			node, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if parameters, ok := node["parameters"]; ok {
+				node["parameters"] = redact.Value("", parameters)
+			}
+		}
+	}
+
+	masked, err := json.Marshal(workflow)
+	if err != nil {
+		return nil
+	}
+	return masked
+}
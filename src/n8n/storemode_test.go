@@ -0,0 +1,44 @@
+package n8n
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowData(t *testing.T) {
+	workflow := Workflow{
+		Name: "Orders",
+		Raw: json.RawMessage(`{
+			"name": "Orders",
+			"pinData": {"Webhook": [{"json": {"email": "someone@example.com"}}]},
+			"nodes": [{
+				"name": "HTTP Request",
+				"parameters": {
+					"url": "https://api.example.com",
+					"headerParameters": {"parameters": [{"name": "Authorization", "value": "Bearer abc123"}]},
+					"apiToken": "plain-token"
+				},
+				"credentials": {"httpHeaderAuth": {"id": "1", "name": "Orders API"}}
+			}]
+		}`),
+	}
+
+	assert.JSONEq(t, string(workflow.Raw), string(workflowData(workflow, StoreFull)))
+	assert.JSONEq(t, string(workflow.Raw), string(workflowData(workflow, "")))
+	assert.Nil(t, workflowData(workflow, StoreMetadata))
+
+	var masked map[string]any
+	require.NoError(t, json.Unmarshal(workflowData(workflow, StoreMasked), &masked))
+	assert.NotContains(t, masked, "pinData")
+
+	node := masked["nodes"].([]any)[0].(map[string]any)
+	params := node["parameters"].(map[string]any)
+	assert.Equal(t, "https://api.example.com", params["url"])
+	assert.Equal(t, "********", params["apiToken"])
+	header := params["headerParameters"].(map[string]any)["parameters"].([]any)[0].(map[string]any)
+	assert.Equal(t, "Bearer ********", header["value"])
+	assert.Equal(t, "Orders API", node["credentials"].(map[string]any)["httpHeaderAuth"].(map[string]any)["name"])
+}
@@ -0,0 +1,216 @@
+package n8n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// maxStuckExecutions caps the running and waiting executions listed per sync
+const maxStuckExecutions = 1000
+
+// stuckThreshold returns how long an execution may stay in status before
+// it counts as stuck, configured with STUCK_RUNNING_MINS (default 60) and
+// STUCK_WAITING_MINS (default 1440, Wait nodes legitimately pause for long)
+func stuckThreshold(status string) time.Duration {
+	variable, mins := "STUCK_RUNNING_MINS", 60
+	if status == ExecutionWaiting {
+		variable, mins = "STUCK_WAITING_MINS", 24*60
+	}
+	if value, err := strconv.Atoi(os.Getenv(variable)); err == nil && value > 0 {
+		mins = value
+	}
+	return time.Duration(mins) * time.Minute
+}
+
+// stuckExecutions returns the ids of the executions running or waiting
+// longer than their threshold, by workflow
+func stuckExecutions(executions []Execution, now time.Time) map[string][]string {
+	stuck := map[string][]string{}
+	for _, execution := range executions {
+		if execution.Status != ExecutionRunning && execution.Status != ExecutionWaiting {
+			continue
+		}
+		if now.Sub(execution.StartedAt) > stuckThreshold(execution.Status) {
+			stuck[execution.WorkflowID] = append(stuck[execution.WorkflowID], execution.ID)
+		}
+	}
+	return stuck
+}
+
+// detectStuckExecutions flags the workflows of an instance with stuck
+// executions and clears the flag of the others
+func detectStuckExecutions(ctx context.Context, app core.App, client N8NClient, record *core.Record, logger *zap.Logger) error {
+	var executions []Execution
+	for _, status := range []string{ExecutionRunning, ExecutionWaiting} {
+		listed, _, err := client.GetExecutions(ctx, ExecutionFilter{Status: status, Limit: maxStuckExecutions})
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest {
+			// Older versions can't filter running executions
+			logger.Debug("Instance doesn't list executions by status",
+				zap.String("instance", record.Id),
+				zap.String("status", status))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get %s executions: %w", status, err)
+		}
+		executions = append(executions, listed...)
+	}
+
+	stuck := stuckExecutions(executions, time.Now())
+	if len(stuck) > 0 {
+		logger.Warn("Found stuck executions",
+			zap.String("instance", record.Id),
+			zap.Int("workflows", len(stuck)))
+	}
+	return setStuckExecutions(app, record.Id, stuck)
+}
+
+// setStuckExecutions stores the stuck executions on the workflows records of
+// an instance, workflows missing in stuck have none
+func setStuckExecutions(app core.App, instanceId string, stuck map[string][]string) error {
+	flagged, err := app.FindAllRecords("workflows", dbx.NewExp("instance = {:instance} AND stuck_executions > 0",
+		dbx.Params{"instance": instanceId}))
+	if err != nil {
+		return err
+	}
+	records := map[string]*core.Record{}
+	for _, record := range flagged {
+		records[record.Id] = record
+	}
+	for workflowID := range stuck {
+		history, err := workflowHistory(app, instanceId, workflowID)
+		if err != nil {
+			return err
+		}
+		for _, record := range history {
+			records[record.Id] = record
+		}
+	}
+
+	for _, record := range records {
+		ids := stuck[record.GetString("workflow_id")]
+		var current []string
+		record.UnmarshalJSONField("stuck_execution_ids", &current)
+		if slices.Equal(current, ids) {
+			continue
+		}
+		record.Set("stuck_executions", len(ids))
+		record.Set("stuck_execution_ids", ids)
+		if err := app.Save(record); err != nil {
+			return fmt.Errorf("failed to flag stuck executions: %w", err)
+		}
+	}
+	return nil
+}
+
+// StopExecution stops a running or waiting execution
+func (instance *Instance) StopExecution(ctx context.Context, id string) error {
+	req, err := instance.newRequest(ctx, "POST", fmt.Sprintf("executions/%s/stop", id))
+	if err != nil {
+		return err
+	}
+	return instance.doJSON(req, nil)
+}
+
+// StopResult is the response of POST /api/instances/{id}/executions/stop
+type StopResult struct {
+	Stopped []string          `json:"stopped"`
+	Failed  map[string]string `json:"failed,omitempty"`
+}
+
+// stopExecutionsHandler stops the executions passed as {"ids": [...]}, or
+// all stuck executions of the instance without ids
+func stopExecutionsHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("instances", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Instance not found", err)
+	}
+
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := e.BindBody(&body); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+
+	flagged, err := e.App.FindAllRecords("workflows", dbx.NewExp("instance = {:instance} AND stuck_executions > 0",
+		dbx.Params{"instance": record.Id}))
+	if err != nil {
+		return apis.NewInternalServerError("Failed to fetch workflows", err)
+	}
+	stuck := map[string][]string{}
+	for _, workflow := range flagged {
+		var ids []string
+		workflow.UnmarshalJSONField("stuck_execution_ids", &ids)
+		stuck[workflow.GetString("workflow_id")] = ids
+	}
+	ids := body.IDs
+	if len(ids) == 0 {
+		for _, workflowIds := range stuck {
+			ids = append(ids, workflowIds...)
+		}
+		slices.Sort(ids)
+	}
+	if len(ids) == 0 {
+		return apis.NewBadRequestError("No stuck executions to stop", nil)
+	}
+
+	instance, err := InstanceFromRecord(e.Request.Context(), record)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to load instance", err)
+	}
+	client := NewN8NClient(instance)
+
+	result := StopResult{Stopped: []string{}, Failed: map[string]string{}}
+	for _, id := range ids {
+		if err := client.StopExecution(e.Request.Context(), id); err != nil {
+			result.Failed[id] = err.Error()
+			continue
+		}
+		result.Stopped = append(result.Stopped, id)
+	}
+
+	// Stopped executions are no longer stuck
+	for workflowID, workflowIds := range stuck {
+		stuck[workflowID] = slices.DeleteFunc(workflowIds, func(id string) bool {
+			return slices.Contains(result.Stopped, id)
+		})
+		if len(stuck[workflowID]) == 0 {
+			delete(stuck, workflowID)
+		}
+	}
+	if err := setStuckExecutions(e.App, record.Id, stuck); err != nil {
+		logger.Error("Failed to update stuck executions", zap.Error(err), zap.String("instance", record.Id))
+	}
+
+	entry := audit.Entry{
+		Action:   "executions.stopped",
+		Instance: record.Id,
+		Actor:    audit.Actor(e.Auth),
+		Message:  fmt.Sprintf("Stopped %d of %d executions", len(result.Stopped), len(ids)),
+		Success:  len(result.Failed) == 0,
+		Details:  map[string]any{"stopped": result.Stopped, "failed": result.Failed},
+	}
+	if err := audit.Log(e.App, entry); err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err))
+	}
+
+	status := http.StatusOK
+	if len(result.Stopped) == 0 {
+		status = http.StatusBadGateway
+	}
+	return e.JSON(status, result)
+}
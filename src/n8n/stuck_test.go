@@ -0,0 +1,25 @@
+package n8n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStuckExecutions(t *testing.T) {
+	now := time.Date(2025, 5, 2, 12, 0, 0, 0, time.UTC)
+	executions := []Execution{
+		{ID: "1", WorkflowID: "orders", Status: ExecutionRunning, StartedAt: now.Add(-2 * time.Hour)},
+		{ID: "2", WorkflowID: "orders", Status: ExecutionRunning, StartedAt: now.Add(-10 * time.Minute)},
+		{ID: "3", WorkflowID: "invoices", Status: ExecutionWaiting, StartedAt: now.Add(-2 * time.Hour)},
+		{ID: "4", WorkflowID: "invoices", Status: ExecutionWaiting, StartedAt: now.Add(-48 * time.Hour)},
+		{ID: "5", WorkflowID: "reports", Status: ExecutionSuccess, StartedAt: now.Add(-48 * time.Hour)},
+	}
+
+	assert.Equal(t, map[string][]string{"orders": {"1"}, "invoices": {"4"}}, stuckExecutions(executions, now))
+
+	t.Setenv("STUCK_WAITING_MINS", "60")
+	t.Setenv("STUCK_RUNNING_MINS", "5")
+	assert.Equal(t, map[string][]string{"orders": {"1", "2"}, "invoices": {"3", "4"}}, stuckExecutions(executions, now))
+}
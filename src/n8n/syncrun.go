@@ -0,0 +1,80 @@
+package n8n
+
+import (
+	"context"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"go.uber.org/zap"
+)
+
+type runIDKey struct{}
+
+// WithRunID returns a context carrying the sync run id
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunIDFromContext returns the sync run id of ctx, or "" outside of a sync run
+func RunIDFromContext(ctx context.Context) string {
+	runID, _ := ctx.Value(runIDKey{}).(string)
+	return runID
+}
+
+// syncRun tracks a single check of an instance. Its ID is attached to every
+// log line (as sync_run_id) and stored with the sync_runs record, so all
+// output of one sync can be found together.
+type syncRun struct {
+	ID       string
+	instance string
+	started  time.Time
+	logger   *zap.Logger
+}
+
+// startSyncRun starts a new sync run for an instances record
+func startSyncRun(record *core.Record, logger *zap.Logger) *syncRun {
+	id := security.RandomString(15)
+	return &syncRun{
+		ID:       id,
+		instance: record.Id,
+		started:  time.Now(),
+		logger:   logger.With(zap.String("sync_run_id", id)),
+	}
+}
+
+// finish stores the outcome of the run in the sync_runs collection
+func (run *syncRun) finish(app core.App, stats *InstanceStats, syncErr error) {
+	finished := time.Now()
+
+	collection, err := app.FindCollectionByNameOrId("sync_runs")
+	if err != nil {
+		run.logger.Error("Failed to find sync_runs collection", zap.Error(err))
+		return
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("run_id", run.ID)
+	record.Set("instance", run.instance)
+	record.Set("started", run.started)
+	record.Set("finished", finished)
+	record.Set("duration_ms", finished.Sub(run.started).Milliseconds())
+	record.Set("success", syncErr == nil)
+	if syncErr != nil {
+		record.Set("error", syncErr.Error())
+	}
+	if stats != nil {
+		record.Set("workflows", stats.TotalWorkflows)
+		record.Set("webhooks", stats.TotalWebhooks)
+		if stats.Disk != nil {
+			record.Set("binary_data_bytes", stats.Disk.BinaryDataBytes)
+			record.Set("database_bytes", stats.Disk.DatabaseBytes)
+			record.Set("disk_free_bytes", stats.Disk.DiskFreeBytes)
+			record.Set("disk_total_bytes", stats.Disk.DiskTotalBytes)
+		}
+	}
+
+	if err := app.Save(record); err != nil {
+		run.logger.Error("Failed to store sync run", zap.Error(err))
+	}
+}
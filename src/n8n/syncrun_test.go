@@ -0,0 +1,64 @@
+package n8n
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRunIDFromContext(t *testing.T) {
+	assert.Empty(t, RunIDFromContext(context.Background()))
+	assert.Equal(t, "run1", RunIDFromContext(WithRunID(context.Background(), "run1")))
+}
+
+func TestCheckInstanceRecordsSyncRun(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	record := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com", "api_key": "key", "metrics_enabled": true})
+	var syncErr error
+	client := &MockClient{
+		GetWorkflowsFunc: func(context.Context) ([]Workflow, error) { return nil, syncErr },
+		GetMetricsFunc:   func(context.Context) (*InstanceMetrics, error) { return &InstanceMetrics{}, nil },
+	}
+	newClient := NewN8NClient
+	NewN8NClient = func(*Instance) N8NClient { return client }
+	t.Cleanup(func() { NewN8NClient = newClient })
+
+	observed, logs := observer.New(zap.DebugLevel)
+	checkInstance(context.Background(), app, record, zap.New(observed))
+
+	runs, err := app.FindAllRecords("sync_runs", dbx.HashExp{"instance": record.Id})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	runID := runs[0].GetString("run_id")
+	require.NotEmpty(t, runID)
+	assert.True(t, runs[0].GetBool("success"))
+	assert.False(t, runs[0].GetDateTime("finished").Before(runs[0].GetDateTime("started")))
+
+	// Every log line and the metrics sample carry the run id
+	require.NotZero(t, logs.Len())
+	for _, entry := range logs.All() {
+		assert.Equal(t, runID, entry.ContextMap()["sync_run_id"], entry.Message)
+	}
+	sample, err := app.FindFirstRecordByData("instance_metrics", "instance", record.Id)
+	require.NoError(t, err)
+	assert.Equal(t, runID, sample.GetString("run_id"))
+
+	// A failed sync is recorded with its error under a new id
+	syncErr = errors.New("connection refused")
+	record, err = app.FindRecordById("instances", record.Id)
+	require.NoError(t, err)
+	checkInstance(context.Background(), app, record, zap.NewNop())
+
+	failed, err := app.FindFirstRecordByFilter("sync_runs", "instance = {:instance} && success = false", dbx.Params{"instance": record.Id})
+	require.NoError(t, err)
+	assert.NotEqual(t, runID, failed.GetString("run_id"))
+	assert.Contains(t, failed.GetString("error"), "connection refused")
+}
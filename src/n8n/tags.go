@@ -0,0 +1,222 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// Tag represents an n8n workflow tag
+type Tag struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// tagsResponse is a page of the tags list
+type tagsResponse struct {
+	Data       []Tag  `json:"data"`
+	NextCursor string `json:"nextCursor"`
+}
+
+// ErrEmptyName is returned when renaming a workflow to an empty name
+var ErrEmptyName = errors.New("workflow name must not be empty")
+
+// WorkflowUpdate holds the changes of PATCH /api/workflows/{id}, nil fields are left unchanged
+type WorkflowUpdate struct {
+	Name *string `json:"name"`
+	// Tags replaces the tags of the workflow, missing tags are created
+	Tags *[]string `json:"tags"`
+}
+
+// doJSON sends req and decodes the response into target, which may be nil
+func (instance *Instance) doJSON(req *http.Request, target any) error {
+	client := NewClient()
+	resp, err := client.do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Any 2xx is a success, e.g. 201 for a created resource or 204 without
+	// a body
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if target == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// GetTags retrieves all tags of the n8n instance
+func (instance *Instance) GetTags(ctx context.Context) ([]Tag, error) {
+	var tags []Tag
+	cursor := ""
+	for {
+		path := "tags?limit=250"
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+		req, err := instance.newRequest(ctx, "GET", path)
+		if err != nil {
+			return nil, err
+		}
+
+		var page tagsResponse
+		if err := instance.doJSON(req, &page); err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Data...)
+
+		if page.NextCursor == "" {
+			return tags, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CreateTag creates a tag on the n8n instance
+func (instance *Instance) CreateTag(ctx context.Context, name string) (*Tag, error) {
+	req, err := instance.newJSONRequest(ctx, "POST", "tags", map[string]string{"name": name})
+	if err != nil {
+		return nil, err
+	}
+
+	var tag Tag
+	if err := instance.doJSON(req, &tag); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// UpdateWorkflow replaces a workflow with the given workflow JSON object
+func (instance *Instance) UpdateWorkflow(ctx context.Context, id string, workflow map[string]any) error {
+	req, err := instance.newJSONRequest(ctx, "PUT", fmt.Sprintf("workflows/%s", id), importableWorkflow(workflow))
+	if err != nil {
+		return err
+	}
+	return instance.doJSON(req, nil)
+}
+
+// SetWorkflowTags replaces the tags of a workflow by tag name, tags that
+// don't exist yet are created
+func (instance *Instance) SetWorkflowTags(ctx context.Context, id string, names []string) ([]Tag, error) {
+	existing, err := instance.GetTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	byName := make(map[string]Tag, len(existing))
+	for _, tag := range existing {
+		byName[strings.ToLower(tag.Name)] = tag
+	}
+
+	ids := make([]map[string]string, 0, len(names))
+	for _, name := range names {
+		tag, ok := byName[strings.ToLower(name)]
+		if !ok {
+			created, err := instance.CreateTag(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create tag %q: %w", name, err)
+			}
+			tag = *created
+			byName[strings.ToLower(name)] = tag
+		}
+		ids = append(ids, map[string]string{"id": tag.ID})
+	}
+
+	req, err := instance.newJSONRequest(ctx, "PUT", fmt.Sprintf("workflows/%s/tags", id), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	if err := instance.doJSON(req, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// UpdateWorkflowMetadata renames and retags a workflow on its instance.
+// record is any record of the workflows collection for the workflow, the
+// local history picks the change up with the next sync.
+func UpdateWorkflowMetadata(ctx context.Context, app core.App, record *core.Record, update WorkflowUpdate, actor string, logger *zap.Logger) error {
+	err := updateWorkflowMetadata(ctx, app, record, update)
+
+	details := map[string]any{"workflow_id": record.GetString("workflow_id")}
+	if update.Name != nil {
+		details["name"] = *update.Name
+	}
+	if update.Tags != nil {
+		details["tags"] = *update.Tags
+	}
+	entry := audit.Entry{
+		Action:   "workflow.updated",
+		Instance: record.GetString("instance"),
+		Actor:    actor,
+		Success:  err == nil,
+		Message:  "Workflow metadata updated",
+		Details:  details,
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	}
+	if auditErr := audit.Log(app, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+
+	return err
+}
+
+func updateWorkflowMetadata(ctx context.Context, app core.App, record *core.Record, update WorkflowUpdate) error {
+	if record.GetBool("archived") {
+		return ErrArchived
+	}
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		return ErrEmptyName
+	}
+
+	instance, err := instanceForWorkflow(ctx, app, record)
+	if err != nil {
+		return err
+	}
+	client := NewN8NClient(instance)
+	workflowID := record.GetString("workflow_id")
+
+	if update.Name != nil {
+		data, err := client.GetWorkflowJSON(ctx, workflowID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch workflow: %w", err)
+		}
+		var workflow map[string]any
+		if err := json.Unmarshal(data, &workflow); err != nil {
+			return fmt.Errorf("error decoding workflow: %w", err)
+		}
+
+		workflow["name"] = strings.TrimSpace(*update.Name)
+		if err := client.UpdateWorkflow(ctx, workflowID, workflow); err != nil {
+			return fmt.Errorf("failed to rename workflow: %w", err)
+		}
+	}
+
+	if update.Tags != nil {
+		if _, err := client.SetWorkflowTags(ctx, workflowID, *update.Tags); err != nil {
+			return fmt.Errorf("failed to update tags: %w", err)
+		}
+	}
+
+	return nil
+}
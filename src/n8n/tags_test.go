@@ -0,0 +1,82 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoJSON(t *testing.T) {
+	status := http.StatusOK
+	body := `{"id": "1", "name": "billing"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+	instance := NewInstance("test", server.URL, "key")
+
+	get := func() (Tag, error) {
+		req, err := instance.newRequest(context.Background(), "GET", "tags/1")
+		require.NoError(t, err)
+		var tag Tag
+		return tag, instance.doJSON(req, &tag)
+	}
+
+	for _, code := range []int{http.StatusOK, http.StatusCreated, http.StatusAccepted} {
+		status = code
+		tag, err := get()
+		require.NoError(t, err, code)
+		assert.Equal(t, Tag{ID: "1", Name: "billing"}, tag, code)
+	}
+
+	// No content is no decoding error
+	status, body = http.StatusNoContent, ""
+	tag, err := get()
+	require.NoError(t, err)
+	assert.Equal(t, Tag{}, tag)
+
+	status, body = http.StatusNotFound, `{"message": "not found"}`
+	_, err = get()
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Contains(t, statusErr.Body, "not found")
+
+	status, body = http.StatusOK, "not json"
+	_, err = get()
+	assert.ErrorContains(t, err, "error decoding response")
+}
+
+func TestSetWorkflowTags(t *testing.T) {
+	var assigned []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + API_PATH + "tags":
+			io.WriteString(w, `{"data": [{"id": "1", "name": "Billing"}]}`)
+		case "POST " + API_PATH + "tags":
+			var tag Tag
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&tag))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Tag{ID: "2", Name: tag.Name})
+		case "PUT " + API_PATH + "workflows/7/tags":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&assigned))
+			io.WriteString(w, `[{"id": "1", "name": "Billing"}, {"id": "2", "name": "reports"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	instance := NewInstance("test", server.URL, "key")
+	tags, err := instance.SetWorkflowTags(context.Background(), "7", []string{"billing", "reports"})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"id": "1"}, {"id": "2"}}, assigned, "existing tags match case-insensitively")
+	assert.Len(t, tags, 2)
+}
@@ -0,0 +1,359 @@
+package n8n
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+// Trigger kinds
+const (
+	TriggerWebhook  = "webhook"
+	TriggerSchedule = "schedule"
+	TriggerRedis    = "redis"
+	TriggerEmail    = "email"
+	TriggerChat     = "chat"
+	TriggerManual   = "manual"
+	TriggerOther    = "other"
+)
+
+// triggerKinds maps node types to trigger kinds, other node types ending
+// in "Trigger" are reported as TriggerOther
+var triggerKinds = map[string]string{
+	"n8n-nodes-base.webhook":                TriggerWebhook,
+	"n8n-nodes-base.formTrigger":            TriggerWebhook,
+	"n8n-nodes-base.scheduleTrigger":        TriggerSchedule,
+	"n8n-nodes-base.cron":                   TriggerSchedule,
+	"n8n-nodes-base.interval":               TriggerSchedule,
+	"n8n-nodes-base.redisTrigger":           TriggerRedis,
+	"n8n-nodes-base.emailReadImap":          TriggerEmail,
+	"@n8n/n8n-nodes-langchain.chatTrigger":  TriggerChat,
+	"n8n-nodes-base.manualTrigger":          TriggerManual,
+	"n8n-nodes-base.executeWorkflowTrigger": TriggerOther,
+}
+
+// Trigger is a node that starts a workflow
+type Trigger struct {
+	NodeID   string         `json:"node_id"`
+	NodeName string         `json:"node_name"`
+	Type     string         `json:"type"`
+	Kind     string         `json:"kind"`
+	Disabled bool           `json:"disabled"`
+	Config   map[string]any `json:"config"`
+	// Cron holds the 5-field cron expressions of schedule triggers
+	Cron []string `json:"cron,omitempty"`
+}
+
+// rawTriggerWorkflow is the part of the workflow JSON needed to extract
+// triggers, the Node model doesn't hold all parameters
+type rawTriggerWorkflow struct {
+	Nodes []struct {
+		ID          string                    `json:"id"`
+		Name        string                    `json:"name"`
+		Type        string                    `json:"type"`
+		Disabled    bool                      `json:"disabled"`
+		Parameters  map[string]any            `json:"parameters"`
+		Credentials map[string]NodeCredential `json:"credentials"`
+	} `json:"nodes"`
+	Settings struct {
+		Timezone string `json:"timezone"`
+	} `json:"settings"`
+}
+
+// extractTriggers returns all trigger nodes of a workflow
+func extractTriggers(workflow Workflow) []Trigger {
+	data := []byte(workflow.Raw)
+	if len(data) == 0 {
+		data, _ = json.Marshal(workflow)
+	}
+
+	var raw rawTriggerWorkflow
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+
+	var triggers []Trigger
+	for _, node := range raw.Nodes {
+		kind, ok := triggerKinds[node.Type]
+		if !ok {
+			if !strings.HasSuffix(node.Type, "Trigger") {
+				continue
+			}
+			kind = TriggerOther
+		}
+
+		trigger := Trigger{
+			NodeID:   node.ID,
+			NodeName: node.Name,
+			Type:     node.Type,
+			Kind:     kind,
+			Disabled: node.Disabled,
+			Config:   map[string]any{},
+		}
+		params := node.Parameters
+
+		switch kind {
+		case TriggerWebhook:
+			trigger.Config["path"] = params["path"]
+			if method, ok := params["httpMethod"]; ok {
+				trigger.Config["method"] = method
+			}
+		case TriggerSchedule:
+			var notes []string
+			trigger.Cron, notes = scheduleCron(node.Type, params)
+			if len(notes) > 0 {
+				trigger.Config["notes"] = notes
+			}
+			if raw.Settings.Timezone != "" {
+				trigger.Config["timezone"] = raw.Settings.Timezone
+			}
+		case TriggerRedis:
+			trigger.Config["channels"] = splitList(stringParam(params, "channels"))
+		case TriggerEmail:
+			trigger.Config["mailbox"] = stringParam(params, "mailbox")
+			if credential, ok := node.Credentials["imap"]; ok {
+				trigger.Config["credential"] = credential.Name
+			}
+		case TriggerChat:
+			trigger.Config["public"] = params["public"]
+			if mode, ok := params["mode"]; ok {
+				trigger.Config["mode"] = mode
+			}
+		}
+
+		triggers = append(triggers, trigger)
+	}
+
+	return triggers
+}
+
+// scheduleCron converts the rules of a schedule node into cron expressions.
+// Rules that cron can't express exactly are approximated and explained in
+// the returned notes.
+func scheduleCron(nodeType string, params map[string]any) ([]string, []string) {
+	var expressions, notes []string
+
+	switch nodeType {
+	case "n8n-nodes-base.scheduleTrigger":
+		rule, _ := params["rule"].(map[string]any)
+		intervals, _ := rule["interval"].([]any)
+		if len(intervals) == 0 {
+			// The default rule runs every day at midnight
+			intervals = []any{map[string]any{"field": "days"}}
+		}
+		for _, item := range intervals {
+			interval, _ := item.(map[string]any)
+			expression, note := scheduleIntervalCron(interval)
+			if expression != "" {
+				expressions = append(expressions, expression)
+			}
+			if note != "" {
+				notes = append(notes, note)
+			}
+		}
+	case "n8n-nodes-base.cron":
+		times, _ := params["triggerTimes"].(map[string]any)
+		items, _ := times["item"].([]any)
+		for _, item := range items {
+			entry, _ := item.(map[string]any)
+			expression, note := legacyCron(entry)
+			if expression != "" {
+				expressions = append(expressions, expression)
+			}
+			if note != "" {
+				notes = append(notes, note)
+			}
+		}
+	case "n8n-nodes-base.interval":
+		value := intParam(params, "interval", 1)
+		switch stringParam(params, "unit") {
+		case "hours":
+			expressions = append(expressions, fmt.Sprintf("0 */%d * * *", value))
+		case "days":
+			expressions = append(expressions, fmt.Sprintf("0 0 */%d * *", value))
+		case "minutes":
+			expressions = append(expressions, fmt.Sprintf("*/%d * * * *", value))
+		default:
+			expressions = append(expressions, "* * * * *")
+			notes = append(notes, fmt.Sprintf("runs every %d seconds", value))
+		}
+	}
+
+	// Expressions the scheduler can't parse, e.g. an interval of 90 minutes
+	// giving */90, have no runs and are reported instead
+	valid := expressions[:0]
+	for _, expression := range expressions {
+		if _, err := cron.NewSchedule(expression); err != nil {
+			notes = append(notes, fmt.Sprintf("invalid cron expression %s: %v", expression, err))
+			continue
+		}
+		valid = append(valid, expression)
+	}
+
+	return valid, notes
+}
+
+// scheduleIntervalCron converts one interval rule of the Schedule Trigger node
+func scheduleIntervalCron(interval map[string]any) (string, string) {
+	minute := intParam(interval, "triggerAtMinute", 0)
+	hour := intParam(interval, "triggerAtHour", 0)
+
+	switch field := stringParam(interval, "field"); field {
+	case "cronExpression":
+		return normalizeCron(stringParam(interval, "expression"))
+	case "seconds":
+		return "* * * * *", fmt.Sprintf("runs every %d seconds", intParam(interval, "secondsInterval", 30))
+	case "minutes":
+		return fmt.Sprintf("*/%d * * * *", intParam(interval, "minutesInterval", 5)), ""
+	case "hours":
+		return fmt.Sprintf("%d */%d * * *", minute, intParam(interval, "hoursInterval", 1)), ""
+	case "days", "":
+		return fmt.Sprintf("%d %d */%d * *", minute, hour, intParam(interval, "daysInterval", 1)), ""
+	case "weeks":
+		days := []string{}
+		if values, ok := interval["triggerAtDay"].([]any); ok {
+			for _, value := range values {
+				days = append(days, strconv.Itoa(toInt(value)))
+			}
+		}
+		if len(days) == 0 {
+			days = []string{"0"}
+		}
+		sort.Strings(days)
+		note := ""
+		if weeks := intParam(interval, "weeksInterval", 1); weeks > 1 {
+			note = fmt.Sprintf("runs every %d weeks, shown as weekly", weeks)
+		}
+		return fmt.Sprintf("%d %d * * %s", minute, hour, strings.Join(days, ",")), note
+	case "months":
+		return fmt.Sprintf("%d %d %d */%d *", minute, hour,
+			intParam(interval, "triggerAtDayOfMonth", 1), intParam(interval, "monthsInterval", 1)), ""
+	default:
+		return "", "unsupported interval " + field
+	}
+}
+
+// legacyCron converts a trigger time of the deprecated Cron node
+func legacyCron(entry map[string]any) (string, string) {
+	minute := intParam(entry, "minute", 0)
+	hour := intParam(entry, "hour", 0)
+
+	switch stringParam(entry, "mode") {
+	case "everyMinute":
+		return "* * * * *", ""
+	case "everyHour":
+		return fmt.Sprintf("%d * * * *", minute), ""
+	case "everyDay":
+		return fmt.Sprintf("%d %d * * *", minute, hour), ""
+	case "everyWeek":
+		return fmt.Sprintf("%d %d * * %d", minute, hour, intParam(entry, "weekday", 1)), ""
+	case "everyMonth":
+		return fmt.Sprintf("%d %d %d * *", minute, hour, intParam(entry, "dayOfMonth", 1)), ""
+	case "everyX":
+		if stringParam(entry, "unit") == "hours" {
+			return fmt.Sprintf("0 */%d * * *", intParam(entry, "value", 1)), ""
+		}
+		return fmt.Sprintf("*/%d * * * *", intParam(entry, "value", 1)), ""
+	case "custom":
+		return normalizeCron(stringParam(entry, "cronExpression"))
+	}
+	return "", ""
+}
+
+// normalizeCron drops the seconds field of 6-field expressions
+func normalizeCron(expression string) (string, string) {
+	fields := strings.Fields(expression)
+	switch len(fields) {
+	case 5:
+		return strings.Join(fields, " "), ""
+	case 6:
+		note := ""
+		if fields[0] != "0" {
+			note = "seconds field " + fields[0] + " ignored"
+		}
+		return strings.Join(fields[1:], " "), note
+	}
+	return "", "invalid cron expression " + expression
+}
+
+func stringParam(params map[string]any, key string) string {
+	value, _ := params[key].(string)
+	return value
+}
+
+func intParam(params map[string]any, key string, fallback int) int {
+	if value, ok := params[key]; ok {
+		if n := toInt(value); n != 0 || value == float64(0) {
+			return n
+		}
+	}
+	return fallback
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// backfillTriggers stores the triggers of a workflow version synced before
+// triggers were stored, versions with triggers are left as they are
+func backfillTriggers(app core.App, instance *Instance, record *core.Record, workflow Workflow) error {
+	count, err := app.CountRecords("triggers", dbx.HashExp{"workflow": record.Id})
+	if err != nil || count > 0 {
+		return err
+	}
+	return syncTriggers(app, instance, record, workflow)
+}
+
+// syncTriggers replaces the stored triggers of a workflow with those of
+// the synced version
+func syncTriggers(app core.App, instance *Instance, record *core.Record, workflow Workflow) error {
+	collection, err := app.FindCollectionByNameOrId("triggers")
+	if err != nil {
+		return err
+	}
+
+	return app.RunInTransaction(func(txApp core.App) error {
+		existing, err := txApp.FindAllRecords(collection,
+			dbx.HashExp{"instance": instance.Id, "workflow_id": workflow.WorkflowID})
+		if err != nil {
+			return err
+		}
+		for _, trigger := range existing {
+			if err := txApp.Delete(trigger); err != nil {
+				return err
+			}
+		}
+
+		for _, trigger := range extractTriggers(workflow) {
+			triggerRecord := core.NewRecord(collection)
+			triggerRecord.Set("workflow", record.Id)
+			triggerRecord.Set("instance", instance.Id)
+			triggerRecord.Set("workflow_id", workflow.WorkflowID)
+			triggerRecord.Set("workflow_name", workflow.Name)
+			triggerRecord.Set("workflow_active", workflow.Active)
+			triggerRecord.Set("node_id", trigger.NodeID)
+			triggerRecord.Set("node_name", trigger.NodeName)
+			triggerRecord.Set("type", trigger.Type)
+			triggerRecord.Set("kind", trigger.Kind)
+			triggerRecord.Set("disabled", trigger.Disabled)
+			triggerRecord.Set("config", trigger.Config)
+			triggerRecord.Set("cron", trigger.Cron)
+			if err := txApp.Save(triggerRecord); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
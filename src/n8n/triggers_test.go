@@ -0,0 +1,117 @@
+package n8n
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExtractTriggers(t *testing.T) {
+	raw := []byte(`{
+		"id": "w1",
+		"settings": {"timezone": "Europe/Berlin"},
+		"nodes": [
+			{"id": "1", "name": "Schedule", "type": "n8n-nodes-base.scheduleTrigger", "parameters": {"rule": {"interval": [
+				{"field": "cronExpression", "expression": "0 30 6 * * 1-5"},
+				{"field": "hours", "hoursInterval": 2, "triggerAtMinute": 15},
+				{"field": "weeks", "weeksInterval": 2, "triggerAtDay": [1, 3], "triggerAtHour": 9},
+				{"field": "seconds", "secondsInterval": 10}
+			]}}},
+			{"id": "2", "name": "Daily", "type": "n8n-nodes-base.scheduleTrigger", "parameters": {}},
+			{"id": "3", "name": "Old Cron", "type": "n8n-nodes-base.cron", "parameters": {"triggerTimes": {"item": [{"mode": "everyDay", "hour": 4}]}}},
+			{"id": "4", "name": "Redis", "type": "n8n-nodes-base.redisTrigger", "parameters": {"channels": "orders, invoices"}},
+			{"id": "5", "name": "Mail", "type": "n8n-nodes-base.emailReadImap", "parameters": {"mailbox": "INBOX"}, "credentials": {"imap": {"id": "9", "name": "Support"}}},
+			{"id": "6", "name": "Chat", "type": "@n8n/n8n-nodes-langchain.chatTrigger", "parameters": {"public": true}, "disabled": true},
+			{"id": "7", "name": "Sheets", "type": "n8n-nodes-base.googleSheetsTrigger", "parameters": {}},
+			{"id": "8", "name": "Set", "type": "n8n-nodes-base.set", "parameters": {}}
+		]
+	}`)
+
+	var workflow Workflow
+	require.NoError(t, json.Unmarshal(raw, &workflow))
+	workflow.Raw = raw
+
+	triggers := extractTriggers(workflow)
+	require.Len(t, triggers, 7)
+
+	schedule := triggers[0]
+	assert.Equal(t, TriggerSchedule, schedule.Kind)
+	assert.Equal(t, []string{"30 6 * * 1-5", "15 */2 * * *", "0 9 * * 1,3", "* * * * *"}, schedule.Cron)
+	assert.Equal(t, "Europe/Berlin", schedule.Config["timezone"])
+	assert.Len(t, schedule.Config["notes"], 2)
+
+	assert.Equal(t, []string{"0 0 */1 * *"}, triggers[1].Cron)
+	assert.Equal(t, []string{"0 4 * * *"}, triggers[2].Cron)
+
+	assert.Equal(t, TriggerRedis, triggers[3].Kind)
+	assert.Equal(t, []string{"orders", "invoices"}, triggers[3].Config["channels"])
+
+	assert.Equal(t, TriggerEmail, triggers[4].Kind)
+	assert.Equal(t, "INBOX", triggers[4].Config["mailbox"])
+	assert.Equal(t, "Support", triggers[4].Config["credential"])
+
+	assert.Equal(t, TriggerChat, triggers[5].Kind)
+	assert.True(t, triggers[5].Disabled)
+
+	assert.Equal(t, TriggerOther, triggers[6].Kind)
+}
+
+func TestScheduleCronReportsInvalidExpressions(t *testing.T) {
+	expressions, notes := scheduleCron("n8n-nodes-base.scheduleTrigger", map[string]any{"rule": map[string]any{"interval": []any{
+		map[string]any{"field": "minutes", "minutesInterval": float64(90)},
+		map[string]any{"field": "cronExpression", "expression": "70 * * * *"},
+		map[string]any{"field": "hours", "hoursInterval": float64(2)},
+	}}})
+	assert.Equal(t, []string{"0 */2 * * *"}, expressions)
+	require.Len(t, notes, 2)
+	assert.Contains(t, notes[0], "invalid cron expression */90 * * * *")
+	assert.Contains(t, notes[1], "invalid cron expression 70 * * * *")
+
+	expressions, notes = scheduleCron("n8n-nodes-base.cron", map[string]any{"triggerTimes": map[string]any{"item": []any{
+		map[string]any{"mode": "custom", "cronExpression": "every day"},
+		map[string]any{"mode": "everyX", "unit": "hours", "value": float64(36)},
+	}}})
+	assert.Empty(t, expressions)
+	assert.Equal(t, "invalid cron expression every day", notes[0])
+	assert.Contains(t, notes[1], "invalid cron expression 0 */36 * * *")
+}
+
+func TestSyncWorkflowsBackfillsTriggers(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	record := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	instance := NewInstance(record.Id, "n8n.example.com", "")
+	updatedAt := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	workflow := Workflow{
+		Name:       "Report",
+		WorkflowID: "wf1",
+		CreatedAt:  updatedAt,
+		UpdatedAt:  updatedAt,
+		Nodes:      []Node{{ID: "n1", Name: "Daily", Type: "n8n-nodes-base.scheduleTrigger"}},
+	}
+
+	// A version synced before triggers were stored
+	version := testutil.Create(t, app, "workflows", map[string]any{
+		"instance":    record.Id,
+		"workflow_id": "wf1",
+		"created_at":  updatedAt.Format(time.RFC3339),
+		"updated_at":  updatedAt.Format(time.RFC3339),
+	})
+
+	for range 2 {
+		require.NoError(t, syncWorkflows(context.Background(), app, instance, []Workflow{workflow}, zap.NewNop()))
+
+		triggers, err := app.FindAllRecords("triggers", dbx.HashExp{"workflow": version.Id})
+		require.NoError(t, err)
+		require.Len(t, triggers, 1, "the unchanged version gets its triggers once")
+		assert.Equal(t, TriggerSchedule, triggers[0].GetString("kind"))
+		assert.Equal(t, "Report", triggers[0].GetString("workflow_name"))
+	}
+}
@@ -0,0 +1,137 @@
+package n8n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// UsersCollection stores the users of the n8n instances
+const UsersCollection = "n8n_users"
+
+// User is a user of an n8n instance
+type User struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	// Role is e.g. "global:owner", "global:admin" or "global:member"
+	Role      string    `json:"role"`
+	IsPending bool      `json:"isPending"`
+	CreatedAt time.Time `json:"createdAt"`
+	// LastActiveAt is only reported by recent versions
+	LastActiveAt *time.Time `json:"lastActiveAt"`
+}
+
+// usersResponse is a page of the users list
+type usersResponse struct {
+	Data       []User `json:"data"`
+	NextCursor string `json:"nextCursor"`
+}
+
+// GetUsers retrieves all users of the instance with their role
+func (instance *Instance) GetUsers(ctx context.Context) ([]User, error) {
+	query := url.Values{}
+	query.Set("includeRole", "true")
+	query.Set("limit", "250")
+
+	var users []User
+	for {
+		req, err := instance.newRequest(ctx, "GET", "users?"+query.Encode())
+		if err != nil {
+			return nil, err
+		}
+
+		var page usersResponse
+		if err := instance.doJSON(req, &page); err != nil {
+			return nil, err
+		}
+		users = append(users, page.Data...)
+
+		if page.NextCursor == "" {
+			return users, nil
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+}
+
+// usersUnavailable reports whether err means the instance doesn't offer the
+// users API to the API key, e.g. older versions or keys without owner rights
+func usersUnavailable(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// syncUsers stores the users of an instance in n8n_users and deletes the
+// ones removed from the instance. Instances without the users API are
+// skipped.
+func syncUsers(ctx context.Context, app core.App, client N8NClient, instanceId string, logger *zap.Logger) error {
+	users, err := client.GetUsers(ctx)
+	if usersUnavailable(err) {
+		logger.Debug("Instance doesn't list users",
+			zap.String("instance", instanceId),
+			zap.Error(err))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+
+	collection, err := app.FindCollectionByNameOrId(UsersCollection)
+	if err != nil {
+		return err
+	}
+	existing, err := app.FindAllRecords(UsersCollection, dbx.HashExp{"instance": instanceId})
+	if err != nil {
+		return err
+	}
+	records := make(map[string]*core.Record, len(existing))
+	for _, record := range existing {
+		records[record.GetString("user_id")] = record
+	}
+
+	for _, user := range users {
+		record, ok := records[user.ID]
+		if !ok {
+			record = core.NewRecord(collection)
+			record.Set("instance", instanceId)
+			record.Set("user_id", user.ID)
+		}
+		delete(records, user.ID)
+
+		record.Set("email", user.Email)
+		record.Set("first_name", user.FirstName)
+		record.Set("last_name", user.LastName)
+		record.Set("role", user.Role)
+		record.Set("pending", user.IsPending)
+		record.Set("created_at", user.CreatedAt)
+		if user.LastActiveAt != nil {
+			record.Set("last_seen", *user.LastActiveAt)
+		}
+		if err := app.Save(record); err != nil {
+			return fmt.Errorf("failed to save user: %w", err)
+		}
+	}
+
+	// Users left were removed from the instance
+	for _, record := range records {
+		if err := app.Delete(record); err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package n8n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("includeRole"))
+		if r.URL.Query().Get("cursor") == "" {
+			fmt.Fprint(w, `{"data": [{"id": "1", "email": "owner@example.com", "role": "global:owner",
+				"createdAt": "2025-01-01T00:00:00.000Z", "lastActiveAt": "2025-05-01T00:00:00.000Z"}], "nextCursor": "2"}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id": "2", "email": "new@example.com", "role": "global:member", "isPending": true,
+			"createdAt": "2025-05-02T00:00:00.000Z"}], "nextCursor": null}`)
+	}))
+	defer server.Close()
+
+	users, err := NewInstance("test", server.URL, "key").GetUsers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "global:owner", users[0].Role)
+	require.NotNil(t, users[0].LastActiveAt)
+	assert.True(t, users[1].IsPending)
+	assert.Nil(t, users[1].LastActiveAt)
+}
+
+func TestUsersUnavailable(t *testing.T) {
+	assert.True(t, usersUnavailable(&StatusError{StatusCode: http.StatusForbidden}))
+	assert.True(t, usersUnavailable(fmt.Errorf("wrapped: %w", &StatusError{StatusCode: http.StatusNotFound})))
+	assert.False(t, usersUnavailable(&StatusError{StatusCode: http.StatusInternalServerError}))
+	assert.False(t, usersUnavailable(nil))
+}
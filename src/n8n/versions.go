@@ -0,0 +1,279 @@
+package n8n
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/backup"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// Sources of workflow versions
+const (
+	VersionSourceHistory = "history"
+	VersionSourceBackup  = "backup"
+)
+
+// defaultCopySuffix is appended to the name of versions restored as a copy
+const defaultCopySuffix = " (restored)"
+
+// ErrNoVersionData is returned for versions without stored workflow JSON
+var ErrNoVersionData = errors.New("no workflow data is stored for this version")
+
+// Version is a restorable version of a workflow, from the local history or
+// a backup archive
+type Version struct {
+	// ID is the id of the workflows or backups record
+	ID     string    `json:"id"`
+	Source string    `json:"source"`
+	Name   string    `json:"name"`
+	Time   time.Time `json:"time"`
+
+	// Active and Nodes are only known for versions of the local history
+	Active bool `json:"active,omitempty"`
+	Nodes  int  `json:"nodes,omitempty"`
+
+	// Location and Verification are only set for backups
+	Location     string `json:"location,omitempty"`
+	Verification string `json:"verification,omitempty"`
+}
+
+// WorkflowVersions lists the versions of the workflow of a workflows record,
+// newest first. Versions of the local history without stored JSON are left
+// out, they can't be restored.
+func WorkflowVersions(app core.App, record *core.Record) ([]Version, error) {
+	instanceID, workflowID := record.GetString("instance"), record.GetString("workflow_id")
+
+	history, err := app.FindAllRecords("workflows",
+		dbx.HashExp{"instance": instanceID, "workflow_id": workflowID}, trash.NotDeleted)
+	if err != nil {
+		return nil, err
+	}
+	versions := []Version{}
+	for _, version := range history {
+		if version.GetString("workflow_data_gz") == "" && version.GetString("workflow_data") == "" {
+			continue
+		}
+		versions = append(versions, Version{
+			ID:     version.Id,
+			Source: VersionSourceHistory,
+			Name:   version.GetString("workflow_name"),
+			Time:   version.GetDateTime("updated_at").Time(),
+			Active: version.GetBool("active"),
+			Nodes:  version.GetInt("number_of_nodes"),
+		})
+	}
+
+	backups, err := app.FindAllRecords(backup.Collection,
+		dbx.HashExp{"instance": instanceID, "workflow_id": workflowID},
+		dbx.NewExp("verification != {:failed}", dbx.Params{"failed": backup.StatusFailed}))
+	if err != nil {
+		return nil, err
+	}
+	for _, archive := range backups {
+		versions = append(versions, Version{
+			ID:           archive.Id,
+			Source:       VersionSourceBackup,
+			Name:         archive.GetString("workflow_name"),
+			Time:         archive.GetDateTime("created").Time(),
+			Location:     archive.GetString("location"),
+			Verification: archive.GetString("verification"),
+		})
+	}
+
+	sortVersions(versions)
+	return versions, nil
+}
+
+// sortVersions sorts versions newest first
+func sortVersions(versions []Version) {
+	slices.SortStableFunc(versions, func(a, b Version) int {
+		return b.Time.Compare(a.Time)
+	})
+}
+
+// copyName returns the name of a version restored as a copy, the name of
+// the version, or fallback without one, followed by suffix
+func copyName(workflow map[string]any, fallback, suffix string) string {
+	name, _ := workflow["name"].(string)
+	return cmp.Or(name, fallback) + cmp.Or(suffix, defaultCopySuffix)
+}
+
+// versionData returns the workflow JSON of the version with id, a workflows
+// record of the workflow or one of its backups
+func versionData(ctx context.Context, app core.App, record *core.Record, id string) (map[string]any, string, error) {
+	var data []byte
+	source := VersionSourceHistory
+	version, err := app.FindFirstRecordByFilter("workflows",
+		"id = {:id} && instance = {:instance} && workflow_id = {:workflow}",
+		dbx.Params{"id": id, "instance": record.GetString("instance"), "workflow": record.GetString("workflow_id")})
+	if err == nil {
+		if data, err = WorkflowData(version); err != nil {
+			return nil, "", err
+		}
+	} else {
+		source = VersionSourceBackup
+		archive, err := app.FindFirstRecordByFilter(backup.Collection,
+			"id = {:id} && instance = {:instance} && workflow_id = {:workflow}",
+			dbx.Params{"id": id, "instance": record.GetString("instance"), "workflow": record.GetString("workflow_id")})
+		if err != nil {
+			return nil, "", fmt.Errorf("version %s not found", id)
+		}
+		dest, err := backup.ForRecord(ctx, app, archive)
+		if err != nil {
+			return nil, "", err
+		}
+		if data, err = dest.Read(ctx, archive.GetString("location")); err != nil {
+			return nil, "", err
+		}
+	}
+	if data == nil {
+		return nil, "", ErrNoVersionData
+	}
+
+	var workflow map[string]any
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, "", fmt.Errorf("error decoding workflow: %w", err)
+	}
+	return workflow, source, nil
+}
+
+// VersionRestore is the body of POST /api/workflows/{id}/versions/{version}/restore
+type VersionRestore struct {
+	// Copy creates a new workflow instead of overwriting the current one
+	Copy bool `json:"copy"`
+
+	// Suffix is appended to the name of the copy, " (restored)" by default
+	Suffix string `json:"suffix"`
+}
+
+// VersionRestoreResult describes a restored version
+type VersionRestoreResult struct {
+	Instance   string `json:"instance"`
+	WorkflowID string `json:"workflow_id"`
+	Version    string `json:"version"`
+	Source     string `json:"source"`
+	Name       string `json:"name"`
+	Copy       bool   `json:"copy"`
+}
+
+// RestoreVersion pushes a version of the workflow of a workflows record back
+// to its instance, overwriting the workflow or as a copy. Archived workflows
+// can only be restored as a copy.
+func RestoreVersion(ctx context.Context, app core.App, record *core.Record, id string, options VersionRestore, actor string, logger *zap.Logger) (*VersionRestoreResult, error) {
+	result, err := restoreVersion(ctx, app, record, id, options)
+
+	entry := audit.Entry{
+		Action:   "workflow.version_restored",
+		Instance: record.GetString("instance"),
+		Actor:    actor,
+		Success:  err == nil,
+		Message:  "Restored version " + id + " of workflow " + record.GetString("workflow_name"),
+		Details: map[string]any{
+			"workflow_id": record.GetString("workflow_id"),
+			"version":     id,
+			"copy":        options.Copy,
+		},
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Details["source"] = result.Source
+		entry.Details["restored_id"] = result.WorkflowID
+	}
+	if auditErr := audit.Log(app, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+	return result, err
+}
+
+func restoreVersion(ctx context.Context, app core.App, record *core.Record, id string, options VersionRestore) (*VersionRestoreResult, error) {
+	if record.GetBool("archived") && !options.Copy {
+		return nil, ErrArchived
+	}
+
+	workflow, source, err := versionData(ctx, app, record, id)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := instanceForWorkflow(ctx, app, record)
+	if err != nil {
+		return nil, err
+	}
+	client := NewN8NClient(instance)
+
+	result := &VersionRestoreResult{
+		Instance:   instance.Id,
+		WorkflowID: record.GetString("workflow_id"),
+		Version:    id,
+		Source:     source,
+		Copy:       options.Copy,
+	}
+	if options.Copy {
+		workflow["name"] = copyName(workflow, record.GetString("workflow_name"), options.Suffix)
+		created, err := client.CreateWorkflow(ctx, workflow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create workflow: %w", err)
+		}
+		result.WorkflowID = created.WorkflowID
+	} else if err := client.UpdateWorkflow(ctx, result.WorkflowID, workflow); err != nil {
+		return nil, fmt.Errorf("failed to update workflow: %w", err)
+	}
+	result.Name, _ = workflow["name"].(string)
+	return result, nil
+}
+
+// workflowVersionsHandler lists the restorable versions of a workflow. The id
+// is the id of any workflows record of the workflow.
+func workflowVersionsHandler(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	versions, err := WorkflowVersions(e.App, record)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to load versions", err)
+	}
+	return e.JSON(http.StatusOK, versions)
+}
+
+// restoreVersionHandler pushes a version back to the instance of the workflow
+func restoreVersionHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	var options VersionRestore
+	if e.Request.ContentLength != 0 {
+		if err := e.BindBody(&options); err != nil {
+			return apis.NewBadRequestError("Invalid request body", err)
+		}
+	}
+
+	result, err := RestoreVersion(e.Request.Context(), e.App, record, e.Request.PathValue("version"), options,
+		audit.Actor(e.Auth), logger)
+	if err != nil {
+		if errors.Is(err, ErrArchived) {
+			return apis.NewBadRequestError("Workflow is archived, restore the version as a copy", nil)
+		}
+		if errors.Is(err, ErrNoVersionData) {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusBadGateway, "Restoring version failed: "+err.Error(), nil)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
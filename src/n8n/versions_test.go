@@ -0,0 +1,44 @@
+package n8n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortVersions(t *testing.T) {
+	now := time.Now()
+	versions := []Version{
+		{ID: "old", Source: VersionSourceHistory, Time: now.Add(-2 * time.Hour)},
+		{ID: "backup", Source: VersionSourceBackup, Time: now.Add(-time.Hour)},
+		{ID: "new", Source: VersionSourceHistory, Time: now},
+	}
+
+	sortVersions(versions)
+
+	ids := []string{}
+	for _, version := range versions {
+		ids = append(ids, version.ID)
+	}
+	assert.Equal(t, []string{"new", "backup", "old"}, ids)
+}
+
+func TestCopyName(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow map[string]any
+		suffix   string
+		expected string
+	}{
+		{"default suffix", map[string]any{"name": "Orders"}, "", "Orders (restored)"},
+		{"custom suffix", map[string]any{"name": "Orders"}, " v3", "Orders v3"},
+		{"no name in version", map[string]any{}, "", "Current (restored)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, copyName(tt.workflow, "Current", tt.suffix))
+		})
+	}
+}
@@ -57,6 +57,9 @@ func syncWebhooks(app core.App, instance *Instance, workflow Workflow, logger *z
 		record.Set("workflow_id", workflow.WorkflowID)
 		record.Set("notes", webhook.Notes)
 		record.Set("route", ExtractRoute(webhook.Notes))
+		record.Set("route_audience", ExtractAnnotation(webhook.Notes, "route-audience"))
+		record.Set("route_hosts", ExtractAnnotation(webhook.Notes, "route-hosts"))
+		record.Set("route_subdomain_header", ExtractAnnotation(webhook.Notes, "route-subdomain-header"))
 
 		// We need to fetch the workflow name from the database
 		// since it's not in our model anymore
@@ -84,6 +87,9 @@ func syncWebhooks(app core.App, instance *Instance, workflow Workflow, logger *z
 			record.Set("options", string(optionsJson))
 		}
 
+		// Expected response, e.g. for route documentation
+		record.Set("response", webhook.Response)
+
 		// Set authentication if available
 		if webhook.Credentials != nil {
 			record.Set("auth_type", webhook.AuthType)
@@ -136,6 +142,18 @@ func ExtractRoute(input string) string {
 	return ""
 }
 
+// ExtractAnnotation returns the trimmed value of the first line starting
+// with "<key>:", e.g. "route-audience: internal"
+func ExtractAnnotation(input, key string) string {
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		if value, found := strings.CutPrefix(strings.TrimSpace(scanner.Text()), key+":"); found {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
 // Extract webhook information from a workflow
 func extractWebhooksFromWorkflow(workflow Workflow) []Webhook {
 	var webhooks []Webhook
@@ -154,6 +172,7 @@ func extractWebhooksFromWorkflow(workflow Workflow) []Webhook {
 			AuthType: node.Parameters.Authentication,
 			Options:  node.Parameters.Options,
 			Notes:    node.Notes,
+			Response: extractWebhookResponse(workflow, node),
 			// Set essential references
 			WorkflowID: workflow.ID,
 			InstanceID: workflow.InstanceID,
@@ -0,0 +1,168 @@
+package n8n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// CheckWebhooksJob is the id of the cron job validating webhook URLs
+const CheckWebhooksJob = "check-webhooks"
+
+// WebhookCheck is the result of probing a webhook URL
+type WebhookCheck struct {
+	URL       string
+	Status    int
+	Reachable bool
+	// RouteBroken is set when a Traefik route exists for a webhook n8n doesn't serve
+	RouteBroken bool
+	Note        string
+}
+
+// webhookCheckConfig is read from the environment:
+//
+//	WEBHOOK_CHECK_SCHEDULE     cron expression, default every 15 minutes, "off" disables the check
+//	WEBHOOK_CHECK_METHOD       OPTIONS (default) or HEAD, neither executes the workflow
+//	WEBHOOK_CHECK_TRAEFIK_URL  base URL of Traefik, webhooks with a route are probed through it
+type webhookCheckConfig struct {
+	schedule   string
+	method     string
+	traefikURL string
+}
+
+func webhookCheckConfigFromEnv() webhookCheckConfig {
+	config := webhookCheckConfig{
+		schedule:   os.Getenv("WEBHOOK_CHECK_SCHEDULE"),
+		method:     strings.ToUpper(os.Getenv("WEBHOOK_CHECK_METHOD")),
+		traefikURL: strings.TrimRight(os.Getenv("WEBHOOK_CHECK_TRAEFIK_URL"), "/"),
+	}
+	if config.schedule == "" {
+		config.schedule = "*/15 * * * *"
+	}
+	if config.method != http.MethodHead {
+		config.method = http.MethodOptions
+	}
+	return config
+}
+
+// initWebhookCheckCron periodically probes all stored webhook URLs
+func initWebhookCheckCron(app core.App, logger *zap.Logger) {
+	config := webhookCheckConfigFromEnv()
+	if config.schedule == "off" {
+		return
+	}
+
+	app.Cron().MustAdd(CheckWebhooksJob, config.schedule, func() {
+		defer errorreport.Recover(logger, zap.String("job", CheckWebhooksJob))
+
+		// Webhooks are expected to fail while everything is down
+		if maintenance.Enabled(app, logger) {
+			return
+		}
+
+		// Webhooks of instances in the trash aren't routed anymore
+		webhooks, err := app.FindAllRecords("webhooks", trash.InstanceNotDeleted("instance"))
+		if err != nil {
+			logger.Error("Failed to fetch webhooks", zap.Error(err))
+			return
+		}
+
+		client := &http.Client{
+			Timeout: 10 * time.Second,
+			// A redirect means something answered, don't follow it
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+
+		for _, record := range webhooks {
+			check := checkWebhook(context.Background(), client, config, record)
+
+			record.Set("reachable", check.Reachable)
+			record.Set("http_status", check.Status)
+			record.Set("route_broken", check.RouteBroken)
+			record.Set("check_note", check.Note)
+			record.Set("checked_at", time.Now())
+			if err := app.Save(record); err != nil {
+				logger.Error("Failed to save webhook check",
+					zap.Error(err),
+					zap.String("webhook", record.Id))
+				continue
+			}
+
+			if check.RouteBroken {
+				logger.Warn("Webhook route points to an unregistered webhook",
+					zap.String("webhook", record.Id),
+					zap.String("workflow", record.GetString("workflow_id")),
+					zap.String("url", check.URL))
+			}
+		}
+	})
+}
+
+// checkWebhook probes a webhook with a request that doesn't execute the
+// workflow. n8n answers 404 for webhooks of inactive workflows.
+func checkWebhook(ctx context.Context, client *http.Client, config webhookCheckConfig, record *core.Record) WebhookCheck {
+	check := WebhookCheck{URL: record.GetString("webhook_url")}
+	route := record.GetString("route")
+
+	target, err := url.Parse(check.URL)
+	if err != nil {
+		check.Note = "invalid webhook URL: " + err.Error()
+		return check
+	}
+
+	host := ""
+	if route != "" && config.traefikURL != "" {
+		// Go through Traefik with the host and the public path of the route
+		// annotation, which exposes the webhook at its own path without one
+		annotation := strings.TrimPrefix(strings.TrimPrefix(route, "https://"), "http://")
+		var path string
+		host, path, _ = strings.Cut(annotation, "/")
+		if path != "" {
+			check.URL = config.traefikURL + "/" + path
+		} else {
+			check.URL = config.traefikURL + target.Path
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, config.method, check.URL, nil)
+	if err != nil {
+		check.Note = err.Error()
+		return check
+	}
+	if host != "" {
+		req.Host = host
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Note = "request failed: " + err.Error()
+		return check
+	}
+	resp.Body.Close()
+
+	check.Status = resp.StatusCode
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		check.Note = "webhook is not registered, the workflow is probably inactive"
+		check.RouteBroken = route != ""
+	case resp.StatusCode >= http.StatusInternalServerError:
+		check.Note = fmt.Sprintf("server error %d", resp.StatusCode)
+	default:
+		// OPTIONS and HEAD may be answered with 405 by a registered webhook
+		check.Reachable = true
+	}
+
+	return check
+}
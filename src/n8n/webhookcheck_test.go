@@ -0,0 +1,70 @@
+package n8n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckWebhook(t *testing.T) {
+	var hosts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		if r.Method != http.MethodOptions {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		// Traefik exposes the orders webhook at /orders too
+		if r.URL.Path == "/webhook/orders" || r.URL.Path == "/orders" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	collection := core.NewBaseCollection("webhooks")
+	collection.Fields.Add(&core.TextField{Name: "webhook_url"}, &core.TextField{Name: "route"})
+	newWebhook := func(path, route string) *core.Record {
+		record := core.NewRecord(collection)
+		record.Set("webhook_url", "http://n8n.internal:5678"+path)
+		record.Set("route", route)
+		return record
+	}
+
+	config := webhookCheckConfig{method: http.MethodOptions, traefikURL: server.URL}
+
+	check := checkWebhook(context.Background(), server.Client(), config, newWebhook("/webhook/orders", "api.example.com"))
+	assert.True(t, check.Reachable)
+	assert.Equal(t, http.StatusNoContent, check.Status)
+	assert.False(t, check.RouteBroken)
+	assert.Equal(t, "api.example.com", hosts[0])
+	assert.Equal(t, server.URL+"/webhook/orders", check.URL)
+
+	// Annotations with a path are probed at the public path
+	check = checkWebhook(context.Background(), server.Client(), config, newWebhook("/webhook/orders", "https://api.example.com/orders"))
+	assert.True(t, check.Reachable)
+	assert.Equal(t, server.URL+"/orders", check.URL)
+
+	check = checkWebhook(context.Background(), server.Client(), config, newWebhook("/webhook/inactive", "api.example.com/inactive"))
+	assert.False(t, check.Reachable)
+	assert.True(t, check.RouteBroken)
+	assert.Equal(t, server.URL+"/inactive", check.URL)
+
+	// Without a route the webhook URL is probed directly
+	record := newWebhook("/webhook/orders", "")
+	record.Set("webhook_url", server.URL+"/webhook/orders")
+	check = checkWebhook(context.Background(), server.Client(), config, record)
+	assert.True(t, check.Reachable)
+	assert.Equal(t, server.URL+"/webhook/orders", check.URL)
+
+	// HEAD answered with 405 still means n8n knows the webhook
+	config.method = http.MethodHead
+	check = checkWebhook(context.Background(), server.Client(), config, record)
+	assert.True(t, check.Reachable)
+	assert.Equal(t, http.StatusMethodNotAllowed, check.Status)
+}
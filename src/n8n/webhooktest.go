@@ -0,0 +1,145 @@
+package n8n
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// maxTestResponseBody is the number of response body bytes returned by a webhook test
+const maxTestResponseBody = 64 << 10
+
+// WebhookTestRequest is the body of POST /api/webhooks/{id}/test
+type WebhookTestRequest struct {
+	// Production calls the production URL, by default the test URL
+	// (/webhook-test/...) is used, which needs the workflow open in the editor
+	Production bool              `json:"production"`
+	Method     string            `json:"method"`
+	Headers    map[string]string `json:"headers"`
+	Query      map[string]string `json:"query"`
+	Payload    json.RawMessage   `json:"payload"`
+}
+
+// WebhookTestResult is the response of the called webhook
+type WebhookTestResult struct {
+	URL        string              `json:"url"`
+	Method     string              `json:"method"`
+	Status     int                 `json:"status"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`
+	Truncated  bool                `json:"truncated"`
+	DurationMs int64               `json:"duration_ms"`
+}
+
+// testURL returns the URL of the n8n test webhook for a production webhook URL
+func testURL(webhookURL string) string {
+	return strings.Replace(webhookURL, "/webhook/", "/webhook-test/", 1)
+}
+
+// webhookTestHandler sends a payload to a webhook and returns its response
+func webhookTestHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	record, err := e.App.FindRecordById("webhooks", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Webhook not found", err)
+	}
+
+	var body WebhookTestRequest
+	if err := e.BindBody(&body); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+
+	target := record.GetString("webhook_url")
+	if !body.Production {
+		target = testURL(target)
+	}
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return apis.NewBadRequestError("Invalid webhook URL", err)
+	}
+	query := parsed.Query()
+	for key, value := range body.Query {
+		query.Set(key, value)
+	}
+	parsed.RawQuery = query.Encode()
+
+	method := strings.ToUpper(body.Method)
+	if method == "" {
+		var methods []string
+		record.UnmarshalJSONField("methods", &methods)
+		method = http.MethodPost
+		if len(methods) > 0 && methods[0] != "" {
+			method = strings.ToUpper(methods[0])
+		}
+	}
+
+	var payload io.Reader
+	if len(body.Payload) > 0 && method != http.MethodGet && method != http.MethodHead {
+		payload = bytes.NewReader(body.Payload)
+	}
+
+	req, err := http.NewRequestWithContext(e.Request.Context(), method, parsed.String(), payload)
+	if err != nil {
+		return apis.NewBadRequestError("Invalid request", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range body.Headers {
+		req.Header.Set(key, value)
+	}
+
+	result := WebhookTestResult{URL: parsed.String(), Method: method}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	started := time.Now()
+	resp, err := client.Do(req)
+	result.DurationMs = time.Since(started).Milliseconds()
+
+	entry := audit.Entry{
+		Action:   "webhook.tested",
+		Instance: record.GetString("instance"),
+		Actor:    audit.Actor(e.Auth),
+		Success:  err == nil,
+		Details: map[string]any{
+			"webhook":    record.Id,
+			"production": body.Production,
+		},
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Message = "Webhook test returned " + resp.Status
+	}
+	if auditErr := audit.Log(e.App, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Webhook request failed: "+err.Error(), nil)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTestResponseBody+1))
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Failed to read webhook response: "+err.Error(), nil)
+	}
+	if len(data) > maxTestResponseBody {
+		data = data[:maxTestResponseBody]
+		result.Truncated = true
+	}
+
+	result.Status = resp.StatusCode
+	result.Headers = resp.Header
+	result.Body = string(data)
+
+	return e.JSON(http.StatusOK, result)
+}
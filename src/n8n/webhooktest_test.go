@@ -0,0 +1,90 @@
+package n8n
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTestURL(t *testing.T) {
+	assert.Equal(t, "https://n8n.example.com/webhook-test/orders", testURL("https://n8n.example.com/webhook/orders"))
+	assert.Equal(t, "https://n8n.example.com/hooks/orders", testURL("https://n8n.example.com/hooks/orders"))
+}
+
+func TestWebhookTestHandler(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	var received *http.Request
+	var receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received, receivedBody = r, string(data)
+		w.Header().Set("X-Execution", "42")
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, strings.Repeat("x", maxTestResponseBody+10))
+	}))
+	defer server.Close()
+
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": server.URL})
+	webhook := testutil.Create(t, app, "webhooks", map[string]any{
+		"instance":    instance.Id,
+		"webhook_url": server.URL + "/webhook/orders",
+		"methods":     []string{"put"},
+	})
+
+	call := func(id, body string) (*httptest.ResponseRecorder, error) {
+		e := &core.RequestEvent{}
+		e.App = app
+		e.Request = httptest.NewRequest(http.MethodPost, "/api/webhooks/"+id+"/test", strings.NewReader(body))
+		e.Request.Header.Set("Content-Type", "application/json")
+		e.Request.SetPathValue("id", id)
+		recorder := httptest.NewRecorder()
+		e.Response = recorder
+		return recorder, webhookTestHandler(e, zap.NewNop())
+	}
+
+	recorder, err := call(webhook.Id, `{"query": {"dry": "1"}, "headers": {"X-Test": "yes"}, "payload": {"order": 7}}`)
+	require.NoError(t, err)
+
+	// The test URL is called with the first method of the webhook
+	assert.Equal(t, "/webhook-test/orders", received.URL.Path)
+	assert.Equal(t, http.MethodPut, received.Method)
+	assert.Equal(t, "1", received.URL.Query().Get("dry"))
+	assert.Equal(t, "yes", received.Header.Get("X-Test"))
+	assert.JSONEq(t, `{"order": 7}`, receivedBody)
+
+	var result WebhookTestResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Equal(t, http.StatusAccepted, result.Status)
+	assert.Equal(t, []string{"42"}, result.Headers["X-Execution"])
+	assert.Len(t, result.Body, maxTestResponseBody)
+	assert.True(t, result.Truncated)
+
+	// Production calls the webhook URL, GET requests have no body
+	_, err = call(webhook.Id, `{"production": true, "method": "get", "payload": {"order": 7}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "/webhook/orders", received.URL.Path)
+	assert.Equal(t, http.MethodGet, received.Method)
+	assert.Empty(t, receivedBody)
+
+	tested, err := app.CountRecords(audit.Collection, dbx.HashExp{"action": "webhook.tested", "instance": instance.Id})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, tested)
+
+	_, err = call("missing", `{}`)
+	var apiErr *router.ApiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+}
@@ -1,6 +1,7 @@
 package n8n
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -9,17 +10,29 @@ import (
 
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/tracing"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // syncWorkflows synchronizes workflows from an n8n instance to the PocketBase database
-func syncWorkflows(app core.App, instance *Instance, workflows []Workflow, logger *zap.Logger) error {
+func syncWorkflows(ctx context.Context, app core.App, instance *Instance, workflows []Workflow, logger *zap.Logger) (err error) {
+	_, span := tracer.Start(ctx, "syncWorkflows", trace.WithAttributes(
+		attribute.String("n8n.instance.id", instance.Id),
+		attribute.Int("n8n.workflows.count", len(workflows)),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	collection, err := app.FindCollectionByNameOrId("workflows")
 	if err != nil {
 		return fmt.Errorf("failed to find workflows collection: %w", err)
 	}
 
-	for _, workflow := range workflows {
+	for i, workflow := range workflows {
+		publishProgress(ctx, app, instance.Id, PhaseSyncing, i, len(workflows), nil)
+
 		logger.Info("Processing workflow",
 			zap.String("name", workflow.Name),
 			zap.String("id", workflow.WorkflowID),
@@ -46,6 +59,15 @@ func syncWorkflows(app core.App, instance *Instance, workflows []Workflow, logge
 		if err == nil && len(existingRecords) > 0 {
 			logger.Debug("Found existing record for workflow, checking timestamps")
 			existing := existingRecords[0]
+
+			// Deleting the workflow in the manager moved it to the trash,
+			// a new version would bring it back
+			if trash.Deleted(existing) {
+				logger.Debug("Workflow is in the trash, skipping",
+					zap.String("workflow", workflow.WorkflowID))
+				continue
+			}
+
 			existingCreatedAt := existing.GetString("created_at")
 			existingUpdatedAt := existing.GetString("updated_at")
 			existingActive := existing.GetBool("active")
@@ -66,6 +88,29 @@ func syncWorkflows(app core.App, instance *Instance, workflows []Workflow, logge
 				logger.Debug("Workflow unchanged, skipping",
 					zap.String("workflow", workflow.WorkflowID))
 				needsUpdate = false
+
+				// Versions stored before the error handling, lint findings,
+				// nodes and triggers were tracked get them on the next full
+				// sync
+				changed := setErrorHandling(existing, workflowErrorHandling(workflow))
+				changed = setLintFindings(existing, lintWorkflow(workflow)) || changed
+				if changed {
+					if err := app.Save(existing); err != nil {
+						logger.Warn("Failed to store workflow checks",
+							zap.String("workflow", workflow.WorkflowID),
+							zap.Error(err))
+					}
+				}
+				if err := backfillNodes(app, instance, existing, workflow); err != nil {
+					logger.Error("Failed to backfill workflow nodes",
+						zap.Error(err),
+						zap.String("workflow", workflow.WorkflowID))
+				}
+				if err := backfillTriggers(app, instance, existing, workflow); err != nil {
+					logger.Error("Failed to backfill triggers",
+						zap.Error(err),
+						zap.String("workflow", workflow.WorkflowID))
+				}
 			} else {
 				logger.Debug("Workflow changed, updating",
 					zap.String("workflow", workflow.WorkflowID),
@@ -79,6 +124,14 @@ func syncWorkflows(app core.App, instance *Instance, workflows []Workflow, logge
 		if needsUpdate {
 			// Create a new workflow record
 			record := createWorkflowRecord(collection, instance, workflow)
+
+			// Store the workflow data as configured for the instance
+			if err := setWorkflowData(record, workflowData(workflow, instance.StoreMode)); err != nil {
+				logger.Warn("Workflow data not stored",
+					zap.String("workflow", workflow.WorkflowID),
+					zap.Error(err))
+			}
+
 			if err := app.Save(record); err != nil {
 				logger.Error("Failed to save workflow",
 					zap.String("workflow", workflow.WorkflowID),
@@ -86,6 +139,18 @@ func syncWorkflows(app core.App, instance *Instance, workflows []Workflow, logge
 				continue
 			}
 
+			if err := syncNodes(app, instance, record, workflow); err != nil {
+				logger.Error("Failed to sync workflow nodes",
+					zap.Error(err),
+					zap.String("workflow", workflow.WorkflowID))
+			}
+
+			if err := syncTriggers(app, instance, record, workflow); err != nil {
+				logger.Error("Failed to sync triggers",
+					zap.Error(err),
+					zap.String("workflow", workflow.WorkflowID))
+			}
+
 			// Sync webhooks to the database
 			logger.Debug("Sync webhooks to database")
 			if err := syncWebhooks(app, instance, workflow, logger); err != nil {
@@ -103,6 +168,29 @@ func syncWorkflows(app core.App, instance *Instance, workflows []Workflow, logge
 	return nil
 }
 
+// latestWorkflows returns the newest version of every workflow of an
+// instance that is neither archived nor in the trash. Records are versions,
+// only the newest one of each workflow counts.
+func latestWorkflows(app core.App, instanceID string) ([]*core.Record, error) {
+	records, err := app.FindRecordsByFilter("workflows",
+		"instance = {:instance} && archived = false && deleted_at = ''", "-updated_at", 0, 0,
+		dbx.Params{"instance": instanceID})
+	if err != nil {
+		return nil, err
+	}
+
+	latest := records[:0]
+	seen := map[string]bool{}
+	for _, record := range records {
+		workflowID := record.GetString("workflow_id")
+		if !seen[workflowID] {
+			seen[workflowID] = true
+			latest = append(latest, record)
+		}
+	}
+	return latest, nil
+}
+
 // createWorkflowRecord creates a new record in the workflows collection
 func createWorkflowRecord(
 	collection *core.Collection,
@@ -124,13 +212,13 @@ func createWorkflowRecord(
 	// Set node information
 	record.Set("nodes", strings.Join(getNodeNames(workflow.Nodes), ","))
 
-	// Store the full workflow data
-	workflowData, _ := json.Marshal(workflow)
-	record.Set("workflow_data", string(workflowData))
 	record.Set("active", workflow.Active)
 
-	t, _ := json.Marshal(workflow)
-	fmt.Println(string(t))
+	// Store what the workflow needs from the instance, e.g. for migrations
+	record.Set("dependencies", ScanDependencies(workflow.Raw))
+
+	setErrorHandling(record, workflowErrorHandling(workflow))
+	setLintFindings(record, lintWorkflow(workflow))
 
 	return record
 }
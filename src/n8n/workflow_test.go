@@ -0,0 +1,45 @@
+package n8n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestWorkflows(t *testing.T) {
+	app := testutil.NewApp(t)
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	other := testutil.Create(t, app, "instances", map[string]any{"host": "other.example.com"})
+	updated := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	version := func(instanceID, workflowID string, age time.Duration, values map[string]any) *core.Record {
+		fields := map[string]any{
+			"instance":    instanceID,
+			"workflow_id": workflowID,
+			"updated_at":  updated.Add(-age).Format(time.RFC3339),
+		}
+		for field, value := range values {
+			fields[field] = value
+		}
+		return testutil.Create(t, app, "workflows", fields)
+	}
+
+	version(instance.Id, "wf1", time.Hour, nil)
+	newest := version(instance.Id, "wf1", 0, nil)
+	version(instance.Id, "wf1", 2*time.Hour, nil)
+	single := version(instance.Id, "wf2", 0, nil)
+	version(instance.Id, "wf3", 0, map[string]any{"archived": true})
+	version(instance.Id, "wf4", 0, map[string]any{"deleted_at": updated})
+	version(other.Id, "wf5", 0, nil)
+
+	records, err := latestWorkflows(app, instance.Id)
+	require.NoError(t, err)
+	ids := []string{}
+	for _, record := range records {
+		ids = append(ids, record.Id)
+	}
+	assert.ElementsMatch(t, []string{newest.Id, single.Id}, ids)
+}
@@ -0,0 +1,114 @@
+package n8n
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/redact"
+)
+
+// defaultMaxWorkflowDataSize is the default limit for the uncompressed
+// workflow JSON, configurable with WORKFLOW_DATA_MAX_SIZE (bytes)
+const defaultMaxWorkflowDataSize = 5 << 20
+
+// maxWorkflowDataSize returns the configured workflow JSON size limit
+func maxWorkflowDataSize() int {
+	if value, err := strconv.Atoi(os.Getenv("WORKFLOW_DATA_MAX_SIZE")); err == nil && value > 0 {
+		return value
+	}
+	return defaultMaxWorkflowDataSize
+}
+
+// setWorkflowData stores data gzip-compressed in workflow_data_gz. Data over
+// the size limit is not stored, only its size is recorded.
+func setWorkflowData(record *core.Record, data []byte) error {
+	record.Set("data_size", len(data))
+	if len(data) == 0 {
+		return nil
+	}
+
+	if limit := maxWorkflowDataSize(); len(data) > limit {
+		return fmt.Errorf("workflow JSON is %d bytes, the limit is %d", len(data), limit)
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	record.Set("workflow_data_gz", encoded)
+	record.Set("data_size_stored", len(encoded))
+	return nil
+}
+
+// WorkflowData returns the stored workflow JSON of a workflows record, or
+// nil if none is stored. Records synced before compression was introduced
+// keep their data in workflow_data.
+func WorkflowData(record *core.Record) ([]byte, error) {
+	encoded := record.GetString("workflow_data_gz")
+	if encoded == "" {
+		raw := record.GetString("workflow_data")
+		if raw == "" || raw == "null" {
+			return nil, nil
+		}
+		return []byte(raw), nil
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding workflow data: %w", err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing workflow data: %w", err)
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// instanceStorageBytes returns the stored size of all workflow versions of an instance
+func instanceStorageBytes(app core.App, instanceID string) (int64, error) {
+	var total struct {
+		Bytes int64 `db:"bytes"`
+	}
+	err := app.DB().
+		Select("COALESCE(SUM(data_size_stored), 0) AS bytes").
+		From("workflows").
+		Where(dbx.HashExp{"instance": instanceID}).
+		One(&total)
+	return total.Bytes, err
+}
+
+// workflowDataHandler returns the decompressed workflow JSON of a workflows record
+func workflowDataHandler(e *core.RequestEvent) error {
+	record, err := e.App.FindRecordById("workflows", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Workflow not found", err)
+	}
+
+	data, err := WorkflowData(record)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to read workflow data", err)
+	}
+	if data == nil {
+		return apis.NewNotFoundError("No workflow data stored for this version", nil)
+	}
+
+	// Mask credentials like the record API does for workflow_data
+	return e.Blob(http.StatusOK, "application/json", redact.JSON(data))
+}
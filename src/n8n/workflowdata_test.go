@@ -0,0 +1,56 @@
+package n8n
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWorkflowsCollection() *core.Collection {
+	collection := core.NewBaseCollection("workflows")
+	collection.Fields.Add(
+		&core.JSONField{Name: "workflow_data"},
+		&core.TextField{Name: "workflow_data_gz"},
+		&core.NumberField{Name: "data_size"},
+		&core.NumberField{Name: "data_size_stored"},
+	)
+	return collection
+}
+
+func TestWorkflowDataCompression(t *testing.T) {
+	record := core.NewRecord(newWorkflowsCollection())
+	data := []byte(`{"name":"Orders","nodes":[{"name":"Set","parameters":{}},{"name":"Set","parameters":{}}]}`)
+
+	require.NoError(t, setWorkflowData(record, data))
+	assert.Equal(t, len(data), record.GetInt("data_size"))
+	assert.NotEmpty(t, record.GetString("workflow_data_gz"))
+
+	stored, err := WorkflowData(record)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(stored))
+}
+
+func TestWorkflowDataLimit(t *testing.T) {
+	t.Setenv("WORKFLOW_DATA_MAX_SIZE", "10")
+
+	record := core.NewRecord(newWorkflowsCollection())
+	err := setWorkflowData(record, []byte(`{"name":"too large"}`))
+	assert.ErrorContains(t, err, "limit is 10")
+	assert.Equal(t, 20, record.GetInt("data_size"))
+	assert.Empty(t, record.GetString("workflow_data_gz"))
+
+	stored, err := WorkflowData(record)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestWorkflowDataUncompressedFallback(t *testing.T) {
+	record := core.NewRecord(newWorkflowsCollection())
+	record.Set("workflow_data", `{"name":"legacy"}`)
+
+	stored, err := WorkflowData(record)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"legacy"}`, string(stored))
+}
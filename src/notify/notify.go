@@ -0,0 +1,96 @@
+// Package notify sends alerts of the manager to the people operating it. It
+// is enabled by setting NOTIFY_WEBHOOK_URL, notifications are posted to it
+// as JSON. The "text" field makes the payload work with Slack, Mattermost
+// and Rocket.Chat incoming webhooks, other receivers (e.g. an n8n workflow)
+// can use the structured fields.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Severities of notifications
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// sendTimeout bounds delivering a notification
+const sendTimeout = 10 * time.Second
+
+var client = &http.Client{Timeout: sendTimeout}
+
+// Notification is an alert sent to NOTIFY_WEBHOOK_URL
+type Notification struct {
+	// Event identifies the kind of notification, e.g. "slo.burn_rate"
+	Event    string `json:"event"`
+	Severity string `json:"severity"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+
+	// Resolved is set when the condition alerted about is over
+	Resolved bool `json:"resolved,omitempty"`
+
+	// Fields hold details such as the affected route
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// payload is the body posted to the webhook
+type payload struct {
+	Notification
+	Text string    `json:"text"`
+	Time time.Time `json:"time"`
+}
+
+// Enabled reports whether notifications are configured
+func Enabled() bool {
+	return os.Getenv("NOTIFY_WEBHOOK_URL") != ""
+}
+
+// Send posts n to NOTIFY_WEBHOOK_URL, it does nothing if notifications
+// aren't configured
+func Send(ctx context.Context, n Notification) error {
+	if !Enabled() {
+		return nil
+	}
+	return send(ctx, os.Getenv("NOTIFY_WEBHOOK_URL"), n, time.Now())
+}
+
+func send(ctx context.Context, url string, n Notification, now time.Time) error {
+	body, err := json.Marshal(payload{Notification: n, Text: n.text(), Time: now.UTC()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// text returns n as a single message for chat webhooks
+func (n Notification) text() string {
+	prefix := "[" + n.Severity + "]"
+	if n.Resolved {
+		prefix = "[resolved]"
+	}
+	return prefix + " " + n.Title + "\n" + n.Message
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	err := send(context.Background(), server.URL, Notification{
+		Event:    "slo.burn_rate",
+		Severity: SeverityCritical,
+		Title:    "SLO of api.example.com/orders is burning its error budget",
+		Message:  "95% of requests within 500ms",
+		Fields:   map[string]any{"route": "r1"},
+	}, time.Date(2025, 5, 2, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, "[critical] SLO of api.example.com/orders is burning its error budget\n95% of requests within 500ms", received["text"])
+	assert.Equal(t, "slo.burn_rate", received["event"])
+	assert.Equal(t, "2025-05-02T12:00:00Z", received["time"])
+	assert.Equal(t, map[string]any{"route": "r1"}, received["fields"])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	assert.Error(t, send(context.Background(), failing.URL, Notification{Title: "test"}, time.Now()))
+}
@@ -0,0 +1,165 @@
+// Package oidc verifies OAuth2/OIDC bearer tokens (JWTs) of a configured
+// issuer, checking their signature against the issuer's JWKS as well as
+// their expiry, audience and scopes.
+//
+// The issuer is set with OIDC_ISSUER (e.g. "https://login.example.com/realms/n8n").
+// Its keys are read from OIDC_JWKS_URL, or from the jwks_uri of the issuer's
+// discovery document if unset.
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// keysTTL is how long fetched keys are used before they are refetched
+	keysTTL = 10 * time.Minute
+
+	// refetchInterval limits refetching the keys for unknown key ids, which
+	// anyone can put into a token
+	refetchInterval = 30 * time.Second
+
+	// leeway tolerates clock skew between the issuer and the manager
+	leeway = 30 * time.Second
+)
+
+// ErrInsufficientScope is returned for valid tokens lacking a required scope
+var ErrInsufficientScope = errors.New("token lacks a required scope")
+
+// signingMethods are the accepted token algorithms, none of them symmetric
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Claims are the claims of a verified token the verifier exposes
+type Claims struct {
+	Subject string
+	Scopes  []string
+}
+
+// Verifier verifies the tokens of a single issuer
+type Verifier struct {
+	issuer  string
+	jwksURL string
+	client  *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+// NewVerifier returns a verifier for the tokens of issuer. The keys are
+// fetched from jwksURL, or from the jwks_uri of the issuer's discovery
+// document if empty.
+func NewVerifier(issuer, jwksURL string) *Verifier {
+	return &Verifier{
+		issuer:  issuer,
+		jwksURL: jwksURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// FromEnv returns the verifier configured with OIDC_ISSUER and OIDC_JWKS_URL
+func FromEnv() (*Verifier, error) {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil, errors.New("OIDC_ISSUER must be set")
+	}
+	return NewVerifier(issuer, os.Getenv("OIDC_JWKS_URL")), nil
+}
+
+// Verify checks token and returns its claims. An empty audience skips the
+// audience check, every scope in scopes must be granted by the token.
+func (v *Verifier) Verify(ctx context.Context, token, audience string, scopes []string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.NewParser(options...).ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims.GetSubject()
+	verified := &Claims{Subject: subject, Scopes: grantedScopes(claims)}
+	for _, scope := range scopes {
+		if !slices.Contains(verified.Scopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInsufficientScope, scope)
+		}
+	}
+	return verified, nil
+}
+
+// grantedScopes returns the scopes of a token, granted as space separated
+// "scope" claim or as "scp" claim (a list or space separated)
+func grantedScopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	switch scp := claims["scp"].(type) {
+	case string:
+		return strings.Fields(scp)
+	case []any:
+		scopes := make([]string, 0, len(scp))
+		for _, scope := range scp {
+			if scope, ok := scope.(string); ok {
+				scopes = append(scopes, scope)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
+
+// key returns the public key with id kid, an empty kid selects the only
+// key of the set. The keys are refetched once expired or if kid is unknown.
+func (v *Verifier) key(ctx context.Context, kid string) (any, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := time.Since(v.fetchedAt)
+	if key, ok := v.lookup(kid); ok && age < keysTTL {
+		return key, nil
+	}
+	if age >= refetchInterval {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v.keys, v.fetchedAt = keys, time.Now()
+	}
+
+	if key, ok := v.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup returns the cached key with id kid
+func (v *Verifier) lookup(kid string) (any, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
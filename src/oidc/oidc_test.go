@@ -0,0 +1,114 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIssuer serves a discovery document and the JWKS of an RSA and an EC key
+func testIssuer(t *testing.T) (*httptest.Server, *rsa.PrivateKey, *ecdsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encode := func(i *big.Int, size int) string {
+		return base64.RawURLEncoding.EncodeToString(i.FillBytes(make([]byte, size)))
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/keys"})
+		case "/keys":
+			json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+				{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encode(rsaKey.N, rsaKey.Size()), "e": "AQAB"},
+				{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X, 32), "y": encode(ecKey.Y, 32)},
+				{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, rsaKey, ecKey
+}
+
+func TestVerify(t *testing.T) {
+	server, rsaKey, ecKey := testIssuer(t)
+	verifier := NewVerifier(server.URL, "")
+
+	sign := func(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+	claims := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"iss":   server.URL,
+			"sub":   "client-1",
+			"aud":   "n8n-hooks",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "hooks:read hooks:write",
+		}
+		// nil values remove a claim
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		audience string
+		scopes   []string
+		err      error
+	}{
+		{"rsa", sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims(nil)), "n8n-hooks", []string{"hooks:write"}, nil},
+		{"ec", sign(jwt.SigningMethodES256, "ec", ecKey, claims(nil)), "n8n-hooks", nil, nil},
+		{"scp claim", sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims(jwt.MapClaims{"scope": nil, "scp": []string{"hooks:write"}})), "", []string{"hooks:write"}, nil},
+		{"any audience", sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims(nil)), "", nil, nil},
+		{"wrong audience", sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims(nil)), "other", nil, jwt.ErrTokenInvalidAudience},
+		{"missing scope", sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims(nil)), "", []string{"hooks:admin"}, ErrInsufficientScope},
+		{"expired", sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})), "", nil, jwt.ErrTokenExpired},
+		{"no expiry", sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims(jwt.MapClaims{"exp": nil})), "", nil, jwt.ErrTokenRequiredClaimMissing},
+		{"wrong issuer", sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims(jwt.MapClaims{"iss": "https://evil.example.com"})), "", nil, jwt.ErrTokenInvalidIssuer},
+		{"symmetric", sign(jwt.SigningMethodHS256, "hmac", []byte("secret"), claims(nil)), "", nil, jwt.ErrTokenSignatureInvalid},
+		{"unknown key", sign(jwt.SigningMethodRS256, "other", rsaKey, claims(nil)), "", nil, jwt.ErrTokenUnverifiable},
+		{"garbage", "not-a-token", "", nil, jwt.ErrTokenMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verified, err := verifier.Verify(context.Background(), tt.token, tt.audience, tt.scopes)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "client-1", verified.Subject)
+		})
+	}
+}
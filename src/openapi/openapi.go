@@ -0,0 +1,328 @@
+// Package openapi imports the operations of OpenAPI documents as routes to
+// n8n webhooks. Each path becomes one route; n8n dispatches the methods of
+// a webhook path itself, so all operations of a path must map to the same
+// webhook.
+//
+// Operations map to the webhook whose n8n path equals their operationId,
+// unless the import's mapping or an "x-n8n-webhook" extension of the
+// operation names another webhook path. Path parameters and declared query
+// parameters are forwarded to n8n as headers.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"gopkg.in/yaml.v3"
+)
+
+// WebhookPrefix is the path n8n serves production webhooks under
+const WebhookPrefix = "/webhook/"
+
+// methods are the operations of a path item, in the order they're imported
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// pathParam matches the parameters of path templates, e.g. "{userId}"
+var pathParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// paramName matches the parameter names Traefik header templates can reference
+var paramName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Parameter is a parameter of an operation or path item
+type Parameter struct {
+	Ref  string `yaml:"$ref"`
+	Name string `yaml:"name"`
+	In   string `yaml:"in"`
+}
+
+// Operation is a single method of a path
+type Operation struct {
+	OperationID string      `yaml:"operationId"`
+	Parameters  []Parameter `yaml:"parameters"`
+
+	// Webhook optionally names the n8n webhook path of the operation
+	Webhook string `yaml:"x-n8n-webhook"`
+}
+
+// Document is the part of an OpenAPI 3 or Swagger 2 document routes are
+// imported from
+type Document struct {
+	// Servers of OpenAPI 3, the first one sets the host and base path
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+
+	// Host and BasePath of Swagger 2
+	Host     string `yaml:"host"`
+	BasePath string `yaml:"basePath"`
+
+	Paths map[string]map[string]yaml.Node `yaml:"paths"`
+
+	// root is the whole document, $ref pointers are resolved in it
+	root map[string]any
+}
+
+// Parse reads an OpenAPI document in JSON or YAML
+func Parse(data []byte) (*Document, error) {
+	// JSON is valid YAML, but may be indented with tabs, which YAML rejects
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var root any
+		if err := json.Unmarshal(trimmed, &root); err != nil {
+			return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+		}
+		var err error
+		if data, err = yaml.Marshal(root); err != nil {
+			return nil, err
+		}
+	}
+
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc.root); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if len(doc.Paths) == 0 {
+		return nil, errors.New("the OpenAPI document has no paths")
+	}
+	return &doc, nil
+}
+
+// Options configure an import
+type Options struct {
+	// Host the routes are exposed under, the host of the document's first
+	// server if empty
+	Host string
+
+	// Mapping maps operationIds, or "METHOD /path" for operations without
+	// one, to n8n webhook paths (e.g. "orders" or "/webhook/orders")
+	Mapping map[string]string
+
+	// Webhooks lists the webhook paths of the instance (e.g.
+	// "/webhook/orders"), operations mapped to others are skipped. Nil
+	// skips the check.
+	Webhooks []string
+}
+
+// Route is a route imported for one path of the document
+type Route struct {
+	Definition traefik.RouteDefinition
+
+	// Operations lists the imported operations as "METHOD /path"
+	Operations []string
+}
+
+// Skipped describes an operation that wasn't imported
+type Skipped struct {
+	Operation string `json:"operation"`
+	Reason    string `json:"reason"`
+}
+
+// Result is the outcome of an import
+type Result struct {
+	Routes  []Route
+	Skipped []Skipped
+
+	// Warnings list parameters that can't be forwarded
+	Warnings []string
+}
+
+// Routes maps the operations of the document to route definitions. The
+// definitions have no service, the caller sets the one of the instance.
+func (d *Document) Routes(options Options) (*Result, error) {
+	host, basePath, err := d.base()
+	if err != nil {
+		return nil, err
+	}
+	if options.Host != "" {
+		host = options.Host
+	}
+	if host == "" {
+		return nil, errors.New("the OpenAPI document has no server host, set one")
+	}
+
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	result := &Result{}
+	for _, path := range paths {
+		route, err := d.route(path, options, result)
+		if err != nil {
+			return nil, err
+		}
+		if route == nil {
+			continue
+		}
+		route.Definition.Host = host
+		route.Definition.Path = basePath + path
+		result.Routes = append(result.Routes, *route)
+	}
+	return result, nil
+}
+
+// route maps the operations of path to a route, or returns nil if none of
+// them could be imported. Skipped operations and warnings are added to result.
+func (d *Document) route(path string, options Options, result *Result) (*Route, error) {
+	item := d.Paths[path]
+
+	var shared []Parameter
+	if node, ok := item["parameters"]; ok {
+		if err := node.Decode(&shared); err != nil {
+			return nil, fmt.Errorf("invalid parameters of %s: %w", path, err)
+		}
+	}
+
+	route := &Route{}
+	queryParams := map[string]bool{}
+	for _, method := range methods {
+		node, ok := item[method]
+		if !ok {
+			continue
+		}
+		name := strings.ToUpper(method) + " " + path
+
+		var operation Operation
+		if err := node.Decode(&operation); err != nil {
+			return nil, fmt.Errorf("invalid operation %s: %w", name, err)
+		}
+
+		webhook := webhookPath(operation, name, options.Mapping)
+		switch {
+		case webhook == "":
+			result.Skipped = append(result.Skipped, Skipped{name, "the operation has no operationId, map it explicitly"})
+			continue
+		case options.Webhooks != nil && !slices.Contains(options.Webhooks, webhook):
+			result.Skipped = append(result.Skipped, Skipped{name, fmt.Sprintf("no webhook %s on the instance", webhook)})
+			continue
+		case route.Definition.ServicePath != "" && webhook != route.Definition.ServicePath:
+			result.Skipped = append(result.Skipped, Skipped{name, fmt.Sprintf("the path already maps to webhook %s", route.Definition.ServicePath)})
+			continue
+		}
+		route.Definition.ServicePath = webhook
+		route.Operations = append(route.Operations, name)
+
+		for _, param := range append(slices.Clone(shared), operation.Parameters...) {
+			param, err := d.resolve(param)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parameter of %s: %w", name, err)
+			}
+			if param.In == "query" {
+				queryParams[param.Name] = true
+			}
+		}
+	}
+	if len(route.Operations) == 0 {
+		return nil, nil
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		name := match[1]
+		if !paramName.MatchString(name) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("path parameter %q of %s can't be forwarded as header", name, path))
+			continue
+		}
+		if route.Definition.PathParams == nil {
+			route.Definition.PathParams = map[string]string{}
+		}
+		route.Definition.PathParams[strings.ReplaceAll(name, "_", "-")] = name
+	}
+	for _, name := range slices.Sorted(maps.Keys(queryParams)) {
+		if !paramName.MatchString(name) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("query parameter %q of %s can't be forwarded as header", name, path))
+			continue
+		}
+		route.Definition.QueryParams = append(route.Definition.QueryParams, name)
+	}
+
+	return route, nil
+}
+
+// webhookPath returns the webhook path operation name maps to, empty if
+// it has neither an operationId nor an explicit mapping
+func webhookPath(operation Operation, name string, mapping map[string]string) string {
+	path := operation.Webhook
+	if mapped, ok := mapping[name]; ok {
+		path = mapped
+	} else if mapped, ok := mapping[operation.OperationID]; ok && operation.OperationID != "" {
+		path = mapped
+	}
+	if path == "" {
+		path = operation.OperationID
+	}
+
+	if path == "" || strings.HasPrefix(path, "/") {
+		return path
+	}
+	return WebhookPrefix + path
+}
+
+// base returns the host and base path of the document's first server
+func (d *Document) base() (host, basePath string, err error) {
+	host, basePath = d.Host, d.BasePath
+	if len(d.Servers) > 0 {
+		server := d.Servers[0].URL
+		if _, rest, ok := strings.Cut(server, "://"); ok && strings.Contains(server, "{") {
+			// Server variables can't be resolved, only use the path
+			_, path, _ := strings.Cut(rest, "/")
+			server = "/" + path
+		}
+		u, err := url.Parse(server)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid server URL %q", d.Servers[0].URL)
+		}
+		host, basePath = u.Hostname(), u.Path
+		if strings.Contains(host, "{") || strings.Contains(basePath, "{") {
+			host, basePath = "", ""
+		}
+	}
+	basePath = strings.TrimSuffix(basePath, "/")
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return host, basePath, nil
+}
+
+// resolve returns the parameter a local $ref (e.g.
+// "#/components/parameters/limit") points to, or param itself
+func (d *Document) resolve(param Parameter) (Parameter, error) {
+	if param.Ref == "" {
+		return param, nil
+	}
+	pointer, ok := strings.CutPrefix(param.Ref, "#/")
+	if !ok {
+		return Parameter{}, fmt.Errorf("only local references are supported, not %q", param.Ref)
+	}
+
+	var node any = d.root
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		object, ok := node.(map[string]any)
+		if !ok {
+			return Parameter{}, fmt.Errorf("reference %q not found", param.Ref)
+		}
+		if node, ok = object[token]; !ok {
+			return Parameter{}, fmt.Errorf("reference %q not found", param.Ref)
+		}
+	}
+
+	object, _ := node.(map[string]any)
+	name, _ := object["name"].(string)
+	in, _ := object["in"].(string)
+	if name == "" || in == "" {
+		return Parameter{}, fmt.Errorf("reference %q is not a parameter", param.Ref)
+	}
+	return Parameter{Name: name, In: in}, nil
+}
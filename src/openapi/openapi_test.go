@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstore = `
+openapi: 3.0.3
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - $ref: '#/components/parameters/limit'
+        - name: tag
+          in: query
+        - name: X-Trace
+          in: header
+    post:
+      operationId: listPets
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+    get:
+      operationId: showPet
+    delete:
+      operationId: deletePet
+  /pets/{petId}/photo:
+    put:
+      x-n8n-webhook: pet-photo
+      parameters:
+        - name: page.size
+          in: query
+  /health:
+    get:
+      summary: no operationId
+components:
+  parameters:
+    limit:
+      name: limit
+      in: query
+`
+
+func TestRoutes(t *testing.T) {
+	doc, err := Parse([]byte(petstore))
+	require.NoError(t, err)
+
+	result, err := doc.Routes(Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Routes, 3)
+
+	pets := result.Routes[0].Definition
+	assert.Equal(t, "api.example.com", pets.Host)
+	assert.Equal(t, "/v1/pets", pets.Path)
+	assert.Equal(t, "/webhook/listPets", pets.ServicePath)
+	assert.Equal(t, []string{"limit", "tag"}, pets.QueryParams)
+	assert.Equal(t, []string{"GET /pets", "POST /pets"}, result.Routes[0].Operations)
+
+	pet := result.Routes[1].Definition
+	assert.Equal(t, "/v1/pets/{petId}", pet.Path)
+	assert.Equal(t, "/webhook/showPet", pet.ServicePath)
+	assert.Equal(t, map[string]string{"petId": "petId"}, pet.PathParams)
+
+	photo := result.Routes[2].Definition
+	assert.Equal(t, "/webhook/pet-photo", photo.ServicePath, "x-n8n-webhook names the webhook")
+	assert.Empty(t, photo.QueryParams)
+	assert.Len(t, result.Warnings, 1)
+
+	assert.Equal(t, []Skipped{
+		{"GET /health", "the operation has no operationId, map it explicitly"},
+		{"DELETE /pets/{petId}", "the path already maps to webhook /webhook/showPet"},
+	}, result.Skipped)
+}
+
+func TestRoutesMapping(t *testing.T) {
+	doc, err := Parse([]byte(petstore))
+	require.NoError(t, err)
+
+	result, err := doc.Routes(Options{
+		Host: "hooks.example.com",
+		Mapping: map[string]string{
+			"showPet":     "pets-by-id",
+			"deletePet":   "/webhook/pets-by-id",
+			"GET /health": "health",
+		},
+		Webhooks: []string{"/webhook/pets-by-id", "/webhook/health"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Routes, 2)
+	assert.Equal(t, "hooks.example.com", result.Routes[0].Definition.Host)
+	assert.Equal(t, "/v1/health", result.Routes[0].Definition.Path)
+	assert.Equal(t, "/webhook/health", result.Routes[0].Definition.ServicePath)
+	assert.Equal(t, []string{"GET /pets/{petId}", "DELETE /pets/{petId}"}, result.Routes[1].Operations)
+	assert.Equal(t, []Skipped{
+		{"GET /pets", "no webhook /webhook/listPets on the instance"},
+		{"POST /pets", "no webhook /webhook/listPets on the instance"},
+		{"PUT /pets/{petId}/photo", "no webhook /webhook/pet-photo on the instance"},
+	}, result.Skipped)
+}
+
+func TestSwagger(t *testing.T) {
+	doc, err := Parse([]byte(`{
+	"swagger": "2.0",
+	"host": "legacy.example.com",
+	"basePath": "/api/",
+	"parameters": {"user_id": {"name": "user_id", "in": "path"}},
+	"paths": {"/users/{user_id}": {"get": {"operationId": "getUser"}}}
+}`))
+	require.NoError(t, err)
+
+	result, err := doc.Routes(Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Routes, 1)
+	route := result.Routes[0].Definition
+	assert.Equal(t, "legacy.example.com", route.Host)
+	assert.Equal(t, "/api/users/{user_id}", route.Path)
+	assert.Equal(t, map[string]string{"user-id": "user_id"}, route.PathParams)
+}
+
+func TestRoutesErrors(t *testing.T) {
+	_, err := Parse([]byte("openapi: 3.0.0\npaths: {}"))
+	assert.Error(t, err)
+
+	doc, err := Parse([]byte("openapi: 3.0.0\nservers: [{url: '{scheme}://{host}/v2'}]\npaths: {/a: {get: {operationId: a}}}"))
+	require.NoError(t, err)
+	_, err = doc.Routes(Options{})
+	assert.Error(t, err, "server variables can't be resolved")
+
+	result, err := doc.Routes(Options{Host: "hooks.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "/v2/a", result.Routes[0].Definition.Path)
+
+	doc, err = Parse([]byte("openapi: 3.0.0\npaths: {/a: {get: {operationId: a, parameters: [{$ref: '#/missing'}]}}}"))
+	require.NoError(t, err)
+	_, err = doc.Routes(Options{Host: "hooks.example.com"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+// Package policies holds the route policies of environments: the auth types
+// routes must use, the rate limit applied to them, whether they may expose
+// webhooks of inactive workflows, the entrypoints they may listen on and
+// whether new routes need an approval. Routes of instances without
+// environment, or of an environment without policy, are unrestricted.
+package policies
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/trash"
+)
+
+// Collection stores the policies, at most one per environment
+const Collection = "policies"
+
+// RateLimit is the rate limit applied to the routes of an environment
+type RateLimit struct {
+	// Average is the number of requests allowed per minute
+	Average int `json:"average"`
+	Burst   int `json:"burst"`
+}
+
+// Policy is the route policy of an environment
+type Policy struct {
+	// AuthTypes are the auth types routes must use, empty allows any
+	// including none
+	AuthTypes []string `json:"auth_types"`
+
+	// RateLimit is nil if the environment doesn't set one
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+
+	AllowInactive bool `json:"allow_inactive"`
+
+	// EntryPoints are the entrypoints routes may use, the default of routes
+	// without their own. Empty allows any.
+	EntryPoints []string `json:"entrypoints"`
+
+	// RequireApproval keeps new routes unexposed until an operator approved
+	// them
+	RequireApproval bool `json:"require_approval"`
+}
+
+// fromRecord converts a policies record
+func fromRecord(record *core.Record) *Policy {
+	policy := &Policy{
+		AuthTypes:       record.GetStringSlice("auth_types"),
+		AllowInactive:   record.GetBool("allow_inactive"),
+		RequireApproval: record.GetBool("require_approval"),
+	}
+	record.UnmarshalJSONField("entrypoints", &policy.EntryPoints)
+	if average := record.GetInt("rate_limit_average"); average > 0 {
+		policy.RateLimit = &RateLimit{
+			Average: average,
+			Burst:   max(record.GetInt("rate_limit_burst"), 1),
+		}
+	}
+	return policy
+}
+
+// ForInstance returns the policy of the environment of an instance, nil if
+// there is none
+func ForInstance(app core.App, instanceID string) (*Policy, error) {
+	instance, err := app.FindRecordById("instances", instanceID)
+	if err != nil {
+		return nil, err
+	}
+	environment := instance.GetString("environment")
+	if environment == "" {
+		return nil, nil
+	}
+
+	records, err := app.FindAllRecords(Collection, dbx.HashExp{"environment": environment})
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return fromRecord(records[0]), nil
+}
+
+// ByInstance returns the policies by instance id, instances without policy
+// are left out
+func ByInstance(app core.App) (map[string]*Policy, error) {
+	records, err := app.FindAllRecords(Collection)
+	if err != nil {
+		return nil, err
+	}
+	byEnvironment := make(map[string]*Policy, len(records))
+	for _, record := range records {
+		byEnvironment[record.GetString("environment")] = fromRecord(record)
+	}
+
+	instances, err := app.FindAllRecords("instances", dbx.NewExp("environment != ''"), trash.NotDeleted)
+	if err != nil {
+		return nil, err
+	}
+	policies := map[string]*Policy{}
+	for _, instance := range instances {
+		if policy := byEnvironment[instance.GetString("environment")]; policy != nil {
+			policies[instance.Id] = policy
+		}
+	}
+	return policies, nil
+}
+
+// Route is what a policy checks of a route
+type Route struct {
+	AuthType    string
+	EntryPoints []string
+
+	// Inactive is set if the route exposes a webhook of an inactive workflow
+	Inactive bool
+}
+
+// Violations are the rules a route breaks by field of routes records
+type Violations map[string]string
+
+// Error lists the violations
+func (v Violations) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, field := range slices.Sorted(maps.Keys(v)) {
+		messages = append(messages, field+": "+v[field])
+	}
+	return "route violates the policy of its environment: " + strings.Join(messages, "; ")
+}
+
+// Check returns Violations if route breaks the policy, a nil policy allows
+// every route
+func (p *Policy) Check(route Route) error {
+	if p == nil {
+		return nil
+	}
+
+	violations := Violations{}
+	if len(p.AuthTypes) > 0 && !slices.Contains(p.AuthTypes, route.AuthType) {
+		violations["auth_type"] = "auth type must be one of " + strings.Join(p.AuthTypes, ", ")
+	}
+	if len(p.EntryPoints) > 0 {
+		for _, entryPoint := range route.EntryPoints {
+			if !slices.Contains(p.EntryPoints, entryPoint) {
+				violations["entrypoints"] = fmt.Sprintf("entrypoint %s is not allowed, allowed are %s",
+					entryPoint, strings.Join(p.EntryPoints, ", "))
+				break
+			}
+		}
+	}
+	if route.Inactive && !p.AllowInactive {
+		violations["webhook_path"] = "the workflow of the webhook is inactive"
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package policies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	policy := &Policy{
+		AuthTypes:   []string{"apikey", "hmac"},
+		EntryPoints: []string{"websecure"},
+	}
+
+	assert.NoError(t, policy.Check(Route{AuthType: "hmac", EntryPoints: []string{"websecure"}}))
+
+	err := policy.Check(Route{EntryPoints: []string{"web", "websecure"}, Inactive: true})
+	var violations Violations
+	require.ErrorAs(t, err, &violations)
+	assert.Equal(t, Violations{
+		"auth_type":    "auth type must be one of apikey, hmac",
+		"entrypoints":  "entrypoint web is not allowed, allowed are websecure",
+		"webhook_path": "the workflow of the webhook is inactive",
+	}, violations)
+	assert.Equal(t, "route violates the policy of its environment: auth_type: auth type must be one of apikey, hmac; "+
+		"entrypoints: entrypoint web is not allowed, allowed are websecure; webhook_path: the workflow of the webhook is inactive",
+		err.Error())
+
+	// Without policy and with an empty one everything is allowed
+	var none *Policy
+	assert.NoError(t, none.Check(Route{Inactive: true}))
+	assert.NoError(t, (&Policy{AllowInactive: true}).Check(Route{EntryPoints: []string{"web"}, Inactive: true}))
+}
@@ -0,0 +1,283 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"github.com/sistemica/n8n-manager-backend/policies"
+	"go.uber.org/zap"
+)
+
+// ApprovalsCollection stores the approvals of "route:" annotations
+const ApprovalsCollection = "route_approvals"
+
+// Approval states of routes records and annotations, routes created without
+// approval have none
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+)
+
+// notPending excludes routes records awaiting approval
+var notPending = dbx.NewExp("approval != {:pending}", dbx.Params{"pending": ApprovalPending})
+
+// approvedFields are the fields of routes records an approval covers,
+// changing one of them needs a new approval
+var approvedFields = []string{
+	"instance", "host", "hosts", "path",
+	"auth_type", "auth_credentials", "auth_username", "auth_password",
+	"auth_api_key", "auth_api_key_credential", "auth_hmac", "auth_hmac_secret", "auth_oidc",
+}
+
+// approvalChanged reports whether record changed a field its approval
+// covers
+func approvalChanged(record *core.Record) bool {
+	original := record.Original()
+	for _, field := range approvedFields {
+		if !reflect.DeepEqual(original.Get(field), record.Get(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+// requireApproval sets a routes record pending if the policy of its
+// instance requires approval
+func requireApproval(app core.App, record *core.Record) {
+	policy, _ := policies.ForInstance(app, record.GetString("instance"))
+	if policy != nil && policy.RequireApproval {
+		record.Set("approval", ApprovalPending)
+		record.Set("approved_by", "")
+		record.Set("approved_at", nil)
+	}
+}
+
+// annotationKey identifies the annotation route of a webhook at webhookPath
+func annotationKey(instanceID, webhookPath, route string) string {
+	return instanceID + " " + webhookPath + " " + route
+}
+
+// webhookPath returns the path of a webhooks record, e.g. "/webhook/orders"
+func webhookPath(webhook *core.Record) string {
+	u, err := url.Parse(webhook.GetString("webhook_url"))
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// annotationApprovals tells which annotations may be exposed
+type annotationApprovals struct {
+	policies map[string]*policies.Policy
+	approved map[string]bool
+}
+
+// loadApprovals loads the approved annotations, routePolicies are the
+// policies by instance id
+func loadApprovals(app core.App, routePolicies map[string]*policies.Policy) (*annotationApprovals, error) {
+	records, err := app.FindAllRecords(ApprovalsCollection, dbx.HashExp{"approval": ApprovalApproved})
+	if err != nil {
+		return nil, err
+	}
+	approvals := &annotationApprovals{policies: routePolicies, approved: map[string]bool{}}
+	for _, record := range records {
+		key := annotationKey(record.GetString("instance"), record.GetString("webhook_path"), record.GetString("route"))
+		approvals.approved[key] = true
+	}
+	return approvals, nil
+}
+
+// pending reports whether the annotation of a webhooks record awaits approval
+func (a *annotationApprovals) pending(webhook *core.Record) bool {
+	instance := webhook.GetString("instance")
+	if policy := a.policies[instance]; policy == nil || !policy.RequireApproval {
+		return false
+	}
+	return !a.approved[annotationKey(instance, webhookPath(webhook), webhook.GetString("route"))]
+}
+
+// bindApproval keeps new routes and annotations of instances whose policy
+// requires approval pending until approved, notifying the approvers
+func bindApproval(app core.App, logger *zap.Logger) {
+	app.OnRecordCreate("routes").BindFunc(func(e *core.RecordEvent) error {
+		requireApproval(e.App, e.Record)
+		return e.Next()
+	})
+	app.OnRecordAfterCreateSuccess("routes").BindFunc(func(e *core.RecordEvent) error {
+		if e.Record.GetString("approval") == ApprovalPending {
+			notifyApproval(e.App, logger, e.Record.Id, e.Record.GetString("instance"),
+				e.Record.GetString("host")+e.Record.GetString("path"))
+		}
+		return e.Next()
+	})
+
+	// An approval covers what was approved, changing where the route is
+	// exposed, the instance it goes to or its authentication, e.g. to none,
+	// requests a new one
+	app.OnRecordUpdate("routes").BindFunc(func(e *core.RecordEvent) error {
+		if approvalChanged(e.Record) {
+			requireApproval(e.App, e.Record)
+		}
+		return e.Next()
+	})
+	app.OnRecordAfterUpdateSuccess("routes").BindFunc(func(e *core.RecordEvent) error {
+		if e.Record.GetString("approval") == ApprovalPending &&
+			e.Record.Original().GetString("approval") != ApprovalPending {
+			notifyApproval(e.App, logger, e.Record.Id, e.Record.GetString("instance"),
+				e.Record.GetString("host")+e.Record.GetString("path"))
+		}
+		return e.Next()
+	})
+
+	// Only the approve endpoint approves routes
+	app.OnRecordUpdateRequest("routes").BindFunc(func(e *core.RecordRequestEvent) error {
+		if e.Record.GetString("approval") != e.Record.Original().GetString("approval") {
+			return apis.NewBadRequestError("Routes are approved with POST /api/routes/{id}/approve", nil)
+		}
+		return e.Next()
+	})
+
+	// Webhooks records are recreated by every sync, the approval of their
+	// annotation is only requested once
+	requestApproval := func(e *core.RecordEvent) error {
+		if err := requestAnnotationApproval(e.App, logger, e.Record); err != nil {
+			logger.Error("Failed to request route approval",
+				zap.Error(err),
+				zap.String("webhook", e.Record.Id))
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("webhooks").BindFunc(requestApproval)
+	app.OnRecordAfterUpdateSuccess("webhooks").BindFunc(requestApproval)
+}
+
+// requestAnnotationApproval creates a pending approval for the annotation of
+// a webhooks record if its instance requires approval and there is none yet
+func requestAnnotationApproval(app core.App, logger *zap.Logger, webhook *core.Record) error {
+	route := webhook.GetString("route")
+	if route == "" {
+		return nil
+	}
+	instance := webhook.GetString("instance")
+	policy, err := policies.ForInstance(app, instance)
+	if err != nil || policy == nil || !policy.RequireApproval {
+		return err
+	}
+
+	path := webhookPath(webhook)
+	existing, _ := app.FindFirstRecordByFilter(ApprovalsCollection,
+		"instance = {:instance} && webhook_path = {:path} && route = {:route}",
+		dbx.Params{"instance": instance, "path": path, "route": route})
+	if existing != nil {
+		return nil
+	}
+
+	collection, err := app.FindCollectionByNameOrId(ApprovalsCollection)
+	if err != nil {
+		return err
+	}
+	approval := core.NewRecord(collection)
+	approval.Set("instance", instance)
+	approval.Set("webhook_path", path)
+	approval.Set("route", route)
+	approval.Set("workflow_name", webhook.GetString("workflow_name"))
+	approval.Set("approval", ApprovalPending)
+	if err := app.Save(approval); err != nil {
+		return err
+	}
+
+	notifyApproval(app, logger, approval.Id, instance, route)
+	return nil
+}
+
+// notifyApproval asks the approvers to approve the route with id
+func notifyApproval(app core.App, logger *zap.Logger, id, instanceID, address string) {
+	logger.Info("Route awaits approval",
+		zap.String("route", id),
+		zap.String("instance", instanceID),
+		zap.String("address", address))
+
+	host := instanceID
+	if instance, err := app.FindRecordById("instances", instanceID); err == nil {
+		host = instance.GetString("host")
+	}
+	err := notify.Send(context.Background(), notify.Notification{
+		Event:    "route.approval_requested",
+		Severity: notify.SeverityInfo,
+		Title:    fmt.Sprintf("Route %s awaits approval", address),
+		Message: fmt.Sprintf("The route to %s isn't exposed before it is approved with POST /api/routes/%s/approve.",
+			host, id),
+		Fields: map[string]any{
+			"route":    id,
+			"instance": instanceID,
+			"address":  address,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to send approval notification", zap.Error(err), zap.String("route", id))
+	}
+}
+
+// ApprovalResult is the response of POST /api/routes/{id}/approve
+type ApprovalResult struct {
+	ID         string    `json:"id"`
+	Address    string    `json:"address"`
+	ApprovedBy string    `json:"approved_by"`
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+// approveRouteHandler approves a routes record or, by the id of its
+// route_approvals record, an annotation
+func approveRouteHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	id := e.Request.PathValue("id")
+	record, err := e.App.FindRecordById("routes", id)
+	if err != nil {
+		if record, err = e.App.FindRecordById(ApprovalsCollection, id); err != nil {
+			return apis.NewNotFoundError("Route not found", err)
+		}
+	}
+	if record.GetString("approval") != ApprovalPending {
+		return apis.NewBadRequestError("Route doesn't await approval", nil)
+	}
+
+	address := record.GetString("route")
+	if record.Collection().Name == "routes" {
+		address = record.GetString("host") + record.GetString("path")
+	}
+
+	actor := audit.Actor(e.Auth)
+	record.Set("approval", ApprovalApproved)
+	record.Set("approved_by", actor)
+	record.Set("approved_at", time.Now())
+	if err := e.App.Save(record); err != nil {
+		return apis.NewInternalServerError("Failed to approve route", err)
+	}
+
+	err = audit.Log(e.App, audit.Entry{
+		Action:   "route.approved",
+		Instance: record.GetString("instance"),
+		Actor:    actor,
+		Success:  true,
+		Message:  "Approved route " + address,
+		Details:  map[string]any{"route": record.Id, "collection": record.Collection().Name},
+	})
+	if err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err))
+	}
+
+	return e.JSON(http.StatusOK, ApprovalResult{
+		ID:         record.Id,
+		Address:    address,
+		ApprovedBy: actor,
+		ApprovedAt: record.GetDateTime("approved_at").Time(),
+	})
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/policies"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAnnotationApprovals(t *testing.T) {
+	webhooks := core.NewBaseCollection("webhooks")
+	webhooks.Fields.Add(
+		&core.TextField{Name: "instance"},
+		&core.TextField{Name: "webhook_url"},
+		&core.TextField{Name: "route"},
+	)
+	webhook := func(instance, route string) *core.Record {
+		record := core.NewRecord(webhooks)
+		record.Set("instance", instance)
+		record.Set("webhook_url", "https://n8n.example.com/webhook/orders")
+		record.Set("route", route)
+		return record
+	}
+
+	approvals := &annotationApprovals{
+		policies: map[string]*policies.Policy{
+			"prod": {RequireApproval: true},
+			"dev":  {},
+		},
+		approved: map[string]bool{
+			annotationKey("prod", "/webhook/orders", "hooks.example.com/orders"): true,
+		},
+	}
+
+	assert.False(t, approvals.pending(webhook("prod", "hooks.example.com/orders")))
+	// A changed annotation needs a new approval
+	assert.True(t, approvals.pending(webhook("prod", "hooks.example.com/all-orders")))
+	assert.False(t, approvals.pending(webhook("dev", "hooks.example.com/orders")))
+	assert.False(t, approvals.pending(webhook("other", "hooks.example.com/orders")))
+}
+
+func TestApprovalReset(t *testing.T) {
+	app := testutil.NewApp(t)
+	bindApproval(app, zap.NewNop())
+
+	prod := testutil.Create(t, app, "environments", map[string]any{"name": "prod"})
+	testutil.Create(t, app, policies.Collection, map[string]any{"environment": prod.Id, "require_approval": true})
+	approving := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com", "environment": prod.Id})
+	open := testutil.Create(t, app, "instances", map[string]any{"host": "dev.example.com"})
+
+	// Updates go through records loaded like by the API, whose original is
+	// the stored state
+	update := func(route *core.Record, values map[string]any) *core.Record {
+		fresh, err := app.FindRecordById("routes", route.Id)
+		require.NoError(t, err)
+		for field, value := range values {
+			fresh.Set(field, value)
+		}
+		require.NoError(t, app.SaveNoValidate(fresh))
+		return fresh
+	}
+	approved := map[string]any{"approval": ApprovalApproved, "approved_by": "admin@example.com"}
+
+	route := testutil.Create(t, app, "routes", map[string]any{"instance": approving.Id, "host": "hooks.example.com", "path": "/orders"})
+	assert.Equal(t, ApprovalPending, route.GetString("approval"))
+	route = update(route, approved)
+	assert.Equal(t, ApprovalApproved, route.GetString("approval"))
+
+	// Changes outside of the approval keep it
+	route = update(route, map[string]any{"active": true})
+	assert.Equal(t, ApprovalApproved, route.GetString("approval"))
+
+	for field, value := range map[string]any{
+		"path":      "/all-orders",
+		"host":      "public.example.com",
+		"auth_type": "none",
+	} {
+		route = update(route, map[string]any{field: value})
+		assert.Equal(t, ApprovalPending, route.GetString("approval"), field)
+		assert.Empty(t, route.GetString("approved_by"), field)
+		route = update(route, approved)
+	}
+
+	// Moving a route to an instance requiring approval requests one
+	moved := testutil.Create(t, app, "routes", map[string]any{"instance": open.Id, "host": "dev-hooks.example.com"})
+	assert.Empty(t, moved.GetString("approval"))
+	moved = update(moved, map[string]any{"instance": approving.Id})
+	assert.Equal(t, ApprovalPending, moved.GetString("approval"))
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex hmac>" of the signed payload
+	SignatureHeader = "X-Signature"
+
+	// TimestampHeader carries the unix time the signature was created at
+	TimestampHeader = "X-Signature-Timestamp"
+
+	// maxSignatureAge bounds the accepted clock skew / replay window
+	maxSignatureAge = 5 * time.Minute
+)
+
+// RequireProviderAuth protects the provider endpoints. A request is accepted if:
+//
+//   - it carries "Authorization: Bearer <TRAEFIK_PROVIDER_TOKEN>", which
+//     Traefik's HTTP provider can send via its static "headers" option,
+//   - it is signed with TRAEFIK_PROVIDER_HMAC_SECRET (see Sign), or
+//   - it is authenticated as a superuser.
+//
+// Without any secret configured only superusers can access the endpoint.
+func RequireProviderAuth() *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "providerAuth",
+		Func: func(e *core.RequestEvent) error {
+			if e.HasSuperuserAuth() {
+				return e.Next()
+			}
+
+			if token := os.Getenv("TRAEFIK_PROVIDER_TOKEN"); token != "" {
+				bearer, found := strings.CutPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+				if found && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+					return e.Next()
+				}
+			}
+
+			if secret := os.Getenv("TRAEFIK_PROVIDER_HMAC_SECRET"); secret != "" {
+				if verifySignature(secret, e.Request.Method, e.Request.URL.Path,
+					e.Request.Header.Get(TimestampHeader),
+					e.Request.Header.Get(SignatureHeader), time.Now()) {
+					return e.Next()
+				}
+			}
+
+			return apis.NewUnauthorizedError("Missing or invalid provider credentials", nil)
+		},
+	}
+}
+
+// Sign returns the signature header value for a request to path at timestamp.
+// The signed payload is "<method>\n<path>\n<unix timestamp>".
+func Sign(secret, method, path string, timestamp time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + strconv.FormatInt(timestamp.Unix(), 10)))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature checks a signature created by Sign and rejects stale timestamps
+func verifySignature(secret, method, path, timestamp, signature string, now time.Time) bool {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	signedAt := time.Unix(unix, 0)
+	if now.Sub(signedAt).Abs() > maxSignatureAge {
+		return false
+	}
+
+	expected := Sign(secret, method, path, signedAt)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Unix(1741700000, 0)
+	signature := Sign("secret", "GET", ConfigPath, now)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		method    string
+		path      string
+		timestamp string
+		signature string
+		now       time.Time
+		expected  bool
+	}{
+		{"valid", "secret", "GET", ConfigPath, timestamp, signature, now, true},
+		{"within skew", "secret", "GET", ConfigPath, timestamp, signature, now.Add(4 * time.Minute), true},
+		{"expired", "secret", "GET", ConfigPath, timestamp, signature, now.Add(6 * time.Minute), false},
+		{"wrong secret", "other", "GET", ConfigPath, timestamp, signature, now, false},
+		{"wrong path", "secret", "GET", "/api/other", timestamp, signature, now, false},
+		{"wrong method", "secret", "POST", ConfigPath, timestamp, signature, now, false},
+		{"invalid timestamp", "secret", "GET", ConfigPath, "abc", signature, now, false},
+		{"missing signature", "secret", "GET", ConfigPath, timestamp, "", now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, verifySignature(tt.secret, tt.method, tt.path, tt.timestamp, tt.signature, tt.now))
+		})
+	}
+}
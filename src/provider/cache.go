@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/policies"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"gopkg.in/yaml.v3"
+)
+
+// configCollections are the collections the configuration is built from.
+// Any change to their records bumps the revision. Workflows being
+// (de)activated, which policies may check, are picked up after maxConfigAge.
+var configCollections = []string{"routes", "route_credentials", "webhooks", "instances", policies.Collection,
+	ApprovalsCollection, maintenance.Collection, features.Collection}
+
+// maxConfigAge bounds how long a cached configuration is served, so rotated
+// credentials behind secret references are picked up without record changes
+const maxConfigAge = 5 * time.Minute
+
+// Formats the configuration is served in
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// revision counts the changes to the records the configuration is built from
+var revision atomic.Uint64
+
+// Revision returns the current revision of the configuration records
+func Revision() uint64 {
+	return revision.Load()
+}
+
+// BumpRevision marks the cached configuration as outdated, the next poll
+// rebuilds it
+func BumpRevision() {
+	revision.Add(1)
+}
+
+// cachedConfig is a configuration marshaled per format and the revision it
+// was built at
+type cachedConfig struct {
+	revision uint64
+	builtAt  time.Time
+	data     map[string][]byte
+
+	// etag and modifiedAt identify the JSON content, see traefik.ChangeTracker
+	etag       string
+	modifiedAt time.Time
+}
+
+// document is a configuration served in one format
+type document struct {
+	data       []byte
+	etag       string
+	modifiedAt time.Time
+}
+
+// configCache holds the marshaled configuration per audience until the
+// revision changes
+type configCache struct {
+	mu       sync.Mutex
+	configs  map[string]*cachedConfig
+	trackers map[string]*traefik.ChangeTracker
+
+	// building serializes builds, so concurrent polls of an outdated
+	// configuration trigger a single build
+	building sync.Mutex
+}
+
+var cache = &configCache{}
+
+// lookup returns the configuration of audience if it is still current
+func (c *configCache) lookup(audience string, current uint64) *cachedConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.configs[audience]
+	if !ok || cached.revision != current || time.Since(cached.builtAt) >= maxConfigAge {
+		return nil
+	}
+	return cached
+}
+
+// get returns the configuration of audience marshaled in format, building
+// it if the revision changed since the last build. Its modification time
+// only changes with the content, not with every build.
+func (c *configCache) get(audience, format string, build func() (*traefik.DynamicConfig, error)) (document, error) {
+	cached := c.lookup(audience, Revision())
+	if cached == nil {
+		c.building.Lock()
+		defer c.building.Unlock()
+
+		// Read the revision before building, so changes during the build
+		// trigger another one on the next poll
+		current := Revision()
+		if cached = c.lookup(audience, current); cached == nil {
+			config, err := build()
+			if err != nil {
+				return document{}, err
+			}
+			data, err := json.Marshal(config)
+			if err != nil {
+				return document{}, err
+			}
+
+			cached = &cachedConfig{
+				revision: current,
+				builtAt:  time.Now(),
+				data:     map[string][]byte{FormatJSON: data},
+			}
+			c.mu.Lock()
+			if c.configs == nil {
+				c.configs = map[string]*cachedConfig{}
+				c.trackers = map[string]*traefik.ChangeTracker{}
+			}
+			tracker, ok := c.trackers[audience]
+			if !ok {
+				tracker = &traefik.ChangeTracker{}
+				c.trackers[audience] = tracker
+			}
+			cached.etag, cached.modifiedAt = tracker.Track(data)
+			c.configs[audience] = cached
+			c.mu.Unlock()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := cached.data[format]
+	if !ok {
+		var err error
+		if data, err = jsonToYAML(cached.data[FormatJSON]); err != nil {
+			return document{}, err
+		}
+		cached.data[format] = data
+	}
+
+	// Both formats have the same content, but are different representations
+	etag := cached.etag
+	if format != FormatJSON {
+		etag = strings.TrimSuffix(etag, `"`) + "-" + format + `"`
+	}
+	return document{data: data, etag: etag, modifiedAt: cached.modifiedAt}, nil
+}
+
+// jsonToYAML converts a JSON document to YAML, keeping the JSON field names
+// Traefik expects in both formats
+func jsonToYAML(data []byte) ([]byte, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(value)
+}
+
+// bindInvalidation bumps the revision whenever a record the configuration
+// is built from is created, updated or deleted
+func bindInvalidation(app core.App) {
+	bump := func(e *core.RecordEvent) error {
+		BumpRevision()
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess(configCollections...).BindFunc(bump)
+	app.OnRecordAfterDeleteSuccess(configCollections...).BindFunc(bump)
+	app.OnRecordAfterUpdateSuccess(configCollections...).BindFunc(func(e *core.RecordEvent) error {
+		// Every sync updates its instance, only the host, the environment
+		// selecting the policy, the timezone of the activation windows and
+		// whether it is in the trash are part of the configuration
+		if e.Record.Collection().Name == "instances" && !instanceConfigChanged(e.Record) {
+			return e.Next()
+		}
+		return bump(e)
+	})
+}
+
+// instanceConfigFields are the fields of instances records the configuration
+// is built from
+var instanceConfigFields = []string{"host", "environment", timezone.Field, trash.Field}
+
+// instanceConfigChanged reports whether an update changed a field of the
+// instance the configuration is built from
+func instanceConfigChanged(record *core.Record) bool {
+	original := record.Original()
+	for _, field := range instanceConfigFields {
+		if original.GetString(field) != record.GetString(field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCache(t *testing.T) {
+	c := &configCache{}
+	builds := 0
+	build := func() (*traefik.DynamicConfig, error) {
+		builds++
+		config := traefik.NewBuilder().Build([]traefik.RouteDefinition{{
+			Host:    "hooks.example.com",
+			Path:    "/orders",
+			Service: traefik.ServiceDefinition{Host: "n8n", Port: 5678},
+		}})
+		return config, nil
+	}
+
+	first, err := c.get("", FormatJSON, build)
+	require.NoError(t, err)
+	second, err := c.get("", FormatJSON, build)
+	require.NoError(t, err)
+	assert.Equal(t, 1, builds)
+	assert.Equal(t, first, second)
+	assert.Contains(t, string(first.data), `"loadBalancer"`)
+
+	// YAML is converted from the cached build and keeps the JSON field names
+	yamlDoc, err := c.get("", FormatYAML, build)
+	require.NoError(t, err)
+	assert.Equal(t, 1, builds)
+	assert.Contains(t, string(yamlDoc.data), "loadBalancer:")
+	assert.NotEqual(t, first.etag, yamlDoc.etag)
+
+	// Audiences are cached separately
+	_, err = c.get("internal", FormatJSON, build)
+	require.NoError(t, err)
+	assert.Equal(t, 2, builds)
+
+	// A rebuild with the same content keeps the ETag and modification time
+	BumpRevision()
+	rebuilt, err := c.get("", FormatJSON, build)
+	require.NoError(t, err)
+	assert.Equal(t, 3, builds)
+	assert.Equal(t, first.etag, rebuilt.etag)
+	assert.Equal(t, first.modifiedAt, rebuilt.modifiedAt)
+}
+
+func TestConfigCacheRebuildsAfterChangeDuringBuild(t *testing.T) {
+	c := &configCache{}
+	builds := 0
+
+	// A record changing during the build outdates its result
+	_, err := c.get("", FormatJSON, func() (*traefik.DynamicConfig, error) {
+		builds++
+		BumpRevision()
+		return &traefik.DynamicConfig{}, nil
+	})
+	require.NoError(t, err)
+
+	_, err = c.get("", FormatJSON, func() (*traefik.DynamicConfig, error) {
+		builds++
+		return nil, errors.New("database is locked")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 2, builds)
+}
+
+func TestBindInvalidation(t *testing.T) {
+	app := testutil.NewApp(t)
+	bindInvalidation(app)
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+
+	update := func(field string, value any) {
+		t.Helper()
+		record, err := app.FindRecordById("instances", instance.Id)
+		require.NoError(t, err)
+		record.Set(field, value)
+		require.NoError(t, app.SaveNoValidate(record))
+	}
+
+	// Syncs don't change the configuration
+	before := Revision()
+	update("synced_at", "2026-01-01 00:00:00.000Z")
+	assert.Equal(t, before, Revision())
+
+	for field, value := range map[string]string{
+		"host":      "n8n2.example.com",
+		"timezone":  "Europe/Berlin",
+		trash.Field: "2026-01-01 00:00:00.000Z",
+	} {
+		before := Revision()
+		update(field, value)
+		assert.Greater(t, Revision(), before, field)
+	}
+}
@@ -0,0 +1,238 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/gateway"
+	"github.com/sistemica/n8n-manager-backend/redact"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"go.uber.org/zap"
+)
+
+// CapturePath is the forwardAuth endpoint capturing the requests of routes
+// with capture_requests set, followed by the id of the route
+const CapturePath = "/api/traefik/capture"
+
+// CapturesCollection stores the captured requests
+const CapturesCollection = "captured_requests"
+
+const (
+	// maxCapturedBody is the number of body bytes captured per request
+	maxCapturedBody = 256 << 10
+
+	// maxReplayResponseBody is the number of response body bytes returned
+	// by a replay
+	maxReplayResponseBody = 64 << 10
+)
+
+// skippedHeaders aren't captured, Traefik sets them for the forwardAuth
+// request or they don't apply to a replay
+var skippedHeaders = []string{"Connection", "Content-Length", "Accept-Encoding", "Upgrade", traefik.ManagerTokenHeader}
+
+// ReplayResult is the response of the workflow to a replayed request
+type ReplayResult struct {
+	URL        string              `json:"url"`
+	Method     string              `json:"method"`
+	Status     int                 `json:"status"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`
+	Truncated  bool                `json:"truncated"`
+	DurationMs int64               `json:"duration_ms"`
+}
+
+// captureURL returns the forwardAuth address capturing the requests of the
+// route with id routeId
+func captureURL(routeId string) (string, error) {
+	base := authURL()
+	if base == "" || managerToken() == "" {
+		return "", errors.New("request capture requires TRAEFIK_AUTH_URL and TRAEFIK_AUTH_TOKEN")
+	}
+	return base + CapturePath + "/" + routeId, nil
+}
+
+// captureHandler answers forwardAuth requests of Traefik for the route in
+// the path, storing the request and dropping captures beyond the route's
+// limit. Requests are let through even if they can't be stored. Only
+// Traefik may capture requests, superusers replay them to the workflow.
+func captureHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	if err := requireManagerToken(e); err != nil {
+		return err
+	}
+	route, err := e.App.FindRecordById("routes", e.Request.PathValue("route"))
+	if err != nil {
+		return e.NoContent(http.StatusNoContent)
+	}
+	limit := route.GetInt("capture_requests")
+	if limit <= 0 {
+		return e.NoContent(http.StatusNoContent)
+	}
+
+	if err := storeCapture(e.App, route.Id, e.Request, limit); err != nil {
+		logger.Warn("Failed to capture request", zap.String("route", route.Id), zap.Error(err))
+	}
+	return e.NoContent(http.StatusNoContent)
+}
+
+// storeCapture stores the request forwarded by Traefik and keeps the latest
+// limit captures of the route
+func storeCapture(app core.App, routeId string, r *http.Request, limit int) error {
+	collection, err := app.FindCollectionByNameOrId(CapturesCollection)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCapturedBody+1))
+	if err != nil {
+		return err
+	}
+
+	headers := map[string][]string{}
+	for name, values := range r.Header {
+		skipped := slices.ContainsFunc(skippedHeaders, func(skipped string) bool {
+			return strings.EqualFold(skipped, name)
+		})
+		if skipped || strings.HasPrefix(name, "X-Forwarded-") {
+			continue
+		}
+		if redact.IsSensitiveKey(name) || strings.EqualFold(name, "Cookie") {
+			values = []string{redact.Mask}
+		}
+		headers[name] = values
+	}
+
+	capture := core.NewRecord(collection)
+	capture.Set("route", routeId)
+	capture.Set("method", r.Header.Get("X-Forwarded-Method"))
+	capture.Set("uri", r.Header.Get("X-Forwarded-Uri"))
+	capture.Set("headers", headers)
+	if len(body) > maxCapturedBody {
+		body = body[:maxCapturedBody]
+		capture.Set("truncated", true)
+	}
+	if utf8.Valid(body) {
+		capture.Set("body", string(body))
+	} else {
+		capture.Set("body", base64.StdEncoding.EncodeToString(body))
+		capture.Set("body_base64", true)
+	}
+	if err := app.Save(capture); err != nil {
+		return err
+	}
+
+	// Keep the latest captures of the route only
+	old, err := app.FindRecordsByFilter(CapturesCollection, "route = {:route}", "-created,-id", 0, limit, dbx.Params{"route": routeId})
+	if err != nil {
+		return err
+	}
+	for _, record := range old {
+		if err := app.Delete(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// captureReplayHandler re-sends a captured request to the instance serving its
+// route and returns the response
+func captureReplayHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	capture, err := e.App.FindRecordById(CapturesCollection, e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Captured request not found", err)
+	}
+	route, err := e.App.FindRecordById("routes", capture.GetString("route"))
+	if err != nil {
+		return apis.NewNotFoundError("Route not found", err)
+	}
+	instance, err := e.App.FindRecordById("instances", route.GetString("instance"))
+	if err != nil {
+		return apis.NewBadRequestError("Instance of route not found", err)
+	}
+
+	target, err := gateway.Target(instance.GetString("host"), route.GetString("webhook_path"), capture.GetString("uri"))
+	if err != nil {
+		return apis.NewBadRequestError(err.Error(), nil)
+	}
+
+	body := []byte(capture.GetString("body"))
+	if capture.GetBool("body_base64") {
+		if body, err = base64.StdEncoding.DecodeString(string(body)); err != nil {
+			return apis.NewBadRequestError("Invalid captured body", err)
+		}
+	}
+	method := capture.GetString("method")
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequestWithContext(e.Request.Context(), method, target, bytes.NewReader(body))
+	if err != nil {
+		return apis.NewBadRequestError("Invalid request", err)
+	}
+	var headers map[string][]string
+	capture.UnmarshalJSONField("headers", &headers)
+	for name, values := range headers {
+		// Masked values would only make the workflow fail
+		if len(values) == 1 && values[0] == redact.Mask {
+			continue
+		}
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+
+	result := ReplayResult{URL: target, Method: method}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	started := time.Now()
+	resp, err := client.Do(req)
+	result.DurationMs = time.Since(started).Milliseconds()
+
+	entry := audit.Entry{
+		Action:   "capture.replayed",
+		Instance: instance.Id,
+		Actor:    audit.Actor(e.Auth),
+		Success:  err == nil,
+		Details: map[string]any{
+			"route":   route.Id,
+			"capture": capture.Id,
+		},
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Message = "Replay returned " + resp.Status
+	}
+	if auditErr := audit.Log(e.App, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Replay failed: "+err.Error(), nil)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReplayResponseBody+1))
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Failed to read replay response: "+err.Error(), nil)
+	}
+	if len(data) > maxReplayResponseBody {
+		data = data[:maxReplayResponseBody]
+		result.Truncated = true
+	}
+
+	result.Status = resp.StatusCode
+	result.Headers = resp.Header
+	result.Body = string(data)
+
+	return e.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCaptureHandlerRequiresToken(t *testing.T) {
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "manager-secret")
+
+	for _, token := range []string{"", "forged"} {
+		e := &core.RequestEvent{}
+		e.Request = httptest.NewRequest(http.MethodPost, CapturePath+"/r1", nil)
+		e.Request.SetPathValue("route", "r1")
+		if token != "" {
+			e.Request.Header.Set(traefik.ManagerTokenHeader, token)
+		}
+
+		var apiErr *router.ApiError
+		require.ErrorAs(t, captureHandler(e, zap.NewNop()), &apiErr, "forged captures are rejected before they are stored")
+		assert.Equal(t, http.StatusUnauthorized, apiErr.Status)
+	}
+}
+
+func TestCaptureURL(t *testing.T) {
+	t.Setenv("TRAEFIK_AUTH_URL", "http://manager:8090")
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "")
+	_, err := captureURL("r1")
+	assert.Error(t, err, "captures require the manager token")
+
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "manager-secret")
+	address, err := captureURL("r1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://manager:8090"+CapturePath+"/r1", address)
+	assert.Contains(t, skippedHeaders, traefik.ManagerTokenHeader, "the token isn't captured")
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"go.uber.org/zap"
+)
+
+// CatalogPath lists the exposed endpoints for developer portals
+const CatalogPath = "/api/catalog"
+
+// StatusMaintenance is the status of all endpoints during maintenance
+const StatusMaintenance = "maintenance"
+
+// CatalogEntry describes an exposed endpoint to the developers calling it
+type CatalogEntry struct {
+	// URL is the public URL, path parameters are written as "{name}"
+	URL         string   `json:"url"`
+	Host        string   `json:"host"`
+	Path        string   `json:"path"`
+	Methods     []string `json:"methods"`
+	QueryParams []string `json:"query_params,omitempty"`
+
+	// Auth is the authentication clients use: none, basic, digest, oidc
+	// or hmac
+	Auth        string `json:"auth"`
+	Description string `json:"description,omitempty"`
+	Workflow    string `json:"workflow,omitempty"`
+
+	Status    string     `json:"status"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// Catalog is the response of GET /api/catalog
+type Catalog struct {
+	Endpoints   []CatalogEntry `json:"endpoints"`
+	Maintenance bool           `json:"maintenance"`
+}
+
+// catalogHandler lists the exposed endpoints, optionally of one
+// ?audience=, without the internal details of routes and webhooks.
+// ?scheme= sets the scheme of the URLs, https by default.
+func catalogHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	scheme, audience, err := endpointOptions(e)
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := loadEndpoints(e.App, logger)
+	if err != nil {
+		logger.Error("Failed to load routes", zap.Error(err))
+		return apis.NewInternalServerError("Failed to load routes", nil)
+	}
+
+	return e.JSON(http.StatusOK, catalogOf(filterEndpoints(endpoints, audience), scheme, maintenance.Enabled(e.App, logger)))
+}
+
+// catalogOf returns the catalog of endpoints, sorted by URL
+func catalogOf(endpoints []endpoint, scheme string, inMaintenance bool) *Catalog {
+	catalog := &Catalog{
+		Endpoints:   make([]CatalogEntry, 0, len(endpoints)),
+		Maintenance: inMaintenance,
+	}
+	for _, ep := range endpoints {
+		entry := CatalogEntry{
+			URL:         scheme + "://" + ep.Host + ep.Path,
+			Host:        ep.Host,
+			Path:        ep.Path,
+			Methods:     ep.Methods,
+			QueryParams: ep.QueryParams,
+			Auth:        ep.clientAuth(),
+			Description: ep.Description,
+			Workflow:    ep.Workflow,
+			Status:      ep.Status,
+		}
+		if len(entry.Methods) == 0 {
+			entry.Methods = []string{http.MethodPost}
+		}
+		if !ep.CheckedAt.IsZero() {
+			entry.CheckedAt = &ep.CheckedAt
+		}
+		if inMaintenance {
+			entry.Status = StatusMaintenance
+		}
+		catalog.Endpoints = append(catalog.Endpoints, entry)
+	}
+
+	sort.Slice(catalog.Endpoints, func(i, j int) bool {
+		return catalog.Endpoints[i].URL < catalog.Endpoints[j].URL
+	})
+	return catalog
+}
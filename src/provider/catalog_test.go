@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogOf(t *testing.T) {
+	checkedAt := time.Date(2025, 4, 14, 8, 0, 0, 0, time.UTC)
+	endpoints := []endpoint{
+		{Host: "hooks.example.com", Path: "/orders", Methods: []string{"POST"}, AuthType: "apikey",
+			Description: "Creates orders", Status: StatusOperational, CheckedAt: checkedAt},
+		{Host: "api.example.com", Path: "/users/{id}", AuthType: "oidc", Status: StatusUnknown},
+	}
+
+	catalog := catalogOf(endpoints, "https", false)
+	require.Len(t, catalog.Endpoints, 2)
+	users, orders := catalog.Endpoints[0], catalog.Endpoints[1]
+
+	assert.Equal(t, "https://api.example.com/users/{id}", users.URL)
+	assert.Equal(t, []string{"POST"}, users.Methods, "endpoints without known methods use POST")
+	assert.Equal(t, "oidc", users.Auth)
+	assert.Nil(t, users.CheckedAt)
+
+	assert.Equal(t, "none", orders.Auth, "API keys are added by Traefik")
+	assert.Equal(t, "Creates orders", orders.Description)
+	assert.Equal(t, StatusOperational, orders.Status)
+	assert.Equal(t, &checkedAt, orders.CheckedAt)
+
+	catalog = catalogOf(endpoints, "http", true)
+	assert.True(t, catalog.Maintenance)
+	assert.Equal(t, StatusMaintenance, catalog.Endpoints[0].Status)
+	assert.Equal(t, "http://api.example.com/users/{id}", catalog.Endpoints[0].URL)
+}
+
+func TestDescribe(t *testing.T) {
+	notes := "Creates orders in the ERP.\nroute: hooks.example.com/orders\nroute-audience: external\n\nRetries are safe."
+	assert.Equal(t, "Creates orders in the ERP.\n\nRetries are safe.", describe(notes))
+	assert.Empty(t, describe("route: hooks.example.com"))
+}
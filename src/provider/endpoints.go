@@ -0,0 +1,235 @@
+package provider
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/incidents"
+	"github.com/sistemica/n8n-manager-backend/policies"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// Endpoint statuses, from the last reachability check of the webhook
+const (
+	StatusOperational = "operational"
+	StatusUnavailable = "unavailable"
+	StatusUnknown     = "unknown"
+)
+
+// endpoint is a route exposed through Traefik, as partners call it
+type endpoint struct {
+	Host        string
+	Path        string
+	Methods     []string
+	QueryParams []string
+	Workflow    string
+	Audience    string
+
+	// Instance serves the endpoint, Component identifies its webhook in
+	// the incident history, see incidents.WebhookComponent
+	Instance  string
+	Component string
+
+	// Description is taken from the notes of the webhook
+	Description string
+
+	// Status is one of StatusOperational, StatusUnavailable and
+	// StatusUnknown, checked at CheckedAt
+	Status    string
+	CheckedAt time.Time
+
+	// AuthType and Username are the authentication clients use, see
+	// routes records. apikey auth is added by Traefik, not the client.
+	AuthType string
+	Username string
+
+	// SignatureHeader carries the payload signature of hmac auth
+	SignatureHeader string
+
+	// ReplayHeader carries the idempotency key of replay protection
+	ReplayHeader string
+}
+
+// clientAuth returns the authentication clients of ep use, "none" if they
+// don't authenticate
+func (ep endpoint) clientAuth() string {
+	if ep.AuthType == "" || ep.AuthType == "apikey" {
+		return "none"
+	}
+	return ep.AuthType
+}
+
+// setWebhook copies the methods, description and status of the webhooks
+// record behind ep
+func (ep *endpoint) setWebhook(webhook *core.Record) {
+	ep.Methods = webhookMethods(webhook)
+	ep.Workflow = webhook.GetString("workflow_name")
+	ep.Description = describe(webhook.GetString("notes"))
+
+	ep.Status = StatusUnknown
+	if checkedAt := webhook.GetDateTime("checked_at"); !checkedAt.IsZero() {
+		ep.CheckedAt = checkedAt.Time()
+		ep.Status = StatusUnavailable
+		if webhook.GetBool("reachable") && !webhook.GetBool("route_broken") {
+			ep.Status = StatusOperational
+		}
+	}
+}
+
+// describe returns notes without the route annotations
+func describe(notes string) string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(notes))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "route:") || strings.HasPrefix(line, "route-") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// webhookMethods returns the HTTP methods of a webhooks record
+func webhookMethods(webhook *core.Record) []string {
+	var methods []string
+	webhook.UnmarshalJSONField("methods", &methods)
+	return slices.DeleteFunc(methods, func(method string) bool { return method == "" })
+}
+
+// loadEndpoints collects the endpoints of exposed routes records and of
+// webhooks with a "route:" annotation, with the methods of their webhooks
+func loadEndpoints(app core.App, logger *zap.Logger) ([]endpoint, error) {
+	webhooks, err := app.FindAllRecords("webhooks", trash.InstanceNotDeleted("instance"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+	// Webhooks by instance and path, e.g. "<id> /webhook/orders"
+	byPath := map[string]*core.Record{}
+	for _, webhook := range webhooks {
+		if u, err := url.Parse(webhook.GetString("webhook_url")); err == nil {
+			byPath[webhook.GetString("instance")+" "+u.Path] = webhook
+		}
+	}
+
+	locations, err := timezone.ByInstance(app)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch instances: %w", err)
+	}
+
+	var endpoints []endpoint
+
+	routes, err := app.FindAllRecords("routes", dbx.HashExp{"active": true}, notPending, trash.NotDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch routes: %w", err)
+	}
+	if failed := app.ExpandRecords(routes, []string{"auth_credentials"}, nil); len(failed) > 0 {
+		return nil, fmt.Errorf("failed to fetch route credentials: %v", failed)
+	}
+	for _, route := range routes {
+		if !exposedNow(route, locations.Of(route.GetString("instance")), logger) {
+			continue
+		}
+		ep := endpoint{
+			Path:     route.GetString("path"),
+			Audience: route.GetString("audience"),
+			Instance: route.GetString("instance"),
+			Status:   StatusUnknown,
+			AuthType: route.GetString("auth_type"),
+			Username: route.GetString("auth_username"),
+		}
+		if credentials := route.ExpandedOne("auth_credentials"); credentials != nil {
+			ep.Username = credentials.GetString("username")
+		}
+		if ep.Host, err = traefik.NormalizeHost(route.GetString("host")); err != nil {
+			logger.Warn("Skipping invalid route", zap.String("route", route.Id), zap.Error(err))
+			continue
+		}
+		route.UnmarshalJSONField("query_params", &ep.QueryParams)
+
+		if ep.AuthType == "hmac" {
+			var config hmacConfig
+			route.UnmarshalJSONField("auth_hmac", &config)
+			ep.SignatureHeader = config.withDefaults().Header
+		}
+		var replay replayConfig
+		route.UnmarshalJSONField("replay_protection", &replay)
+		ep.ReplayHeader = replay.Header
+
+		webhookPath := cmp.Or(route.GetString("webhook_path"), ep.Path)
+		ep.Component = incidents.WebhookComponent(ep.Instance, webhookPath)
+		if webhook := byPath[route.GetString("instance")+" "+webhookPath]; webhook != nil {
+			ep.setWebhook(webhook)
+		}
+		endpoints = append(endpoints, ep)
+	}
+
+	routePolicies, err := policies.ByInstance(app)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch policies: %w", err)
+	}
+	approvals, err := loadApprovals(app, routePolicies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch route approvals: %w", err)
+	}
+	for _, webhook := range webhooks {
+		if webhook.GetString("route") == "" || approvals.pending(webhook) {
+			continue
+		}
+		// The instance host only matters for the service, which isn't exported
+		route, err := routeFromWebhook(webhook, "http://localhost")
+		if err == nil {
+			route, err = route.Normalize()
+		}
+		if err != nil {
+			logger.Warn("Skipping invalid webhook route annotation", zap.String("webhook", webhook.Id), zap.Error(err))
+			continue
+		}
+		ep := endpoint{
+			Host:      route.Host,
+			Path:      route.Path,
+			Audience:  route.Audience,
+			Instance:  webhook.GetString("instance"),
+			Component: incidents.WebhookComponent(webhook.GetString("instance"), route.ServicePath),
+		}
+		ep.setWebhook(webhook)
+		endpoints = append(endpoints, ep)
+	}
+
+	return endpoints, nil
+}
+
+// filterEndpoints returns the endpoints served to audience, see FilterAudience
+func filterEndpoints(endpoints []endpoint, audience string) []endpoint {
+	if audience == "" {
+		return endpoints
+	}
+	return slices.DeleteFunc(endpoints, func(ep endpoint) bool {
+		return ep.Audience != "" && ep.Audience != audience
+	})
+}
+
+// endpointOptions returns the ?scheme= of the public URLs, https by
+// default, and the ?audience= to list the endpoints of
+func endpointOptions(e *core.RequestEvent) (scheme, audience string, err error) {
+	query := e.Request.URL.Query()
+	scheme, audience = cmp.Or(query.Get("scheme"), "https"), query.Get("audience")
+	if scheme != "http" && scheme != "https" {
+		return "", "", apis.NewBadRequestError("scheme must be http or https", nil)
+	}
+	if audience != "" && !slices.Contains(Audiences, audience) {
+		return "", "", apis.NewBadRequestError("Unknown audience", nil)
+	}
+	return scheme, audience, nil
+}
@@ -0,0 +1,272 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// Ids of the cron jobs of expiring routes
+const (
+	RouteScheduleJob       = "route-schedule"
+	RouteExpiryReminderJob = "route-expiry-reminders"
+)
+
+// defaultReminderLead is how long before their expiry routes are reminded of
+const defaultReminderLead = 72 * time.Hour
+
+// activationWindow is a period a route is exposed in
+type activationWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// localLayouts are the layouts of activation window times without an
+// offset, which are in the timezone of the route's instance
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// parseWindowTime parses an RFC 3339 time, or a local time in loc
+func parseWindowTime(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339 or a local time like 2006-01-02T15:04", value)
+}
+
+// routeSchedule is when a routes record is exposed: until it expires and, if
+// it has activation windows, only within them
+type routeSchedule struct {
+	ExpiresAt time.Time
+	Windows   []activationWindow
+}
+
+// scheduleOf returns the schedule of a routes record, whose activation
+// windows without an offset are in loc
+func scheduleOf(record *core.Record, loc *time.Location) (routeSchedule, error) {
+	schedule := routeSchedule{ExpiresAt: record.GetDateTime("expires_at").Time()}
+	var windows []struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	}
+	if err := record.UnmarshalJSONField("activation_windows", &windows); err != nil {
+		return schedule, fmt.Errorf("activation windows must be a list of start and end times: %w", err)
+	}
+	for _, raw := range windows {
+		var window activationWindow
+		var err error
+		if window.Start, err = parseWindowTime(raw.Start, loc); err != nil {
+			return schedule, err
+		}
+		if window.End, err = parseWindowTime(raw.End, loc); err != nil {
+			return schedule, err
+		}
+		if window.Start.IsZero() || window.End.IsZero() || !window.End.After(window.Start) {
+			return schedule, fmt.Errorf("activation window %s - %s must have a start before its end",
+				window.Start.Format(time.RFC3339), window.End.Format(time.RFC3339))
+		}
+		schedule.Windows = append(schedule.Windows, window)
+	}
+	return schedule, nil
+}
+
+// exposed reports whether the route is exposed at now
+func (s routeSchedule) exposed(now time.Time) bool {
+	if !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt) {
+		return false
+	}
+	if len(s.Windows) == 0 {
+		return true
+	}
+	for _, window := range s.Windows {
+		if !now.Before(window.Start) && now.Before(window.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// changes reports whether the route is exposed or hidden at a time in
+// (from, to]
+func (s routeSchedule) changes(from, to time.Time) bool {
+	within := func(t time.Time) bool {
+		return !t.IsZero() && t.After(from) && !t.After(to)
+	}
+	if within(s.ExpiresAt) {
+		return true
+	}
+	for _, window := range s.Windows {
+		if within(window.Start) || within(window.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// exposedNow reports whether a routes record, whose instance is in the
+// timezone loc, is exposed now, logging records with an invalid schedule
+// which are never exposed
+func exposedNow(record *core.Record, loc *time.Location, logger *zap.Logger) bool {
+	schedule, err := scheduleOf(record, loc)
+	if err != nil {
+		logger.Warn("Skipping route with invalid schedule",
+			zap.String("route", record.Id),
+			zap.Error(err))
+		return false
+	}
+	return schedule.exposed(time.Now())
+}
+
+// scheduled selects the routes records with an expiry or activation windows
+var scheduled = dbx.NewExp("expires_at != '' OR (activation_windows != '' AND activation_windows != 'null' AND activation_windows != '[]')")
+
+// bindExpiry clears the reminder of routes records whose expiry changed, so
+// the new one is reminded of again
+func bindExpiry(app core.App) {
+	app.OnRecordUpdate("routes").BindFunc(func(e *core.RecordEvent) error {
+		if !e.Record.GetDateTime("expires_at").Equal(e.Record.Original().GetDateTime("expires_at")) {
+			e.Record.Set("expiry_reminded_at", nil)
+		}
+		return e.Next()
+	})
+}
+
+// reminderConfig is read from the environment:
+//
+//	ROUTE_EXPIRY_REMINDER_SCHEDULE  cron expression, default hourly, "off" disables the reminders
+//	ROUTE_EXPIRY_REMINDER_BEFORE    how long before the expiry to remind, default 72h
+type reminderConfig struct {
+	schedule string
+	lead     time.Duration
+}
+
+func reminderConfigFromEnv() reminderConfig {
+	config := reminderConfig{
+		schedule: os.Getenv("ROUTE_EXPIRY_REMINDER_SCHEDULE"),
+		lead:     defaultReminderLead,
+	}
+	if config.schedule == "" {
+		config.schedule = "0 * * * *"
+	}
+	if lead, err := time.ParseDuration(os.Getenv("ROUTE_EXPIRY_REMINDER_BEFORE")); err == nil && lead > 0 {
+		config.lead = lead
+	}
+	return config
+}
+
+// initExpiryCron rebuilds the configuration when routes expire or enter or
+// leave an activation window, and reminds of routes about to expire
+func initExpiryCron(app core.App, logger *zap.Logger) {
+	var mu sync.Mutex
+	lastCheck := time.Now()
+	app.Cron().MustAdd(RouteScheduleJob, "* * * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", RouteScheduleJob))
+
+		mu.Lock()
+		defer mu.Unlock()
+		now := time.Now()
+		records, err := app.FindAllRecords("routes", dbx.HashExp{"active": true}, scheduled, trash.NotDeleted)
+		if err != nil {
+			logger.Error("Failed to fetch scheduled routes", zap.Error(err))
+			return
+		}
+		locations, err := timezone.ByInstance(app)
+		if err != nil {
+			logger.Error("Failed to fetch instance timezones", zap.Error(err))
+			return
+		}
+		for _, record := range records {
+			if schedule, err := scheduleOf(record, locations.Of(record.GetString("instance"))); err == nil && schedule.changes(lastCheck, now) {
+				BumpRevision()
+				break
+			}
+		}
+		lastCheck = now
+	})
+
+	config := reminderConfigFromEnv()
+	if config.schedule == "off" {
+		return
+	}
+	app.Cron().MustAdd(RouteExpiryReminderJob, config.schedule, func() {
+		defer errorreport.Recover(logger, zap.String("job", RouteExpiryReminderJob))
+
+		if maintenance.Enabled(app, logger) {
+			return
+		}
+		remindExpiringRoutes(app, logger, config.lead)
+	})
+}
+
+// remindExpiringRoutes notifies once of every active route expiring within
+// lead
+func remindExpiringRoutes(app core.App, logger *zap.Logger, lead time.Duration) {
+	now, err := types.ParseDateTime(time.Now())
+	if err != nil {
+		return
+	}
+	until, err := types.ParseDateTime(now.Time().Add(lead))
+	if err != nil {
+		return
+	}
+
+	records, err := app.FindAllRecords("routes",
+		dbx.HashExp{"active": true, "expiry_reminded_at": "", trash.Field: ""},
+		dbx.NewExp("expires_at > {:now} AND expires_at <= {:until}",
+			dbx.Params{"now": now.String(), "until": until.String()}))
+	if err != nil {
+		logger.Error("Failed to fetch expiring routes", zap.Error(err))
+		return
+	}
+	locations, err := timezone.ByInstance(app)
+	if err != nil {
+		logger.Error("Failed to fetch instance timezones", zap.Error(err))
+		return
+	}
+
+	for _, record := range records {
+		address := record.GetString("host") + record.GetString("path")
+		expiresAt := record.GetDateTime("expires_at").Time()
+		err := notify.Send(context.Background(), notify.Notification{
+			Event:    "route.expiring",
+			Severity: notify.SeverityWarning,
+			Title:    fmt.Sprintf("Route %s expires soon", address),
+			Message: fmt.Sprintf("The route %s stops being exposed at %s, extend its expires_at to keep it.",
+				address, timezone.Format(expiresAt, locations.Of(record.GetString("instance")))),
+			Fields: map[string]any{
+				"route":      record.Id,
+				"instance":   record.GetString("instance"),
+				"address":    address,
+				"expires_at": expiresAt,
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send expiry reminder", zap.Error(err), zap.String("route", record.Id))
+			continue
+		}
+
+		record.Set("expiry_reminded_at", now)
+		if err := app.Save(record); err != nil {
+			logger.Error("Failed to save expiry reminder", zap.Error(err), zap.String("route", record.Id))
+		}
+	}
+}
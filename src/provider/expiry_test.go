@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteSchedule(t *testing.T) {
+	routes := core.NewBaseCollection("routes")
+	routes.Fields.Add(
+		&core.DateField{Name: "expires_at"},
+		&core.JSONField{Name: "activation_windows"},
+	)
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("unscheduled", func(t *testing.T) {
+		schedule, err := scheduleOf(core.NewRecord(routes), time.UTC)
+		require.NoError(t, err)
+		assert.True(t, schedule.exposed(day))
+		assert.False(t, schedule.changes(day, day.Add(time.Hour)))
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		record := core.NewRecord(routes)
+		record.Set("expires_at", day.Add(12*time.Hour))
+		schedule, err := scheduleOf(record, time.UTC)
+		require.NoError(t, err)
+
+		assert.True(t, schedule.exposed(day))
+		assert.False(t, schedule.exposed(day.Add(12*time.Hour)))
+		assert.True(t, schedule.changes(day.Add(11*time.Hour), day.Add(12*time.Hour)))
+		assert.False(t, schedule.changes(day.Add(12*time.Hour), day.Add(13*time.Hour)))
+	})
+
+	t.Run("activation windows", func(t *testing.T) {
+		record := core.NewRecord(routes)
+		record.Set("expires_at", day.Add(48*time.Hour))
+		record.Set("activation_windows", []activationWindow{
+			{Start: day.Add(9 * time.Hour), End: day.Add(17 * time.Hour)},
+			{Start: day.Add(33 * time.Hour), End: day.Add(60 * time.Hour)},
+		})
+		schedule, err := scheduleOf(record, time.UTC)
+		require.NoError(t, err)
+
+		assert.False(t, schedule.exposed(day))
+		assert.True(t, schedule.exposed(day.Add(9*time.Hour)))
+		assert.False(t, schedule.exposed(day.Add(17*time.Hour)))
+		assert.True(t, schedule.exposed(day.Add(40*time.Hour)))
+		// The expiry ends the second window early
+		assert.False(t, schedule.exposed(day.Add(50*time.Hour)))
+		assert.True(t, schedule.changes(day.Add(8*time.Hour), day.Add(9*time.Hour)))
+	})
+
+	t.Run("local activation windows", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		record := core.NewRecord(routes)
+		record.Set("activation_windows", `[
+			{"start": "2025-06-02T09:00", "end": "2025-06-02 17:00"},
+			{"start": "2025-06-03T09:00:00Z", "end": "2025-06-03T17:00:00+02:00"}
+		]`)
+		schedule, err := scheduleOf(record, berlin)
+		require.NoError(t, err)
+
+		// 09:00 in Berlin is 07:00 UTC in summer
+		assert.False(t, schedule.exposed(day.Add(6*time.Hour)))
+		assert.True(t, schedule.exposed(day.Add(7*time.Hour)))
+		assert.False(t, schedule.exposed(day.Add(15*time.Hour)))
+		// Times with an offset ignore the timezone
+		assert.True(t, schedule.exposed(day.Add(33*time.Hour)))
+		assert.False(t, schedule.exposed(day.Add(39*time.Hour)))
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		record := core.NewRecord(routes)
+		record.Set("activation_windows", []activationWindow{
+			{Start: day.Add(17 * time.Hour), End: day.Add(9 * time.Hour)},
+		})
+		_, err := scheduleOf(record, time.UTC)
+		assert.Error(t, err)
+
+		record.Set("activation_windows", `[{"start": "tomorrow"}]`)
+		_, err = scheduleOf(record, time.UTC)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/oidc"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"go.uber.org/zap"
+)
+
+// OIDCAuthPath is the forwardAuth endpoint verifying the bearer tokens of
+// routes with oidc auth. The required audience and scopes are passed as
+// query parameters, e.g. ?audience=n8n-hooks&scope=hooks:write.
+const OIDCAuthPath = "/api/traefik/auth/oidc"
+
+// oidcConfig is the "auth_oidc" field of routes records
+type oidcConfig struct {
+	Audience string   `json:"audience,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// verifier verifies tokens of the issuer set in OIDC_ISSUER
+var verifier = sync.OnceValues(oidc.FromEnv)
+
+// authURL returns the URL Traefik reaches the manager at for forwardAuth,
+// set with TRAEFIK_AUTH_URL (e.g. "http://n8n-manager:8090")
+func authURL() string {
+	return strings.TrimSuffix(os.Getenv("TRAEFIK_AUTH_URL"), "/")
+}
+
+// managerToken returns the token Traefik authenticates with to the
+// forwardAuth checks changing state, set with TRAEFIK_AUTH_TOKEN
+func managerToken() string {
+	return os.Getenv("TRAEFIK_AUTH_TOKEN")
+}
+
+// requireManagerToken rejects requests of a forwardAuth check changing
+// state that weren't sent by Traefik, which adds the token in
+// TRAEFIK_AUTH_TOKEN. Otherwise anyone reaching the manager could e.g. use up
+// the quota of another client.
+func requireManagerToken(e *core.RequestEvent) error {
+	token := managerToken()
+	if token == "" {
+		return apis.NewApiError(http.StatusServiceUnavailable, "TRAEFIK_AUTH_TOKEN is not configured", nil)
+	}
+	if subtle.ConstantTimeCompare([]byte(e.Request.Header.Get(traefik.ManagerTokenHeader)), []byte(token)) != 1 {
+		return apis.NewUnauthorizedError("Invalid manager token", nil)
+	}
+	return nil
+}
+
+// oidcVerifyURL returns the forwardAuth address verifying the tokens of a
+// route requiring config
+func oidcVerifyURL(config oidcConfig) (string, error) {
+	base := authURL()
+	if base == "" {
+		return "", errors.New("oidc auth requires TRAEFIK_AUTH_URL")
+	}
+
+	query := url.Values{}
+	if config.Audience != "" {
+		query.Set("audience", config.Audience)
+	}
+	for _, scope := range config.Scopes {
+		query.Add("scope", scope)
+	}
+	if len(query) == 0 {
+		return base + OIDCAuthPath, nil
+	}
+	return base + OIDCAuthPath + "?" + query.Encode(), nil
+}
+
+// oidcAuthHandler answers forwardAuth requests of Traefik, letting requests
+// with a valid bearer token through. The subject of the token is returned in
+// the X-Auth-Subject header.
+func oidcAuthHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	v, err := verifier()
+	if err != nil {
+		logger.Error("OIDC auth requested but not configured", zap.Error(err))
+		return apis.NewApiError(http.StatusServiceUnavailable, "OIDC auth is not configured", nil)
+	}
+
+	token, found := strings.CutPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		e.Response.Header().Set("WWW-Authenticate", `Bearer realm="n8n-manager"`)
+		return apis.NewUnauthorizedError("Missing bearer token", nil)
+	}
+
+	query := e.Request.URL.Query()
+	claims, err := v.Verify(e.Request.Context(), token, query.Get("audience"), query["scope"])
+	if errors.Is(err, oidc.ErrInsufficientScope) {
+		e.Response.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="n8n-manager", error="insufficient_scope", scope=%q`, strings.Join(query["scope"], " ")))
+		return apis.NewForbiddenError("Insufficient scope", nil)
+	}
+	if err != nil {
+		logger.Debug("Rejected bearer token", zap.Error(err))
+		e.Response.Header().Set("WWW-Authenticate", `Bearer realm="n8n-manager", error="invalid_token"`)
+		return apis.NewUnauthorizedError("Invalid bearer token", nil)
+	}
+
+	e.Response.Header().Set(traefik.AuthSubjectHeader, claims.Subject)
+	return e.NoContent(http.StatusNoContent)
+}
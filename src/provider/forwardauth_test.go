@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCVerifyURL(t *testing.T) {
+	t.Setenv("TRAEFIK_AUTH_URL", "")
+	_, err := oidcVerifyURL(oidcConfig{})
+	assert.Error(t, err, "oidc routes can't be protected without the manager's URL")
+
+	t.Setenv("TRAEFIK_AUTH_URL", "http://n8n-manager:8090/")
+	address, err := oidcVerifyURL(oidcConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://n8n-manager:8090/api/traefik/auth/oidc", address)
+
+	address, err = oidcVerifyURL(oidcConfig{Audience: "n8n-hooks", Scopes: []string{"hooks:read", "hooks:write"}})
+	require.NoError(t, err)
+	assert.Equal(t, "http://n8n-manager:8090/api/traefik/auth/oidc?audience=n8n-hooks&scope=hooks%3Aread&scope=hooks%3Awrite", address)
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/geoip"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/sistemica/n8n-manager-backend/usage"
+	"go.uber.org/zap"
+)
+
+// GeoIPPath is the forwardAuth endpoint checking the country of requests
+// to routes with geoip rules, followed by the id of the route
+const GeoIPPath = "/api/traefik/auth/geoip"
+
+// geoConfig is the "geoip" field of routes records, e.g. {"allow": ["DE", "AT"]}
+// or {"deny": ["KP"]}. Countries are ISO 3166-1 codes.
+type geoConfig struct {
+	// Allow lists the only countries requests are accepted from
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny lists countries requests are rejected from
+	Deny []string `json:"deny,omitempty"`
+
+	// AllowUnknown accepts requests from addresses without a country, e.g.
+	// private networks, which an allow list rejects otherwise
+	AllowUnknown bool `json:"allow_unknown,omitempty"`
+}
+
+// enabled reports whether c restricts any country
+func (c geoConfig) enabled() bool {
+	return len(c.Allow) > 0 || len(c.Deny) > 0
+}
+
+// allowed reports whether requests from country, empty if unknown, pass
+func (c geoConfig) allowed(country string) bool {
+	if country == "" {
+		return len(c.Allow) == 0 || c.AllowUnknown
+	}
+	if slices.Contains(c.Deny, country) {
+		return false
+	}
+	return len(c.Allow) == 0 || slices.Contains(c.Allow, country)
+}
+
+// normalize returns c with upper case country codes, or an error if a code
+// isn't made of two letters
+func (c geoConfig) normalize() (geoConfig, error) {
+	normalized := geoConfig{AllowUnknown: c.AllowUnknown}
+	for _, list := range []struct {
+		codes []string
+		to    *[]string
+	}{{c.Allow, &normalized.Allow}, {c.Deny, &normalized.Deny}} {
+		for _, code := range list.codes {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+				return geoConfig{}, errors.New("countries must be two letter ISO 3166-1 codes like DE")
+			}
+			*list.to = append(*list.to, code)
+		}
+	}
+	return normalized, nil
+}
+
+// geoDatabase resolves countries from the database set in GEOIP_DATABASE
+var geoDatabase = sync.OnceValues(geoip.FromEnv)
+
+// geoIPCheckURL returns the forwardAuth address checking the country of
+// requests to the route with id routeId
+func geoIPCheckURL(routeId string) (string, error) {
+	base := authURL()
+	if base == "" || os.Getenv("GEOIP_DATABASE") == "" {
+		return "", errors.New("geoip rules require TRAEFIK_AUTH_URL and GEOIP_DATABASE")
+	}
+	return base + GeoIPPath + "/" + routeId, nil
+}
+
+// geoIPHandler answers forwardAuth requests of Traefik for the route in the
+// path, rejecting requests from countries the route doesn't allow. The
+// country is passed on to n8n in traefik.CountryHeader.
+func geoIPHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	route, err := e.App.FindRecordById("routes", e.Request.PathValue("route"))
+	if err != nil {
+		return apis.NewNotFoundError("Route not found", nil)
+	}
+
+	var config geoConfig
+	if err := route.UnmarshalJSONField("geoip", &config); err != nil || !config.enabled() {
+		return e.NoContent(http.StatusNoContent)
+	}
+
+	db, err := geoDatabase()
+	if err != nil {
+		return apis.NewApiError(http.StatusServiceUnavailable, "GeoIP is not configured", nil)
+	}
+
+	country := ""
+	if addr, err := netip.ParseAddr(usage.ForwardedIP(e.Request)); err == nil {
+		if country, err = db.Country(addr); err != nil {
+			logger.Error("Failed to look up country", zap.String("route", route.Id), zap.Error(err))
+			return apis.NewApiError(http.StatusServiceUnavailable, "GeoIP lookup failed", nil)
+		}
+	}
+
+	if !config.allowed(country) {
+		return apis.NewForbiddenError("Requests from your country are not allowed", nil)
+	}
+	e.Response.Header().Set(traefik.CountryHeader, country)
+	return e.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoConfigAllowed(t *testing.T) {
+	allow := geoConfig{Allow: []string{"DE", "AT"}}
+	assert.True(t, allow.allowed("DE"))
+	assert.False(t, allow.allowed("US"))
+	assert.False(t, allow.allowed(""), "unknown countries are rejected by allow lists")
+
+	allow.AllowUnknown = true
+	assert.True(t, allow.allowed(""))
+
+	deny := geoConfig{Deny: []string{"KP"}}
+	assert.False(t, deny.allowed("KP"))
+	assert.True(t, deny.allowed("DE"))
+	assert.True(t, deny.allowed(""))
+
+	both := geoConfig{Allow: []string{"DE", "AT"}, Deny: []string{"AT"}}
+	assert.True(t, both.allowed("DE"))
+	assert.False(t, both.allowed("AT"), "deny wins")
+}
+
+func TestGeoConfigNormalize(t *testing.T) {
+	config, err := geoConfig{Allow: []string{"de", " At "}, Deny: []string{"kp"}, AllowUnknown: true}.normalize()
+	require.NoError(t, err)
+	assert.Equal(t, geoConfig{Allow: []string{"DE", "AT"}, Deny: []string{"KP"}, AllowUnknown: true}, config)
+
+	for _, code := range []string{"DEU", "D", "1A", ""} {
+		_, err := geoConfig{Deny: []string{code}}.normalize()
+		assert.Error(t, err, code)
+	}
+}
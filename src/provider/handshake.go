@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/sistemica/n8n-manager-backend/trash"
+)
+
+// Headers describing the provider on every config response
+const (
+	ProviderHeader     = "X-Traefik-Provider"
+	RevisionHeader     = "X-Traefik-Provider-Revision"
+	StatusHeader       = "X-Traefik-Provider-Status"
+	PollIntervalHeader = "X-Traefik-Poll-Interval"
+)
+
+// ProviderName is sent in the X-Traefik-Provider header
+const ProviderName = "n8n-manager"
+
+// Provider states sent in the X-Traefik-Provider-Status header
+const (
+	StatusReady     = "ready"
+	StatusWarmingUp = "warming-up"
+)
+
+const defaultPollInterval = 5 * time.Second
+
+// startedAt is when the manager started, instances synced before don't end
+// the warm-up
+var startedAt = time.Now()
+
+// warm is set once the first sync after the start has completed
+var warm atomic.Bool
+
+// PollInterval returns the poll interval Traefik is advised to use, set with
+// TRAEFIK_POLL_INTERVAL (e.g. "10s"). It should match the pollInterval of
+// Traefik's HTTP provider.
+func PollInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("TRAEFIK_POLL_INTERVAL")); err == nil && interval > 0 {
+		return interval
+	}
+	return defaultPollInterval
+}
+
+// warmedUp reports whether the first sync after the start has completed,
+// which it has if there are no instances to sync. Instances in the trash
+// aren't synced and don't count.
+func warmedUp(app core.App) bool {
+	if warm.Load() {
+		return true
+	}
+
+	total, err := app.CountRecords("instances", trash.NotDeleted)
+	if err != nil {
+		return false
+	}
+	start, err := types.ParseDateTime(startedAt)
+	if err != nil {
+		return false
+	}
+	synced, err := app.CountRecords("instances", trash.NotDeleted, dbx.NewExp("synced_at >= {:start}", dbx.Params{"start": start.String()}))
+	if err != nil {
+		return false
+	}
+
+	if total == 0 || synced > 0 {
+		warm.Store(true)
+	}
+	return warm.Load()
+}
+
+// emptyDocument returns a valid configuration without any routes, served
+// if the configuration can't be built while the manager warms up
+func emptyDocument(format string) (document, error) {
+	data, err := json.Marshal(traefik.NewBuilder().Build(nil))
+	if err != nil {
+		return document{}, err
+	}
+	if format == FormatYAML {
+		if data, err = jsonToYAML(data); err != nil {
+			return document{}, err
+		}
+	}
+	return document{
+		data:       data,
+		etag:       traefik.ETag(data),
+		modifiedAt: startedAt.UTC().Truncate(time.Second),
+	}, nil
+}
+
+// setProviderHeaders describes the provider, its state and the advised poll
+// interval, which is also the time the response may be cached
+func setProviderHeaders(w http.ResponseWriter, status string) {
+	interval := PollInterval()
+	w.Header().Set(ProviderHeader, ProviderName)
+	w.Header().Set(RevisionHeader, strconv.FormatUint(Revision(), 10))
+	w.Header().Set(StatusHeader, status)
+	w.Header().Set(PollIntervalHeader, interval.String())
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(interval.Seconds())))
+}
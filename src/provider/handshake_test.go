@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollInterval(t *testing.T) {
+	t.Setenv("TRAEFIK_POLL_INTERVAL", "")
+	assert.Equal(t, 5*time.Second, PollInterval())
+
+	t.Setenv("TRAEFIK_POLL_INTERVAL", "30s")
+	assert.Equal(t, 30*time.Second, PollInterval())
+
+	t.Setenv("TRAEFIK_POLL_INTERVAL", "soon")
+	assert.Equal(t, 5*time.Second, PollInterval())
+}
+
+func TestEmptyDocument(t *testing.T) {
+	doc, err := emptyDocument(FormatJSON)
+	require.NoError(t, err)
+
+	var config map[string]map[string]map[string]any
+	require.NoError(t, json.Unmarshal(doc.data, &config))
+	assert.Empty(t, config["http"]["routers"])
+	assert.NotEmpty(t, doc.etag)
+
+	doc, err = emptyDocument(FormatYAML)
+	require.NoError(t, err)
+	assert.Contains(t, string(doc.data), "routers: {}")
+}
+
+func TestSetProviderHeaders(t *testing.T) {
+	t.Setenv("TRAEFIK_POLL_INTERVAL", "10s")
+	recorder := httptest.NewRecorder()
+	setProviderHeaders(recorder, StatusWarmingUp)
+
+	assert.Equal(t, ProviderName, recorder.Header().Get(ProviderHeader))
+	assert.Equal(t, StatusWarmingUp, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "10s", recorder.Header().Get(PollIntervalHeader))
+	assert.Equal(t, "private, max-age=10", recorder.Header().Get("Cache-Control"))
+}
+
+func TestWarmedUpIgnoresTrash(t *testing.T) {
+	app := testutil.NewApp(t)
+	warm.Store(false)
+	t.Cleanup(func() { warm.Store(false) })
+
+	// Instances in the trash are never synced
+	testutil.Create(t, app, "instances", map[string]any{"host": "old.example.com", trash.Field: "2026-01-01 00:00:00.000Z"})
+	assert.True(t, warmedUp(app))
+
+	warm.Store(false)
+	testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	assert.False(t, warmedUp(app))
+}
@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/openapi"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"go.uber.org/zap"
+)
+
+// OpenAPIImportPath is the endpoint creating routes from an OpenAPI document
+const OpenAPIImportPath = "/api/routes/import/openapi"
+
+// OpenAPIImportRequest is the body of POST /api/routes/import/openapi
+type OpenAPIImportRequest struct {
+	// Instance is the id of the instance serving the webhooks
+	Instance string `json:"instance"`
+
+	// Host overrides the host of the document's first server
+	Host string `json:"host,omitempty"`
+
+	// Spec is the OpenAPI document, as object or as JSON or YAML string
+	Spec json.RawMessage `json:"spec"`
+
+	// Mapping maps operationIds, or "METHOD /path", to n8n webhook paths
+	// for operations not following the operationId convention
+	Mapping map[string]string `json:"mapping,omitempty"`
+
+	// Active enables the created routes right away
+	Active bool `json:"active,omitempty"`
+
+	// DryRun only reports the routes that would be created
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// ImportedRoute describes a route of an OpenAPI import
+type ImportedRoute struct {
+	Id          string            `json:"id,omitempty"`
+	Host        string            `json:"host"`
+	Path        string            `json:"path"`
+	WebhookPath string            `json:"webhook_path"`
+	PathParams  map[string]string `json:"path_params,omitempty"`
+	QueryParams []string          `json:"query_params,omitempty"`
+	Operations  []string          `json:"operations"`
+}
+
+// OpenAPIImportResult is the response of an OpenAPI import. Paths that
+// already have a route are listed in Existing and left unchanged.
+type OpenAPIImportResult struct {
+	Created  []ImportedRoute   `json:"created"`
+	Existing []ImportedRoute   `json:"existing,omitempty"`
+	Skipped  []openapi.Skipped `json:"skipped,omitempty"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+// openAPIImportHandler creates a routes record for every path of an OpenAPI
+// document whose operations map to webhooks of the instance
+func openAPIImportHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	var body OpenAPIImportRequest
+	if err := e.BindBody(&body); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+	if body.Instance == "" || len(body.Spec) == 0 {
+		return apis.NewBadRequestError("Set instance and spec", nil)
+	}
+
+	instance, err := e.App.FindRecordById("instances", body.Instance)
+	if err != nil {
+		return apis.NewBadRequestError("Instance not found", err)
+	}
+
+	// The spec is either the document itself or a string holding it
+	spec := []byte(body.Spec)
+	var text string
+	if json.Unmarshal(body.Spec, &text) == nil {
+		spec = []byte(text)
+	}
+	doc, err := openapi.Parse(spec)
+	if err != nil {
+		return apis.NewBadRequestError(err.Error(), nil)
+	}
+
+	webhooks, err := instanceWebhookPaths(e.App, instance.Id)
+	if err != nil {
+		logger.Error("Failed to fetch webhooks", zap.Error(err))
+		return apis.NewInternalServerError("Failed to fetch webhooks", nil)
+	}
+
+	imported, err := doc.Routes(openapi.Options{
+		Host:     body.Host,
+		Mapping:  body.Mapping,
+		Webhooks: webhooks,
+	})
+	if err != nil {
+		return apis.NewBadRequestError(err.Error(), nil)
+	}
+
+	result := OpenAPIImportResult{
+		Created:  []ImportedRoute{},
+		Skipped:  imported.Skipped,
+		Warnings: imported.Warnings,
+	}
+	err = e.App.RunInTransaction(func(txApp core.App) error {
+		collection, err := txApp.FindCollectionByNameOrId("routes")
+		if err != nil {
+			return err
+		}
+
+		for _, route := range imported.Routes {
+			definition, err := route.Definition.Normalize()
+			if err != nil {
+				return validationErrors(err, recordFields)
+			}
+			summary := ImportedRoute{
+				Host:        definition.Host,
+				Path:        definition.Path,
+				WebhookPath: definition.ServicePath,
+				PathParams:  definition.PathParams,
+				QueryParams: definition.QueryParams,
+				Operations:  route.Operations,
+			}
+
+			existing, err := txApp.FindAllRecords(collection, dbx.HashExp{"host": definition.Host, "path": definition.Path})
+			if err != nil {
+				return err
+			}
+			if len(existing) > 0 {
+				summary.Id = existing[0].Id
+				result.Existing = append(result.Existing, summary)
+				continue
+			}
+
+			if !body.DryRun {
+				record := routeRecord(collection, instance.Id, definition, body.Active)
+				if err := txApp.Save(record); err != nil {
+					return err
+				}
+				summary.Id = record.Id
+			}
+			result.Created = append(result.Created, summary)
+		}
+		return nil
+	})
+	if err != nil {
+		return apis.NewBadRequestError("Failed to create routes", err)
+	}
+
+	if !body.DryRun {
+		logger.Info("Imported routes from OpenAPI document",
+			zap.String("instance", instance.Id),
+			zap.Int("created", len(result.Created)),
+			zap.Int("skipped", len(result.Skipped)))
+	}
+	return e.JSON(http.StatusOK, result)
+}
+
+// routeRecord returns a new routes record for definition, served by instance
+func routeRecord(collection *core.Collection, instance string, definition traefik.RouteDefinition, active bool) *core.Record {
+	record := core.NewRecord(collection)
+	record.Set("instance", instance)
+	record.Set("host", definition.Host)
+	record.Set("path", definition.Path)
+	record.Set("webhook_path", definition.ServicePath)
+	if len(definition.PathParams) > 0 {
+		record.Set("path_params", definition.PathParams)
+	}
+	if len(definition.QueryParams) > 0 {
+		record.Set("query_params", definition.QueryParams)
+	}
+	record.Set("active", active)
+	return record
+}
+
+// instanceWebhookPaths returns the paths of the synced webhooks of instance,
+// e.g. "/webhook/orders"
+func instanceWebhookPaths(app core.App, instance string) ([]string, error) {
+	records, err := app.FindAllRecords("webhooks", dbx.HashExp{"instance": instance})
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(records))
+	for _, record := range records {
+		if u, err := url.Parse(record.GetString("webhook_url")); err == nil {
+			paths = append(paths, u.Path)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"errors"
+	"net/url"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/policies"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/sistemica/n8n-manager-backend/trash"
+)
+
+// webhookActivity tells whether the workflows of webhooks are active, by
+// instance id and webhook path (e.g. "<id> /webhook/orders")
+type webhookActivity map[string]bool
+
+// loadWebhookActivity collects the activity of the webhooks of an instance,
+// or of all instances if instanceID is empty
+func loadWebhookActivity(app core.App, instanceID string) (webhookActivity, error) {
+	filter := dbx.HashExp{"archived": false, trash.Field: ""}
+	if instanceID != "" {
+		filter["instance"] = instanceID
+	}
+	var versions []struct {
+		Instance   string `db:"instance"`
+		WorkflowID string `db:"workflow_id"`
+		Active     bool   `db:"active"`
+	}
+	err := app.DB().
+		Select("instance", "workflow_id", "active").
+		From("workflows").
+		Where(filter).
+		OrderBy("updated_at DESC").
+		All(&versions)
+	if err != nil {
+		return nil, err
+	}
+	// Only the newest version of each workflow counts
+	active := map[string]bool{}
+	for _, version := range versions {
+		key := version.Instance + " " + version.WorkflowID
+		if _, seen := active[key]; !seen {
+			active[key] = version.Active
+		}
+	}
+
+	var webhookFilter dbx.Expression
+	if instanceID != "" {
+		webhookFilter = dbx.HashExp{"instance": instanceID}
+	}
+	webhooks, err := app.FindAllRecords("webhooks", webhookFilter)
+	if err != nil {
+		return nil, err
+	}
+	activity := webhookActivity{}
+	for _, webhook := range webhooks {
+		if u, err := url.Parse(webhook.GetString("webhook_url")); err == nil {
+			instance := webhook.GetString("instance")
+			activity[instance+" "+u.Path] = active[instance+" "+webhook.GetString("workflow_id")]
+		}
+	}
+	return activity, nil
+}
+
+// inactive reports whether the webhook at path is known and its workflow
+// inactive
+func (a webhookActivity) inactive(instanceID, path string) bool {
+	active, known := a[instanceID+" "+path]
+	return known && !active
+}
+
+// applyPolicy checks route against policy and applies its defaults: the
+// entrypoints of routes without their own and the rate limit
+func applyPolicy(route *traefik.RouteDefinition, policy *policies.Policy, authType string, ownEntryPoints, inactive bool) error {
+	if policy == nil {
+		return nil
+	}
+
+	if !ownEntryPoints && len(policy.EntryPoints) > 0 {
+		route.EntryPoints = policy.EntryPoints
+	}
+	err := policy.Check(policies.Route{
+		AuthType:    authType,
+		EntryPoints: route.EntryPoints,
+		Inactive:    inactive,
+	})
+	if err != nil {
+		return err
+	}
+
+	if policy.RateLimit != nil {
+		route.RateLimit = &traefik.RateLimitConfig{
+			Average: policy.RateLimit.Average,
+			Burst:   policy.RateLimit.Burst,
+		}
+	}
+	return nil
+}
+
+// hasEntryPoints reports whether a routes record sets its own entrypoints
+func hasEntryPoints(record *core.Record) bool {
+	var entryPoints []string
+	return record.UnmarshalJSONField("entrypoints", &entryPoints) == nil && len(entryPoints) > 0
+}
+
+// checkPolicy rejects active routes records breaking the policy of the
+// environment of their instance. New routes without entrypoints get the
+// ones of the policy.
+func checkPolicy(app core.App, record *core.Record) error {
+	instanceID := record.GetString("instance")
+	if instanceID == "" {
+		return nil
+	}
+	policy, err := policies.ForInstance(app, instanceID)
+	if err != nil || policy == nil {
+		// A missing instance is reported by the relation field
+		return nil
+	}
+
+	if record.IsNew() && !hasEntryPoints(record) && len(policy.EntryPoints) > 0 {
+		record.Set("entrypoints", policy.EntryPoints)
+	}
+	if !record.GetBool("active") {
+		return nil
+	}
+
+	activity, err := loadWebhookActivity(app, instanceID)
+	if err != nil {
+		return err
+	}
+	path := record.GetString("webhook_path")
+	if path == "" {
+		path = record.GetString("path")
+	}
+
+	var entryPoints []string
+	if err := record.UnmarshalJSONField("entrypoints", &entryPoints); err != nil || len(entryPoints) == 0 {
+		entryPoints = defaultEntryPoints
+	}
+	err = policy.Check(policies.Route{
+		AuthType:    record.GetString("auth_type"),
+		EntryPoints: entryPoints,
+		Inactive:    activity.inactive(instanceID, path),
+	})
+
+	var violations policies.Violations
+	if !errors.As(err, &violations) {
+		return err
+	}
+	errs := validation.Errors{}
+	for field, message := range violations {
+		errs[field] = validation.NewError("policy_violation", message)
+	}
+	return errs
+}
@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/sistemica/n8n-manager-backend/policies"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPolicy(t *testing.T) {
+	policy := &policies.Policy{
+		AuthTypes:   []string{"apikey"},
+		RateLimit:   &policies.RateLimit{Average: 60, Burst: 20},
+		EntryPoints: []string{"websecure"},
+	}
+
+	// Routes without own entrypoints get the ones of the policy
+	route := traefik.RouteDefinition{EntryPoints: defaultEntryPoints}
+	require.NoError(t, applyPolicy(&route, policy, "apikey", false, false))
+	assert.Equal(t, []string{"websecure"}, route.EntryPoints)
+	assert.Equal(t, &traefik.RateLimitConfig{Average: 60, Burst: 20}, route.RateLimit)
+
+	route = traefik.RouteDefinition{EntryPoints: []string{"web"}}
+	assert.Error(t, applyPolicy(&route, policy, "apikey", true, false))
+	assert.Nil(t, route.RateLimit)
+
+	route = traefik.RouteDefinition{EntryPoints: defaultEntryPoints}
+	assert.Error(t, applyPolicy(&route, policy, "", false, false))
+
+	// Routes without policy are left alone
+	route = traefik.RouteDefinition{EntryPoints: defaultEntryPoints}
+	require.NoError(t, applyPolicy(&route, nil, "", false, true))
+	assert.Equal(t, traefik.RouteDefinition{EntryPoints: defaultEntryPoints}, route)
+}
+
+func TestWebhookActivity(t *testing.T) {
+	activity := webhookActivity{
+		"abc /webhook/orders":  true,
+		"abc /webhook/invoice": false,
+	}
+	assert.False(t, activity.inactive("abc", "/webhook/orders"))
+	assert.True(t, activity.inactive("abc", "/webhook/invoice"))
+	// Unknown webhooks aren't known to be inactive
+	assert.False(t, activity.inactive("abc", "/webhook/unknown"))
+}
@@ -0,0 +1,236 @@
+package provider
+
+import (
+	"cmp"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"go.uber.org/zap"
+)
+
+// PostmanExportPath serves the exposed webhooks as Postman collection
+const PostmanExportPath = "/api/export/postman.json"
+
+// postmanSchema is the version of the exported collections
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanPathParam matches the parameters of route paths, e.g. "{userId}"
+var postmanPathParam = regexp.MustCompile(`^\{([^{}]+)\}$`)
+
+// postmanKeyValue is a header, query parameter, variable or auth attribute
+type postmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Basic  []postmanKeyValue `json:"basic,omitempty"`
+	Digest []postmanKeyValue `json:"digest,omitempty"`
+	Bearer []postmanKeyValue `json:"bearer,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string `json:"mode"`
+	Raw     string `json:"raw"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Header      []postmanKeyValue `json:"header"`
+	URL         postmanURL        `json:"url"`
+	Auth        *postmanAuth      `json:"auth,omitempty"`
+	Body        *postmanBody      `json:"body,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Request *postmanRequest `json:"request,omitempty"`
+
+	// Item holds the requests of folders
+	Item []postmanItem `json:"item,omitempty"`
+}
+
+// PostmanCollection is a Postman collection in format v2.1
+type PostmanCollection struct {
+	Info struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Schema      string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+// postmanVariables describe the collection variables requests may use
+var postmanVariables = map[string]string{
+	"password":    "Password of basic and digest auth",
+	"accessToken": "Access token of the OIDC provider",
+	"signature":   "HMAC signature of the request body",
+	"subdomain":   "Subdomain of wildcard hosts",
+}
+
+// postmanExportHandler serves the routes, optionally of one ?audience=, as
+// Postman collection. ?scheme= sets the scheme of the URLs, https by default.
+func postmanExportHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	scheme, audience, err := endpointOptions(e)
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := loadEndpoints(e.App, logger)
+	if err != nil {
+		logger.Error("Failed to load routes", zap.Error(err))
+		return apis.NewInternalServerError("Failed to load routes", nil)
+	}
+	endpoints = filterEndpoints(endpoints, audience)
+
+	e.Response.Header().Set("Content-Disposition", `attachment; filename="postman.json"`)
+	return e.JSON(http.StatusOK, postmanCollection("n8n webhooks", scheme, endpoints))
+}
+
+// postmanCollection returns the collection of endpoints, with a folder per
+// host. Endpoints without known methods are exported as POST, n8n's default.
+func postmanCollection(name, scheme string, endpoints []endpoint) *PostmanCollection {
+	collection := &PostmanCollection{Item: []postmanItem{}}
+	collection.Info.Name = name
+	collection.Info.Description = "Webhooks exposed by the n8n manager. Set the collection variables before sending requests."
+	collection.Info.Schema = postmanSchema
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].Host != endpoints[j].Host {
+			return endpoints[i].Host < endpoints[j].Host
+		}
+		return endpoints[i].Path < endpoints[j].Path
+	})
+
+	used := map[string]bool{}
+	for _, ep := range endpoints {
+		if len(collection.Item) == 0 || collection.Item[len(collection.Item)-1].Name != ep.Host {
+			collection.Item = append(collection.Item, postmanItem{Name: ep.Host})
+		}
+		folder := &collection.Item[len(collection.Item)-1]
+
+		methods := ep.Methods
+		if len(methods) == 0 {
+			methods = []string{http.MethodPost}
+		}
+		for _, method := range methods {
+			folder.Item = append(folder.Item, postmanItem{
+				Name:    method + " " + ep.Path,
+				Request: postmanRequestOf(ep, strings.ToUpper(method), scheme, used),
+			})
+		}
+	}
+
+	for _, variable := range slices.Sorted(maps.Keys(used)) {
+		collection.Variable = append(collection.Variable, postmanKeyValue{
+			Key:         variable,
+			Description: postmanVariables[variable],
+		})
+	}
+	return collection
+}
+
+// postmanRequestOf returns the request calling ep with method, adding the
+// collection variables it uses to used
+func postmanRequestOf(ep endpoint, method, scheme string, used map[string]bool) *postmanRequest {
+	request := &postmanRequest{
+		Method:      method,
+		Header:      []postmanKeyValue{},
+		Description: cmp.Or(ep.Description, ep.Workflow),
+	}
+
+	host := ep.Host
+	if domain, ok := strings.CutPrefix(host, "*."); ok {
+		host = "{{subdomain}}." + domain
+		used["subdomain"] = true
+	}
+	request.URL = postmanURL{
+		Protocol: scheme,
+		Host:     strings.Split(host, "."),
+	}
+	for _, segment := range strings.Split(strings.Trim(ep.Path, "/"), "/") {
+		if match := postmanPathParam.FindStringSubmatch(segment); match != nil {
+			segment = ":" + match[1]
+			request.URL.Variable = append(request.URL.Variable, postmanKeyValue{Key: match[1]})
+		}
+		request.URL.Path = append(request.URL.Path, segment)
+	}
+	request.URL.Raw = scheme + "://" + host + "/" + strings.Join(request.URL.Path, "/")
+	for i, param := range ep.QueryParams {
+		request.URL.Query = append(request.URL.Query, postmanKeyValue{Key: param})
+		if i == 0 {
+			request.URL.Raw += "?"
+		} else {
+			request.URL.Raw += "&"
+		}
+		request.URL.Raw += param + "="
+	}
+
+	switch ep.AuthType {
+	case "basic", "digest":
+		credentials := []postmanKeyValue{
+			{Key: "username", Value: ep.Username},
+			{Key: "password", Value: "{{password}}"},
+		}
+		request.Auth = &postmanAuth{Type: ep.AuthType}
+		if ep.AuthType == "basic" {
+			request.Auth.Basic = credentials
+		} else {
+			request.Auth.Digest = credentials
+		}
+		used["password"] = true
+	case "oidc":
+		request.Auth = &postmanAuth{
+			Type:   "bearer",
+			Bearer: []postmanKeyValue{{Key: "token", Value: "{{accessToken}}"}},
+		}
+		used["accessToken"] = true
+	case "hmac":
+		request.Header = append(request.Header, postmanKeyValue{
+			Key:         ep.SignatureHeader,
+			Value:       "{{signature}}",
+			Description: "HMAC of the request body with the shared secret",
+		})
+		used["signature"] = true
+	}
+
+	if ep.ReplayHeader != "" {
+		request.Header = append(request.Header, postmanKeyValue{
+			Key:         ep.ReplayHeader,
+			Value:       "{{$guid}}",
+			Description: "Unique per delivery, repeated values are rejected",
+		})
+	}
+
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		request.Header = append(request.Header, postmanKeyValue{Key: "Content-Type", Value: "application/json"})
+		request.Body = &postmanBody{Mode: "raw", Raw: "{}"}
+		request.Body.Options.Raw.Language = "json"
+	}
+	return request
+}
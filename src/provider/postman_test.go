@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostmanCollection(t *testing.T) {
+	collection := postmanCollection("n8n webhooks", "https", []endpoint{
+		{Host: "hooks.example.com", Path: "/users/{userId}", Methods: []string{"GET", "PATCH"},
+			QueryParams: []string{"version", "lang"}, AuthType: "basic", Username: "partner"},
+		{Host: "*.tenants.example.com", Path: "/events", AuthType: "hmac", SignatureHeader: "X-Hub-Signature-256",
+			ReplayHeader: "Idempotency-Key"},
+		{Host: "hooks.example.com", Path: "/orders", Methods: []string{"POST"}, AuthType: "apikey", Workflow: "Orders"},
+	})
+
+	assert.Equal(t, postmanSchema, collection.Info.Schema)
+	require.Len(t, collection.Item, 2)
+
+	tenants := collection.Item[0]
+	assert.Equal(t, "*.tenants.example.com", tenants.Name)
+	require.Len(t, tenants.Item, 1)
+	events := tenants.Item[0].Request
+	assert.Equal(t, "POST", events.Method, "endpoints without known methods use POST")
+	assert.Equal(t, "https://{{subdomain}}.tenants.example.com/events", events.URL.Raw)
+	assert.Nil(t, events.Auth)
+	assert.Equal(t, []postmanKeyValue{
+		{Key: "X-Hub-Signature-256", Value: "{{signature}}", Description: "HMAC of the request body with the shared secret"},
+		{Key: "Idempotency-Key", Value: "{{$guid}}", Description: "Unique per delivery, repeated values are rejected"},
+		{Key: "Content-Type", Value: "application/json"},
+	}, events.Header)
+	require.NotNil(t, events.Body)
+
+	hooks := collection.Item[1]
+	require.Len(t, hooks.Item, 3)
+	orders := hooks.Item[0]
+	assert.Equal(t, "POST /orders", orders.Name)
+	assert.Nil(t, orders.Request.Auth, "API keys are added by Traefik")
+	assert.Equal(t, "Orders", orders.Request.Description)
+
+	user := hooks.Item[1].Request
+	assert.Equal(t, "GET", user.Method)
+	assert.Nil(t, user.Body)
+	assert.Equal(t, "https://hooks.example.com/users/:userId?version=&lang=", user.URL.Raw)
+	assert.Equal(t, []string{"hooks", "example", "com"}, user.URL.Host)
+	assert.Equal(t, []string{"users", ":userId"}, user.URL.Path)
+	assert.Equal(t, []postmanKeyValue{{Key: "userId"}}, user.URL.Variable)
+	require.NotNil(t, user.Auth)
+	assert.Equal(t, "basic", user.Auth.Type)
+	assert.Equal(t, []postmanKeyValue{{Key: "username", Value: "partner"}, {Key: "password", Value: "{{password}}"}}, user.Auth.Basic)
+	assert.Equal(t, "PATCH", hooks.Item[2].Request.Method)
+
+	keys := make([]string, len(collection.Variable))
+	for i, variable := range collection.Variable {
+		keys[i] = variable.Key
+	}
+	assert.Equal(t, []string{"password", "signature", "subdomain"}, keys)
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+)
+
+// PreviewPath is the endpoint previewing the configuration of a single route
+const PreviewPath = "/api/traefik/preview"
+
+// PreviewRequest is the body of POST /api/traefik/preview. Either Route or
+// Webhook must be set. Route fields use the RouteDefinition field names,
+// e.g. {"host": "hooks.example.com", "path": "/orders", "entryPoints": ["web"]}.
+type PreviewRequest struct {
+	// Route is previewed as is
+	Route *traefik.RouteDefinition `json:"route,omitempty"`
+
+	// Instance is the id of the instance record serving Route, used if the
+	// route has no service
+	Instance string `json:"instance,omitempty"`
+
+	// Webhook is the id of a webhook record whose route is previewed. Its
+	// "route:" annotation may be missing if Overrides sets a host.
+	Webhook string `json:"webhook,omitempty"`
+
+	// Overrides replace the non-empty fields of the previewed route
+	Overrides *traefik.RouteDefinition `json:"overrides,omitempty"`
+}
+
+// previewHandler returns the routers, services and middlewares the builder
+// generates for a route, without storing anything
+func previewHandler(e *core.RequestEvent) error {
+	var body PreviewRequest
+	if err := e.BindBody(&body); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+
+	var route traefik.RouteDefinition
+	switch {
+	case body.Route != nil && body.Webhook != "":
+		return apis.NewBadRequestError("Set either route or webhook", nil)
+	case body.Route != nil:
+		route = *body.Route
+		if route.EntryPoints == nil {
+			route.EntryPoints = defaultEntryPoints
+		}
+		if route.Service.Host == "" && body.Instance != "" {
+			instance, err := e.App.FindRecordById("instances", body.Instance)
+			if err != nil {
+				return apis.NewBadRequestError("Instance not found", err)
+			}
+			if route.Service, err = serviceFromHost(instance.GetString("host")); err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+		}
+	case body.Webhook != "":
+		webhook, err := e.App.FindRecordById("webhooks", body.Webhook)
+		if err != nil {
+			return apis.NewNotFoundError("Webhook not found", err)
+		}
+		instance, err := e.App.FindRecordById("instances", webhook.GetString("instance"))
+		if err != nil {
+			return apis.NewBadRequestError("Instance of webhook not found", err)
+		}
+
+		// Preview a route for a webhook that isn't annotated yet
+		if webhook.GetString("route") == "" && body.Overrides != nil && body.Overrides.Host != "" {
+			webhook = webhook.Clone()
+			webhook.Set("route", body.Overrides.Host)
+		}
+		if route, err = routeFromWebhook(webhook, instance.GetString("host")); err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+	default:
+		return apis.NewBadRequestError("Set either route or webhook", nil)
+	}
+
+	if body.Overrides != nil {
+		applyOverrides(&route, *body.Overrides)
+	}
+	if route.ErrorPages == nil {
+		route.ErrorPages = defaultErrorPages()
+	}
+	route, err := route.Normalize()
+	if err != nil {
+		return apis.NewBadRequestError("Invalid route", validationErrors(err, nil))
+	}
+	if route.Service.Host == "" {
+		return apis.NewBadRequestError("Invalid route", validation.Errors{
+			"service": validation.NewError("missing_service", "set service or instance"),
+		})
+	}
+
+	return e.JSON(http.StatusOK, traefik.NewBuilder(builderOptions()...).Build([]traefik.RouteDefinition{route}))
+}
+
+// applyOverrides replaces the fields of route set in overrides
+func applyOverrides(route *traefik.RouteDefinition, overrides traefik.RouteDefinition) {
+	if overrides.Host != "" {
+		route.Host = overrides.Host
+	}
+	if overrides.Hosts != nil {
+		route.Hosts = overrides.Hosts
+	}
+	if overrides.SubdomainHeader != "" {
+		route.SubdomainHeader = overrides.SubdomainHeader
+	}
+	if overrides.Path != "" {
+		route.Path = overrides.Path
+	}
+	if overrides.ServicePath != "" {
+		route.ServicePath = overrides.ServicePath
+	}
+	if overrides.PathParams != nil {
+		route.PathParams = overrides.PathParams
+	}
+	if overrides.QueryParams != nil {
+		route.QueryParams = overrides.QueryParams
+	}
+	if overrides.EntryPoints != nil {
+		route.EntryPoints = overrides.EntryPoints
+	}
+	if overrides.Audience != "" {
+		route.Audience = overrides.Audience
+	}
+	if overrides.Service.Host != "" {
+		route.Service = overrides.Service
+	}
+	if overrides.SetRequestHeaders != nil {
+		route.SetRequestHeaders = overrides.SetRequestHeaders
+	}
+	if overrides.RemoveRequestHeaders != nil {
+		route.RemoveRequestHeaders = overrides.RemoveRequestHeaders
+	}
+	if overrides.SetResponseHeaders != nil {
+		route.SetResponseHeaders = overrides.SetResponseHeaders
+	}
+	if overrides.Authentication != nil {
+		route.Authentication = overrides.Authentication
+	}
+	if overrides.GeoIPCheckURL != "" {
+		route.GeoIPCheckURL = overrides.GeoIPCheckURL
+	}
+	if overrides.ReplayCheckURL != "" {
+		route.ReplayCheckURL = overrides.ReplayCheckURL
+	}
+	if overrides.QuotaCheckURL != "" {
+		route.QuotaCheckURL = overrides.QuotaCheckURL
+	}
+	if overrides.CaptureURL != "" {
+		route.CaptureURL = overrides.CaptureURL
+	}
+	if overrides.Observability != nil {
+		route.Observability = overrides.Observability
+	}
+	if overrides.ErrorPages != nil {
+		route.ErrorPages = overrides.ErrorPages
+	}
+}
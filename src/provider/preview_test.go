@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	route := traefik.RouteDefinition{
+		Host:        "hooks.example.com",
+		Path:        "/webhook/abc",
+		ServicePath: "/webhook/abc",
+		EntryPoints: []string{"web"},
+		Service:     traefik.ServiceDefinition{Host: "n8n", Port: 5678},
+	}
+
+	applyOverrides(&route, traefik.RouteDefinition{
+		Path:        "/orders",
+		EntryPoints: []string{"websecure"},
+		Audience:    "external",
+	})
+
+	assert.Equal(t, traefik.RouteDefinition{
+		Host:        "hooks.example.com",
+		Path:        "/orders",
+		ServicePath: "/webhook/abc",
+		EntryPoints: []string{"websecure"},
+		Audience:    "external",
+		Service:     traefik.ServiceDefinition{Host: "n8n", Port: 5678},
+	}, route)
+}
+
+func TestValidationErrors(t *testing.T) {
+	_, err := traefik.RouteDefinition{Host: "bad host", Path: "/x", ServicePath: "webhook/x"}.Normalize()
+
+	errs, ok := validationErrors(err, recordFields).(validation.Errors)
+	require.True(t, ok)
+	assert.Contains(t, errs, "host")
+	assert.Contains(t, errs, "webhook_path")
+	assert.NotContains(t, errs, "path")
+}
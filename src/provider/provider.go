@@ -0,0 +1,170 @@
+// Package provider serves Traefik's dynamic configuration over HTTP, built
+// from the routes and annotated webhooks stored in PocketBase.
+package provider
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/tracing"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ConfigPath is the endpoint Traefik's HTTP provider polls
+const ConfigPath = "/api/traefik/config"
+
+// Audiences are the route audiences served as separate config documents at
+// ConfigPath/{audience}, e.g. for a DMZ and an internal Traefik instance
+var Audiences = []string{"internal", "external"}
+
+var tracer = tracing.Tracer("github.com/sistemica/n8n-manager-backend/provider")
+
+// InitRoutes registers the Traefik provider endpoints, the route preview and
+// the jobs of expiring routes
+func InitRoutes(app core.App, logger *zap.Logger) {
+	bindValidation(app)
+	bindInvalidation(app)
+	bindApproval(app, logger)
+	bindExpiry(app)
+	initExpiryCron(app, logger)
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET(ConfigPath, func(e *core.RequestEvent) error {
+			return configHandler(e, "", logger)
+		}).Bind(RequireProviderAuth())
+		se.Router.GET(ConfigPath+"/{audience}", func(e *core.RequestEvent) error {
+			audience := e.Request.PathValue("audience")
+			if !slices.Contains(Audiences, audience) {
+				return apis.NewNotFoundError("Unknown audience", nil)
+			}
+			return configHandler(e, audience, logger)
+		}).Bind(RequireProviderAuth())
+		se.Router.POST(PreviewPath, previewHandler).Bind(apis.RequireSuperuserAuth())
+		se.Router.GET(StatusPagePath, func(e *core.RequestEvent) error {
+			return statusPageHandler(e, logger)
+		})
+		se.Router.GET(CatalogPath, func(e *core.RequestEvent) error {
+			return catalogHandler(e, logger)
+		}).Bind(apis.RequireAuth())
+		se.Router.GET(PostmanExportPath, func(e *core.RequestEvent) error {
+			return postmanExportHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+		se.Router.POST(OpenAPIImportPath, func(e *core.RequestEvent) error {
+			return openAPIImportHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		// Traefik forwards the method of the original request
+		se.Router.Any(OIDCAuthPath, func(e *core.RequestEvent) error {
+			return oidcAuthHandler(e, logger)
+		})
+		se.Router.Any(HMACAuthPath+"/{route}", func(e *core.RequestEvent) error {
+			return hmacAuthHandler(e, logger)
+		})
+		se.Router.Any(GeoIPPath+"/{route}", func(e *core.RequestEvent) error {
+			return geoIPHandler(e, logger)
+		})
+		se.Router.Any(ReplayPath+"/{route}", replayHandler)
+		se.Router.Any(ReplayPath+"/{route}/release", replayReleaseHandler)
+		se.Router.Any(QuotaPath+"/{route}", func(e *core.RequestEvent) error {
+			return quotaHandler(e, logger)
+		})
+		se.Router.Any(CapturePath+"/{route}", func(e *core.RequestEvent) error {
+			return captureHandler(e, logger)
+		})
+		se.Router.POST("/api/routes/{id}/approve", func(e *core.RequestEvent) error {
+			return approveRouteHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+		se.Router.POST("/api/captures/{id}/replay", func(e *core.RequestEvent) error {
+			return captureReplayHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
+
+// BuildConfig loads the routes of audience and builds the Traefik dynamic
+// configuration. Routes without an audience are part of every document, an
+// empty audience selects all routes.
+func BuildConfig(ctx context.Context, app core.App, audience string, logger *zap.Logger) (config *traefik.DynamicConfig, err error) {
+	ctx, span := tracer.Start(ctx, "BuildConfig", trace.WithAttributes(
+		attribute.String("traefik.audience", audience),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	routes, err := LoadRoutes(ctx, app, logger)
+	if err != nil {
+		return nil, err
+	}
+	routes = FilterAudience(routes, audience)
+
+	_, buildSpan := tracer.Start(ctx, "traefik.Build", trace.WithAttributes(
+		attribute.Int("traefik.routes.count", len(routes)),
+	))
+	config = traefik.NewBuilder(builderOptions()...).Build(routes)
+	if maintenance.Enabled(app, logger) {
+		traefik.ApplyMaintenance(config, maintenance.ServiceURL(), maintenance.Path)
+		buildSpan.SetAttributes(attribute.Bool("traefik.maintenance", true))
+	}
+	buildSpan.SetAttributes(
+		attribute.Int("traefik.routers.count", len(config.HTTP.Routers)),
+		attribute.Int("traefik.middlewares.count", len(config.HTTP.Middlewares)),
+	)
+	buildSpan.End()
+
+	return config, nil
+}
+
+// FilterAudience returns the routes served to audience
+func FilterAudience(routes []traefik.RouteDefinition, audience string) []traefik.RouteDefinition {
+	if audience == "" {
+		return routes
+	}
+	filtered := make([]traefik.RouteDefinition, 0, len(routes))
+	for _, route := range routes {
+		if route.Audience == "" || route.Audience == audience {
+			filtered = append(filtered, route)
+		}
+	}
+	return filtered
+}
+
+// configHandler serves the current dynamic configuration of audience as
+// JSON, or as YAML with ?format=yaml or an Accept header asking for YAML.
+// The configuration is only rebuilt after the revision changed, Last-Modified
+// and ETag only change with its content. Until the first sync completed,
+// a configuration that fails to build is served empty instead of an error.
+func configHandler(e *core.RequestEvent, audience string, logger *zap.Logger) error {
+	format, contentType := FormatJSON, "application/json"
+	if e.Request.URL.Query().Get("format") == FormatYAML || strings.Contains(e.Request.Header.Get("Accept"), "yaml") {
+		format, contentType = FormatYAML, "application/yaml"
+	}
+
+	status := StatusReady
+	if !warmedUp(e.App) {
+		status = StatusWarmingUp
+	}
+
+	doc, err := cache.get(audience, format, func() (*traefik.DynamicConfig, error) {
+		return BuildConfig(e.Request.Context(), e.App, audience, logger)
+	})
+	if err != nil && status == StatusWarmingUp {
+		// The routes may not be synced yet, answer with a valid configuration
+		logger.Warn("Serving empty Traefik config while warming up", zap.Error(err))
+		doc, err = emptyDocument(format)
+	}
+	if err != nil {
+		logger.Error("Failed to load routes", zap.Error(err))
+		return apis.NewInternalServerError("Failed to load routes", nil)
+	}
+
+	setProviderHeaders(e.Response, status)
+	traefik.ServeConfig(e.Response, e.Request, contentType, doc.data, doc.etag, doc.modifiedAt)
+	return nil
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterAudience(t *testing.T) {
+	routes := []traefik.RouteDefinition{
+		{Host: "shared.example.com"},
+		{Host: "internal.example.com", Audience: "internal"},
+		{Host: "public.example.com", Audience: "external"},
+	}
+
+	hosts := func(routes []traefik.RouteDefinition) []string {
+		var hosts []string
+		for _, route := range routes {
+			hosts = append(hosts, route.Host)
+		}
+		return hosts
+	}
+
+	assert.Equal(t, []string{"shared.example.com", "internal.example.com", "public.example.com"},
+		hosts(FilterAudience(routes, "")))
+	assert.Equal(t, []string{"shared.example.com", "internal.example.com"},
+		hosts(FilterAudience(routes, "internal")))
+	assert.Equal(t, []string{"shared.example.com", "public.example.com"},
+		hosts(FilterAudience(routes, "external")))
+}
@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/usage"
+	"go.uber.org/zap"
+)
+
+// QuotaPath is the forwardAuth endpoint enforcing the quota of routes,
+// followed by the id of the route
+const QuotaPath = "/api/traefik/auth/quota"
+
+// AnalyticsCollection stores the request counters of routes with a quota
+const AnalyticsCollection = "analytics"
+
+// Quota periods, counted in UTC
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+// Quota subjects
+const (
+	QuotaPerAPIKey = "api_key"
+	QuotaPerIP     = "ip"
+)
+
+// quotaConfig is the "quota" field of routes records, e.g.
+// {"per": "api_key", "daily": 1000, "monthly": 20000}
+type quotaConfig struct {
+	// Per is what requests are counted by, "api_key" (default) or "ip".
+	// "api_key" counts by the client the auth of the route verified, the
+	// subject of its OIDC token or its basic or digest auth user, requests
+	// of routes without such auth are counted by IP. Headers the client
+	// sends unverified, like an API key n8n checks, would let it get a new
+	// quota with every request.
+	Per string `json:"per,omitempty"`
+
+	// Daily and Monthly limit the requests per subject, 0 is unlimited
+	Daily   int `json:"daily,omitempty"`
+	Monthly int `json:"monthly,omitempty"`
+}
+
+// enabled reports whether c limits any period
+func (c quotaConfig) enabled() bool {
+	return c.Daily > 0 || c.Monthly > 0
+}
+
+// limits returns the limit of each period of c
+func (c quotaConfig) limits() map[string]int {
+	limits := map[string]int{}
+	if c.Daily > 0 {
+		limits[PeriodDay] = c.Daily
+	}
+	if c.Monthly > 0 {
+		limits[PeriodMonth] = c.Monthly
+	}
+	return limits
+}
+
+// subject returns who the request r of a route with authType is counted
+// for. The IP is the one Traefik forwards, requests are only accepted from
+// Traefik which overwrites X-Real-Ip and X-Forwarded-For of clients.
+func (c quotaConfig) subject(r *http.Request, authType string) string {
+	if c.Per == QuotaPerIP {
+		return "ip:" + usage.ForwardedIP(r)
+	}
+	return usage.Subject(r, authType)
+}
+
+// quotaBucket returns the bucket of period containing now, e.g. 2025-04-14
+// or 2025-04, and when it ends
+func quotaBucket(period string, now time.Time) (bucket string, ends time.Time) {
+	now = now.UTC()
+	if period == PeriodMonth {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start.AddDate(0, 1, 0)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01-02"), start.AddDate(0, 0, 1)
+}
+
+// quotaMu serializes the counter updates, so concurrent requests can't
+// both take the last request of a quota
+var quotaMu sync.Mutex
+
+// countRequest counts a request of subject on route against the limits and
+// returns how long to wait if a quota is used up, in which case nothing is
+// counted
+func countRequest(app core.App, routeId, subject string, limits map[string]int, now time.Time) (time.Duration, error) {
+	collection, err := app.FindCollectionByNameOrId(AnalyticsCollection)
+	if err != nil {
+		return 0, err
+	}
+
+	quotaMu.Lock()
+	defer quotaMu.Unlock()
+
+	counters := make([]*core.Record, 0, len(limits))
+	var retryAfter time.Duration
+	for period, limit := range limits {
+		bucket, ends := quotaBucket(period, now)
+		counter, err := app.FindFirstRecordByFilter(AnalyticsCollection,
+			"route = {:route} && subject = {:subject} && period = {:period} && bucket = {:bucket}",
+			dbx.Params{"route": routeId, "subject": subject, "period": period, "bucket": bucket})
+		if err != nil {
+			counter = core.NewRecord(collection)
+			counter.Set("route", routeId)
+			counter.Set("subject", subject)
+			counter.Set("period", period)
+			counter.Set("bucket", bucket)
+		}
+		if counter.GetInt("count") >= limit {
+			retryAfter = max(retryAfter, ends.Sub(now))
+		}
+		counter.Set("quota", limit)
+		counters = append(counters, counter)
+	}
+	if retryAfter > 0 {
+		return retryAfter, nil
+	}
+
+	for _, counter := range counters {
+		counter.Set("count", counter.GetInt("count")+1)
+		if err := app.Save(counter); err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+// quotaCheckURL returns the forwardAuth address enforcing the quota of the
+// route with id routeId
+func quotaCheckURL(routeId string) (string, error) {
+	base := authURL()
+	if base == "" || managerToken() == "" {
+		return "", errors.New("quotas require TRAEFIK_AUTH_URL and TRAEFIK_AUTH_TOKEN")
+	}
+	return base + QuotaPath + "/" + routeId, nil
+}
+
+// quotaHandler answers forwardAuth requests of Traefik for the route in the
+// path, rejecting requests beyond the quota with 429 and Retry-After.
+// Requests are let through if they can't be counted.
+func quotaHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	if err := requireManagerToken(e); err != nil {
+		return err
+	}
+	route, err := e.App.FindRecordById("routes", e.Request.PathValue("route"))
+	if err != nil {
+		return apis.NewNotFoundError("Route not found", nil)
+	}
+
+	var config quotaConfig
+	if err := route.UnmarshalJSONField("quota", &config); err != nil || !config.enabled() {
+		return e.NoContent(http.StatusNoContent)
+	}
+
+	retryAfter, err := countRequest(e.App, route.Id, config.subject(e.Request, route.GetString("auth_type")), config.limits(), time.Now())
+	if err != nil {
+		logger.Warn("Failed to count request", zap.String("route", route.Id), zap.Error(err))
+		return e.NoContent(http.StatusNoContent)
+	}
+	if retryAfter > 0 {
+		e.Response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		return apis.NewApiError(http.StatusTooManyRequests, "Quota exceeded", nil)
+	}
+	return e.NoContent(http.StatusNoContent)
+}
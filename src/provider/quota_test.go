@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaBucket(t *testing.T) {
+	now := time.Date(2025, time.December, 31, 22, 30, 0, 0, time.UTC)
+
+	bucket, ends := quotaBucket(PeriodDay, now)
+	assert.Equal(t, "2025-12-31", bucket)
+	assert.Equal(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), ends)
+
+	bucket, ends = quotaBucket(PeriodMonth, now)
+	assert.Equal(t, "2025-12", bucket)
+	assert.Equal(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), ends)
+
+	// Buckets are counted in UTC
+	bucket, _ = quotaBucket(PeriodDay, now.In(time.FixedZone("CET", 3600)))
+	assert.Equal(t, "2025-12-31", bucket)
+}
+
+func TestQuotaSubject(t *testing.T) {
+	request := func(header http.Header) *http.Request {
+		return &http.Request{Header: header}
+	}
+	byKey := quotaConfig{Daily: 10}
+
+	// Unverified API keys would give every request a new quota
+	assert.Equal(t, "ip:203.0.113.7", byKey.subject(request(http.Header{"X-Api-Key": {"random"}, "X-Real-Ip": {"203.0.113.7"}}), ""))
+	assert.Equal(t, "ip:203.0.113.7", byKey.subject(request(http.Header{"X-Api-Key": {"random"}, "X-Real-Ip": {"203.0.113.7"}}), "apikey"))
+
+	oidc := request(http.Header{"X-Auth-Subject": {"client-42"}, "X-Real-Ip": {"203.0.113.7"}})
+	assert.Equal(t, "sub:client-42", byKey.subject(oidc, "oidc"))
+	assert.Equal(t, "ip:203.0.113.7", byKey.subject(oidc, ""), "the subject is only verified on oidc routes")
+
+	basic := &http.Request{Header: http.Header{}}
+	basic.SetBasicAuth("partner", "secret")
+	assert.Equal(t, "user:partner", byKey.subject(basic, "basic"))
+	digest := request(http.Header{"Authorization": {`Digest username="partner", realm="n8n", nonce="abc", response="def"`}})
+	assert.Equal(t, "user:partner", byKey.subject(digest, "digest"))
+
+	byIP := quotaConfig{Per: QuotaPerIP, Daily: 10}
+	assert.Equal(t, "ip:203.0.113.7", byIP.subject(oidc, "oidc"))
+	assert.Equal(t, "ip:198.51.100.2", byIP.subject(request(http.Header{"X-Forwarded-For": {"10.0.0.1, 198.51.100.2"}}), ""), "the entry added by Traefik is used")
+}
+
+func TestRequireManagerToken(t *testing.T) {
+	event := func(token string) *core.RequestEvent {
+		e := &core.RequestEvent{}
+		e.Request = httptest.NewRequest(http.MethodPost, QuotaPath+"/r1", nil)
+		if token != "" {
+			e.Request.Header.Set(traefik.ManagerTokenHeader, token)
+		}
+		return e
+	}
+
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "")
+	var apiErr *router.ApiError
+	require.ErrorAs(t, requireManagerToken(event("anything")), &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "manager-secret")
+	assert.NoError(t, requireManagerToken(event("manager-secret")))
+	for _, token := range []string{"", "wrong"} {
+		require.ErrorAs(t, requireManagerToken(event(token)), &apiErr)
+		assert.Equal(t, http.StatusUnauthorized, apiErr.Status)
+	}
+}
+
+func TestQuotaCheckURL(t *testing.T) {
+	t.Setenv("TRAEFIK_AUTH_URL", "http://manager:8090/")
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "")
+	_, err := quotaCheckURL("r1")
+	assert.Error(t, err, "quotas require the manager token")
+
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "manager-secret")
+	address, err := quotaCheckURL("r1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://manager:8090"+QuotaPath+"/r1", address)
+}
+
+func TestQuotaLimits(t *testing.T) {
+	assert.False(t, quotaConfig{Per: QuotaPerIP}.enabled())
+	assert.Equal(t, map[string]int{PeriodDay: 100}, quotaConfig{Daily: 100}.limits())
+	assert.Equal(t, map[string]int{PeriodDay: 100, PeriodMonth: 2000}, quotaConfig{Daily: 100, Monthly: 2000}.limits())
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ReplayPath is the forwardAuth endpoint rejecting replayed deliveries of
+// routes with replay protection, followed by the id of the route
+const ReplayPath = "/api/traefik/auth/replay"
+
+const (
+	defaultReplayTTL = time.Hour
+	maxReplayTTL     = 24 * time.Hour
+
+	// pruneInterval is how often expired keys are dropped
+	pruneInterval = time.Minute
+)
+
+// replayConfig is the "replay_protection" field of routes records, e.g.
+// {"header": "X-GitHub-Delivery", "ttl": "1h"}
+type replayConfig struct {
+	// Header carries the idempotency key or delivery id, requests without
+	// it aren't checked
+	Header string `json:"header,omitempty"`
+
+	// TTL is how long keys are remembered, one hour by default and at most
+	// a day
+	TTL string `json:"ttl,omitempty"`
+}
+
+// ttl returns how long the keys of c are remembered
+func (c replayConfig) ttl() time.Duration {
+	ttl, err := time.ParseDuration(c.TTL)
+	if err != nil || ttl <= 0 {
+		return defaultReplayTTL
+	}
+	return min(ttl, maxReplayTTL)
+}
+
+// keyStore remembers keys until they expire. It is kept in memory, keys
+// are forgotten when the manager restarts.
+type keyStore struct {
+	mu       sync.Mutex
+	expires  map[string]time.Time
+	prunedAt time.Time
+}
+
+// seenKeys are the keys of recent deliveries of all routes
+var seenKeys = &keyStore{expires: map[string]time.Time{}}
+
+// add remembers key for ttl and reports whether it is new, i.e. it wasn't
+// remembered already
+func (s *keyStore) add(key string, ttl time.Duration, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.prunedAt) >= pruneInterval {
+		for k, expires := range s.expires {
+			if !now.Before(expires) {
+				delete(s.expires, k)
+			}
+		}
+		s.prunedAt = now
+	}
+
+	if expires, ok := s.expires[key]; ok && now.Before(expires) {
+		return false
+	}
+	s.expires[key] = now.Add(ttl)
+	return true
+}
+
+// remove forgets key
+func (s *keyStore) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.expires, key)
+}
+
+// replayCheckURL returns the forwardAuth address checking the deliveries of
+// the route with id routeId
+func replayCheckURL(routeId string) (string, error) {
+	base := authURL()
+	if base == "" || managerToken() == "" {
+		return "", errors.New("replay protection requires TRAEFIK_AUTH_URL and TRAEFIK_AUTH_TOKEN")
+	}
+	return base + ReplayPath + "/" + routeId, nil
+}
+
+// releaseSignature authenticates the release address of the route with id
+// routeId. Traefik's errors middleware can't add headers and the token is
+// removed before the request reaches the service, so the address carries
+// an HMAC of the route with TRAEFIK_AUTH_TOKEN.
+func releaseSignature(routeId string) string {
+	mac := hmac.New(sha256.New, []byte(managerToken()))
+	mac.Write([]byte("replay-release\n" + routeId))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// replayReleaseURL returns the address Traefik calls when the service
+// fails a delivery of the route with id routeId
+func replayReleaseURL(routeId string) string {
+	return authURL() + ReplayPath + "/" + routeId + "/release?sig=" + releaseSignature(routeId)
+}
+
+// replayKey returns the key of the delivery r of route, empty if the route
+// has no replay protection or r no key
+func replayKey(route *core.Record, r *http.Request) (string, replayConfig) {
+	var config replayConfig
+	if err := route.UnmarshalJSONField("replay_protection", &config); err != nil || config.Header == "" {
+		return "", config
+	}
+	key := r.Header.Get(config.Header)
+	if key == "" {
+		return "", config
+	}
+	return route.Id + "\x00" + key, config
+}
+
+// replayHandler answers forwardAuth requests of Traefik for the route in the
+// path, rejecting requests whose key was seen within the TTL of the route.
+// The key is released again if the service fails the delivery, see
+// replayReleaseHandler.
+func replayHandler(e *core.RequestEvent) error {
+	if err := requireManagerToken(e); err != nil {
+		return err
+	}
+	route, err := e.App.FindRecordById("routes", e.Request.PathValue("route"))
+	if err != nil {
+		return apis.NewNotFoundError("Route not found", nil)
+	}
+
+	key, config := replayKey(route, e.Request)
+	if key == "" {
+		return e.NoContent(http.StatusNoContent)
+	}
+
+	if !seenKeys.add(key, config.ttl(), time.Now()) {
+		return apis.NewApiError(http.StatusConflict, "Duplicate delivery", nil)
+	}
+	return e.NoContent(http.StatusNoContent)
+}
+
+// replayReleaseHandler is called by Traefik's errors middleware with the
+// headers of a delivery the service answered with a 5xx. It forgets the key
+// of the delivery, so the sender can retry it, and answers with the body of
+// the error response, whose status Traefik keeps.
+func replayReleaseHandler(e *core.RequestEvent) error {
+	if managerToken() == "" {
+		return apis.NewApiError(http.StatusServiceUnavailable, "TRAEFIK_AUTH_TOKEN is not configured", nil)
+	}
+	routeId := e.Request.PathValue("route")
+	signature := e.Request.URL.Query().Get("sig")
+	if !hmac.Equal([]byte(signature), []byte(releaseSignature(routeId))) {
+		return apis.NewUnauthorizedError("Invalid signature", nil)
+	}
+
+	route, err := e.App.FindRecordById("routes", routeId)
+	if err != nil {
+		return apis.NewNotFoundError("Route not found", nil)
+	}
+	if key, _ := replayKey(route, e.Request); key != "" {
+		seenKeys.remove(key)
+	}
+	return e.JSON(http.StatusOK, map[string]string{"message": "The delivery failed and may be retried"})
+}
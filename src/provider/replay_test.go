@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyStore(t *testing.T) {
+	store := &keyStore{expires: map[string]time.Time{}}
+	now := time.Unix(1744300000, 0)
+
+	assert.True(t, store.add("route\x00delivery-1", time.Hour, now))
+	assert.False(t, store.add("route\x00delivery-1", time.Hour, now.Add(time.Minute)), "duplicates are rejected")
+	assert.True(t, store.add("other\x00delivery-1", time.Hour, now), "keys are per route")
+	assert.True(t, store.add("route\x00delivery-1", time.Hour, now.Add(time.Hour)), "keys expire")
+
+	store.add("route\x00delivery-2", time.Minute, now)
+	store.add("route\x00delivery-3", time.Hour, now.Add(2*time.Hour))
+	assert.NotContains(t, store.expires, "route\x00delivery-2", "expired keys are pruned")
+
+	store.remove("route\x00delivery-3")
+	assert.True(t, store.add("route\x00delivery-3", time.Hour, now.Add(2*time.Hour)), "released keys can be retried")
+}
+
+func TestReplayKey(t *testing.T) {
+	routes := core.NewBaseCollection("routes")
+	routes.Fields.Add(&core.JSONField{Name: "replay_protection"})
+	route := core.NewRecord(routes)
+	route.Id = "r1"
+	request := httptest.NewRequest(http.MethodPost, "/hooks/github", nil)
+	request.Header.Set("X-GitHub-Delivery", "d-1")
+
+	key, _ := replayKey(route, request)
+	assert.Empty(t, key, "routes without replay protection")
+
+	route.Set("replay_protection", replayConfig{Header: "X-GitHub-Delivery", TTL: "10m"})
+	key, config := replayKey(route, request)
+	assert.Equal(t, "r1\x00d-1", key)
+	assert.Equal(t, 10*time.Minute, config.ttl())
+
+	key, _ = replayKey(route, httptest.NewRequest(http.MethodPost, "/hooks/github", nil))
+	assert.Empty(t, key, "requests without a key")
+}
+
+func TestReplayRelease(t *testing.T) {
+	t.Setenv("TRAEFIK_AUTH_URL", "http://manager:8090")
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "manager-secret")
+
+	address, err := url.Parse(replayReleaseURL("r1"))
+	require.NoError(t, err)
+	assert.Equal(t, ReplayPath+"/r1/release", address.Path)
+	assert.Equal(t, releaseSignature("r1"), address.Query().Get("sig"))
+	assert.NotEqual(t, releaseSignature("r1"), releaseSignature("r2"), "signatures are per route")
+
+	t.Setenv("TRAEFIK_AUTH_TOKEN", "other-secret")
+	assert.NotEqual(t, address.Query().Get("sig"), releaseSignature("r1"), "signatures depend on the token")
+
+	e := &core.RequestEvent{}
+	e.Request = httptest.NewRequest(http.MethodGet, ReplayPath+"/r1/release?sig=forged", nil)
+	e.Request.SetPathValue("route", "r1")
+	var apiErr *router.ApiError
+	require.ErrorAs(t, replayReleaseHandler(e), &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status)
+}
+
+func TestReplayConfigTTL(t *testing.T) {
+	assert.Equal(t, time.Hour, replayConfig{}.ttl())
+	assert.Equal(t, 10*time.Minute, replayConfig{TTL: "10m"}.ttl())
+	assert.Equal(t, 24*time.Hour, replayConfig{TTL: "720h"}.ttl())
+	assert.Equal(t, time.Hour, replayConfig{TTL: "soon"}.ttl())
+}
@@ -0,0 +1,412 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/features"
+	"github.com/sistemica/n8n-manager-backend/gateway"
+	"github.com/sistemica/n8n-manager-backend/policies"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// defaultEntryPoints is used for routes without explicit entrypoints
+var defaultEntryPoints = []string{"web"}
+
+// defaultErrorPages returns the error pages of routes without their own,
+// configured with ERROR_PAGES_SERVICE (an existing Traefik service) or
+// ERROR_PAGES_URL, ERROR_PAGES_STATUS and ERROR_PAGES_QUERY. It returns nil
+// if neither service nor URL is set.
+func defaultErrorPages() *traefik.ErrorPagesConfig {
+	pages := &traefik.ErrorPagesConfig{
+		Service: os.Getenv("ERROR_PAGES_SERVICE"),
+		URL:     os.Getenv("ERROR_PAGES_URL"),
+		Status:  []string{"502-504"},
+		Query:   "/{status}",
+	}
+	if pages.Service == "" && pages.URL == "" {
+		return nil
+	}
+	if status := os.Getenv("ERROR_PAGES_STATUS"); status != "" {
+		pages.Status = strings.Split(status, ",")
+	}
+	if query := os.Getenv("ERROR_PAGES_QUERY"); query != "" {
+		pages.Query = query
+	}
+	return pages
+}
+
+// headerRules is the "headers" field of routes records. Set values may be
+// secret references, resolved when the configuration is built.
+type headerRules struct {
+	SetRequest    map[string]string `json:"set_request,omitempty"`
+	RemoveRequest []string          `json:"remove_request,omitempty"`
+	SetResponse   map[string]string `json:"set_response,omitempty"`
+}
+
+// passwordHasher hashes basic auth passwords with the bcrypt cost set in
+// TRAEFIK_BCRYPT_COST. It's shared by all builds to keep its cache.
+var passwordHasher = sync.OnceValue(func() *traefik.PasswordHasher {
+	cost, err := strconv.Atoi(os.Getenv("TRAEFIK_BCRYPT_COST"))
+	if err != nil {
+		return traefik.DefaultHasher
+	}
+	return traefik.NewPasswordHasher(cost)
+})
+
+// builderOptions configures the builder from the environment. Setting
+// TRAEFIK_ROUTE_HEADER (e.g. "X-N8N-Route") forwards the client information
+// and the name of the matched route to the instances.
+func builderOptions() []traefik.BuilderOption {
+	options := []traefik.BuilderOption{traefik.WithPasswordHasher(passwordHasher())}
+	if header := os.Getenv("TRAEFIK_ROUTE_HEADER"); header != "" {
+		options = append(options, traefik.WithClientInfo(header))
+	}
+	return options
+}
+
+// LoadRoutes collects the route definitions to expose through Traefik from
+// active records of the routes collection and from webhooks annotated with
+// a "route:" line in their notes. Routes breaking the policy of their
+// environment, awaiting approval, expired or outside their activation
+// windows are skipped.
+func LoadRoutes(ctx context.Context, app core.App, logger *zap.Logger) ([]traefik.RouteDefinition, error) {
+	instanceHosts := map[string]string{}
+	locations := timezone.Locations{}
+	instances, err := app.FindAllRecords("instances", trash.NotDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch instances: %w", err)
+	}
+	for _, instance := range instances {
+		instanceHosts[instance.Id] = instance.GetString("host")
+		locations[instance.Id] = timezone.Of(instance)
+	}
+
+	routePolicies, err := policies.ByInstance(app)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch policies: %w", err)
+	}
+	activity, err := loadWebhookActivity(app, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+	approvals, err := loadApprovals(app, routePolicies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch route approvals: %w", err)
+	}
+
+	// Routes in gateway mode are sent to their instance while the gateway
+	// is disabled
+	gatewayEnabled := features.Enabled(app, features.Gateway)
+
+	var routes []traefik.RouteDefinition
+
+	records, err := app.FindAllRecords("routes", dbx.HashExp{"active": true}, notPending, trash.NotDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch routes: %w", err)
+	}
+	if failed := app.ExpandRecords(records, []string{"auth_credentials"}, nil); len(failed) > 0 {
+		return nil, fmt.Errorf("failed to fetch route credentials: %v", failed)
+	}
+	for _, record := range records {
+		instance := record.GetString("instance")
+		if !exposedNow(record, locations.Of(instance), logger) {
+			continue
+		}
+		route, err := routeFromRecord(ctx, record, instanceHosts[instance], gatewayEnabled)
+		if err == nil {
+			route, err = route.Normalize()
+		}
+		if err == nil {
+			err = applyPolicy(&route, routePolicies[instance], record.GetString("auth_type"), hasEntryPoints(record),
+				activity.inactive(instance, cmp.Or(route.ServicePath, route.Path)))
+		}
+		if err != nil {
+			logger.Warn("Skipping invalid route",
+				zap.String("route", record.Id),
+				zap.Error(err))
+			continue
+		}
+		routes = append(routes, route)
+	}
+
+	webhooks, err := app.FindAllRecords("webhooks", dbx.NewExp("route != ''"), trash.InstanceNotDeleted("instance"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+	for _, webhook := range webhooks {
+		if approvals.pending(webhook) {
+			continue
+		}
+		instance := webhook.GetString("instance")
+		route, err := routeFromWebhook(webhook, instanceHosts[instance])
+		if err == nil {
+			route, err = route.Normalize()
+		}
+		if err == nil {
+			// Annotations can't set auth or entrypoints
+			err = applyPolicy(&route, routePolicies[instance], "", false, activity.inactive(instance, route.ServicePath))
+		}
+		if err != nil {
+			logger.Warn("Skipping invalid webhook route annotation",
+				zap.String("webhook", webhook.Id),
+				zap.Error(err))
+			continue
+		}
+		routes = append(routes, route)
+	}
+
+	// Routes opt out of the default error pages with an empty status list
+	if pages := defaultErrorPages(); pages != nil {
+		for i := range routes {
+			if routes[i].ErrorPages == nil {
+				routes[i].ErrorPages = pages
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+// routeFromRecord converts a routes record into a route definition
+func routeFromRecord(ctx context.Context, record *core.Record, instanceHost string, gatewayEnabled bool) (traefik.RouteDefinition, error) {
+	service, err := serviceFromHost(instanceHost)
+	if err != nil {
+		return traefik.RouteDefinition{}, err
+	}
+
+	route := traefik.RouteDefinition{
+		Host:        record.GetString("host"),
+		Path:        record.GetString("path"),
+		ServicePath: record.GetString("webhook_path"),
+		EntryPoints: defaultEntryPoints,
+		Audience:    record.GetString("audience"),
+		Service:     service,
+
+		SubdomainHeader: record.GetString("subdomain_header"),
+	}
+
+	var entryPoints []string
+	if err := record.UnmarshalJSONField("entrypoints", &entryPoints); err == nil && len(entryPoints) > 0 {
+		route.EntryPoints = entryPoints
+	}
+	record.UnmarshalJSONField("hosts", &route.Hosts)
+	record.UnmarshalJSONField("path_params", &route.PathParams)
+	record.UnmarshalJSONField("query_params", &route.QueryParams)
+
+	var errorPages traefik.ErrorPagesConfig
+	if err := record.UnmarshalJSONField("error_pages", &errorPages); err == nil && errorPages.Status != nil {
+		route.ErrorPages = &errorPages
+	}
+
+	var headers headerRules
+	if err := record.UnmarshalJSONField("headers", &headers); err == nil {
+		route.RemoveRequestHeaders = headers.RemoveRequest
+		route.SetResponseHeaders = headers.SetResponse
+		route.SetRequestHeaders = make(map[string]string, len(headers.SetRequest))
+		for name, value := range headers.SetRequest {
+			if route.SetRequestHeaders[name], err = secrets.Resolve(ctx, value); err != nil {
+				return traefik.RouteDefinition{}, err
+			}
+		}
+	}
+
+	var observability traefik.Observability
+	if err := record.UnmarshalJSONField("observability", &observability); err == nil && observability != (traefik.Observability{}) {
+		route.Observability = &observability
+	}
+
+	var geo geoConfig
+	if err := record.UnmarshalJSONField("geoip", &geo); err == nil && geo.enabled() {
+		if route.GeoIPCheckURL, err = geoIPCheckURL(record.Id); err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+	}
+
+	var replay replayConfig
+	if err := record.UnmarshalJSONField("replay_protection", &replay); err == nil && replay.Header != "" {
+		if route.ReplayCheckURL, err = replayCheckURL(record.Id); err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+		route.ReplayReleaseURL = replayReleaseURL(record.Id)
+	}
+
+	var quota quotaConfig
+	if err := record.UnmarshalJSONField("quota", &quota); err == nil && quota.enabled() {
+		if route.QuotaCheckURL, err = quotaCheckURL(record.Id); err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+	}
+
+	// Gateway mode sends the requests to the manager, which delivers them
+	if record.GetBool("gateway") && gatewayEnabled {
+		if authURL() == "" || gateway.Token() == "" {
+			return traefik.RouteDefinition{}, errors.New("gateway mode requires TRAEFIK_AUTH_URL and GATEWAY_TOKEN")
+		}
+		if route.Service, err = serviceFromHost(authURL()); err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+		route.ServicePath = gateway.Path + "/" + record.Id
+		if route.SetRequestHeaders == nil {
+			route.SetRequestHeaders = map[string]string{}
+		}
+		route.SetRequestHeaders[gateway.TokenHeader] = gateway.Token()
+	}
+
+	if record.GetInt("capture_requests") > 0 {
+		if route.CaptureURL, err = captureURL(record.Id); err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+	}
+	if route.ReplayCheckURL != "" || route.QuotaCheckURL != "" || route.CaptureURL != "" {
+		route.ManagerToken = managerToken()
+	}
+
+	switch authType := record.GetString("auth_type"); authType {
+	case "basic", "digest":
+		// Shared credentials replace the ones of the route
+		username, password := record.GetString("auth_username"), record.GetString("auth_password")
+		if id := record.GetString("auth_credentials"); id != "" {
+			credentials := record.ExpandedOne("auth_credentials")
+			if credentials == nil {
+				return traefik.RouteDefinition{}, fmt.Errorf("route credentials %s not found", id)
+			}
+			username, password = credentials.GetString("username"), credentials.GetString("password")
+		}
+		// Deleting shared credentials clears the relation of their routes
+		if username == "" || password == "" {
+			return traefik.RouteDefinition{}, fmt.Errorf("%s auth requires a username and password", authType)
+		}
+
+		password, err := secrets.Resolve(ctx, password)
+		if err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+		route.Authentication = &traefik.AuthConfig{
+			Type:     authType,
+			Username: username,
+			Password: password,
+		}
+	case "apikey":
+		apiKey, err := secrets.Resolve(ctx, record.GetString("auth_api_key"))
+		if err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+		route.Authentication = &traefik.AuthConfig{
+			Type:   authType,
+			APIKey: apiKey,
+		}
+	case "oidc":
+		var config oidcConfig
+		record.UnmarshalJSONField("auth_oidc", &config)
+		verifyURL, err := oidcVerifyURL(config)
+		if err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+		route.Authentication = &traefik.AuthConfig{
+			Type:      authType,
+			VerifyURL: verifyURL,
+		}
+	case "hmac":
+		verifyURL, err := hmacVerifyURL(record.Id)
+		if err != nil {
+			return traefik.RouteDefinition{}, err
+		}
+		route.Authentication = &traefik.AuthConfig{
+			Type:      authType,
+			VerifyURL: verifyURL,
+		}
+	}
+
+	return route, nil
+}
+
+// routeFromWebhook converts a "route:" annotation of a webhook into a route
+// definition. The annotation is "<host>[/<path>]", without a path the
+// webhook's own n8n path is exposed. An optional "route-audience:" line
+// limits the route to one audience, a "route-hosts:" line lists further
+// comma separated domains. The host may be a wildcard, whose subdomain is
+// forwarded in the header named by a "route-subdomain-header:" line.
+func routeFromWebhook(webhook *core.Record, instanceHost string) (traefik.RouteDefinition, error) {
+	service, err := serviceFromHost(instanceHost)
+	if err != nil {
+		return traefik.RouteDefinition{}, err
+	}
+
+	webhookURL, err := url.Parse(webhook.GetString("webhook_url"))
+	if err != nil {
+		return traefik.RouteDefinition{}, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	annotation := strings.TrimPrefix(strings.TrimPrefix(webhook.GetString("route"), "https://"), "http://")
+	host, path, _ := strings.Cut(annotation, "/")
+	if host == "" {
+		return traefik.RouteDefinition{}, fmt.Errorf("route annotation %q has no host", webhook.GetString("route"))
+	}
+
+	route := traefik.RouteDefinition{
+		Host:        host,
+		Path:        webhookURL.Path,
+		ServicePath: webhookURL.Path,
+		EntryPoints: defaultEntryPoints,
+		Audience:    webhook.GetString("route_audience"),
+		Service:     service,
+
+		SubdomainHeader: webhook.GetString("route_subdomain_header"),
+	}
+	if path != "" {
+		route.Path = "/" + path
+	}
+	for _, alias := range strings.Split(webhook.GetString("route_hosts"), ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			route.Hosts = append(route.Hosts, alias)
+		}
+	}
+
+	return route, nil
+}
+
+// serviceFromHost converts an instance host URL (e.g. "https://n8n.example.com")
+// into a Traefik service definition
+func serviceFromHost(host string) (traefik.ServiceDefinition, error) {
+	if host == "" {
+		return traefik.ServiceDefinition{}, fmt.Errorf("instance not found")
+	}
+
+	u, err := url.Parse(host)
+	if err != nil || u.Hostname() == "" {
+		return traefik.ServiceDefinition{}, fmt.Errorf("invalid instance host %q", host)
+	}
+
+	service := traefik.ServiceDefinition{
+		Host:   u.Hostname(),
+		Scheme: u.Scheme,
+	}
+
+	switch {
+	case u.Port() != "":
+		service.Port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return traefik.ServiceDefinition{}, fmt.Errorf("invalid instance port %q", u.Port())
+		}
+	case u.Scheme == "https":
+		service.Port = 443
+	default:
+		service.Port = 80
+	}
+
+	return service, nil
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoadRoutesCredentials(t *testing.T) {
+	app := testutil.NewApp(t)
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "https://n8n.example.com"})
+	route := func(host string, values map[string]any) *core.Record {
+		fields := map[string]any{"instance": instance.Id, "host": host, "path": "/orders", "webhook_path": "/webhook/orders", "active": true}
+		for field, value := range values {
+			fields[field] = value
+		}
+		return testutil.Create(t, app, "routes", fields)
+	}
+	load := func() (map[string]*traefik.AuthConfig, *observer.ObservedLogs) {
+		observed, logs := observer.New(zap.WarnLevel)
+		routes, err := LoadRoutes(context.Background(), app, zap.New(observed))
+		require.NoError(t, err)
+		auth := map[string]*traefik.AuthConfig{}
+		for _, route := range routes {
+			auth[route.Host] = route.Authentication
+		}
+		return auth, logs
+	}
+
+	t.Setenv("PARTNER_PASSWORD", "from-env")
+	shared := testutil.Create(t, app, "route_credentials", map[string]any{"name": "partner", "username": "partner", "password": "shared-secret"})
+	referenced := testutil.Create(t, app, "route_credentials", map[string]any{"name": "referenced", "username": "referenced", "password": "env:PARTNER_PASSWORD"})
+
+	// Shared credentials replace the ones of the route
+	route("shared.example.com", map[string]any{"auth_type": "basic", "auth_username": "own", "auth_password": "own-secret", "auth_credentials": shared.Id})
+	route("referenced.example.com", map[string]any{"auth_type": "digest", "auth_credentials": referenced.Id})
+	route("own.example.com", map[string]any{"auth_type": "basic", "auth_username": "own", "auth_password": "own-secret"})
+	route("missing.example.com", map[string]any{"auth_type": "basic", "auth_username": "own", "auth_password": "own-secret", "auth_credentials": "missing00000000"})
+
+	auth, logs := load()
+	assert.Equal(t, &traefik.AuthConfig{Type: "basic", Username: "partner", Password: "shared-secret"}, auth["shared.example.com"])
+	assert.Equal(t, &traefik.AuthConfig{Type: "digest", Username: "referenced", Password: "from-env"}, auth["referenced.example.com"])
+	assert.Equal(t, &traefik.AuthConfig{Type: "basic", Username: "own", Password: "own-secret"}, auth["own.example.com"])
+	assert.NotContains(t, auth, "missing.example.com", "the route isn't exposed with its own credentials")
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "route credentials missing00000000 not found", logs.All()[0].ContextMap()["error"])
+
+	// Deleting the credentials clears the relation, which must not expose
+	// the route without authentication
+	require.NoError(t, app.Delete(referenced))
+	auth, logs = load()
+	assert.NotContains(t, auth, "referenced.example.com")
+	assert.Contains(t, auth, "shared.example.com")
+	assert.Equal(t, 2, logs.Len())
+}
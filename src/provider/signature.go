@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"go.uber.org/zap"
+)
+
+// HMACAuthPath is the forwardAuth endpoint verifying the payload signatures
+// of routes with hmac auth, followed by the id of the route
+const HMACAuthPath = "/api/traefik/auth/hmac"
+
+// Signature schemes of hmac auth
+const (
+	// SchemeHex signs the body, the header holds the hex (or base64)
+	// encoded HMAC after an optional prefix, e.g. GitHub's
+	// "X-Hub-Signature-256: sha256=<hex>"
+	SchemeHex = "hex"
+
+	// SchemeStripe signs "<timestamp>.<body>" with HMAC-SHA256, the header
+	// is "t=<timestamp>,v1=<hex>[,v1=<hex>]"
+	SchemeStripe = "stripe"
+)
+
+// stripeTolerance is how old the timestamp of a Stripe signature may be
+const stripeTolerance = 5 * time.Minute
+
+// hmacConfig is the "auth_hmac" field of routes records. The defaults
+// verify GitHub's X-Hub-Signature-256 header.
+type hmacConfig struct {
+	Scheme    string `json:"scheme,omitempty"`
+	Header    string `json:"header,omitempty"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	Encoding  string `json:"encoding,omitempty"`
+}
+
+// withDefaults returns c with the defaults of its scheme applied
+func (c hmacConfig) withDefaults() hmacConfig {
+	if c.Scheme == "" {
+		c.Scheme = SchemeHex
+	}
+	if c.Scheme == SchemeStripe {
+		if c.Header == "" {
+			c.Header = "Stripe-Signature"
+		}
+		return c
+	}
+
+	if c.Header == "" {
+		c.Header, c.Algorithm, c.Prefix = "X-Hub-Signature-256", "sha256", "sha256="
+	}
+	if c.Algorithm == "" {
+		c.Algorithm = "sha256"
+	}
+	if c.Encoding == "" {
+		c.Encoding = "hex"
+	}
+	return c
+}
+
+// hashes are the algorithms of hex signatures
+var hashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// hmacVerifyURL returns the forwardAuth address verifying the signatures of
+// the route with id routeId
+func hmacVerifyURL(routeId string) (string, error) {
+	base := authURL()
+	if base == "" {
+		return "", errors.New("hmac auth requires TRAEFIK_AUTH_URL")
+	}
+	return base + HMACAuthPath + "/" + routeId, nil
+}
+
+// verifyWebhookSignature reports whether header holds a valid signature of
+// body with secret
+func verifyWebhookSignature(config hmacConfig, secret string, header http.Header, body []byte, now time.Time) bool {
+	config = config.withDefaults()
+	value := header.Get(config.Header)
+	if value == "" || secret == "" {
+		return false
+	}
+
+	switch config.Scheme {
+	case SchemeHex:
+		newHash, ok := hashes[config.Algorithm]
+		if !ok {
+			return false
+		}
+		encoded, found := strings.CutPrefix(value, config.Prefix)
+		if !found {
+			return false
+		}
+		var signature []byte
+		var err error
+		if config.Encoding == "base64" {
+			signature, err = base64.StdEncoding.DecodeString(encoded)
+		} else {
+			signature, err = hex.DecodeString(encoded)
+		}
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(newHash, []byte(secret))
+		mac.Write(body)
+		return hmac.Equal(signature, mac.Sum(nil))
+	case SchemeStripe:
+		var timestamp string
+		var signatures [][]byte
+		for _, part := range strings.Split(value, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = val
+			case "v1":
+				if signature, err := hex.DecodeString(val); err == nil {
+					signatures = append(signatures, signature)
+				}
+			}
+		}
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return false
+		}
+		if age := now.Sub(time.Unix(seconds, 0)); age > stripeTolerance || age < -stripeTolerance {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		expected := mac.Sum(nil)
+		for _, signature := range signatures {
+			if hmac.Equal(signature, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hmacAuthHandler answers forwardAuth requests of Traefik for the route
+// in the path, letting requests with a valid payload signature through
+func hmacAuthHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	route, err := e.App.FindRecordById("routes", e.Request.PathValue("route"))
+	if err != nil || route.GetString("auth_type") != "hmac" {
+		return apis.NewNotFoundError("Route not found", nil)
+	}
+
+	secret, err := secrets.Resolve(e.Request.Context(), route.GetString("auth_hmac_secret"))
+	if err != nil {
+		logger.Error("Failed to resolve HMAC secret", zap.String("route", route.Id), zap.Error(err))
+		return apis.NewInternalServerError("Failed to resolve HMAC secret", nil)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(e.Request.Body, traefik.MaxSignedBodySize+1))
+	if err != nil {
+		return apis.NewBadRequestError("Failed to read body", nil)
+	}
+	if len(body) > traefik.MaxSignedBodySize {
+		return apis.NewApiError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Body exceeds %d bytes", traefik.MaxSignedBodySize), nil)
+	}
+
+	var config hmacConfig
+	route.UnmarshalJSONField("auth_hmac", &config)
+	if !verifyWebhookSignature(config, secret, e.Request.Header, body, time.Now()) {
+		logger.Debug("Rejected webhook signature", zap.String("route", route.Id))
+		return apis.NewUnauthorizedError("Invalid signature", nil)
+	}
+	return e.NoContent(http.StatusNoContent)
+}
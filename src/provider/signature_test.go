@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	now := time.Unix(1744200000, 0)
+	sum := func(newHash func() hash.Hash, data ...[]byte) []byte {
+		mac := hmac.New(newHash, []byte("secret"))
+		for _, d := range data {
+			mac.Write(d)
+		}
+		return mac.Sum(nil)
+	}
+	sha256Hex := hex.EncodeToString(sum(sha256.New, body))
+	sha1Base64 := base64.StdEncoding.EncodeToString(sum(sha1.New, body))
+	stripe := func(timestamp time.Time) string {
+		t := strconv.FormatInt(timestamp.Unix(), 10)
+		return "t=" + t + ",v1=deadbeef,v1=" + hex.EncodeToString(sum(sha256.New, []byte(t+"."), body))
+	}
+
+	tests := []struct {
+		name     string
+		config   hmacConfig
+		header   string
+		value    string
+		secret   string
+		expected bool
+	}{
+		{"github", hmacConfig{}, "X-Hub-Signature-256", "sha256=" + sha256Hex, "secret", true},
+		{"github wrong secret", hmacConfig{}, "X-Hub-Signature-256", "sha256=" + sha256Hex, "other", false},
+		{"github missing prefix", hmacConfig{}, "X-Hub-Signature-256", sha256Hex, "secret", false},
+		{"missing header", hmacConfig{}, "X-Other", "sha256=" + sha256Hex, "secret", false},
+		{"empty secret", hmacConfig{}, "X-Hub-Signature-256", "sha256=" + sha256Hex, "", false},
+		{"custom base64", hmacConfig{Header: "X-Signature", Algorithm: "sha1", Encoding: "base64"}, "X-Signature", sha1Base64, "secret", true},
+		{"unknown algorithm", hmacConfig{Header: "X-Signature", Algorithm: "md5"}, "X-Signature", sha256Hex, "secret", false},
+		{"stripe", hmacConfig{Scheme: SchemeStripe}, "Stripe-Signature", stripe(now), "secret", true},
+		{"stripe within tolerance", hmacConfig{Scheme: SchemeStripe}, "Stripe-Signature", stripe(now.Add(-4 * time.Minute)), "secret", true},
+		{"stripe replayed", hmacConfig{Scheme: SchemeStripe}, "Stripe-Signature", stripe(now.Add(-6 * time.Minute)), "secret", false},
+		{"stripe wrong secret", hmacConfig{Scheme: SchemeStripe}, "Stripe-Signature", stripe(now), "other", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(tt.header, tt.value)
+			assert.Equal(t, tt.expected, verifyWebhookSignature(tt.config, tt.secret, header, body, now))
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package provider
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/health"
+	"github.com/sistemica/n8n-manager-backend/incidents"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// StatusPagePath serves the data of a status page
+const StatusPagePath = "/api/status"
+
+// Statuses of status page components besides StatusOperational,
+// StatusUnknown and StatusMaintenance
+const (
+	StatusDegraded = "degraded"
+	StatusOutage   = "outage"
+)
+
+// StatusComponent is an instance or route on the status page
+type StatusComponent struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+
+	// Uptime is the percentage of the last incidents.HistoryDays days the
+	// component was up, Days the uptime of each of these days
+	Uptime float64         `json:"uptime"`
+	Days   []incidents.Day `json:"days"`
+}
+
+// StatusPage is the response of GET /api/status
+type StatusPage struct {
+	Status    string               `json:"status"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Instances []StatusComponent    `json:"instances"`
+	Routes    []StatusComponent    `json:"routes"`
+	Incidents []incidents.Incident `json:"incidents"`
+}
+
+// statusPagePublic reports whether anonymous requests may see the status
+// page, enabled with STATUS_PAGE_PUBLIC=true
+func statusPagePublic() bool {
+	return os.Getenv("STATUS_PAGE_PUBLIC") == "true"
+}
+
+// statusPageHandler serves the current status and the uptime of the
+// instances and the routes, optionally of one ?audience=, with the
+// incidents of the last incidents.HistoryDays days. Incident notes may
+// contain internal details and are only returned to authenticated requests.
+func statusPageHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	authenticated := e.Auth != nil
+	if !authenticated && !statusPagePublic() {
+		return apis.NewUnauthorizedError("The request requires valid record authorization token.", nil)
+	}
+	_, audience, err := endpointOptions(e)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	instances, err := e.App.FindAllRecords("instances", trash.NotDeleted)
+	if err != nil {
+		logger.Error("Failed to fetch n8n instances", zap.Error(err))
+		return apis.NewInternalServerError("Failed to fetch instances", nil)
+	}
+	endpoints, err := loadEndpoints(e.App, logger)
+	if err != nil {
+		logger.Error("Failed to load routes", zap.Error(err))
+		return apis.NewInternalServerError("Failed to load routes", nil)
+	}
+	history, err := incidents.List(e.App, now.AddDate(0, 0, -incidents.HistoryDays))
+	if err != nil {
+		logger.Error("Failed to fetch incidents", zap.Error(err))
+		return apis.NewInternalServerError("Failed to fetch incidents", nil)
+	}
+
+	states := make([]health.InstanceState, len(instances))
+	for i, instance := range instances {
+		states[i] = health.StateOf(instance, now)
+	}
+
+	page := statusPageOf(states, filterEndpoints(endpoints, audience), history, maintenance.Enabled(e.App, logger), now)
+	if !authenticated {
+		for i := range page.Incidents {
+			page.Incidents[i].Note = ""
+		}
+	}
+	return e.JSON(http.StatusOK, page)
+}
+
+// instanceStatus returns the status page status of an instance health
+func instanceStatus(state health.InstanceState) string {
+	switch state.Health {
+	case "healthy":
+		return StatusOperational
+	case "degraded":
+		return StatusDegraded
+	}
+	return StatusOutage
+}
+
+// hostName returns the host name of an instance host URL, instances are
+// listed without scheme and port
+func hostName(host string) string {
+	if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return host
+}
+
+// statusPageOf builds the status page of the instance states and
+// endpoints from the incident history
+func statusPageOf(states []health.InstanceState, endpoints []endpoint, history []incidents.Incident, inMaintenance bool, now time.Time) *StatusPage {
+	from := now.AddDate(0, 0, -incidents.HistoryDays)
+	page := &StatusPage{
+		Status:    StatusOperational,
+		UpdatedAt: now,
+		Instances: make([]StatusComponent, 0, len(states)),
+		Routes:    make([]StatusComponent, 0, len(endpoints)),
+		Incidents: []incidents.Incident{},
+	}
+
+	component := func(name, status string, components ...string) StatusComponent {
+		downtime := incidents.Downtime(history, from, now, components...)
+		return StatusComponent{
+			Name:   name,
+			Status: status,
+			Uptime: incidents.Uptime(downtime, from, now),
+			Days:   incidents.Daily(downtime, incidents.HistoryDays, now, timezone.Global()),
+		}
+	}
+
+	instanceStatuses := map[string]string{}
+	listed := map[string]bool{}
+	outages := 0
+	for _, state := range states {
+		status := instanceStatus(state)
+		instanceStatuses[state.ID] = status
+		if status == StatusOutage {
+			outages++
+		}
+		if inMaintenance {
+			status = StatusMaintenance
+		}
+		listed[state.ID] = true
+		page.Instances = append(page.Instances, component(hostName(state.Host), status, state.ID))
+	}
+
+	for _, ep := range endpoints {
+		status := ep.Status
+		switch {
+		case inMaintenance:
+			status = StatusMaintenance
+		case instanceStatuses[ep.Instance] == StatusOutage || ep.Status == StatusUnavailable:
+			status = StatusOutage
+		case instanceStatuses[ep.Instance] == StatusDegraded && ep.Status != StatusUnknown:
+			status = StatusDegraded
+		}
+		listed[ep.Component] = true
+		page.Routes = append(page.Routes, component(ep.Host+ep.Path, status, ep.Instance, ep.Component))
+	}
+
+	// Only incidents of the listed components, e.g. of one audience
+	for _, incident := range history {
+		if listed[incident.Component] {
+			page.Incidents = append(page.Incidents, incident)
+		}
+	}
+
+	sort.Slice(page.Instances, func(i, j int) bool { return page.Instances[i].Name < page.Instances[j].Name })
+	sort.Slice(page.Routes, func(i, j int) bool { return page.Routes[i].Name < page.Routes[j].Name })
+
+	switch {
+	case inMaintenance:
+		page.Status = StatusMaintenance
+	case len(states) > 0 && outages == len(states):
+		page.Status = StatusOutage
+	case slices.ContainsFunc(slices.Concat(page.Instances, page.Routes), func(c StatusComponent) bool {
+		return c.Status == StatusDegraded || c.Status == StatusOutage
+	}):
+		page.Status = StatusDegraded
+	}
+	return page
+}
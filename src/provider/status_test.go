@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sistemica/n8n-manager-backend/health"
+	"github.com/sistemica/n8n-manager-backend/incidents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusPageOf(t *testing.T) {
+	now := time.Date(2025, 4, 14, 12, 0, 0, 0, time.UTC)
+	resolved := now.Add(-12 * time.Hour)
+	states := []health.InstanceState{
+		{ID: "a", Host: "https://n8n-a.internal:5678", Health: "healthy"},
+		{ID: "b", Host: "https://n8n-b.internal", Health: "stale"},
+	}
+	endpoints := []endpoint{
+		{Host: "hooks.example.com", Path: "/orders", Instance: "a", Component: "a /webhook/orders", Status: StatusOperational},
+		{Host: "hooks.example.com", Path: "/users", Instance: "b", Component: "b /webhook/users", Status: StatusOperational},
+		{Host: "hooks.example.com", Path: "/broken", Instance: "a", Component: "a /webhook/broken", Status: StatusUnavailable},
+	}
+	history := []incidents.Incident{
+		{Kind: incidents.KindWebhook, Component: "a /webhook/orders", Started: now.Add(-30 * time.Hour), Resolved: &resolved},
+		{Kind: incidents.KindInstance, Component: "hidden", Started: now.Add(-time.Hour)},
+	}
+
+	page := statusPageOf(states, endpoints, history, false, now)
+	assert.Equal(t, StatusDegraded, page.Status)
+
+	require.Len(t, page.Instances, 2)
+	assert.Equal(t, "n8n-a.internal", page.Instances[0].Name)
+	assert.Equal(t, StatusOperational, page.Instances[0].Status)
+	assert.Equal(t, StatusOutage, page.Instances[1].Status, "stale instances are out")
+	assert.Len(t, page.Instances[0].Days, incidents.HistoryDays)
+
+	require.Len(t, page.Routes, 3)
+	assert.Equal(t, "hooks.example.com/broken", page.Routes[0].Name)
+	assert.Equal(t, StatusOutage, page.Routes[0].Status)
+	orders := page.Routes[1]
+	assert.Equal(t, StatusOperational, orders.Status)
+	assert.Less(t, orders.Uptime, 100.0)
+	assert.Equal(t, 25.0, orders.Days[len(orders.Days)-2].Uptime)
+	assert.Equal(t, StatusOutage, page.Routes[2].Status, "routes of instances that are out")
+
+	require.Len(t, page.Incidents, 1, "incidents of components not listed are left out")
+
+	page = statusPageOf(states, endpoints, history, true, now)
+	assert.Equal(t, StatusMaintenance, page.Status)
+	assert.Equal(t, StatusMaintenance, page.Routes[0].Status)
+
+	page = statusPageOf(states[1:], nil, nil, false, now)
+	assert.Equal(t, StatusOutage, page.Status)
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+)
+
+// recordFields maps route definition fields to the fields of routes records
+var recordFields = map[string]string{
+	"servicePath":          "webhook_path",
+	"subdomainHeader":      "subdomain_header",
+	"setRequestHeaders":    "headers",
+	"removeRequestHeaders": "headers",
+	"setResponseHeaders":   "headers",
+}
+
+// validationErrors converts a traefik.ValidationError into validation errors
+// PocketBase returns per field, renaming fields found in names
+func validationErrors(err error, names map[string]string) error {
+	var routeErr traefik.ValidationError
+	if !errors.As(err, &routeErr) {
+		return err
+	}
+
+	errs := validation.Errors{}
+	for field, fieldErr := range routeErr {
+		if name, ok := names[field]; ok {
+			field = name
+		}
+		errs[field] = validation.NewError(fieldErr.Code, fieldErr.Message)
+	}
+	return errs
+}
+
+// bindValidation rejects routes records with an invalid host or path or
+// breaking the policy of their environment and stores their hosts
+// normalized, see traefik.NormalizeHost
+func bindValidation(app core.App) {
+	app.OnRecordValidate("routes").BindFunc(func(e *core.RecordEvent) error {
+		route := traefik.RouteDefinition{
+			Host:        e.Record.GetString("host"),
+			Path:        e.Record.GetString("path"),
+			ServicePath: e.Record.GetString("webhook_path"),
+
+			SubdomainHeader: e.Record.GetString("subdomain_header"),
+		}
+		if err := e.Record.UnmarshalJSONField("hosts", &route.Hosts); err != nil {
+			return validation.Errors{"hosts": validation.NewError("invalid_hosts", "hosts must be a list of domains")}
+		}
+
+		var headers headerRules
+		if err := e.Record.UnmarshalJSONField("headers", &headers); err != nil {
+			return validation.Errors{"headers": validation.NewError("invalid_headers", "headers must be an object of header rules")}
+		}
+		route.SetRequestHeaders = headers.SetRequest
+		route.RemoveRequestHeaders = headers.RemoveRequest
+		route.SetResponseHeaders = headers.SetResponse
+
+		var geo geoConfig
+		if err := e.Record.UnmarshalJSONField("geoip", &geo); err != nil {
+			return validation.Errors{"geoip": validation.NewError("invalid_geoip", "geoip must be an object with allow and deny lists")}
+		}
+		geo, err := geo.normalize()
+		if err != nil {
+			return validation.Errors{"geoip": validation.NewError("invalid_geoip", err.Error())}
+		}
+
+		var quota quotaConfig
+		if err := e.Record.UnmarshalJSONField("quota", &quota); err != nil ||
+			quota.Daily < 0 || quota.Monthly < 0 ||
+			(quota.Per != "" && quota.Per != QuotaPerAPIKey && quota.Per != QuotaPerIP) {
+			return validation.Errors{"quota": validation.NewError("invalid_quota", `quota must set "per" to api_key or ip and non-negative daily and monthly limits`)}
+		}
+
+		if _, err := scheduleOf(e.Record, timezone.ForInstance(e.App, e.Record.GetString("instance"))); err != nil {
+			return validation.Errors{"activation_windows": validation.NewError("invalid_activation_windows", err.Error())}
+		}
+
+		route, err = route.Normalize()
+		if err != nil {
+			return validationErrors(err, recordFields)
+		}
+
+		e.Record.Set("host", route.Host)
+		e.Record.Set("hosts", route.Hosts)
+		if geo.enabled() {
+			e.Record.Set("geoip", geo)
+		}
+
+		if err := checkPolicy(e.App, e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+}
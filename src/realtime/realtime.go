@@ -0,0 +1,50 @@
+// Package realtime publishes custom events over the PocketBase realtime
+// API. Clients subscribe to the topics below like to record topics
+// (POST /api/realtime with {"clientId", "subscriptions": ["n8n/sync"]}).
+// Only authenticated clients receive events, matching the collection rules.
+package realtime
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+// Topics of the custom events
+const (
+	// TopicSync carries sync progress of instances
+	TopicSync = "n8n/sync"
+	// TopicInstances carries instance state transitions
+	TopicInstances = "n8n/instances"
+)
+
+// clientsChunkSize is the number of clients served by one goroutine
+const clientsChunkSize = 150
+
+// Publish sends data as JSON to all authenticated clients subscribed to topic
+func Publish(app core.App, topic string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	message := subscriptions.Message{Name: topic, Data: payload}
+
+	for _, chunk := range app.SubscriptionsBroker().ChunkedClients(clientsChunkSize) {
+		routine.FireAndForget(func() {
+			for _, client := range chunk {
+				if !client.HasSubscription(topic) {
+					continue
+				}
+				if auth, _ := client.Get(apis.RealtimeClientAuthKey).(*core.Record); auth == nil {
+					continue
+				}
+				client.Send(message)
+			}
+		})
+	}
+
+	return nil
+}
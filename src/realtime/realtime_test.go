@@ -0,0 +1,60 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive returns the next message of client, false if none arrives
+func receive(client subscriptions.Client, wait time.Duration) (subscriptions.Message, bool) {
+	select {
+	case message := <-client.Channel():
+		return message, true
+	case <-time.After(wait):
+		return subscriptions.Message{}, false
+	}
+}
+
+func TestPublish(t *testing.T) {
+	app := testutil.NewApp(t)
+	user, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	require.NoError(t, err)
+
+	client := func(authenticated bool, topics ...string) subscriptions.Client {
+		c := subscriptions.NewDefaultClient()
+		c.Subscribe(topics...)
+		if authenticated {
+			c.Set(apis.RealtimeClientAuthKey, core.NewRecord(user))
+		}
+		app.SubscriptionsBroker().Register(c)
+		t.Cleanup(func() { app.SubscriptionsBroker().Unregister(c.Id()) })
+		return c
+	}
+	subscribed := client(true, TopicSync)
+	anonymous := client(false, TopicSync)
+	other := client(true, TopicInstances)
+
+	require.NoError(t, Publish(app, TopicSync, map[string]int{"percent": 40}))
+
+	message, ok := receive(subscribed, time.Second)
+	require.True(t, ok)
+	assert.Equal(t, TopicSync, message.Name)
+	var data map[string]int
+	require.NoError(t, json.Unmarshal(message.Data, &data))
+	assert.Equal(t, 40, data["percent"])
+
+	_, ok = receive(anonymous, 50*time.Millisecond)
+	assert.False(t, ok, "unauthenticated clients receive nothing")
+	_, ok = receive(other, 50*time.Millisecond)
+	assert.False(t, ok, "only subscribers of the topic receive it")
+
+	assert.Error(t, Publish(app, TopicSync, func() {}), "data must be JSON encodable")
+}
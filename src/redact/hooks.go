@@ -0,0 +1,72 @@
+package redact
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/secrets"
+)
+
+// BindRecordHooks masks sensitive record fields whenever records are
+// serialized by the PocketBase API (REST responses and realtime events).
+//
+// Text fields with a sensitive name (e.g. "api_key") are replaced by Mask and
+// JSON fields (e.g. "workflow_data") are scanned for nested credentials.
+// Because clients will send the masked value back on update, an update
+// carrying Mask keeps the stored value.
+func BindRecordHooks(app core.App) {
+	app.OnRecordEnrich().BindFunc(func(e *core.RecordEnrichEvent) error {
+		maskRecord(e.Record)
+		return e.Next()
+	})
+
+	app.OnRecordUpdateRequest().BindFunc(func(e *core.RecordRequestEvent) error {
+		original := e.Record.Original()
+		for _, field := range maskableFields(e.Record.Collection()) {
+			if _, ok := field.(*core.TextField); !ok {
+				continue
+			}
+			if e.Record.GetString(field.GetName()) == Mask {
+				e.Record.Set(field.GetName(), original.Get(field.GetName()))
+			}
+		}
+		return e.Next()
+	})
+}
+
+// maskRecord replaces sensitive values in record with their masked form.
+func maskRecord(record *core.Record) {
+	for _, field := range maskableFields(record.Collection()) {
+		name := field.GetName()
+		switch field.(type) {
+		case *core.TextField:
+			if IsSensitiveKey(name) {
+				// Secret references only point to a credential and stay visible
+				if value := record.GetString(name); value != "" && !secrets.IsReference(value) {
+					record.Set(name, Mask)
+				}
+			} else {
+				record.Set(name, String(record.GetString(name)))
+			}
+		case *core.JSONField:
+			raw, ok := record.Get(name).(types.JSONRaw)
+			if !ok || len(raw) == 0 {
+				continue
+			}
+			record.Set(name, types.JSONRaw(JSON(raw)))
+		}
+	}
+}
+
+// maskableFields returns the visible, non-system fields of a collection.
+// Hidden and system fields (password hashes, token keys) are never
+// serialized and must not be touched.
+func maskableFields(collection *core.Collection) []core.Field {
+	var fields []core.Field
+	for _, field := range collection.Fields {
+		if field.GetHidden() || field.GetSystem() {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
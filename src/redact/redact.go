@@ -0,0 +1,103 @@
+// Package redact masks credentials such as n8n API keys and Authorization
+// header values before they end up in logs or API responses.
+package redact
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Mask is the placeholder written in place of a sensitive value.
+const Mask = "********"
+
+// sensitiveKeys lists key fragments whose values are always masked.
+// Keys are compared lowercased with "-" normalized to "_".
+var sensitiveKeys = []string{
+	"api_key",
+	"apikey",
+	"authorization",
+	"password",
+	"secret",
+	"token",
+}
+
+var (
+	// jwtPattern matches JSON Web Tokens, which is the format n8n uses for API keys
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+	// authSchemePattern matches credentials following an Authorization scheme
+	authSchemePattern = regexp.MustCompile(`(?i)\b(Bearer|Basic|Digest|Token)\s+[A-Za-z0-9._~+/=-]+`)
+)
+
+// IsSensitiveKey reports whether values stored under key should be masked.
+func IsSensitiveKey(key string) bool {
+	normalized := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(normalized, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks JWTs and Authorization-like credentials found in free text.
+func String(s string) string {
+	if s == "" {
+		return s
+	}
+	s = jwtPattern.ReplaceAllString(s, Mask)
+	return authSchemePattern.ReplaceAllString(s, "$1 "+Mask)
+}
+
+// Value masks v if the key is sensitive, otherwise it recursively masks
+// credentials nested in maps, slices and strings.
+func Value(key string, v any) any {
+	if v == nil {
+		return nil
+	}
+	if key != "" && IsSensitiveKey(key) {
+		if s, ok := v.(string); ok && s == "" {
+			return s
+		}
+		return Mask
+	}
+
+	switch val := v.(type) {
+	case string:
+		return String(val)
+	case map[string]any:
+		masked := make(map[string]any, len(val))
+		for k, item := range val {
+			masked[k] = Value(k, item)
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(val))
+		for i, item := range val {
+			masked[i] = Value("", item)
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// JSON masks sensitive values in a JSON document. Invalid JSON is treated
+// as free text so that nothing slips through unmasked.
+func JSON(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return []byte(String(string(data)))
+	}
+
+	masked, err := json.Marshal(Value("", decoded))
+	if err != nil {
+		return []byte(String(string(data)))
+	}
+	return masked
+}
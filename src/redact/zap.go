@@ -0,0 +1,82 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactingCore wraps a zapcore.Core and masks sensitive fields before they are encoded.
+type redactingCore struct {
+	zapcore.Core
+}
+
+// NewCore returns a zapcore.Core that masks credentials in the message and
+// fields of every entry before delegating to the wrapped core.
+func NewCore(c zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: c}
+}
+
+// WrapCore is a zap.Option installing the redacting core on a logger.
+func WrapCore() zap.Option {
+	return zap.WrapCore(NewCore)
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(Fields(fields))}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = String(entry.Message)
+	return c.Core.Write(entry, Fields(fields))
+}
+
+// Fields returns a copy of fields with sensitive values masked.
+func Fields(fields []zapcore.Field) []zapcore.Field {
+	masked := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		masked[i] = Field(field)
+	}
+	return masked
+}
+
+// Field masks a single zap field.
+func Field(field zapcore.Field) zapcore.Field {
+	if IsSensitiveKey(field.Key) {
+		return zap.String(field.Key, Mask)
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		field.String = String(field.String)
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			return zap.String(field.Key, String(err.Error()))
+		}
+	case zapcore.ByteStringType:
+		if b, ok := field.Interface.([]byte); ok {
+			return zap.ByteString(field.Key, JSON(b))
+		}
+	case zapcore.StringerType:
+		if s, ok := field.Interface.(fmt.Stringer); ok {
+			return zap.String(field.Key, String(s.String()))
+		}
+	case zapcore.ReflectType:
+		// Round-trip through JSON so struct fields are masked as well
+		data, err := json.Marshal(field.Interface)
+		if err != nil {
+			return zap.String(field.Key, Mask)
+		}
+		return zap.Reflect(field.Key, json.RawMessage(JSON(data)))
+	}
+	return field
+}
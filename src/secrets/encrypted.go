@@ -0,0 +1,68 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// EncryptedScheme prefixes values encrypted with SECRETS_ENCRYPTION_KEY by
+// Encrypt, like the credentials the manager stores itself
+const EncryptedScheme = "enc"
+
+// ErrNoEncryptionKey is returned when SECRETS_ENCRYPTION_KEY isn't set
+var ErrNoEncryptionKey = errors.New("SECRETS_ENCRYPTION_KEY is not set")
+
+// encryptionKey returns the AES-256 key of SECRETS_ENCRYPTION_KEY
+func encryptionKey() (string, error) {
+	key := os.Getenv("SECRETS_ENCRYPTION_KEY")
+	if key == "" {
+		return "", ErrNoEncryptionKey
+	}
+	if len(key) != 32 {
+		return "", errors.New("SECRETS_ENCRYPTION_KEY must be 32 characters long")
+	}
+	return key, nil
+}
+
+// EncryptionEnabled reports whether SECRETS_ENCRYPTION_KEY is set
+func EncryptionEnabled() bool {
+	return os.Getenv("SECRETS_ENCRYPTION_KEY") != ""
+}
+
+// Encrypt encrypts value with SECRETS_ENCRYPTION_KEY into an "enc:"
+// reference, which Resolve decrypts
+func Encrypt(value string) (string, error) {
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := security.Encrypt([]byte(value), key)
+	if err != nil {
+		return "", err
+	}
+	return EncryptedScheme + ":" + encrypted, nil
+}
+
+// IsEncrypted reports whether value was encrypted by Encrypt, unlike other
+// references it lives in the database
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedScheme+":")
+}
+
+// resolveEncrypted decrypts a value encrypted by Encrypt, e.g. "enc:<base64>"
+func resolveEncrypted(_ context.Context, location string) (string, error) {
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+	value, err := security.Decrypt(location, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt, was SECRETS_ENCRYPTION_KEY changed? %w", err)
+	}
+	return string(value), nil
+}
@@ -0,0 +1,20 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// resolveEnv reads a secret from an environment variable, e.g.
+// "env:N8N_PROD_API_KEY". An optional "#field" selects a key of a JSON value.
+func resolveEnv(_ context.Context, location string) (string, error) {
+	name, field := splitField(location)
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return extractField(value, field)
+}
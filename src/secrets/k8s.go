@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/sistemica/n8n-manager-backend/kube"
+)
+
+var (
+	kubeOnce   sync.Once
+	kubeClient *kube.Client
+	kubeErr    error
+)
+
+// resolveK8s reads a key of a Kubernetes Secret, e.g.
+// "k8s:n8n/n8n-credentials#api-key". The namespace defaults to the one the
+// manager runs in ("k8s:n8n-credentials#api-key").
+func resolveK8s(ctx context.Context, location string) (string, error) {
+	ref, key := splitField(location)
+	if key == "" {
+		return "", errors.New("Kubernetes secret reference requires a #key")
+	}
+
+	kubeOnce.Do(func() { kubeClient, kubeErr = kube.InCluster() })
+	if kubeErr != nil {
+		return "", kubeErr
+	}
+
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found {
+		namespace, name = kubeClient.Namespace, ref
+	}
+
+	var secret kube.Secret
+	path := fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", url.PathEscape(namespace), url.PathEscape(name))
+	if err := kubeClient.Get(ctx, path, &secret); err != nil {
+		return "", err
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret %s/%s", key, namespace, name)
+	}
+	return string(value), nil
+}
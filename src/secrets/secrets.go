@@ -0,0 +1,183 @@
+// Package secrets resolves secret references stored in place of credentials,
+// so that values such as n8n API keys never have to live in the database.
+//
+// A reference has the form "<scheme>:<location>[#field]", for example:
+//
+//	vault:kv/n8n/prod#api_key
+//	aws-sm:arn:aws:secretsmanager:eu-central-1:123456789012:secret:n8n-prod#api_key
+//	gcp-sm:projects/my-project/secrets/n8n-prod/versions/latest
+//	env:N8N_PROD_API_KEY
+//	k8s:n8n/n8n-credentials#api-key
+//	enc:<ciphertext>
+//
+// Values without a known scheme are returned unchanged, so plain credentials
+// keep working. "enc:" values are encrypted with SECRETS_ENCRYPTION_KEY, see
+// Encrypt, and hold credentials the manager stores itself.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultCacheTTL = 5 * time.Minute
+
+// Resolver fetches the secret stored at location from a single backend.
+// The location still carries the optional "#field" suffix.
+type Resolver interface {
+	Resolve(ctx context.Context, location string) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface.
+type ResolverFunc func(ctx context.Context, location string) (string, error)
+
+// Resolve calls f(ctx, location).
+func (f ResolverFunc) Resolve(ctx context.Context, location string) (string, error) {
+	return f(ctx, location)
+}
+
+type cacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+var (
+	mu        sync.RWMutex
+	resolvers = map[string]Resolver{}
+	cache     = map[string]cacheEntry{}
+
+	httpClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+func init() {
+	Register("vault", ResolverFunc(resolveVault))
+	Register("aws-sm", ResolverFunc(resolveAWS))
+	Register("gcp-sm", ResolverFunc(resolveGCP))
+	Register("env", ResolverFunc(resolveEnv))
+	Register("k8s", ResolverFunc(resolveK8s))
+	Register(EncryptedScheme, ResolverFunc(resolveEncrypted))
+}
+
+// Register adds or replaces the resolver for scheme.
+func Register(scheme string, resolver Resolver) {
+	mu.Lock()
+	defer mu.Unlock()
+	resolvers[scheme] = resolver
+}
+
+// unregister removes the resolver for scheme and the values it resolved.
+func unregister(scheme string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(resolvers, scheme)
+	for ref := range cache {
+		if strings.HasPrefix(ref, scheme+":") {
+			delete(cache, ref)
+		}
+	}
+}
+
+// parseReference splits a reference into scheme and location.
+// ok is false if value doesn't start with a registered scheme.
+func parseReference(value string) (scheme, location string, ok bool) {
+	scheme, location, found := strings.Cut(value, ":")
+	if !found || location == "" {
+		return "", "", false
+	}
+
+	mu.RLock()
+	_, registered := resolvers[scheme]
+	mu.RUnlock()
+
+	return scheme, location, registered
+}
+
+// IsReference reports whether value is a secret reference rather than a plain value.
+func IsReference(value string) bool {
+	_, _, ok := parseReference(value)
+	return ok
+}
+
+// Resolve returns the secret a reference points to, or value itself if it is
+// not a reference. Resolved secrets are cached for SECRETS_CACHE_TTL
+// (default 5m).
+func Resolve(ctx context.Context, value string) (string, error) {
+	scheme, location, ok := parseReference(value)
+	if !ok {
+		return value, nil
+	}
+
+	mu.RLock()
+	entry, cached := cache[value]
+	resolver := resolvers[scheme]
+	mu.RUnlock()
+
+	if cached && time.Now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	secret, err := resolver.Resolve(ctx, location)
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s secret: %w", scheme, err)
+	}
+
+	mu.Lock()
+	cache[value] = cacheEntry{value: secret, expires: time.Now().Add(cacheTTL())}
+	mu.Unlock()
+
+	return secret, nil
+}
+
+// Invalidate drops a cached reference, forcing the next Resolve to hit the backend.
+func Invalidate(value string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(cache, value)
+}
+
+// splitField separates the "#field" suffix from a location.
+func splitField(location string) (path, field string) {
+	path, field, _ = strings.Cut(location, "#")
+	return path, field
+}
+
+// extractField reads a single key from a secret stored as a JSON object.
+// Without a field the secret is returned as-is.
+func extractField(secret, field string) (string, error) {
+	if field == "" {
+		return secret, nil
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, cannot select field %q", field)
+	}
+
+	return fieldValue(values, field)
+}
+
+// fieldValue returns values[field] as a string.
+func fieldValue(values map[string]any, field string) (string, error) {
+	value, ok := values[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found in secret", field)
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+func cacheTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("SECRETS_CACHE_TTL")); err == nil {
+		return ttl
+	}
+	return defaultCacheTTL
+}
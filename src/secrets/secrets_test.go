@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePlainValue(t *testing.T) {
+	tests := []string{
+		"eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJ0ZXN0In0.sig",
+		"plain-api-key",
+		"unknown:scheme",
+		"",
+	}
+
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			assert.False(t, IsReference(value))
+			resolved, err := Resolve(context.Background(), value)
+			require.NoError(t, err)
+			assert.Equal(t, value, resolved)
+		})
+	}
+}
+
+func TestResolveCaches(t *testing.T) {
+	calls := 0
+	Register("test", ResolverFunc(func(ctx context.Context, location string) (string, error) {
+		calls++
+		path, field := splitField(location)
+		return extractField(`{"api_key":"resolved-`+path+`"}`, field)
+	}))
+	t.Cleanup(func() { unregister("test") })
+
+	ref := "test:n8n/prod#api_key"
+	assert.True(t, IsReference(ref))
+
+	for i := 0; i < 3; i++ {
+		value, err := Resolve(context.Background(), ref)
+		require.NoError(t, err)
+		assert.Equal(t, "resolved-n8n/prod", value)
+	}
+	assert.Equal(t, 1, calls)
+
+	Invalidate(ref)
+	_, err := Resolve(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestResolveVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/kv/data/n8n/prod":
+			json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{"data": map[string]any{"api_key": "from-kv2"}},
+			})
+		case "/v1/legacy/n8n":
+			json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{"api_key": "from-kv1"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "test-token")
+
+	tests := []struct {
+		name     string
+		location string
+		expected string
+		wantErr  bool
+	}{
+		{name: "kv v2", location: "kv/n8n/prod#api_key", expected: "from-kv2"},
+		{name: "kv v1", location: "legacy/n8n#api_key", expected: "from-kv1"},
+		{name: "missing field", location: "kv/n8n/prod#other", wantErr: true},
+		{name: "no field", location: "kv/n8n/prod", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := resolveVault(context.Background(), tt.location)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	t.Setenv("TEST_N8N_API_KEY", "env-key")
+	t.Setenv("TEST_N8N_CREDENTIALS", `{"password":"env-password"}`)
+
+	value, err := resolveEnv(context.Background(), "TEST_N8N_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "env-key", value)
+
+	value, err = resolveEnv(context.Background(), "TEST_N8N_CREDENTIALS#password")
+	require.NoError(t, err)
+	assert.Equal(t, "env-password", value)
+
+	_, err = resolveEnv(context.Background(), "TEST_N8N_MISSING")
+	assert.ErrorContains(t, err, "not set")
+}
+
+func TestEncrypt(t *testing.T) {
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "")
+	_, err := Encrypt("n8n-api-key")
+	assert.ErrorIs(t, err, ErrNoEncryptionKey)
+
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "too-short")
+	_, err = Encrypt("n8n-api-key")
+	assert.Error(t, err)
+
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "0123456789abcdef0123456789abcdef")
+	encrypted, err := Encrypt("n8n-api-key")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.True(t, IsReference(encrypted))
+	assert.NotContains(t, encrypted, "n8n-api-key")
+
+	resolved, err := Resolve(context.Background(), encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "n8n-api-key", resolved)
+
+	Invalidate(encrypted)
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "fedcba9876543210fedcba9876543210")
+	_, err = Resolve(context.Background(), encrypted)
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// resolveVault reads a secret from HashiCorp Vault using VAULT_ADDR and
+// VAULT_TOKEN (and VAULT_NAMESPACE if set).
+//
+// The location is "<mount>/<path>#field". KV v2 mounts are tried first,
+// falling back to the KV v1 layout. A field is required.
+func resolveVault(ctx context.Context, location string) (string, error) {
+	addr := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	token := os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", errors.New("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+
+	path, field := splitField(location)
+	if field == "" {
+		return "", errors.New("vault references require a #field")
+	}
+
+	mount, rest, found := strings.Cut(strings.Trim(path, "/"), "/")
+	if !found {
+		return "", fmt.Errorf("invalid vault path %q", path)
+	}
+
+	// KV v2 nests the secret under data/data
+	var v2 struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	err := vaultGet(ctx, addr, token, fmt.Sprintf("%s/data/%s", mount, rest), &v2)
+	if err == nil && v2.Data.Data != nil {
+		return fieldValue(v2.Data.Data, field)
+	}
+
+	var v1 struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := vaultGet(ctx, addr, token, path, &v1); err != nil {
+		return "", err
+	}
+	return fieldValue(v1.Data, field)
+}
+
+// vaultGet performs an authenticated GET against the Vault HTTP API
+func vaultGet(ctx context.Context, addr, token, path string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+strings.Trim(path, "/"), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("vault request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package slo
+
+import (
+	"strconv"
+	"time"
+)
+
+// BucketSize is the period events are counted in
+const BucketSize = 5 * time.Minute
+
+// Bucket counts the events of a route starting at Start
+type Bucket struct {
+	Start time.Time
+	Total int
+	Good  int
+}
+
+// AlertWindow is a multi-window burn-rate alert: it fires while the error
+// budget burns faster than Factor over both the Long and the Short window.
+// The short window lets the alert resolve soon after the burn stops.
+type AlertWindow struct {
+	Severity string
+	Long     time.Duration
+	Short    time.Duration
+	Factor   float64
+}
+
+// AlertWindows are the alerts evaluated, most severe first. A factor of
+// 14.4 spends 2% of a 30 day budget in an hour, 6 spends 5% in six hours.
+var AlertWindows = []AlertWindow{
+	{Severity: SeverityCritical, Long: time.Hour, Short: 5 * time.Minute, Factor: 14.4},
+	{Severity: SeverityWarning, Long: 6 * time.Hour, Short: 30 * time.Minute, Factor: 6},
+}
+
+// Status is the SLO compliance of a route
+type Status struct {
+	Route     string  `json:"route"`
+	Host      string  `json:"host"`
+	Path      string  `json:"path"`
+	LatencyMs int     `json:"latency_ms"`
+	Objective float64 `json:"objective"`
+
+	// WindowDays is the compliance period
+	WindowDays int `json:"window_days"`
+	Total      int `json:"total"`
+	Good       int `json:"good"`
+
+	// Compliance is the percentage of good events, 100 without events
+	Compliance float64 `json:"compliance"`
+
+	// BudgetRemaining is the share of the error budget left, negative
+	// once the objective is missed
+	BudgetRemaining float64 `json:"budget_remaining"`
+
+	// BurnRates by window, e.g. "1h", 1 spends the budget exactly over
+	// the compliance period
+	BurnRates map[string]float64 `json:"burn_rates"`
+
+	// Alert is the severity of the firing alert, empty if none fires
+	Alert string `json:"alert,omitempty"`
+}
+
+// count sums the buckets starting at or after since
+func count(buckets []Bucket, since time.Time) (total, good int) {
+	for _, bucket := range buckets {
+		if !bucket.Start.Before(since) {
+			total += bucket.Total
+			good += bucket.Good
+		}
+	}
+	return total, good
+}
+
+// burnRate returns how fast the events of the buckets since spend the
+// error budget of config
+func burnRate(config Config, buckets []Bucket, since time.Time) float64 {
+	total, good := count(buckets, since)
+	if total == 0 {
+		return 0
+	}
+	errorRatio := float64(total-good) / float64(total)
+	return errorRatio / config.budget()
+}
+
+// windowName returns d as a burn rate key, e.g. "30m" or "6h"
+func windowName(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	}
+	return strconv.Itoa(int(d/time.Minute)) + "m"
+}
+
+// Evaluate computes the compliance and burn rates of config from the
+// buckets at now. The current bucket is included, it holds the latest
+// events.
+func Evaluate(config Config, buckets []Bucket, now time.Time) Status {
+	config = config.withDefaults()
+	status := Status{
+		LatencyMs:  config.LatencyMs,
+		Objective:  config.Objective,
+		WindowDays: config.WindowDays,
+		Compliance: 100,
+		BurnRates:  map[string]float64{},
+	}
+
+	status.Total, status.Good = count(buckets, now.AddDate(0, 0, -config.WindowDays))
+	if status.Total > 0 {
+		status.Compliance = 100 * float64(status.Good) / float64(status.Total)
+	}
+	status.BudgetRemaining = 1 - burnRate(config, buckets, now.AddDate(0, 0, -config.WindowDays))
+
+	for _, window := range AlertWindows {
+		long := burnRate(config, buckets, now.Add(-window.Long))
+		short := burnRate(config, buckets, now.Add(-window.Short))
+		status.BurnRates[windowName(window.Long)] = long
+		status.BurnRates[windowName(window.Short)] = short
+		if status.Alert == "" && long > window.Factor && short > window.Factor {
+			status.Alert = window.Severity
+		}
+	}
+	return status
+}
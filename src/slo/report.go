@@ -0,0 +1,50 @@
+package slo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/environments"
+	"github.com/sistemica/n8n-manager-backend/trash"
+)
+
+// Report is the response of GET /api/slo
+type Report struct {
+	Generated time.Time `json:"generated"`
+	Routes    []Status  `json:"routes"`
+}
+
+// reportHandler returns the SLO status of the active routes with an SLO,
+// or of the one passed as ?route=, optionally of the instances in
+// ?environment=
+func reportHandler(e *core.RequestEvent) error {
+	filter := dbx.HashExp{"active": true}
+	if route := e.Request.URL.Query().Get("route"); route != "" {
+		filter["id"] = route
+	}
+	environment, err := environments.Filter(e, "instance")
+	if err != nil {
+		return err
+	}
+	routes, err := e.App.FindAllRecords("routes", filter, environment, trash.NotDeleted)
+	if err != nil {
+		return apis.NewInternalServerError("Failed to fetch routes", err)
+	}
+
+	report := Report{Generated: time.Now().UTC(), Routes: []Status{}}
+	for _, route := range routes {
+		config, ok := ConfigOf(route)
+		if !ok {
+			continue
+		}
+		status, err := routeStatus(e.App, route, config, report.Generated)
+		if err != nil {
+			return apis.NewInternalServerError("Failed to evaluate SLO", err)
+		}
+		report.Routes = append(report.Routes, status)
+	}
+	return e.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,398 @@
+// Package slo tracks latency objectives of routes, e.g. 99% of requests
+// answered successfully within 500ms over 30 days. Events are the requests
+// of routes in gateway mode and the runs of synthetic checks, counted per
+// five minutes into the slo_events collection.
+//
+// Multi-window burn-rate alerts (see AlertWindows) are recorded in the
+// slo_alerts collection and sent through the notify package when they fire
+// and resolve.
+package slo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/notify"
+	"github.com/sistemica/n8n-manager-backend/trash"
+	"go.uber.org/zap"
+)
+
+// Path is the endpoint reporting the SLO compliance of routes
+const Path = "/api/slo"
+
+// EventsCollection stores the counted events
+const EventsCollection = "slo_events"
+
+// AlertsCollection stores the burn-rate alerts
+const AlertsCollection = "slo_alerts"
+
+// Ids of the cron jobs
+const (
+	FlushJob    = "flush-slo-events"
+	EvaluateJob = "evaluate-slo"
+	PurgeJob    = "purge-slo-events"
+)
+
+// MaxWindowDays is the longest compliance period, events are kept as long
+const MaxWindowDays = 90
+
+// Alert severities
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Config is the "slo" field of routes records, e.g.
+// {"latency_ms": 500, "objective": 99, "window_days": 30}
+type Config struct {
+	// LatencyMs is the time good events are answered within
+	LatencyMs int `json:"latency_ms"`
+
+	// Objective is the percentage of good events, e.g. 99.5
+	Objective float64 `json:"objective"`
+
+	// WindowDays is the compliance period, 30 by default
+	WindowDays int `json:"window_days,omitempty"`
+}
+
+// ConfigOf returns the SLO of a routes record, ok is false if it has none
+func ConfigOf(route *core.Record) (config Config, ok bool) {
+	if err := route.UnmarshalJSONField("slo", &config); err != nil || !config.enabled() {
+		return Config{}, false
+	}
+	return config.withDefaults(), true
+}
+
+// enabled reports whether c sets an objective
+func (c Config) enabled() bool {
+	return c.LatencyMs > 0 && c.Objective > 0
+}
+
+func (c Config) withDefaults() Config {
+	if c.WindowDays == 0 {
+		c.WindowDays = 30
+	}
+	return c
+}
+
+// budget returns the share of events allowed to be bad
+func (c Config) budget() float64 {
+	return 1 - c.Objective/100
+}
+
+// validate returns an error if c can't be evaluated. An empty config
+// disables the SLO.
+func (c Config) validate() error {
+	if c == (Config{}) {
+		return nil
+	}
+	switch {
+	case c.LatencyMs <= 0:
+		return errors.New("latency_ms must be positive")
+	case c.Objective <= 0 || c.Objective >= 100:
+		return errors.New("objective must be a percentage between 0 and 100, e.g. 99.5")
+	case c.WindowDays < 0 || c.WindowDays > MaxWindowDays:
+		return fmt.Errorf("window_days must be between 1 and %d", MaxWindowDays)
+	}
+	return nil
+}
+
+// good reports whether an event answered after latency is good
+func (c Config) good(latency time.Duration, failed bool) bool {
+	return !failed && latency <= time.Duration(c.LatencyMs)*time.Millisecond
+}
+
+// bucketKey identifies the bucket of a route
+type bucketKey struct {
+	route string
+	start time.Time
+}
+
+// tracker counts events until they are flushed
+type tracker struct {
+	mu      sync.Mutex
+	pending map[bucketKey]*Bucket
+}
+
+// events are the counted events of all routes not written yet
+var events = &tracker{pending: map[bucketKey]*Bucket{}}
+
+// Track counts an event of the routes record route answered after latency,
+// failed if it wasn't answered successfully. Routes without an SLO are
+// ignored.
+func Track(route *core.Record, latency time.Duration, failed bool) {
+	config, ok := ConfigOf(route)
+	if !ok {
+		return
+	}
+	events.track(route.Id, config.good(latency, failed), time.Now())
+}
+
+func (t *tracker) track(routeId string, good bool, now time.Time) {
+	event := &Bucket{Start: now.UTC().Truncate(BucketSize), Total: 1}
+	if good {
+		event.Good = 1
+	}
+	t.merge(map[bucketKey]*Bucket{{routeId, event.Start}: event})
+}
+
+// merge adds counts to the pending ones
+func (t *tracker) merge(counts map[bucketKey]*Bucket) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, c := range counts {
+		if pending, ok := t.pending[key]; ok {
+			pending.Total += c.Total
+			pending.Good += c.Good
+		} else {
+			t.pending[key] = c
+		}
+	}
+}
+
+// take returns the pending counts and resets them
+func (t *tracker) take() map[bucketKey]*Bucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := t.pending
+	t.pending = map[bucketKey]*Bucket{}
+	return counts
+}
+
+// flush adds the pending counts to the events collection. Counts that can't
+// be saved are kept for the next flush, unless their route was deleted.
+func (t *tracker) flush(app core.App) error {
+	counts := t.take()
+	if len(counts) == 0 {
+		return nil
+	}
+	collection, err := app.FindCollectionByNameOrId(EventsCollection)
+	if err != nil {
+		t.merge(counts)
+		return err
+	}
+
+	var lastErr error
+	for key, c := range counts {
+		start := key.start.Format(types.DefaultDateLayout)
+		record, err := app.FindFirstRecordByFilter(EventsCollection,
+			"route = {:route} && start = {:start}",
+			dbx.Params{"route": key.route, "start": start})
+		if err != nil {
+			if _, err := app.FindRecordById("routes", key.route); err != nil {
+				continue
+			}
+			record = core.NewRecord(collection)
+			record.Set("route", key.route)
+			record.Set("start", key.start)
+		}
+		record.Set("total", record.GetInt("total")+c.Total)
+		record.Set("good", record.GetInt("good")+c.Good)
+		if err := app.Save(record); err != nil {
+			t.merge(map[bucketKey]*Bucket{key: c})
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Init registers the report endpoint and the validation of route SLOs,
+// writes the counted events every minute and evaluates the alerts every
+// five minutes
+func Init(app core.App, logger *zap.Logger) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET(Path, reportHandler).Bind(apis.RequireAuth())
+		return se.Next()
+	})
+
+	app.OnRecordValidate("routes").BindFunc(func(e *core.RecordEvent) error {
+		var config Config
+		if err := e.Record.UnmarshalJSONField("slo", &config); err != nil {
+			return validation.Errors{"slo": validation.NewError("invalid_slo", "slo must be an object with latency_ms and objective")}
+		}
+		if err := config.validate(); err != nil {
+			return validation.Errors{"slo": validation.NewError("invalid_slo", err.Error())}
+		}
+		return e.Next()
+	})
+
+	app.Cron().MustAdd(FlushJob, "* * * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", FlushJob))
+
+		if err := events.flush(app); err != nil {
+			logger.Error("Failed to write SLO events", zap.Error(err))
+		}
+	})
+
+	app.Cron().MustAdd(EvaluateJob, "*/5 * * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", EvaluateJob))
+
+		if err := evaluateAlerts(app, logger, time.Now()); err != nil {
+			logger.Error("Failed to evaluate SLO alerts", zap.Error(err))
+		}
+	})
+
+	app.Cron().MustAdd(PurgeJob, "30 5 * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", PurgeJob))
+
+		if err := purge(app, time.Now().AddDate(0, 0, -MaxWindowDays)); err != nil {
+			logger.Error("Failed to purge SLO events", zap.Error(err))
+		}
+	})
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		if err := events.flush(app); err != nil {
+			logger.Error("Failed to write SLO events", zap.Error(err))
+		}
+		return e.Next()
+	})
+}
+
+// routeStatus evaluates the SLO of a routes record at now
+func routeStatus(app core.App, route *core.Record, config Config, now time.Time) (Status, error) {
+	records, err := app.FindAllRecords(EventsCollection, dbx.NewExp("route = {:route} AND start >= {:since}", dbx.Params{
+		"route": route.Id,
+		"since": now.AddDate(0, 0, -config.WindowDays).UTC().Format(types.DefaultDateLayout),
+	}))
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to fetch SLO events: %w", err)
+	}
+
+	buckets := make([]Bucket, len(records))
+	for i, record := range records {
+		buckets[i] = Bucket{
+			Start: record.GetDateTime("start").Time(),
+			Total: record.GetInt("total"),
+			Good:  record.GetInt("good"),
+		}
+	}
+
+	status := Evaluate(config, buckets, now)
+	status.Route = route.Id
+	status.Host = route.GetString("host")
+	status.Path = route.GetString("path")
+	return status, nil
+}
+
+// evaluateAlerts opens and resolves the burn-rate alerts of all routes with
+// an SLO and notifies about the changes
+func evaluateAlerts(app core.App, logger *zap.Logger, now time.Time) error {
+	routes, err := app.FindAllRecords("routes", dbx.HashExp{"active": true}, trash.NotDeleted)
+	if err != nil {
+		return fmt.Errorf("failed to fetch routes: %w", err)
+	}
+	collection, err := app.FindCollectionByNameOrId(AlertsCollection)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range routes {
+		config, ok := ConfigOf(route)
+		if !ok {
+			continue
+		}
+		status, err := routeStatus(app, route, config, now)
+		if err != nil {
+			return err
+		}
+		open, err := app.FindAllRecords(AlertsCollection, dbx.HashExp{"route": route.Id, "resolved": ""})
+		if err != nil {
+			return err
+		}
+
+		current := ""
+		for _, alert := range open {
+			if alert.GetString("severity") == status.Alert {
+				current = status.Alert
+				continue
+			}
+			// The burn stopped or changed its severity
+			alert.Set("resolved", now)
+			if err := app.Save(alert); err != nil {
+				return err
+			}
+			notifyAlert(logger, status, alert.GetString("severity"), true)
+		}
+
+		if status.Alert == "" || current != "" {
+			continue
+		}
+		alert := core.NewRecord(collection)
+		alert.Set("route", route.Id)
+		alert.Set("severity", status.Alert)
+		alert.Set("burn_rate", status.BurnRates[longWindow(status.Alert)])
+		alert.Set("started", now)
+		if err := app.Save(alert); err != nil {
+			return err
+		}
+		logger.Warn("SLO burn-rate alert",
+			zap.String("route", route.Id),
+			zap.String("severity", status.Alert),
+			zap.Float64("compliance", status.Compliance))
+		notifyAlert(logger, status, status.Alert, false)
+	}
+	return nil
+}
+
+// longWindow returns the name of the long window of the alert of severity
+func longWindow(severity string) string {
+	for _, window := range AlertWindows {
+		if window.Severity == severity {
+			return windowName(window.Long)
+		}
+	}
+	return ""
+}
+
+// notifyAlert sends an alert of severity about status, or its resolution
+func notifyAlert(logger *zap.Logger, status Status, severity string, resolved bool) {
+	title := fmt.Sprintf("SLO of %s%s is burning its error budget", status.Host, status.Path)
+	if resolved {
+		title = fmt.Sprintf("SLO of %s%s recovered", status.Host, status.Path)
+	}
+	err := notify.Send(context.Background(), notify.Notification{
+		Event:    "slo.burn_rate",
+		Severity: severity,
+		Title:    title,
+		Message: fmt.Sprintf("%.3f%% of %d events within %dms over %d days (objective %g%%), %.0f%% of the error budget left.",
+			status.Compliance, status.Total, status.LatencyMs, status.WindowDays, status.Objective, 100*status.BudgetRemaining),
+		Resolved: resolved,
+		Fields: map[string]any{
+			"route":      status.Route,
+			"burn_rates": status.BurnRates,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to send SLO notification", zap.Error(err), zap.String("route", status.Route))
+	}
+}
+
+// purge deletes the events and resolved alerts from before
+func purge(app core.App, before time.Time) error {
+	params := dbx.Params{"before": before.UTC().Format(types.DefaultDateLayout)}
+	events, err := app.FindAllRecords(EventsCollection, dbx.NewExp("start < {:before}", params))
+	if err != nil {
+		return err
+	}
+	alerts, err := app.FindAllRecords(AlertsCollection, dbx.NewExp("resolved != '' AND resolved < {:before}", params))
+	if err != nil {
+		return err
+	}
+	for _, record := range append(events, alerts...) {
+		if err := app.Delete(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
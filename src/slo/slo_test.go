@@ -0,0 +1,84 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2025, 5, 2, 12, 0, 0, 0, time.UTC)
+
+// history returns a bucket of 100 events per five minutes of the last day,
+// of which good are good during the last hours and all before
+func history(hours, good int) []Bucket {
+	var result []Bucket
+	for start := now.Add(-24 * time.Hour); start.Before(now); start = start.Add(BucketSize) {
+		bucket := Bucket{Start: start, Total: 100, Good: 100}
+		if !start.Before(now.Add(-time.Duration(hours) * time.Hour)) {
+			bucket.Good = good
+		}
+		result = append(result, bucket)
+	}
+	return result
+}
+
+func TestEvaluate(t *testing.T) {
+	config := Config{LatencyMs: 500, Objective: 99}
+
+	status := Evaluate(config, history(0, 100), now)
+	assert.Equal(t, 100.0, status.Compliance)
+	assert.Equal(t, 1.0, status.BudgetRemaining)
+	assert.Equal(t, 30, status.WindowDays)
+	assert.Empty(t, status.Alert)
+
+	status = Evaluate(config, history(24, 99), now)
+	assert.InDelta(t, 99.0, status.Compliance, 0.001)
+	assert.InDelta(t, 1.0, status.BurnRates["1h"], 0.001, "an error ratio of the budget burns at rate 1")
+	assert.InDelta(t, 0.0, status.BudgetRemaining, 0.001)
+	assert.Empty(t, status.Alert)
+
+	// 20% errors in the last hour burn the budget 20 times too fast
+	status = Evaluate(config, history(1, 80), now)
+	assert.Equal(t, SeverityCritical, status.Alert)
+	assert.InDelta(t, 20.0, status.BurnRates["5m"], 0.001)
+
+	// Once the burn stops the short window resolves the alert
+	recovered := append(history(1, 80), Bucket{Start: now, Total: 100, Good: 100})
+	status = Evaluate(config, recovered, now.Add(BucketSize))
+	assert.Empty(t, status.Alert)
+
+	// 8% errors over six hours are a warning
+	status = Evaluate(config, history(6, 92), now)
+	assert.Equal(t, SeverityWarning, status.Alert)
+
+	status = Evaluate(config, nil, now)
+	assert.Equal(t, 100.0, status.Compliance, "without events the objective is met")
+	assert.Empty(t, status.Alert)
+}
+
+func TestConfig(t *testing.T) {
+	assert.NoError(t, Config{}.validate(), "an empty config disables the SLO")
+	assert.NoError(t, Config{LatencyMs: 500, Objective: 99.5}.validate())
+	assert.Error(t, Config{LatencyMs: 500}.validate())
+	assert.Error(t, Config{LatencyMs: 500, Objective: 100}.validate())
+	assert.Error(t, Config{Objective: 99}.validate())
+	assert.Error(t, Config{LatencyMs: 500, Objective: 99, WindowDays: 365}.validate())
+
+	config := Config{LatencyMs: 500, Objective: 99}
+	assert.True(t, config.good(499*time.Millisecond, false))
+	assert.False(t, config.good(501*time.Millisecond, false))
+	assert.False(t, config.good(time.Millisecond, true))
+}
+
+func TestTracker(t *testing.T) {
+	tracker := &tracker{pending: map[bucketKey]*Bucket{}}
+	tracker.track("route", true, now.Add(time.Minute))
+	tracker.track("route", false, now.Add(2*time.Minute))
+	tracker.track("route", true, now.Add(BucketSize))
+
+	counts := tracker.take()
+	assert.Equal(t, &Bucket{Start: now, Total: 2, Good: 1}, counts[bucketKey{"route", now}])
+	assert.Equal(t, &Bucket{Start: now.Add(BucketSize), Total: 1, Good: 1}, counts[bucketKey{"route", now.Add(BucketSize)}])
+	assert.Empty(t, tracker.take())
+}
@@ -0,0 +1,87 @@
+package synthetic
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBody is the number of response bytes searched for the expected body
+const maxBody = 1 << 20
+
+// Check is a request exercising a route end-to-end
+type Check struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+
+	// Host overrides the Host header, to go through Traefik by its address
+	Host string
+
+	// ExpectedStatus is the status the route must answer with, any 2xx if 0
+	ExpectedStatus int
+
+	// ExpectedBody must be part of the response body if set
+	ExpectedBody string
+
+	Timeout time.Duration
+}
+
+// Result is the outcome of a check
+type Result struct {
+	OK      bool
+	Status  int
+	Latency time.Duration
+	Error   string
+}
+
+// Run sends the request of c with client and verifies the response. The
+// latency includes reading the body.
+func (c Check) Run(ctx context.Context, client *http.Client) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var body io.Reader
+	if c.Body != "" {
+		body = strings.NewReader(c.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, c.Method, c.URL, body)
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+	if c.Host != "" {
+		req.Host = c.Host
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{Latency: time.Since(start), Error: "request failed: " + err.Error()}
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	result := Result{Status: resp.StatusCode, Latency: time.Since(start)}
+	if err != nil {
+		result.Error = "failed to read response: " + err.Error()
+		return result
+	}
+
+	switch {
+	case c.ExpectedStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+		result.Error = fmt.Sprintf("expected a 2xx status, got %d", resp.StatusCode)
+	case c.ExpectedStatus != 0 && resp.StatusCode != c.ExpectedStatus:
+		result.Error = fmt.Sprintf("expected status %d, got %d", c.ExpectedStatus, resp.StatusCode)
+	case c.ExpectedBody != "" && !strings.Contains(string(content), c.ExpectedBody):
+		result.Error = fmt.Sprintf("response doesn't contain %q", c.ExpectedBody)
+	default:
+		result.OK = true
+	}
+	return result
+}
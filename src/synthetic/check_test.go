@@ -0,0 +1,111 @@
+package synthetic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var hosts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		switch r.URL.Path {
+		case "/status":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	check := Check{Method: http.MethodGet, URL: server.URL + "/status", Host: "api.example.com", ExpectedBody: `"ok"`, Timeout: time.Second}
+	result := check.Run(context.Background(), server.Client())
+	assert.True(t, result.OK, result.Error)
+	assert.Equal(t, http.StatusOK, result.Status)
+	assert.Positive(t, result.Latency)
+	assert.Equal(t, "api.example.com", hosts[0])
+
+	check.ExpectedBody = "healthy"
+	result = check.Run(context.Background(), server.Client())
+	assert.False(t, result.OK)
+	assert.Contains(t, result.Error, "doesn't contain")
+
+	check = Check{Method: http.MethodGet, URL: server.URL + "/missing", Timeout: time.Second}
+	result = check.Run(context.Background(), server.Client())
+	assert.False(t, result.OK, "any 2xx status is expected by default")
+	assert.Equal(t, http.StatusNotFound, result.Status)
+
+	check.ExpectedStatus = http.StatusNotFound
+	assert.True(t, check.Run(context.Background(), server.Client()).OK)
+
+	check = Check{Method: http.MethodGet, URL: server.URL + "/slow", Timeout: 50 * time.Millisecond}
+	result = check.Run(context.Background(), server.Client())
+	assert.False(t, result.OK)
+	assert.Contains(t, result.Error, "request failed")
+}
+
+func TestCheckFromRecord(t *testing.T) {
+	routes := core.NewBaseCollection("routes")
+	routes.Fields.Add(&core.TextField{Name: "host"}, &core.TextField{Name: "path"})
+	checks := core.NewBaseCollection(Collection)
+	checks.Fields.Add(
+		&core.TextField{Name: "path"},
+		&core.TextField{Name: "method"},
+		&core.JSONField{Name: "headers"},
+		&core.NumberField{Name: "timeout_secs"},
+	)
+
+	route := core.NewRecord(routes)
+	route.Set("host", "API.example.com")
+	route.Set("path", "/orders")
+	record := core.NewRecord(checks)
+	record.Set("headers", map[string]string{"X-API-Key": "secret"})
+
+	check, err := checkFromRecord(record, route, config{scheme: "https"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/orders", check.URL)
+	assert.Equal(t, http.MethodGet, check.Method)
+	assert.Equal(t, defaultTimeout, check.Timeout)
+	assert.Equal(t, map[string]string{"X-API-Key": "secret"}, check.Headers)
+	assert.Empty(t, check.Host)
+
+	record.Set("path", "/orders/42?full=true")
+	record.Set("method", http.MethodHead)
+	check, err = checkFromRecord(record, route, config{scheme: "https", traefikURL: "http://traefik:80"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://traefik:80/orders/42?full=true", check.URL, "checks go through Traefik with the route host")
+	assert.Equal(t, "api.example.com", check.Host)
+	assert.Equal(t, http.MethodHead, check.Method)
+
+	record.Set("path", "")
+	route.Set("path", "/orders/{id}")
+	_, err = checkFromRecord(record, route, config{scheme: "https"})
+	assert.ErrorContains(t, err, "parameters")
+
+	route.Set("host", "*.example.com")
+	_, err = checkFromRecord(record, route, config{scheme: "https"})
+	assert.ErrorContains(t, err, "wildcard")
+}
+
+func TestDue(t *testing.T) {
+	checks := core.NewBaseCollection(Collection)
+	checks.Fields.Add(&core.NumberField{Name: "interval_mins"}, &core.DateField{Name: "last_run"})
+	record := core.NewRecord(checks)
+	now := time.Now()
+
+	assert.True(t, due(record, now), "checks that never ran are due")
+
+	record.Set("last_run", now.Add(-2*time.Minute))
+	assert.False(t, due(record, now))
+	record.Set("interval_mins", 1)
+	assert.True(t, due(record, now))
+}
@@ -0,0 +1,273 @@
+// Package synthetic runs the checks of the synthetic_checks collection:
+// requests sent periodically to the public URL of a route, so the whole
+// path through DNS, Traefik and its middlewares to the workflow is
+// exercised. Each run is recorded in synthetic_results with its latency and
+// whether the response was the expected one, separately from the API health
+// check of the instances.
+//
+// Checks may execute workflows, they should target routes whose workflows
+// tolerate the requests (e.g. a GET answering a static status).
+package synthetic
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/maintenance"
+	"github.com/sistemica/n8n-manager-backend/slo"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"go.uber.org/zap"
+)
+
+// Collection stores the checks
+const Collection = "synthetic_checks"
+
+// ResultsCollection stores a record per run of a check
+const ResultsCollection = "synthetic_results"
+
+// RunJob is the id of the cron job running the due checks
+const RunJob = "run-synthetic-checks"
+
+// PurgeJob is the id of the cron job deleting old results
+const PurgeJob = "purge-synthetic-results"
+
+// HistoryDays is how many days of results are kept
+const HistoryDays = 30
+
+// Defaults of checks without interval or timeout
+const (
+	defaultInterval = 5 * time.Minute
+	defaultTimeout  = 10 * time.Second
+)
+
+// config is read from the environment:
+//
+//	SYNTHETIC_CHECKS             "off" disables running checks
+//	SYNTHETIC_CHECK_TRAEFIK_URL  base URL of Traefik, checks are sent to it with the
+//	                             host of the route instead of resolving the host
+//	SYNTHETIC_CHECK_SCHEME       scheme of the public URLs, https by default
+type config struct {
+	disabled   bool
+	traefikURL string
+	scheme     string
+}
+
+func configFromEnv() config {
+	return config{
+		disabled:   os.Getenv("SYNTHETIC_CHECKS") == "off",
+		traefikURL: strings.TrimRight(os.Getenv("SYNTHETIC_CHECK_TRAEFIK_URL"), "/"),
+		scheme:     cmp.Or(os.Getenv("SYNTHETIC_CHECK_SCHEME"), "https"),
+	}
+}
+
+// running holds the ids of the checks in progress, a check slower than the
+// cron tick isn't started twice
+var running sync.Map
+
+// Init runs the due checks every minute and purges results older than
+// HistoryDays once a day
+func Init(app core.App, logger *zap.Logger) {
+	config := configFromEnv()
+	bindValidation(app)
+
+	app.Cron().MustAdd(PurgeJob, "15 5 * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", PurgeJob))
+
+		if err := purge(app, time.Now().AddDate(0, 0, -HistoryDays)); err != nil {
+			logger.Error("Failed to purge synthetic check results", zap.Error(err))
+		}
+	})
+
+	if config.disabled {
+		return
+	}
+
+	client := &http.Client{
+		// A redirect is the answer of the route, don't follow it
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	app.Cron().MustAdd(RunJob, "* * * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", RunJob))
+
+		// Routes answer 503 during maintenance
+		if maintenance.Enabled(app, logger) {
+			return
+		}
+
+		checks, err := app.FindAllRecords(Collection, dbx.HashExp{"active": true})
+		if err != nil {
+			logger.Error("Failed to fetch synthetic checks", zap.Error(err))
+			return
+		}
+
+		now := time.Now()
+		for _, record := range checks {
+			if !due(record, now) {
+				continue
+			}
+			if _, busy := running.LoadOrStore(record.Id, true); busy {
+				continue
+			}
+			// Checks run concurrently so a slow route doesn't delay the others
+			go func(record *core.Record) {
+				defer running.Delete(record.Id)
+				defer errorreport.Recover(logger, zap.String("job", RunJob), zap.String("check", record.Id))
+
+				runCheck(context.Background(), app, client, config, record, logger)
+			}(record)
+		}
+	})
+}
+
+// bindValidation rejects checks with headers that can't be sent
+func bindValidation(app core.App) {
+	app.OnRecordValidate(Collection).BindFunc(func(e *core.RecordEvent) error {
+		var headers map[string]string
+		if err := e.Record.UnmarshalJSONField("headers", &headers); err != nil {
+			return validation.Errors{"headers": validation.NewError("invalid_headers", "headers must be an object of header values")}
+		}
+		return e.Next()
+	})
+}
+
+// due reports whether the interval of a check has elapsed since its last run
+func due(record *core.Record, now time.Time) bool {
+	interval := time.Duration(record.GetInt("interval_mins")) * time.Minute
+	if interval == 0 {
+		interval = defaultInterval
+	}
+	lastRun := record.GetDateTime("last_run")
+	return lastRun.IsZero() || !now.Before(lastRun.Time().Add(interval))
+}
+
+// runCheck runs a check and records its result
+func runCheck(ctx context.Context, app core.App, client *http.Client, config config, record *core.Record, logger *zap.Logger) {
+	var result Result
+	route, err := app.FindRecordById("routes", record.GetString("route"))
+	if err != nil {
+		result.Error = "route not found"
+	} else if !route.GetBool("active") {
+		result.Error = "route is inactive"
+	} else if check, err := checkFromRecord(record, route, config); err != nil {
+		result.Error = err.Error()
+	} else {
+		result = check.Run(ctx, client)
+		slo.Track(route, result.Latency, !result.OK)
+	}
+
+	if err := saveResult(app, record, result, time.Now()); err != nil {
+		logger.Error("Failed to save synthetic check result",
+			zap.Error(err),
+			zap.String("check", record.Id))
+		return
+	}
+	if !result.OK {
+		logger.Warn("Synthetic check failed",
+			zap.String("check", record.Id),
+			zap.String("route", record.GetString("route")),
+			zap.Int("status", result.Status),
+			zap.String("error", result.Error))
+	}
+}
+
+// checkFromRecord returns the check of a synthetic_checks record against
+// the public URL of its route
+func checkFromRecord(record, route *core.Record, config config) (Check, error) {
+	host, err := traefik.NormalizeHost(route.GetString("host"))
+	if err != nil {
+		return Check{}, err
+	}
+	if strings.HasPrefix(host, "*.") {
+		return Check{}, fmt.Errorf("route host %q is a wildcard, checks need a concrete host", host)
+	}
+
+	path := cmp.Or(record.GetString("path"), route.GetString("path"), "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if strings.ContainsAny(path, "{}") {
+		return Check{}, fmt.Errorf("path %q has parameters, set the path of the check", path)
+	}
+
+	check := Check{
+		Method:         cmp.Or(record.GetString("method"), http.MethodGet),
+		URL:            config.scheme + "://" + host + path,
+		Body:           record.GetString("body"),
+		ExpectedStatus: record.GetInt("expected_status"),
+		ExpectedBody:   record.GetString("expected_body"),
+		Timeout:        time.Duration(record.GetInt("timeout_secs")) * time.Second,
+	}
+	if check.Timeout == 0 {
+		check.Timeout = defaultTimeout
+	}
+	if err := record.UnmarshalJSONField("headers", &check.Headers); err != nil {
+		return Check{}, fmt.Errorf("invalid headers: %w", err)
+	}
+	if config.traefikURL != "" {
+		check.URL = config.traefikURL + path
+		check.Host = host
+	}
+	if _, err := url.Parse(check.URL); err != nil {
+		return Check{}, fmt.Errorf("invalid URL: %w", err)
+	}
+	return check, nil
+}
+
+// saveResult stores result as the last one of the check record and in the
+// results history
+func saveResult(app core.App, record *core.Record, result Result, at time.Time) error {
+	collection, err := app.FindCollectionByNameOrId(ResultsCollection)
+	if err != nil {
+		return err
+	}
+
+	return app.RunInTransaction(func(txApp core.App) error {
+		record.Set("last_run", at)
+		record.Set("last_ok", result.OK)
+		record.Set("last_status", result.Status)
+		record.Set("last_latency_ms", result.Latency.Milliseconds())
+		record.Set("last_error", result.Error)
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+
+		run := core.NewRecord(collection)
+		run.Set("check", record.Id)
+		run.Set("route", record.GetString("route"))
+		run.Set("ok", result.OK)
+		run.Set("status", result.Status)
+		run.Set("latency_ms", result.Latency.Milliseconds())
+		run.Set("error", result.Error)
+		return txApp.Save(run)
+	})
+}
+
+// purge deletes the results recorded before
+func purge(app core.App, before time.Time) error {
+	records, err := app.FindAllRecords(ResultsCollection,
+		dbx.NewExp("created < {:before}", dbx.Params{"before": before.UTC().Format(types.DefaultDateLayout)}))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := app.Delete(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
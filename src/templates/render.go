@@ -0,0 +1,96 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// placeholder matches "[[NAME]]". The syntax doesn't clash with n8n
+// expressions, which use "{{ }}".
+var placeholder = regexp.MustCompile(`\[\[\s*([A-Za-z_][A-Za-z0-9_]*)\s*\]\]`)
+
+// Variable declares a template variable
+type Variable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// resolveValues merges the provided values with the variable defaults and
+// checks that all required variables are set
+func resolveValues(variables []Variable, provided map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(variables))
+	var missing []string
+
+	for _, variable := range variables {
+		value, ok := provided[variable.Name]
+		if !ok || value == "" {
+			value = variable.Default
+		}
+		if value == "" && variable.Required {
+			missing = append(missing, variable.Name)
+			continue
+		}
+		values[variable.Name] = value
+	}
+
+	for name := range provided {
+		if _, ok := values[name]; !ok && !slices.Contains(missing, name) {
+			return nil, fmt.Errorf("unknown variable %q", name)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+// render replaces placeholders in all strings of a decoded JSON value.
+// Replacing inside decoded strings keeps the JSON valid whatever the values
+// contain. Placeholders without a value are an error.
+func render(value any, values map[string]string) (any, error) {
+	switch v := value.(type) {
+	case string:
+		var unknown string
+		rendered := placeholder.ReplaceAllStringFunc(v, func(match string) string {
+			name := placeholder.FindStringSubmatch(match)[1]
+			if replacement, ok := values[name]; ok {
+				return replacement
+			}
+			unknown = name
+			return match
+		})
+		if unknown != "" {
+			return nil, fmt.Errorf("undeclared variable %q", unknown)
+		}
+		return rendered, nil
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			rendered, err := render(item, values)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = rendered
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			rendered, err := render(item, values)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = rendered
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,58 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveValues(t *testing.T) {
+	variables := []Variable{
+		{Name: "WEBHOOK_PATH", Required: true},
+		{Name: "CHANNEL", Default: "#general"},
+	}
+
+	values, err := resolveValues(variables, map[string]string{"WEBHOOK_PATH": "orders"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"WEBHOOK_PATH": "orders", "CHANNEL": "#general"}, values)
+
+	_, err = resolveValues(variables, map[string]string{"CHANNEL": "#ops"})
+	assert.ErrorContains(t, err, "missing required variables: WEBHOOK_PATH")
+
+	_, err = resolveValues(variables, map[string]string{"WEBHOOK_PATH": "orders", "OTHER": "x"})
+	assert.ErrorContains(t, err, `unknown variable "OTHER"`)
+}
+
+func TestRender(t *testing.T) {
+	workflow := map[string]any{
+		"name": "Orders [[ENV]]",
+		"nodes": []any{
+			map[string]any{
+				"type": "n8n-nodes-base.webhook",
+				"parameters": map[string]any{
+					"path":    "[[WEBHOOK_PATH]]",
+					"message": `={{ $json.text }} "[[ENV]]"`,
+				},
+				"position": []any{float64(100), float64(200)},
+			},
+		},
+	}
+
+	rendered, err := render(workflow, map[string]string{"ENV": "prod", "WEBHOOK_PATH": "orders"})
+	require.NoError(t, err)
+
+	result := rendered.(map[string]any)
+	assert.Equal(t, "Orders prod", result["name"])
+	node := result["nodes"].([]any)[0].(map[string]any)
+	params := node["parameters"].(map[string]any)
+	assert.Equal(t, "orders", params["path"])
+	assert.Equal(t, `={{ $json.text }} "prod"`, params["message"])
+	assert.Equal(t, []any{float64(100), float64(200)}, node["position"])
+
+	// The input is left untouched
+	assert.Equal(t, "Orders [[ENV]]", workflow["name"])
+
+	_, err = render(workflow, map[string]string{"ENV": "prod"})
+	assert.ErrorContains(t, err, `undeclared variable "WEBHOOK_PATH"`)
+}
@@ -0,0 +1,206 @@
+// Package templates provides a library of reusable workflows. A template
+// holds workflow JSON with "[[NAME]]" placeholders, which are rendered when
+// the template is deployed to an instance.
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"github.com/sistemica/n8n-manager-backend/n8n"
+	"go.uber.org/zap"
+)
+
+// DeployRequest is the body of POST /api/templates/{id}/deploy
+type DeployRequest struct {
+	Instance  string            `json:"instance"`
+	Variables map[string]string `json:"variables"`
+	Name      string            `json:"name"`
+	Activate  bool              `json:"activate"`
+}
+
+// DeployResult describes a deployment
+type DeployResult struct {
+	Deployment      string `json:"deployment"`
+	Instance        string `json:"instance"`
+	WorkflowID      string `json:"workflow_id"`
+	TemplateVersion int    `json:"template_version"`
+	Active          bool   `json:"active"`
+}
+
+// InitAPI registers the template endpoints and keeps template versions up to date
+func InitAPI(app core.App, logger *zap.Logger) {
+	// Bump the version whenever the workflow or variables of a template change
+	app.OnRecordUpdate("templates").BindFunc(func(e *core.RecordEvent) error {
+		original := e.Record.Original()
+		if string(mustJSON(original.Get("workflow"))) != string(mustJSON(e.Record.Get("workflow"))) ||
+			string(mustJSON(original.Get("variables"))) != string(mustJSON(e.Record.Get("variables"))) {
+			e.Record.Set("version", original.GetInt("version")+1)
+		}
+		return e.Next()
+	})
+	app.OnRecordCreate("templates").BindFunc(func(e *core.RecordEvent) error {
+		e.Record.Set("version", 1)
+		return e.Next()
+	})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/templates/{id}/deploy", func(e *core.RequestEvent) error {
+			return deployHandler(e, logger)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
+
+func mustJSON(value any) []byte {
+	data, _ := json.Marshal(value)
+	return data
+}
+
+// deployHandler renders a template and imports it into an instance
+func deployHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	var body DeployRequest
+	if err := e.BindBody(&body); err != nil {
+		return apis.NewBadRequestError("Invalid request body", err)
+	}
+
+	template, err := e.App.FindRecordById("templates", e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Template not found", err)
+	}
+
+	instanceRecord, err := e.App.FindRecordById("instances", body.Instance)
+	if err != nil {
+		return apis.NewBadRequestError("Instance not found", err)
+	}
+
+	workflow, err := renderTemplate(template, body)
+	if err != nil {
+		return apis.NewBadRequestError(err.Error(), nil)
+	}
+
+	result, err := deploy(e, template, instanceRecord, workflow, body)
+
+	entry := audit.Entry{
+		Action:   "template.deployed",
+		Instance: instanceRecord.Id,
+		Actor:    audit.Actor(e.Auth),
+		Success:  err == nil,
+		Details: map[string]any{
+			"template":         template.Id,
+			"template_version": template.GetInt("version"),
+		},
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	} else {
+		entry.Message = "Deployed template " + template.GetString("name")
+		entry.Details["workflow_id"] = result.WorkflowID
+	}
+	if auditErr := audit.Log(e.App, entry); auditErr != nil {
+		logger.Error("Failed to write audit log", zap.Error(auditErr))
+	}
+
+	if err != nil {
+		logger.Error("Failed to deploy template",
+			zap.Error(err),
+			zap.String("template", template.Id),
+			zap.String("instance", instanceRecord.Id))
+		return apis.NewApiError(http.StatusBadGateway, "Deployment failed: "+err.Error(), nil)
+	}
+
+	logger.Info("Deployed template",
+		zap.String("template", template.Id),
+		zap.Int("version", result.TemplateVersion),
+		zap.String("instance", instanceRecord.Id),
+		zap.String("workflow_id", result.WorkflowID))
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// renderTemplate renders the workflow of a template with the request variables
+func renderTemplate(template *core.Record, body DeployRequest) (map[string]any, error) {
+	var variables []Variable
+	if err := template.UnmarshalJSONField("variables", &variables); err != nil {
+		return nil, fmt.Errorf("invalid template variables: %w", err)
+	}
+
+	var workflow map[string]any
+	if err := template.UnmarshalJSONField("workflow", &workflow); err != nil || workflow == nil {
+		return nil, errors.New("template has no valid workflow JSON")
+	}
+
+	values, err := resolveValues(variables, body.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := render(workflow, values)
+	if err != nil {
+		return nil, err
+	}
+	workflow = rendered.(map[string]any)
+
+	if body.Name != "" {
+		workflow["name"] = body.Name
+	}
+	if name, _ := workflow["name"].(string); name == "" {
+		workflow["name"] = template.GetString("name")
+	}
+
+	return workflow, nil
+}
+
+// deploy imports the rendered workflow and records the deployment
+func deploy(e *core.RequestEvent, template, instanceRecord *core.Record, workflow map[string]any, body DeployRequest) (*DeployResult, error) {
+	ctx := e.Request.Context()
+
+	instance, err := n8n.InstanceFromRecord(ctx, instanceRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	client := n8n.NewN8NClient(instance)
+	created, err := client.CreateWorkflow(ctx, workflow)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DeployResult{
+		Instance:        instanceRecord.Id,
+		WorkflowID:      created.WorkflowID,
+		TemplateVersion: template.GetInt("version"),
+	}
+
+	if body.Activate {
+		if err := client.ActivateWorkflow(ctx, created.WorkflowID); err != nil {
+			return nil, fmt.Errorf("workflow %s was created but could not be activated: %w", created.WorkflowID, err)
+		}
+		result.Active = true
+	}
+
+	collection, err := e.App.FindCollectionByNameOrId("template_deployments")
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := core.NewRecord(collection)
+	deployment.Set("template", template.Id)
+	deployment.Set("template_version", result.TemplateVersion)
+	deployment.Set("instance", instanceRecord.Id)
+	deployment.Set("workflow_id", created.WorkflowID)
+	deployment.Set("variables", body.Variables)
+	deployment.Set("deployed_by", audit.Actor(e.Auth))
+	if err := e.App.Save(deployment); err != nil {
+		return nil, fmt.Errorf("workflow %s was created but the deployment could not be stored: %w", created.WorkflowID, err)
+	}
+	result.Deployment = deployment.Id
+
+	return result, nil
+}
@@ -0,0 +1,45 @@
+// Package testutil provides helpers for tests that need a PocketBase app
+// with the collections of the manager.
+package testutil
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	_ "github.com/sistemica/n8n-manager-backend/migrations"
+)
+
+// NewApp returns an app with an empty database migrated to the latest
+// collections, cleaned up when t ends. It skips the test on toolchains
+// whose encoding/json can't load PocketBase collections, run them with
+// GOEXPERIMENT=nojsonv2.
+func NewApp(t testing.TB) *tests.TestApp {
+	t.Helper()
+	if jsonV2 {
+		t.Skip("PocketBase collections don't decode with encoding/json/v2, run with GOEXPERIMENT=nojsonv2")
+	}
+	app, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	t.Cleanup(app.Cleanup)
+	return app
+}
+
+// Create saves a record of collection with values, without validation
+func Create(t testing.TB, app core.App, collection string, values map[string]any) *core.Record {
+	t.Helper()
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatalf("collection %s not found: %v", collection, err)
+	}
+	record := core.NewRecord(c)
+	for key, value := range values {
+		record.Set(key, value)
+	}
+	if err := app.SaveNoValidate(record); err != nil {
+		t.Fatalf("failed to save %s record: %v", collection, err)
+	}
+	return record
+}
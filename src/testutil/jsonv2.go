@@ -0,0 +1,7 @@
+//go:build goexperiment.jsonv2
+
+package testutil
+
+// jsonV2 is set when encoding/json is backed by encoding/json/v2, which
+// recurses infinitely in the UnmarshalJSON of PocketBase v0.25 collections
+const jsonV2 = true
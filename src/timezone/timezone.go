@@ -0,0 +1,122 @@
+// Package timezone resolves the timezones schedules and reports are
+// evaluated in. The global timezone, set by TIMEZONE (default UTC), is the
+// one of the cron scheduler, so expressions like
+// ROUTE_EXPIRY_REMINDER_SCHEDULE or SECURITY_AUDIT_SCHEDULE run at local
+// times, and of the days of usage reports and the status page. Instances
+// may override it with their timezone field, which applies to their
+// schedule triggers without a timezone, the activation windows of their
+// routes and the times in their notifications.
+package timezone
+
+import (
+	"fmt"
+	"os"
+	"time"
+	_ "time/tzdata" // images without zoneinfo
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/trash"
+)
+
+// Field is the timezone field of instances records
+const Field = "timezone"
+
+// Layout formats times in notifications and messages
+const Layout = "2006-01-02 15:04 MST"
+
+// global is the timezone set by TIMEZONE, loaded by Init
+var global = time.UTC
+
+// Load returns the IANA timezone name, e.g. Europe/Berlin. An empty name is
+// the global timezone.
+func Load(name string) (*time.Location, error) {
+	if name == "" {
+		return Global(), nil
+	}
+	return time.LoadLocation(name)
+}
+
+// Global returns the timezone set by TIMEZONE
+func Global() *time.Location {
+	return global
+}
+
+// Of returns the timezone of an instances record
+func Of(instance *core.Record) *time.Location {
+	if instance == nil {
+		return Global()
+	}
+	loc, err := Load(instance.GetString(Field))
+	if err != nil {
+		return Global()
+	}
+	return loc
+}
+
+// ForInstance returns the timezone of the instance with id instanceID, the
+// global one if it doesn't exist
+func ForInstance(app core.App, instanceID string) *time.Location {
+	if instanceID == "" {
+		return Global()
+	}
+	instance, err := app.FindRecordById("instances", instanceID)
+	if err != nil {
+		return Global()
+	}
+	return Of(instance)
+}
+
+// Locations are the timezones of instances by id
+type Locations map[string]*time.Location
+
+// Of returns the timezone of the instance with id instanceID
+func (l Locations) Of(instanceID string) *time.Location {
+	if loc := l[instanceID]; loc != nil {
+		return loc
+	}
+	return Global()
+}
+
+// ByInstance returns the timezones of the instances setting one
+func ByInstance(app core.App) (Locations, error) {
+	instances, err := app.FindAllRecords("instances", trash.NotDeleted)
+	if err != nil {
+		return nil, err
+	}
+	locations := Locations{}
+	for _, instance := range instances {
+		if instance.GetString(Field) != "" {
+			locations[instance.Id] = Of(instance)
+		}
+	}
+	return locations, nil
+}
+
+// Format formats t in loc with Layout
+func Format(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(Layout)
+}
+
+// Init loads the global timezone from TIMEZONE, sets it as the timezone of
+// the cron scheduler and rejects instances with an unknown timezone
+func Init(app core.App) error {
+	if name := os.Getenv("TIMEZONE"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return fmt.Errorf("invalid TIMEZONE %q: %w", name, err)
+		}
+		global = loc
+	}
+	app.Cron().SetTimezone(global)
+
+	app.OnRecordValidate("instances").BindFunc(func(e *core.RecordEvent) error {
+		if name := e.Record.GetString(Field); name != "" {
+			if _, err := time.LoadLocation(name); err != nil {
+				return validation.Errors{Field: validation.NewError("invalid_timezone", "Unknown timezone "+name+", use an IANA name like Europe/Berlin")}
+			}
+		}
+		return e.Next()
+	})
+	return nil
+}
@@ -0,0 +1,50 @@
+package timezone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOf(t *testing.T) {
+	instances := core.NewBaseCollection("instances")
+	instances.Fields.Add(&core.TextField{Name: Field})
+
+	instance := core.NewRecord(instances)
+	assert.Equal(t, Global(), Of(instance))
+	assert.Equal(t, Global(), Of(nil))
+
+	instance.Set(Field, "Europe/Berlin")
+	assert.Equal(t, "Europe/Berlin", Of(instance).String())
+
+	instance.Set(Field, "Not/AZone")
+	assert.Equal(t, Global(), Of(instance), "unknown timezones fall back to the global one")
+}
+
+func TestLocations(t *testing.T) {
+	berlin, err := Load("Europe/Berlin")
+	require.NoError(t, err)
+	locations := Locations{"berlin": berlin}
+
+	assert.Equal(t, berlin, locations.Of("berlin"))
+	assert.Equal(t, Global(), locations.Of("other"))
+	assert.Equal(t, Global(), Locations(nil).Of("berlin"))
+
+	loc, err := Load("")
+	require.NoError(t, err)
+	assert.Equal(t, Global(), loc)
+	_, err = Load("Not/AZone")
+	assert.Error(t, err)
+}
+
+func TestFormat(t *testing.T) {
+	berlin, err := Load("Europe/Berlin")
+	require.NoError(t, err)
+	at := time.Date(2025, 6, 2, 7, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "2025-06-02 09:30 CEST", Format(at, berlin))
+	assert.Equal(t, "2025-06-02 07:30 UTC", Format(at, time.UTC))
+}
@@ -0,0 +1,99 @@
+// Package tracing configures OpenTelemetry tracing. The exporter is set up
+// from the standard OTEL_* environment variables, e.g.:
+//
+//	OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4318
+//	OTEL_SERVICE_NAME=n8n-manager
+//	OTEL_TRACES_SAMPLER=parentbased_traceidratio
+//	OTEL_TRACES_SAMPLER_ARG=0.25
+//
+// Tracing stays disabled (no-op) unless an OTLP endpoint is configured or
+// OTEL_TRACES_EXPORTER=otlp is set.
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const defaultServiceName = "n8n-manager-backend"
+
+// Enabled reports whether the environment configures a trace exporter.
+func Enabled() bool {
+	if os.Getenv("OTEL_SDK_DISABLED") == "true" || os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return false
+	}
+	return os.Getenv("OTEL_TRACES_EXPORTER") == "otlp" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Init installs the global tracer provider. The returned function flushes
+// pending spans and must be called on shutdown.
+func Init(ctx context.Context, logger *zap.Logger) (func(context.Context) error, error) {
+	if !Enabled() {
+		logger.Debug("Tracing disabled, no OTLP exporter configured")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(serviceName())),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Let OTEL_SERVICE_NAME / OTEL_RESOURCE_ATTRIBUTES win over the default name
+	envResource, err := resource.New(ctx, resource.WithFromEnv())
+	if err == nil {
+		res, _ = resource.Merge(res, envResource)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	logger.Info("Tracing enabled", zap.String("service", serviceName()))
+	return provider.Shutdown, nil
+}
+
+func serviceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+	return defaultServiceName
+}
+
+// Tracer returns a named tracer from the global provider.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// End records err on span (if any) and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -4,6 +4,10 @@ package traefik
 
 import (
 	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -34,6 +38,7 @@ func (n *ResourceNamer) generateName(parts ...string) string {
 		"{", "",
 		"}", "",
 		":", "-",
+		"*", "wildcard",
 	)
 	name := replacer.Replace(fullName)
 
@@ -62,13 +67,48 @@ func (n *ResourceNamer) getMiddlewareName(rd RouteDefinition, mwType string) str
 // Builder constructs Traefik's dynamic configuration from route definitions.
 type Builder struct {
 	namer *ResourceNamer
+
+	// routeHeader names the header carrying the router name, see WithClientInfo
+	routeHeader string
+
+	// hasher hashes basic auth passwords, see WithPasswordHasher
+	hasher *PasswordHasher
+}
+
+// BuilderOption configures a Builder
+type BuilderOption func(*Builder)
+
+// WithClientInfo forwards the original client information to the services:
+// the original Host header and routeHeader (e.g. "X-N8N-Route") carrying the
+// name of the matched router, so workflows can branch on the public route.
+// Traefik itself sets X-Forwarded-For, -Host, -Proto and X-Real-Ip; to pass
+// through the values of a proxy in front of Traefik, trust it with the
+// entrypoint's forwardedHeaders.trustedIPs option.
+func WithClientInfo(routeHeader string) BuilderOption {
+	return func(b *Builder) {
+		b.routeHeader = routeHeader
+	}
+}
+
+// WithPasswordHasher hashes basic auth passwords with hasher instead of
+// DefaultHasher, e.g. to use another bcrypt cost. Builders are short lived,
+// the hasher should be shared to keep its cache.
+func WithPasswordHasher(hasher *PasswordHasher) BuilderOption {
+	return func(b *Builder) {
+		b.hasher = hasher
+	}
 }
 
 // NewBuilder creates a new Builder instance.
-func NewBuilder() *Builder {
-	return &Builder{
-		namer: NewResourceNamer(),
+func NewBuilder(options ...BuilderOption) *Builder {
+	b := &Builder{
+		namer:  NewResourceNamer(),
+		hasher: DefaultHasher,
+	}
+	for _, option := range options {
+		option(b)
 	}
+	return b
 }
 
 // Build generates a complete Traefik dynamic configuration from route definitions.
@@ -104,10 +144,35 @@ func (b *Builder) addRoute(rd RouteDefinition, config *DynamicConfig) {
 	serviceName := b.namer.getServiceName(rd)
 	var middlewares []string
 
-	// Path params middleware
-	if len(rd.PathParams) > 0 {
+	// Error pages middleware, applied first so it also covers auth failures
+	if pages := rd.ErrorPages; pages != nil && len(pages.Status) > 0 && (pages.Service != "" || pages.URL != "") {
+		errorService := rd.ErrorPages.Service
+		if errorService == "" {
+			errorService = b.namer.getServiceName(rd) + "-error-pages"
+			config.HTTP.Services[errorService] = Service{
+				LoadBalancer: &LoadBalancer{
+					Servers: []Server{{URL: rd.ErrorPages.URL}},
+				},
+			}
+		}
+
+		mwName := b.namer.getMiddlewareName(rd, "error-pages")
+		config.HTTP.Middlewares[mwName] = ErrorPagesMw(rd.ErrorPages.Status, errorService, rd.ErrorPages.Query)
+		middlewares = append(middlewares, mwName)
+	}
+
+	// Path params middleware, also forwarding the subdomain of wildcard hosts
+	pathParams := rd.PathParams
+	if rd.hasWildcardHost() {
+		pathParams = maps.Clone(pathParams)
+		if pathParams == nil {
+			pathParams = map[string]string{}
+		}
+		pathParams[rd.subdomainHeader()] = SubdomainParam
+	}
+	if len(pathParams) > 0 {
 		mwName := b.namer.getMiddlewareName(rd, "path-params")
-		config.HTTP.Middlewares[mwName] = PathParamsToHeaderMw(rd.PathParams)
+		config.HTTP.Middlewares[mwName] = PathParamsToHeaderMw(pathParams)
 		middlewares = append(middlewares, mwName)
 	}
 
@@ -118,6 +183,13 @@ func (b *Builder) addRoute(rd RouteDefinition, config *DynamicConfig) {
 		middlewares = append(middlewares, mwName)
 	}
 
+	// GeoIP middleware, before auth so rejected countries can't try credentials
+	if rd.GeoIPCheckURL != "" {
+		mwName := b.namer.getMiddlewareName(rd, "geoip")
+		config.HTTP.Middlewares[mwName] = ForwardAuthMw(rd.GeoIPCheckURL, CountryHeader)
+		middlewares = append(middlewares, mwName)
+	}
+
 	// Auth middleware
 	if rd.Authentication != nil {
 		switch rd.Authentication.Type {
@@ -126,43 +198,236 @@ func (b *Builder) addRoute(rd RouteDefinition, config *DynamicConfig) {
 			authMwName := b.namer.getMiddlewareName(rd, "basic-auth")
 			rateMwName := b.namer.getMiddlewareName(rd, "rate-limit")
 
-			config.HTTP.Middlewares[authMwName] = BasicAuthMw(
+			config.HTTP.Middlewares[authMwName] = basicAuthMw(
+				b.hasher,
+				rd.Authentication.Username,
+				rd.Authentication.Password,
+			)
+			config.HTTP.Middlewares[rateMwName] = rateLimitMw(rd)
+
+			middlewares = append(middlewares, authMwName, rateMwName)
+		case "digest":
+			authMwName := b.namer.getMiddlewareName(rd, "digest-auth")
+			rateMwName := b.namer.getMiddlewareName(rd, "rate-limit")
+
+			config.HTTP.Middlewares[authMwName] = DigestAuthMw(
 				rd.Authentication.Username,
 				rd.Authentication.Password,
 			)
-			config.HTTP.Middlewares[rateMwName] = RateLimitMw(100, 50)
+			config.HTTP.Middlewares[rateMwName] = rateLimitMw(rd)
 
 			middlewares = append(middlewares, authMwName, rateMwName)
 		case "apikey":
 			mwName := b.namer.getMiddlewareName(rd, "apikey")
 			config.HTTP.Middlewares[mwName] = APIKeyMw(
-				"X-API-Key",
+				APIKeyHeader,
 				rd.Authentication.APIKey,
 			)
 			middlewares = append(middlewares, mwName)
+		case "oidc":
+			mwName := b.namer.getMiddlewareName(rd, "oidc")
+			config.HTTP.Middlewares[mwName] = ForwardAuthMw(
+				rd.Authentication.VerifyURL,
+				AuthSubjectHeader,
+			)
+			middlewares = append(middlewares, mwName)
+		case "hmac":
+			mwName := b.namer.getMiddlewareName(rd, "hmac")
+			config.HTTP.Middlewares[mwName] = SignedBodyAuthMw(rd.Authentication.VerifyURL)
+			middlewares = append(middlewares, mwName)
 		}
 	}
 
+	// Rate limit middleware of routes without basic or digest auth, which
+	// are always limited above
+	if rd.RateLimit != nil && !slices.Contains(middlewares, b.namer.getMiddlewareName(rd, "rate-limit")) {
+		mwName := b.namer.getMiddlewareName(rd, "rate-limit")
+		config.HTTP.Middlewares[mwName] = rateLimitMw(rd)
+		middlewares = append(middlewares, mwName)
+	}
+
+	// Manager token middleware, authenticating the checks below to the manager
+	managerChecks := rd.ManagerToken != "" && (rd.ReplayCheckURL != "" || rd.QuotaCheckURL != "" || rd.CaptureURL != "")
+	if managerChecks {
+		mwName := b.namer.getMiddlewareName(rd, "manager-token")
+		config.HTTP.Middlewares[mwName] = HeaderRulesMw(map[string]string{ManagerTokenHeader: rd.ManagerToken}, nil, nil)
+		middlewares = append(middlewares, mwName)
+	}
+
+	// Replay check middleware, after auth so forged requests can't use up keys
+	if rd.ReplayCheckURL != "" {
+		mwName := b.namer.getMiddlewareName(rd, "replay")
+		config.HTTP.Middlewares[mwName] = ForwardAuthMw(rd.ReplayCheckURL)
+		middlewares = append(middlewares, mwName)
+
+		if release, err := url.Parse(rd.ReplayReleaseURL); err == nil && release.Host != "" {
+			releaseService := b.namer.getServiceName(rd) + "-replay-release"
+			config.HTTP.Services[releaseService] = Service{
+				LoadBalancer: &LoadBalancer{
+					Servers: []Server{{URL: release.Scheme + "://" + release.Host}},
+				},
+			}
+			mwName := b.namer.getMiddlewareName(rd, "replay-release")
+			config.HTTP.Middlewares[mwName] = ErrorPagesMw([]string{"500-599"}, releaseService, release.RequestURI())
+			middlewares = append(middlewares, mwName)
+		}
+	}
+
+	// Quota middleware, after the replay check so duplicates aren't counted
+	if rd.QuotaCheckURL != "" {
+		mwName := b.namer.getMiddlewareName(rd, "quota")
+		config.HTTP.Middlewares[mwName] = ForwardAuthMw(rd.QuotaCheckURL)
+		middlewares = append(middlewares, mwName)
+	}
+
+	// Header rules middleware
+	if len(rd.SetRequestHeaders) > 0 || len(rd.RemoveRequestHeaders) > 0 || len(rd.SetResponseHeaders) > 0 {
+		mwName := b.namer.getMiddlewareName(rd, "headers")
+		config.HTTP.Middlewares[mwName] = HeaderRulesMw(rd.SetRequestHeaders, rd.RemoveRequestHeaders, rd.SetResponseHeaders)
+		middlewares = append(middlewares, mwName)
+	}
+
+	// Route info middleware, overwriting any route header sent by the client
+	if b.routeHeader != "" {
+		mwName := b.namer.getMiddlewareName(rd, "route-info")
+		config.HTTP.Middlewares[mwName] = Middleware{
+			Headers: &Headers{
+				CustomRequestHeaders: map[string]string{b.routeHeader: routerName},
+			},
+		}
+		middlewares = append(middlewares, mwName)
+	}
+
+	// Capture middleware, after the headers are final
+	if rd.CaptureURL != "" {
+		mwName := b.namer.getMiddlewareName(rd, "capture")
+		config.HTTP.Middlewares[mwName] = CaptureMw(rd.CaptureURL)
+		middlewares = append(middlewares, mwName)
+	}
+
+	// The service never sees the manager token
+	if managerChecks {
+		mwName := b.namer.getMiddlewareName(rd, "manager-token-remove")
+		config.HTTP.Middlewares[mwName] = HeaderRulesMw(nil, []string{ManagerTokenHeader}, nil)
+		middlewares = append(middlewares, mwName)
+	}
+
+	// Replace path middleware, applied last so auth sees the public path
+	if rd.ServicePath != "" && rd.ServicePath != rd.Path {
+		mwName := b.namer.getMiddlewareName(rd, "replace-path")
+		config.HTTP.Middlewares[mwName] = ReplacePathMw(rd.ServicePath)
+		middlewares = append(middlewares, mwName)
+	}
+
 	// Create router rule combining host and path matching
-	hostRule := fmt.Sprintf("Host(`%s`)", rd.Host)
+	hostRule := hostMatcher(rd.Host)
+	if len(rd.Hosts) > 0 {
+		hostRules := []string{hostRule}
+		for _, host := range rd.Hosts {
+			hostRules = append(hostRules, hostMatcher(host))
+		}
+		hostRule = "(" + strings.Join(hostRules, " || ") + ")"
+	}
 	pathRule := fmt.Sprintf("Path(`%s`)", rd.Path)
 
 	// Add router with combined rules
 	config.HTTP.Routers[routerName] = Router{
-		EntryPoints: rd.EntryPoints,
-		Service:     serviceName,
-		Rule:        fmt.Sprintf("%s && %s", hostRule, pathRule),
-		Middlewares: middlewares,
+		EntryPoints:   rd.EntryPoints,
+		Service:       serviceName,
+		Rule:          fmt.Sprintf("%s && %s", hostRule, pathRule),
+		Middlewares:   middlewares,
+		Observability: rd.Observability,
 	}
 
 	// Add service with protocol-aware URL
+	loadBalancer := &LoadBalancer{
+		Servers: []Server{
+			{
+				URL: buildServiceURL(rd.Service),
+			},
+		},
+	}
+	if b.routeHeader != "" {
+		passHostHeader := true
+		loadBalancer.PassHostHeader = &passHostHeader
+	}
 	config.HTTP.Services[serviceName] = Service{
+		LoadBalancer: loadBalancer,
+	}
+}
+
+// rateLimitMw returns the rate limit middleware of a route, 100 requests
+// per minute with a burst of 50 unless the route sets its own
+func rateLimitMw(rd RouteDefinition) Middleware {
+	if rd.RateLimit != nil {
+		return RateLimitMw(rd.RateLimit.Average, rd.RateLimit.Burst)
+	}
+	return RateLimitMw(100, 50)
+}
+
+// SubdomainParam is the name of the route parameter capturing the subdomain
+// matched by a wildcard host
+const SubdomainParam = "subdomain"
+
+// defaultSubdomainHeader forwards the subdomain as "X-Subdomain"
+const defaultSubdomainHeader = "Subdomain"
+
+// hostMatcher returns the rule matching host. A wildcard host such as
+// "*.hooks.example.com" becomes a HostRegexp capturing one subdomain label.
+func hostMatcher(host string) string {
+	domain, wildcard := strings.CutPrefix(host, "*.")
+	if !wildcard {
+		return fmt.Sprintf("Host(`%s`)", host)
+	}
+	return fmt.Sprintf("HostRegexp(`^(?P<%s>[a-z0-9-]+)\\.%s$`)", SubdomainParam, regexp.QuoteMeta(domain))
+}
+
+// hasWildcardHost reports whether any host of rd is a wildcard host
+func (rd RouteDefinition) hasWildcardHost() bool {
+	for _, host := range append([]string{rd.Host}, rd.Hosts...) {
+		if strings.HasPrefix(host, "*.") {
+			return true
+		}
+	}
+	return false
+}
+
+// subdomainHeader returns the header name forwarding the matched subdomain
+func (rd RouteDefinition) subdomainHeader() string {
+	if rd.SubdomainHeader != "" {
+		return rd.SubdomainHeader
+	}
+	return defaultSubdomainHeader
+}
+
+// Maintenance names of the resources replacing all routes during maintenance
+const (
+	MaintenanceService    = "maintenance-service"
+	MaintenanceMiddleware = "maintenance-replace-path-middleware"
+)
+
+// ApplyMaintenance points every router of config at the maintenance
+// responder at serviceURL + path, dropping their own services and
+// middlewares. Without a serviceURL all routers are removed.
+func ApplyMaintenance(config *DynamicConfig, serviceURL, path string) {
+	config.HTTP.Services = make(map[string]Service)
+	config.HTTP.Middlewares = make(map[string]Middleware)
+
+	if serviceURL == "" {
+		config.HTTP.Routers = make(map[string]Router)
+		return
+	}
+
+	config.HTTP.Services[MaintenanceService] = Service{
 		LoadBalancer: &LoadBalancer{
-			Servers: []Server{
-				{
-					URL: buildServiceURL(rd.Service),
-				},
-			},
+			Servers: []Server{{URL: serviceURL}},
 		},
 	}
+	config.HTTP.Middlewares[MaintenanceMiddleware] = ReplacePathMw(path)
+
+	for name, router := range config.HTTP.Routers {
+		router.Service = MaintenanceService
+		router.Middlewares = []string{MaintenanceMiddleware}
+		config.HTTP.Routers[name] = router
+	}
 }
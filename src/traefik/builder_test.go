@@ -126,6 +126,73 @@ func TestBuilder(t *testing.T) {
 				assert.Equal(t, "http://backend:8080", service.LoadBalancer.Servers[0].URL)
 			},
 		},
+		{
+			name: "route with further hosts",
+			route: RouteDefinition{
+				Host:  "hooks.example.com",
+				Hosts: []string{"legacy.example.com"},
+				Path:  "/orders",
+				Service: ServiceDefinition{
+					Host: "backend",
+					Port: 8080,
+				},
+				EntryPoints: []string{"web"},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				// Resources are named after the primary host
+				router, exists := config.HTTP.Routers["hooks-example-com-orders-router"]
+				require.True(t, exists)
+				assert.Equal(t, "(Host(`hooks.example.com`) || Host(`legacy.example.com`)) && Path(`/orders`)", router.Rule)
+				assert.Len(t, config.HTTP.Routers, 1)
+			},
+		},
+		{
+			name: "route with wildcard host",
+			route: RouteDefinition{
+				Host:            "*.hooks.example.com",
+				Path:            "/orders",
+				SubdomainHeader: "Tenant",
+				Service: ServiceDefinition{
+					Host: "backend",
+					Port: 8080,
+				},
+				EntryPoints: []string{"web"},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				router, exists := config.HTTP.Routers["wildcard-hooks-example-com-orders-router"]
+				require.True(t, exists)
+				assert.Equal(t, "HostRegexp(`^(?P<subdomain>[a-z0-9-]+)\\.hooks\\.example\\.com$`) && Path(`/orders`)", router.Rule)
+
+				// The subdomain is forwarded like a path parameter
+				mw, exists := config.HTTP.Middlewares["wildcard-hooks-example-com-orders-path-params-middleware"]
+				require.True(t, exists)
+				assert.Equal(t, "{{ .Route.subdomain }}", mw.Headers.CustomRequestHeaders["X-Tenant"])
+			},
+		},
+		{
+			name: "route with header rules",
+			route: RouteDefinition{
+				Host:                 "example.com",
+				Path:                 "/api",
+				SetRequestHeaders:    map[string]string{"X-Token": "abc"},
+				RemoveRequestHeaders: []string{"Cookie"},
+				SetResponseHeaders:   map[string]string{"X-Served-By": "n8n"},
+				Service: ServiceDefinition{
+					Host: "backend",
+					Port: 8080,
+				},
+				EntryPoints: []string{"web"},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				router := config.HTTP.Routers["example-com-api-router"]
+				assert.Equal(t, []string{"example-com-api-headers-middleware"}, router.Middlewares)
+
+				mw, exists := config.HTTP.Middlewares["example-com-api-headers-middleware"]
+				require.True(t, exists)
+				assert.Equal(t, map[string]string{"X-Token": "abc", "Cookie": ""}, mw.Headers.CustomRequestHeaders)
+				assert.Equal(t, map[string]string{"X-Served-By": "n8n"}, mw.Headers.CustomResponseHeaders)
+			},
+		},
 		{
 			name: "route with path parameters",
 			route: RouteDefinition{
@@ -210,6 +277,199 @@ func TestBuilder(t *testing.T) {
 				assert.Equal(t, "http://search-service:8080", service.LoadBalancer.Servers[0].URL)
 			},
 		},
+		{
+			name: "route with service path",
+			route: RouteDefinition{
+				Host:        "hooks.example.com",
+				Path:        "/orders",
+				ServicePath: "/webhook/orders-v2",
+				Service: ServiceDefinition{
+					Host:   "n8n.internal",
+					Port:   5678,
+					Scheme: "http",
+				},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				router, exists := config.HTTP.Routers["hooks-example-com-orders-router"]
+				require.True(t, exists)
+				assert.Equal(t, []string{"hooks-example-com-orders-replace-path-middleware"}, router.Middlewares)
+
+				mw, exists := config.HTTP.Middlewares["hooks-example-com-orders-replace-path-middleware"]
+				require.True(t, exists)
+				assert.Equal(t, "/webhook/orders-v2", mw.ReplacePath.Path)
+			},
+		},
+		{
+			name: "route with observability overrides",
+			route: RouteDefinition{
+				Host: "hooks.example.com",
+				Path: "/health",
+				Service: ServiceDefinition{
+					Host: "n8n.internal",
+					Port: 5678,
+				},
+				Observability: &Observability{AccessLogs: boolPtr(false)},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				router, exists := config.HTTP.Routers["hooks-example-com-health-router"]
+				require.True(t, exists)
+				require.NotNil(t, router.Observability)
+				assert.False(t, *router.Observability.AccessLogs)
+				assert.Nil(t, router.Observability.Tracing)
+			},
+		},
+		{
+			name: "route with error pages",
+			route: RouteDefinition{
+				Host: "hooks.example.com",
+				Path: "/orders",
+				Service: ServiceDefinition{
+					Host: "n8n.internal",
+					Port: 5678,
+				},
+				Authentication: &AuthConfig{Type: "apikey", APIKey: "secret"},
+				ErrorPages: &ErrorPagesConfig{
+					Status: []string{"502-504"},
+					URL:    "http://errors.internal:8080",
+					Query:  "/{status}.json",
+				},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				router, exists := config.HTTP.Routers["hooks-example-com-orders-router"]
+				require.True(t, exists)
+				require.Len(t, router.Middlewares, 2)
+				assert.Equal(t, "hooks-example-com-orders-error-pages-middleware", router.Middlewares[0])
+
+				mw := config.HTTP.Middlewares["hooks-example-com-orders-error-pages-middleware"]
+				require.NotNil(t, mw.Errors)
+				assert.Equal(t, []string{"502-504"}, mw.Errors.Status)
+				assert.Equal(t, "/{status}.json", mw.Errors.Query)
+
+				service, exists := config.HTTP.Services[mw.Errors.Service]
+				require.True(t, exists)
+				assert.Equal(t, "http://errors.internal:8080", service.LoadBalancer.Servers[0].URL)
+			},
+		},
+		{
+			name: "route with error pages of an existing service",
+			route: RouteDefinition{
+				Host: "hooks.example.com",
+				Path: "/status",
+				Service: ServiceDefinition{
+					Host: "n8n.internal",
+					Port: 5678,
+				},
+				ErrorPages: &ErrorPagesConfig{
+					Status:  []string{"500-599"},
+					Service: "error-pages@file",
+				},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				mw := config.HTTP.Middlewares["hooks-example-com-status-error-pages-middleware"]
+				require.NotNil(t, mw.Errors)
+				assert.Equal(t, "error-pages@file", mw.Errors.Service)
+				assert.Len(t, config.HTTP.Services, 1)
+			},
+		},
+		{
+			name: "route with rate limit",
+			route: RouteDefinition{
+				Host: "hooks.example.com",
+				Path: "/orders",
+				Service: ServiceDefinition{
+					Host: "n8n.internal",
+					Port: 5678,
+				},
+				Authentication: &AuthConfig{Type: "apikey", APIKey: "secret"},
+				RateLimit:      &RateLimitConfig{Average: 30, Burst: 10},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				router := config.HTTP.Routers["hooks-example-com-orders-router"]
+				assert.Equal(t, []string{
+					"hooks-example-com-orders-apikey-middleware",
+					"hooks-example-com-orders-rate-limit-middleware",
+				}, router.Middlewares)
+
+				mw := config.HTTP.Middlewares["hooks-example-com-orders-rate-limit-middleware"]
+				require.NotNil(t, mw.RateLimit)
+				assert.Equal(t, 30, mw.RateLimit.Average)
+				assert.Equal(t, 10, mw.RateLimit.Burst)
+			},
+		},
+		{
+			name: "basic auth route with rate limit",
+			route: RouteDefinition{
+				Host: "hooks.example.com",
+				Path: "/orders",
+				Service: ServiceDefinition{
+					Host: "n8n.internal",
+					Port: 5678,
+				},
+				Authentication: &AuthConfig{Type: "basic", Username: "user", Password: "pass"},
+				RateLimit:      &RateLimitConfig{Average: 30, Burst: 10},
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				// The rate limit replaces the default one of basic auth
+				router := config.HTTP.Routers["hooks-example-com-orders-router"]
+				assert.Len(t, router.Middlewares, 2)
+				assert.Equal(t, 30, config.HTTP.Middlewares["hooks-example-com-orders-rate-limit-middleware"].RateLimit.Average)
+			},
+		},
+		{
+			name: "route with replay protection",
+			route: RouteDefinition{
+				Host: "hooks.example.com",
+				Path: "/github",
+				Service: ServiceDefinition{
+					Host: "n8n.internal",
+					Port: 5678,
+				},
+				ReplayCheckURL:   "http://manager:8090/api/traefik/auth/replay/r1",
+				ReplayReleaseURL: "http://manager:8090/api/traefik/auth/replay/r1/release?sig=abc",
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				router := config.HTTP.Routers["hooks-example-com-github-router"]
+				assert.Equal(t, []string{
+					"hooks-example-com-github-replay-middleware",
+					"hooks-example-com-github-replay-release-middleware",
+				}, router.Middlewares)
+
+				// 5xx of the service release the key of the delivery
+				release := config.HTTP.Middlewares["hooks-example-com-github-replay-release-middleware"].Errors
+				require.NotNil(t, release)
+				assert.Equal(t, []string{"500-599"}, release.Status)
+				assert.Equal(t, "/api/traefik/auth/replay/r1/release?sig=abc", release.Query)
+				assert.Equal(t, "http://manager:8090", config.HTTP.Services[release.Service].LoadBalancer.Servers[0].URL)
+			},
+		},
+		{
+			name: "route with manager checks",
+			route: RouteDefinition{
+				Host: "hooks.example.com",
+				Path: "/orders",
+				Service: ServiceDefinition{
+					Host: "n8n.internal",
+					Port: 5678,
+				},
+				QuotaCheckURL: "http://manager:8090/api/traefik/auth/quota/r1",
+				CaptureURL:    "http://manager:8090/api/traefik/auth/capture/r1",
+				ManagerToken:  "manager-secret",
+			},
+			check: func(t *testing.T, config *DynamicConfig) {
+				// The token is added before the checks and removed after them
+				router := config.HTTP.Routers["hooks-example-com-orders-router"]
+				assert.Equal(t, []string{
+					"hooks-example-com-orders-manager-token-middleware",
+					"hooks-example-com-orders-quota-middleware",
+					"hooks-example-com-orders-capture-middleware",
+					"hooks-example-com-orders-manager-token-remove-middleware",
+				}, router.Middlewares)
+				assert.Equal(t, "manager-secret",
+					config.HTTP.Middlewares["hooks-example-com-orders-manager-token-middleware"].Headers.CustomRequestHeaders[ManagerTokenHeader])
+				assert.Equal(t, map[string]string{ManagerTokenHeader: ""},
+					config.HTTP.Middlewares["hooks-example-com-orders-manager-token-remove-middleware"].Headers.CustomRequestHeaders)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -220,3 +480,71 @@ func TestBuilder(t *testing.T) {
 		})
 	}
 }
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestApplyMaintenance(t *testing.T) {
+	routes := []RouteDefinition{
+		{
+			Host:           "hooks.example.com",
+			Path:           "/orders",
+			Service:        ServiceDefinition{Host: "n8n.internal", Port: 5678},
+			Authentication: &AuthConfig{Type: "apikey", APIKey: "secret"},
+		},
+		{
+			Host:    "hooks.example.com",
+			Path:    "/status",
+			Service: ServiceDefinition{Host: "n8n.internal", Port: 5678},
+		},
+	}
+
+	config := NewBuilder().Build(routes)
+	ApplyMaintenance(config, "http://manager:8090", "/api/maintenance")
+
+	require.Len(t, config.HTTP.Routers, 2)
+	for _, router := range config.HTTP.Routers {
+		assert.Equal(t, MaintenanceService, router.Service)
+		assert.Equal(t, []string{MaintenanceMiddleware}, router.Middlewares)
+	}
+	require.Len(t, config.HTTP.Services, 1)
+	assert.Equal(t, "http://manager:8090", config.HTTP.Services[MaintenanceService].LoadBalancer.Servers[0].URL)
+	assert.Equal(t, "/api/maintenance", config.HTTP.Middlewares[MaintenanceMiddleware].ReplacePath.Path)
+
+	config = NewBuilder().Build(routes)
+	ApplyMaintenance(config, "", "/api/maintenance")
+	assert.Empty(t, config.HTTP.Routers)
+	assert.Empty(t, config.HTTP.Services)
+}
+
+func TestBuilderWithClientInfo(t *testing.T) {
+	route := RouteDefinition{
+		Host:        "hooks.example.com",
+		Path:        "/orders",
+		ServicePath: "/webhook/orders",
+		Service:     ServiceDefinition{Host: "backend", Port: 8080},
+		EntryPoints: []string{"web"},
+	}
+
+	config := NewBuilder(WithClientInfo("X-N8N-Route")).Build([]RouteDefinition{route})
+
+	router := config.HTTP.Routers["hooks-example-com-orders-router"]
+	assert.Equal(t, []string{
+		"hooks-example-com-orders-route-info-middleware",
+		"hooks-example-com-orders-replace-path-middleware",
+	}, router.Middlewares)
+
+	mw := config.HTTP.Middlewares["hooks-example-com-orders-route-info-middleware"]
+	require.NotNil(t, mw.Headers)
+	assert.Equal(t, map[string]string{"X-N8N-Route": "hooks-example-com-orders-router"}, mw.Headers.CustomRequestHeaders)
+
+	service := config.HTTP.Services["hooks-example-com-orders-service"]
+	require.NotNil(t, service.LoadBalancer.PassHostHeader)
+	assert.True(t, *service.LoadBalancer.PassHostHeader)
+
+	// Without the option nothing is added
+	config = NewBuilder().Build([]RouteDefinition{route})
+	assert.Len(t, config.HTTP.Middlewares, 1)
+	assert.Nil(t, config.HTTP.Services["hooks-example-com-orders-service"].LoadBalancer.PassHostHeader)
+}
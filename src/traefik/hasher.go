@@ -0,0 +1,50 @@
+package traefik
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher bcrypt-hashes basic auth passwords. Hashes are cached per
+// user and password, so a rebuild neither pays for bcrypt again nor changes
+// the configuration of unchanged credentials.
+type PasswordHasher struct {
+	cost   int
+	hashes sync.Map
+}
+
+// DefaultHasher hashes with bcrypt's default cost, used by BasicAuthMw and
+// builders without WithPasswordHasher
+var DefaultHasher = NewPasswordHasher(bcrypt.DefaultCost)
+
+// NewPasswordHasher returns a hasher using cost, falling back to bcrypt's
+// default for costs out of its range
+func NewPasswordHasher(cost int) *PasswordHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &PasswordHasher{cost: cost}
+}
+
+// Cost returns the bcrypt cost of the hasher
+func (h *PasswordHasher) Cost() int {
+	return h.cost
+}
+
+// Hash returns the bcrypt hash of the password of username
+func (h *PasswordHasher) Hash(username, password string) (string, error) {
+	// The key is a digest, the cache doesn't keep plain passwords
+	key := sha256.Sum256([]byte(username + "\x00" + password))
+	if hash, ok := h.hashes.Load(key); ok {
+		return hash.(string), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	h.hashes.Store(key, string(hash))
+	return string(hash), nil
+}
@@ -0,0 +1,53 @@
+package traefik
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	hasher := NewPasswordHasher(bcrypt.MinCost)
+
+	hash, err := hasher.Hash("user", "secret")
+	require.NoError(t, err)
+	cost, err := bcrypt.Cost([]byte(hash))
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost, cost)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")))
+
+	again, err := hasher.Hash("user", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, hash, again, "hashes are cached")
+
+	rotated, err := hasher.Hash("user", "rotated")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, rotated)
+
+	assert.Equal(t, bcrypt.DefaultCost, NewPasswordHasher(99).Cost())
+}
+
+func TestBuilderWithPasswordHasher(t *testing.T) {
+	route := RouteDefinition{
+		Host:           "hooks.example.com",
+		Path:           "/orders",
+		EntryPoints:    []string{"web"},
+		Service:        ServiceDefinition{Host: "n8n", Port: 5678, Scheme: "http"},
+		Authentication: &AuthConfig{Type: "basic", Username: "user", Password: "secret"},
+	}
+	builder := NewBuilder(WithPasswordHasher(NewPasswordHasher(bcrypt.MinCost)))
+
+	users := func() []string {
+		for _, mw := range builder.Build([]RouteDefinition{route}).HTTP.Middlewares {
+			if mw.BasicAuth != nil {
+				return mw.BasicAuth.Users
+			}
+		}
+		return nil
+	}
+	first := users()
+	require.Len(t, first, 1)
+	assert.Equal(t, first, users(), "rebuilds keep the hash")
+}
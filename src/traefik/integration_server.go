@@ -8,7 +8,6 @@ import (
 	"os"
 	"strconv"
 	"testing"
-	"time"
 )
 
 // TestPort is the environment variable name for the test server port
@@ -37,6 +36,9 @@ func startTestServer(t *testing.T, routes []RouteDefinition) (*http.Server, stri
 	// Flag to track if config has been logged
 	var configLogged bool
 
+	// Keeps Last-Modified stable while the configuration is unchanged
+	var tracker ChangeTracker
+
 	// Health check endpoint
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -72,12 +74,14 @@ func startTestServer(t *testing.T, routes []RouteDefinition) (*http.Server, stri
 		}
 
 		// Serve the config
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
-
-		if err := json.NewEncoder(w).Encode(config); err != nil {
+		data, err := json.Marshal(config)
+		if err != nil {
 			t.Errorf("Failed to encode config: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
+		etag, modifiedAt := tracker.Track(data)
+		ServeConfig(w, r, "application/json", data, etag, modifiedAt)
 	})
 
 	// Echo server endpoint (catch-all)
@@ -1,11 +1,14 @@
 package traefik
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
+// authRealm is the realm of the basic and digest auth middlewares
+const authRealm = "Protected API"
+
 // PathParamsToHeaderMw creates a middleware that converts path parameters to headers.
 // Example:
 //
@@ -42,26 +45,94 @@ func QueryParamsToHeaderMw(queryParams []string) Middleware {
 	}
 }
 
-// BasicAuthRateLimitMw creates a middleware combining basic authentication and rate limiting.
-// It adds basic auth protection and limits request rates to prevent abuse.
+// BasicAuthMw creates a middleware adding basic auth protection. The password
+// is hashed by DefaultHasher.
 //
 //	username: basic auth username
 //	password: basic auth password
 func BasicAuthMw(username, password string) Middleware {
+	return basicAuthMw(DefaultHasher, username, password)
+}
+
+// basicAuthMw creates the basic auth middleware with a password hashed by hasher
+func basicAuthMw(hasher *PasswordHasher, username, password string) Middleware {
 	authStr := fmt.Sprintf("%s:%s", username, password)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err == nil {
-		authStr = fmt.Sprintf("%s:%s", username, string(hashedPassword))
+	if hashedPassword, err := hasher.Hash(username, password); err == nil {
+		authStr = fmt.Sprintf("%s:%s", username, hashedPassword)
 	}
 
 	return Middleware{
 		BasicAuth: &BasicAuth{
 			Users: []string{authStr},
-			Realm: "Protected API",
+			Realm: authRealm,
+		},
+	}
+}
+
+// DigestAuthMw creates a middleware adding digest auth protection, for
+// clients that don't accept basic auth. Digest auth is bound to MD5, the
+// stored hash is only as strong as the password.
+//
+//	username: digest auth username
+//	password: digest auth password
+func DigestAuthMw(username, password string) Middleware {
+	hash := md5.Sum([]byte(fmt.Sprintf("%s:%s:%s", username, authRealm, password)))
+
+	return Middleware{
+		DigestAuth: &DigestAuth{
+			Users: []string{fmt.Sprintf("%s:%s:%s", username, authRealm, hex.EncodeToString(hash[:]))},
+			Realm: authRealm,
+		},
+	}
+}
+
+// AuthSubjectHeader carries the subject of a verified token to the service
+const AuthSubjectHeader = "X-Auth-Subject"
+
+// CountryHeader carries the country of the client, resolved by the geoip
+// check, to the service
+const CountryHeader = "X-Client-Country"
+
+// ManagerTokenHeader authenticates Traefik to the forwardAuth checks of the
+// manager changing state
+const ManagerTokenHeader = "X-Manager-Token"
+
+// ForwardAuthMw creates a middleware asking address to authenticate requests.
+// Example:
+//
+//	ForwardAuthMw("http://manager:8090/api/traefik/auth/oidc", "X-Auth-Subject")
+//	Requests are only forwarded if the manager answers 2xx, with its
+//	"X-Auth-Subject" response header added
+func ForwardAuthMw(address string, responseHeaders ...string) Middleware {
+	return Middleware{
+		ForwardAuth: &ForwardAuth{
+			Address:             address,
+			AuthResponseHeaders: responseHeaders,
 		},
 	}
 }
 
+// MaxSignedBodySize limits the bodies forwarded to verify their signature
+const MaxSignedBodySize = 5 << 20
+
+// SignedBodyAuthMw creates a forwardAuth middleware sending the request body
+// to address, which verifies its signature. Larger bodies than
+// MaxSignedBodySize are rejected.
+func SignedBodyAuthMw(address string) Middleware {
+	mw := ForwardAuthMw(address)
+	mw.ForwardAuth.ForwardBody = true
+	mw.ForwardAuth.MaxBodySize = MaxSignedBodySize
+	return mw
+}
+
+// CaptureMw creates a forwardAuth middleware sending requests including
+// their body to address, which stores them and lets them through
+func CaptureMw(address string) Middleware {
+	mw := ForwardAuthMw(address)
+	mw.ForwardAuth.ForwardBody = true
+	return mw
+}
+
 // RateLimitMw creates a middleware for rate limiting
 //
 //	rateAvg: average requests per minute allowed
@@ -76,6 +147,10 @@ func RateLimitMw(average, burst int) Middleware {
 	}
 }
 
+// APIKeyHeader carries the static token of routes with apikey auth to the
+// service
+const APIKeyHeader = "X-API-Key"
+
 // APIKeyMw creates a middleware that adds API key authentication via headers.
 // Example:
 //
@@ -91,5 +166,59 @@ func APIKeyMw(headerName, apiKey string) Middleware {
 	}
 }
 
+// ReplacePathMw creates a middleware that replaces the request path before forwarding.
+// Example:
+//
+//	ReplacePathMw("/webhook/orders")
+//	A request to "/orders" is forwarded to the service as "/webhook/orders"
+func ReplacePathMw(path string) Middleware {
+	return Middleware{
+		ReplacePath: &ReplacePath{
+			Path: path,
+		},
+	}
+}
+
+// HeaderRulesMw creates a middleware setting and removing headers.
+// Traefik removes the headers set to an empty value.
+// Example:
+//
+//	HeaderRulesMw(map[string]string{"X-Token": "abc"}, []string{"Cookie"}, nil)
+//	A request gets "X-Token: abc" added and its "Cookie" header removed
+func HeaderRulesMw(setRequest map[string]string, removeRequest []string, setResponse map[string]string) Middleware {
+	var requestHeaders map[string]string
+	if len(setRequest) > 0 || len(removeRequest) > 0 {
+		requestHeaders = make(map[string]string, len(setRequest)+len(removeRequest))
+		for _, name := range removeRequest {
+			requestHeaders[name] = ""
+		}
+		for name, value := range setRequest {
+			requestHeaders[name] = value
+		}
+	}
+
+	return Middleware{
+		Headers: &Headers{
+			CustomRequestHeaders:  requestHeaders,
+			CustomResponseHeaders: setResponse,
+		},
+	}
+}
+
+// ErrorPagesMw creates a middleware that serves the error pages of service.
+// Example:
+//
+//	ErrorPagesMw([]string{"502-504"}, "error-pages", "/{status}.json")
+//	A 502 of the backend is replaced by the response of "/502.json" of error-pages
+func ErrorPagesMw(status []string, service, query string) Middleware {
+	return Middleware{
+		Errors: &ErrorPages{
+			Status:  status,
+			Service: service,
+			Query:   query,
+		},
+	}
+}
+
 // StripPrefixMW removes a part of the incoming request URL.
 // TODO
@@ -84,6 +84,31 @@ func TestBasicAuthRateLimitMw(t *testing.T) {
 	})
 }
 
+func TestDigestAuthMw(t *testing.T) {
+	mw := DigestAuthMw("testuser", "testpass")
+
+	assert.NotNil(t, mw.DigestAuth)
+	assert.Nil(t, mw.BasicAuth)
+	assert.Equal(t, "Protected API", mw.DigestAuth.Realm)
+	// htdigest entry of testuser with password testpass
+	assert.Equal(t, []string{"testuser:Protected API:0e61b41a132ce9d460f3e664d83f23c3"}, mw.DigestAuth.Users)
+}
+
+func TestForwardAuthMw(t *testing.T) {
+	mw := ForwardAuthMw("http://manager:8090/api/traefik/auth/oidc", AuthSubjectHeader)
+
+	assert.NotNil(t, mw.ForwardAuth)
+	assert.Equal(t, "http://manager:8090/api/traefik/auth/oidc", mw.ForwardAuth.Address)
+	assert.Equal(t, []string{"X-Auth-Subject"}, mw.ForwardAuth.AuthResponseHeaders)
+}
+
+func TestSignedBodyAuthMw(t *testing.T) {
+	mw := SignedBodyAuthMw("http://manager:8090/api/traefik/auth/hmac/abc")
+
+	assert.True(t, mw.ForwardAuth.ForwardBody)
+	assert.Equal(t, int64(MaxSignedBodySize), mw.ForwardAuth.MaxBodySize)
+}
+
 func TestRateLimitMw(t *testing.T) {
 	mw := RateLimitMw(100, 50)
 
@@ -121,3 +146,24 @@ func TestAPIKeyMw(t *testing.T) {
 		})
 	}
 }
+
+func TestReplacePathMw(t *testing.T) {
+	mw := ReplacePathMw("/webhook/orders")
+
+	assert.NotNil(t, mw.ReplacePath)
+	assert.Equal(t, "/webhook/orders", mw.ReplacePath.Path)
+}
+
+func TestHeaderRulesMw(t *testing.T) {
+	mw := HeaderRulesMw(
+		map[string]string{"X-Token": "abc"},
+		[]string{"Cookie"},
+		map[string]string{"X-Served-By": "n8n"},
+	)
+
+	assert.Equal(t, map[string]string{"X-Token": "abc", "Cookie": ""}, mw.Headers.CustomRequestHeaders)
+	assert.Equal(t, map[string]string{"X-Served-By": "n8n"}, mw.Headers.CustomResponseHeaders)
+
+	mw = HeaderRulesMw(nil, nil, map[string]string{"X-Served-By": "n8n"})
+	assert.Nil(t, mw.Headers.CustomRequestHeaders)
+}
@@ -10,11 +10,12 @@ type DynamicConfig struct {
 }
 
 type Router struct {
-	EntryPoints []string `json:"entryPoints"`
-	Service     string   `json:"service"`
-	Rule        string   `json:"rule"`
-	Middlewares []string `json:"middlewares,omitempty"`
-	TLS         *TLS     `json:"tls,omitempty"`
+	EntryPoints   []string       `json:"entryPoints"`
+	Service       string         `json:"service"`
+	Rule          string         `json:"rule"`
+	Middlewares   []string       `json:"middlewares,omitempty"`
+	TLS           *TLS           `json:"tls,omitempty"`
+	Observability *Observability `json:"observability,omitempty"`
 }
 
 type Service struct {
@@ -22,13 +23,22 @@ type Service struct {
 }
 
 type LoadBalancer struct {
-	Servers []Server `json:"servers"`
+	Servers        []Server `json:"servers"`
+	PassHostHeader *bool    `json:"passHostHeader,omitempty"`
 }
 
 type Server struct {
 	URL string `json:"url"`
 }
 
+// Observability overrides the entrypoint's access log, tracing and metrics
+// settings for a single router (Traefik v3). Unset values are inherited.
+type Observability struct {
+	AccessLogs *bool `json:"accessLogs,omitempty"`
+	Tracing    *bool `json:"tracing,omitempty"`
+	Metrics    *bool `json:"metrics,omitempty"`
+}
+
 type TLS struct {
 	CertResolver string `json:"certResolver,omitempty"`
 }
@@ -36,9 +46,13 @@ type TLS struct {
 type Middleware struct {
 	StripPrefix *StripPrefix `json:"stripPrefix,omitempty"`
 	AddPrefix   *AddPrefix   `json:"addPrefix,omitempty"`
+	ReplacePath *ReplacePath `json:"replacePath,omitempty"`
 	Headers     *Headers     `json:"headers,omitempty"`
 	RateLimit   *RateLimit   `json:"rateLimit,omitempty"`
 	BasicAuth   *BasicAuth   `json:"basicAuth,omitempty"`
+	DigestAuth  *DigestAuth  `json:"digestAuth,omitempty"`
+	ForwardAuth *ForwardAuth `json:"forwardAuth,omitempty"`
+	Errors      *ErrorPages  `json:"errors,omitempty"`
 }
 
 type StripPrefix struct {
@@ -49,6 +63,10 @@ type AddPrefix struct {
 	Prefix string `json:"prefix"`
 }
 
+type ReplacePath struct {
+	Path string `json:"path"`
+}
+
 type Headers struct {
 	CustomRequestHeaders  map[string]string `json:"customRequestHeaders,omitempty"`
 	CustomResponseHeaders map[string]string `json:"customResponseHeaders,omitempty"`
@@ -60,7 +78,33 @@ type RateLimit struct {
 	Period  string `json:"period,omitempty"`
 }
 
+// ErrorPages replaces responses with a status in Status by the page Query
+// of Service, "{status}" in Query is replaced by the status code
+type ErrorPages struct {
+	Status  []string `json:"status"`
+	Service string   `json:"service"`
+	Query   string   `json:"query,omitempty"`
+}
+
 type BasicAuth struct {
 	Users []string `json:"users"`
 	Realm string   `json:"realm,omitempty"`
 }
+
+// ForwardAuth delegates authentication to the service at Address, which
+// answers 2xx to let a request through. AuthResponseHeaders are copied from
+// its response to the forwarded request. With ForwardBody the request body,
+// up to MaxBodySize bytes, is sent to Address too.
+type ForwardAuth struct {
+	Address             string   `json:"address"`
+	AuthResponseHeaders []string `json:"authResponseHeaders,omitempty"`
+	ForwardBody         bool     `json:"forwardBody,omitempty"`
+	MaxBodySize         int64    `json:"maxBodySize,omitempty"`
+}
+
+// DigestAuth users are "user:realm:hash", hash being the hex MD5 of
+// "user:realm:password"
+type DigestAuth struct {
+	Users []string `json:"users"`
+	Realm string   `json:"realm,omitempty"`
+}
@@ -0,0 +1,45 @@
+package traefik
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ChangeTracker remembers when a served configuration last changed, so
+// Last-Modified and ETag stay stable while the content is the same
+type ChangeTracker struct {
+	mu         sync.Mutex
+	etag       string
+	modifiedAt time.Time
+}
+
+// Track returns the ETag of data and the time the content changed to data
+func (t *ChangeTracker) Track(data []byte) (etag string, modifiedAt time.Time) {
+	etag = ETag(data)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if etag != t.etag {
+		// Last-Modified has a resolution of seconds
+		t.etag, t.modifiedAt = etag, time.Now().UTC().Truncate(time.Second)
+	}
+	return t.etag, t.modifiedAt
+}
+
+// ETag returns the strong entity tag of data
+func ETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ServeConfig writes a marshaled configuration with its Last-Modified and
+// ETag headers, answering conditional requests with 304 Not Modified
+func ServeConfig(w http.ResponseWriter, r *http.Request, contentType string, data []byte, etag string, modifiedAt time.Time) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", modifiedAt, bytes.NewReader(data))
+}
@@ -0,0 +1,40 @@
+package traefik
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeTracker(t *testing.T) {
+	var tracker ChangeTracker
+
+	etag, modifiedAt := tracker.Track([]byte(`{"http":{}}`))
+	sameETag, sameModifiedAt := tracker.Track([]byte(`{"http":{}}`))
+	assert.Equal(t, etag, sameETag)
+	assert.Equal(t, modifiedAt, sameModifiedAt)
+
+	changedETag, _ := tracker.Track([]byte(`{"http":{"routers":{}}}`))
+	assert.NotEqual(t, etag, changedETag)
+}
+
+func TestServeConfig(t *testing.T) {
+	var tracker ChangeTracker
+	data := []byte(`{"http":{}}`)
+	etag, modifiedAt := tracker.Track(data)
+
+	recorder := httptest.NewRecorder()
+	ServeConfig(recorder, httptest.NewRequest(http.MethodGet, "/api/config", nil), "application/json", data, etag, modifiedAt)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, etag, recorder.Header().Get("ETag"))
+	assert.Equal(t, modifiedAt.Format(http.TimeFormat), recorder.Header().Get("Last-Modified"))
+	assert.Equal(t, string(data), recorder.Body.String())
+
+	request := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+	request.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	ServeConfig(recorder, request, "application/json", data, etag, modifiedAt)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+}
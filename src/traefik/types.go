@@ -8,6 +8,16 @@ type RouteDefinition struct {
 	// Host specifies the domain for the route (e.g., "example.com")
 	Host string
 
+	// Hosts optionally lists further domains the route is reachable under
+	// (e.g., a legacy domain). Resources are named after Host only.
+	// Hosts may be wildcards (e.g., "*.hooks.example.com") matching any
+	// single subdomain, which is forwarded in a header.
+	Hosts []string
+
+	// SubdomainHeader names the header forwarding the subdomain matched by
+	// a wildcard host, "Subdomain" (sent as "X-Subdomain") by default
+	SubdomainHeader string
+
 	// Path defines the URL path pattern including parameters (e.g., "/api/v1/users/{userId}")
 	Path string
 
@@ -22,11 +32,68 @@ type RouteDefinition struct {
 	// EntryPoints lists Traefik entrypoints to use (e.g., ["web", "websecure"])
 	EntryPoints []string
 
+	// Audience optionally restricts the route to the Traefik instances
+	// pulling the config of that audience (e.g., "internal"), empty for all
+	Audience string
+
 	// Service defines the backend service configuration
 	Service ServiceDefinition
 
+	// ServicePath optionally replaces the request path before it is forwarded
+	// Example: "/webhook/3f2a..." exposes an n8n webhook under a friendly path
+	ServicePath string
+
+	// SetRequestHeaders are set on requests forwarded to the service, e.g.
+	// a static token or a tracing header
+	SetRequestHeaders map[string]string
+
+	// RemoveRequestHeaders are removed from requests forwarded to the service
+	RemoveRequestHeaders []string
+
+	// SetResponseHeaders are set on responses returned to the client
+	SetResponseHeaders map[string]string
+
 	// Authentication defines optional auth configuration (basic auth or API key)
 	Authentication *AuthConfig
+
+	// RateLimit optionally limits the requests per minute, routes with basic
+	// or digest auth are limited to 100 (burst 50) without
+	RateLimit *RateLimitConfig
+
+	// GeoIPCheckURL is an optional forwardAuth address rejecting requests
+	// from countries the route doesn't allow, checked before authentication
+	GeoIPCheckURL string
+
+	// ReplayCheckURL is an optional forwardAuth address rejecting replayed
+	// deliveries, checked after authentication
+	ReplayCheckURL string
+
+	// ReplayReleaseURL is called when the service answers a request that
+	// passed the replay check with a 5xx, so the failed delivery can be
+	// retried with the same key. Its response replaces the error body.
+	ReplayReleaseURL string
+
+	// QuotaCheckURL is an optional forwardAuth address rejecting requests
+	// beyond the daily or monthly quota, checked after the replay check
+	QuotaCheckURL string
+
+	// CaptureURL is an optional forwardAuth address storing the requests
+	// as forwarded to the service, for debugging
+	CaptureURL string
+
+	// ManagerToken is sent as ManagerTokenHeader to ReplayCheckURL,
+	// QuotaCheckURL and CaptureURL, which change state and only accept
+	// requests of Traefik. It's removed before the request reaches the
+	// service.
+	ManagerToken string
+
+	// Observability optionally enables or disables access logs, tracing and
+	// metrics for this route only, e.g. to silence health checks
+	Observability *Observability
+
+	// ErrorPages optionally serves branded error pages, e.g. when the n8n
+	// instance is down instead of Traefik's bare 502
+	ErrorPages *ErrorPagesConfig
 }
 
 // ServiceDefinition contains backend service configuration details
@@ -39,17 +106,48 @@ type ServiceDefinition struct {
 	Scheme string // http, https, or empty
 }
 
+// ErrorPagesConfig defines the error pages of a route
+type ErrorPagesConfig struct {
+	// Status lists the status codes or ranges to replace (e.g. ["502-504"])
+	Status []string `json:"status"`
+
+	// Service is the name of an existing Traefik service serving the pages
+	// (e.g. "error-pages@file")
+	Service string `json:"service,omitempty"`
+
+	// URL of the error page server, used if Service is empty. A service is
+	// created for it.
+	URL string `json:"url,omitempty"`
+
+	// Query is the path requested from the error service (e.g. "/{status}.json")
+	Query string `json:"query,omitempty"`
+}
+
+// RateLimitConfig defines the rate limit of a route
+type RateLimitConfig struct {
+	// Average is the number of requests allowed per minute
+	Average int
+
+	// Burst is the number of requests allowed at once
+	Burst int
+}
+
 // AuthConfig defines authentication configuration for a route
 type AuthConfig struct {
-	// Type specifies the authentication type ("basic" or "apikey")
+	// Type specifies the authentication type ("basic", "digest", "apikey" or "oidc")
 	Type string
 
-	// Username for basic authentication
+	// Username for basic and digest authentication
 	Username string
 
-	// Password for basic authentication
+	// Password for basic and digest authentication
 	Password string
 
 	// APIKey for API key authentication
 	APIKey string
+
+	// VerifyURL is the forwardAuth address verifying the bearer tokens of
+	// oidc authentication, including the required audience and scopes, or
+	// the payload signatures of hmac authentication
+	VerifyURL string
 }
@@ -0,0 +1,145 @@
+package traefik
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// hostProfile converts hosts to their lowercase ASCII form, rejecting
+// anything that isn't a valid DNS name
+var hostProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.VerifyDNSLength(true),
+	idna.StrictDomainName(true),
+)
+
+// headerName matches the header names routes may set
+var headerName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// headerNameError returns the error of an invalid header name
+func headerNameError(name string) FieldError {
+	return FieldError{Code: "invalid_header", Message: fmt.Sprintf("header name %q may only contain letters, digits and dashes", name)}
+}
+
+// FieldError describes why a field of a route definition is invalid
+type FieldError struct {
+	Code    string
+	Message string
+}
+
+// ValidationError maps the invalid fields of a route definition (e.g.
+// "host" or "path") to their errors
+type ValidationError map[string]FieldError
+
+func (e ValidationError) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = fmt.Sprintf("%s: %s", field, e[field].Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// NormalizeHost returns host in lowercase, with unicode domains converted
+// to punycode (e.g. "Bücher.example" becomes "xn--bcher-kva.example"). A
+// leading "*." label makes it a wildcard host.
+func NormalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.TrimSpace(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("host is required")
+	}
+	domain, wildcard := strings.CutPrefix(host, "*.")
+	if strings.ContainsAny(domain, ":/*") {
+		return "", fmt.Errorf("host %q must be a plain domain without scheme, port or path, wildcards are only allowed as first label", host)
+	}
+
+	ascii, err := hostProfile.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("invalid host %q", host)
+	}
+	if wildcard {
+		return "*." + ascii, nil
+	}
+	return ascii, nil
+}
+
+// Normalize validates rd and returns it with its host normalized, see
+// NormalizeHost. It returns a ValidationError listing all invalid fields.
+func (rd RouteDefinition) Normalize() (RouteDefinition, error) {
+	errs := ValidationError{}
+
+	host, err := NormalizeHost(rd.Host)
+	if err != nil {
+		errs["host"] = FieldError{Code: "invalid_host", Message: err.Error()}
+	}
+	rd.Host = host
+
+	// Further hosts are normalized alike, dropping duplicates of the others
+	var hosts []string
+	for _, alias := range rd.Hosts {
+		alias, err := NormalizeHost(alias)
+		if err != nil {
+			errs["hosts"] = FieldError{Code: "invalid_host", Message: err.Error()}
+			break
+		}
+		if alias != rd.Host && !slices.Contains(hosts, alias) {
+			hosts = append(hosts, alias)
+		}
+	}
+	rd.Hosts = hosts
+
+	if rd.SubdomainHeader != "" && !headerName.MatchString(rd.SubdomainHeader) {
+		errs["subdomainHeader"] = headerNameError(rd.SubdomainHeader)
+	}
+
+	for field, names := range map[string][]string{
+		"setRequestHeaders":    slices.Collect(maps.Keys(rd.SetRequestHeaders)),
+		"removeRequestHeaders": rd.RemoveRequestHeaders,
+		"setResponseHeaders":   slices.Collect(maps.Keys(rd.SetResponseHeaders)),
+	} {
+		for _, name := range names {
+			if !headerName.MatchString(name) {
+				errs[field] = headerNameError(name)
+				break
+			}
+		}
+	}
+	for field, headers := range map[string]map[string]string{
+		"setRequestHeaders":  rd.SetRequestHeaders,
+		"setResponseHeaders": rd.SetResponseHeaders,
+	} {
+		for name, value := range headers {
+			if value == "" || strings.ContainsAny(value, "\r\n") {
+				errs[field] = FieldError{Code: "invalid_header", Message: fmt.Sprintf("header %q needs a single line value", name)}
+				break
+			}
+		}
+	}
+
+	switch {
+	case rd.Path == "":
+		errs["path"] = FieldError{Code: "missing_path", Message: "path is required"}
+	case !strings.HasPrefix(rd.Path, "/"):
+		errs["path"] = FieldError{Code: "invalid_path", Message: fmt.Sprintf("path %q must start with /", rd.Path)}
+	}
+	if rd.ServicePath != "" && !strings.HasPrefix(rd.ServicePath, "/") {
+		errs["servicePath"] = FieldError{Code: "invalid_path", Message: fmt.Sprintf("service path %q must start with /", rd.ServicePath)}
+	}
+
+	if len(errs) > 0 {
+		return rd, errs
+	}
+	return rd, nil
+}
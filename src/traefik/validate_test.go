@@ -0,0 +1,88 @@
+package traefik
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+		wantErr  bool
+	}{
+		{"hooks.example.com", "hooks.example.com", false},
+		{"Hooks.Example.COM", "hooks.example.com", false},
+		{"hooks.example.com.", "hooks.example.com", false},
+		{"Bücher.example", "xn--bcher-kva.example", false},
+		{"10.0.0.1", "10.0.0.1", false},
+		{"", "", true},
+		{"https://hooks.example.com", "", true},
+		{"hooks.example.com:8080", "", true},
+		{"*.Hooks.example.com", "*.hooks.example.com", false},
+		{"*", "", true},
+		{"hooks.*.example.com", "", true},
+		{"hooks example.com", "", true},
+		{"hooks_example.com", "", true},
+		{"-hooks.example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			host, err := NormalizeHost(tt.host)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, host)
+		})
+	}
+}
+
+func TestRouteDefinitionNormalize(t *testing.T) {
+	route, err := RouteDefinition{Host: "Hooks.Example.com", Path: "/orders"}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, "hooks.example.com", route.Host)
+
+	route, err = RouteDefinition{
+		Host:  "hooks.example.com",
+		Hosts: []string{"Legacy.Example.com", "hooks.example.com", "legacy.example.com"},
+		Path:  "/orders",
+	}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legacy.example.com"}, route.Hosts)
+
+	_, err = RouteDefinition{Host: "hooks.example.com", Hosts: []string{"bad host"}, Path: "/orders"}.Normalize()
+	assert.ErrorContains(t, err, "hosts:")
+
+	_, err = RouteDefinition{Host: "bad host", Path: "orders", ServicePath: "webhook/x"}.Normalize()
+	var validationErr ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "invalid_host", validationErr["host"].Code)
+	assert.Equal(t, "invalid_path", validationErr["path"].Code)
+	assert.Equal(t, "invalid_path", validationErr["servicePath"].Code)
+
+	_, err = RouteDefinition{Host: "*.hooks.example.com", Path: "/orders", SubdomainHeader: "X Tenant"}.Normalize()
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "invalid_header", validationErr["subdomainHeader"].Code)
+
+	_, err = RouteDefinition{
+		Host:                 "hooks.example.com",
+		Path:                 "/orders",
+		SetRequestHeaders:    map[string]string{"X-Token": "line\nbreak"},
+		RemoveRequestHeaders: []string{"Bad Header"},
+		SetResponseHeaders:   map[string]string{"X-Served-By": "n8n"},
+	}.Normalize()
+	require.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, validationErr, "setRequestHeaders")
+	assert.Contains(t, validationErr, "removeRequestHeaders")
+	assert.NotContains(t, validationErr, "setResponseHeaders")
+
+	_, err = RouteDefinition{Host: "hooks.example.com"}.Normalize()
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "missing_path", validationErr["path"].Code)
+}
@@ -0,0 +1,148 @@
+package trash
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/audit"
+	"go.uber.org/zap"
+)
+
+// Item is a record in the trash
+type Item struct {
+	Collection string    `json:"collection"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Instance   string    `json:"instance,omitempty"`
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAt    time.Time `json:"purge_at"`
+}
+
+// itemOf describes a record in the trash
+func itemOf(record *core.Record) Item {
+	item := Item{
+		Collection: record.Collection().Name,
+		ID:         record.Id,
+		Instance:   record.GetString("instance"),
+		DeletedAt:  record.GetDateTime(Field).Time(),
+	}
+	item.PurgeAt = item.DeletedAt.AddDate(0, 0, RetentionDays())
+	switch item.Collection {
+	case "instances":
+		item.Name = record.GetString("host")
+	case "routes":
+		item.Name = record.GetString("host") + record.GetString("path")
+	case "workflows":
+		item.Name = record.GetString("workflow_name")
+	}
+	return item
+}
+
+// registerRoutes registers the endpoints listing and restoring the trash
+func registerRoutes(se *core.ServeEvent, logger *zap.Logger) {
+	se.Router.GET("/api/trash", listHandler).Bind(apis.RequireSuperuserAuth())
+	se.Router.POST("/api/trash/{collection}/{id}/restore", func(e *core.RequestEvent) error {
+		return restoreHandler(e, logger)
+	}).Bind(apis.RequireSuperuserAuth())
+}
+
+// deleteRequest moves the record of an API deletion to the trash, deleting
+// a record already in the trash purges it
+func deleteRequest(e *core.RecordRequestEvent, logger *zap.Logger) error {
+	purge := Deleted(e.Record)
+	if purge {
+		if err := e.Next(); err != nil {
+			return err
+		}
+	} else if err := Delete(e.App, e.Record); err != nil {
+		return apis.NewInternalServerError("Failed to move record to the trash", err)
+	}
+
+	item := itemOf(e.Record)
+	entry := audit.Entry{
+		Action:   "record.deleted",
+		Instance: item.Instance,
+		Actor:    audit.Actor(e.Auth),
+		Success:  true,
+		Message:  "Moved " + item.Name + " to the trash",
+		Details:  map[string]any{"collection": item.Collection, "id": item.ID},
+	}
+	if item.Collection == "instances" {
+		entry.Instance = item.ID
+	}
+	if purge {
+		entry.Action, entry.Message = "record.purged", "Purged "+item.Name+" from the trash"
+	} else {
+		entry.Details["purge_at"] = item.PurgeAt
+	}
+	if err := audit.Log(e.App, entry); err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err))
+	}
+
+	if purge {
+		return nil
+	}
+	return e.NoContent(http.StatusNoContent)
+}
+
+// listHandler lists the records in the trash, most recently deleted first
+func listHandler(e *core.RequestEvent) error {
+	items := []Item{}
+	for _, collection := range Collections {
+		records, err := e.App.FindAllRecords(collection, dbx.NewExp("deleted_at != ''"))
+		if err != nil {
+			return apis.NewInternalServerError("Failed to fetch trash", err)
+		}
+		for _, record := range records {
+			items = append(items, itemOf(record))
+		}
+	}
+	slices.SortFunc(items, func(a, b Item) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return e.JSON(http.StatusOK, items)
+}
+
+// restoreHandler takes a record out of the trash
+func restoreHandler(e *core.RequestEvent, logger *zap.Logger) error {
+	collection := e.Request.PathValue("collection")
+	if !slices.Contains(Collections, collection) {
+		return apis.NewNotFoundError("Unknown collection", nil)
+	}
+	record, err := e.App.FindRecordById(collection, e.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("Record not found", err)
+	}
+
+	item := itemOf(record)
+	err = Restore(e.App, record)
+	switch {
+	case errors.Is(err, ErrNotDeleted), errors.Is(err, ErrInstanceDeleted):
+		return apis.NewBadRequestError(err.Error(), nil)
+	case err != nil:
+		return apis.NewInternalServerError("Failed to restore record", err)
+	}
+
+	instance := item.Instance
+	if collection == "instances" {
+		instance = item.ID
+	}
+	err = audit.Log(e.App, audit.Entry{
+		Action:   "record.restored",
+		Instance: instance,
+		Actor:    audit.Actor(e.Auth),
+		Success:  true,
+		Message:  "Restored " + item.Name + " from the trash",
+		Details:  map[string]any{"collection": collection, "id": record.Id},
+	})
+	if err != nil {
+		logger.Error("Failed to write audit log", zap.Error(err))
+	}
+
+	return e.JSON(http.StatusOK, item)
+}
@@ -0,0 +1,186 @@
+// Package trash soft deletes instances, routes and workflows. Deleting one
+// of their records through the API moves it to the trash by setting its
+// deleted_at; deleting it again, or the retention period passing, purges
+// it. Routes and workflows of an instance move to the trash and are
+// restored with it, so a fat-fingered deletion doesn't lose their history.
+//
+// Records in the trash are hidden from the API rules and from the queries
+// of the manager, filter with NotDeleted and InstanceNotDeleted.
+package trash
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"go.uber.org/zap"
+)
+
+// Field is set while a record is in the trash
+const Field = "deleted_at"
+
+// PurgeJob is the id of the cron job purging records past the retention
+const PurgeJob = "purge-trash"
+
+// DefaultRetentionDays is how long records stay in the trash unless
+// TRASH_RETENTION_DAYS is set
+const DefaultRetentionDays = 30
+
+// Collections are the collections whose records are soft deleted
+var Collections = []string{"instances", "routes", "workflows"}
+
+// children are the collections moved to the trash with their instance
+var children = []string{"routes", "workflows"}
+
+// NotDeleted excludes records in the trash
+var NotDeleted = dbx.HashExp{Field: ""}
+
+// ErrNotDeleted is returned when restoring a record that isn't in the trash
+var ErrNotDeleted = errors.New("record is not in the trash")
+
+// ErrInstanceDeleted is returned when restoring a route or workflow of an
+// instance in the trash
+var ErrInstanceDeleted = errors.New("the instance of the record is in the trash, restore it first")
+
+// InstanceNotDeleted excludes records whose instance, referenced by column,
+// is in the trash
+func InstanceNotDeleted(column string) dbx.Expression {
+	return dbx.NewExp("[[" + column + "]] NOT IN (SELECT id FROM instances WHERE deleted_at != '')")
+}
+
+// WorkflowNotDeleted excludes records whose workflow, referenced by column,
+// is in the trash
+func WorkflowNotDeleted(column string) dbx.Expression {
+	return dbx.NewExp("[[" + column + "]] NOT IN (SELECT id FROM workflows WHERE deleted_at != '')")
+}
+
+// Deleted reports whether record is in the trash
+func Deleted(record *core.Record) bool {
+	return !record.GetDateTime(Field).IsZero()
+}
+
+// RetentionDays returns how many days records stay in the trash, configured
+// with TRASH_RETENTION_DAYS
+func RetentionDays() int {
+	if days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && days > 0 {
+		return days
+	}
+	return DefaultRetentionDays
+}
+
+// Delete moves record to the trash, the routes and workflows of an instance
+// with it
+func Delete(app core.App, record *core.Record) error {
+	now, err := types.ParseDateTime(time.Now())
+	if err != nil {
+		return err
+	}
+	return app.RunInTransaction(func(txApp core.App) error {
+		if record.Collection().Name == "instances" {
+			for _, collection := range children {
+				records, err := txApp.FindAllRecords(collection, dbx.HashExp{"instance": record.Id}, NotDeleted)
+				if err != nil {
+					return err
+				}
+				for _, child := range records {
+					child.Set(Field, now)
+					if err := txApp.SaveNoValidate(child); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		record.Set(Field, now)
+		return txApp.SaveNoValidate(record)
+	})
+}
+
+// Restore takes record out of the trash. Restoring an instance restores the
+// routes and workflows deleted with it, not the ones deleted before.
+func Restore(app core.App, record *core.Record) error {
+	if !Deleted(record) {
+		return ErrNotDeleted
+	}
+	if instanceID := record.GetString("instance"); instanceID != "" {
+		instance, err := app.FindRecordById("instances", instanceID)
+		if err != nil {
+			return err
+		}
+		if Deleted(instance) {
+			return ErrInstanceDeleted
+		}
+	}
+
+	deletedAt := record.GetDateTime(Field).String()
+	return app.RunInTransaction(func(txApp core.App) error {
+		if record.Collection().Name == "instances" {
+			for _, collection := range children {
+				records, err := txApp.FindAllRecords(collection, dbx.HashExp{"instance": record.Id, Field: deletedAt})
+				if err != nil {
+					return err
+				}
+				for _, child := range records {
+					child.Set(Field, nil)
+					if err := txApp.SaveNoValidate(child); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		record.Set(Field, nil)
+		return txApp.SaveNoValidate(record)
+	})
+}
+
+// purge deletes the records moved to the trash before, instances first so
+// their routes and workflows go with them
+func purge(app core.App, before time.Time) (int, error) {
+	purged := 0
+	for _, collection := range Collections {
+		records, err := app.FindAllRecords(collection,
+			dbx.NewExp("deleted_at != '' AND deleted_at < {:before}",
+				dbx.Params{"before": before.UTC().Format(types.DefaultDateLayout)}))
+		if err != nil {
+			return purged, err
+		}
+		for _, record := range records {
+			if err := app.Delete(record); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Init turns API deletions of instances, routes and workflows into soft
+// deletions, registers the trash endpoints and purges the records past the
+// retention once a day
+func Init(app core.App, logger *zap.Logger) {
+	app.OnRecordDeleteRequest(Collections...).BindFunc(func(e *core.RecordRequestEvent) error {
+		return deleteRequest(e, logger)
+	})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		registerRoutes(se, logger)
+		return se.Next()
+	})
+
+	app.Cron().MustAdd(PurgeJob, "15 4 * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", PurgeJob))
+
+		purged, err := purge(app, time.Now().AddDate(0, 0, -RetentionDays()))
+		if err != nil {
+			logger.Error("Failed to purge trash", zap.Error(err))
+			return
+		}
+		if purged > 0 {
+			logger.Info("Purged trash", zap.Int("records", purged))
+		}
+	})
+}
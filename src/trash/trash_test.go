@@ -0,0 +1,114 @@
+package trash
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemOf(t *testing.T) {
+	routes := core.NewBaseCollection("routes")
+	routes.Fields.Add(
+		&core.TextField{Name: "instance"},
+		&core.TextField{Name: "host"},
+		&core.TextField{Name: "path"},
+		&core.DateField{Name: Field},
+	)
+	record := core.NewRecord(routes)
+	record.Id = "route1"
+	record.Set("instance", "instance1")
+	record.Set("host", "hooks.example.com")
+	record.Set("path", "/orders")
+	assert.False(t, Deleted(record))
+
+	deletedAt := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	record.Set(Field, deletedAt)
+	assert.True(t, Deleted(record))
+
+	t.Setenv("TRASH_RETENTION_DAYS", "7")
+	assert.Equal(t, Item{
+		Collection: "routes",
+		ID:         "route1",
+		Name:       "hooks.example.com/orders",
+		Instance:   "instance1",
+		DeletedAt:  deletedAt,
+		PurgeAt:    deletedAt.AddDate(0, 0, 7),
+	}, itemOf(record))
+}
+
+func TestRetentionDays(t *testing.T) {
+	t.Setenv("TRASH_RETENTION_DAYS", "")
+	assert.Equal(t, DefaultRetentionDays, RetentionDays())
+	t.Setenv("TRASH_RETENTION_DAYS", "-1")
+	assert.Equal(t, DefaultRetentionDays, RetentionDays())
+	t.Setenv("TRASH_RETENTION_DAYS", "90")
+	assert.Equal(t, 90, RetentionDays())
+}
+
+// reload returns the stored state of record
+func reload(t *testing.T, app core.App, record *core.Record) *core.Record {
+	t.Helper()
+	stored, err := app.FindRecordById(record.Collection().Name, record.Id)
+	require.NoError(t, err)
+	return stored
+}
+
+func TestDeleteRestore(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	route := testutil.Create(t, app, "routes", map[string]any{"instance": instance.Id, "host": "hooks.example.com"})
+	workflow := testutil.Create(t, app, "workflows", map[string]any{"instance": instance.Id, "workflow_id": "wf1"})
+	deletedBefore := testutil.Create(t, app, "routes", map[string]any{"instance": instance.Id, "host": "old.example.com"})
+
+	require.NoError(t, Delete(app, deletedBefore))
+	time.Sleep(2 * time.Millisecond)
+	require.NoError(t, Delete(app, instance))
+
+	assert.True(t, Deleted(reload(t, app, instance)))
+	assert.True(t, Deleted(reload(t, app, route)))
+	assert.True(t, Deleted(reload(t, app, workflow)))
+
+	// A route can't come back without its instance
+	assert.ErrorIs(t, Restore(app, reload(t, app, route)), ErrInstanceDeleted)
+
+	require.NoError(t, Restore(app, reload(t, app, instance)))
+	assert.False(t, Deleted(reload(t, app, instance)))
+	assert.False(t, Deleted(reload(t, app, route)))
+	assert.False(t, Deleted(reload(t, app, workflow)))
+	// Deleted on its own before, it stays in the trash
+	assert.True(t, Deleted(reload(t, app, deletedBefore)))
+
+	assert.ErrorIs(t, Restore(app, reload(t, app, instance)), ErrNotDeleted)
+}
+
+func TestPurge(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	instance := testutil.Create(t, app, "instances", map[string]any{"host": "n8n.example.com"})
+	route := testutil.Create(t, app, "routes", map[string]any{"instance": instance.Id, "host": "hooks.example.com"})
+	kept := testutil.Create(t, app, "instances", map[string]any{"host": "kept.example.com"})
+	live := testutil.Create(t, app, "instances", map[string]any{"host": "live.example.com"})
+
+	require.NoError(t, Delete(app, instance))
+	time.Sleep(2 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	require.NoError(t, Delete(app, kept))
+
+	purged, err := purge(app, cutoff)
+	require.NoError(t, err)
+	// The route goes with its instance
+	assert.Equal(t, 1, purged)
+
+	_, err = app.FindRecordById("instances", instance.Id)
+	assert.Error(t, err)
+	_, err = app.FindRecordById("routes", route.Id)
+	assert.Error(t, err)
+	assert.True(t, Deleted(reload(t, app, kept)))
+	assert.False(t, Deleted(reload(t, app, live)))
+}
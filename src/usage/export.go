@@ -0,0 +1,134 @@
+package usage
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/environments"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+)
+
+// ExportPath is the endpoint exporting the usage reports of a date range
+const ExportPath = "/api/usage/export"
+
+// Report is an exported usage report
+type Report struct {
+	Day       string `json:"day"`
+	Route     string `json:"route"`
+	Host      string `json:"host"`
+	Path      string `json:"path"`
+	Subject   string `json:"subject"`
+	Requests  int    `json:"requests"`
+	BytesIn   int    `json:"bytes_in"`
+	BytesOut  int    `json:"bytes_out"`
+	Status2xx int    `json:"status_2xx"`
+	Status3xx int    `json:"status_3xx"`
+	Status4xx int    `json:"status_4xx"`
+	Status5xx int    `json:"status_5xx"`
+}
+
+// csvHeader are the columns of CSV exports, in the order of Report
+var csvHeader = []string{
+	"day", "route", "host", "path", "subject", "requests", "bytes_in", "bytes_out",
+	"status_2xx", "status_3xx", "status_4xx", "status_5xx",
+}
+
+// row returns r as a CSV row
+func (r Report) row() []string {
+	return []string{
+		r.Day, r.Route, r.Host, r.Path, r.Subject,
+		strconv.Itoa(r.Requests), strconv.Itoa(r.BytesIn), strconv.Itoa(r.BytesOut),
+		strconv.Itoa(r.Status2xx), strconv.Itoa(r.Status3xx), strconv.Itoa(r.Status4xx), strconv.Itoa(r.Status5xx),
+	}
+}
+
+// exportRange returns the days to export, from and to as passed or the
+// current month so far
+func exportRange(from, to string, now time.Time) (string, string, error) {
+	now = now.In(timezone.Global())
+	if from == "" {
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format(DayLayout)
+	}
+	if to == "" {
+		to = now.Format(DayLayout)
+	}
+	for _, day := range []string{from, to} {
+		if _, err := time.Parse(DayLayout, day); err != nil {
+			return "", "", err
+		}
+	}
+	return from, to, nil
+}
+
+// exportHandler returns the reports of the days from to to (both included,
+// the current month by default), optionally of one route or the routes of
+// one environment, as JSON or with format=csv as CSV
+func exportHandler(e *core.RequestEvent) error {
+	query := e.Request.URL.Query()
+	from, to, err := exportRange(query.Get("from"), query.Get("to"), time.Now())
+	if err != nil {
+		return apis.NewBadRequestError("from and to must be days like 2006-01-02", nil)
+	}
+
+	filter := "day >= {:from} && day <= {:to}"
+	params := dbx.Params{"from": from, "to": to}
+	if route := query.Get("route"); route != "" {
+		filter += " && route = {:route}"
+		params["route"] = route
+	}
+	if name := query.Get(environments.QueryParam); name != "" {
+		environment, err := environments.Find(e.App, name)
+		if err != nil {
+			return apis.NewBadRequestError("Unknown environment "+name, nil)
+		}
+		filter += " && route.instance.environment = {:environment}"
+		params["environment"] = environment.Id
+	}
+	records, err := e.App.FindRecordsByFilter(ReportsCollection, filter, "day,route,subject", 0, 0, params)
+	if err != nil {
+		return apis.NewBadRequestError("Failed to load usage reports", err)
+	}
+	if errs := e.App.ExpandRecords(records, []string{"route"}, nil); len(errs) > 0 {
+		return apis.NewBadRequestError("Failed to load routes of usage reports", nil)
+	}
+
+	reports := make([]Report, 0, len(records))
+	for _, record := range records {
+		report := Report{
+			Day:       record.GetString("day"),
+			Route:     record.GetString("route"),
+			Subject:   record.GetString("subject"),
+			Requests:  record.GetInt("requests"),
+			BytesIn:   record.GetInt("bytes_in"),
+			BytesOut:  record.GetInt("bytes_out"),
+			Status2xx: record.GetInt("status_2xx"),
+			Status3xx: record.GetInt("status_3xx"),
+			Status4xx: record.GetInt("status_4xx"),
+			Status5xx: record.GetInt("status_5xx"),
+		}
+		if route := record.ExpandedOne("route"); route != nil {
+			report.Host = route.GetString("host")
+			report.Path = route.GetString("path")
+		}
+		reports = append(reports, report)
+	}
+
+	if query.Get("format") != "csv" {
+		return e.JSON(http.StatusOK, reports)
+	}
+
+	e.Response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	e.Response.Header().Set("Content-Disposition", `attachment; filename="usage-`+from+`-`+to+`.csv"`)
+	w := csv.NewWriter(e.Response)
+	w.Write(csvHeader)
+	for _, report := range reports {
+		w.Write(report.row())
+	}
+	w.Flush()
+	return w.Error()
+}
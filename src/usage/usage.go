@@ -0,0 +1,232 @@
+// Package usage tracks the requests of gateway routes per client and
+// aggregates them daily into the usage_reports collection, which billing or
+// chargeback can export as CSV or JSON.
+//
+// Requests are counted in memory and written to the collection every
+// minute, a crash loses at most the last minute.
+package usage
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/sistemica/n8n-manager-backend/errorreport"
+	"github.com/sistemica/n8n-manager-backend/timezone"
+	"github.com/sistemica/n8n-manager-backend/traefik"
+	"go.uber.org/zap"
+)
+
+// ReportsCollection stores the daily usage per route and subject
+const ReportsCollection = "usage_reports"
+
+// FlushJob is the id of the cron job writing the counted requests
+const FlushJob = "flush-usage"
+
+// DayLayout is the format of the days of reports, in the timezone set by
+// TIMEZONE
+const DayLayout = "2006-01-02"
+
+// Subject returns who the request r of a route with authType is accounted
+// to, the identity Traefik verified: "sub:" and the subject of oidc tokens,
+// "user:" and the basic or digest user, or "ip:" and the client IP. Headers
+// like X-API-Key aren't verified, clients could account their requests to
+// others or to a new subject each.
+func Subject(r *http.Request, authType string) string {
+	switch authType {
+	case "oidc":
+		// Set by the oidc check, Traefik replaces any sent by the client
+		if subject := r.Header.Get(traefik.AuthSubjectHeader); subject != "" {
+			return "sub:" + subject
+		}
+	case "basic", "digest":
+		if user := authUser(r); user != "" {
+			return "user:" + user
+		}
+	}
+	return "ip:" + ForwardedIP(r)
+}
+
+// digestUsername matches the username of a digest Authorization header
+var digestUsername = regexp.MustCompile(`(?i)\busername="([^"]*)"`)
+
+// authUser returns the user of the basic or digest Authorization header of
+// r, Traefik verified it before the request reached the manager
+func authUser(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	if credentials, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Digest "); ok {
+		if match := digestUsername.FindStringSubmatch(credentials); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// ForwardedIP returns the client IP Traefik forwarded the request of. Only
+// the last X-Forwarded-For entry is added by Traefik itself.
+func ForwardedIP(r *http.Request) string {
+	if ip := r.Header.Get("X-Real-Ip"); ip != "" {
+		return ip
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		entries := strings.Split(forwarded, ",")
+		return strings.TrimSpace(entries[len(entries)-1])
+	}
+	return "unknown"
+}
+
+// counters are the requests of a subject on a route and day
+type counters struct {
+	requests  int
+	bytesIn   int64
+	bytesOut  int64
+	status2xx int
+	status3xx int
+	status4xx int
+	status5xx int
+}
+
+// add adds the counts of other to c
+func (c *counters) add(other *counters) {
+	c.requests += other.requests
+	c.bytesIn += other.bytesIn
+	c.bytesOut += other.bytesOut
+	c.status2xx += other.status2xx
+	c.status3xx += other.status3xx
+	c.status4xx += other.status4xx
+	c.status5xx += other.status5xx
+}
+
+// reportKey identifies a report
+type reportKey struct {
+	route   string
+	subject string
+	day     string
+}
+
+// tracker counts requests until they are flushed
+type tracker struct {
+	mu      sync.Mutex
+	pending map[reportKey]*counters
+}
+
+// requests are the counted requests of all routes not written yet
+var requests = &tracker{pending: map[reportKey]*counters{}}
+
+// Track counts a request of subject on the route with id routeId, answered
+// with status
+func Track(routeId, subject string, status int, bytesIn, bytesOut int64) {
+	requests.track(routeId, subject, status, bytesIn, bytesOut, time.Now())
+}
+
+func (t *tracker) track(routeId, subject string, status int, bytesIn, bytesOut int64, now time.Time) {
+	request := &counters{requests: 1, bytesIn: bytesIn, bytesOut: bytesOut}
+	switch {
+	case status >= 500:
+		request.status5xx = 1
+	case status >= 400:
+		request.status4xx = 1
+	case status >= 300:
+		request.status3xx = 1
+	default:
+		request.status2xx = 1
+	}
+	t.merge(map[reportKey]*counters{{routeId, subject, now.In(timezone.Global()).Format(DayLayout)}: request})
+}
+
+// merge adds counts to the pending ones
+func (t *tracker) merge(counts map[reportKey]*counters) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, c := range counts {
+		if pending, ok := t.pending[key]; ok {
+			pending.add(c)
+		} else {
+			t.pending[key] = c
+		}
+	}
+}
+
+// take returns the pending counts and resets them
+func (t *tracker) take() map[reportKey]*counters {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := t.pending
+	t.pending = map[reportKey]*counters{}
+	return counts
+}
+
+// flush adds the pending counts to the reports. Counts that can't be saved
+// are kept for the next flush, unless their route was deleted.
+func (t *tracker) flush(app core.App) error {
+	counts := t.take()
+	if len(counts) == 0 {
+		return nil
+	}
+	collection, err := app.FindCollectionByNameOrId(ReportsCollection)
+	if err != nil {
+		t.merge(counts)
+		return err
+	}
+
+	var lastErr error
+	for key, c := range counts {
+		report, err := app.FindFirstRecordByFilter(ReportsCollection,
+			"route = {:route} && subject = {:subject} && day = {:day}",
+			dbx.Params{"route": key.route, "subject": key.subject, "day": key.day})
+		if err != nil {
+			if _, err := app.FindRecordById("routes", key.route); err != nil {
+				continue
+			}
+			report = core.NewRecord(collection)
+			report.Set("route", key.route)
+			report.Set("subject", key.subject)
+			report.Set("day", key.day)
+		}
+		report.Set("requests", report.GetInt("requests")+c.requests)
+		report.Set("bytes_in", report.GetInt("bytes_in")+int(c.bytesIn))
+		report.Set("bytes_out", report.GetInt("bytes_out")+int(c.bytesOut))
+		report.Set("status_2xx", report.GetInt("status_2xx")+c.status2xx)
+		report.Set("status_3xx", report.GetInt("status_3xx")+c.status3xx)
+		report.Set("status_4xx", report.GetInt("status_4xx")+c.status4xx)
+		report.Set("status_5xx", report.GetInt("status_5xx")+c.status5xx)
+		if err := app.Save(report); err != nil {
+			t.merge(map[reportKey]*counters{key: c})
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Init registers the export endpoint and writes the counted requests every
+// minute and on shutdown
+func Init(app core.App, logger *zap.Logger) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET(ExportPath, exportHandler).Bind(apis.RequireSuperuserAuth())
+		return se.Next()
+	})
+
+	app.Cron().MustAdd(FlushJob, "* * * * *", func() {
+		defer errorreport.Recover(logger, zap.String("job", FlushJob))
+
+		if err := requests.flush(app); err != nil {
+			logger.Error("Failed to write usage reports", zap.Error(err))
+		}
+	})
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		if err := requests.flush(app); err != nil {
+			logger.Error("Failed to write usage reports", zap.Error(err))
+		}
+		return e.Next()
+	})
+}
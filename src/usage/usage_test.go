@@ -0,0 +1,84 @@
+package usage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubject(t *testing.T) {
+	request := func(header http.Header) *http.Request {
+		return &http.Request{Header: header}
+	}
+
+	oidc := request(http.Header{"X-Auth-Subject": {"client-42"}, "X-Real-Ip": {"203.0.113.7"}})
+	assert.Equal(t, "sub:client-42", Subject(oidc, "oidc"))
+	assert.Equal(t, "ip:203.0.113.7", Subject(oidc, ""), "the subject is only verified on oidc routes")
+
+	basic := request(http.Header{})
+	basic.SetBasicAuth("partner", "secret")
+	assert.Equal(t, "user:partner", Subject(basic, "basic"))
+	digest := request(http.Header{"Authorization": {`Digest username="partner", realm="n8n", nonce="abc", response="def"`}})
+	assert.Equal(t, "user:partner", Subject(digest, "digest"))
+
+	assert.Equal(t, "ip:203.0.113.7", Subject(request(http.Header{"X-Real-Ip": {"203.0.113.7"}}), "apikey"))
+	assert.Equal(t, "ip:198.51.100.2", Subject(request(http.Header{"X-Forwarded-For": {"10.0.0.1, 198.51.100.2"}}), ""), "the entry added by Traefik is used")
+	assert.Equal(t, "ip:unknown", Subject(request(http.Header{}), ""))
+}
+
+func TestSubjectIgnoresAPIKey(t *testing.T) {
+	// Traefik sets the single token of apikey routes, clients of other
+	// routes any key they like
+	for _, authType := range []string{"", "apikey", "oidc", "basic"} {
+		plain := &http.Request{Header: http.Header{"X-Auth-Subject": {"client-42"}, "X-Real-Ip": {"203.0.113.7"}}}
+		plain.SetBasicAuth("partner", "secret")
+		spoofed := plain.Clone(context.Background())
+		spoofed.Header.Set("X-API-Key", "someone-elses-key")
+
+		assert.Equal(t, Subject(plain, authType), Subject(spoofed, authType), authType)
+		assert.NotContains(t, Subject(spoofed, authType), "key:", authType)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tracker := &tracker{pending: map[reportKey]*counters{}}
+	day := time.Date(2025, time.April, 14, 23, 59, 0, 0, time.UTC)
+
+	tracker.track("r1", "key:a", 200, 100, 20, day)
+	tracker.track("r1", "key:a", 404, 50, 10, day)
+	tracker.track("r1", "key:a", 502, 10, 0, day)
+	tracker.track("r1", "key:b", 301, 0, 0, day)
+	tracker.track("r1", "key:a", 202, 1, 1, day.Add(time.Minute))
+
+	counts := tracker.take()
+	require.Len(t, counts, 3)
+	assert.Equal(t, &counters{requests: 3, bytesIn: 160, bytesOut: 30, status2xx: 1, status4xx: 1, status5xx: 1}, counts[reportKey{"r1", "key:a", "2025-04-14"}])
+	assert.Equal(t, &counters{requests: 1, status3xx: 1}, counts[reportKey{"r1", "key:b", "2025-04-14"}])
+	assert.Equal(t, &counters{requests: 1, bytesIn: 1, bytesOut: 1, status2xx: 1}, counts[reportKey{"r1", "key:a", "2025-04-15"}], "days are UTC")
+	assert.Empty(t, tracker.take(), "taken counts are reset")
+
+	tracker.merge(counts)
+	tracker.track("r1", "key:b", 200, 0, 0, day)
+	assert.Equal(t, 2, tracker.take()[reportKey{"r1", "key:b", "2025-04-14"}].requests, "counts that failed to save are merged")
+}
+
+func TestExportRange(t *testing.T) {
+	now := time.Date(2025, time.April, 14, 12, 0, 0, 0, time.UTC)
+
+	from, to, err := exportRange("", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-04-01", from)
+	assert.Equal(t, "2025-04-14", to)
+
+	from, to, err = exportRange("2025-03-01", "2025-03-31", now)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-03-01", from)
+	assert.Equal(t, "2025-03-31", to)
+
+	_, _, err = exportRange("March", "", now)
+	assert.Error(t, err)
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -42,44 +45,55 @@ type StripPrefix struct {
 	Prefixes []string `json:"prefixes"`
 }
 
-func main() {
-	http.HandleFunc("/api/config", func(w http.ResponseWriter, r *http.Request) {
-		config := DynamicConfig{}
-		config.HTTP.Routers = make(map[string]Router)
-		config.HTTP.Services = make(map[string]Service)
-		config.HTTP.Middleware = make(map[string]Middleware)
-
-		// Configure httpbin router
-		config.HTTP.Routers["httpbin"] = Router{
-			EntryPoints: []string{"web"},
-			Service:     "httpbin-service",
-			Rule:        "PathPrefix(`/httpbin`)",
-			Middleware:  []string{"httpbin-strip"},
-		}
-
-		// Configure httpbin service pointing to our Docker container
-		config.HTTP.Services["httpbin-service"] = Service{
-			LoadBalancer: &LoadBalancer{
-				Servers: []Server{
-					{URL: "http://httpbin:80"},
-				},
-			},
-		}
+// buildConfig returns the static demo configuration
+func buildConfig() DynamicConfig {
+	config := DynamicConfig{}
+	config.HTTP.Routers = make(map[string]Router)
+	config.HTTP.Services = make(map[string]Service)
+	config.HTTP.Middleware = make(map[string]Middleware)
+
+	// Configure httpbin router
+	config.HTTP.Routers["httpbin"] = Router{
+		EntryPoints: []string{"web"},
+		Service:     "httpbin-service",
+		Rule:        "PathPrefix(`/httpbin`)",
+		Middleware:  []string{"httpbin-strip"},
+	}
 
-		// Configure strip prefix middleware
-		config.HTTP.Middleware["httpbin-strip"] = Middleware{
-			StripPrefix: &StripPrefix{
-				Prefixes: []string{"/httpbin"},
+	// Configure httpbin service pointing to our Docker container
+	config.HTTP.Services["httpbin-service"] = Service{
+		LoadBalancer: &LoadBalancer{
+			Servers: []Server{
+				{URL: "http://httpbin:80"},
 			},
-		}
+		},
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+	// Configure strip prefix middleware
+	config.HTTP.Middleware["httpbin-strip"] = Middleware{
+		StripPrefix: &StripPrefix{
+			Prefixes: []string{"/httpbin"},
+		},
+	}
 
-		if err := json.NewEncoder(w).Encode(config); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+	return config
+}
+
+func main() {
+	data, err := json.Marshal(buildConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The configuration never changes, so it was last modified at startup
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	modifiedAt := time.Now().UTC().Truncate(time.Second)
+
+	http.HandleFunc("/api/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "", modifiedAt, bytes.NewReader(data))
 	})
 
 	log.Println("Starting config server on :9000")